@@ -0,0 +1,32 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcs
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachlabs-field/blobcheck/cmd/s3"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// Add the command.
+func Add(env *env.Env, parent *cobra.Command) {
+	cmd := s3.Command(env, "gcs", "Performs a validation test for a Google Cloud Storage bucket", blob.GCSFromEnv)
+	cmd.PreRun = func(*cobra.Command, []string) {
+		env.GCS = true
+	}
+	parent.AddCommand(cmd)
+}
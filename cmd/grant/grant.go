@@ -0,0 +1,75 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grant
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/prompt"
+	"github.com/cockroachlabs-field/blobcheck/internal/tunnel"
+	"github.com/cockroachlabs-field/blobcheck/internal/validate"
+)
+
+func command(env *env.Env) *cobra.Command {
+	var user string
+	var execute bool
+	cmd := &cobra.Command{
+		Use:   "grant",
+		Short: "Prints (or executes) the statements that give a dedicated user the privileges needed by blobcheck",
+		// The command only talks to the database.
+		Annotations: map[string]string{"storage": "none"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if user == "" {
+				return errors.New("the user cannot be blank")
+			}
+			stmts := db.Grants(db.Ident(user), env.Database)
+			for _, stmt := range stmts {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s;\n", stmt)
+			}
+			if !execute {
+				return nil
+			}
+			question := fmt.Sprintf("execute the statements above to grant privileges to %s?", user)
+			if !env.AssumeYes && !prompt.Confirm(cmd.InOrStdin(), cmd.OutOrStdout(), question) {
+				fmt.Fprintln(cmd.OutOrStdout(), "aborted")
+				return nil
+			}
+			ctx := stopper.WithContext(cmd.Context())
+			if err := tunnel.Open(ctx, env); err != nil {
+				return err
+			}
+			if err := validate.Grant(ctx, env, stmts); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "privileges granted to %s\n", user)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&user, "user", "blobcheck_svc", "name of the dedicated user running the validation")
+	cmd.Flags().BoolVar(&execute, "execute", false, "execute the statements, after asking for confirmation")
+	return cmd
+}
+
+// Add the command.
+func Add(env *env.Env, parent *cobra.Command) {
+	cmd := command(env)
+	parent.AddCommand(cmd)
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prune
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/format"
+	"github.com/cockroachlabs-field/blobcheck/internal/prompt"
+	"github.com/cockroachlabs-field/blobcheck/internal/tunnel"
+)
+
+func command(env *env.Env) *cobra.Command {
+	var keep int
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Deletes older backup collections created by blobcheck from the destination",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if keep < 0 {
+				return errors.New("the number of runs to keep cannot be negative")
+			}
+			ctx := stopper.WithContext(cmd.Context())
+			if err := tunnel.Open(ctx, env); err != nil {
+				return err
+			}
+			store, err := blob.Open(ctx, env)
+			if err != nil {
+				return err
+			}
+			objects, err := store.List(ctx)
+			if err != nil {
+				return err
+			}
+			runs := blob.Runs(objects)
+			format.Runs(cmd.OutOrStdout(), runs, keep)
+			if len(runs) <= keep {
+				fmt.Fprintln(cmd.OutOrStdout(), "nothing to prune")
+				return nil
+			}
+			question := fmt.Sprintf("delete %d blobcheck runs?", len(runs)-keep)
+			if !env.AssumeYes && !prompt.Confirm(cmd.InOrStdin(), cmd.OutOrStdout(), question) {
+				fmt.Fprintln(cmd.OutOrStdout(), "aborted")
+				return nil
+			}
+			res, err := blob.Prune(ctx, store, runs[keep:])
+			if res != nil {
+				format.Pruned(cmd.OutOrStdout(), res)
+			}
+			return err
+		},
+	}
+	cmd.Flags().IntVar(&keep, "keep-latest", 1, "number of most recent blobcheck runs to keep")
+	return cmd
+}
+
+// Add the command.
+func Add(env *env.Env, parent *cobra.Command) {
+	cmd := command(env)
+	parent.AddCommand(cmd)
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/format"
+	"github.com/cockroachlabs-field/blobcheck/internal/validate"
+)
+
+func command(_ *env.Env) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay <recording>",
+		Short: "Replays the selection of the storage parameters against a recording taken with s3 --record",
+		Args:  cobra.ExactArgs(1),
+		// The recording replaces the destination.
+		Annotations: map[string]string{"storage": "none"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return errors.Wrap(err, "failed to open recording")
+			}
+			defer f.Close()
+			params, err := blob.Replay(f)
+			if err != nil {
+				return err
+			}
+			format.Report(cmd.OutOrStdout(), &validate.Report{
+				SuggestedParams: params,
+			})
+			return nil
+		},
+	}
+	return cmd
+}
+
+// Add the command.
+func Add(env *env.Env, parent *cobra.Command) {
+	cmd := command(env)
+	parent.AddCommand(cmd)
+}
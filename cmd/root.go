@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -23,10 +24,27 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/cockroachlabs-field/blobcheck/cmd/gcs"
+	"github.com/cockroachlabs-field/blobcheck/cmd/grant"
+	"github.com/cockroachlabs-field/blobcheck/cmd/http"
+	"github.com/cockroachlabs-field/blobcheck/cmd/list"
+	"github.com/cockroachlabs-field/blobcheck/cmd/minio"
+	"github.com/cockroachlabs-field/blobcheck/cmd/nodelocal"
+	"github.com/cockroachlabs-field/blobcheck/cmd/prune"
+	"github.com/cockroachlabs-field/blobcheck/cmd/replay"
 	"github.com/cockroachlabs-field/blobcheck/cmd/s3"
+	"github.com/cockroachlabs-field/blobcheck/cmd/tls"
+	"github.com/cockroachlabs-field/blobcheck/cmd/userfile"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
 	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/validate"
 )
 
+// exitTransient is the exit code used when the validation failed because of
+// a transient error, so that automation knows that re-running it is
+// worthwhile (EX_TEMPFAIL).
+const exitTransient = 75
+
 var verbosity int
 var envConfig = &env.Env{
 	DatabaseURL: "postgresql://root@localhost:26257?sslmode=disable",
@@ -41,22 +59,30 @@ var rootCmd = &cobra.Command{
 and integration with CockroachDB backup/restore workflows. 
 It verifies that the storage provider is correctly configured, 
 runs synthetic workloads, and produces network performance statistics.`,
-	PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
 		if envConfig.DatabaseURL == "" && !envConfig.Guess {
 			return errors.New("database URL cannot be blank")
 		}
-		if envConfig.URI != "" {
-			if envConfig.Endpoint != "" || envConfig.Path != "" {
-				return errors.New("URI and (endpoint + path) cannot be set simultaneously")
-			}
-		} else {
-			if envConfig.Endpoint == "" {
-				return errors.New("set (endpoint + path) or URI")
+		// Commands that do not access the destination are annotated.
+		if cmd.Annotations["storage"] != "none" {
+			if err := checkDestination(); err != nil {
+				return err
 			}
-			if envConfig.Path == "" {
-				return errors.New("set (endpoint + path) or URI")
+		}
+		if envConfig.CertsDir != "" {
+			for _, dbURL := range []*string{&envConfig.DatabaseURL, &envConfig.DRClusterURL, &envConfig.RestoreCheckURL} {
+				if *dbURL == "" {
+					continue
+				}
+				var err error
+				if *dbURL, err = db.WithCertsDir(*dbURL, envConfig.CertsDir); err != nil {
+					return err
+				}
 			}
 		}
+		if envConfig.Interactive {
+			envConfig.Stdin, envConfig.Stdout = bufio.NewReader(cmd.InOrStdin()), cmd.OutOrStdout()
+		}
 		if verbosity > 0 {
 			slog.SetLogLoggerLevel(slog.LevelDebug)
 		}
@@ -67,25 +93,211 @@ runs synthetic workloads, and produces network performance statistics.`,
 	},
 }
 
+// checkDestination verifies that the destination is set either as a URI or
+// as an endpoint and path.
+func checkDestination() error {
+	if envConfig.URI != "" {
+		if envConfig.Endpoint != "" || envConfig.Path != "" {
+			return errors.New("URI and (endpoint + path) cannot be set simultaneously")
+		}
+		return nil
+	}
+	if envConfig.Endpoint == "" || envConfig.Path == "" {
+		return errors.New("set (endpoint + path) or URI")
+	}
+	return nil
+}
+
 // Execute runs the root command.
 func Execute() {
+	gcs.Add(envConfig, rootCmd)
+	grant.Add(envConfig, rootCmd)
+	http.Add(envConfig, rootCmd)
+	list.Add(envConfig, rootCmd)
+	minio.Add(envConfig, rootCmd)
+	nodelocal.Add(envConfig, rootCmd)
+	prune.Add(envConfig, rootCmd)
+	replay.Add(envConfig, rootCmd)
 	s3.Add(envConfig, rootCmd)
+	tls.Add(envConfig, rootCmd)
+	userfile.Add(envConfig, rootCmd)
 	f := rootCmd.PersistentFlags()
+	f.StringVar(&envConfig.ApplyConn, "apply", "",
+		"after a successful validation, create (or replace) the named external connection with the validated URL")
+	f.BoolVar(&envConfig.AssumeYes, "yes", false, "do not ask for confirmation before modifying the cluster or the destination")
+	f.StringVar(&envConfig.CertsDir, "certs-dir", "",
+		"directory with ca.crt, client.<user>.crt and client.<user>.key used to authenticate with the database, the --dr-cluster and the --restore-check-version clusters")
+	f.StringVar(&envConfig.ChaosListen, "chaos-listen", "",
+		"address (e.g. 0.0.0.0:9100) of a local proxy that injects faults between the cluster and the storage provider")
+	f.StringVar(&envConfig.ChaosAdvertise, "chaos-advertise", "",
+		"endpoint used by the cluster to reach the fault injection proxy (default: http://<chaos-listen>)")
+	f.DurationVar(&envConfig.ChaosLatency, "chaos-latency", 0, "latency added by the proxy to every storage request")
+	f.StringVar(&envConfig.ChaosBandwidth, "chaos-bandwidth", "",
+		"transfer rate cap applied by the proxy to every storage request (e.g. 10MiB/s)")
+	f.StringVar(&envConfig.ExternalIODir, "external-io-dir", "",
+		"local path of the external IO directory of the node addressed by a nodelocal:// URI (e.g. /mnt/data1/extern)")
+	f.Float64Var(&envConfig.ChaosErrorRate, "chaos-error-rate", 0,
+		"fraction of storage requests failed by the proxy with a SlowDown error")
+	f.BoolVar(&envConfig.CheckEgress, "check-egress", false,
+		"report whether the storage is reached over a private endpoint or the public internet, and the source addresses of the nodes")
+	f.StringVar(&envConfig.IncrementalLocation, "incremental-location", "",
+		"sub-prefix of the destination (e.g. incrementals) storing the incremental backup, passed as incremental_location")
+	f.StringVar(&envConfig.CACert, "ca-cert", "",
+		"PEM bundle of the CA certificates of the storage endpoint, tried before skipping the verification")
 	f.StringVar(&envConfig.DatabaseURL, "db", envConfig.DatabaseURL, "PostgreSQL connection URL")
+	f.StringVar(&envConfig.Database, "database", "",
+		"existing database where the test tables are created (default: a new _blobcheck database)")
+	f.StringVar(&envConfig.DRClusterURL, "dr-cluster", "",
+		"connection URL of a second cluster: run a disaster recovery drill restoring into it and report RPO/RTO timings")
+	f.IntVar(&envConfig.ObjectCount, "object-count", 0,
+		"number of tiny objects created under a prefix to measure how the listing time grows, e.g. 20000 (0 to disable)")
+	f.BoolVar(&envConfig.ProbePagination, "probe-pagination", false,
+		"create more than 1000 tiny objects under a prefix to verify the list pagination and delimiters of the provider")
+	f.StringVar(&envConfig.VirtualHostDomain, "virtual-host-domain", "",
+		"domain serving the buckets as sub-domains (e.g. s3.corp.example.com), probed with virtual-hosted requests")
+	f.StringVar(&envConfig.Redact, "redact", validate.RedactSecrets,
+		"redaction policy of the report: secrets, or full to also mask the access key ID and the endpoint host names")
+	f.StringVar(&envConfig.RedactArtifact, "redact-artifact", "blobcheck-report.txt",
+		"with --redact full, local file (readable only by the operator) receiving the report without full redaction")
+	f.StringVar(&envConfig.SnippetsDir, "snippets-dir", "",
+		"after a successful run, write Terraform and Kubernetes snippets of the validated configuration to the directory")
+	f.StringVar(&envConfig.RestoreCheckURL, "restore-check-version", "",
+		"connection URL of a second cluster (e.g. running a different version) to restore the backup into")
+	f.StringVar(&envConfig.Proxy, "proxy", "",
+		"HTTP proxy (http://host:port) of the requests to the storage (default: HTTPS_PROXY or HTTP_PROXY)")
+	f.StringVar(&envConfig.NoProxy, "no-proxy", "",
+		"comma-separated hosts reached without the proxy (default: NO_PROXY)")
+	f.StringVar(&envConfig.SOCKS5Proxy, "socks5", "",
+		"address (host:port) of a SOCKS5 proxy used to reach the database and the storage provider")
+	f.StringVar(&envConfig.SSHHost, "ssh", "",
+		"SSH jump host ([user@]host[:port]) used to tunnel the connections to the database and the storage provider")
+	f.StringVar(&envConfig.SSHKey, "ssh-key", "",
+		"private key used to authenticate with the SSH jump host (default: keys of the running SSH agent)")
+	f.StringVar(&envConfig.Schema, "schema", "", "schema where the test tables are created (default: public)")
+	f.StringVar(&envConfig.OnBackupStartCmd, "on-backup-start-cmd", "",
+		"shell command run once the full backup job is running (e.g. a script restarting a node); the backup must still complete")
+	f.StringVar(&envConfig.SchemaChange, "schema-change", "",
+		"online schema change run on the source table during the full backup: add-column or add-index")
+	f.BoolVar(&envConfig.Stream, "stream", false,
+		"render each section of the report as soon as its data is final, instead of once the run completes")
+	f.BoolVar(&envConfig.Strict, "strict", false,
+		"never try the candidate configurations skipping the TLS verification, and fail if no other configuration works")
+	f.BoolVar(&envConfig.StrictChecksum, "strict-checksum", false,
+		"like --strict, and also never try the candidate configurations skipping the checksum validation")
+	f.BoolVar(&envConfig.StrictTLS, "strict-tls", false,
+		"fail the run if the connections to the database or the storage do not meet the TLS policy")
+	f.StringVar(&envConfig.Tenant, "tenant", "", "virtual cluster (tenant) to connect to on multi-tenant clusters")
+	f.BoolVar(&envConfig.OfflineAudit, "offline-audit", false,
+		"block and report any connection to hosts other than the configured database and storage endpoints")
+	f.BoolVar(&envConfig.TLSFIPS, "tls-fips", false, "require FIPS approved TLS cipher suites")
+	f.StringVar(&envConfig.TLSMinVersion, "tls-min-version", "1.2",
+		"minimum TLS version required for the connections to the database and the storage")
 	f.StringVar(&envConfig.Path, "path", envConfig.Path, "destination path (e.g. bucket/folder)")
-	f.StringVar(&envConfig.Endpoint, "endpoint", envConfig.Path, "http endpoint")
+	f.StringVar(&envConfig.Endpoint, "endpoint", envConfig.Path, "http endpoint, or a comma separated list of endpoints serving the same bucket, to check failover between them")
+	f.StringVar(&envConfig.EndpointPrefix, "endpoint-prefix", "",
+		"path prefix (e.g. /s3proxy) of a gateway serving the S3 API, added to the endpoint of the SDK and of the suggested URL")
 	f.StringVar(&envConfig.URI, "uri", envConfig.URI, "S3 URI")
+	f.DurationVar(&envConfig.DialTimeout, "dial-timeout", 30*time.Second,
+		"time to establish a connection to the storage provider (0 for no timeout)")
+	f.Int64Var(&envConfig.MultipartPartSize, "multipart-part-size", 5<<20,
+		"size in bytes of the first part uploaded by the multipart probe, followed by a small last part (0 for a single part)")
+	f.StringVar(&envConfig.DestID, "dest-id", "",
+		"sub-path of the destination written by the run (default a random UUID)")
+	f.BoolVar(&envConfig.Force, "force", false,
+		"proceed when the sub-path of the run already has objects, leaving them in place, instead of failing")
+	f.StringVar(&envConfig.ProbeKey, "probe-key", "",
+		"base name of the objects written by the storage probes, recognizable by security scanners (default _blobcheck)")
+	f.StringVar(&envConfig.ProbeMarker, "probe-marker", "",
+		"content of the objects written by the storage probes (default dummy_data)")
+	f.Int64Var(&envConfig.ProbeSize, "probe-size", 0,
+		"size in bytes of the objects written by the S3 probe, e.g. 16777216, to measure the transfer throughput (0 for the marker only)")
+	f.IntVar(&envConfig.ProbeCount, "probe-count", 1,
+		"number of objects written by the S3 probe of each candidate configuration")
+	f.StringVar(&envConfig.ProbeTag, "probe-tag", "",
+		"purpose attached as x-amz-meta-blobcheck-purpose metadata to the objects written by the S3 probes (optional)")
+	f.DurationVar(&envConfig.DeleteVisibilityWindow, "delete-visibility-window", 10*time.Second,
+		"time allowed for the object deleted by the probe to disappear from listings (0 to skip the check)")
+	f.DurationVar(&envConfig.TLSHandshakeTimeout, "tls-handshake-timeout", 10*time.Second,
+		"time to complete the TLS handshake with the storage provider (0 for no timeout)")
+	f.DurationVar(&envConfig.ResponseHeaderTimeout, "response-header-timeout", 0,
+		"time to receive the response headers of a storage request once it is sent (0 for no timeout)")
+	f.DurationVar(&envConfig.ProbeTimeout, "probe-timeout", 0,
+		"time to complete the probe of a candidate configuration, retries included (0 for no timeout)")
+	f.IntVar(&envConfig.ProbeRetries, "probe-retries", 0,
+		"number of times a request of the S3 probes is retried after a transient failure, such as throttling (0 for a single attempt)")
+	f.DurationVar(&envConfig.ProbeBackoff, "probe-backoff", 0,
+		"maximum delay, with exponential backoff and jitter, before a request of the S3 probes is retried (0 for the default of the SDK, 20s)")
+	f.DurationVar(&envConfig.TCPKeepAlive, "tcp-keepalive", 30*time.Second,
+		"interval between TCP keepalive probes on the connections to the storage provider (negative to disable)")
 	f.BoolVar(&envConfig.Guess, "guess", false, `perform a short test to guess suggested parameters:
 it only require access to the bucket; 
 it does not try to run a full backup/restore cycle 
 in the CockroachDB cluster.`)
+	f.BoolVar(&envConfig.RankCandidates, "rank-candidates", false,
+		"probe every candidate configuration and report the working ones ranked by security and latency")
+	f.BoolVar(&envConfig.Interactive, "interactive", false,
+		"show the parameters of each candidate configuration and ask whether to probe it, skip it or stop probing")
+	f.DurationVar(&envConfig.SessionTokenDuration, "session-token-duration", 0,
+		"exchange the access keys for temporary credentials valid for the duration (15m to 36h) with STS GetSessionToken, used by the probes and the external connection (0 to use the keys)")
+	f.BoolVar(&envConfig.GapAnalysis, "gap-analysis", false,
+		"check every candidate configuration from the nodes with CHECK EXTERNAL CONNECTION and report those that work only locally or only from the cluster; requires --rank-candidates")
+	f.StringVar(&envConfig.Dataset, "dataset", "",
+		"CSV file used to populate the source table instead of synthetic data (one or two fields: [key,]value)")
+	f.Int64Var(&envConfig.DatasetMaxBytes, "dataset-max-bytes", 1<<30, "maximum number of bytes loaded from the dataset")
+	f.StringArrayVar(&envConfig.Variants, "variant", nil,
+		"parameter overrides (e.g. AWS_USE_PATH_STYLE=false) for an additional external connection checked with CHECK EXTERNAL CONNECTION and compared with the suggested parameters (repeatable)")
+	f.StringVar(&envConfig.WorkloadLocality, "workload-locality", "",
+		"locality filter (e.g. region=us-east1) of the nodes running the workload; requires --execution-locality on other nodes")
+	f.StringVar(&envConfig.ExecutionLocality, "execution-locality", "",
+		"locality filter (e.g. region=us-west1) of the nodes running the backups (EXECUTION LOCALITY)")
 	f.CountVarP(&verbosity, "verbosity", "v", "increase logging verbosity to debug")
 	f.IntVar(&envConfig.Workers, "workers", 5, "number of concurrent workers")
 	f.DurationVar(&envConfig.WorkloadDuration, "workload-duration", 5*time.Second, "duration of the workload")
+	f.Int64Var(&envConfig.MinRows, "min-rows", 0,
+		"run the initial workload until the source table has this many rows, instead of for --workload-duration")
+	f.Int64Var(&envConfig.MinBytes, "min-bytes", 0,
+		"run the initial workload until the source table has this many bytes, instead of for --workload-duration")
+	f.DurationVar(&envConfig.WorkloadMaxDuration, "workload-max-duration", 30*time.Minute,
+		"maximum duration of the initial workload growing the source table to --min-rows or --min-bytes")
+	f.BoolVar(&envConfig.PauseWorkload, "pause-workload", false,
+		"keep the workload running after the full backup and pause it during the incremental backup, "+
+			"to check that the incremental layer has exactly the rows written between the backups")
+	f.Float64Var(&envConfig.OracleSample, "oracle-sample", 1,
+		"fraction of the rows written by the workload whose restored values are verified (0 to disable)")
+	f.StringVar(&envConfig.BackupScope, "backup-scope", "table",
+		"objects backed up: table, or database to also restore the table out of a backup of the whole test database")
+	f.StringArrayVar(&envConfig.MetricsURLs, "metrics-url", nil,
+		"base URL of the DB Console of a node (e.g. https://node1:8080) whose /_status/vars metrics are scraped during the full backup (repeatable)")
+	f.Float64Var(&envConfig.MinFreeSpace, "min-free-space", 0.1,
+		"minimum fraction of free space left on every store, and in the MinIO bucket quota, once the data of the validation is written (0 to disable)")
+	f.DurationVar(&envConfig.GCTTL, "gc-ttl", 0,
+		"set a short GC TTL on the source table and validate revision history backups across the GC boundary (0 to disable)")
+	f.DurationVar(&envConfig.BackupWindow, "backup-window", 0,
+		"time available to complete a full backup: report whether a full backup of --data-size fits in it (0 to disable)")
+	f.StringVar(&envConfig.DataSize, "data-size", "",
+		"size of the data to back up (e.g. 500GiB), used to scale the estimates (default: size of the test table)")
+	f.Float64Var(&envConfig.StoragePrice, "storage-price", 0,
+		"storage price per GB-month, used to estimate the monthly cost of the backup schedule (0 to disable)")
+	f.Float64Var(&envConfig.EgressPrice, "egress-price", 0,
+		"price per GB read from the storage provider, used to estimate the monthly egress cost of restores and reads (0 to disable)")
+	f.StringVar(&envConfig.ReadVolume, "read-volume", "",
+		"bytes read from the storage every month by restores and reads (e.g. 2TiB), used with --egress-price (default: one restore of a full backup and its incrementals)")
+	f.DurationVar(&envConfig.FullBackupInterval, "full-backup-interval", 24*time.Hour,
+		"interval between full backups in the backup schedule")
+	f.DurationVar(&envConfig.IncrementalInterval, "incremental-interval", time.Hour,
+		"interval between incremental backups in the backup schedule")
+	f.DurationVar(&envConfig.Retention, "retention", 30*24*time.Hour, "retention of the backups in the backup schedule")
+	f.IntVar(&envConfig.Retries, "retries", 0,
+		"number of times the validation is torn down and re-run after a transient failure")
+	f.DurationVar(&envConfig.HeartbeatInterval, "heartbeat", 10*time.Second,
+		"interval between progress messages during backup and restore (0 to disable)")
 	err := rootCmd.Execute()
 
 	if err != nil {
 		fmt.Println(err)
+		if validate.IsTransient(err) {
+			os.Exit(exitTransient)
+		}
 		os.Exit(1)
 	}
 }
@@ -15,24 +15,44 @@
 package s3
 
 import (
+	"bytes"
+	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/audit"
 	"github.com/cockroachlabs-field/blobcheck/internal/blob"
 	"github.com/cockroachlabs-field/blobcheck/internal/env"
 	"github.com/cockroachlabs-field/blobcheck/internal/format"
+	"github.com/cockroachlabs-field/blobcheck/internal/prompt"
+	"github.com/cockroachlabs-field/blobcheck/internal/tunnel"
 	"github.com/cockroachlabs-field/blobcheck/internal/validate"
 )
 
-func command(env *env.Env) *cobra.Command {
+// retryDelay is the time to wait before re-running a validation that failed
+// with a transient error.
+const retryDelay = 5 * time.Second
+
+// Opener connects to the destination of the validation, verifying that the
+// storage is usable.
+type Opener func(ctx *stopper.Context, env *env.Env) (blob.Storage, error)
+
+// Command returns a command that validates the destination opened by open.
+func Command(env *env.Env, use, short string, open Opener) *cobra.Command {
+	var record string
 	cmd := &cobra.Command{
-		Use:   "s3",
-		Short: "Performs a validation test for a s3 object store",
+		Use:   use,
+		Short: short,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Parent context for cleanup operations
 			parentCtx := stopper.WithContext(cmd.Context())
@@ -50,38 +70,259 @@ func command(env *env.Env) *cobra.Command {
 				ctx.Stop(0)
 			}()
 
-			store, err := blob.S3FromEnv(ctx, env)
-			if err != nil {
-				return err
+			if record != "" {
+				f, err := os.Create(record)
+				if err != nil {
+					return errors.Wrap(err, "failed to create recording")
+				}
+				defer f.Close()
+				env.Recording = f
 			}
-			if env.Guess {
-				format.Report(cmd.OutOrStdout(), &validate.Report{
-					SuggestedParams: store.Params(),
-				})
-				return nil
-			}
-			validator, err := validate.New(ctx, env, store)
-			if err != nil {
+			// The tunnel outlives the validation, so that cleanup can use it.
+			if err := tunnel.Open(parentCtx, env); err != nil {
 				return err
 			}
-			// Use parent context for cleanup so it can access the database
-			defer validator.Clean(parentCtx)
-
-			report, err := validator.Validate(ctx)
-			if err != nil {
-				return err
+			var auditor *audit.Auditor
+			if env.OfflineAudit {
+				hosts, err := audit.AllowedHosts(env)
+				if err != nil {
+					return err
+				}
+				slog.Info("offline audit enabled", slog.Any("allowed", hosts))
+				auditor = audit.New(hosts)
+				env.Dial = auditor.Wrap(env.Dial)
 			}
-			if report != nil {
-				format.Report(cmd.OutOrStdout(), report)
+			for attempt := 1; ; attempt++ {
+				err := run(ctx, parentCtx, cmd, env, open, auditor)
+				if err == nil || attempt > env.Retries || !validate.IsTransient(err) {
+					return err
+				}
+				slog.Warn("transient failure, retrying the validation",
+					slog.Int("attempt", attempt), slog.Int("retries", env.Retries), slog.Any("error", err))
+				select {
+				case <-time.After(retryDelay):
+				case <-ctx.Stopping():
+					return err
+				}
 			}
-			return nil
 		},
 	}
+	cmd.Flags().StringVar(&record, "record", "",
+		"file where the storage operations and their outcomes are recorded, for use with blobcheck replay")
 	return cmd
 }
 
+// run performs a single validation, tearing down the resources it created
+// before returning. Cleanup uses parentCtx, so that it can access the
+// database after ctx is stopped. If auditor is not nil, its attestation is
+// added to the report.
+func run(
+	ctx, parentCtx *stopper.Context, cmd *cobra.Command, env *env.Env, open Opener, auditor *audit.Auditor,
+) error {
+	probe := blob.ProbeObjectFromEnv(env)
+	if err := probe.Validate(); err != nil {
+		return err
+	}
+	if err := blob.ValidateDestID(env.DestID); err != nil {
+		return err
+	}
+	if err := validate.CheckRedactPolicy(env.Redact); err != nil {
+		return err
+	}
+	store, err := open(ctx, env)
+	var permErr *blob.PermissionError
+	var diagErr *blob.DiagnosisError
+	if hasPerm, hasDiag := errors.As(err, &permErr), errors.As(err, &diagErr); hasPerm || hasDiag {
+		report := &validate.Report{}
+		if hasPerm {
+			report.Permissions = permErr.Permissions
+		}
+		if hasDiag {
+			report.Diagnoses = diagErr.Diagnoses
+		}
+		if auditErr := attest(cmd, env, report, auditor, nil); auditErr != nil {
+			slog.Error("audit failed", slog.Any("error", auditErr))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if env.Guess {
+		capabilities, err := store.Capabilities(ctx)
+		if err != nil {
+			return err
+		}
+		keyChecks, err := validate.ProbeKeys(ctx, store)
+		if err != nil {
+			return err
+		}
+		archival, err := validate.ProbeArchival(ctx, store)
+		if err != nil {
+			return err
+		}
+		failover, err := validate.ProbeEndpoints(ctx, store)
+		if err != nil {
+			return err
+		}
+		immutability, err := validate.CheckImmutability(ctx, store, env.Retention)
+		if err != nil {
+			return err
+		}
+		bucket, err := validate.InventoryBucket(ctx, store)
+		if err != nil {
+			return err
+		}
+		minio, err := validate.CheckMinIO(ctx, store)
+		if err != nil {
+			return err
+		}
+		listing, err := validate.ProbeListing(ctx, env, store)
+		if err != nil {
+			return err
+		}
+		pagination, err := validate.ProbePagination(ctx, env, store)
+		if err != nil {
+			return err
+		}
+		virtualHost, err := validate.ProbeVirtualHost(ctx, env, store)
+		if err != nil {
+			return err
+		}
+		report := &validate.Report{
+			SuggestedParams: store.Params(),
+			SuggestedURL:    blob.ObfuscateURL(store.RootURL()),
+			ProvidedParams:  store.ProvidedParams(),
+			Credentials:     validate.CheckCredentials(store),
+			ProbeLatency:    store.Latency(),
+			Candidates:      store.Candidates(),
+			Limitations:     blob.Limitations(store.BucketName()),
+			Probe:           &probe,
+			CustomCA:        store.CustomCA(),
+			Proxy:           validate.CheckProxy(store),
+			Capabilities:    capabilities,
+			KeyChecks:       keyChecks,
+			Listing:         listing,
+			Pagination:      pagination,
+			VirtualHost:     virtualHost,
+			Archival:        archival,
+			Failover:        failover,
+			Immutability:    immutability,
+			Bucket:          bucket,
+			MinIO:           minio,
+		}
+		return attest(cmd, env, report, auditor, nil)
+	}
+	validator, err := validate.New(ctx, env, store)
+	if err != nil {
+		return err
+	}
+	defer validator.Close()
+
+	var stream *format.Stream
+	if env.Stream {
+		stream = format.NewStream(cmd.OutOrStdout())
+		validator.OnProgress(func(report *validate.Report) {
+			stream.Update(report.Redact(env.Redact))
+		})
+	}
+	report, err := validator.Validate(ctx)
+	// Clean up before rendering the report, so that it tells whether the
+	// cleanup was verified. Use parent context for cleanup so it can access
+	// the database.
+	cleanup, cleanErr := validator.Clean(parentCtx)
+	if cleanErr != nil {
+		slog.Error("cleanup failed", slog.Any("error", cleanErr))
+	}
+	if report != nil {
+		report.Cleanup = cleanup
+		if auditErr := attest(cmd, env, report, auditor, stream); err == nil {
+			err = auditErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if env.ApplyConn != "" {
+		question := fmt.Sprintf("create external connection %q with the validated URL?", env.ApplyConn)
+		if !env.AssumeYes && !prompt.Confirm(cmd.InOrStdin(), cmd.OutOrStdout(), question) {
+			slog.Info("external connection not applied")
+			return nil
+		}
+		return validator.Apply(ctx, env.ApplyConn)
+	}
+	return nil
+}
+
+// attest adds the attestation of the auditor, if any, to the report and
+// renders it, completing the stream if it is not nil. It returns an error if
+// connections outside of the configured endpoints were attempted.
+func attest(
+	cmd *cobra.Command, env *env.Env, report *validate.Report, auditor *audit.Auditor, stream *format.Stream,
+) error {
+	if auditor != nil {
+		report.Audit = auditor.Attestation()
+	}
+	if err := render(cmd, env, report, stream); err != nil {
+		return err
+	}
+	if err := writeSnippets(env, report); err != nil {
+		return err
+	}
+	if report.Audit != nil && !report.Audit.Passed() {
+		return errors.New("offline audit failed: connections outside of the configured endpoints were attempted")
+	}
+	return nil
+}
+
+// render writes the report, redacted according to the policy. Under full
+// redaction, the report with the default policy is kept in a local file
+// readable only by the operator. If stream is not nil, only the sections it
+// has not rendered yet are written to the output; the local report is always
+// complete.
+func render(cmd *cobra.Command, env *env.Env, report *validate.Report, stream *format.Stream) error {
+	if env.Redact == validate.RedactFull && env.RedactArtifact != "" {
+		f, err := os.OpenFile(env.RedactArtifact, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return errors.Wrap(err, "failed to create the local report")
+		}
+		defer f.Close()
+		format.Report(f, report)
+		slog.Info("local report written", slog.String("path", env.RedactArtifact))
+	}
+	if stream != nil {
+		stream.Finish(report.Redact(env.Redact))
+		return nil
+	}
+	format.Report(cmd.OutOrStdout(), report.Redact(env.Redact))
+	return nil
+}
+
+// writeSnippets writes the deployment snippets of the suggested URL in the
+// directory of --snippets-dir, after a successful run. The secrets are left
+// as variables or placeholders, as in the report.
+func writeSnippets(env *env.Env, report *validate.Report) error {
+	if env.SnippetsDir == "" || report.Failure != nil || report.SuggestedURL == "" {
+		return nil
+	}
+	if err := os.MkdirAll(env.SnippetsDir, 0700); err != nil {
+		return errors.Wrap(err, "failed to create the snippets directory")
+	}
+	for _, name := range slices.Sorted(maps.Keys(format.Snippets)) {
+		var b bytes.Buffer
+		if err := format.Snippets[name](&b, report.SuggestedURL); err != nil {
+			return errors.Wrapf(err, "failed to render %s", name)
+		}
+		path := filepath.Join(env.SnippetsDir, name)
+		if err := os.WriteFile(path, b.Bytes(), 0600); err != nil {
+			return errors.Wrapf(err, "failed to write %s", name)
+		}
+		slog.Info("deployment snippet written", slog.String("path", path))
+	}
+	return nil
+}
+
 // Add the command.
 func Add(env *env.Env, parent *cobra.Command) {
-	cmd := command(env)
+	cmd := Command(env, "s3", "Performs a validation test for a s3 object store", blob.S3FromEnv)
 	parent.AddCommand(cmd)
 }
@@ -0,0 +1,54 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/format"
+	"github.com/cockroachlabs-field/blobcheck/internal/tunnel"
+	"github.com/cockroachlabs-field/blobcheck/internal/validate"
+)
+
+func command(env *env.Env) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tls <endpoint>",
+		Short: "Inspects the TLS connection and the certificates of a storage endpoint",
+		Args:  cobra.ExactArgs(1),
+		// The command only talks to the endpoint.
+		Annotations: map[string]string{"storage": "none"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := stopper.WithContext(cmd.Context())
+			if err := tunnel.Open(ctx, env); err != nil {
+				return err
+			}
+			res, err := validate.InspectTLS(ctx, env, args[0])
+			if err != nil {
+				return err
+			}
+			format.TLS(cmd.OutOrStdout(), []*validate.TLSResult{res})
+			return nil
+		},
+	}
+	return cmd
+}
+
+// Add the command.
+func Add(env *env.Env, parent *cobra.Command) {
+	cmd := command(env)
+	parent.AddCommand(cmd)
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit verifies that blobcheck only connects to the database and
+// storage endpoints it is configured with, as required to run it in
+// air-gapped and regulated environments.
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// awsDomain is allowed when no explicit storage endpoint is configured, so
+// that the SDK can reach the S3 and STS endpoints of the region.
+const awsDomain = "amazonaws.com"
+
+// googleDomain is allowed for Google Cloud Storage destinations, so that
+// blobcheck can reach the JSON API and the OAuth2, STS and IAM credentials
+// endpoints issuing its access tokens, even with a custom API endpoint.
+const googleDomain = "googleapis.com"
+
+// ErrBlocked is returned when a connection to a host that is not
+// configured is attempted.
+var ErrBlocked = errors.New("connection blocked by the offline audit")
+
+// Connection is a destination blobcheck attempted to connect to.
+type Connection struct {
+	Network string
+	Address string
+	Count   int  // number of connection attempts
+	Allowed bool // the destination is a configured endpoint
+}
+
+// Attestation is the outcome of the audit.
+type Attestation struct {
+	Allowed     []string     // hosts blobcheck is allowed to connect to
+	Connections []Connection // destinations, in order of first connection
+}
+
+// Passed returns whether no connection to a host outside of the configured
+// endpoints was attempted.
+func (a *Attestation) Passed() bool {
+	for _, c := range a.Connections {
+		if !c.Allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// Auditor records the outgoing connections, and blocks those to hosts that
+// are not configured.
+type Auditor struct {
+	allowed []string
+	mu      struct {
+		sync.Mutex
+		conns []*Connection
+	}
+}
+
+// New returns an auditor that allows connections to the given hosts and to
+// their sub-domains (e.g. virtual hosted buckets).
+func New(allowed []string) *Auditor {
+	return &Auditor{allowed: allowed}
+}
+
+// Wrap returns a dial function that records the connections and blocks those
+// to hosts that are not allowed, before any name is resolved. If dial is
+// nil, connections are established directly.
+func (a *Auditor) Wrap(dial env.DialFunc) env.DialFunc {
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		allowed := a.allows(host)
+		a.record(network, addr, allowed)
+		if !allowed {
+			slog.Error("connection blocked by the offline audit", slog.String("addr", addr))
+			return nil, errors.Wrapf(ErrBlocked, "%s", addr)
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+// allows returns whether the host is allowed.
+func (a *Auditor) allows(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range a.allowed {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// record counts a connection attempt.
+func (a *Auditor) record(network, addr string, allowed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, c := range a.mu.conns {
+		if c.Network == network && c.Address == addr {
+			c.Count++
+			return
+		}
+	}
+	a.mu.conns = append(a.mu.conns, &Connection{Network: network, Address: addr, Count: 1, Allowed: allowed})
+}
+
+// Attestation returns the connections recorded so far.
+func (a *Auditor) Attestation() *Attestation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	res := &Attestation{Allowed: slices.Clone(a.allowed)}
+	for _, c := range a.mu.conns {
+		res.Connections = append(res.Connections, *c)
+	}
+	return res
+}
+
+// AllowedHosts returns the hosts of the databases, of the storage endpoint
+// and of the DB Console URLs configured in the environment.
+func AllowedHosts(env *env.Env) ([]string, error) {
+	var hosts []string
+	for _, dbURL := range []string{env.DatabaseURL, env.DRClusterURL, env.RestoreCheckURL} {
+		if dbURL == "" {
+			continue
+		}
+		config, err := pgconn.ParseConfig(dbURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse database URL")
+		}
+		hosts = append(hosts, config.Host)
+		for _, fallback := range config.Fallbacks {
+			hosts = append(hosts, fallback.Host)
+		}
+	}
+	endpoints := blob.SplitEndpoints(env.Endpoint)
+	gcs := env.GCS || strings.HasPrefix(env.URI, "gs://")
+	switch {
+	case gcs:
+		// The JSON API is reached at the custom endpoint, if any.
+		hosts = append(hosts, googleDomain)
+		if env.URI != "" {
+			endpoints = nil
+		}
+	case blob.IsHTTP(env.URI):
+		// File servers are reached at the host of the URL.
+		endpoints = []string{blob.HTTPEndpoint(env.URI)}
+	case env.URI != "" && !blob.IsClusterLocal(env.URI):
+		_, params, err := blob.ParseURI(env.URI)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = []string{params[blob.EndPointParam]}
+	}
+	switch {
+	case blob.IsClusterLocal(env.URI):
+		// The cluster stores the objects itself.
+	case gcs && len(endpoints) == 0:
+	case len(endpoints) == 0 || endpoints[0] == "":
+		hosts = append(hosts, awsDomain)
+	default:
+		for _, endpoint := range endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || u.Host == "" {
+				return nil, errors.Newf("invalid endpoint %q", endpoint)
+			}
+			hosts = append(hosts, u.Hostname())
+		}
+	}
+	for _, metricsURL := range env.MetricsURLs {
+		u, err := url.Parse(metricsURL)
+		if err != nil || u.Host == "" {
+			return nil, errors.Newf("invalid metrics URL %q", metricsURL)
+		}
+		hosts = append(hosts, u.Hostname())
+	}
+	for i, h := range hosts {
+		hosts[i] = strings.ToLower(h)
+	}
+	slices.Sort(hosts)
+	return slices.Compact(hosts), nil
+}
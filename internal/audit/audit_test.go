@@ -0,0 +1,91 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestAllowedHosts(t *testing.T) {
+	hosts, err := AllowedHosts(&env.Env{
+		DatabaseURL:     "postgresql://root@db1.internal:26257,db2.internal:26257/defaultdb?sslmode=disable",
+		RestoreCheckURL: "postgresql://root@DR.internal:26257?sslmode=disable",
+		Endpoint:        "https://minio.internal:9000,https://minio2.internal:9000",
+		MetricsURLs:     []string{"https://db1.internal:8080", "https://db3.internal:8080"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db1.internal", "db2.internal", "db3.internal", "dr.internal",
+		"minio.internal", "minio2.internal"}, hosts)
+
+	hosts, err = AllowedHosts(&env.Env{
+		DatabaseURL: "postgresql://root@localhost:26257?sslmode=disable",
+		URI:         "s3://bucket/path?AWS_REGION=us-east-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{awsDomain, "localhost"}, hosts)
+
+	hosts, err = AllowedHosts(&env.Env{
+		DatabaseURL: "postgresql://root@localhost:26257?sslmode=disable",
+		URI:         "gs://bucket/path?AUTH=implicit",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{googleDomain, "localhost"}, hosts)
+
+	// The gcs command with a custom endpoint still fetches the tokens from Google.
+	hosts, err = AllowedHosts(&env.Env{
+		DatabaseURL: "postgresql://root@localhost:26257?sslmode=disable",
+		Endpoint:    "http://gcs-emulator.internal:4443",
+		Path:        "bucket/path",
+		GCS:         true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gcs-emulator.internal", googleDomain, "localhost"}, hosts)
+}
+
+func TestAuditor(t *testing.T) {
+	a := assert.New(t)
+	auditor := New([]string{"localhost", "minio.internal"})
+	var dialed []string
+	dial := auditor.Wrap(func(_ context.Context, _, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return nil, nil
+	})
+	ctx := context.Background()
+	for _, addr := range []string{"localhost:26257", "localhost:26257", "bucket.minio.internal:9000"} {
+		_, err := dial(ctx, "tcp", addr)
+		a.NoError(err)
+	}
+	_, err := dial(ctx, "tcp", "169.254.169.254:80")
+	a.ErrorIs(err, ErrBlocked)
+	_, err = dial(ctx, "tcp", "evilminio.internal:9000")
+	a.ErrorIs(err, ErrBlocked)
+
+	a.Equal([]string{"localhost:26257", "localhost:26257", "bucket.minio.internal:9000"}, dialed)
+	attestation := auditor.Attestation()
+	a.False(attestation.Passed())
+	a.Equal([]Connection{
+		{Network: "tcp", Address: "localhost:26257", Count: 2, Allowed: true},
+		{Network: "tcp", Address: "bucket.minio.internal:9000", Count: 1, Allowed: true},
+		{Network: "tcp", Address: "169.254.169.254:80", Count: 1},
+		{Network: "tcp", Address: "evilminio.internal:9000", Count: 1},
+	}, attestation.Connections)
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/cockroachdb/errors"
+)
+
+// archivalClasses are the storage classes whose objects cannot be read
+// until they are restored: BACKUP and RESTORE fail, or wait for the
+// retrieval, when they read them.
+var archivalClasses = []string{
+	string(types.StorageClassGlacier),
+	string(types.StorageClassDeepArchive),
+}
+
+// ArchivalRule is a lifecycle rule that transitions the objects written to
+// the destination to an archival storage class.
+type ArchivalRule struct {
+	ID    string
+	Class string
+	Days  int32
+}
+
+// Archival is the outcome of checking whether the objects written to the
+// destination can end up in an archival storage class.
+type Archival struct {
+	Rules        []ArchivalRule // lifecycle rules moving the destination to archival classes
+	StorageClass string         // storage class of a newly written object
+	ForcedRead   string         // outcome of reading an object forced into an archival class
+	Err          string         // why the lifecycle configuration could not be read, if it could not
+}
+
+// Archived reports whether the backups may end up in an archival storage
+// class.
+func (a *Archival) Archived() bool {
+	return len(a.Rules) > 0 || slices.Contains(archivalClasses, a.StorageClass)
+}
+
+// ProbeArchival implements ArchivalProber. It reads the lifecycle rules of
+// the bucket, writes a probe object with the configured storage class to
+// learn the class assigned to new objects, and forces the object into the
+// GLACIER class to verify that reading it is rejected until it is restored.
+func (s *s3Store) ProbeArchival(ctx context.Context) (*Archival, error) {
+	if s.client == nil {
+		return nil, errors.New("storage is not connected")
+	}
+	res := &Archival{}
+	res.Rules, res.Err = s.archivalRules(ctx)
+
+	name := s.objects.name("archival")
+	key := aws.String(path.Join(s.keyPrefix(), name))
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(s.BucketName()),
+		Key:                  key,
+		Body:                 strings.NewReader(s.objects.content()),
+		Metadata:             s.objects.metadata(),
+		ServerSideEncryption: serverSideEncryption(s.params),
+		SSEKMSKeyId:          kmsKeyID(s.params),
+		StorageClass:         types.StorageClass(s.params[StorageClassParam]),
+		RequestPayer:         s.requestPayer(),
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to put object")
+	}
+	defer func() {
+		if err := s.deleteObject(ctx, name); err != nil {
+			slog.Warn("failed to delete archival probe object", slog.Any("error", err))
+		}
+	}()
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(s.BucketName()),
+		Key:          key,
+		RequestPayer: s.requestPayer(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read object metadata")
+	}
+	// Objects in the STANDARD class do not report it.
+	res.StorageClass = string(head.StorageClass)
+	if res.StorageClass == "" {
+		res.StorageClass = string(types.StorageClassStandard)
+	}
+	res.ForcedRead = s.forcedArchivalRead(ctx, name)
+	if res.Archived() {
+		slog.Warn("backups may transition to an archival storage class: "+
+			"restores fail or are delayed until the objects are retrieved",
+			slog.String("class", res.StorageClass), slog.Int("lifecycle_rules", len(res.Rules)))
+	}
+	return res, nil
+}
+
+// archivalRules returns the enabled lifecycle rules of the bucket that
+// transition objects under the destination to an archival class. If the
+// rules cannot be read, the reason is returned instead.
+func (s *s3Store) archivalRules(ctx context.Context) ([]ArchivalRule, string) {
+	rules, err := s.lifecycleRules(ctx)
+	if err != "" {
+		return nil, err
+	}
+	var res []ArchivalRule
+	for _, rule := range rules {
+		for _, t := range rule.Transitions {
+			if slices.Contains(archivalClasses, string(t.StorageClass)) {
+				res = append(res, ArchivalRule{
+					ID:    aws.ToString(rule.ID),
+					Class: string(t.StorageClass),
+					Days:  aws.ToInt32(t.Days),
+				})
+			}
+		}
+	}
+	return res, ""
+}
+
+// rulePrefix returns the key prefix filtered by a lifecycle rule.
+func rulePrefix(rule types.LifecycleRule) string {
+	if f := rule.Filter; f != nil {
+		if f.And != nil {
+			return aws.ToString(f.And.Prefix)
+		}
+		return aws.ToString(f.Prefix)
+	}
+	//lint:ignore SA1019 buckets configured before filters were introduced use the rule prefix.
+	return aws.ToString(rule.Prefix)
+}
+
+// coversDest reports whether a rule with the given prefix applies to some
+// of the objects written under the destination.
+func (s *s3Store) coversDest(prefix string) bool {
+	dest := s.keyPrefix() + "/"
+	return strings.HasPrefix(dest, prefix) || strings.HasPrefix(prefix, dest)
+}
+
+// forcedArchivalRead copies the probe object onto itself in the GLACIER
+// class, as a lifecycle transition would, and reads it back. Reading an
+// archived object must fail until it is restored; providers that accept
+// the class but serve the object have no archival tier.
+func (s *s3Store) forcedArchivalRead(ctx context.Context, name string) string {
+	key := path.Join(s.keyPrefix(), name)
+	if _, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:               aws.String(s.BucketName()),
+		Key:                  aws.String(key),
+		CopySource:           aws.String(s.BucketName() + "/" + strings.ReplaceAll(url.PathEscape(key), "%2F", "/")),
+		StorageClass:         types.StorageClassGlacier,
+		MetadataDirective:    types.MetadataDirectiveCopy,
+		ServerSideEncryption: serverSideEncryption(s.params),
+		SSEKMSKeyId:          kmsKeyID(s.params),
+		RequestPayer:         s.requestPayer(),
+	}); err != nil {
+		return fmt.Sprintf("transition not possible: %v", err)
+	}
+	_, err := s.Get(ctx, name)
+	var archived *types.InvalidObjectState
+	switch {
+	case errors.As(err, &archived):
+		return "rejected until the object is restored"
+	case err != nil:
+		return fmt.Sprintf("read failed: %v", err)
+	default:
+		return "readable: the archival class is not enforced"
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lifecycle transitions the objects under backups/ to GLACIER, and all the
+// objects to DEEP_ARCHIVE once disabled.
+const lifecycle = `<LifecycleConfiguration>
+<Rule><ID>archive-backups</ID><Status>Enabled</Status><Filter><Prefix>backups/</Prefix></Filter>
+<Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>
+<Rule><ID>disabled</ID><Status>Disabled</Status><Filter><Prefix></Prefix></Filter>
+<Transition><Days>1</Days><StorageClass>DEEP_ARCHIVE</StorageClass></Transition></Rule>
+<Rule><ID>logs</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter>
+<Transition><Days>1</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>
+</LifecycleConfiguration>`
+
+func TestProbeArchival(t *testing.T) {
+	tests := []struct {
+		name         string
+		fake         *fakeS3
+		class        string
+		wantRules    []ArchivalRule
+		wantClass    string
+		wantRead     string
+		wantArchived bool
+	}{
+		{
+			name:      "no lifecycle",
+			fake:      &fakeS3{archival: true},
+			wantClass: "STANDARD",
+			wantRead:  "rejected until the object is restored",
+		},
+		{
+			name:         "lifecycle",
+			fake:         &fakeS3{archival: true, lifecycle: lifecycle},
+			wantRules:    []ArchivalRule{{ID: "archive-backups", Class: "GLACIER", Days: 30}},
+			wantClass:    "STANDARD",
+			wantRead:     "rejected until the object is restored",
+			wantArchived: true,
+		},
+		{
+			name:         "storage class",
+			fake:         &fakeS3{},
+			class:        "DEEP_ARCHIVE",
+			wantClass:    "DEEP_ARCHIVE",
+			wantRead:     "readable: the archival class is not enforced",
+			wantArchived: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			_, alt := newFakeS3Store(t, tt.fake, func(s *s3Store) {
+				s.dest, s.root = "bucket/backups/run", "bucket/backups"
+				if tt.class != "" {
+					s.params[StorageClassParam] = tt.class
+				}
+			})
+
+			got, err := alt.ProbeArchival(context.Background())
+			r.NoError(err)
+			assert.Equal(t, tt.wantRules, got.Rules)
+			assert.Equal(t, tt.wantClass, got.StorageClass)
+			assert.Equal(t, tt.wantRead, got.ForcedRead)
+			assert.Empty(t, got.Err)
+			assert.Equal(t, tt.wantArchived, got.Archived())
+			objects, err := alt.List(context.Background())
+			r.NoError(err)
+			assert.Empty(t, objects)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Versioning states of a bucket.
+const (
+	VersioningEnabled   = string(types.BucketVersioningStatusEnabled)
+	VersioningSuspended = string(types.BucketVersioningStatusSuspended)
+	// VersioningDisabled is the state of buckets where versioning was
+	// never enabled, which S3 reports without a status.
+	VersioningDisabled = "Disabled"
+)
+
+// Transition is a transition of a lifecycle rule to another storage class.
+type Transition struct {
+	Class string
+	Days  int32
+}
+
+// LifecycleRule is an enabled lifecycle rule of the bucket that applies to
+// some of the objects written under the destination.
+type LifecycleRule struct {
+	ID             string
+	Prefix         string
+	ExpirationDays int32     // days after which the current objects expire, if set
+	ExpirationDate time.Time // date after which the current objects expire, if set
+	NoncurrentDays int32     // days after which the noncurrent versions expire, if set
+	AbortDays      int32     // days after which incomplete multipart uploads are aborted, if set
+	Transitions    []Transition
+}
+
+// Expires reports whether the rule deletes the current objects, which
+// garbage collects backup files regardless of the backup retention.
+func (r LifecycleRule) Expires() bool {
+	return r.ExpirationDays > 0 || !r.ExpirationDate.IsZero()
+}
+
+// BucketInventory describes the features of the bucket that affect
+// backups: versioning, object lock and lifecycle rules.
+type BucketInventory struct {
+	Versioning    string          // one of the versioning states, empty if unknown
+	VersioningErr string          // why the versioning state could not be read, if it could not
+	ObjectLock    *ObjectLock     // object lock configuration of the bucket
+	Lifecycle     []LifecycleRule // lifecycle rules applying to the destination
+	LifecycleErr  string          // why the lifecycle configuration could not be read, if it could not
+}
+
+// Warnings returns the features of the bucket that can break incremental
+// backups or garbage collect backup files.
+func (b *BucketInventory) Warnings() []string {
+	var res []string
+	if l := b.ObjectLock; l != nil && l.Enabled && l.Retention > 0 {
+		res = append(res, fmt.Sprintf("object lock retains every version written for %d days: "+
+			"backup files cannot be deleted before, and overwritten files accumulate as locked versions",
+			int(l.Retention.Hours()/24)))
+	}
+	noncurrent := slices.ContainsFunc(b.Lifecycle, func(r LifecycleRule) bool { return r.NoncurrentDays > 0 })
+	if (b.Versioning == VersioningEnabled || b.Versioning == VersioningSuspended) && !noncurrent {
+		res = append(res, "no lifecycle rule expires the noncurrent versions: "+
+			"overwritten and deleted backup files are kept and billed indefinitely")
+	}
+	for _, r := range b.Lifecycle {
+		if r.Expires() {
+			res = append(res, fmt.Sprintf("lifecycle rule %s expires backup files: "+
+				"incremental backups cannot be restored once the files of their full backup are deleted", r.ID))
+		}
+		for _, t := range r.Transitions {
+			if slices.Contains(archivalClasses, t.Class) {
+				res = append(res, fmt.Sprintf("lifecycle rule %s moves backup files to %s after %d days: "+
+					"restores fail until the files are retrieved", r.ID, t.Class, t.Days))
+			}
+		}
+	}
+	return res
+}
+
+// BucketInventory implements BucketInventoryReporter.
+func (s *s3Store) BucketInventory(ctx context.Context) (*BucketInventory, error) {
+	if s.client == nil {
+		return nil, errors.New("storage is not connected")
+	}
+	res := &BucketInventory{}
+	res.Versioning, res.VersioningErr = s.versioning(ctx)
+	lock, err := s.ObjectLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res.ObjectLock = lock
+	rules, lifecycleErr := s.lifecycleRules(ctx)
+	res.LifecycleErr = lifecycleErr
+	for _, rule := range rules {
+		r := LifecycleRule{ID: aws.ToString(rule.ID), Prefix: rulePrefix(rule)}
+		if e := rule.Expiration; e != nil {
+			r.ExpirationDays = aws.ToInt32(e.Days)
+			r.ExpirationDate = aws.ToTime(e.Date)
+		}
+		if e := rule.NoncurrentVersionExpiration; e != nil {
+			r.NoncurrentDays = aws.ToInt32(e.NoncurrentDays)
+		}
+		if a := rule.AbortIncompleteMultipartUpload; a != nil {
+			r.AbortDays = aws.ToInt32(a.DaysAfterInitiation)
+		}
+		for _, t := range rule.Transitions {
+			r.Transitions = append(r.Transitions, Transition{Class: string(t.StorageClass), Days: aws.ToInt32(t.Days)})
+		}
+		res.Lifecycle = append(res.Lifecycle, r)
+	}
+	return res, nil
+}
+
+// versioning returns the versioning state of the bucket. If the state
+// cannot be read, the reason is returned instead.
+func (s *s3Store) versioning(ctx context.Context) (string, string) {
+	out, err := s.client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{
+		Bucket: aws.String(s.BucketName()),
+	})
+	if err != nil {
+		return "", err.Error()
+	}
+	if out.Status == "" {
+		return VersioningDisabled, ""
+	}
+	return string(out.Status), ""
+}
+
+// lifecycleRules returns the enabled lifecycle rules of the bucket that
+// apply to objects under the destination. If the rules cannot be read, the
+// reason is returned instead.
+func (s *s3Store) lifecycleRules(ctx context.Context) ([]types.LifecycleRule, string) {
+	config, err := s.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(s.BucketName()),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchLifecycleConfiguration" {
+			return nil, ""
+		}
+		return nil, err.Error()
+	}
+	var res []types.LifecycleRule
+	for _, rule := range config.Rules {
+		if rule.Status == types.ExpirationStatusEnabled && s.coversDest(rulePrefix(rule)) {
+			res = append(res, rule)
+		}
+	}
+	return res, ""
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketInventory(t *testing.T) {
+	tests := []struct {
+		name         string
+		fake         *fakeS3
+		want         BucketInventory
+		wantWarnings []string
+	}{
+		{
+			name: "plain bucket",
+			fake: &fakeS3{},
+			want: BucketInventory{Versioning: VersioningDisabled, ObjectLock: &ObjectLock{}},
+		},
+		{
+			name: "versioned without noncurrent expiration",
+			fake: &fakeS3{versioning: VersioningEnabled},
+			want: BucketInventory{Versioning: VersioningEnabled, ObjectLock: &ObjectLock{}},
+			wantWarnings: []string{"no lifecycle rule expires the noncurrent versions: " +
+				"overwritten and deleted backup files are kept and billed indefinitely"},
+		},
+		{
+			name: "locked and expiring",
+			fake: &fakeS3{
+				versioning: VersioningEnabled,
+				objectLock: `<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled>
+<Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>30</Days></DefaultRetention></Rule></ObjectLockConfiguration>`,
+				lifecycle: `<LifecycleConfiguration>
+<Rule><ID>gc</ID><Status>Enabled</Status><Filter><Prefix>backups/</Prefix></Filter>
+<Expiration><Days>14</Days></Expiration><NoncurrentVersionExpiration><NoncurrentDays>1</NoncurrentDays></NoncurrentVersionExpiration>
+<AbortIncompleteMultipartUpload><DaysAfterInitiation>2</DaysAfterInitiation></AbortIncompleteMultipartUpload></Rule>
+<Rule><ID>cold</ID><Status>Enabled</Status><Filter><Prefix></Prefix></Filter>
+<Transition><Days>90</Days><StorageClass>DEEP_ARCHIVE</StorageClass></Transition></Rule>
+<Rule><ID>disabled</ID><Status>Disabled</Status><Filter><Prefix></Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+<Rule><ID>logs</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>1</Days></Expiration></Rule>
+</LifecycleConfiguration>`,
+			},
+			want: BucketInventory{
+				Versioning: VersioningEnabled,
+				ObjectLock: &ObjectLock{Enabled: true, Mode: LockGovernance, Retention: 30 * 24 * time.Hour},
+				Lifecycle: []LifecycleRule{
+					{ID: "gc", Prefix: "backups/", ExpirationDays: 14, NoncurrentDays: 1, AbortDays: 2},
+					{ID: "cold", Transitions: []Transition{{Class: "DEEP_ARCHIVE", Days: 90}}},
+				},
+			},
+			wantWarnings: []string{
+				"object lock retains every version written for 30 days: " +
+					"backup files cannot be deleted before, and overwritten files accumulate as locked versions",
+				"lifecycle rule gc expires backup files: " +
+					"incremental backups cannot be restored once the files of their full backup are deleted",
+				"lifecycle rule cold moves backup files to DEEP_ARCHIVE after 90 days: " +
+					"restores fail until the files are retrieved",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			_, alt := newFakeS3Store(t, tt.fake, func(s *s3Store) {
+				s.dest, s.root = "bucket/backups", "bucket/backups"
+			})
+
+			got, err := alt.BucketInventory(context.Background())
+			r.NoError(err)
+			assert.Equal(t, tt.want, *got)
+			assert.Equal(t, tt.wantWarnings, got.Warnings())
+		})
+	}
+}
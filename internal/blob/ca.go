@@ -0,0 +1,49 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"crypto/x509"
+	"os"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// CACertEnv is the PEM bundle of the CA certificates of the storage
+// endpoint, if --ca-cert is not set.
+const CACertEnv = "BLOBCHECK_CA_CERT"
+
+// caBundle returns the CA bundle of the storage endpoint provided by the user,
+// if any, after checking that it holds PEM encoded certificates: a bundle
+// that cannot be loaded would leave only the configurations that skip the
+// verification.
+func caBundle(env *env.Env) (string, error) {
+	file := env.CACert
+	if file == "" && env.LookupEnv != nil {
+		file, _ = env.LookupEnv(CACertEnv)
+	}
+	if file == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the CA bundle")
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(data) {
+		return "", errors.Newf("no PEM encoded certificate found in %s", file)
+	}
+	return file, nil
+}
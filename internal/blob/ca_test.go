@@ -0,0 +1,87 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// TestCustomCA verifies that an endpoint signed by a private CA is verified
+// with the CA bundle, rather than by skipping the verification.
+func TestCustomCA(t *testing.T) {
+	t.Setenv("AWS_CA_BUNDLE", "")
+	server := httptest.NewTLSServer(&fakeS3{})
+	defer server.Close()
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+
+	tests := []struct {
+		name         string
+		caFile       string
+		wantSkip     bool
+		wantCustomCA string
+	}{
+		{name: "no bundle", wantSkip: true},
+		{name: "bundle", caFile: ca, wantCustomCA: ca},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			s := &s3Store{
+				dest: "bucket/path",
+				root: "bucket",
+				params: Params{
+					AccountParam:      "id",
+					SecretParam:       "secret",
+					RegionParam:       DefaultRegion,
+					EndPointParam:     server.URL,
+					UsePathStyleParam: "true",
+				},
+				caFile:  tt.caFile,
+				testing: true,
+			}
+			store, err := s.try(context.Background(), s.BucketName())
+			r.NoError(err)
+			r.Equal(tt.wantSkip, store.Params().Bool(SkipTLSVerify))
+			r.Equal(tt.wantCustomCA, store.CustomCA())
+		})
+	}
+}
+
+// TestCABundle verifies that a CA bundle without certificates is rejected.
+func TestCABundle(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.pem")
+	r.NoError(os.WriteFile(invalid, []byte("not a certificate"), 0o600))
+
+	file, err := caBundle(&env.Env{})
+	r.NoError(err)
+	r.Empty(file)
+	_, err = caBundle(&env.Env{CACert: invalid})
+	r.ErrorContains(err, "no PEM encoded certificate")
+	_, err = caBundle(&env.Env{CACert: filepath.Join(dir, "missing.pem")})
+	r.ErrorContains(err, "failed to read the CA bundle")
+}
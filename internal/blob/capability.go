@@ -0,0 +1,287 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Operations verified by the capability probe.
+const (
+	CapList      = "list"
+	CapPut       = "put"
+	CapGet       = "get"
+	CapDelete    = "delete"
+	CapMultipart = "multipart"
+	CapRange     = "range"
+	CapOverwrite = "overwrite"
+)
+
+// rangeLength is the number of bytes requested by the range probe.
+const rangeLength = 4
+
+// overwriteReads is the number of reads verifying the content of an
+// overwritten object.
+const overwriteReads = 10
+
+// Capability is the outcome of probing an operation used by backups and
+// restores with the selected configuration.
+type Capability struct {
+	Operation string
+	Supported bool
+	Latency   time.Duration
+	Err       string // reason the operation is not supported
+}
+
+// newCapability returns the capability of an operation, given the error
+// returned by its probe.
+func newCapability(op string, latency time.Duration, err error) Capability {
+	if err != nil {
+		return Capability{Operation: op, Err: err.Error()}
+	}
+	return Capability{Operation: op, Supported: true, Latency: latency}
+}
+
+// Capabilities implements Storage. The basic operations were verified when
+// the configuration was selected; multipart uploads and ranged reads are
+// probed with additional objects, which are deleted afterwards, as well as
+// the consistency of overwritten objects and the server side encryption
+// modes accepted by the bucket.
+func (s *s3Store) Capabilities(ctx context.Context) ([]Capability, error) {
+	if s.client == nil {
+		return nil, errors.New("storage is not connected")
+	}
+	var latency Latency
+	if s.latency != nil {
+		latency = *s.latency
+	}
+	res := []Capability{
+		newCapability(CapList, latency.List, nil),
+		newCapability(CapPut, latency.Put, nil),
+		newCapability(CapGet, latency.Get, nil),
+		newCapability(CapDelete, latency.Delete, nil),
+	}
+	start := time.Now()
+	err := s.probeMultipart(ctx, s.objects.name("multipart"))
+	res = append(res, newCapability(CapMultipart, time.Since(start), err))
+	start = time.Now()
+	err = s.probeRange(ctx, s.objects.name("range"))
+	res = append(res, newCapability(CapRange, time.Since(start), err))
+	start = time.Now()
+	err = s.probeOverwrite(ctx, s.objects.name("overwrite"))
+	res = append(res, newCapability(CapOverwrite, time.Since(start), err))
+	res = append(res, s.probeEncryption(ctx, s.objects.name("encryption"))...)
+	res = append(res, s.probeChecksums(ctx, s.objects.name("checksum"))...)
+	return res, nil
+}
+
+// probeMultipart uploads an object using the multipart API, verifies its
+// size and deletes it. The object is uploaded in a single small part, or,
+// if a part size is set, in a part of that size followed by a small one,
+// like the multipart uploads of the backups. The upload is aborted if it
+// cannot be completed.
+func (s *s3Store) probeMultipart(ctx context.Context, name string) error {
+	bucket := aws.String(s.BucketName())
+	key := path.Join(s.keyPrefix(), name)
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:               bucket,
+		Key:                  aws.String(key),
+		Metadata:             s.objects.metadata(),
+		ServerSideEncryption: serverSideEncryption(s.params),
+		SSEKMSKeyId:          kmsKeyID(s.params),
+		RequestPayer:         s.requestPayer(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create multipart upload")
+	}
+	abort := func() {
+		if _, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:       bucket,
+			Key:          aws.String(key),
+			UploadId:     created.UploadId,
+			RequestPayer: s.requestPayer(),
+		}); err != nil {
+			// The parts are removed by the lifecycle rules of the bucket, if
+			// any.
+			slog.Warn("failed to abort multipart upload", slog.String("key", key), slog.Any("error", err))
+		}
+	}
+	bodies := [][]byte{[]byte(s.objects.content())}
+	if s.partSize > 0 {
+		bodies = [][]byte{make([]byte, s.partSize), []byte(s.objects.content())}
+	}
+	var parts []types.CompletedPart
+	var size int64
+	for i, body := range bodies {
+		number := aws.Int32(int32(i + 1))
+		part, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:       bucket,
+			Key:          aws.String(key),
+			UploadId:     created.UploadId,
+			PartNumber:   number,
+			Body:         bytes.NewReader(body),
+			RequestPayer: s.requestPayer(),
+		})
+		if err != nil {
+			abort()
+			return errors.Wrapf(err, "failed to upload part %d of %d bytes", *number, len(body))
+		}
+		parts = append(parts, types.CompletedPart{
+			ETag:          part.ETag,
+			PartNumber:    number,
+			ChecksumCRC32: part.ChecksumCRC32,
+		})
+		size += int64(len(body))
+	}
+	if _, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          bucket,
+		Key:             aws.String(key),
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		RequestPayer:    s.requestPayer(),
+	}); err != nil {
+		abort()
+		return errors.Wrap(err, "failed to complete multipart upload")
+	}
+	defer func() {
+		if err := s.deleteObject(ctx, name); err != nil {
+			slog.Warn("failed to delete multipart probe object", slog.Any("error", err))
+		}
+	}()
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       bucket,
+		Key:          aws.String(key),
+		RequestPayer: s.requestPayer(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to read multipart object")
+	}
+	if got := aws.ToInt64(head.ContentLength); got != size {
+		return errors.Newf("multipart object has %d bytes, want %d", got, size)
+	}
+	return nil
+}
+
+// probeRange writes an object, reads its first bytes with a ranged
+// request and deletes it. Providers that ignore the range return the whole
+// object, which restores would misread.
+func (s *s3Store) probeRange(ctx context.Context, name string) error {
+	bucket := aws.String(s.BucketName())
+	key := path.Join(s.keyPrefix(), name)
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               bucket,
+		Key:                  aws.String(key),
+		Body:                 strings.NewReader(s.objects.content()),
+		Metadata:             s.objects.metadata(),
+		ServerSideEncryption: serverSideEncryption(s.params),
+		SSEKMSKeyId:          kmsKeyID(s.params),
+		RequestPayer:         s.requestPayer(),
+	}); err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+	defer func() {
+		if err := s.deleteObject(ctx, name); err != nil {
+			slog.Warn("failed to delete range probe object", slog.Any("error", err))
+		}
+	}()
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:       bucket,
+		Key:          aws.String(key),
+		Range:        aws.String("bytes=0-" + strconv.Itoa(rangeLength-1)),
+		RequestPayer: s.requestPayer(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to get object range")
+	}
+	defer result.Body.Close()
+	got, err := io.ReadAll(result.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read object range")
+	}
+	if string(got) != s.objects.content()[:rangeLength] {
+		return errors.Newf("range ignored: got %d bytes, want %d", len(got), rangeLength)
+	}
+	return nil
+}
+
+// probeOverwrite writes an object twice with different contents, and reads
+// it back several times.
+func (s *s3Store) probeOverwrite(ctx context.Context, name string) error {
+	return verifyOverwrite(s.objects.content(),
+		func(body string) error {
+			_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket:               aws.String(s.BucketName()),
+				Key:                  aws.String(path.Join(s.keyPrefix(), name)),
+				Body:                 strings.NewReader(body),
+				Metadata:             s.objects.metadata(),
+				ServerSideEncryption: serverSideEncryption(s.params),
+				SSEKMSKeyId:          kmsKeyID(s.params),
+				RequestPayer:         s.requestPayer(),
+			})
+			return err
+		},
+		func() ([]byte, error) { return s.Get(ctx, name) },
+		func() error { return s.deleteObject(ctx, name) },
+	)
+}
+
+// verifyOverwrite writes an object twice with contents derived from the
+// marker, and reads it back several times: every read must return the
+// second version. Caching gateways that serve stale objects break the
+// LATEST file of backup collections, which is overwritten by every backup.
+// The object is deleted afterwards.
+func verifyOverwrite(
+	marker string, put func(body string) error, get func() ([]byte, error), remove func() error,
+) error {
+	if err := put(marker + "_1"); err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+	defer func() {
+		if err := remove(); err != nil {
+			slog.Warn("failed to delete overwrite probe object", slog.Any("error", err))
+		}
+	}()
+	want := marker + "_2"
+	if err := put(want); err != nil {
+		return errors.Wrap(err, "failed to overwrite object")
+	}
+	stale := 0
+	for range overwriteReads {
+		got, err := get()
+		if err != nil {
+			return err
+		}
+		if string(got) != want {
+			stale++
+		}
+	}
+	if stale > 0 {
+		return errors.Newf("stale content returned by %d of %d reads after the overwrite", stale, overwriteReads)
+	}
+	return nil
+}
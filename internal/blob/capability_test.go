@@ -0,0 +1,546 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"bufio"
+	"context"
+	"encoding/pem"
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 serves the requests of the probes, storing the objects in memory.
+// Multipart uploads and ranged reads can be disabled, to emulate providers
+// that do not support them, overwritten objects can be served stale, to
+// emulate caching gateways, and deleted objects can remain listed, to
+// emulate eventually consistent listings. Keys can be rewritten or
+// rejected, to emulate appliances with restrictions on object names.
+// Storage classes are kept, and reading GLACIER objects can be rejected, to
+// emulate archival tiers.
+type fakeS3 struct {
+	multipart, ranges, stale bool
+	listLag                  int    // number of listings that still include a deleted object
+	plusAsSpace              bool   // store the keys with '+' replaced by spaces
+	maxKey                   int    // maximum length of the keys, if set
+	requesterPays            bool   // deny the requests that do not set the request payer
+	lifecycle                string // lifecycle configuration of the bucket, if any
+	archival                 bool   // reject reads of the objects in the GLACIER class
+	objectLock               string // object lock configuration of the bucket, if any
+	versioning               string // versioning status of the bucket, if ever enabled
+	region                   string // reject the requests signed for other regions, if set
+	lastPartOnly             bool   // assemble the multipart uploads from their last part only
+	denyWrites               bool   // deny the writes of objects
+	accessKey                string // reject the requests signed with other access keys, if set
+	encryption               string // deny the writes without this server side encryption, if set
+	checksums                string // checksum algorithms accepted by the writes, comma separated, if set
+	sigV2                    bool   // reject every request, as providers that only accept Signature Version 2
+	truncate                 int    // serve at most this many bytes of the objects, as proxies dropping transfers, if set
+	pageSize                 int    // paginate the sorted listings with this many entries per page, and support delimiters, if set
+	truncatedListing         bool   // report the first page of the paginated listings as complete
+	virtualHosted            bool   // serve the buckets addressed as sub-domains of the host
+	denyRetention            bool   // deny the requests reading the retention of the objects
+
+	mu      sync.Mutex
+	objects map[string]string
+	classes map[string]string   // storage class of the objects, if set
+	deleted map[string]int      // remaining listings of the deleted objects
+	parts   map[string][]string // uploaded parts of the multipart uploads
+	written map[string]string   // purpose metadata of the objects written, kept after deletion
+	// versions of the objects, oldest first, with versioning enabled.
+	versions map[string][]fakeVersion
+	retained map[string]bool // versions retained by object lock, as path?versionId
+	nextID   int
+}
+
+// fakeVersion is a version, or a delete marker, of an object.
+type fakeVersion struct {
+	id     string
+	body   string
+	marker bool
+}
+
+// ServeHTTP implements http.Handler.
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.objects == nil {
+		f.objects = make(map[string]string)
+		f.classes = make(map[string]string)
+		f.deleted = make(map[string]int)
+		f.parts = make(map[string][]string)
+		f.written = make(map[string]string)
+		f.versions = make(map[string][]fakeVersion)
+	}
+	if f.requesterPays && req.Header.Get("x-amz-request-payer") != "requester" {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>AccessDenied</Code></Error>`)
+		return
+	}
+	if f.region != "" && !strings.Contains(req.Header.Get("Authorization"), "/"+f.region+"/s3/") {
+		w.Header().Set(bucketRegionHeader, f.region)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `<Error><Code>AuthorizationHeaderMalformed</Code></Error>`)
+		return
+	}
+	if f.accessKey != "" && !strings.Contains(req.Header.Get("Authorization"), "Credential="+f.accessKey+"/") {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>InvalidAccessKeyId</Code></Error>`)
+		return
+	}
+	if f.sigV2 {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `<Error><Code>InvalidArgument</Code>`+
+			`<Message>AWS4-HMAC-SHA256 is not supported, use signature version 2</Message></Error>`)
+		return
+	}
+	q := req.URL.Query()
+	if algorithm := checksumAlgorithm(req); f.checksums != "" && req.Method == http.MethodPut && algorithm != "" &&
+		!slices.Contains(strings.Split(f.checksums, ","), algorithm) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `<Error><Code>InvalidArgument</Code><Message>unsupported checksum</Message></Error>`)
+		return
+	}
+	if f.denyWrites && req.Method == http.MethodPut {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+		return
+	}
+	if f.encryption != "" && (req.Method == http.MethodPut || q.Has("uploads")) &&
+		!q.Has("partNumber") && req.Header.Get("x-amz-server-side-encryption") != f.encryption {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+		return
+	}
+	if bucket, _, ok := strings.Cut(req.Host, "."); ok && f.virtualHosted {
+		req.URL.Path = "/" + bucket + req.URL.Path
+	}
+	if f.plusAsSpace {
+		req.URL.Path = strings.ReplaceAll(req.URL.Path, "+", " ")
+	}
+	switch {
+	case q.Has("uploads") || q.Has("uploadId") || q.Has("partNumber"):
+		if !f.multipart {
+			w.WriteHeader(http.StatusNotImplemented)
+			fmt.Fprint(w, `<Error><Code>NotImplemented</Code></Error>`)
+			return
+		}
+		switch {
+		case q.Has("uploads"):
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>1</UploadId></InitiateMultipartUploadResult>`)
+		case q.Has("partNumber"):
+			body := readBody(req)
+			f.parts[req.URL.Path] = append(f.parts[req.URL.Path], string(body))
+			w.Header().Set("ETag", `"etag"`)
+		case req.Method == http.MethodPost:
+			parts := f.parts[req.URL.Path]
+			if f.lastPartOnly {
+				parts = parts[len(parts)-1:]
+			}
+			f.objects[req.URL.Path] = strings.Join(parts, "")
+			delete(f.parts, req.URL.Path)
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case req.Method == http.MethodGet && q.Has("list-type") && f.pageSize > 0:
+		f.listPage(w, strings.TrimSuffix(req.URL.Path, "/")+"/", q)
+	case req.Method == http.MethodGet && q.Has("list-type"):
+		bucket := strings.TrimSuffix(req.URL.Path, "/") + "/"
+		fmt.Fprint(w, `<ListBucketResult><Name>bucket</Name>`)
+		for p := range f.objects {
+			if key, ok := strings.CutPrefix(p, bucket); ok && strings.HasPrefix(key, q.Get("prefix")) {
+				fmt.Fprintf(w, `<Contents><Key>%s</Key></Contents>`, html.EscapeString(key))
+			}
+		}
+		for p, n := range f.deleted {
+			if key, ok := strings.CutPrefix(p, bucket); ok && strings.HasPrefix(key, q.Get("prefix")) {
+				fmt.Fprintf(w, `<Contents><Key>%s</Key></Contents>`, html.EscapeString(key))
+				if n <= 1 {
+					delete(f.deleted, p)
+				} else {
+					f.deleted[p] = n - 1
+				}
+			}
+		}
+		fmt.Fprint(w, `</ListBucketResult>`)
+	case req.Method == http.MethodPut && f.maxKey > 0 && len(req.URL.Path) > f.maxKey:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `<Error><Code>KeyTooLongError</Code></Error>`)
+	case q.Has("object-lock"):
+		if f.objectLock == "" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>ObjectLockConfigurationNotFoundError</Code></Error>`)
+			return
+		}
+		fmt.Fprint(w, f.objectLock)
+	case q.Has("retention") || q.Has("legal-hold"):
+		f.lockStatus(w, req.URL.Path, q)
+	case q.Has("versions"):
+		f.listVersions(w, strings.TrimSuffix(req.URL.Path, "/")+"/", q.Get("prefix"))
+	case req.Method == http.MethodDelete && q.Has("versionId"):
+		f.deleteVersion(req.URL.Path, q.Get("versionId"))
+		w.WriteHeader(http.StatusNoContent)
+	case q.Has("lifecycle"):
+		if f.lifecycle == "" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchLifecycleConfiguration</Code></Error>`)
+			return
+		}
+		fmt.Fprint(w, f.lifecycle)
+	case q.Has("versioning"):
+		fmt.Fprintf(w, `<VersioningConfiguration>%s</VersioningConfiguration>`, statusElement(f.versioning))
+	case req.Method == http.MethodPut && req.Header.Get("x-amz-copy-source") != "":
+		f.classes[req.URL.Path] = req.Header.Get("x-amz-storage-class")
+		fmt.Fprint(w, `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+	case req.Method == http.MethodPut:
+		body := readBody(req)
+		f.written[req.URL.Path] = req.Header.Get("x-amz-meta-" + ProbeTagMetadata)
+		if _, ok := f.objects[req.URL.Path]; !ok || !f.stale {
+			f.objects[req.URL.Path] = string(body)
+			f.classes[req.URL.Path] = req.Header.Get("x-amz-storage-class")
+		}
+		f.addVersion(req.URL.Path, fakeVersion{body: string(body)})
+	case req.Method == http.MethodHead:
+		if object, ok := f.objects[req.URL.Path]; ok {
+			w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+		}
+		if class := f.classes[req.URL.Path]; class != "" {
+			w.Header().Set("x-amz-storage-class", class)
+		}
+	case req.Method == http.MethodGet:
+		object, ok := f.objects[req.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		if f.archival && f.classes[req.URL.Path] == "GLACIER" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>InvalidObjectState</Code></Error>`)
+			return
+		}
+		if f.ranges && req.Header.Get("Range") != "" {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", rangeLength-1, len(object)))
+			w.WriteHeader(http.StatusPartialContent)
+			fmt.Fprint(w, object[:rangeLength])
+			return
+		}
+		if f.truncate > 0 && len(object) > f.truncate {
+			object = object[:f.truncate]
+		}
+		fmt.Fprint(w, object)
+	case req.Method == http.MethodDelete:
+		if _, ok := f.objects[req.URL.Path]; ok && f.listLag > 0 {
+			f.deleted[req.URL.Path] = f.listLag
+		}
+		if _, ok := f.objects[req.URL.Path]; ok {
+			f.addVersion(req.URL.Path, fakeVersion{marker: true})
+		}
+		delete(f.objects, req.URL.Path)
+		delete(f.classes, req.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// addVersion records a version of the object, if versioning is enabled.
+func (f *fakeS3) addVersion(p string, v fakeVersion) {
+	if f.versioning != VersioningEnabled {
+		return
+	}
+	f.nextID++
+	v.id = strconv.Itoa(f.nextID)
+	f.versions[p] = append(f.versions[p], v)
+}
+
+// deleteVersion permanently removes a version of the object, exposing the
+// previous one.
+func (f *fakeS3) deleteVersion(p, id string) {
+	f.versions[p] = slices.DeleteFunc(f.versions[p], func(v fakeVersion) bool { return v.id == id })
+	delete(f.objects, p)
+	if n := len(f.versions[p]); n > 0 && !f.versions[p][n-1].marker {
+		f.objects[p] = f.versions[p][n-1].body
+	}
+}
+
+// listVersions lists the versions and delete markers of the objects of the
+// bucket.
+func (f *fakeS3) listVersions(w http.ResponseWriter, bucket, prefix string) {
+	fmt.Fprint(w, `<ListVersionsResult><Name>bucket</Name>`)
+	for _, p := range slices.Sorted(maps.Keys(f.versions)) {
+		key, ok := strings.CutPrefix(p, bucket)
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		for i, v := range f.versions[p] {
+			latest := i == len(f.versions[p])-1
+			if v.marker {
+				fmt.Fprintf(w, `<DeleteMarker><Key>%s</Key><VersionId>%s</VersionId><IsLatest>%t</IsLatest></DeleteMarker>`,
+					html.EscapeString(key), v.id, latest)
+				continue
+			}
+			fmt.Fprintf(w, `<Version><Key>%s</Key><VersionId>%s</VersionId><IsLatest>%t</IsLatest><Size>%d</Size></Version>`,
+				html.EscapeString(key), v.id, latest, len(v.body))
+		}
+	}
+	fmt.Fprint(w, `</ListVersionsResult>`)
+}
+
+// lockStatus serves the retention and the legal hold of an object, as AWS
+// does: buckets without object lock reject the requests, and objects without
+// retention have no configuration.
+func (f *fakeS3) lockStatus(w http.ResponseWriter, p string, q url.Values) {
+	switch {
+	case f.denyRetention:
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+	case f.objectLock == "":
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `<Error><Code>InvalidRequest</Code><Message>Bucket is missing Object Lock Configuration</Message></Error>`)
+	case q.Has("retention") && f.retained[p+"?"+q.Get("versionId")]:
+		fmt.Fprint(w, `<Retention><Mode>GOVERNANCE</Mode><RetainUntilDate>2999-01-01T00:00:00Z</RetainUntilDate></Retention>`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<Error><Code>NoSuchObjectLockConfiguration</Code></Error>`)
+	}
+}
+
+// fakeS3Stores serves the handler, usually a fakeS3, and returns a store
+// for the bucket/path destination, modified by the options, and the
+// configuration to probe it with.
+func fakeS3Stores(t *testing.T, handler http.Handler, opts ...func(*s3Store)) (*s3Store, *s3Store) {
+	t.Helper()
+	return serveFakeS3(t, httptest.NewServer(handler), opts...)
+}
+
+// fakeS3TLSStores returns the stores of fakeS3Stores, with the handler
+// served over TLS. The stores verify the endpoint with a CA bundle holding
+// the certificate of the server, valid for example.com and *.example.com.
+func fakeS3TLSStores(t *testing.T, handler http.Handler, opts ...func(*s3Store)) (*s3Store, *s3Store) {
+	t.Helper()
+	server := httptest.NewTLSServer(handler)
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	return serveFakeS3(t, server, append([]func(*s3Store){func(s *s3Store) { s.caFile = ca }}, opts...)...)
+}
+
+// serveFakeS3 returns the stores of fakeS3Stores for a started server,
+// which is closed with the test.
+func serveFakeS3(t *testing.T, server *httptest.Server, opts ...func(*s3Store)) (*s3Store, *s3Store) {
+	t.Helper()
+	t.Cleanup(server.Close)
+	// The CA bundle of the environment cannot be added to the test client.
+	t.Setenv("AWS_CA_BUNDLE", "")
+	params := Params{
+		AccountParam: "id", SecretParam: "secret", RegionParam: DefaultRegion,
+		EndPointParam: server.URL, UsePathStyleParam: "true",
+	}
+	s := &s3Store{dest: "bucket/path", root: "bucket/path", params: params, testing: true}
+	for _, opt := range opts {
+		opt(s)
+	}
+	alt := &s3Store{dest: s.dest, root: s.root, params: s.params, objects: s.objects}
+	return s, alt
+}
+
+// newFakeS3Store returns the stores of fakeS3Stores, once the probe of the
+// fake succeeded.
+func newFakeS3Store(t *testing.T, fake *fakeS3, opts ...func(*s3Store)) (*s3Store, *s3Store) {
+	t.Helper()
+	s, alt := fakeS3Stores(t, fake, opts...)
+	require.NoError(t, s.probe(context.Background(), alt, s.BucketName()))
+	return s, alt
+}
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		multipart    bool
+		lastPartOnly bool
+		ranges       bool
+		stale        bool
+	}{
+		{name: "all", multipart: true, ranges: true},
+		{name: "no multipart", ranges: true},
+		{name: "parts dropped", multipart: true, lastPartOnly: true, ranges: true},
+		{name: "range ignored", multipart: true},
+		{name: "stale overwrite", multipart: true, ranges: true, stale: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			_, alt := newFakeS3Store(t, &fakeS3{
+				multipart: tt.multipart, lastPartOnly: tt.lastPartOnly, ranges: tt.ranges, stale: tt.stale,
+			})
+			alt.partSize = 1024
+
+			caps, err := alt.Capabilities(context.Background())
+			r.NoError(err)
+			supported := make(map[string]bool)
+			for _, c := range caps {
+				supported[c.Operation] = c.Supported
+				if !c.Supported {
+					assert.NotEmpty(t, c.Err, c.Operation)
+				}
+			}
+			assert.Equal(t, map[string]bool{
+				CapList: true, CapPut: true, CapGet: true, CapDelete: true,
+				CapMultipart: tt.multipart && !tt.lastPartOnly, CapRange: tt.ranges, CapOverwrite: !tt.stale,
+				CapUnencrypted: true, CapSSES3: true,
+				CapChecksumCRC32: true, CapChecksumCRC64: true, CapChecksumSHA256: true, CapNoChecksum: true,
+			}, supported)
+		})
+	}
+
+	_, err := (&s3Store{}).Capabilities(context.Background())
+	assert.ErrorContains(t, err, "not connected")
+}
+
+func TestDeleteVisibility(t *testing.T) {
+	tests := []struct {
+		name    string
+		listLag int
+		wantErr string
+	}{
+		{name: "consistent"},
+		{name: "lagging", listLag: 2},
+		{name: "never deleted", listLag: 1000, wantErr: "still listed after"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			s, alt := fakeS3Stores(t, &fakeS3{listLag: tt.listLag}, func(s *s3Store) {
+				s.deleteWindow = 500 * time.Millisecond
+			})
+			err := s.probe(context.Background(), alt, s.BucketName())
+			if tt.wantErr != "" {
+				r.ErrorContains(err, tt.wantErr)
+				return
+			}
+			r.NoError(err)
+			r.Positive(alt.latency.DeleteVisible)
+			if tt.listLag > 0 {
+				r.GreaterOrEqual(alt.latency.DeleteVisible, time.Duration(tt.listLag)*deletePoll)
+			}
+		})
+	}
+}
+
+// readBody returns the content of an uploaded object, decoding the
+// aws-chunked encoding the SDK uses over HTTPS to send trailing checksums.
+func readBody(req *http.Request) []byte {
+	if !strings.Contains(req.Header.Get("Content-Encoding"), "aws-chunked") {
+		body, _ := io.ReadAll(req.Body)
+		return body
+	}
+	var body []byte
+	reader := bufio.NewReader(req.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return body
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(strings.Split(line, ";")[0]), 16, 64)
+		if err != nil || size == 0 {
+			return body
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return body
+		}
+		body = append(body, chunk[:size]...)
+	}
+}
+
+// checksumAlgorithm returns the algorithm of the checksum of an upload,
+// sent either as a header or as a trailer, if any.
+func checksumAlgorithm(req *http.Request) string {
+	for name := range req.Header {
+		if algorithm, ok := strings.CutPrefix(strings.ToLower(name), "x-amz-checksum-"); ok {
+			return algorithm
+		}
+	}
+	if trailer := req.Header.Get("x-amz-trailer"); trailer != "" {
+		return strings.TrimPrefix(strings.ToLower(trailer), "x-amz-checksum-")
+	}
+	return ""
+}
+
+// statusElement returns the Status element of a versioning configuration,
+// which S3 omits if versioning was never enabled.
+func statusElement(status string) string {
+	if status == "" {
+		return ""
+	}
+	return "<Status>" + status + "</Status>"
+}
+
+// listPage serves a page of the sorted listing of the bucket, grouping the
+// keys by delimiter, if any. The continuation token is the last entry of
+// the previous page.
+func (f *fakeS3) listPage(w http.ResponseWriter, bucket string, q url.Values) {
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	var entries []string // keys, and common prefixes ending with the delimiter
+	for p := range f.objects {
+		key, ok := strings.CutPrefix(p, bucket)
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if i := strings.Index(key[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+			key = key[:len(prefix)+i+len(delimiter)]
+		}
+		entries = append(entries, key)
+	}
+	slices.Sort(entries)
+	entries = slices.Compact(entries)
+	if token := q.Get("continuation-token"); token != "" {
+		i, _ := slices.BinarySearch(entries, token)
+		entries = entries[min(i+1, len(entries)):]
+	}
+	truncated := len(entries) > f.pageSize
+	if truncated {
+		entries = entries[:f.pageSize]
+	}
+	fmt.Fprint(w, `<ListBucketResult><Name>bucket</Name>`)
+	for _, e := range entries {
+		if delimiter != "" && strings.HasSuffix(e, delimiter) {
+			fmt.Fprintf(w, `<CommonPrefixes><Prefix>%s</Prefix></CommonPrefixes>`, html.EscapeString(e))
+		} else {
+			fmt.Fprintf(w, `<Contents><Key>%s</Key></Contents>`, html.EscapeString(e))
+		}
+	}
+	if truncated && !f.truncatedListing {
+		fmt.Fprintf(w, `<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>`,
+			html.EscapeString(entries[len(entries)-1]))
+	}
+	fmt.Fprint(w, `</ListBucketResult>`)
+}
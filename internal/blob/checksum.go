@@ -0,0 +1,116 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Request checksums verified by the capability probe. The SDK sends a
+// CRC32 checksum by default, which AWS_SKIP_CHECKSUM disables; some
+// providers reject the checksums, or the algorithms they do not know.
+const (
+	CapChecksumCRC32  = "put with CRC32 checksum"
+	CapChecksumCRC64  = "put with CRC64NVME checksum"
+	CapChecksumSHA256 = "put with SHA256 checksum"
+	CapNoChecksum     = "put without checksum"
+)
+
+// checksumAlgorithms are the algorithms of the checksum probe, in the order
+// of the capabilities above; the empty algorithm disables the checksum.
+var checksumAlgorithms = []struct {
+	op        string
+	algorithm types.ChecksumAlgorithm
+}{
+	{CapChecksumCRC32, types.ChecksumAlgorithmCrc32},
+	{CapChecksumCRC64, types.ChecksumAlgorithmCrc64nvme},
+	{CapChecksumSHA256, types.ChecksumAlgorithmSha256},
+	{CapNoChecksum, ""},
+}
+
+// unsupportedSignature are fragments of the errors of providers that do not
+// accept Signature Version 4, which is the only version supported by the
+// SDK and by CockroachDB.
+var unsupportedSignature = []string{
+	"aws4-hmac-sha256",
+	"signature version",
+	"sigv4",
+	"authorization mechanism",
+}
+
+// isUnsupportedSignature returns whether the provider rejected the request
+// because of the signature version.
+func isUnsupportedSignature(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	msg := strings.ToLower(apiErr.ErrorMessage())
+	for _, fragment := range unsupportedSignature {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// probeChecksums writes an object with each checksum algorithm, and without
+// a checksum, and deletes it, to tell which checksums the provider accepts.
+func (s *s3Store) probeChecksums(ctx context.Context, name string) []Capability {
+	var res []Capability
+	for _, c := range checksumAlgorithms {
+		start := time.Now()
+		err := s.putChecksum(ctx, name, c.algorithm)
+		res = append(res, newCapability(c.op, time.Since(start), err))
+	}
+	return res
+}
+
+// putChecksum writes an object with a checksum computed with the
+// algorithm, or without a checksum if empty, and deletes it.
+func (s *s3Store) putChecksum(ctx context.Context, name string, algorithm types.ChecksumAlgorithm) error {
+	withoutChecksum := func(o *s3.Options) {
+		if algorithm == "" {
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		}
+	}
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(s.BucketName()),
+		Key:                  aws.String(path.Join(s.keyPrefix(), name)),
+		Body:                 strings.NewReader(s.objects.content()),
+		Metadata:             s.objects.metadata(),
+		ChecksumAlgorithm:    algorithm,
+		ServerSideEncryption: serverSideEncryption(s.params),
+		SSEKMSKeyId:          kmsKeyID(s.params),
+		RequestPayer:         s.requestPayer(),
+	}, withoutChecksum); err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+	if err := s.deleteObject(ctx, name); err != nil {
+		slog.Warn("failed to delete checksum probe object", slog.Any("error", err))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChecksumMatrix verifies that the algorithms rejected by the provider
+// are reported by the capabilities.
+func TestChecksumMatrix(t *testing.T) {
+	tests := []struct {
+		name             string
+		checksums        string
+		wantSkipChecksum bool
+		wantSupported    map[string]bool
+	}{
+		{
+			name:      "crc32 only",
+			checksums: "crc32",
+			wantSupported: map[string]bool{
+				CapChecksumCRC32: true, CapChecksumCRC64: false, CapChecksumSHA256: false, CapNoChecksum: true,
+			},
+		},
+		{
+			name:             "no checksums",
+			checksums:        "none",
+			wantSkipChecksum: true,
+			wantSupported: map[string]bool{
+				CapChecksumCRC32: false, CapChecksumCRC64: false, CapChecksumSHA256: false, CapNoChecksum: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			s, _ := fakeS3Stores(t, &fakeS3{checksums: tt.checksums})
+			store, err := s.try(context.Background(), s.BucketName())
+			r.NoError(err)
+			r.Equal(tt.wantSkipChecksum, store.Params().Bool(SkipChecksum))
+			caps, err := store.Capabilities(context.Background())
+			r.NoError(err)
+			supported := make(map[string]bool)
+			for _, c := range caps {
+				if _, ok := tt.wantSupported[c.Operation]; ok {
+					supported[c.Operation] = c.Supported
+				}
+			}
+			assert.Equal(t, tt.wantSupported, supported)
+		})
+	}
+}
+
+// TestUnsupportedSignature verifies that a provider that rejects Signature
+// Version 4 is reported as such, rather than as unreachable.
+func TestUnsupportedSignature(t *testing.T) {
+	t.Setenv("AWS_CA_BUNDLE", "")
+	server := httptest.NewServer(&fakeS3{sigV2: true})
+	defer server.Close()
+
+	s := &s3Store{
+		dest: "bucket/path",
+		root: "bucket",
+		params: Params{
+			AccountParam:      "id",
+			SecretParam:       "secret",
+			RegionParam:       "eu-west-1",
+			EndPointParam:     server.URL,
+			UsePathStyleParam: "true",
+		},
+		testing: true,
+	}
+	_, err := s.try(context.Background(), s.BucketName())
+	require.Error(t, err)
+	assert.True(t, isUnsupportedSignature(err))
+}
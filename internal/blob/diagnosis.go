@@ -0,0 +1,199 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Cause is the likely cause of a probe failure. Causes are ordered by the
+// stage the request reached before failing, from the name resolution of
+// the endpoint to the response of the provider.
+type Cause int
+
+// Causes of the probe failures.
+const (
+	CauseUnknown         Cause = iota // the failure could not be classified
+	CauseDNS                          // the host name did not resolve
+	CauseConnectTimeout               // no TCP connection within the dial timeout
+	CauseConnectRefused               // the endpoint refused the TCP connection
+	CauseTLS                          // the TLS handshake failed or timed out
+	CauseResponseTimeout              // the request was sent, but no response arrived
+	CauseHTTP                         // the provider rejected the request
+	CauseNotFound                     // the provider answered HTTP 404
+	CauseForbidden                    // the provider answered HTTP 403
+)
+
+// String implements fmt.Stringer.
+func (c Cause) String() string {
+	switch c {
+	case CauseDNS:
+		return "DNS resolution"
+	case CauseConnectTimeout:
+		return "connect timeout"
+	case CauseConnectRefused:
+		return "connection refused"
+	case CauseTLS:
+		return "TLS handshake"
+	case CauseResponseTimeout:
+		return "response timeout"
+	case CauseHTTP:
+		return "HTTP error"
+	case CauseNotFound:
+		return "HTTP 404"
+	case CauseForbidden:
+		return "HTTP 403"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnosis is the classified failure of a candidate configuration.
+type Diagnosis struct {
+	Flags  Params // boolean parameters set to true, server side encryption and retried region of the configuration
+	Cause  Cause
+	Status int    // HTTP status code of the response, if the provider answered
+	Err    string // error returned by the probe
+}
+
+// Hint returns the likely fix for the failure.
+func (d Diagnosis) Hint() string {
+	switch d.Cause {
+	case CauseDNS:
+		if !d.Flags.Bool(UsePathStyleParam) {
+			return "check the endpoint host name and the DNS servers of the host; virtual-hosted " +
+				"requests also need the bucket host name to resolve, or " + UsePathStyleParam + "=true"
+		}
+		return "check the endpoint host name and the DNS servers of the host"
+	case CauseConnectTimeout:
+		return "a firewall, security group or missing route drops the connections to the endpoint"
+	case CauseConnectRefused:
+		return "nothing listens on the endpoint port: check the port and the scheme of the endpoint"
+	case CauseTLS:
+		return "the certificate is not trusted or does not match the host name: pass the CA with " +
+			"--ca-cert, or check that the port serves HTTPS"
+	case CauseResponseTimeout:
+		return "the endpoint accepted the request but did not answer: check the proxies and load balancers in between"
+	case CauseNotFound:
+		if d.Flags.Bool(UsePathStyleParam) {
+			return "the bucket does not exist on the endpoint: check the bucket name"
+		}
+		return "the bucket does not exist on the endpoint: check the bucket name, or set " +
+			UsePathStyleParam + "=true"
+	case CauseForbidden:
+		return "the credentials are rejected or not allowed to access the bucket: check the keys, " +
+			"the region and the bucket policy"
+	case CauseHTTP:
+		return fmt.Sprintf("the provider rejected the request with HTTP %d", d.Status)
+	default:
+		return ""
+	}
+}
+
+// DiagnosisError is returned when no configuration works. It reports the
+// classified failure of each candidate configuration.
+type DiagnosisError struct {
+	Diagnoses []Diagnosis
+	err       error
+}
+
+// Error implements error.
+func (e *DiagnosisError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error reported by the search for a configuration.
+func (e *DiagnosisError) Unwrap() error {
+	return e.err
+}
+
+// LikelyCause returns the diagnosis of the configuration that got the
+// furthest, which is the most telling: a TLS failure of the configurations
+// that verify the certificate does not matter if the others are denied
+// access. Ties are broken by the order of the diagnoses.
+func LikelyCause(diagnoses []Diagnosis) (Diagnosis, bool) {
+	if len(diagnoses) == 0 {
+		return Diagnosis{}, false
+	}
+	res := diagnoses[0]
+	for _, d := range diagnoses[1:] {
+		if d.Cause > res.Cause {
+			res = d
+		}
+	}
+	return res, true
+}
+
+// diagnose classifies the failure of a probe of the configuration.
+func diagnose(params Params, err error) Diagnosis {
+	d := Diagnosis{Flags: configFlags(params), Err: err.Error()}
+	var respErr *awshttp.ResponseError
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	switch {
+	case errors.As(err, &respErr) && respErr.HTTPStatusCode() != 0:
+		d.Status = respErr.HTTPStatusCode()
+		switch d.Status {
+		case 403:
+			d.Cause = CauseForbidden
+		case 404:
+			d.Cause = CauseNotFound
+		default:
+			d.Cause = CauseHTTP
+		}
+	case errors.As(err, &dnsErr):
+		d.Cause = CauseDNS
+	case errors.Is(err, ErrDialTimeout):
+		d.Cause = CauseConnectTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		d.Cause = CauseConnectRefused
+	case errors.Is(err, ErrTLSHandshakeTimeout), errors.As(err, &certErr), errors.As(err, &authorityErr),
+		errors.As(err, &hostErr), errors.As(err, &recordErr):
+		d.Cause = CauseTLS
+	case errors.Is(err, ErrResponseHeaderTimeout), errors.Is(err, ErrProbeTimeout):
+		d.Cause = CauseResponseTimeout
+	case errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout():
+		d.Cause = CauseConnectTimeout
+	case strings.Contains(err.Error(), "tls: "):
+		// Handshake alerts sent by the server are not exported.
+		d.Cause = CauseTLS
+	}
+	return d
+}
+
+// sortDiagnoses orders the diagnoses of the concurrent probes by cause,
+// from the furthest stage, and then by configuration.
+func sortDiagnoses(diagnoses []Diagnosis) {
+	slices.SortStableFunc(diagnoses, func(a, b Diagnosis) int {
+		if a.Cause != b.Cause {
+			return int(b.Cause - a.Cause)
+		}
+		return strings.Compare(a.Flags.Encode(), b.Flags.Encode())
+	})
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestDiagnose(t *testing.T) {
+	response := func(status int) error {
+		return &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      errors.New("api error"),
+		}}
+	}
+	tests := []struct {
+		name   string
+		err    error
+		cause  Cause
+		status int
+	}{
+		{"dns", &net.DNSError{Err: "no such host", Name: "bucket.storage", IsNotFound: true}, CauseDNS, 0},
+		{"dial timeout", errors.Mark(errors.New("no connection"), ErrDialTimeout), CauseConnectTimeout, 0},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			CauseConnectRefused, 0},
+		{"handshake timeout", errors.Mark(errors.New("no handshake"), ErrTLSHandshakeTimeout), CauseTLS, 0},
+		{"unknown authority", errors.Wrap(x509.UnknownAuthorityError{}, "get"), CauseTLS, 0},
+		{"alert", errors.New("remote error: tls: handshake failure"), CauseTLS, 0},
+		{"response timeout", errors.Mark(errors.New("no headers"), ErrResponseHeaderTimeout), CauseResponseTimeout, 0},
+		{"forbidden", errors.Wrap(response(403), "failed to list objects"), CauseForbidden, 403},
+		{"not found", response(404), CauseNotFound, 404},
+		{"bad request", response(400), CauseHTTP, 400},
+		{"content", errors.New("unexpected content"), CauseUnknown, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := diagnose(Params{UsePathStyleParam: "true", RegionParam: "us-east-1"}, tt.err)
+			assert.Equal(t, tt.cause, d.Cause)
+			assert.Equal(t, tt.status, d.Status)
+			assert.Equal(t, Params{UsePathStyleParam: "true"}, d.Flags)
+			assert.Equal(t, tt.err.Error(), d.Err)
+		})
+	}
+}
+
+func TestDiagnosisHint(t *testing.T) {
+	a := assert.New(t)
+	a.Contains(Diagnosis{Cause: CauseDNS}.Hint(), UsePathStyleParam)
+	a.NotContains(Diagnosis{Cause: CauseDNS, Flags: Params{UsePathStyleParam: "true"}}.Hint(), UsePathStyleParam)
+	a.Contains(Diagnosis{Cause: CauseHTTP, Status: 501}.Hint(), "HTTP 501")
+	a.Empty(Diagnosis{Cause: CauseUnknown}.Hint())
+}
+
+func TestLikelyCause(t *testing.T) {
+	a := assert.New(t)
+	_, ok := LikelyCause(nil)
+	a.False(ok)
+	likely, ok := LikelyCause([]Diagnosis{
+		{Cause: CauseTLS},
+		{Cause: CauseForbidden, Flags: Params{SkipTLSVerify: "true"}},
+		{Cause: CauseForbidden, Flags: Params{SkipTLSVerify: "true", SkipChecksum: "true"}},
+	})
+	a.True(ok)
+	a.Equal(Diagnosis{Cause: CauseForbidden, Flags: Params{SkipTLSVerify: "true"}}, likely)
+}
+
+func TestDiagnosisError(t *testing.T) {
+	// The search is not retried with the region of the bucket.
+	region := func(s *s3Store) { s.params[RegionParam] = "us-west-2" }
+	t.Run("refused", func(t *testing.T) {
+		r := require.New(t)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		r.NoError(err)
+		addr := listener.Addr().String()
+		r.NoError(listener.Close())
+
+		s, _ := fakeS3Stores(t, &fakeS3{}, region, func(s *s3Store) {
+			s.params[EndPointParam] = "http://" + addr
+		})
+		_, err = s.try(context.Background(), s.BucketName())
+		var diagErr *DiagnosisError
+		r.True(errors.As(err, &diagErr))
+		r.NotEmpty(diagErr.Diagnoses)
+		for _, d := range diagErr.Diagnoses {
+			r.Equal(CauseConnectRefused, d.Cause, d.Err)
+		}
+	})
+	t.Run("denied", func(t *testing.T) {
+		r := require.New(t)
+		s, _ := fakeS3Stores(t, &fakeS3{denyWrites: true}, region)
+		_, err := s.try(context.Background(), s.BucketName())
+		var diagErr *DiagnosisError
+		r.True(errors.As(err, &diagErr))
+		likely, ok := LikelyCause(diagErr.Diagnoses)
+		r.True(ok)
+		r.Equal(CauseForbidden, likely.Cause)
+		r.Equal(403, likely.Status)
+		// The permissions are still diagnosed underneath.
+		var permErr *PermissionError
+		r.True(errors.As(err, &permErr))
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// KMSKeyEnv is the KMS key of the candidate configurations that encrypt
+// the objects with SSE-KMS, unless the parameters set the encryption.
+const KMSKeyEnv = "BLOBCHECK_KMS_KEY_ID"
+
+// Server side encryption modes verified by the capability probe.
+const (
+	CapUnencrypted = "put without encryption"
+	CapSSES3       = "put with SSE-S3"
+	CapSSEKMS      = "put with SSE-KMS"
+)
+
+// serverSideEncryption returns the encryption requested by the
+// parameters, if any.
+func serverSideEncryption(params Params) types.ServerSideEncryption {
+	return types.ServerSideEncryption(params[ServerEncModeParam])
+}
+
+// kmsKeyID returns the KMS key requested by the parameters, if they
+// encrypt the objects with SSE-KMS.
+func kmsKeyID(params Params) *string {
+	if serverSideEncryption(params) != types.ServerSideEncryptionAwsKms {
+		return nil
+	}
+	return aws.String(params[ServerKMSIDParam])
+}
+
+// encryptionModes returns the parameters of the server side encryption
+// modes, from the least to the most demanding: no encryption headers,
+// which leaves the objects to the default encryption of the bucket,
+// SSE-S3, and SSE-KMS if a key is known. Buckets whose policy requires the
+// encryption headers reject the writes of the preceding modes.
+func (s *s3Store) encryptionModes() []Params {
+	modes := []Params{{}, {ServerEncModeParam: string(types.ServerSideEncryptionAes256)}}
+	if key := cmp.Or(s.params[ServerKMSIDParam], s.kmsKey); key != "" {
+		modes = append(modes, Params{
+			ServerEncModeParam: string(types.ServerSideEncryptionAwsKms),
+			ServerKMSIDParam:   key,
+		})
+	}
+	return modes
+}
+
+// kmsKeyFromEnv returns the KMS key of the SSE-KMS candidates set in the
+// environment, if any.
+func kmsKeyFromEnv(env *env.Env) string {
+	if env.LookupEnv == nil {
+		return ""
+	}
+	key, _ := env.LookupEnv(KMSKeyEnv)
+	return key
+}
+
+// probeEncryption writes an object with each server side encryption mode,
+// and deletes it, to tell which modes the bucket accepts and whether it
+// requires one.
+func (s *s3Store) probeEncryption(ctx context.Context, name string) []Capability {
+	ops := []string{CapUnencrypted, CapSSES3, CapSSEKMS}
+	var res []Capability
+	for i, mode := range s.encryptionModes() {
+		start := time.Now()
+		err := s.putEncrypted(ctx, name, mode)
+		res = append(res, newCapability(ops[i], time.Since(start), err))
+	}
+	return res
+}
+
+// putEncrypted writes an object with the encryption mode of the parameters,
+// and deletes it.
+func (s *s3Store) putEncrypted(ctx context.Context, name string, mode Params) error {
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(s.BucketName()),
+		Key:                  aws.String(path.Join(s.keyPrefix(), name)),
+		Body:                 strings.NewReader(s.objects.content()),
+		Metadata:             s.objects.metadata(),
+		ServerSideEncryption: serverSideEncryption(mode),
+		SSEKMSKeyId:          kmsKeyID(mode),
+		RequestPayer:         s.requestPayer(),
+	}); err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+	if err := s.deleteObject(ctx, name); err != nil {
+		slog.Warn("failed to delete encryption probe object", slog.Any("error", err))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptionCandidates(t *testing.T) {
+	modes := func(s *s3Store) []string {
+		var res []string
+		for candidate := range s.candidateConfigs() {
+			res = append(res, candidate.(*s3Store).params[ServerEncModeParam]+"/"+candidate.(*s3Store).params[ServerKMSIDParam])
+		}
+		return res
+	}
+	got := modes(&s3Store{dest: "bucket/path", params: Params{}, kmsKey: "key"})
+	require.Len(t, got, 24)
+	// Every combination is tried without encryption headers first.
+	for i, mode := range got {
+		want := "/"
+		switch {
+		case i >= 16:
+			want = "aws:kms/key"
+		case i >= 8:
+			want = "AES256/"
+		}
+		assert.Equal(t, want, mode, i)
+	}
+
+	// The encryption set by the user is not varied.
+	got = modes(&s3Store{dest: "bucket/path", params: Params{ServerEncModeParam: "AES256"}, kmsKey: "key"})
+	require.Len(t, got, 8)
+	for _, mode := range got {
+		assert.Equal(t, "AES256/", mode)
+	}
+}
+
+func TestEncryptionRequired(t *testing.T) {
+	tests := []struct {
+		name       string
+		encryption string
+		kmsKey     string
+		want       Params
+		wantCaps   map[string]bool
+		wantErr    bool
+	}{
+		{name: "optional",
+			wantCaps: map[string]bool{CapUnencrypted: true, CapSSES3: true}},
+		{name: "sse-s3", encryption: "AES256",
+			want:     Params{ServerEncModeParam: "AES256"},
+			wantCaps: map[string]bool{CapUnencrypted: false, CapSSES3: true}},
+		{name: "sse-kms", encryption: "aws:kms", kmsKey: "arn:aws:kms:us-east-1:1:key/k",
+			want:     Params{ServerEncModeParam: "aws:kms", ServerKMSIDParam: "arn:aws:kms:us-east-1:1:key/k"},
+			wantCaps: map[string]bool{CapUnencrypted: false, CapSSES3: false, CapSSEKMS: true}},
+		{name: "sse-kms without key", encryption: "aws:kms", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			s, _ := fakeS3Stores(t, &fakeS3{encryption: tt.encryption}, func(s *s3Store) {
+				s.kmsKey = tt.kmsKey
+			})
+			store, err := s.try(context.Background(), s.BucketName())
+			if tt.wantErr {
+				r.Error(err)
+				return
+			}
+			r.NoError(err)
+			params := store.Params()
+			assert.Equal(t, tt.want[ServerEncModeParam], params[ServerEncModeParam])
+			assert.Equal(t, tt.want[ServerKMSIDParam], params[ServerKMSIDParam])
+
+			caps, err := store.Capabilities(context.Background())
+			r.NoError(err)
+			got := make(map[string]bool)
+			for _, c := range caps {
+				if _, ok := tt.wantCaps[c.Operation]; ok {
+					got[c.Operation] = c.Supported
+				}
+			}
+			assert.Equal(t, tt.wantCaps, got)
+		})
+	}
+}
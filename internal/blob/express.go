@@ -0,0 +1,118 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/cockroachdb/errors"
+)
+
+// directoryBucketSuffix ends the names of S3 Express One Zone directory
+// buckets, such as bucket-base-name--usw2-az1--x-s3.
+const directoryBucketSuffix = "--x-s3"
+
+// expressRegions maps the prefix of the availability zone IDs that host
+// directory buckets to their region.
+var expressRegions = map[string]string{
+	"use1":  "us-east-1",
+	"use2":  "us-east-2",
+	"usw2":  "us-west-2",
+	"aps1":  "ap-south-1",
+	"apne1": "ap-northeast-1",
+	"euw1":  "eu-west-1",
+	"eun1":  "eu-north-1",
+}
+
+// Limitation is a constraint of the storage that affects backups and
+// restores.
+type Limitation struct {
+	Feature string
+	Detail  string
+}
+
+// IsDirectoryBucket reports whether the bucket is an S3 Express One Zone
+// directory bucket.
+func IsDirectoryBucket(bucket string) bool {
+	base, ok := strings.CutSuffix(bucket, directoryBucketSuffix)
+	return ok && strings.Contains(base, "--")
+}
+
+// directoryZone returns the availability zone ID of a directory bucket.
+func directoryZone(bucket string) string {
+	base := strings.TrimSuffix(bucket, directoryBucketSuffix)
+	return base[strings.LastIndex(base, "--")+2:]
+}
+
+// expressRegion returns the region of the availability zone of a directory
+// bucket, if known.
+func expressRegion(bucket string) (string, bool) {
+	prefix, _, _ := strings.Cut(directoryZone(bucket), "-")
+	region, ok := expressRegions[prefix]
+	return region, ok
+}
+
+// validateDirectoryBucket checks the parameters that directory buckets do
+// not support.
+func validateDirectoryBucket(bucket string, p Params) error {
+	if p[RegionParam] == DefaultRegion {
+		return errors.WithHintf(
+			errors.Newf("the region of the directory bucket %q is unknown", bucket),
+			"set %s to the region of the availability zone %s", RegionParam, directoryZone(bucket))
+	}
+	if class, ok := p[StorageClassParam]; ok && class != string(types.StorageClassExpressOnezone) {
+		return errors.Newf("directory buckets only support the %s storage class, not %q",
+			types.StorageClassExpressOnezone, class)
+	}
+	if p.Bool(RequesterPaysParam) {
+		return errors.Newf("directory buckets do not support %s", RequesterPaysParam)
+	}
+	return nil
+}
+
+// listPrefix returns the prefix used to list an object. Directory buckets
+// only accept prefixes that end with a delimiter, so the parent directory
+// of the object is listed instead.
+func listPrefix(bucket, key string) string {
+	if !IsDirectoryBucket(bucket) {
+		return key
+	}
+	if dir := path.Dir(key); dir != "." {
+		return dir + "/"
+	}
+	return ""
+}
+
+// Limitations returns the known limitations of the bucket that affect
+// backups and restores.
+func Limitations(bucket string) []Limitation {
+	if !IsDirectoryBucket(bucket) {
+		return nil
+	}
+	return []Limitation{
+		{"availability", "objects are stored in the single availability zone " + directoryZone(bucket) +
+			"; an outage of the zone makes the backups unavailable"},
+		{"authentication", "requests use session credentials obtained with CreateSession, " +
+			"which the S3 client of the cluster must support"},
+		{"addressing", "only virtual hosted requests to the zonal endpoint are supported"},
+		{"listing", "listings are not sorted and only prefixes ending with '/' are supported"},
+		{"object lock", "retention periods and legal holds are not supported"},
+		{"storage class", "only " + string(types.StorageClassExpressOnezone) + " is supported"},
+		{"requester pays", "not supported"},
+	}
+}
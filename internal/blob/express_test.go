@@ -0,0 +1,79 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestIsDirectoryBucket(t *testing.T) {
+	a := assert.New(t)
+	a.True(IsDirectoryBucket("backups--usw2-az1--x-s3"))
+	a.False(IsDirectoryBucket("backups"))
+	a.False(IsDirectoryBucket("backups--x-s3"))
+	a.Equal("usw2-az1", directoryZone("my--backups--usw2-az1--x-s3"))
+	region, ok := expressRegion("backups--use1-az4--x-s3")
+	a.True(ok)
+	a.Equal("us-east-1", region)
+	_, ok = expressRegion("backups--xyz1-az1--x-s3")
+	a.False(ok)
+	a.Empty(Limitations("backups"))
+	a.NotEmpty(Limitations("backups--usw2-az1--x-s3"))
+}
+
+func TestListPrefix(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("path/_blobcheck", listPrefix("bucket", "path/_blobcheck"))
+	a.Equal("path/", listPrefix("backups--usw2-az1--x-s3", "path/_blobcheck"))
+	a.Equal("", listPrefix("backups--usw2-az1--x-s3", "_blobcheck"))
+}
+
+func TestDirectoryBucketParams(t *testing.T) {
+	keys := "AWS_ACCESS_KEY_ID=id&AWS_SECRET_ACCESS_KEY=secret"
+	tests := []struct {
+		name    string
+		uri     string
+		region  string
+		wantErr string
+	}{
+		{name: "region from zone", uri: "s3://backups--usw2-az1--x-s3/path?" + keys, region: "us-west-2"},
+		{name: "explicit region", uri: "s3://backups--xyz1-az1--x-s3/path?AWS_REGION=xy-1&" + keys, region: "xy-1"},
+		{name: "unknown zone", uri: "s3://backups--xyz1-az1--x-s3/path?" + keys, wantErr: "region of the directory bucket"},
+		{name: "storage class", uri: "s3://backups--usw2-az1--x-s3/path?S3_STORAGE_CLASS=STANDARD&" + keys,
+			wantErr: "only support the EXPRESS_ONEZONE storage class"},
+		{name: "requester pays", uri: "s3://backups--usw2-az1--x-s3/path?AWS_REQUESTER_PAYS=true&" + keys,
+			wantErr: "do not support AWS_REQUESTER_PAYS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, dest, err := s3Params(&env.Env{URI: tt.uri})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.region, params[RegionParam])
+			s := &s3Store{dest: dest, params: params}
+			for candidate := range s.candidateConfigs() {
+				assert.False(t, candidate.Params().Bool(UsePathStyleParam))
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// EndpointCheck is the outcome of probing the suggested configuration
+// against one of the endpoints provided by the user.
+type EndpointCheck struct {
+	Endpoint  string
+	Suggested bool     // whether the suggested configuration was found on this endpoint
+	Latency   *Latency // timings of the probe, if it succeeded
+	Err       string   // why the probe failed, if it did
+}
+
+// Works reports whether the endpoint accepts the suggested configuration.
+func (c EndpointCheck) Works() bool {
+	return c.Err == ""
+}
+
+// Failover is the outcome of probing the suggested configuration against
+// each of the endpoints serving the destination, such as the virtual IPs of
+// two gateways behind a DNS name.
+type Failover struct {
+	Endpoints []EndpointCheck
+}
+
+// Survives reports whether the suggested configuration keeps working when
+// the endpoint at index i is unreachable: every other endpoint must accept
+// it, since DNS-based failover sends the requests to any of them.
+func (f *Failover) Survives(i int) bool {
+	if len(f.Endpoints) < 2 {
+		return false
+	}
+	for j, c := range f.Endpoints {
+		if j != i && !c.Works() {
+			return false
+		}
+	}
+	return true
+}
+
+// Viable reports whether the destination survives the outage of any one of
+// its endpoints.
+func (f *Failover) Viable() bool {
+	for i := range f.Endpoints {
+		if !f.Survives(i) {
+			return false
+		}
+	}
+	return len(f.Endpoints) > 1
+}
+
+// SplitEndpoints returns the endpoints in a comma separated list, such as
+// the value of --endpoint.
+func SplitEndpoints(list string) []string {
+	var res []string
+	for endpoint := range strings.SplitSeq(list, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			res = append(res, endpoint)
+		}
+	}
+	return res
+}
+
+// failoverEndpoints returns the endpoints provided by the user, if more
+// than one, with the path prefix of the gateway, if any.
+func failoverEndpoints(env *env.Env) ([]string, error) {
+	endpoints := SplitEndpoints(env.Endpoint)
+	if env.URI != "" || len(endpoints) < 2 {
+		return nil, nil
+	}
+	if env.EndpointPrefix == "" {
+		return endpoints, nil
+	}
+	for i, endpoint := range endpoints {
+		var err error
+		if endpoints[i], err = WithPathPrefix(endpoint, env.EndpointPrefix); err != nil {
+			return nil, err
+		}
+	}
+	return endpoints, nil
+}
+
+// tryEndpoints searches for a working configuration on the endpoints in
+// order, skipping the ones where none works, such as an unreachable
+// gateway.
+func (s *s3Store) tryEndpoints(ctx context.Context) (s3Storage, error) {
+	if len(s.endpoints) == 0 {
+		return s.try(ctx, s.BucketName())
+	}
+	var err error
+	for _, endpoint := range s.endpoints {
+		s.params = s.params.Merge(Params{EndPointParam: endpoint})
+		var store s3Storage
+		if store, err = s.try(ctx, s.BucketName()); err == nil {
+			return store, nil
+		}
+		slog.Warn("no working configuration on the endpoint, skipping it",
+			slog.String("endpoint", endpoint), slog.Any("error", err))
+	}
+	return nil, err
+}
+
+// ProbeEndpoints implements EndpointProber. It probes the suggested
+// configuration against each endpoint, unchanged but for the endpoint
+// itself.
+func (s *s3Store) ProbeEndpoints(ctx context.Context) (*Failover, error) {
+	if len(s.endpoints) == 0 {
+		return nil, nil
+	}
+	res := &Failover{}
+	for _, endpoint := range s.endpoints {
+		alt := &s3Store{
+			dest:        s.dest,
+			root:        s.root,
+			params:      s.params.Merge(Params{EndPointParam: endpoint}),
+			dial:        s.dial,
+			customCA:    s.customCA,
+			bypassProxy: s.bypassProxy,
+		}
+		check := EndpointCheck{Endpoint: endpoint, Suggested: endpoint == s.params[EndPointParam]}
+		if err := s.probe(ctx, alt, s.BucketName()); err != nil {
+			slog.Warn("the suggested configuration fails on the endpoint",
+				slog.String("endpoint", endpoint), slog.Any("error", err))
+			check.Err = err.Error()
+		} else {
+			check.Latency = alt.latency
+		}
+		res.Endpoints = append(res.Endpoints, check)
+	}
+	return res, nil
+}
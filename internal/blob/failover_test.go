@@ -0,0 +1,102 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// TestFailoverEndpoints verifies that the endpoints of a comma separated
+// list are probed in order, skipping the unreachable ones, and that the
+// suggested configuration is then probed against each of them.
+func TestFailoverEndpoints(t *testing.T) {
+	r := require.New(t)
+	down := httptest.NewServer(&fakeS3{})
+	down.Close()
+	readOnly, _ := fakeS3Stores(t, &fakeS3{denyWrites: true})
+	var primary string
+	s, _ := fakeS3Stores(t, &fakeS3{}, func(s *s3Store) {
+		primary = s.params[EndPointParam]
+		delete(s.params, EndPointParam)
+		s.endpoints = []string{down.URL, primary, readOnly.params[EndPointParam]}
+	})
+	store, err := s.tryEndpoints(context.Background())
+	r.NoError(err)
+	r.Equal(primary, store.Params()[EndPointParam])
+
+	failover, err := store.ProbeEndpoints(context.Background())
+	r.NoError(err)
+	r.Len(failover.Endpoints, 3)
+	r.False(failover.Endpoints[0].Works())
+	r.True(failover.Endpoints[1].Works())
+	r.True(failover.Endpoints[1].Suggested)
+	r.False(failover.Endpoints[2].Works())
+	r.False(failover.Viable())
+}
+
+// TestFailoverViable verifies that the failover is viable only if every
+// endpoint accepts the suggested configuration.
+func TestFailoverViable(t *testing.T) {
+	works, fails := EndpointCheck{Endpoint: "a"}, EndpointCheck{Endpoint: "b", Err: "denied"}
+	tests := []struct {
+		name         string
+		endpoints    []EndpointCheck
+		wantSurvives []bool
+		wantViable   bool
+	}{
+		{name: "single", endpoints: []EndpointCheck{works}, wantSurvives: []bool{false}},
+		{name: "all working", endpoints: []EndpointCheck{works, works}, wantSurvives: []bool{true, true}, wantViable: true},
+		{name: "one failing", endpoints: []EndpointCheck{works, fails}, wantSurvives: []bool{false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Failover{Endpoints: tt.endpoints}
+			for i, want := range tt.wantSurvives {
+				assert.Equal(t, want, f.Survives(i))
+			}
+			assert.Equal(t, tt.wantViable, f.Viable())
+		})
+	}
+}
+
+// TestFailoverEndpointsFromEnv verifies that a list of endpoints is split,
+// and that the path prefix is added to each of them.
+func TestFailoverEndpointsFromEnv(t *testing.T) {
+	r := require.New(t)
+	endpoints, err := failoverEndpoints(&env.Env{Endpoint: "http://gw1:9000"})
+	r.NoError(err)
+	r.Nil(endpoints)
+
+	endpoints, err = failoverEndpoints(&env.Env{Endpoint: "http://gw1:9000, http://gw2:9000", EndpointPrefix: "s3proxy"})
+	r.NoError(err)
+	r.Equal([]string{"http://gw1:9000/s3proxy", "http://gw2:9000/s3proxy"}, endpoints)
+
+	lookup := func(key string) (string, bool) {
+		res, ok := map[string]string{AccountParam: "id", SecretParam: "secret"}[key]
+		return res, ok
+	}
+	params, _, err := s3Params(&env.Env{
+		Endpoint: "http://gw1:9000,http://gw2:9000", Path: "bucket/path", LookupEnv: lookup,
+	})
+	r.NoError(err)
+	r.Equal("http://gw1:9000", params[EndPointParam])
+}
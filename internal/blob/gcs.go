@@ -0,0 +1,638 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// CredentialsParam is the base64 encoded JSON key used by the cluster to
+	// authenticate with Google Cloud Storage when AUTH is "specified".
+	CredentialsParam = "CREDENTIALS"
+	// GoogleCredentialsEnv names the file of the credentials used when the
+	// URL does not include them.
+	GoogleCredentialsEnv = "GOOGLE_APPLICATION_CREDENTIALS"
+)
+
+const (
+	// gcsEndpoint is the base URL of the Google Cloud Storage JSON API.
+	gcsEndpoint = "https://storage.googleapis.com"
+	// gcsScope is the OAuth2 scope requested for the access tokens.
+	gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+	// tokenLeeway is how long before its expiry a token is refreshed.
+	tokenLeeway = time.Minute
+)
+
+// GCSValidParams lists the parameters of gs:// URLs supported by blobcheck.
+var GCSValidParams = []string{AuthParam, CredentialsParam}
+
+type gcsStore struct {
+	client   *http.Client
+	token    oauth2.TokenSource // cached access tokens of blobcheck
+	endpoint string             // base URL of the JSON API
+	params   Params
+	dest     string
+	root     string      // destination provided by the user, without the unique sub-path
+	latency  *Latency    // timings of the probe operations, once connected
+	objects  ProbeObject // objects written by the probes
+}
+
+var _ Storage = &gcsStore{}
+
+// GCSFromEnv creates a new Google Cloud Storage store from the
+// environment, under a unique sub-path of the destination, and verifies
+// that it can list, write, read and delete objects.
+func GCSFromEnv(ctx *stopper.Context, env *env.Env) (Storage, error) {
+	s, err := newGCSStore(env)
+	if err != nil {
+		return nil, err
+	}
+	s.dest = path.Join(s.root, DestID(env))
+	if err := s.probe(ctx); err != nil {
+		return nil, errors.Wrapf(err, "unable to connect to storage provider %q", s.root)
+	}
+	return s, nil
+}
+
+// OpenGCS connects to the Google Cloud Storage destination in the
+// environment as is, without adding a unique sub-path, so that existing
+// backups can be inspected.
+func OpenGCS(_ *stopper.Context, env *env.Env) (Storage, error) {
+	s, err := newGCSStore(env)
+	if err != nil {
+		return nil, err
+	}
+	s.dest = s.root
+	return s, nil
+}
+
+// Open connects to the destination in the environment as is, selecting the
+// provider from the scheme of the URI.
+func Open(ctx *stopper.Context, env *env.Env) (Storage, error) {
+	switch {
+	case strings.HasPrefix(env.URI, "gs://"):
+		return OpenGCS(ctx, env)
+	case strings.HasPrefix(env.URI, NodeLocalScheme+"://"):
+		return openLocal(ctx, env, newNodeLocalStore)
+	case strings.HasPrefix(env.URI, UserFileScheme+"://"):
+		return openLocal(ctx, env, newUserFileStore)
+	case IsHTTP(env.URI):
+		return OpenHTTP(ctx, env)
+	}
+	return OpenS3(ctx, env)
+}
+
+// newGCSStore resolves the parameters and the credentials of the
+// destination.
+func newGCSStore(env *env.Env) (*gcsStore, error) {
+	if env.Recording != nil {
+		slog.Warn("recording is only supported for S3 destinations")
+	}
+	params, dest, err := gcsParams(env)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: newTransport(timeoutsFromEnv(env), env.Dial, false)}
+	token, err := localToken(env, params, client)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := gcsEndpoint
+	if env.URI == "" && env.Endpoint != "" {
+		endpoint = strings.TrimSuffix(env.Endpoint, "/")
+	}
+	return &gcsStore{
+		client:   client,
+		token:    token,
+		endpoint: endpoint,
+		params:   params,
+		root:     dest,
+		objects:  ProbeObjectFromEnv(env),
+	}, nil
+}
+
+// gcsParams extracts the parameters and the destination from either the
+// gs:// URI or the path. Unless AUTH is "implicit", the credentials are
+// added to the parameters from the file named by
+// GOOGLE_APPLICATION_CREDENTIALS if the URI does not include them.
+func gcsParams(env *env.Env) (Params, string, error) {
+	params, dest := make(Params), env.Path
+	if env.URI != "" {
+		var err error
+		if params, dest, err = parseGSURL(env.URI); err != nil {
+			return nil, "", err
+		}
+	}
+	if bucket, _, _ := strings.Cut(dest, "/"); !isBucketName(bucket) {
+		return nil, "", errors.Newf("invalid bucket name %q", bucket)
+	}
+	switch auth := params[AuthParam]; auth {
+	case "", AuthSpecified:
+		if params[CredentialsParam] != "" {
+			break
+		}
+		file, ok := env.LookupEnv(GoogleCredentialsEnv)
+		if !ok || file == "" {
+			return nil, "", errors.WithHintf(
+				errors.Newf("%s is not set and %s is not set", CredentialsParam, GoogleCredentialsEnv),
+				"set %s=%s to use the credentials available on the nodes", AuthParam, AuthImplicit)
+		}
+		key, err := os.ReadFile(file)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to read the credentials")
+		}
+		params[CredentialsParam] = base64.StdEncoding.EncodeToString(key)
+	case AuthImplicit:
+		if params[CredentialsParam] != "" {
+			return nil, "", errors.Newf("%s cannot be set when %s is %q", CredentialsParam, AuthParam, auth)
+		}
+	default:
+		return nil, "", errors.Newf("unsupported value %q for %s: expected %s or %s",
+			auth, AuthParam, AuthSpecified, AuthImplicit)
+	}
+	return params, dest, nil
+}
+
+// parseGSURL parses a gs:// URL, returning its parameters and the bucket
+// and path joined.
+func parseGSURL(uri string) (Params, string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "invalid URL")
+	}
+	if parsed.Scheme != "gs" {
+		return nil, "", errors.Newf("unsupported scheme: %q", parsed.Scheme)
+	}
+	if parsed.Opaque != "" || parsed.User != nil || parsed.Fragment != "" {
+		return nil, "", errors.Newf("invalid URL %q: expected gs://bucket/path?params", uri)
+	}
+	query, err := url.ParseQuery(parsed.RawQuery)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "invalid URL parameters")
+	}
+	params := make(Params, len(query))
+	for k, v := range query {
+		if !slices.Contains(GCSValidParams, k) {
+			return nil, "", errors.WithHintf(errors.Newf("unknown parameter %q", k),
+				"supported parameters: %s", strings.Join(GCSValidParams, ", "))
+		}
+		if len(v) > 1 {
+			return nil, "", errors.Newf("parameter %q is repeated", k)
+		}
+		params[k] = v[0]
+	}
+	return params, path.Join(parsed.Host, parsed.Path), nil
+}
+
+// credentialsTypes lists the types of credentials files accepted, all of
+// which are created by the operator: keys of service accounts, application
+// default credentials of users (gcloud auth application-default login), and
+// workload identity federation configurations.
+var credentialsTypes = []google.CredentialsType{
+	google.ServiceAccount,
+	google.AuthorizedUser,
+	google.ExternalAccount,
+	google.ExternalAccountAuthorizedUser,
+	google.ImpersonatedServiceAccount,
+}
+
+// localToken returns the source of the access tokens used by blobcheck: the
+// credentials in the parameters, or, with implicit authentication, those
+// named by GOOGLE_APPLICATION_CREDENTIALS or the application default
+// credentials (the gcloud credentials file or the service account of the
+// instance). The tokens are cached until shortly before they expire.
+func localToken(env *env.Env, params Params, client *http.Client) (oauth2.TokenSource, error) {
+	// The token sources fetch tokens long after this function returns.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	var creds *google.Credentials
+	if encoded := params[CredentialsParam]; encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s: expected a base64 encoded JSON key", CredentialsParam)
+		}
+		if creds, err = credentialsFromJSON(ctx, key); err != nil {
+			return nil, err
+		}
+	} else if file, ok := env.LookupEnv(GoogleCredentialsEnv); ok && file != "" {
+		key, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the credentials")
+		}
+		if creds, err = credentialsFromJSON(ctx, key); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if creds, err = google.FindDefaultCredentials(ctx, gcsScope); err != nil {
+			return nil, errors.Wrap(err, "failed to find the application default credentials")
+		}
+	}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, creds.TokenSource, tokenLeeway), nil
+}
+
+// credentialsFromJSON parses a credentials file of one of the accepted types.
+func credentialsFromJSON(ctx context.Context, key []byte) (*google.Credentials, error) {
+	var file struct {
+		Type google.CredentialsType `json:"type"`
+	}
+	if err := json.Unmarshal(key, &file); err != nil {
+		return nil, errors.Wrap(err, "invalid credentials")
+	}
+	if !slices.Contains(credentialsTypes, file.Type) {
+		return nil, errors.Newf("unsupported credentials type %q: expected one of %s",
+			file.Type, joinTypes(credentialsTypes))
+	}
+	creds, err := google.CredentialsFromJSONWithType(ctx, key, file.Type, gcsScope)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid credentials")
+	}
+	return creds, nil
+}
+
+// joinTypes returns the comma separated list of the credentials types.
+func joinTypes(types []google.CredentialsType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// do sends an authenticated request to the JSON API. Responses other than
+// 2xx are returned as errors, with the message of the API.
+func (s *gcsStore) do(
+	ctx context.Context, method, target string, body io.Reader, header http.Header,
+) (*http.Response, error) {
+	tok, err := s.token.Token()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain an access token")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(req.Header, header)
+	tok.SetAuthHeader(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, apiError(resp)
+	}
+	return resp, nil
+}
+
+// apiError returns the error described by a failed JSON API response.
+func apiError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err := json.Unmarshal(data, &body); err != nil || body.Error.Message == "" {
+		return errors.Newf("%s (HTTP %d)", bytes.TrimSpace(data), resp.StatusCode)
+	}
+	return errors.Newf("%s (HTTP %d)", body.Error.Message, resp.StatusCode)
+}
+
+// objectURL returns the JSON API URL of an object, given its full name.
+func (s *gcsStore) objectURL(name string) string {
+	return s.endpoint + "/storage/v1/b/" + url.PathEscape(s.BucketName()) + "/o/" + url.PathEscape(name)
+}
+
+// put writes an object, given its full name.
+func (s *gcsStore) put(ctx context.Context, name, body string) error {
+	target := s.endpoint + "/upload/storage/v1/b/" + url.PathEscape(s.BucketName()) +
+		"/o?uploadType=media&name=" + url.QueryEscape(name)
+	resp, err := s.do(ctx, http.MethodPost, target, strings.NewReader(body),
+		http.Header{"Content-Type": {"application/octet-stream"}})
+	if err != nil {
+		return errors.Wrapf(err, "failed to put %q", name)
+	}
+	return resp.Body.Close()
+}
+
+// read returns the content of an object, given its full name. If length is
+// positive, only the first length bytes are requested.
+func (s *gcsStore) read(ctx context.Context, name string, length int) ([]byte, error) {
+	header := http.Header{}
+	if length > 0 {
+		header.Set("Range", "bytes=0-"+strconv.Itoa(length-1))
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(name)+"?alt=media", nil, header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %q", name)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", name)
+	}
+	return data, nil
+}
+
+// remove deletes an object, given its full name, without checking its
+// holds.
+func (s *gcsStore) remove(ctx context.Context, name string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(name), nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %q", name)
+	}
+	return resp.Body.Close()
+}
+
+// list returns the objects whose full name starts with the prefix.
+func (s *gcsStore) list(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	pageToken := ""
+	for {
+		query := url.Values{"prefix": {prefix}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		target := s.endpoint + "/storage/v1/b/" + url.PathEscape(s.BucketName()) + "/o?" + query.Encode()
+		resp, err := s.do(ctx, http.MethodGet, target, nil, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list objects")
+		}
+		var page struct {
+			Items []struct {
+				Name    string    `json:"name"`
+				Size    string    `json:"size"`
+				Updated time.Time `json:"updated"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid listing")
+		}
+		for _, item := range page.Items {
+			size, _ := strconv.ParseInt(item.Size, 10, 64)
+			objects = append(objects, Object{
+				Key:          strings.TrimPrefix(item.Name, prefix),
+				Size:         size,
+				LastModified: item.Updated,
+			})
+		}
+		if page.NextPageToken == "" {
+			return objects, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// probe verifies that the store can list, write, read and delete objects,
+// recording the latency of each operation.
+func (s *gcsStore) probe(ctx context.Context) error {
+	var latency Latency
+	name := path.Join(s.keyPrefix(), s.objects.key())
+	start := time.Now()
+	if _, err := s.list(ctx, name); err != nil {
+		return err
+	}
+	latency.List = time.Since(start)
+	start = time.Now()
+	if err := s.put(ctx, name, s.objects.content()); err != nil {
+		return err
+	}
+	latency.Put = time.Since(start)
+	start = time.Now()
+	got, err := s.read(ctx, name, 0)
+	if err != nil {
+		return err
+	}
+	latency.Get = time.Since(start)
+	if string(got) != s.objects.content() {
+		return fmt.Errorf("unexpected content: got %q, want %q", got, s.objects.content())
+	}
+	start = time.Now()
+	if err := s.remove(ctx, name); err != nil {
+		return err
+	}
+	latency.Delete = time.Since(start)
+	s.latency = &latency
+	return nil
+}
+
+// keyPrefix returns the destination path within the bucket.
+func (s *gcsStore) keyPrefix() string {
+	_, prefix, _ := strings.Cut(path.Clean(s.dest), "/")
+	return prefix
+}
+
+// BucketName implements Storage.
+func (s *gcsStore) BucketName() string {
+	bucket, _, _ := strings.Cut(s.dest, "/")
+	return bucket
+}
+
+// ProvidedParams implements Storage. The params are used as provided.
+func (s *gcsStore) ProvidedParams() Params {
+	return nil
+}
+
+// Params implements Storage.
+func (s *gcsStore) Params() Params {
+	params := maps.Clone(s.params)
+	for param := range params {
+		if slices.Contains(ObfuscatedParams, param) {
+			params[param] = Obfuscated
+		}
+	}
+	return params
+}
+
+// URL implements Storage.
+func (s *gcsStore) URL() string {
+	return gsURL(s.dest, s.params)
+}
+
+// RootURL implements Storage.
+func (s *gcsStore) RootURL() string {
+	return gsURL(s.root, s.params)
+}
+
+// gsURL returns the gs:// URL of a destination within a bucket.
+func gsURL(dest string, params Params) string {
+	bucket, prefix, _ := strings.Cut(dest, "/")
+	res := url.URL{Scheme: "gs", Host: bucket, RawQuery: params.Encode()}
+	if prefix != "" {
+		res.Path = "/" + prefix
+	}
+	return res.String()
+}
+
+// Candidates implements Storage. Google Cloud Storage has a single
+// configuration, so there is nothing to rank.
+func (s *gcsStore) Candidates() []Candidate {
+	return nil
+}
+
+// Probed implements Storage.
+func (s *gcsStore) Probed() []Probed {
+	return nil
+}
+
+// CustomCA implements Storage.
+func (s *gcsStore) CustomCA() string {
+	return ""
+}
+
+// Latency implements Storage.
+func (s *gcsStore) Latency() *Latency {
+	return s.latency
+}
+
+// Capabilities implements Storage. The basic operations were verified when
+// connecting; ranged reads and the consistency of overwritten objects are
+// probed with additional objects, which are deleted afterwards. Large
+// objects are written by CockroachDB with resumable uploads, which are not
+// probed.
+func (s *gcsStore) Capabilities(ctx context.Context) ([]Capability, error) {
+	var latency Latency
+	if s.latency != nil {
+		latency = *s.latency
+	}
+	res := []Capability{
+		newCapability(CapList, latency.List, nil),
+		newCapability(CapPut, latency.Put, nil),
+		newCapability(CapGet, latency.Get, nil),
+		newCapability(CapDelete, latency.Delete, nil),
+	}
+	rangeName := path.Join(s.keyPrefix(), s.objects.name("range"))
+	start := time.Now()
+	err := s.probeRange(ctx, rangeName)
+	res = append(res, newCapability(CapRange, time.Since(start), err))
+	overwriteName := path.Join(s.keyPrefix(), s.objects.name("overwrite"))
+	start = time.Now()
+	err = verifyOverwrite(s.objects.content(),
+		func(body string) error { return s.put(ctx, overwriteName, body) },
+		func() ([]byte, error) { return s.read(ctx, overwriteName, 0) },
+		func() error { return s.remove(ctx, overwriteName) },
+	)
+	res = append(res, newCapability(CapOverwrite, time.Since(start), err))
+	return res, nil
+}
+
+// probeRange writes an object and reads its first bytes.
+func (s *gcsStore) probeRange(ctx context.Context, name string) error {
+	if err := s.put(ctx, name, s.objects.content()); err != nil {
+		return err
+	}
+	defer func() {
+		if err := s.remove(ctx, name); err != nil {
+			slog.Warn("failed to delete range probe object", slog.Any("error", err))
+		}
+	}()
+	got, err := s.read(ctx, name, rangeLength)
+	if err != nil {
+		return err
+	}
+	if string(got) != s.objects.content()[:rangeLength] {
+		return errors.Newf("range ignored: got %d bytes, want %d", len(got), rangeLength)
+	}
+	return nil
+}
+
+// Clean implements Storage.
+func (s *gcsStore) Clean(ctx context.Context) error {
+	if s.keyPrefix() == "" {
+		// Never wipe a whole bucket.
+		return errors.New("refusing to clean a destination without a prefix")
+	}
+	objects, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := s.remove(ctx, path.Join(s.keyPrefix(), obj.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete implements Storage.
+func (s *gcsStore) Delete(ctx context.Context, key string) error {
+	name := path.Join(s.keyPrefix(), key)
+	locked, err := s.locked(ctx, name)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return errors.Wrapf(ErrLocked, "cannot delete %q", key)
+	}
+	return s.remove(ctx, name)
+}
+
+// locked returns whether the object is protected by a hold or by the
+// retention policy of the bucket.
+func (s *gcsStore) locked(ctx context.Context, name string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(name), nil, nil)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get the metadata of %q", name)
+	}
+	defer resp.Body.Close()
+	var meta struct {
+		TemporaryHold           bool      `json:"temporaryHold"`
+		EventBasedHold          bool      `json:"eventBasedHold"`
+		RetentionExpirationTime time.Time `json:"retentionExpirationTime"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return false, errors.Wrapf(err, "invalid metadata of %q", name)
+	}
+	return meta.TemporaryHold || meta.EventBasedHold || meta.RetentionExpirationTime.After(time.Now()), nil
+}
+
+// Get implements Storage.
+func (s *gcsStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.read(ctx, path.Join(s.keyPrefix(), key), 0)
+}
+
+// List implements Storage.
+func (s *gcsStore) List(ctx context.Context) ([]Object, error) {
+	prefix := s.keyPrefix()
+	if prefix != "" {
+		prefix += "/"
+	}
+	return s.list(ctx, prefix)
+}
@@ -0,0 +1,312 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// fakeGCS serves the token endpoint and the subset of the JSON API used by
+// the store, storing the objects in memory.
+type fakeGCS struct {
+	mu      sync.Mutex
+	objects map[string]string
+	held    map[string]bool // objects with a temporary hold
+	tokens  int             // number of tokens issued
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.objects == nil {
+		f.objects = make(map[string]string)
+	}
+	if req.URL.Path == "/token" {
+		_ = req.ParseForm()
+		switch {
+		case req.Form.Get("grant_type") == "urn:ietf:params:oauth:grant-type:token-exchange" &&
+			req.Form.Get("subject_token") == "subject":
+			// Workload identity federation exchanges the external token.
+			f.tokens++
+			fmt.Fprint(w, `{"access_token":"token","issued_token_type":"urn:ietf:params:oauth:token-type:access_token",`+
+				`"token_type":"Bearer","expires_in":3600}`)
+			return
+		case req.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" ||
+			strings.Count(req.Form.Get("assertion"), ".") != 2:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_grant"}`)
+			return
+		}
+		f.tokens++
+		fmt.Fprint(w, `{"access_token":"token","expires_in":3600}`)
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"code":401,"message":"Invalid Credentials"}}`)
+		return
+	}
+	if rest, ok := strings.CutPrefix(req.URL.Path, "/upload/storage/v1/b/bucket/o"); ok && rest == "" {
+		body, _ := io.ReadAll(req.Body)
+		f.objects[req.URL.Query().Get("name")] = string(body)
+		fmt.Fprint(w, `{}`)
+		return
+	}
+	name, ok := strings.CutPrefix(req.URL.EscapedPath(), "/storage/v1/b/bucket/o")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if name == "" {
+		prefix := req.URL.Query().Get("prefix")
+		var items []map[string]string
+		for _, key := range slices.Sorted(func(yield func(string) bool) {
+			for k := range f.objects {
+				if !yield(k) {
+					return
+				}
+			}
+		}) {
+			if strings.HasPrefix(key, prefix) {
+				items = append(items, map[string]string{"name": key, "size": fmt.Sprint(len(f.objects[key]))})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items})
+		return
+	}
+	name, _ = url.PathUnescape(strings.TrimPrefix(name, "/"))
+	body, found := f.objects[name]
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"code":404,"message":"No such object"}}`)
+		return
+	}
+	switch {
+	case req.Method == http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	case req.URL.Query().Get("alt") == "media" && req.Header.Get("Range") != "":
+		w.WriteHeader(http.StatusPartialContent)
+		fmt.Fprint(w, body[:rangeLength])
+	case req.URL.Query().Get("alt") == "media":
+		fmt.Fprint(w, body)
+	default:
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": name, "temporaryHold": f.held[name]})
+	}
+}
+
+// writeServiceAccount writes a service account key whose token endpoint is
+// the fake server, and returns the name of the file.
+func writeServiceAccount(t *testing.T, tokenURI string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: mustPKCS8(t, key)})
+	data, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "blobcheck@project.iam.gserviceaccount.com",
+		"private_key":  string(pemKey),
+		"token_uri":    tokenURI,
+	})
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(file, data, 0600))
+	return file
+}
+
+func mustPKCS8(t *testing.T, key *rsa.PrivateKey) []byte {
+	data, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return data
+}
+
+func TestGCSParams(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"type":"service_account"}`), 0600))
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"type":"service_account"}`))
+	withFile := func(key string) (string, bool) {
+		if key == GoogleCredentialsEnv {
+			return file, true
+		}
+		return "", false
+	}
+	noFile := func(string) (string, bool) { return "", false }
+	tests := []struct {
+		name    string
+		uri     string
+		lookup  env.LookupEnv
+		want    Params
+		wantErr string
+	}{
+		{name: "credentials from file", uri: "gs://bucket/path", lookup: withFile,
+			want: Params{CredentialsParam: encoded}},
+		{name: "credentials in URL", uri: "gs://bucket/path?CREDENTIALS=" + url.QueryEscape(encoded), lookup: noFile,
+			want: Params{CredentialsParam: encoded}},
+		{name: "implicit", uri: "gs://bucket/path?AUTH=implicit", lookup: withFile,
+			want: Params{AuthParam: AuthImplicit}},
+		{name: "no credentials", uri: "gs://bucket/path", lookup: noFile, wantErr: "GOOGLE_APPLICATION_CREDENTIALS is not set"},
+		{name: "bad auth", uri: "gs://bucket/path?AUTH=iam", lookup: noFile, wantErr: `unsupported value "iam" for AUTH`},
+		{name: "unknown", uri: "gs://bucket/path?AWS_REGION=x", lookup: noFile, wantErr: `unknown parameter "AWS_REGION"`},
+		{name: "scheme", uri: "s3://bucket/path", lookup: noFile, wantErr: "unsupported scheme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, dest, err := gcsParams(&env.Env{URI: tt.uri, LookupEnv: tt.lookup})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, params)
+			assert.Equal(t, "bucket/path", dest)
+		})
+	}
+}
+
+func TestGCSStore(t *testing.T) {
+	r := require.New(t)
+	fake := &fakeGCS{held: map[string]bool{"path/held": true}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	file := writeServiceAccount(t, server.URL+"/token")
+	ctx := stopper.WithContext(t.Context())
+
+	store, err := GCSFromEnv(ctx, &env.Env{
+		Endpoint: server.URL,
+		Path:     "bucket/path",
+		LookupEnv: func(key string) (string, bool) {
+			if key == GoogleCredentialsEnv {
+				return file, true
+			}
+			return "", false
+		},
+	})
+	r.NoError(err)
+	r.Equal("bucket", store.BucketName())
+	r.NotNil(store.Latency())
+	r.Equal(Obfuscated, store.Params()[CredentialsParam])
+	u, err := url.Parse(store.URL())
+	r.NoError(err)
+	r.Equal("gs", u.Scheme)
+	r.Equal("bucket", u.Host)
+	r.True(strings.HasPrefix(u.Path, "/path/"))
+	r.NotEmpty(u.Query().Get(CredentialsParam))
+	r.Equal("gs://bucket/path?"+u.RawQuery, store.RootURL())
+
+	capabilities, err := store.Capabilities(ctx)
+	r.NoError(err)
+	for _, c := range capabilities {
+		r.True(c.Supported, "%s: %s", c.Operation, c.Err)
+	}
+	_, ok := store.(KeyProber)
+	r.False(ok, "the key probe requires an S3 destination")
+
+	gcs := store.(*gcsStore)
+	r.NoError(gcs.put(ctx, gcs.keyPrefix()+"/a b", "data"))
+	objects, err := store.List(ctx)
+	r.NoError(err)
+	r.Equal([]Object{{Key: "a b", Size: 4}}, objects)
+	got, err := store.Get(ctx, "a b")
+	r.NoError(err)
+	r.Equal("data", string(got))
+	r.NoError(store.Clean(ctx))
+	objects, err = store.List(ctx)
+	r.NoError(err)
+	r.Empty(objects)
+	// The token is cached across requests.
+	r.Equal(1, fake.tokens)
+
+	// Objects with a hold cannot be deleted.
+	root, err := OpenGCS(ctx, &env.Env{URI: "gs://bucket/path?AUTH=implicit", LookupEnv: func(key string) (string, bool) {
+		if key == GoogleCredentialsEnv {
+			return file, true
+		}
+		return "", false
+	}})
+	r.NoError(err)
+	root.(*gcsStore).endpoint = server.URL
+	r.NoError(root.(*gcsStore).put(ctx, "path/held", "data"))
+	r.ErrorIs(root.Delete(ctx, "held"), ErrLocked)
+}
+
+func TestLocalToken(t *testing.T) {
+	fake := &fakeGCS{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	dir := t.TempDir()
+	subject := filepath.Join(dir, "subject")
+	require.NoError(t, os.WriteFile(subject, []byte("subject"), 0600))
+	external, err := json.Marshal(map[string]any{
+		"type":               "external_account",
+		"audience":           "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/aws",
+		"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+		"token_url":          server.URL + "/token",
+		"credential_source":  map[string]string{"file": subject},
+	})
+	require.NoError(t, err)
+	encode := func(data []byte) Params {
+		return Params{CredentialsParam: base64.StdEncoding.EncodeToString(data)}
+	}
+	key, err := os.ReadFile(writeServiceAccount(t, server.URL+"/token"))
+	require.NoError(t, err)
+	noFile := func(string) (string, bool) { return "", false }
+
+	tests := []struct {
+		name    string
+		params  Params
+		wantErr string
+	}{
+		{name: "service account", params: encode(key)},
+		{name: "external account", params: encode(external)},
+		{name: "unsupported", params: encode([]byte(`{"type":"gdch_service_account"}`)),
+			wantErr: `unsupported credentials type "gdch_service_account"`},
+		{name: "malformed", params: encode([]byte(`{`)), wantErr: "invalid credentials"},
+		{name: "not base64", params: Params{CredentialsParam: "%"}, wantErr: "base64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := localToken(&env.Env{LookupEnv: noFile}, tt.params, server.Client())
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tok, err := source.Token()
+			require.NoError(t, err)
+			assert.Equal(t, "token", tok.AccessToken)
+		})
+	}
+}
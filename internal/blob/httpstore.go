@@ -0,0 +1,562 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// Flags of the candidate configurations of HTTPS file servers. They are
+// not URL parameters: CockroachDB verifies the certificate of the server
+// with the system roots, or with the CA set in the cloudstorage.http.custom_ca
+// cluster setting, and cannot skip the verification.
+const (
+	// HTTPCustomCA marks the configuration that verifies the server with
+	// the CA bundle provided with --ca-cert.
+	HTTPCustomCA = "CUSTOM_CA"
+	// HTTPSkipTLSVerify marks the configuration that does not verify the
+	// certificate of the server.
+	HTTPSkipTLSVerify = "SKIP_TLS_VERIFY"
+)
+
+// CustomCASetting is the cluster setting holding the CA certificate used by
+// the nodes to verify HTTPS file servers and S3 endpoints.
+const CustomCASetting = "cloudstorage.http.custom_ca"
+
+// httpStore is a plain HTTP(S) file server, such as nginx with WebDAV
+// methods enabled, which stores the files with PUT, serves them with GET
+// and removes them with DELETE.
+type httpStore struct {
+	client   *http.Client
+	base     *url.URL // scheme and host of the server
+	dest     string   // path of the destination on the server, without a trailing slash
+	root     string   // destination provided by the user, without the unique sub-path
+	flags    Params   // flags of the selected configuration
+	latency  *Latency
+	ranked   []Candidate // working configurations, if ranking is enabled
+	timeouts Timeouts
+	dial     env.DialFunc
+	caFile   string      // CA certificate provided by the user, if any
+	strict   bool        // never skip the verification of the certificate, with --strict
+	objects  ProbeObject // objects written by the probes
+}
+
+var _ Storage = &httpStore{}
+
+// HTTPFromEnv creates a new store for an http:// or https:// URI, under a
+// unique sub-path of the destination. For HTTPS servers, it looks for a
+// configuration that can write, read and delete files, verifying the
+// certificate of the server with the system roots, then with the custom CA,
+// if provided, and finally without verification.
+func HTTPFromEnv(ctx *stopper.Context, env *env.Env) (Storage, error) {
+	s, err := newHTTPStore(env)
+	if err != nil {
+		return nil, err
+	}
+	s.dest = path.Join(s.root, DestID(env))
+	run := func(ctx context.Context, alt *httpStore) error {
+		probeCtx, done := withProbeTimeout(ctx, s.timeouts)
+		return done(alt.probe(probeCtx))
+	}
+	approver := approverFromEnv(env)
+	if approver != nil {
+		run = withApproval(approver, run)
+	}
+	var mu sync.Mutex
+	var lastErr error
+	probe := func(ctx context.Context, alt *httpStore) error {
+		err := run(ctx, alt)
+		if err != nil && ctx.Err() == nil {
+			slog.Debug("configuration failed", slog.Any("flags", alt.flags), slog.Any("error", err))
+			mu.Lock()
+			lastErr = err
+			mu.Unlock()
+		}
+		return err
+	}
+	ranked, working, ok, err := searchCandidates(ctx, s.candidateConfigs(), probe, env.RankCandidates, approver != nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		err := approver.withSkippedHint(errors.Wrapf(lastErr, "unable to connect to storage %q", s.RootURL()))
+		if s.strict {
+			return nil, withStrictHint(err, []string{HTTPSkipTLSVerify})
+		}
+		return nil, err
+	}
+	working.ranked = ranked
+	switch {
+	case working.flags.Bool(HTTPSkipTLSVerify):
+		slog.Warn("the certificate of the server could not be verified: CockroachDB cannot skip the verification",
+			slog.String("hint", "set the cluster setting "+CustomCASetting+" to the CA of the server"))
+	case working.flags.Bool(HTTPCustomCA):
+		slog.Warn("the server is verified with the custom CA only",
+			slog.String("hint", "set the cluster setting "+CustomCASetting+" to the content of "+s.caFile))
+	}
+	return working, nil
+}
+
+// OpenHTTP connects to the file server in the environment as is, without
+// adding a unique sub-path, verifying its certificate with the custom CA,
+// if provided.
+func OpenHTTP(_ *stopper.Context, env *env.Env) (Storage, error) {
+	s, err := newHTTPStore(env)
+	if err != nil {
+		return nil, err
+	}
+	s.dest = s.root
+	flags := Params{}
+	if s.caFile != "" {
+		flags[HTTPCustomCA] = "true"
+	}
+	return s.withFlags(flags), nil
+}
+
+// IsHTTP returns whether the URI is served by a plain HTTP(S) file server.
+func IsHTTP(uri string) bool {
+	return strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://")
+}
+
+// HTTPEndpoint returns the scheme and host of a file server URL, or an
+// empty string if the URI is not served by a file server.
+func HTTPEndpoint(uri string) string {
+	if !IsHTTP(uri) {
+		return ""
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// newHTTPStore parses the http:// or https:// URI of the environment.
+func newHTTPStore(env *env.Env) (*httpStore, error) {
+	if env.Recording != nil {
+		slog.Warn("recording is only supported for S3 destinations")
+	}
+	parsed, err := url.Parse(env.URI)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL")
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.Newf("invalid URL %q: expected http(s)://host[:port]/path", env.URI)
+	}
+	if parsed.User != nil || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return nil, errors.Newf("invalid URL %q: file server URLs have no credentials or parameters", env.URI)
+	}
+	if env.CACert != "" && parsed.Scheme != "https" {
+		return nil, errors.New("a CA certificate can only be used with https:// URLs")
+	}
+	root := strings.TrimSuffix(path.Clean("/"+parsed.Path), "/")
+	if root == "" {
+		return nil, errors.Newf("invalid URL %q: a path is required", env.URI)
+	}
+	caFile, err := caBundle(env)
+	if err != nil {
+		return nil, err
+	}
+	return &httpStore{
+		base:     &url.URL{Scheme: parsed.Scheme, Host: parsed.Host},
+		root:     root,
+		timeouts: timeoutsFromEnv(env),
+		dial:     env.Dial,
+		caFile:   caFile,
+		strict:   env.Strict || env.StrictChecksum,
+		objects:  ProbeObjectFromEnv(env),
+	}, nil
+}
+
+// candidateConfigs returns the configurations to try, from the most to the
+// least secure.
+func (s *httpStore) candidateConfigs() iter.Seq[Storage] {
+	res := []Params{{}}
+	if s.base.Scheme == "https" {
+		if s.caFile != "" {
+			res = append(res, Params{HTTPCustomCA: "true"})
+		}
+		if !s.strict {
+			res = append(res, Params{HTTPSkipTLSVerify: "true"})
+		}
+	}
+	return func(yield func(Storage) bool) {
+		for _, flags := range res {
+			if !yield(s.withFlags(flags)) {
+				return
+			}
+		}
+	}
+}
+
+// candidateFlags returns the flags of the configuration.
+func (s *httpStore) candidateFlags() Params {
+	return s.flags
+}
+
+// candidate scores the configuration, once it passed the probe.
+func (s *httpStore) candidate(latency time.Duration) Candidate {
+	return newHTTPCandidate(s.flags, latency)
+}
+
+// newHTTPCandidate scores a configuration that passed the probe. File
+// servers have no checksums: verifying the certificate of the server is
+// worth all the points.
+func newHTTPCandidate(flags Params, latency time.Duration) Candidate {
+	c := Candidate{Flags: flags, Latency: latency}
+	if !flags.Bool(HTTPSkipTLSVerify) {
+		c.Security = MaxSecurity
+	}
+	return c
+}
+
+// withFlags returns a copy of the store using the given configuration.
+func (s *httpStore) withFlags(flags Params) *httpStore {
+	alt := *s
+	alt.flags = flags
+	transport := newTransport(s.timeouts, s.dial, flags.Bool(HTTPSkipTLSVerify))
+	if flags.Bool(HTTPCustomCA) {
+		transport.TLSClientConfig = &tls.Config{RootCAs: loadCA(s.caFile)}
+	}
+	alt.client = &http.Client{Transport: transport}
+	return &alt
+}
+
+// loadCA returns a pool with the CA certificates of the PEM file. Failures
+// are logged, and result in an empty pool, which rejects every server.
+func loadCA(file string) *x509.CertPool {
+	pool := x509.NewCertPool()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		slog.Error("failed to read the CA certificate", slog.Any("error", err))
+		return pool
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		slog.Error("no PEM encoded certificate found", slog.String("file", file))
+	}
+	return pool
+}
+
+// fileURL returns the URL of a file, given its path on the server.
+func (s *httpStore) fileURL(name string) string {
+	return s.base.JoinPath(name).String()
+}
+
+// do sends a request to the server. Responses other than 2xx are returned
+// as errors.
+func (s *httpStore) do(
+	ctx context.Context, method, target string, body io.Reader, header http.Header,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, classifyTimeout(err, s.timeouts)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp, errors.Newf("%s %s: %s %s", method, target, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
+
+// put writes a file, given its path on the server.
+func (s *httpStore) put(ctx context.Context, name, body string) error {
+	resp, err := s.do(ctx, http.MethodPut, s.fileURL(name), strings.NewReader(body), nil)
+	if resp != nil && resp.StatusCode == http.StatusConflict {
+		return errors.WithHint(errors.Wrapf(err, "failed to put %q", name),
+			"the server must create the missing directories, e.g. with create_full_put_path on nginx")
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to put %q", name)
+	}
+	return resp.Body.Close()
+}
+
+// read returns the content of a file, given its path on the server. If
+// length is positive, only the first length bytes are requested.
+func (s *httpStore) read(ctx context.Context, name string, length int) ([]byte, error) {
+	header := http.Header{}
+	if length > 0 {
+		header.Set("Range", "bytes=0-"+strconv.Itoa(length-1))
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.fileURL(name), nil, header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %q", name)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", name)
+	}
+	return data, nil
+}
+
+// remove deletes a file or a directory, given its path on the server.
+// Missing files are ignored.
+func (s *httpStore) remove(ctx context.Context, name string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.fileURL(name), nil, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %q", name)
+	}
+	return resp.Body.Close()
+}
+
+// multistatus is the response of WebDAV servers to PROPFIND requests.
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				ContentLength int64  `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+				ResourceType  struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// list returns the files under a directory, given its path on the server
+// with a trailing slash, walking its subdirectories with WebDAV PROPFIND
+// requests. Keys are relative to prefix.
+func (s *httpStore) list(ctx context.Context, dir, prefix string) ([]Object, error) {
+	resp, err := s.do(ctx, "PROPFIND", s.fileURL(dir), nil, http.Header{"Depth": {"1"}})
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return nil, nil
+		case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			return nil, errors.Wrap(ErrUnsupported, "the file server does not support WebDAV listings")
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+	defer resp.Body.Close()
+	var body multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errors.Wrap(err, "invalid listing")
+	}
+	var objects []Object
+	for _, r := range body.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid listing entry %q", r.Href)
+		}
+		name := href.Path
+		if strings.TrimSuffix(name, "/") == strings.TrimSuffix(dir, "/") || len(r.Propstat) == 0 {
+			continue
+		}
+		prop := r.Propstat[0].Prop
+		if prop.ResourceType.Collection != nil {
+			children, err := s.list(ctx, strings.TrimSuffix(name, "/")+"/", prefix)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, children...)
+			continue
+		}
+		modified, _ := http.ParseTime(prop.LastModified)
+		objects = append(objects, Object{
+			Key:          strings.TrimPrefix(name, prefix),
+			Size:         prop.ContentLength,
+			LastModified: modified,
+		})
+	}
+	return objects, nil
+}
+
+// probe verifies that the configuration can write, read and delete files,
+// the operations used by CockroachDB, recording the latency of each
+// operation. Listing is not used by CockroachDB, and is not probed.
+func (s *httpStore) probe(ctx context.Context) error {
+	var latency Latency
+	name := path.Join(s.dest, s.objects.key())
+	start := time.Now()
+	if err := s.put(ctx, name, s.objects.content()); err != nil {
+		return err
+	}
+	latency.Put = time.Since(start)
+	start = time.Now()
+	got, err := s.read(ctx, name, 0)
+	if err != nil {
+		return err
+	}
+	latency.Get = time.Since(start)
+	if string(got) != s.objects.content() {
+		return fmt.Errorf("unexpected content: got %q, want %q", got, s.objects.content())
+	}
+	start = time.Now()
+	if err := s.remove(ctx, name); err != nil {
+		return err
+	}
+	latency.Delete = time.Since(start)
+	s.latency = &latency
+	return nil
+}
+
+// BucketName implements Storage. It returns the host of the server.
+func (s *httpStore) BucketName() string {
+	return s.base.Host
+}
+
+// Params implements Storage. File server URLs have no parameters.
+func (s *httpStore) Params() Params {
+	return Params{}
+}
+
+// ProvidedParams implements Storage.
+func (s *httpStore) ProvidedParams() Params {
+	return nil
+}
+
+// URL implements Storage.
+func (s *httpStore) URL() string {
+	return s.fileURL(s.dest)
+}
+
+// RootURL implements Storage.
+func (s *httpStore) RootURL() string {
+	return s.fileURL(s.root)
+}
+
+// Candidates implements Storage.
+func (s *httpStore) Candidates() []Candidate {
+	return s.ranked
+}
+
+// Probed implements Storage. The configurations only differ in the
+// verification of the certificate, which the URL does not carry, so the
+// cluster cannot check them one by one.
+func (s *httpStore) Probed() []Probed {
+	return nil
+}
+
+// CustomCA implements Storage.
+func (s *httpStore) CustomCA() string {
+	if s.flags.Bool(HTTPCustomCA) {
+		return s.caFile
+	}
+	return ""
+}
+
+// Latency implements Storage.
+func (s *httpStore) Latency() *Latency {
+	return s.latency
+}
+
+// Capabilities implements Storage. Writes, reads and deletes were verified
+// when connecting; WebDAV listings, ranged reads, used by restores to
+// resume interrupted reads, and the consistency of overwritten files are
+// probed with additional files, which are deleted afterwards.
+func (s *httpStore) Capabilities(ctx context.Context) ([]Capability, error) {
+	var latency Latency
+	if s.latency != nil {
+		latency = *s.latency
+	}
+	start := time.Now()
+	_, err := s.List(ctx)
+	res := []Capability{
+		newCapability(CapList, time.Since(start), err),
+		newCapability(CapPut, latency.Put, nil),
+		newCapability(CapGet, latency.Get, nil),
+		newCapability(CapDelete, latency.Delete, nil),
+	}
+	rangeName := path.Join(s.dest, s.objects.name("range"))
+	start = time.Now()
+	err = s.probeRange(ctx, rangeName)
+	res = append(res, newCapability(CapRange, time.Since(start), err))
+	overwriteName := path.Join(s.dest, s.objects.name("overwrite"))
+	start = time.Now()
+	err = verifyOverwrite(s.objects.content(),
+		func(body string) error { return s.put(ctx, overwriteName, body) },
+		func() ([]byte, error) { return s.read(ctx, overwriteName, 0) },
+		func() error { return s.remove(ctx, overwriteName) },
+	)
+	res = append(res, newCapability(CapOverwrite, time.Since(start), err))
+	return res, nil
+}
+
+// probeRange writes a file and reads its first bytes.
+func (s *httpStore) probeRange(ctx context.Context, name string) error {
+	if err := s.put(ctx, name, s.objects.content()); err != nil {
+		return err
+	}
+	defer func() {
+		if err := s.remove(ctx, name); err != nil {
+			slog.Warn("failed to delete range probe object", slog.Any("error", err))
+		}
+	}()
+	got, err := s.read(ctx, name, rangeLength)
+	if err != nil {
+		return err
+	}
+	if string(got) != s.objects.content()[:rangeLength] {
+		return errors.Newf("range ignored: got %d bytes, want %d", len(got), rangeLength)
+	}
+	return nil
+}
+
+// Clean implements Storage. The directory of the destination is deleted
+// with its content, which WebDAV servers support without listing it.
+func (s *httpStore) Clean(ctx context.Context) error {
+	return s.remove(ctx, s.dest+"/")
+}
+
+// Delete implements Storage. File servers cannot lock files.
+func (s *httpStore) Delete(ctx context.Context, key string) error {
+	return s.remove(ctx, path.Join(s.dest, key))
+}
+
+// Get implements Storage.
+func (s *httpStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.read(ctx, path.Join(s.dest, key), 0)
+}
+
+// List implements Storage. It requires a server supporting WebDAV
+// PROPFIND requests, and returns ErrUnsupported otherwise.
+func (s *httpStore) List(ctx context.Context) ([]Object, error) {
+	return s.list(ctx, s.dest+"/", s.dest+"/")
+}
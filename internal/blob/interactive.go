@@ -0,0 +1,137 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/prompt"
+)
+
+// Answers to the prompt of --interactive. Skipping is the default, so that
+// nothing is sent to the storage unless the operator approves it.
+const (
+	answerSkip  = "skip"
+	answerProbe = "probe"
+	answerQuit  = "quit"
+)
+
+// errSkipped is returned by the probes of the configurations skipped by the
+// operator.
+var errSkipped = errors.New("candidate configuration skipped by the operator")
+
+// approver asks the operator before each candidate configuration is probed.
+type approver struct {
+	in      *bufio.Reader
+	out     io.Writer
+	mu      sync.Mutex
+	skipped int // configurations skipped so far
+}
+
+// approverFromEnv returns the approver of --interactive, or nil to probe
+// every candidate configuration without asking.
+func approverFromEnv(env *env.Env) *approver {
+	if !env.Interactive || env.Stdin == nil || env.Stdout == nil {
+		return nil
+	}
+	in, ok := env.Stdin.(*bufio.Reader)
+	if !ok {
+		in = bufio.NewReader(env.Stdin)
+	}
+	return &approver{in: in, out: env.Stdout}
+}
+
+// approve shows the flags of a configuration and asks whether it may be
+// probed. It returns errSkipped if the operator skips it, marked with
+// errAbort if the operator quits.
+func (a *approver) approve(flags Params) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	question := fmt.Sprintf("probe the candidate configuration %s?", describeFlags(flags))
+	switch prompt.Choose(a.in, a.out, question, answerSkip, answerProbe, answerQuit) {
+	case answerProbe:
+		return nil
+	case answerQuit:
+		a.skipped++
+		return errors.Mark(errors.Wrap(errSkipped, "probing stopped by the operator"), errAbort)
+	default:
+		a.skipped++
+		slog.Info("candidate configuration skipped", slog.Any("flags", flags))
+		return errSkipped
+	}
+}
+
+// withApproval returns a probe that only runs once the operator approves
+// the configuration.
+func withApproval[T candidateConfig](a *approver, probe func(context.Context, T) error) func(context.Context, T) error {
+	return func(ctx context.Context, alt T) error {
+		if err := a.approve(alt.candidateFlags()); err != nil {
+			return err
+		}
+		return probe(ctx, alt)
+	}
+}
+
+// withSkippedHint tells the user that configurations were skipped, so that
+// they may be the ones that work.
+func (a *approver) withSkippedHint(err error) error {
+	if a == nil || a.skipped == 0 {
+		return err
+	}
+	return errors.WithHintf(err, "%d candidate configurations were skipped at the prompt of --interactive", a.skipped)
+}
+
+// describeFlags returns the flags in a stable order, for the prompt.
+func describeFlags(flags Params) string {
+	if len(flags) == 0 {
+		return "with the provided parameters"
+	}
+	var res []string
+	for _, key := range slices.Sorted(maps.Keys(flags)) {
+		res = append(res, key+"="+flags[key])
+	}
+	return strings.Join(res, " ")
+}
+
+// selectSequential returns the first candidate configuration accepted by
+// the probe, probing the candidates one at a time so that the operator is
+// asked about each of them in order, and only until one works.
+func selectSequential[T candidateConfig](
+	ctx context.Context, candidates iter.Seq[Storage], probe func(context.Context, T) error,
+) (T, bool, error) {
+	var none T
+	for candidate := range candidates {
+		alt := candidate.(T)
+		err := probe(ctx, alt)
+		if err == nil {
+			return alt, true, nil
+		}
+		if errors.Is(err, errAbort) {
+			return none, false, err
+		}
+	}
+	return none, false, nil
+}
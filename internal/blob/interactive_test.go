@@ -0,0 +1,104 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// newTestApprover returns an approver reading the answers.
+func newTestApprover(answers string) (*approver, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return approverFromEnv(&env.Env{Interactive: true, Stdin: strings.NewReader(answers), Stdout: out}), out
+}
+
+func TestApproverFromEnv(t *testing.T) {
+	a := assert.New(t)
+	a.Nil(approverFromEnv(&env.Env{}))
+	a.Nil(approverFromEnv(&env.Env{Stdin: strings.NewReader(""), Stdout: &bytes.Buffer{}}))
+	approver, _ := newTestApprover("")
+	a.NotNil(approver)
+}
+
+func TestInteractiveSelection(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+	initial := &s3Store{dest: "bucket/key", params: Params{RegionParam: "us-east-1"}}
+	approver, out := newTestApprover("probe\nskip\ns\n\nprobe\n")
+	// The provider only accepts path style requests.
+	var probed []Params
+	probe := withApproval(approver, func(_ context.Context, alt *s3Store) error {
+		probed = append(probed, alt.candidateFlags())
+		if !alt.params.Bool(UsePathStyleParam) {
+			return errors.New("no such host")
+		}
+		return nil
+	})
+
+	alt, ok, err := selectSequential(t.Context(), initial.candidateConfigs(), probe)
+	r.NoError(err)
+	r.True(ok)
+	a.Equal(Params{UsePathStyleParam: "true"}, alt.candidateFlags())
+	// The candidates skipping the checksums or the TLS verification are
+	// never probed.
+	a.Equal([]Params{{}, {UsePathStyleParam: "true"}}, probed)
+	a.Equal(3, approver.skipped)
+	a.Equal("probe the candidate configuration with the provided parameters? [skip/probe/quit] "+
+		"probe the candidate configuration AWS_SKIP_CHECKSUM=true? [skip/probe/quit] "+
+		"probe the candidate configuration AWS_SKIP_TLS_VERIFY=true? [skip/probe/quit] "+
+		"probe the candidate configuration AWS_SKIP_CHECKSUM=true AWS_SKIP_TLS_VERIFY=true? [skip/probe/quit] "+
+		"probe the candidate configuration AWS_USE_PATH_STYLE=true? [skip/probe/quit] ", out.String())
+	hints := errors.GetAllHints(approver.withSkippedHint(errors.New("unable to connect")))
+	a.Equal([]string{"3 candidate configurations were skipped at the prompt of --interactive"}, hints)
+}
+
+func TestInteractiveQuit(t *testing.T) {
+	a := assert.New(t)
+	initial := &s3Store{dest: "bucket/key", params: Params{RegionParam: "us-east-1"}}
+	approver, _ := newTestApprover("skip\nquit\n")
+	probe := withApproval(approver, func(context.Context, *s3Store) error {
+		a.Fail("no configuration is approved")
+		return nil
+	})
+	_, ok, err := selectSequential(t.Context(), initial.candidateConfigs(), probe)
+	a.False(ok)
+	a.True(errors.Is(err, errAbort))
+	a.True(errors.Is(err, errSkipped))
+}
+
+func TestInteractiveRank(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+	initial := &s3Store{dest: "bucket/key", params: Params{RegionParam: "us-east-1"}}
+	// Only the first candidate is approved; the input then ends, which
+	// skips the others.
+	approver, _ := newTestApprover("p\n")
+	ranked, _, _, err := probeAll(t.Context(), initial.candidateConfigs(), withApproval(approver,
+		initial.recordProbed(func(context.Context, *s3Store) error { return nil })))
+	r.NoError(err)
+	a.Len(ranked, 1)
+	// The skipped configurations are not checked from the cluster either.
+	a.Len(initial.probed, 1)
+	a.Equal(15, approver.skipped)
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/cockroachdb/errors"
+)
+
+// MaxKeyLength is the maximum length, in bytes, of an S3 object key.
+const MaxKeyLength = 1024
+
+// Character classes verified by the key probe.
+const (
+	KeysEncoded = "percent-encoded"
+	KeysPlus    = "plus"
+	KeysSpace   = "space"
+	KeysUnicode = "unicode"
+	KeysSpecial = "punctuation"
+	KeysLong    = "max length"
+)
+
+// keyClasses are the names probed for each character class. Backup file
+// names include timestamps and encoded metadata, which some appliances
+// reject or rewrite.
+var keyClasses = []struct {
+	class, name string
+}{
+	{KeysEncoded, "encoded%2Fslash%20space"},
+	{KeysPlus, "2025-10-16+00:00"},
+	{KeysSpace, "with space"},
+	{KeysUnicode, "clé-键-ключ"},
+	{KeysSpecial, "a=b,c;d@e&f$g!h'(i)*j:k"},
+}
+
+// KeyCheck is the outcome of writing, listing and reading back an object
+// whose key contains a class of characters.
+type KeyCheck struct {
+	Class   string
+	Example string // name of the object probed, relative to the probe directory
+	Safe    bool
+	Err     string // reason the class is not safe
+}
+
+// ProbeKeys implements KeyProber.
+func (s *s3Store) ProbeKeys(ctx context.Context) ([]KeyCheck, error) {
+	if s.client == nil {
+		return nil, errors.New("storage is not connected")
+	}
+	var res []KeyCheck
+	check := func(class, name, example string) {
+		c := KeyCheck{Class: class, Example: example, Safe: true}
+		if err := s.probeKey(ctx, path.Join(s.objects.name("keys"), name)); err != nil {
+			c.Safe, c.Err = false, err.Error()
+		}
+		res = append(res, c)
+	}
+	for _, k := range keyClasses {
+		check(k.class, k.name, k.name)
+	}
+	// The longest key accepted by S3, including the destination prefix.
+	full := path.Join(s.keyPrefix(), s.objects.name("keys"), "long-")
+	if pad := MaxKeyLength - len(full); pad > 0 {
+		check(KeysLong, "long-"+strings.Repeat("x", pad), fmt.Sprintf("long-xxx… (%d bytes)", MaxKeyLength))
+	}
+	return res, nil
+}
+
+// probeKey writes an object, verifies that the listing returns its key
+// unchanged and that it can be read back, and deletes it.
+func (s *s3Store) probeKey(ctx context.Context, name string) error {
+	key := path.Join(s.keyPrefix(), name)
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(s.BucketName()),
+		Key:                  aws.String(key),
+		Body:                 strings.NewReader(s.objects.content()),
+		Metadata:             s.objects.metadata(),
+		ServerSideEncryption: serverSideEncryption(s.params),
+		SSEKMSKeyId:          kmsKeyID(s.params),
+		RequestPayer:         s.requestPayer(),
+	}); err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+	defer func() {
+		// The object may have been stored under a rewritten key: a failure
+		// is reported by the listing or the read.
+		_ = s.deleteObject(ctx, name)
+	}()
+	listed, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:       aws.String(s.BucketName()),
+		Prefix:       aws.String(path.Join(s.keyPrefix(), s.objects.name("keys")) + "/"),
+		RequestPayer: s.requestPayer(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list objects")
+	}
+	found := false
+	var keys []string
+	for _, o := range listed.Contents {
+		found = found || aws.ToString(o.Key) == key
+		keys = append(keys, strings.TrimPrefix(aws.ToString(o.Key), s.keyPrefix()+"/"))
+	}
+	if !found {
+		return errors.Newf("key not listed as written: got %q", keys)
+	}
+	got, err := s.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if want := s.objects.content(); string(got) != want {
+		return errors.Newf("unexpected content: got %q, want %q", got, want)
+	}
+	return nil
+}
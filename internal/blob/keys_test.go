@@ -0,0 +1,43 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeKeys(t *testing.T) {
+	r := require.New(t)
+	_, alt := newFakeS3Store(t, &fakeS3{plusAsSpace: true, maxKey: 512})
+
+	checks, err := alt.ProbeKeys(context.Background())
+	r.NoError(err)
+	safe := make(map[string]bool)
+	for _, c := range checks {
+		safe[c.Class] = c.Safe
+	}
+	r.Equal(map[string]bool{
+		KeysEncoded: true, KeysPlus: false, KeysSpace: true,
+		KeysUnicode: true, KeysSpecial: true, KeysLong: false,
+	}, safe)
+	r.Contains(checks[1].Err, "key not listed as written")
+	r.Contains(checks[5].Err, "KeyTooLongError")
+
+	_, err = (&s3Store{}).ProbeKeys(context.Background())
+	r.ErrorContains(err, "not connected")
+}
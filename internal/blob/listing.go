@@ -0,0 +1,186 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/cockroachdb/errors"
+)
+
+// listingWorkers is the number of concurrent requests creating and
+// deleting the objects of the listing probe.
+const listingWorkers = 32
+
+// ListingSample is the time taken to list the objects under a prefix, once
+// it contains the given number of objects.
+type ListingSample struct {
+	Objects int
+	Pages   int // number of list requests
+	Latency time.Duration
+}
+
+// ListingGrowth returns the increase of the listing time per thousand
+// objects, between the first and the last sample.
+func ListingGrowth(samples []ListingSample) time.Duration {
+	if len(samples) < 2 {
+		return 0
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	if last.Objects <= first.Objects {
+		return 0
+	}
+	return (last.Latency - first.Latency) * 1000 / time.Duration(last.Objects-first.Objects)
+}
+
+// listingSteps returns the number of objects at which the listing is timed,
+// following a 1-2-5 sequence from 100 up to count.
+func listingSteps(count int) []int {
+	var steps []int
+	for decade := 100; decade <= count; decade *= 10 {
+		for _, m := range []int{1, 2, 5} {
+			if step := m * decade; step < count {
+				steps = append(steps, step)
+			}
+		}
+	}
+	return append(steps, count)
+}
+
+// ProbeListing implements ListingProber.
+func (s *s3Store) ProbeListing(ctx context.Context, count int) ([]ListingSample, error) {
+	if s.client == nil {
+		return nil, errors.New("storage is not connected")
+	}
+	if count <= 0 {
+		return nil, errors.New("the number of objects must be positive")
+	}
+	created := 0
+	defer func() {
+		slog.Info("deleting the objects of the listing probe", slog.Int("objects", created))
+		if err := parallel(ctx, 0, created, func(i int) error {
+			return s.deleteObject(ctx, s.listingKey(i))
+		}); err != nil {
+			slog.Warn("failed to delete the objects of the listing probe", slog.Any("error", err))
+		}
+	}()
+	var res []ListingSample
+	for _, step := range listingSteps(count) {
+		slog.Info("creating objects for the listing probe", slog.Int("objects", step))
+		from := created
+		// Objects that failed to be created are deleted anyway.
+		created = step
+		if err := parallel(ctx, from, step, func(i int) error {
+			_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket:               aws.String(s.BucketName()),
+				Key:                  aws.String(path.Join(s.keyPrefix(), s.listingKey(i))),
+				Body:                 strings.NewReader(s.objects.content()),
+				Metadata:             s.objects.metadata(),
+				ServerSideEncryption: serverSideEncryption(s.params),
+				SSEKMSKeyId:          kmsKeyID(s.params),
+				RequestPayer:         s.requestPayer(),
+			})
+			return err
+		}); err != nil {
+			return res, errors.Wrap(err, "failed to create the objects of the listing probe")
+		}
+		sample, err := s.timeListing(ctx)
+		if err != nil {
+			return res, err
+		}
+		if sample.Objects != step {
+			return res, errors.Newf("listed %d objects, expected %d", sample.Objects, step)
+		}
+		res = append(res, sample)
+	}
+	return res, nil
+}
+
+// timeListing lists the objects of the listing probe, following the
+// pagination, and returns the time it took.
+func (s *s3Store) timeListing(ctx context.Context) (ListingSample, error) {
+	var sample ListingSample
+	start := time.Now()
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:       aws.String(s.BucketName()),
+		Prefix:       aws.String(path.Join(s.keyPrefix(), s.objects.name("listing")) + "/"),
+		RequestPayer: s.requestPayer(),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return sample, errors.Wrap(err, "failed to list objects")
+		}
+		sample.Pages++
+		sample.Objects += len(page.Contents)
+	}
+	sample.Latency = time.Since(start)
+	slog.Debug("listed objects", slog.Int("objects", sample.Objects), slog.Duration("latency", sample.Latency))
+	return sample, nil
+}
+
+// listingKey returns the key, relative to the destination, of the i-th
+// object of the listing probe.
+func (s *s3Store) listingKey(i int) string {
+	return path.Join(s.objects.name("listing"), fmt.Sprintf("%08d", i))
+}
+
+// parallel calls fn for each index in [from, to), with up to listingWorkers
+// concurrent calls, and returns the first error.
+func parallel(ctx context.Context, from, to int, fn func(i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	indexes := make(chan int)
+	var g sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for range min(listingWorkers, max(to-from, 0)) {
+		g.Add(1)
+		go func() {
+			defer g.Done()
+			for i := range indexes {
+				if err := fn(i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+send:
+	for i := from; i < to; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(indexes)
+	g.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListingSteps(t *testing.T) {
+	a := assert.New(t)
+	a.Equal([]int{50}, listingSteps(50))
+	a.Equal([]int{100, 200, 500, 1000}, listingSteps(1000))
+	a.Equal([]int{100, 200, 500, 1000, 2000, 5000, 10000, 20000, 25000}, listingSteps(25000))
+}
+
+func TestListingGrowth(t *testing.T) {
+	a := assert.New(t)
+	a.Zero(ListingGrowth(nil))
+	a.Equal(10*time.Millisecond, ListingGrowth([]ListingSample{
+		{Objects: 1000, Latency: 50 * time.Millisecond},
+		{Objects: 2000, Latency: 55 * time.Millisecond},
+		{Objects: 11000, Latency: 150 * time.Millisecond},
+	}))
+}
+
+func TestProbeListing(t *testing.T) {
+	r := require.New(t)
+	fake := &fakeS3{}
+	_, alt := newFakeS3Store(t, fake)
+
+	samples, err := alt.ProbeListing(context.Background(), 250)
+	r.NoError(err)
+	r.Len(samples, 3)
+	for i, want := range []int{100, 200, 250} {
+		r.Equal(want, samples[i].Objects)
+		r.Equal(1, samples[i].Pages)
+	}
+	r.Empty(fake.objects, "the objects of the probe are deleted")
+
+	_, err = (&s3Store{}).ProbeListing(context.Background(), 10)
+	r.ErrorContains(err, "not connected")
+}
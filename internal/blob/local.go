@@ -0,0 +1,401 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+const (
+	// NodeLocalScheme is the scheme of the destinations stored in the
+	// external IO directory of a node.
+	NodeLocalScheme = "nodelocal"
+	// UserFileScheme is the scheme of the destinations stored in tables of
+	// the cluster.
+	UserFileScheme = "userfile"
+)
+
+// files stores the objects of a destination managed by the cluster. Names
+// are relative to the root of the storage, e.g. the external IO directory
+// of the node.
+type files interface {
+	put(ctx context.Context, name, body string) error
+	read(ctx context.Context, name string) ([]byte, error)
+	remove(ctx context.Context, name string) error
+	// list returns the objects whose name starts with the prefix, with
+	// their keys relative to the prefix.
+	list(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// localStore is a destination managed by the cluster itself, rather than by
+// an external storage provider: nodelocal:// and userfile:// URLs.
+type localStore struct {
+	files   files
+	scheme  string
+	host    string // node ID, or qualified table prefix of the user files
+	dest    string // path of the destination, without a leading slash
+	root    string // destination provided by the user, without the unique sub-path
+	latency *Latency
+	objects ProbeObject // objects written by the probes
+}
+
+var _ Storage = &localStore{}
+
+// NodeLocalFromEnv creates a new store for a nodelocal:// URI, under a
+// unique sub-path of the destination, and verifies that it can list, write,
+// read and delete objects. The objects are accessed through the external IO
+// directory of the node, which must be reachable from blobcheck, e.g. when
+// it runs on the node.
+func NodeLocalFromEnv(ctx *stopper.Context, env *env.Env) (Storage, error) {
+	return localFromEnv(ctx, env, newNodeLocalStore)
+}
+
+// UserFileFromEnv creates a new store for a userfile:// URI, under a unique
+// sub-path of the destination, and verifies that it can list, write, read
+// and delete objects. The objects are accessed through the tables of the
+// cluster storing the user files.
+func UserFileFromEnv(ctx *stopper.Context, env *env.Env) (Storage, error) {
+	return localFromEnv(ctx, env, newUserFileStore)
+}
+
+// localFromEnv creates a store with newStore, adds a unique sub-path to its
+// destination and probes it.
+func localFromEnv(
+	ctx *stopper.Context, env *env.Env, newStore func(*stopper.Context, *env.Env) (*localStore, error),
+) (Storage, error) {
+	s, err := newStore(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	s.dest = path.Join(s.root, DestID(env))
+	if err := s.probe(ctx); err != nil {
+		return nil, errors.Wrapf(err, "unable to connect to storage %q", s.RootURL())
+	}
+	return s, nil
+}
+
+// newNodeLocalStore parses the nodelocal:// URI of the environment, and
+// accesses its objects in the external IO directory set in the environment.
+func newNodeLocalStore(_ *stopper.Context, env *env.Env) (*localStore, error) {
+	host, dest, err := parseLocalURL(env.URI, NodeLocalScheme)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		return nil, errors.Newf("invalid URL %q: expected nodelocal://<node ID or self>/path", env.URI)
+	}
+	if env.ExternalIODir == "" {
+		return nil, errors.WithHint(
+			errors.New("the external IO directory of the node is not set"),
+			"run blobcheck on the node, or on a host mounting its external IO directory, with --external-io-dir")
+	}
+	info, err := os.Stat(env.ExternalIODir)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid external IO directory")
+	}
+	if !info.IsDir() {
+		return nil, errors.Newf("invalid external IO directory %q: not a directory", env.ExternalIODir)
+	}
+	return &localStore{
+		files:   dirFiles(env.ExternalIODir),
+		scheme:  NodeLocalScheme,
+		host:    host,
+		root:    dest,
+		objects: ProbeObjectFromEnv(env),
+	}, nil
+}
+
+// IsClusterLocal returns whether the objects of the destination are stored
+// by the cluster itself, so that there is no storage endpoint to reach.
+func IsClusterLocal(uri string) bool {
+	return strings.HasPrefix(uri, NodeLocalScheme+"://") || strings.HasPrefix(uri, UserFileScheme+"://")
+}
+
+// parseLocalURL parses a URL with the given scheme and no parameters,
+// returning its host and its path, without a leading slash.
+func parseLocalURL(uri, scheme string) (host, dest string, _ error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid URL")
+	}
+	if parsed.Scheme != scheme {
+		return "", "", errors.Newf("unsupported scheme: %q, expected %q", parsed.Scheme, scheme)
+	}
+	if parsed.Opaque != "" || parsed.User != nil || parsed.Fragment != "" {
+		return "", "", errors.Newf("invalid URL %q: expected %s://host/path", uri, scheme)
+	}
+	if parsed.RawQuery != "" {
+		return "", "", errors.Newf("invalid URL %q: %s URLs have no parameters", uri, scheme)
+	}
+	dest = strings.TrimPrefix(path.Clean("/"+parsed.Path), "/")
+	if dest == "" {
+		return "", "", errors.Newf("invalid URL %q: a path is required", uri)
+	}
+	return parsed.Host, dest, nil
+}
+
+// probe verifies that the store can list, write, read and delete objects,
+// recording the latency of each operation.
+func (s *localStore) probe(ctx context.Context) error {
+	var latency Latency
+	name := path.Join(s.dest, s.objects.key())
+	start := time.Now()
+	if _, err := s.files.list(ctx, name); err != nil {
+		return err
+	}
+	latency.List = time.Since(start)
+	start = time.Now()
+	if err := s.files.put(ctx, name, s.objects.content()); err != nil {
+		return err
+	}
+	latency.Put = time.Since(start)
+	start = time.Now()
+	got, err := s.files.read(ctx, name)
+	if err != nil {
+		return err
+	}
+	latency.Get = time.Since(start)
+	if string(got) != s.objects.content() {
+		return fmt.Errorf("unexpected content: got %q, want %q", got, s.objects.content())
+	}
+	start = time.Now()
+	if err := s.files.remove(ctx, name); err != nil {
+		return err
+	}
+	latency.Delete = time.Since(start)
+	s.latency = &latency
+	return nil
+}
+
+// BucketName implements Storage. It returns the node ID of nodelocal
+// destinations, and the qualified table prefix of userfile destinations.
+func (s *localStore) BucketName() string {
+	return s.host
+}
+
+// Params implements Storage. Destinations managed by the cluster have no
+// parameters.
+func (s *localStore) Params() Params {
+	return Params{}
+}
+
+// ProvidedParams implements Storage.
+func (s *localStore) ProvidedParams() Params {
+	return nil
+}
+
+// URL implements Storage.
+func (s *localStore) URL() string {
+	return s.toURL(s.dest)
+}
+
+// RootURL implements Storage.
+func (s *localStore) RootURL() string {
+	return s.toURL(s.root)
+}
+
+// toURL returns the URL of a destination of the store.
+func (s *localStore) toURL(dest string) string {
+	res := url.URL{Scheme: s.scheme, Host: s.host, Path: "/" + dest}
+	return res.String()
+}
+
+// Candidates implements Storage. Destinations managed by the cluster have
+// a single configuration, so there is nothing to rank.
+func (s *localStore) Candidates() []Candidate {
+	return nil
+}
+
+// Probed implements Storage.
+func (s *localStore) Probed() []Probed {
+	return nil
+}
+
+// CustomCA implements Storage.
+func (s *localStore) CustomCA() string {
+	return ""
+}
+
+// Latency implements Storage.
+func (s *localStore) Latency() *Latency {
+	return s.latency
+}
+
+// Capabilities implements Storage. The basic operations were verified when
+// connecting; the consistency of overwritten objects is probed with an
+// additional object, which is deleted afterwards.
+func (s *localStore) Capabilities(ctx context.Context) ([]Capability, error) {
+	var latency Latency
+	if s.latency != nil {
+		latency = *s.latency
+	}
+	res := []Capability{
+		newCapability(CapList, latency.List, nil),
+		newCapability(CapPut, latency.Put, nil),
+		newCapability(CapGet, latency.Get, nil),
+		newCapability(CapDelete, latency.Delete, nil),
+	}
+	name := path.Join(s.dest, s.objects.name("overwrite"))
+	start := time.Now()
+	err := verifyOverwrite(s.objects.content(),
+		func(body string) error { return s.files.put(ctx, name, body) },
+		func() ([]byte, error) { return s.files.read(ctx, name) },
+		func() error { return s.files.remove(ctx, name) },
+	)
+	res = append(res, newCapability(CapOverwrite, time.Since(start), err))
+	return res, nil
+}
+
+// Clean implements Storage.
+func (s *localStore) Clean(ctx context.Context) error {
+	objects, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := s.files.remove(ctx, path.Join(s.dest, obj.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete implements Storage. Destinations managed by the cluster cannot be
+// locked.
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	return s.files.remove(ctx, path.Join(s.dest, key))
+}
+
+// Get implements Storage.
+func (s *localStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.files.read(ctx, path.Join(s.dest, key))
+}
+
+// List implements Storage.
+func (s *localStore) List(ctx context.Context) ([]Object, error) {
+	return s.files.list(ctx, s.dest+"/")
+}
+
+// dirFiles stores the objects in a local directory, e.g. the external IO
+// directory of a node.
+type dirFiles string
+
+var _ files = dirFiles("")
+
+// put implements files.
+func (d dirFiles) put(_ context.Context, name, body string) error {
+	file := d.path(name)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return errors.Wrapf(err, "failed to put %q", name)
+	}
+	if err := os.WriteFile(file, []byte(body), 0o644); err != nil {
+		return errors.Wrapf(err, "failed to put %q", name)
+	}
+	return nil
+}
+
+// read implements files.
+func (d dirFiles) read(_ context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(d.path(name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %q", name)
+	}
+	return data, nil
+}
+
+// remove implements files. The parent directories left empty are removed
+// as well, up to the root directory.
+func (d dirFiles) remove(_ context.Context, name string) error {
+	if err := os.Remove(d.path(name)); err != nil {
+		return errors.Wrapf(err, "failed to delete %q", name)
+	}
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if err := os.Remove(d.path(dir)); err != nil {
+			// Not empty, or already removed.
+			break
+		}
+	}
+	return nil
+}
+
+// list implements files.
+func (d dirFiles) list(_ context.Context, prefix string) ([]Object, error) {
+	// Walk the deepest directory containing every name with the prefix.
+	dir := prefix
+	if !strings.HasSuffix(dir, "/") {
+		dir = path.Dir(dir)
+	}
+	var objects []Object
+	err := filepath.WalkDir(d.path(dir), func(file string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(string(d), file)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{
+			Key:          strings.TrimPrefix(name, prefix),
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+	return objects, nil
+}
+
+// path returns the local path of an object, given its name.
+func (d dirFiles) path(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(name))
+}
+
+// openLocal connects to a nodelocal:// or userfile:// destination as is,
+// without adding a unique sub-path.
+func openLocal(
+	ctx *stopper.Context, env *env.Env, newStore func(*stopper.Context, *env.Env) (*localStore, error),
+) (Storage, error) {
+	s, err := newStore(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	s.dest = s.root
+	return s, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestParseLocalURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		scheme   string
+		wantHost string
+		wantDest string
+		wantErr  string
+	}{
+		{"node", "nodelocal://1/backups/run", NodeLocalScheme, "1", "backups/run", ""},
+		{"self", "nodelocal://self/backups/", NodeLocalScheme, "self", "backups", ""},
+		{"default user files", "userfile:///backups", UserFileScheme, "", "backups", ""},
+		{"qualified user files", "userfile://db.public.files/backups", UserFileScheme, "db.public.files", "backups", ""},
+		{"wrong scheme", "s3://bucket/backups", NodeLocalScheme, "", "", "unsupported scheme"},
+		{"parameters", "nodelocal://1/backups?AUTH=implicit", NodeLocalScheme, "", "", "no parameters"},
+		{"no path", "nodelocal://1/", NodeLocalScheme, "", "", "a path is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, dest, err := parseLocalURL(tt.uri, tt.scheme)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHost, host)
+			assert.Equal(t, tt.wantDest, dest)
+		})
+	}
+}
+
+func TestNodeLocal(t *testing.T) {
+	r := require.New(t)
+	ctx := stopper.WithContext(t.Context())
+	dir := t.TempDir()
+	store, err := NodeLocalFromEnv(ctx, &env.Env{URI: "nodelocal://1/backups", ExternalIODir: dir})
+	r.NoError(err)
+	assert.True(t, strings.HasPrefix(store.URL(), "nodelocal://1/backups/"))
+	assert.Equal(t, "nodelocal://1/backups", store.RootURL())
+	assert.Equal(t, "1", store.BucketName())
+	assert.Empty(t, store.Params())
+	r.NotNil(store.Latency())
+
+	capabilities, err := store.Capabilities(ctx)
+	r.NoError(err)
+	for _, c := range capabilities {
+		assert.True(t, c.Supported, c.Operation)
+	}
+
+	// Files written by the cluster are visible to the store.
+	local := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(store.URL(), "nodelocal://1/")))
+	r.NoError(os.MkdirAll(filepath.Join(local, "data"), 0o755))
+	r.NoError(os.WriteFile(filepath.Join(local, "BACKUP_MANIFEST"), []byte("manifest"), 0o644))
+	r.NoError(os.WriteFile(filepath.Join(local, "data", "1.sst"), []byte("sst"), 0o644))
+	objects, err := store.List(ctx)
+	r.NoError(err)
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	assert.Equal(t, []string{"BACKUP_MANIFEST", "data/1.sst"}, keys)
+	got, err := store.Get(ctx, "BACKUP_MANIFEST")
+	r.NoError(err)
+	assert.Equal(t, "manifest", string(got))
+
+	r.NoError(store.Delete(ctx, "BACKUP_MANIFEST"))
+	r.NoError(store.Clean(ctx))
+	objects, err = store.List(ctx)
+	r.NoError(err)
+	assert.Empty(t, objects)
+	// The directories left empty are removed.
+	entries, err := os.ReadDir(dir)
+	r.NoError(err)
+	assert.Empty(t, entries)
+}
+
+func TestNodeLocalRequiresDir(t *testing.T) {
+	ctx := stopper.WithContext(t.Context())
+	_, err := NodeLocalFromEnv(ctx, &env.Env{URI: "nodelocal://1/backups"})
+	require.ErrorContains(t, err, "external IO directory")
+	_, err = NodeLocalFromEnv(ctx, &env.Env{URI: "nodelocal:///backups", ExternalIODir: t.TempDir()})
+	require.ErrorContains(t, err, "node ID")
+}
+
+func TestUserFileTables(t *testing.T) {
+	ctx := stopper.WithContext(t.Context())
+	tests := []struct {
+		name        string
+		uri         string
+		wantFiles   string
+		wantURL     string
+		wantErr     string
+		wantPayload string
+	}{
+		{
+			name:        "default",
+			uri:         "userfile:///backups",
+			wantFiles:   `"defaultdb"."public"."userfiles_maxroach_upload_files"`,
+			wantPayload: `"defaultdb"."public"."userfiles_maxroach_upload_payload"`,
+			wantURL:     "userfile:///backups",
+		},
+		{
+			name:        "qualified",
+			uri:         "userfile://db.backups.files/run",
+			wantFiles:   `"db"."backups"."files_upload_files"`,
+			wantPayload: `"db"."backups"."files_upload_payload"`,
+			wantURL:     "userfile://db.backups.files/run",
+		},
+		{
+			name:    "unqualified",
+			uri:     "userfile://files/run",
+			wantErr: "expected userfile://database.schema.prefix/path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			// The pool connects lazily, so no cluster is needed.
+			s, err := newUserFileStore(ctx, &env.Env{
+				URI:         tt.uri,
+				DatabaseURL: "postgresql://maxroach@localhost:26257/defaultdb?sslmode=disable",
+			})
+			if tt.wantErr != "" {
+				r.ErrorContains(err, tt.wantErr)
+				return
+			}
+			r.NoError(err)
+			files := s.files.(*tableFiles)
+			defer files.pool.Close()
+			assert.Equal(t, tt.wantFiles, files.files)
+			assert.Equal(t, tt.wantPayload, files.payload)
+			assert.Equal(t, "maxroach", files.user)
+			s.dest = s.root
+			assert.Equal(t, tt.wantURL, s.URL())
+		})
+	}
+}
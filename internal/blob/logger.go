@@ -0,0 +1,116 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/smithy-go/logging"
+)
+
+const (
+	// maxLoggedBody is the maximum number of bytes of a request or response
+	// body included in the SDK debug logs.
+	maxLoggedBody = 4 << 10
+	// maxLogsPerSecond caps the number of SDK debug messages, so that probes
+	// issuing many requests do not flood the logs.
+	maxLogsPerSecond = 50
+)
+
+// sdkLogger wraps the logger of the AWS SDK, truncating large bodies,
+// eliding binary ones, and dropping messages above a rate limit.
+type sdkLogger struct {
+	next logging.Logger
+	mu   struct {
+		sync.Mutex
+		window  time.Time // start of the current one second window
+		count   int       // messages logged in the current window
+		dropped int       // messages dropped in the current window
+	}
+}
+
+var _ logging.Logger = &sdkLogger{}
+
+// newSDKLogger returns a logger that writes the guarded messages to w, in
+// the format of the SDK's standard logger.
+func newSDKLogger(w io.Writer) *sdkLogger {
+	return &sdkLogger{next: logging.NewStandardLogger(w)}
+}
+
+// Logf implements logging.Logger.
+func (l *sdkLogger) Logf(classification logging.Classification, format string, v ...any) {
+	if !l.allow(time.Now()) {
+		return
+	}
+	l.next.Logf(classification, "%s", guardBody(fmt.Sprintf(format, v...), maxLoggedBody))
+}
+
+// allow returns whether a message can be logged at the given time.
+func (l *sdkLogger) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.mu.window) >= time.Second {
+		if l.mu.dropped > 0 {
+			slog.Debug("aws sdk messages dropped", slog.Int("count", l.mu.dropped))
+		}
+		l.mu.window, l.mu.count, l.mu.dropped = now, 0, 0
+	}
+	if l.mu.count >= maxLogsPerSecond {
+		l.mu.dropped++
+		return false
+	}
+	l.mu.count++
+	return true
+}
+
+// guardBody replaces a binary body in an HTTP dump with a placeholder, and
+// truncates a text body to max bytes. The headers are always preserved.
+func guardBody(dump string, max int) string {
+	headers, body, ok := strings.Cut(dump, "\r\n\r\n")
+	if !ok || body == "" {
+		return dump
+	}
+	if isBinary(body) {
+		return fmt.Sprintf("%s\r\n\r\n[binary body, %d bytes]", headers, len(body))
+	}
+	if len(body) > max {
+		return fmt.Sprintf("%s\r\n\r\n%s... [truncated %d bytes]", headers, body[:max], len(body)-max)
+	}
+	return dump
+}
+
+// isBinary returns whether the body looks like binary data: invalid UTF-8,
+// or control characters other than whitespace in its first bytes.
+func isBinary(body string) bool {
+	if !utf8.ValidString(body) {
+		return true
+	}
+	for i, r := range body {
+		if i >= 512 {
+			break
+		}
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuardBody(t *testing.T) {
+	a := assert.New(t)
+	headers := "PUT /bucket/_blobcheck HTTP/1.1\r\nHost: s3.example.com"
+	// No body.
+	a.Equal(headers, guardBody(headers, 10))
+	// Short text body.
+	a.Equal(headers+"\r\n\r\nhello", guardBody(headers+"\r\n\r\nhello", 10))
+	// Long text body.
+	a.Equal(headers+"\r\n\r\n0123456789... [truncated 5 bytes]",
+		guardBody(headers+"\r\n\r\n012345678901234", 10))
+	// Binary body.
+	a.Equal(headers+"\r\n\r\n[binary body, 4 bytes]",
+		guardBody(headers+"\r\n\r\n\x00\x01\xff\xfe", 10))
+	// Text body with multi-byte characters is not binary.
+	a.False(isBinary(strings.Repeat("é", 600)))
+}
+
+func TestSDKLoggerRateLimit(t *testing.T) {
+	a := assert.New(t)
+	l := &sdkLogger{}
+	now := time.Now()
+	for range maxLogsPerSecond {
+		a.True(l.allow(now))
+	}
+	a.False(l.allow(now))
+	a.True(l.allow(now.Add(time.Second)))
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// MinIO admin and health endpoints, relative to the S3 endpoint.
+const (
+	minioHealthPath = "/minio/health/cluster"
+	minioInfoPath   = "/minio/admin/v3/info"
+	minioQuotaPath  = "/minio/admin/v3/get-bucket-quota"
+	minioUsagePath  = "/minio/admin/v3/datausageinfo"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body, used to sign
+// the admin requests.
+var emptyPayloadHash = hex.EncodeToString(sha256.New().Sum(nil))
+
+// MinIOInfo describes the MinIO deployment serving the destination.
+type MinIOInfo struct {
+	Health   string   // health of the cluster, as reported by the health endpoint
+	Versions []string // distinct versions of the servers, with the admin API
+	Mode     string   // mode of the deployment, e.g. online
+	Backend  string   // backend type, e.g. Erasure
+	Servers  int      // number of servers
+	Offline  int      // number of servers not online
+	Drives   int      // number of drives
+	Faulty   int      // number of drives not ok
+	Sets     int      // number of erasure sets
+	Parity   int      // parity of the standard storage class
+	Quota    int64    // quota of the bucket in bytes, 0 if none
+	Usage    int64    // bytes stored in the bucket, if it has a quota
+	AdminErr string   // why the admin API could not be queried, if it could not
+}
+
+// FreeFraction returns the fraction of the quota of the bucket still
+// available, or 1 if the bucket has no quota.
+func (m *MinIOInfo) FreeFraction() float64 {
+	if m.Quota <= 0 {
+		return 1
+	}
+	return max(float64(m.Quota-m.Usage), 0) / float64(m.Quota)
+}
+
+// Degraded reports whether servers or drives of the deployment are
+// offline.
+func (m *MinIOInfo) Degraded() bool {
+	return m.Offline > 0 || m.Faulty > 0 || (m.Health != "" && m.Health != minioHealthy)
+}
+
+// minioHealthy is the health of a cluster with write quorum.
+const minioHealthy = "healthy"
+
+// MinIOReporter is implemented by the stores that can describe the MinIO
+// deployment serving the destination.
+type MinIOReporter interface {
+	MinIO(ctx context.Context) (*MinIOInfo, error)
+}
+
+// minioStore is an S3 store served by MinIO, which can also query the
+// health and admin endpoints of the deployment.
+type minioStore struct {
+	s3Storage
+	client   *http.Client
+	endpoint string // S3 endpoint of the deployment
+	creds    aws.Credentials
+	region   string
+	admin    bool // query the admin API, which requires admin privileges
+}
+
+var (
+	_ s3Storage     = &minioStore{}
+	_ MinIOReporter = &minioStore{}
+)
+
+// MinIOFromEnv creates a new S3 store for a MinIO deployment. Requests are
+// path-style, which MinIO supports without further configuration, unless
+// the user sets AWS_USE_PATH_STYLE.
+func MinIOFromEnv(ctx *stopper.Context, env *env.Env) (Storage, error) {
+	params, _, err := s3Params(env)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := params[EndPointParam]
+	if endpoint == "" {
+		return nil, errors.Newf("the MinIO endpoint is not set: set %s or --endpoint", EndPointParam)
+	}
+	store, err := s3FromEnv(ctx, env, Params{UsePathStyleParam: "true"})
+	if err != nil {
+		return nil, err
+	}
+	return &minioStore{
+		s3Storage: store,
+		client: &http.Client{
+			Transport: newTransport(timeoutsFromEnv(env), env.Dial, store.Params().Bool(SkipTLSVerify)),
+		},
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		creds: aws.Credentials{
+			AccessKeyID:     params[AccountParam],
+			SecretAccessKey: params[SecretParam],
+			SessionToken:    params[TokenParam],
+		},
+		region: params[RegionParam],
+		admin:  env.MinIOAdmin,
+	}, nil
+}
+
+// MinIO implements MinIOReporter. The health of the cluster is always
+// reported; the servers, drives and quota of the bucket require the admin
+// API, which is only queried if enabled, and whose failures are reported
+// rather than returned.
+func (s *minioStore) MinIO(ctx context.Context) (*MinIOInfo, error) {
+	res := &MinIOInfo{Health: s.health(ctx)}
+	if !s.admin {
+		return res, nil
+	}
+	if err := s.serverInfo(ctx, res); err != nil {
+		res.AdminErr = err.Error()
+		return res, nil
+	}
+	if err := s.quota(ctx, res); err != nil {
+		res.AdminErr = err.Error()
+	}
+	if res.Degraded() {
+		slog.Warn("the MinIO deployment is degraded",
+			slog.Int("offline_servers", res.Offline), slog.Int("faulty_drives", res.Faulty))
+	}
+	return res, nil
+}
+
+// health queries the cluster health endpoint, which fails if the cluster
+// lost write quorum.
+func (s *minioStore) health(ctx context.Context) string {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+minioHealthPath, nil)
+	if err != nil {
+		return err.Error()
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "unknown: " + err.Error()
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return minioHealthy
+	case http.StatusServiceUnavailable:
+		return "write quorum not met"
+	default:
+		return "unknown: " + resp.Status
+	}
+}
+
+// adminGet sends a signed request to the admin API and decodes the JSON
+// response.
+func (s *minioStore) adminGet(ctx context.Context, path string, query url.Values, into any) error {
+	target := s.endpoint + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if err := v4.NewSigner().SignHTTP(ctx, s.creds, req, emptyPayloadHash, "s3", s.region, time.Now()); err != nil {
+		return errors.Wrap(err, "failed to sign the admin request")
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "admin API unreachable")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Newf("admin API returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(into), "invalid admin API response")
+}
+
+// serverInfo reads the servers and drives of the deployment.
+func (s *minioStore) serverInfo(ctx context.Context, res *MinIOInfo) error {
+	var info struct {
+		Mode    string `json:"mode"`
+		Backend struct {
+			Type      string `json:"backendType"`
+			Parity    int    `json:"standardSCParity"`
+			TotalSets []int  `json:"totalSets"`
+		} `json:"backend"`
+		Servers []struct {
+			State   string `json:"state"`
+			Version string `json:"version"`
+			Drives  []struct {
+				State string `json:"state"`
+			} `json:"drives"`
+		} `json:"servers"`
+	}
+	if err := s.adminGet(ctx, minioInfoPath, nil, &info); err != nil {
+		return err
+	}
+	res.Mode, res.Backend, res.Parity = info.Mode, info.Backend.Type, info.Backend.Parity
+	for _, sets := range info.Backend.TotalSets {
+		res.Sets += sets
+	}
+	res.Servers = len(info.Servers)
+	for _, server := range info.Servers {
+		if server.State != "online" {
+			res.Offline++
+		}
+		if server.Version != "" && !slices.Contains(res.Versions, server.Version) {
+			res.Versions = append(res.Versions, server.Version)
+		}
+		res.Drives += len(server.Drives)
+		for _, drive := range server.Drives {
+			if drive.State != "ok" {
+				res.Faulty++
+			}
+		}
+	}
+	slices.Sort(res.Versions)
+	return nil
+}
+
+// quota reads the quota of the bucket and, if it has one, the bytes stored
+// in the bucket, as last computed by the data usage scanner of MinIO.
+func (s *minioStore) quota(ctx context.Context, res *MinIOInfo) error {
+	var quota struct {
+		Quota int64 `json:"quota"`
+		Size  int64 `json:"size"`
+	}
+	if err := s.adminGet(ctx, minioQuotaPath, url.Values{"bucket": {s.BucketName()}}, &quota); err != nil {
+		return err
+	}
+	res.Quota = max(quota.Quota, quota.Size)
+	if res.Quota == 0 {
+		return nil
+	}
+	var usage struct {
+		Buckets map[string]struct {
+			Size int64 `json:"size"`
+		} `json:"bucketsUsageInfo"`
+	}
+	if err := s.adminGet(ctx, minioUsagePath, nil, &usage); err != nil {
+		return err
+	}
+	res.Usage = usage.Buckets[s.BucketName()].Size
+	return nil
+}
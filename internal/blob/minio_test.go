@@ -0,0 +1,143 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMinIO serves the health and admin endpoints of a MinIO deployment.
+type fakeMinIO struct {
+	quorum    bool // the cluster has write quorum
+	forbidden bool // the admin API rejects the credentials
+}
+
+func (f *fakeMinIO) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == minioHealthPath {
+		if !f.quorum {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") || f.forbidden {
+		http.Error(w, "access denied", http.StatusForbidden)
+		return
+	}
+	switch r.URL.Path {
+	case minioInfoPath:
+		drive := map[string]string{"state": "ok"}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"mode": "online",
+			"backend": map[string]any{
+				"backendType": "Erasure", "standardSCParity": 2, "totalSets": []int{1},
+			},
+			"servers": []any{
+				map[string]any{"state": "online", "version": "v2", "drives": []any{drive, drive}},
+				map[string]any{"state": "offline", "version": "v1", "drives": []any{drive, map[string]string{"state": "offline"}}},
+			},
+		})
+	case minioQuotaPath:
+		if r.URL.Query().Get("bucket") != "bucket" {
+			http.Error(w, "no such bucket", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"quota":1024,"quotatype":"hard"}`))
+	case minioUsagePath:
+		_, _ = w.Write([]byte(`{"bucketsUsageInfo":{"bucket":{"size":768},"other":{"size":4096}}}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestMinIO(t *testing.T) {
+	tests := []struct {
+		name  string
+		fake  *fakeMinIO
+		admin bool
+		want  *MinIOInfo
+		err   string
+	}{
+		{
+			name: "health only",
+			fake: &fakeMinIO{quorum: true},
+			want: &MinIOInfo{Health: minioHealthy},
+		},
+		{
+			name: "quorum lost",
+			fake: &fakeMinIO{},
+			want: &MinIOInfo{Health: "write quorum not met"},
+		},
+		{
+			name:  "admin",
+			fake:  &fakeMinIO{quorum: true},
+			admin: true,
+			want: &MinIOInfo{
+				Health:   minioHealthy,
+				Versions: []string{"v1", "v2"},
+				Mode:     "online",
+				Backend:  "Erasure",
+				Servers:  2,
+				Offline:  1,
+				Drives:   4,
+				Faulty:   1,
+				Sets:     1,
+				Parity:   2,
+				Quota:    1024,
+				Usage:    768,
+			},
+		},
+		{
+			name:  "admin forbidden",
+			fake:  &fakeMinIO{quorum: true, forbidden: true},
+			admin: true,
+			err:   "403 Forbidden",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			a := assert.New(t)
+			server := httptest.NewServer(tt.fake)
+			defer server.Close()
+			store := &minioStore{
+				s3Storage: &s3Store{dest: "bucket/path"},
+				client:    server.Client(),
+				endpoint:  server.URL,
+				creds:     aws.Credentials{AccessKeyID: "access", SecretAccessKey: "secret"},
+				region:    "us-east-1",
+				admin:     tt.admin,
+			}
+			info, err := store.MinIO(t.Context())
+			r.NoError(err)
+			if tt.err != "" {
+				a.Contains(info.AdminErr, tt.err)
+				return
+			}
+			a.Equal(tt.want, info)
+			if tt.want.Quota > 0 {
+				a.InDelta(0.25, info.FreeFraction(), 1e-9)
+			}
+			a.Equal(tt.want.Offline > 0 || tt.want.Health != minioHealthy, info.Degraded())
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Object lock retention modes.
+const (
+	LockGovernance = string(types.ObjectLockRetentionModeGovernance)
+	LockCompliance = string(types.ObjectLockRetentionModeCompliance)
+)
+
+// ObjectLock describes the object lock (WORM) configuration of the bucket.
+type ObjectLock struct {
+	Enabled   bool
+	Mode      string        // mode of the default retention, if any
+	Retention time.Duration // default retention applied to new objects, if any
+	Err       string        // why the configuration could not be read, if it could not
+}
+
+// Compliance reports whether new objects are retained in compliance mode,
+// which no user can shorten or bypass.
+func (l *ObjectLock) Compliance() bool {
+	return l.Enabled && l.Mode == LockCompliance && l.Retention > 0
+}
+
+// ObjectLock implements ObjectLockReporter.
+func (s *s3Store) ObjectLock(ctx context.Context) (*ObjectLock, error) {
+	if s.client == nil {
+		return nil, errors.New("storage is not connected")
+	}
+	out, err := s.client.GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(s.BucketName()),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "ObjectLockConfigurationNotFoundError" {
+			return &ObjectLock{}, nil
+		}
+		return &ObjectLock{Err: err.Error()}, nil
+	}
+	config := out.ObjectLockConfiguration
+	if config == nil || config.ObjectLockEnabled != types.ObjectLockEnabledEnabled {
+		return &ObjectLock{}, nil
+	}
+	res := &ObjectLock{Enabled: true}
+	if config.Rule != nil && config.Rule.DefaultRetention != nil {
+		retention := config.Rule.DefaultRetention
+		res.Mode = string(retention.Mode)
+		days := aws.ToInt32(retention.Days) + 365*aws.ToInt32(retention.Years)
+		res.Retention = time.Duration(days) * 24 * time.Hour
+	}
+	return res, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectLock(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         string
+		want           ObjectLock
+		wantCompliance bool
+	}{
+		{
+			name: "not enabled",
+		},
+		{
+			name:   "no default retention",
+			config: `<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>`,
+			want:   ObjectLock{Enabled: true},
+		},
+		{
+			name: "compliance",
+			config: `<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled>
+<Rule><DefaultRetention><Mode>COMPLIANCE</Mode><Days>7</Days></DefaultRetention></Rule></ObjectLockConfiguration>`,
+			want:           ObjectLock{Enabled: true, Mode: LockCompliance, Retention: 7 * 24 * time.Hour},
+			wantCompliance: true,
+		},
+		{
+			name: "governance",
+			config: `<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled>
+<Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Years>1</Years></DefaultRetention></Rule></ObjectLockConfiguration>`,
+			want: ObjectLock{Enabled: true, Mode: LockGovernance, Retention: 365 * 24 * time.Hour},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			_, alt := newFakeS3Store(t, &fakeS3{objectLock: tt.config}, func(s *s3Store) {
+				s.dest, s.root = "bucket/backups", "bucket/backups"
+			})
+
+			got, err := alt.ObjectLock(context.Background())
+			r.NoError(err)
+			assert.Equal(t, tt.want, *got)
+			assert.Equal(t, tt.wantCompliance, got.Compliance())
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// paginationFlat is the number of objects written directly under the
+	// prefix of the pagination probe: more than the 1000 keys returned by
+	// a page, so that the listing needs continuation tokens.
+	paginationFlat = 1050
+	// paginationDirs is the number of sub-prefixes of the pagination probe,
+	// each holding paginationDirObjects objects.
+	paginationDirs       = 5
+	paginationDirObjects = 2
+	// paginationMaxPages bounds the listing, in case the provider keeps
+	// returning the same continuation token.
+	paginationMaxPages = 100
+)
+
+// Pagination is the outcome of the list pagination probe, which writes
+// more objects than a page holds under a prefix, some of them under
+// sub-prefixes, and lists them with and without a delimiter.
+type Pagination struct {
+	Written      int  // objects written under the prefix, including the sub-prefixes
+	Listed       int  // keys listed without a delimiter, following the continuation tokens
+	Pages        int  // pages of the listing without a delimiter
+	Duplicates   int  // keys listed more than once
+	Unordered    bool // whether the keys were not listed in ascending order
+	TokenMissing bool // whether a truncated page had no continuation token
+	Loop         bool // whether the listing was abandoned after returning the same token
+	// Prefixes and DelimitedKeys are the common prefixes and the keys
+	// listed with the "/" delimiter.
+	Prefixes      int
+	DelimitedKeys int
+}
+
+// Problems returns how the listings of the provider differ from S3.
+// Backups and restores list collections of thousands of files, and a
+// truncated listing makes them miss some.
+func (p *Pagination) Problems() []string {
+	var res []string
+	if p.Listed < p.Written {
+		res = append(res, fmt.Sprintf("listing truncated: %d of %d objects listed", p.Listed, p.Written))
+	}
+	if p.Duplicates > 0 {
+		res = append(res, fmt.Sprintf("%d keys listed more than once across pages", p.Duplicates))
+	}
+	if p.Unordered {
+		res = append(res, "keys not listed in ascending order")
+	}
+	if p.TokenMissing {
+		res = append(res, "a truncated page has no continuation token")
+	}
+	if p.Loop {
+		res = append(res, "the continuation tokens do not advance the listing")
+	}
+	if p.Prefixes != paginationDirs {
+		res = append(res, fmt.Sprintf("%d common prefixes listed with the delimiter, expected %d",
+			p.Prefixes, paginationDirs))
+	}
+	if want := p.Written - paginationDirs*paginationDirObjects; p.DelimitedKeys != want {
+		res = append(res, fmt.Sprintf("%d keys listed with the delimiter, expected %d",
+			p.DelimitedKeys, want))
+	}
+	return res
+}
+
+// ProbePagination implements PaginationProber.
+func (s *s3Store) ProbePagination(ctx context.Context) (*Pagination, error) {
+	if s.client == nil {
+		return nil, errors.New("storage is not connected")
+	}
+	keys := paginationKeys()
+	prefix := path.Join(s.keyPrefix(), s.objects.name("pagination")) + "/"
+	created := 0
+	defer func() {
+		slog.Info("deleting the objects of the pagination probe", slog.Int("objects", created))
+		if err := parallel(ctx, 0, created, func(i int) error {
+			return s.deleteObject(ctx, path.Join(s.objects.name("pagination"), keys[i]))
+		}); err != nil {
+			slog.Warn("failed to delete the objects of the pagination probe", slog.Any("error", err))
+		}
+	}()
+	slog.Info("creating objects for the pagination probe", slog.Int("objects", len(keys)))
+	// Objects that failed to be created are deleted anyway.
+	created = len(keys)
+	if err := parallel(ctx, 0, len(keys), func(i int) error {
+		_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:               aws.String(s.BucketName()),
+			Key:                  aws.String(prefix + keys[i]),
+			Body:                 strings.NewReader(s.objects.content()),
+			Metadata:             s.objects.metadata(),
+			ServerSideEncryption: serverSideEncryption(s.params),
+			SSEKMSKeyId:          kmsKeyID(s.params),
+			RequestPayer:         s.requestPayer(),
+		})
+		return err
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to create the objects of the pagination probe")
+	}
+
+	res := &Pagination{Written: len(keys)}
+	seen := make(map[string]bool, len(keys))
+	last := ""
+	err := s.listPages(ctx, prefix, "", res, func(page *s3.ListObjectsV2Output) {
+		res.Pages++
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if seen[key] {
+				res.Duplicates++
+				continue
+			}
+			seen[key] = true
+			res.Listed++
+			if key < last {
+				res.Unordered = true
+			}
+			last = key
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.listPages(ctx, prefix, "/", res, func(page *s3.ListObjectsV2Output) {
+		res.Prefixes += len(page.CommonPrefixes)
+		res.DelimitedKeys += len(page.Contents)
+	}); err != nil {
+		return nil, err
+	}
+	for _, problem := range res.Problems() {
+		slog.Warn("list pagination: "+problem, slog.Int("pages", res.Pages))
+	}
+	return res, nil
+}
+
+// listPages lists the objects under the prefix, following the continuation
+// tokens, and calls visit for each page. Missing or repeated tokens are
+// recorded in res, and stop the listing.
+func (s *s3Store) listPages(
+	ctx context.Context, prefix, delimiter string, res *Pagination, visit func(*s3.ListObjectsV2Output),
+) error {
+	input := &s3.ListObjectsV2Input{
+		Bucket:       aws.String(s.BucketName()),
+		Prefix:       aws.String(prefix),
+		RequestPayer: s.requestPayer(),
+	}
+	if delimiter != "" {
+		input.Delimiter = aws.String(delimiter)
+	}
+	tokens := make(map[string]bool)
+	for range paginationMaxPages {
+		page, err := s.client.ListObjectsV2(ctx, input)
+		if err != nil {
+			return errors.Wrap(err, "failed to list objects")
+		}
+		visit(page)
+		if !aws.ToBool(page.IsTruncated) {
+			return nil
+		}
+		token := aws.ToString(page.NextContinuationToken)
+		switch {
+		case token == "":
+			res.TokenMissing = true
+			return nil
+		case tokens[token]:
+			res.Loop = true
+			return nil
+		}
+		tokens[token] = true
+		input.ContinuationToken = aws.String(token)
+	}
+	res.Loop = true
+	return nil
+}
+
+// paginationKeys returns the keys of the objects of the pagination probe,
+// relative to its prefix.
+func paginationKeys() []string {
+	var keys []string
+	for i := range paginationFlat {
+		keys = append(keys, fmt.Sprintf("%08d", i))
+	}
+	for d := range paginationDirs {
+		for i := range paginationDirObjects {
+			keys = append(keys, fmt.Sprintf("dir%d/%08d", d, i))
+		}
+	}
+	return keys
+}
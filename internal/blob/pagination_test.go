@@ -0,0 +1,87 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbePagination(t *testing.T) {
+	tests := []struct {
+		name         string
+		fake         *fakeS3
+		wantPages    int
+		wantProblems []string
+	}{
+		{
+			name:      "paginated",
+			fake:      &fakeS3{pageSize: 1000},
+			wantPages: 2,
+		},
+		{
+			name:      "small pages",
+			fake:      &fakeS3{pageSize: 100},
+			wantPages: 11,
+		},
+		{
+			name:      "truncated",
+			fake:      &fakeS3{pageSize: 1000, truncatedListing: true},
+			wantPages: 1,
+			wantProblems: []string{
+				"listing truncated: 1000 of 1060 objects listed",
+				"0 common prefixes listed with the delimiter, expected 5",
+				"1000 keys listed with the delimiter, expected 1050",
+			},
+		},
+		{
+			name:      "unordered without delimiters",
+			fake:      &fakeS3{},
+			wantPages: 1,
+			wantProblems: []string{
+				"keys not listed in ascending order",
+				"0 common prefixes listed with the delimiter, expected 5",
+				"1060 keys listed with the delimiter, expected 1050",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			_, alt := newFakeS3Store(t, tt.fake)
+
+			res, err := alt.ProbePagination(context.Background())
+			r.NoError(err)
+			r.Equal(1060, res.Written)
+			r.Equal(tt.wantPages, res.Pages)
+			r.Equal(tt.wantProblems, res.Problems())
+			r.Empty(tt.fake.objects, "the objects of the probe are deleted")
+		})
+	}
+}
+
+func TestPaginationProblems(t *testing.T) {
+	r := require.New(t)
+	p := &Pagination{Written: 1060, Listed: 1060, Duplicates: 3, Unordered: true, TokenMissing: true, Loop: true,
+		Prefixes: paginationDirs, DelimitedKeys: 1050}
+	r.Equal([]string{
+		"3 keys listed more than once across pages",
+		"keys not listed in ascending order",
+		"a truncated page has no continuation token",
+		"the continuation tokens do not advance the listing",
+	}, p.Problems())
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Actions probed separately when no configuration works.
+const (
+	ActionListBucket           = "s3:ListBucket"
+	ActionGetObject            = "s3:GetObject"
+	ActionPutObject            = "s3:PutObject"
+	ActionDeleteObject         = "s3:DeleteObject"
+	ActionAbortMultipartUpload = "s3:AbortMultipartUpload"
+)
+
+// deniedCodes are the error codes of the requests rejected because the
+// credentials are not allowed to perform them.
+var deniedCodes = map[string]bool{
+	"AccessDenied":      true,
+	"AllAccessDisabled": true,
+	"Forbidden":         true,
+}
+
+// Permission is the outcome of probing an action on its own.
+type Permission struct {
+	Action  string
+	Allowed bool
+	Denied  bool   // the provider rejected the credentials for the action
+	Code    string // error code returned by the provider, if any
+	Err     string // reason the action failed, if it did
+}
+
+// PermissionError is returned when no configuration works. It reports
+// the outcome of probing each action required by backups separately.
+type PermissionError struct {
+	Permissions []Permission
+	err         error
+}
+
+// Error implements error.
+func (e *PermissionError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error reported by the search for a configuration.
+func (e *PermissionError) Unwrap() error {
+	return e.err
+}
+
+// newPermission returns the permission of an action, given the error
+// returned by its probe. Errors reporting that the target of the request
+// does not exist, listed in absent, show that the action is allowed.
+func newPermission(action string, err error, absent string) Permission {
+	if err == nil {
+		return Permission{Action: action, Allowed: true}
+	}
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return Permission{Action: action, Err: err.Error()}
+	}
+	code := apiErr.ErrorCode()
+	if code == absent {
+		return Permission{Action: action, Allowed: true, Code: code}
+	}
+	msg := apiErr.ErrorMessage()
+	if msg == "" {
+		msg = code
+	}
+	return Permission{Action: action, Denied: deniedCodes[code], Code: code, Err: msg}
+}
+
+// diagnosePermissions probes the actions required by backups one at a
+// time, so that the ones denied to the credentials can be told apart. The
+// object read is the one written by the probe, if the write is allowed:
+// without s3:ListBucket, providers deny reads of missing objects.
+func (s *s3Store) diagnosePermissions(ctx context.Context, params Params, bucketName string) []Permission {
+	client, err := s.newClient(ctx, params, s.caFile != "", false)
+	if err != nil {
+		slog.Debug("failed to create the permission diagnosis client", slog.Any("error", err))
+		return nil
+	}
+	bucket := aws.String(bucketName)
+	key := aws.String(path.Join(s.keyPrefix(), s.objects.name("permissions")))
+	var payer types.RequestPayer
+	if params.Bool(RequesterPaysParam) {
+		payer = types.RequestPayerRequester
+	}
+	checks := []struct {
+		action string
+		absent string
+		fn     func() error
+	}{
+		{action: ActionListBucket, fn: func() error {
+			_, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+				Bucket:       bucket,
+				Prefix:       aws.String(s.keyPrefix()),
+				MaxKeys:      aws.Int32(1),
+				RequestPayer: payer,
+			})
+			return err
+		}},
+		{action: ActionPutObject, fn: func() error {
+			_, err := client.PutObject(ctx, &s3.PutObjectInput{
+				Bucket:               bucket,
+				Key:                  key,
+				Body:                 strings.NewReader(s.objects.content()),
+				Metadata:             s.objects.metadata(),
+				ServerSideEncryption: serverSideEncryption(params),
+				SSEKMSKeyId:          kmsKeyID(params),
+				RequestPayer:         payer,
+			})
+			return err
+		}},
+		{action: ActionGetObject, absent: "NoSuchKey", fn: func() error {
+			out, err := client.GetObject(ctx, &s3.GetObjectInput{
+				Bucket:       bucket,
+				Key:          key,
+				RequestPayer: payer,
+			})
+			if err == nil {
+				out.Body.Close()
+			}
+			return err
+		}},
+		{action: ActionDeleteObject, fn: func() error {
+			_, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket:       bucket,
+				Key:          key,
+				RequestPayer: payer,
+			})
+			return err
+		}},
+		{action: ActionAbortMultipartUpload, absent: "NoSuchUpload", fn: func() error {
+			// Providers check the permission before looking up the upload.
+			_, err := client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:       bucket,
+				Key:          key,
+				UploadId:     aws.String("blobcheck-permission-probe"),
+				RequestPayer: payer,
+			})
+			return err
+		}},
+	}
+	res := make([]Permission, 0, len(checks))
+	for _, c := range checks {
+		p := newPermission(c.action, c.fn(), c.absent)
+		slog.Debug("permission probed", slog.String("action", p.Action),
+			slog.Bool("allowed", p.Allowed), slog.String("code", p.Code))
+		res = append(res, p)
+	}
+	return res
+}
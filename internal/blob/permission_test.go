@@ -0,0 +1,43 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestDiagnosePermissions(t *testing.T) {
+	r := require.New(t)
+	s, _ := fakeS3Stores(t, &fakeS3{denyWrites: true}, func(s *s3Store) {
+		s.params[RegionParam] = "us-west-2"
+	})
+	_, err := s.try(context.Background(), s.BucketName())
+	r.ErrorContains(err, "unable to connect to storage provider")
+	var permErr *PermissionError
+	r.True(errors.As(err, &permErr))
+	assert.Equal(t, []Permission{
+		{Action: ActionListBucket, Allowed: true},
+		{Action: ActionPutObject, Denied: true, Code: "AccessDenied", Err: "Access Denied"},
+		{Action: ActionGetObject, Allowed: true, Code: "NoSuchKey"},
+		{Action: ActionDeleteObject, Allowed: true},
+		{Action: ActionAbortMultipartUpload, Code: "NotImplemented", Err: "UnknownError"},
+	}, permErr.Permissions)
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"cmp"
+	"crypto/rand"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+const (
+	// defaultProbeKey is the base name of the objects written by the probes.
+	defaultProbeKey = "_blobcheck"
+	// defaultProbeMarker is the content of the objects written by the probes.
+	defaultProbeMarker = "dummy_data"
+	// ProbeTagMetadata is the user metadata key explaining the purpose of the
+	// objects written by the probes.
+	ProbeTagMetadata = "blobcheck-purpose"
+)
+
+// ProbeObject describes the objects written by the probes, so that the
+// security scanners of the customer can recognize them. The zero value
+// describes the default objects.
+type ProbeObject struct {
+	Key    string // base name of the objects
+	Marker string // content of the objects
+	Tag    string // purpose of the objects, attached as user metadata where supported
+	Size   int64  // size of the objects written by the S3 probe, padded after the marker (0 for the marker only)
+	Count  int    // number of objects written by the S3 probe (0 for one)
+}
+
+// ProbeObjectFromEnv returns the probe objects configured in the
+// environment.
+func ProbeObjectFromEnv(env *env.Env) ProbeObject {
+	return ProbeObject{
+		Key:    cmp.Or(env.ProbeKey, defaultProbeKey),
+		Marker: cmp.Or(env.ProbeMarker, defaultProbeMarker),
+		Tag:    env.ProbeTag,
+		Size:   env.ProbeSize,
+		Count:  env.ProbeCount,
+	}
+}
+
+// Validate checks that the objects can be written under the destination,
+// and that the content is long enough for the ranged reads.
+func (p ProbeObject) Validate() error {
+	if strings.Contains(p.Key, "/") {
+		return errors.Newf("invalid probe key %q: must not contain '/'", p.Key)
+	}
+	if p.Marker != "" && len(p.Marker) < rangeLength {
+		return errors.Newf("invalid probe marker %q: must have at least %d characters", p.Marker, rangeLength)
+	}
+	if p.Size < 0 {
+		return errors.Newf("invalid probe size %d: must not be negative", p.Size)
+	}
+	if p.Count < 0 {
+		return errors.Newf("invalid probe count %d: must not be negative", p.Count)
+	}
+	return nil
+}
+
+// key returns the base name of the objects.
+func (p ProbeObject) key() string {
+	return cmp.Or(p.Key, defaultProbeKey)
+}
+
+// content returns the content of the objects.
+func (p ProbeObject) content() string {
+	return cmp.Or(p.Marker, defaultProbeMarker)
+}
+
+// sized returns whether the objects of the S3 probe are padded to a
+// configured size, so that their transfer throughput is meaningful.
+func (p ProbeObject) sized() bool {
+	return p.Size > int64(len(p.content()))
+}
+
+// count returns the number of objects written by the S3 probe.
+func (p ProbeObject) count() int {
+	return max(p.Count, 1)
+}
+
+// payload returns the content of an object of the S3 probe: the marker,
+// padded with random bytes up to the configured size, so that the transfer
+// cannot be compressed on the way.
+func (p ProbeObject) payload() []byte {
+	content := p.content()
+	if !p.sized() {
+		return []byte(content)
+	}
+	payload := make([]byte, p.Size)
+	rand.Read(payload[copy(payload, content):])
+	return payload
+}
+
+// name returns the name of the objects of a probe, relative to the
+// destination.
+func (p ProbeObject) name(probe string) string {
+	return p.key() + "_" + probe
+}
+
+// metadata returns the user metadata of the objects, if any.
+func (p ProbeObject) metadata() map[string]string {
+	if p.Tag == "" {
+		return nil
+	}
+	return map[string]string{ProbeTagMetadata: p.Tag}
+}
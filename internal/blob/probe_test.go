@@ -0,0 +1,125 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestProbeObjectFromEnv(t *testing.T) {
+	assert.Equal(t, ProbeObject{Key: defaultProbeKey, Marker: defaultProbeMarker}, ProbeObjectFromEnv(&env.Env{}))
+	assert.Equal(t, ProbeObject{Key: "_acme", Marker: "acme_marker", Tag: "dr drill"},
+		ProbeObjectFromEnv(&env.Env{ProbeKey: "_acme", ProbeMarker: "acme_marker", ProbeTag: "dr drill"}))
+	assert.Equal(t, ProbeObject{Key: defaultProbeKey, Marker: defaultProbeMarker, Size: 1 << 20, Count: 4},
+		ProbeObjectFromEnv(&env.Env{ProbeSize: 1 << 20, ProbeCount: 4}))
+}
+
+func TestProbeObjectValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		probe   ProbeObject
+		wantErr string
+	}{
+		{name: "default"},
+		{name: "custom", probe: ProbeObject{Key: "_acme", Marker: "acme_marker"}},
+		{name: "nested key", probe: ProbeObject{Key: "scan/_acme"}, wantErr: "must not contain '/'"},
+		{name: "short marker", probe: ProbeObject{Marker: "ab"}, wantErr: "at least"},
+		{name: "sized", probe: ProbeObject{Size: 16 << 20, Count: 4}},
+		{name: "negative size", probe: ProbeObject{Size: -1}, wantErr: "invalid probe size"},
+		{name: "negative count", probe: ProbeObject{Count: -1}, wantErr: "invalid probe count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.probe.Validate()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestProbeObjectWritten(t *testing.T) {
+	r := require.New(t)
+	fake := &fakeS3{multipart: true, ranges: true}
+	probe := ProbeObject{Key: "_acme", Marker: "acme_marker", Tag: "dr drill"}
+	_, alt := newFakeS3Store(t, fake, func(s *s3Store) { s.objects = probe })
+	_, err := alt.Capabilities(context.Background())
+	r.NoError(err)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	r.NotEmpty(fake.written)
+	for name, tag := range fake.written {
+		assert.True(t, strings.HasPrefix(name, "/bucket/path/_acme_"), name)
+		assert.Equal(t, "dr drill", tag, name)
+	}
+}
+
+func TestProbeObjectPayload(t *testing.T) {
+	probe := ProbeObject{Marker: "acme_marker"}
+	assert.Equal(t, []byte("acme_marker"), probe.payload())
+	assert.False(t, probe.sized())
+
+	probe.Size = 1 << 10
+	payload := probe.payload()
+	assert.True(t, probe.sized())
+	assert.Len(t, payload, 1<<10)
+	assert.True(t, strings.HasPrefix(string(payload), "acme_marker"))
+	// The padding is random, so that the transfers cannot be compressed.
+	assert.NotEqual(t, payload, probe.payload())
+}
+
+func TestProbeThroughput(t *testing.T) {
+	tests := []struct {
+		name     string
+		truncate int
+		wantErr  string
+	}{
+		{name: "intact"},
+		{name: "truncated", truncate: 1 << 10, wantErr: "unexpected content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			fake := &fakeS3{truncate: tt.truncate}
+			probe := ProbeObject{Size: 64 << 10, Count: 3}
+			s, alt := fakeS3Stores(t, fake, func(s *s3Store) { s.objects = probe })
+			err := s.probe(context.Background(), alt, s.BucketName())
+			if tt.wantErr != "" {
+				r.ErrorContains(err, tt.wantErr)
+				r.True(errors.Is(err, errAbort))
+				return
+			}
+			r.NoError(err)
+			r.Positive(alt.latency.Upload)
+			r.Positive(alt.latency.Download)
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			r.Len(fake.written, 3)
+			r.Empty(fake.objects)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// BypassProxy marks the configurations that connect to the storage
+// directly, instead of through the proxy.
+const BypassProxy = "BYPASS_PROXY"
+
+// Environment variables configuring the proxy when the flags are not set,
+// in the order they are looked up. The lower case forms are the ones curl
+// honors.
+var (
+	proxyEnvs   = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"}
+	noProxyEnvs = []string{"NO_PROXY", "no_proxy"}
+)
+
+// proxyConfig is the HTTP proxy the requests to the storage go through.
+type proxyConfig struct {
+	url     *url.URL
+	noProxy string
+	proxy   func(*url.URL) (*url.URL, error)
+}
+
+// proxyFromEnv returns the proxy set with --proxy and --no-proxy, or with
+// the standard environment variables, or nil if there is none.
+func proxyFromEnv(env *env.Env) (*proxyConfig, error) {
+	proxy, noProxy := env.Proxy, env.NoProxy
+	if proxy == "" {
+		proxy = lookupFirst(env, proxyEnvs)
+	}
+	if proxy == "" {
+		return nil, nil
+	}
+	if noProxy == "" {
+		noProxy = lookupFirst(env, noProxyEnvs)
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid proxy %q", proxy)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, errors.Newf("invalid proxy %q: expected http://host:port or https://host:port", u.Redacted())
+	}
+	config := httpproxy.Config{HTTPProxy: proxy, HTTPSProxy: proxy, NoProxy: noProxy}
+	return &proxyConfig{url: u, noProxy: noProxy, proxy: config.ProxyFunc()}, nil
+}
+
+// lookupFirst returns the first environment variable that is set.
+func lookupFirst(env *env.Env, names []string) string {
+	if env.LookupEnv == nil {
+		return ""
+	}
+	for _, name := range names {
+		if v, ok := env.LookupEnv(name); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// transportProxy returns the proxy function of the HTTP transport. The
+// hosts in the no proxy list, and the loopback addresses, are reached
+// directly.
+func (p *proxyConfig) transportProxy(req *http.Request) (*url.URL, error) {
+	return p.proxy(req.URL)
+}
+
+// ProxyCheck reports whether the storage is reachable through the proxy.
+type ProxyCheck struct {
+	Proxy    string // URL of the proxy, with the password redacted
+	NoProxy  string // hosts reached directly
+	Bypassed bool   // the suggested configuration only works without the proxy
+	Err      string // why the last configuration probed through the proxy failed, if bypassed
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Run groups the objects written by a single blobcheck run. Each run stores
+// its backup collections under a unique (UUID) sub-path of the destination.
+type Run struct {
+	Prefix       string
+	Objects      []Object
+	Size         int64
+	LastModified time.Time
+}
+
+// PruneResult summarizes the outcome of pruning blobcheck runs.
+type PruneResult struct {
+	Runs      int   // number of runs whose objects were all deleted
+	Partial   int   // number of runs with some objects left because of object lock
+	Deleted   int   // number of objects deleted
+	Locked    int   // number of objects skipped because of object lock
+	Reclaimed int64 // bytes reclaimed
+}
+
+// Runs groups the objects by the blobcheck run that created them, most recent
+// first. Objects that are not under a UUID sub-path were not created by
+// blobcheck and are ignored.
+func Runs(objects []Object) []Run {
+	byPrefix := make(map[string]*Run)
+	for _, obj := range objects {
+		prefix, _, ok := strings.Cut(obj.Key, "/")
+		if !ok || uuid.Validate(prefix) != nil {
+			continue
+		}
+		run, ok := byPrefix[prefix]
+		if !ok {
+			run = &Run{Prefix: prefix}
+			byPrefix[prefix] = run
+		}
+		run.Objects = append(run.Objects, obj)
+		run.Size += obj.Size
+		if obj.LastModified.After(run.LastModified) {
+			run.LastModified = obj.LastModified
+		}
+	}
+	res := make([]Run, 0, len(byPrefix))
+	for _, run := range byPrefix {
+		res = append(res, *run)
+	}
+	slices.SortFunc(res, func(a, b Run) int {
+		return b.LastModified.Compare(a.LastModified)
+	})
+	return res
+}
+
+// VersionedStorage is implemented by the stores of buckets that may keep the
+// previous versions of the objects, which Delete only hides behind a delete
+// marker.
+type VersionedStorage interface {
+	Storage
+	// Versioned returns whether the bucket keeps the versions of the objects.
+	Versioned(ctx context.Context) (bool, error)
+	// DeleteVersions permanently deletes every version and delete marker of
+	// the object, and returns the bytes freed.
+	DeleteVersions(ctx context.Context, key string) (int64, error)
+}
+
+// Prune deletes the objects of the given runs. In versioned buckets, every
+// version of the objects is deleted, since a delete marker does not reclaim
+// any space. Objects protected by object lock are skipped and counted in the
+// result; the runs they belong to are reported as partially pruned.
+func Prune(ctx context.Context, store Storage, runs []Run) (*PruneResult, error) {
+	deleteObject := func(obj Object) (int64, error) {
+		if err := store.Delete(ctx, obj.Key); err != nil {
+			return 0, err
+		}
+		return obj.Size, nil
+	}
+	if vs, ok := store.(VersionedStorage); ok {
+		versioned, err := vs.Versioned(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if versioned {
+			deleteObject = func(obj Object) (int64, error) {
+				return vs.DeleteVersions(ctx, obj.Key)
+			}
+		}
+	}
+	res := &PruneResult{}
+	for _, run := range runs {
+		locked := 0
+		for _, obj := range run.Objects {
+			freed, err := deleteObject(obj)
+			if errors.Is(err, ErrLocked) {
+				slog.Warn("skipping locked object", slog.String("key", obj.Key))
+				// Unlocked versions may have been deleted.
+				res.Reclaimed += freed
+				locked++
+				continue
+			}
+			if err != nil {
+				res.Locked += locked
+				return res, err
+			}
+			res.Deleted++
+			res.Reclaimed += freed
+		}
+		res.Locked += locked
+		if locked > 0 {
+			res.Partial++
+		} else {
+			res.Runs++
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	run1 = "5f0c6a9e-0d0f-4a6c-9a3e-2a6f0c1b2d3e"
+	run2 = "8b1d7c2f-1e2a-4b7d-8c4f-3b7a1d2c3e4f"
+)
+
+// fakeStorage records deleted keys, and reports the keys in locked as
+// protected by object lock. It stands for an S3 store, whose other methods
+// are not called.
+type fakeStorage struct {
+	s3Storage
+	locked  map[string]bool
+	deleted []string
+}
+
+func (f *fakeStorage) Delete(_ context.Context, key string) error {
+	if f.locked[key] {
+		return ErrLocked
+	}
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+// Versioned implements VersionedStorage. The bucket is not versioned.
+func (f *fakeStorage) Versioned(context.Context) (bool, error) {
+	return false, nil
+}
+
+func TestRuns(t *testing.T) {
+	a := assert.New(t)
+	now := time.Now()
+	runs := Runs([]Object{
+		{Key: run1 + "/2025/06/01/BACKUP_MANIFEST", Size: 10, LastModified: now.Add(-time.Hour)},
+		{Key: run1 + "/2025/06/01/data/1.sst", Size: 100, LastModified: now.Add(-2 * time.Hour)},
+		{Key: run2 + "/2025/06/02/BACKUP_MANIFEST", Size: 20, LastModified: now},
+		{Key: "customer/2025/06/01/BACKUP_MANIFEST", Size: 1000, LastModified: now},
+		{Key: "stray", Size: 1, LastModified: now},
+	})
+	a.Len(runs, 2)
+	a.Equal(run2, runs[0].Prefix)
+	a.Equal(run1, runs[1].Prefix)
+	a.Equal(int64(110), runs[1].Size)
+	a.Equal(now.Add(-time.Hour), runs[1].LastModified)
+}
+
+func TestPrune(t *testing.T) {
+	a := require.New(t)
+	locked := run1 + "/data/2.sst"
+	store := &fakeStorage{locked: map[string]bool{locked: true}}
+	res, err := Prune(context.Background(), store, []Run{{
+		Prefix: run1,
+		Objects: []Object{
+			{Key: run1 + "/data/1.sst", Size: 100},
+			{Key: locked, Size: 50},
+		},
+	}})
+	a.NoError(err)
+	a.Equal(&PruneResult{Partial: 1, Deleted: 1, Locked: 1, Reclaimed: 100}, res)
+	a.Equal([]string{run1 + "/data/1.sst"}, store.deleted)
+
+	store = &fakeStorage{}
+	res, err = Prune(context.Background(), store, []Run{{
+		Prefix:  run1,
+		Objects: []Object{{Key: run1 + "/data/1.sst", Size: 100}, {Key: run1 + "/data/2.sst", Size: 50}},
+	}})
+	a.NoError(err)
+	a.Equal(&PruneResult{Runs: 1, Deleted: 2, Reclaimed: 150}, res)
+}
+
+func TestPruneVersions(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	fake := &fakeS3{versioning: VersioningEnabled, objectLock: "<ObjectLockConfiguration/>"}
+	_, alt := newFakeS3Store(t, fake)
+	put := func(key, body string) {
+		_, err := alt.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String("bucket"), Key: aws.String("path/" + key), Body: strings.NewReader(body),
+		})
+		r.NoError(err)
+	}
+	put(run1+"/data/1.sst", "old")
+	put(run1+"/data/1.sst", "current")
+	put(run1+"/data/2.sst", "locked")
+	put(run1+"/data/2.sst", "free")
+	fake.mu.Lock()
+	fake.retained = map[string]bool{"/bucket/path/" + run1 + "/data/2.sst?" + fake.versions["/bucket/path/"+run1+"/data/2.sst"][0].id: true}
+	fake.mu.Unlock()
+
+	// The older versions are deleted as well, except for the retained one.
+	res, err := Prune(ctx, alt, []Run{{
+		Prefix: run1,
+		Objects: []Object{
+			{Key: run1 + "/data/1.sst", Size: 7},
+			{Key: run1 + "/data/2.sst", Size: 4},
+		},
+	}})
+	r.NoError(err)
+	r.Equal(&PruneResult{Partial: 1, Deleted: 1, Locked: 1, Reclaimed: 14}, res)
+	fake.mu.Lock()
+	r.Empty(fake.versions["/bucket/path/"+run1+"/data/1.sst"])
+	r.Len(fake.versions["/bucket/path/"+run1+"/data/2.sst"], 1)
+	fake.mu.Unlock()
+
+	// Errors reading the retention are not taken as an unlocked object.
+	fake.denyRetention = true
+	_, err = Prune(ctx, alt, []Run{{Prefix: run1, Objects: []Object{{Key: run1 + "/data/2.sst", Size: 6}}}})
+	r.ErrorContains(err, "AccessDenied")
+}
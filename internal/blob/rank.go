@@ -0,0 +1,166 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Candidate is a working configuration found while probing the storage,
+// scored so that users can trade security for compatibility or speed.
+type Candidate struct {
+	Flags    Params        // boolean parameters set to true, and server side encryption, of the configuration
+	Security int           // 2 points for TLS verification, 1 for checksums
+	Latency  time.Duration // time taken by the probe operations
+	Upload   float64       // effective upload throughput in bytes per second, if the probe objects are sized
+	Download float64       // effective download throughput in bytes per second, if the probe objects are sized
+}
+
+// Probed is a candidate configuration probed locally, with the outcome of
+// the probe.
+type Probed struct {
+	Config Storage // the storage with the configuration, whose URL the cluster can check
+	Flags  Params  // as in Candidate, and the region if it was discovered
+	Err    error   // why the probe failed, nil if the configuration works
+}
+
+// TLSVerify reports whether the configuration verifies the certificate of
+// the storage.
+func (c Candidate) TLSVerify() bool {
+	return !c.Flags.Bool(SkipTLSVerify) && !c.Flags.Bool(HTTPSkipTLSVerify)
+}
+
+// MaxSecurity is the security score of a configuration that verifies TLS
+// certificates and checksums.
+const MaxSecurity = 3
+
+// configFlags returns the boolean parameters set to true, and the server
+// side encryption, of a configuration.
+func configFlags(params Params) Params {
+	flags := make(Params)
+	for _, key := range boolParams {
+		if params.Bool(key) {
+			flags[key] = "true"
+		}
+	}
+	if mode := params[ServerEncModeParam]; mode != "" {
+		flags[ServerEncModeParam] = mode
+	}
+	return flags
+}
+
+// candidateConfig is a store with one of the candidate configurations of
+// its backend, which the selection probes, ranks and shows to the operator.
+type candidateConfig interface {
+	Storage
+	comparable
+	// candidateFlags returns the flags of the configuration.
+	candidateFlags() Params
+	// candidate scores the configuration, once it passed the probe.
+	candidate(latency time.Duration) Candidate
+}
+
+// candidateFlags returns the flags of a candidate configuration, including
+// how it verifies and reaches the endpoint.
+func (s *s3Store) candidateFlags() Params {
+	flags := configFlags(s.params)
+	if s.customCA {
+		flags[HTTPCustomCA] = "true"
+	}
+	if s.bypassProxy {
+		flags[BypassProxy] = "true"
+	}
+	return flags
+}
+
+// candidate scores a configuration that passed the probe, with the
+// throughput of the probe objects, if they are sized.
+func (s *s3Store) candidate(latency time.Duration) Candidate {
+	c := newCandidate(s.params, latency)
+	c.Flags = s.candidateFlags()
+	if l := s.latency; l != nil {
+		c.Upload, c.Download = l.Upload, l.Download
+	}
+	return c
+}
+
+// newCandidate scores a configuration that passed the probe.
+func newCandidate(params Params, latency time.Duration) Candidate {
+	c := Candidate{Flags: configFlags(params), Latency: latency}
+	if !params.Bool(SkipTLSVerify) {
+		c.Security += 2
+	}
+	if !params.Bool(SkipChecksum) {
+		c.Security++
+	}
+	return c
+}
+
+// rank sorts the candidates from the most secure to the least secure,
+// breaking ties by latency.
+func rank(candidates []Candidate) {
+	slices.SortStableFunc(candidates, func(a, b Candidate) int {
+		return cmp.Or(cmp.Compare(b.Security, a.Security), cmp.Compare(a.Latency, b.Latency))
+	})
+}
+
+// probed is the outcome of probing a candidate configuration.
+type probed[T candidateConfig] struct {
+	alt     T
+	err     error
+	latency time.Duration
+}
+
+// probeAll probes every candidate configuration, instead of stopping at the
+// first that works. It returns the ranked working configurations, and
+// replays the outcomes so that the selection does not probe again. Probe
+// failures marked with errAbort stop the evaluation.
+func probeAll[T candidateConfig](
+	ctx context.Context, candidates iter.Seq[Storage], probe func(context.Context, T) error,
+) ([]Candidate, iter.Seq[Storage], func(context.Context, T) error, error) {
+	var outcomes []probed[T]
+	for candidate := range candidates {
+		alt := candidate.(T)
+		start := time.Now()
+		err := probe(ctx, alt)
+		if errors.Is(err, errAbort) {
+			return nil, nil, nil, err
+		}
+		outcomes = append(outcomes, probed[T]{alt: alt, err: err, latency: time.Since(start)})
+	}
+	var ranked []Candidate
+	results := make(map[T]error, len(outcomes))
+	for _, o := range outcomes {
+		results[o.alt] = o.err
+		if o.err == nil {
+			ranked = append(ranked, o.alt.candidate(o.latency))
+		}
+	}
+	rank(ranked)
+	replay := func(yield func(Storage) bool) {
+		for _, o := range outcomes {
+			if !yield(o.alt) {
+				return
+			}
+		}
+	}
+	return ranked, replay, func(_ context.Context, alt T) error { return results[alt] }, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestRank(t *testing.T) {
+	candidates := []Candidate{
+		newCandidate(Params{SkipTLSVerify: "true"}, 10*time.Millisecond),
+		newCandidate(Params{SkipChecksum: "true"}, 20*time.Millisecond),
+		newCandidate(Params{UsePathStyleParam: "true"}, 30*time.Millisecond),
+		newCandidate(Params{UsePathStyleParam: "false"}, 25*time.Millisecond),
+	}
+	rank(candidates)
+	assert.Equal(t, []Candidate{
+		{Flags: Params{}, Security: 3, Latency: 25 * time.Millisecond},
+		{Flags: Params{UsePathStyleParam: "true"}, Security: 3, Latency: 30 * time.Millisecond},
+		{Flags: Params{SkipChecksum: "true"}, Security: 2, Latency: 20 * time.Millisecond},
+		{Flags: Params{SkipTLSVerify: "true"}, Security: 1, Latency: 10 * time.Millisecond},
+	}, candidates)
+}
+
+func TestProbeAll(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+	initial := &s3Store{dest: "bucket/key", params: Params{RegionParam: "us-east-1"}}
+	probes := 0
+	// The provider only accepts path style requests, without encryption
+	// headers.
+	probe := func(_ context.Context, alt *s3Store) error {
+		probes++
+		if !alt.params.Bool(UsePathStyleParam) {
+			return errors.New("no such host")
+		}
+		if alt.params[ServerEncModeParam] != "" {
+			return errors.New("NotImplemented")
+		}
+		return nil
+	}
+	ranked, candidates, cached, err := probeAll(t.Context(), initial.candidateConfigs(), probe)
+	r.NoError(err)
+	a.Equal(16, probes)
+	r.Len(ranked, 4)
+	a.Equal(Params{UsePathStyleParam: "true"}, ranked[0].Flags)
+	a.Equal(MaxSecurity, ranked[0].Security)
+
+	// Selection replays the outcomes without probing again.
+	alt, ok, err := selectCandidate(t.Context(), candidates, cached)
+	r.NoError(err)
+	r.True(ok)
+	a.Equal(16, probes)
+	a.Equal(Params{RegionParam: "us-east-1", UsePathStyleParam: "true"}, alt.params)
+
+	_, _, _, err = probeAll(t.Context(), initial.candidateConfigs(), func(context.Context, *s3Store) error {
+		return errors.Mark(errors.New("boom"), errAbort)
+	})
+	a.True(errors.Is(err, errAbort))
+}
+
+func TestRecordProbed(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+	initial := &s3Store{dest: "bucket/key", params: Params{RegionParam: "us-east-1"}}
+	probe := func(_ context.Context, alt *s3Store) error {
+		if !alt.params.Bool(UsePathStyleParam) {
+			return errors.New("no such host")
+		}
+		return nil
+	}
+	from := &s3Store{dest: "bucket/key", params: Params{RegionParam: "eu-west-1"}}
+	_, _, _, err := probeAll(t.Context(), from.candidateConfigs(), initial.recordProbed(probe))
+	r.NoError(err)
+	r.Len(initial.probed, 16)
+	var working int
+	for _, p := range initial.probed {
+		a.Equal("eu-west-1", p.Flags[RegionParam])
+		if p.Err == nil {
+			working++
+			a.Equal("true", p.Flags[UsePathStyleParam])
+		}
+	}
+	a.Equal(8, working)
+
+	initial.probed = nil
+	_, _, _, err = probeAll(t.Context(), from.candidateConfigs(), initial.recordProbed(
+		func(context.Context, *s3Store) error { return errors.Mark(errors.New("boom"), errAbort) }))
+	a.True(errors.Is(err, errAbort))
+	a.Empty(initial.probed)
+}
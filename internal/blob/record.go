@@ -0,0 +1,225 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/aws/smithy-go"
+	"github.com/cockroachdb/errors"
+)
+
+// Operations captured in a recording.
+const (
+	OpOpen   = "open"   // the destination is opened, with the initial parameters
+	OpProbe  = "probe"  // a candidate configuration is probed
+	OpRegion = "region" // the region of the bucket, in the key, is discovered after the candidates failed
+	OpClean  = "clean"  // the destination is cleaned
+	OpDelete = "delete" // an object is deleted
+	OpList   = "list"   // the objects are listed
+)
+
+// Event is a storage operation and its outcome. Secrets in the parameters
+// are obfuscated.
+type Event struct {
+	Op      string   `json:"op"`
+	Key     string   `json:"key,omitempty"`
+	Params  Params   `json:"params,omitempty"`
+	Objects []Object `json:"objects,omitempty"`
+	Err     string   `json:"error,omitempty"`
+	Abort   bool     `json:"abort,omitempty"`  // the error stopped the search for a configuration
+	Denied  bool     `json:"denied,omitempty"` // the provider denied access to the probe
+	Proxy   bool     `json:"proxy,omitempty"`  // the destination is opened through a proxy
+	Direct  bool     `json:"direct,omitempty"` // the candidate bypasses the proxy
+	// Requests are the timings of the attempts of the requests sent by a
+	// probe, in the order they completed.
+	Requests []RequestMetrics `json:"requests,omitempty"`
+}
+
+// err returns the recorded error, or nil if the operation succeeded.
+func (e Event) err() error {
+	if e.Err == "" {
+		return nil
+	}
+	err := errors.New(e.Err)
+	if e.Denied {
+		// The requester pays retry is driven by access denied errors.
+		err = &smithy.GenericAPIError{Code: "AccessDenied", Message: e.Err}
+	}
+	if e.Abort {
+		return errors.Mark(err, errAbort)
+	}
+	return err
+}
+
+// probeEvent returns the event recording the outcome of probing a candidate.
+func probeEvent(alt *s3Store, err error) Event {
+	e := Event{Op: OpProbe, Params: alt.Params(), Direct: alt.bypassProxy, Requests: alt.requests}
+	if err != nil {
+		e.Err = err.Error()
+		e.Abort = errors.Is(err, errAbort)
+		e.Denied = isAccessDenied(err)
+	}
+	return e
+}
+
+// Recorder writes the storage operations, one JSON object per line, so that
+// the selection of the configuration can be replayed later. A nil Recorder
+// discards the events.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecorder returns a recorder writing to w, or nil if w is nil.
+func NewRecorder(w io.Writer) *Recorder {
+	if w == nil {
+		return nil
+	}
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// record writes an event. Failures are logged, since the recording must not
+// affect the validation.
+func (r *Recorder) record(e Event) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(e); err != nil {
+		slog.Warn("failed to record storage operation", slog.String("op", e.Op), slog.Any("error", err))
+	}
+}
+
+// wrap returns a store that records the operations performed on store.
+func (r *Recorder) wrap(store s3Storage) s3Storage {
+	if r == nil {
+		return store
+	}
+	return &recordingStorage{s3Storage: store, recorder: r}
+}
+
+// recordingStorage is a Storage decorator that records the operations that
+// modify or inspect the destination.
+type recordingStorage struct {
+	s3Storage
+	recorder *Recorder
+}
+
+var _ s3Storage = &recordingStorage{}
+
+// Clean implements Storage.
+func (s *recordingStorage) Clean(ctx context.Context) error {
+	err := s.s3Storage.Clean(ctx)
+	s.recorder.record(withErr(Event{Op: OpClean}, err))
+	return err
+}
+
+// Delete implements Storage.
+func (s *recordingStorage) Delete(ctx context.Context, key string) error {
+	err := s.s3Storage.Delete(ctx, key)
+	s.recorder.record(withErr(Event{Op: OpDelete, Key: key}, err))
+	return err
+}
+
+// List implements Storage.
+func (s *recordingStorage) List(ctx context.Context) ([]Object, error) {
+	objects, err := s.s3Storage.List(ctx)
+	s.recorder.record(withErr(Event{Op: OpList, Objects: objects}, err))
+	return objects, err
+}
+
+// withErr adds the error, if any, to the event.
+func withErr(e Event, err error) Event {
+	if err != nil {
+		e.Err = err.Error()
+	}
+	return e
+}
+
+// Replay re-runs the selection of the configuration against a recording:
+// the candidates are generated from the initial parameters of the first
+// destination opened in the recording, and each probe returns the recorded
+// outcome instead of contacting the storage provider. The retries with the
+// region of the bucket, as a requester pays bucket and without the proxy
+// follow the recording as well. It returns the parameters of the selected
+// candidate, after minimization.
+func Replay(r io.Reader) (Params, error) {
+	var open *Event
+	var region string
+	probes := make(map[string]Event)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, errors.Wrap(err, "invalid recording")
+		}
+		if e.Op == OpOpen {
+			if open != nil {
+				// Only the first attempt is replayed.
+				break
+			}
+			open = &e
+		}
+		if e.Op == OpProbe {
+			probes[probeKey(e.Params, e.Direct)] = e
+		}
+		if e.Op == OpRegion && open != nil {
+			region = e.Key
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read recording")
+	}
+	if open == nil {
+		return nil, errors.New("the recording does not open a destination")
+	}
+	initial := &s3Store{dest: open.Key, root: open.Key, params: open.Params}
+	if open.Proxy {
+		// Only the presence of the proxy matters to the candidates.
+		initial.proxy = &proxyConfig{}
+	}
+	probe := func(_ context.Context, alt *s3Store) error {
+		e, found := probes[probeKey(alt.Params(), alt.bypassProxy)]
+		if !found {
+			return errors.Mark(errors.Newf("candidate %v is not in the recording", alt.Params()), errAbort)
+		}
+		return e.err()
+	}
+	_, alt, ok, err := initial.selectConfig(context.Background(), probe,
+		func(context.Context) string { return region })
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.Newf("unable to connect to storage provider %q", open.Key)
+	}
+	return minimize(context.Background(), alt, probe).Params(), nil
+}
+
+// probeKey identifies the probe of a candidate in a recording.
+func probeKey(params Params, direct bool) string {
+	if direct {
+		return params.Encode() + "#direct"
+	}
+	return params.Encode()
+}
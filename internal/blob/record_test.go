@@ -0,0 +1,111 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplayTrace replays a recording taken against a MinIO server that
+// only supports path style requests.
+func TestReplayTrace(t *testing.T) {
+	f, err := os.Open("testdata/minio_path_style.jsonl")
+	require.NoError(t, err)
+	defer f.Close()
+	params, err := Replay(f)
+	require.NoError(t, err)
+	assert.Equal(t, "true", params[UsePathStyleParam])
+	assert.Empty(t, params[SkipChecksum])
+	assert.Empty(t, params[SkipTLSVerify])
+}
+
+func TestRecordReplay(t *testing.T) {
+	var buf bytes.Buffer
+	initial := &s3Store{
+		dest:     "bucket/prefix",
+		params:   Params{AccountParam: "id", SecretParam: "secret", RegionParam: DefaultRegion},
+		recorder: NewRecorder(&buf),
+	}
+	initial.recorder.record(Event{Op: OpOpen, Key: initial.dest, Params: initial.Params()})
+	// Only candidates skipping TLS verification work.
+	alt, ok, err := selectCandidate(t.Context(), initial.candidateConfigs(), func(_ context.Context, alt *s3Store) error {
+		var err error
+		if alt.params[SkipTLSVerify] != "true" {
+			err = errors.New("tls: failed to verify certificate")
+		}
+		initial.recorder.record(probeEvent(alt, err))
+		return err
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.NotContains(t, buf.String(), "secret")
+
+	params, err := Replay(strings.NewReader(buf.String()))
+	require.NoError(t, err)
+	assert.Equal(t, alt.Params(), params)
+}
+
+// TestReplayRegion replays a recording in which the candidates only worked
+// once retried with the region of the bucket.
+func TestReplayRegion(t *testing.T) {
+	r := require.New(t)
+	var buf bytes.Buffer
+	s, _ := fakeS3Stores(t, &fakeS3{region: "eu-central-1"}, func(s *s3Store) {
+		s.recorder = NewRecorder(&buf)
+	})
+	store, err := s.try(context.Background(), s.BucketName())
+	r.NoError(err)
+	r.Contains(buf.String(), `{"op":"region","key":"eu-central-1"}`)
+
+	params, err := Replay(&buf)
+	r.NoError(err)
+	r.Equal("eu-central-1", params[RegionParam])
+	r.Equal(store.Params(), params)
+}
+
+func TestReplayAbort(t *testing.T) {
+	a := assert.New(t)
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	initial := &s3Store{dest: "bucket", params: Params{RegionParam: DefaultRegion}}
+	rec.record(Event{Op: OpOpen, Key: initial.dest, Params: initial.Params()})
+	for alt := range initial.candidateConfigs() {
+		rec.record(probeEvent(alt.(*s3Store), errors.Mark(errors.New("unexpected content"), errAbort)))
+		break
+	}
+	_, err := Replay(&buf)
+	a.ErrorContains(err, "unexpected content")
+
+	_, err = Replay(strings.NewReader(""))
+	a.Error(err)
+}
+
+func TestRecordingStorage(t *testing.T) {
+	var buf bytes.Buffer
+	store := NewRecorder(&buf).wrap(&fakeStorage{locked: map[string]bool{"locked": true}})
+	require.NoError(t, store.Delete(context.Background(), "a"))
+	require.ErrorIs(t, store.Delete(context.Background(), "locked"), ErrLocked)
+	assert.Equal(t,
+		`{"op":"delete","key":"a"}`+"\n"+`{"op":"delete","key":"locked","error":"object is locked"}`+"\n",
+		buf.String())
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/cockroachdb/errors"
+)
+
+// discoveryRegion is the region requests discovering the region of a bucket
+// are signed for. Providers redirect them, reporting the region of the
+// bucket, rather than rejecting them as they do with DefaultRegion.
+const discoveryRegion = "us-east-1"
+
+// bucketRegionHeader reports the region of a bucket, also in the responses
+// to requests signed for another region.
+const bucketRegionHeader = "X-Amz-Bucket-Region"
+
+// discoverRegion returns the region of the bucket, as reported by
+// HeadBucket or GetBucketLocation, or "" if the provider does not report
+// it.
+func (s *s3Store) discoverRegion(ctx context.Context, bucketName string) string {
+	params := s.params.Merge(Params{RegionParam: discoveryRegion})
+	if params[EndPointParam] != "" && params[UsePathStyleParam] == "" {
+		// Custom endpoints rarely resolve virtual-hosted bucket names.
+		params[UsePathStyleParam] = "true"
+	}
+	client, err := s.newClient(ctx, params, false, false)
+	if err != nil {
+		slog.Debug("failed to create the region discovery client", slog.Any("error", err))
+		return ""
+	}
+	head, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucketName)})
+	if err == nil {
+		if region := aws.ToString(head.BucketRegion); region != "" {
+			return region
+		}
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		if region := respErr.Response.Header.Get(bucketRegionHeader); region != "" {
+			return region
+		}
+	}
+	location, locErr := client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+		Bucket: aws.String(bucketName),
+	})
+	if locErr == nil {
+		return locationRegion(location.LocationConstraint)
+	}
+	slog.Debug("failed to discover the region of the bucket",
+		slog.Any("head", err), slog.Any("location", locErr))
+	if err == nil {
+		// The bucket accepts requests signed for the discovery region.
+		return discoveryRegion
+	}
+	return ""
+}
+
+// locationRegion returns the region of a location constraint. Buckets in
+// us-east-1 have no location constraint, and the legacy EU constraint
+// stands for eu-west-1.
+func locationRegion(c types.BucketLocationConstraint) string {
+	switch c {
+	case "":
+		return discoveryRegion
+	case types.BucketLocationConstraintEu:
+		return "eu-west-1"
+	default:
+		return string(c)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverRegion(t *testing.T) {
+	r := require.New(t)
+	s, _ := fakeS3Stores(t, &fakeS3{region: "eu-central-1"})
+	r.Equal("eu-central-1", s.discoverRegion(context.Background(), s.BucketName()))
+	store, err := s.try(context.Background(), s.BucketName())
+	r.NoError(err)
+	r.Equal("eu-central-1", store.Params()[RegionParam])
+	suggested, err := ParseS3URL(store.URL())
+	r.NoError(err)
+	r.Equal("eu-central-1", suggested.Params[RegionParam])
+}
+
+func TestLocationRegion(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("us-east-1", locationRegion(""))
+	a.Equal("eu-west-1", locationRegion(types.BucketLocationConstraintEu))
+	a.Equal("ap-south-1", locationRegion(types.BucketLocationConstraintApSouth1))
+}
@@ -0,0 +1,185 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// RequestMetrics are the timings of an attempt of a request sent by the SDK.
+// The connection timings are zero if the attempt reused a connection, or if
+// the connection is established by a proxy or a tunnel.
+type RequestMetrics struct {
+	Operation  string        `json:"operation"`
+	Attempt    int           `json:"attempt"`               // 1 for the first attempt of the request
+	RetryDelay time.Duration `json:"retry_delay,omitempty"` // time since the end of the previous attempt
+	DNS        time.Duration `json:"dns,omitempty"`         // time to resolve the host name
+	Connect    time.Duration `json:"connect,omitempty"`     // time to establish the TCP connection
+	TLS        time.Duration `json:"tls,omitempty"`         // time to complete the TLS handshake
+	TTFB       time.Duration `json:"ttfb,omitempty"`        // time from the request sent to the first response byte
+	Total      time.Duration `json:"total"`
+	Reused     bool          `json:"reused,omitempty"` // the attempt reused a connection
+	Status     int           `json:"status,omitempty"` // HTTP status code of the response, if any
+	Err        string        `json:"error,omitempty"`
+}
+
+// requestLog collects the metrics of the attempts of the requests sent by a
+// client, through middlewares added to its stack.
+type requestLog struct {
+	mu       sync.Mutex
+	attempts []RequestMetrics
+}
+
+// attemptsKey is the stack value holding the attempts of a request.
+type attemptsKey struct{}
+
+// requestAttempts tracks the attempts of a single request.
+type requestAttempts struct {
+	count   int
+	lastEnd time.Time
+}
+
+// addMiddlewares adds to the stack of a client the middlewares timing the
+// attempts of its requests. It is an API option of the client.
+func (l *requestLog) addMiddlewares(stack *middleware.Stack) error {
+	if err := stack.Initialize.Add(middleware.InitializeMiddlewareFunc("blobcheckRequest",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+			middleware.InitializeOutput, middleware.Metadata, error,
+		) {
+			ctx = middleware.WithStackValue(ctx, attemptsKey{}, &requestAttempts{})
+			return next.HandleInitialize(ctx, in)
+		}), middleware.Before); err != nil {
+		return err
+	}
+	// The attempts are timed after the retry and signing middlewares, so
+	// that each attempt is timed separately, without the signature.
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("blobcheckAttempt",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+			middleware.FinalizeOutput, middleware.Metadata, error,
+		) {
+			m := RequestMetrics{Operation: awsmiddleware.GetOperationName(ctx), Attempt: 1}
+			start := time.Now()
+			attempts, _ := middleware.GetStackValue(ctx, attemptsKey{}).(*requestAttempts)
+			if attempts != nil {
+				attempts.count++
+				m.Attempt = attempts.count
+				if !attempts.lastEnd.IsZero() {
+					m.RetryDelay = start.Sub(attempts.lastEnd)
+				}
+			}
+			trace := &attemptTrace{}
+			out, metadata, err := next.HandleFinalize(httptrace.WithClientTrace(ctx, trace.clientTrace()), in)
+			end := time.Now()
+			if attempts != nil {
+				attempts.lastEnd = end
+			}
+			m.Total = end.Sub(start)
+			trace.apply(&m)
+			if resp, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok && resp != nil {
+				m.Status = resp.StatusCode
+			}
+			if err != nil {
+				m.Err = err.Error()
+			}
+			l.mu.Lock()
+			l.attempts = append(l.attempts, m)
+			l.mu.Unlock()
+			return out, metadata, err
+		}), middleware.After)
+}
+
+// snapshot returns the metrics of the attempts collected so far.
+func (l *requestLog) snapshot() []RequestMetrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]RequestMetrics(nil), l.attempts...)
+}
+
+// attemptTrace records the times of the events of an HTTP request. The
+// connection events may be reported by the goroutines dialing the host.
+type attemptTrace struct {
+	mu                        sync.Mutex
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	wrote, firstByte          time.Time
+	reused                    bool
+}
+
+// clientTrace returns the hooks recording the events.
+func (t *attemptTrace) clientTrace() *httptrace.ClientTrace {
+	at := func(field *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		// Only the first event counts, such as the connection that won a
+		// dual-stack race.
+		if field.IsZero() {
+			*field = time.Now()
+		}
+	}
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { at(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { at(&t.dnsDone) },
+		ConnectStart:         func(string, string) { at(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { at(&t.connectDone) },
+		TLSHandshakeStart:    func() { at(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { at(&t.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { at(&t.wrote) },
+		GotFirstResponseByte: func() { at(&t.firstByte) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.reused = info.Reused
+		},
+	}
+}
+
+// apply sets the timings of the recorded events in the metrics.
+func (t *attemptTrace) apply(m *RequestMetrics) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	between := func(start, end time.Time) time.Duration {
+		if start.IsZero() || end.IsZero() {
+			return 0
+		}
+		return end.Sub(start)
+	}
+	m.DNS = between(t.dnsStart, t.dnsDone)
+	m.Connect = between(t.connectStart, t.connectDone)
+	m.TLS = between(t.tlsStart, t.tlsDone)
+	m.TTFB = between(t.wrote, t.firstByte)
+	m.Reused = t.reused
+}
+
+// logRequests logs the metrics of the attempts sent while probing a
+// candidate configuration.
+func logRequests(params Params, attempts []RequestMetrics) {
+	for _, m := range attempts {
+		slog.Debug("probe request", slog.Any("env", params), slog.String("operation", m.Operation),
+			slog.Int("attempt", m.Attempt), slog.Duration("retry_delay", m.RetryDelay),
+			slog.Duration("dns", m.DNS), slog.Duration("connect", m.Connect), slog.Duration("tls", m.TLS),
+			slog.Duration("ttfb", m.TTFB), slog.Duration("total", m.Total), slog.Bool("reused", m.Reused),
+			slog.Int("status", m.Status), slog.String("error", m.Err))
+	}
+}
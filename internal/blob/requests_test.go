@@ -0,0 +1,74 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeRequestMetrics(t *testing.T) {
+	tests := []struct {
+		name       string
+		fake       *fakeS3
+		operations []string
+		statuses   []int
+	}{
+		{
+			name:       "works",
+			fake:       &fakeS3{},
+			operations: []string{"ListObjectsV2", "PutObject", "GetObject", "DeleteObject"},
+			statuses:   []int{200, 200, 200, 204},
+		},
+		{
+			name:       "denied",
+			fake:       &fakeS3{denyWrites: true},
+			operations: []string{"ListObjectsV2", "PutObject"},
+			statuses:   []int{200, 403},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			s, alt := fakeS3Stores(t, tt.fake)
+			err := s.probe(context.Background(), alt, s.BucketName())
+
+			var operations []string
+			var statuses []int
+			for _, m := range alt.requests {
+				operations = append(operations, m.Operation)
+				statuses = append(statuses, m.Status)
+				r.Equal(1, m.Attempt)
+				r.Zero(m.RetryDelay)
+				r.Positive(m.Total)
+				r.GreaterOrEqual(m.Total, m.TTFB)
+			}
+			r.Equal(tt.operations, operations)
+			r.Equal(tt.statuses, statuses)
+			// The first request opens the connection, the others reuse it.
+			r.False(alt.requests[0].Reused)
+			r.Positive(alt.requests[0].Connect)
+			r.True(alt.requests[1].Reused)
+			r.Zero(alt.requests[1].Connect)
+			if err != nil {
+				r.Contains(alt.requests[len(alt.requests)-1].Err, "AccessDenied")
+			}
+			event := probeEvent(alt, err)
+			r.Equal(alt.requests, event.Requests)
+		})
+	}
+}
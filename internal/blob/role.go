@@ -0,0 +1,132 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// Environment variables configuring the role assumed by the probes and by
+// the cluster.
+const (
+	// RoleARNEnv is the ARN of the role to assume, or a comma separated
+	// chain of roles.
+	RoleARNEnv = "BLOBCHECK_ASSUME_ROLE_ARN"
+	// RoleExternalIDEnv is the external ID required by the trust policy of
+	// the last role of the chain.
+	RoleExternalIDEnv = "BLOBCHECK_ASSUME_ROLE_EXTERNAL_ID"
+	// RoleDurationEnv is the duration of the role sessions of the probes.
+	RoleDurationEnv = "BLOBCHECK_ASSUME_ROLE_DURATION"
+	// STSEndpointEnv overrides the STS endpoint, such as a VPC endpoint.
+	STSEndpointEnv = "BLOBCHECK_STS_ENDPOINT"
+)
+
+// externalIDOption introduces the external ID of a role in ASSUME_ROLE.
+const externalIDOption = ";external_id="
+
+// roleSessionName identifies the sessions of the probes in CloudTrail.
+const roleSessionName = "blobcheck"
+
+// roleOptions configures the sessions of the roles assumed by the probes.
+// The cluster uses its own defaults.
+type roleOptions struct {
+	duration time.Duration // duration of the sessions, if set
+	endpoint string        // STS endpoint, if not the default one
+}
+
+// assumedRole is a role of the ASSUME_ROLE chain.
+type assumedRole struct {
+	arn        string
+	externalID string
+}
+
+// parseAssumeRole returns the chain of roles of an ASSUME_ROLE value, in
+// the order they are assumed.
+func parseAssumeRole(value string) []assumedRole {
+	var res []assumedRole
+	for role := range strings.SplitSeq(value, ",") {
+		arn, externalID, _ := strings.Cut(role, externalIDOption)
+		res = append(res, assumedRole{arn: arn, externalID: externalID})
+	}
+	return res
+}
+
+// roleParams returns the ASSUME_ROLE parameter configured by the
+// environment variables, if any.
+func roleParams(env *env.Env) Params {
+	if env.LookupEnv == nil {
+		return nil
+	}
+	arn, ok := env.LookupEnv(RoleARNEnv)
+	if !ok || arn == "" {
+		return nil
+	}
+	if id, ok := env.LookupEnv(RoleExternalIDEnv); ok && id != "" {
+		arn += externalIDOption + id
+	}
+	return Params{AssumeRoleParam: arn}
+}
+
+// roleOptionsFromEnv returns the options of the role sessions configured
+// by the environment variables.
+func roleOptionsFromEnv(env *env.Env) (roleOptions, error) {
+	var opts roleOptions
+	if env.LookupEnv == nil {
+		return opts, nil
+	}
+	opts.endpoint, _ = env.LookupEnv(STSEndpointEnv)
+	if value, ok := env.LookupEnv(RoleDurationEnv); ok {
+		var err error
+		if opts.duration, err = time.ParseDuration(value); err != nil {
+			return opts, errors.Wrapf(err, "invalid %s", RoleDurationEnv)
+		}
+	}
+	return opts, nil
+}
+
+// assumeRoles returns the credentials of the last role of the chain,
+// assuming each role with the credentials of the previous one. The
+// credentials are cached, and refreshed before they expire.
+func assumeRoles(config aws.Config, value, region string, opts roleOptions) aws.CredentialsProvider {
+	for _, role := range parseAssumeRole(value) {
+		client := sts.NewFromConfig(config, func(o *sts.Options) {
+			if region != "" {
+				o.Region = region
+			}
+			if opts.endpoint != "" {
+				o.BaseEndpoint = aws.String(opts.endpoint)
+			}
+		})
+		provider := stscreds.NewAssumeRoleProvider(client, role.arn, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = roleSessionName
+			if role.externalID != "" {
+				o.ExternalID = aws.String(role.externalID)
+			}
+			if opts.duration > 0 {
+				o.Duration = opts.duration
+			}
+		})
+		config.Credentials = aws.NewCredentialsCache(provider)
+	}
+	return config.Credentials
+}
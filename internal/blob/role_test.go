@@ -0,0 +1,164 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// fakeSTS grants a session for every AssumeRole request, with an access
+// key named after the role, and for every GetSessionToken request, with
+// the ASIASESSION access key, and records the requests.
+type fakeSTS struct {
+	mu       sync.Mutex
+	requests []url.Values
+}
+
+// ServeHTTP implements http.Handler.
+func (f *fakeSTS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := req.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.requests = append(f.requests, req.PostForm)
+	if req.PostForm.Get("Action") == "GetSessionToken" {
+		fmt.Fprintf(w, `<GetSessionTokenResponse><GetSessionTokenResult><Credentials>
+<AccessKeyId>ASIASESSION</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken>
+<Expiration>%s</Expiration></Credentials></GetSessionTokenResult></GetSessionTokenResponse>`,
+			time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		return
+	}
+	fmt.Fprintf(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ASIA%s</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken>
+<Expiration>%s</Expiration></Credentials></AssumeRoleResult></AssumeRoleResponse>`,
+		strings.ToUpper(path.Base(req.PostForm.Get("RoleArn"))), time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+}
+
+func TestAssumeRole(t *testing.T) {
+	r := require.New(t)
+	t.Setenv("AWS_CA_BUNDLE", "")
+	sts := &fakeSTS{}
+	stsServer := httptest.NewServer(sts)
+	defer stsServer.Close()
+	// The last role of the chain is assumed with the session of the first.
+	s3Server := httptest.NewServer(&fakeS3{accessKey: "ASIABACKUP"})
+	defer s3Server.Close()
+
+	vars := map[string]string{
+		AccountParam:      "id",
+		SecretParam:       "secret",
+		RoleARNEnv:        "arn:aws:iam::123456789012:role/jump,arn:aws:iam::210987654321:role/backup",
+		RoleExternalIDEnv: "tenant-1",
+		RoleDurationEnv:   "30m",
+		STSEndpointEnv:    stsServer.URL,
+	}
+	e := &env.Env{
+		Endpoint: s3Server.URL,
+		Path:     "bucket/path",
+		Testing:  true,
+		LookupEnv: func(key string) (string, bool) {
+			v, ok := vars[key]
+			return v, ok
+		},
+	}
+	params, _, err := s3Params(e)
+	r.NoError(err)
+	r.Equal("arn:aws:iam::123456789012:role/jump,arn:aws:iam::210987654321:role/backup;external_id=tenant-1",
+		params[AssumeRoleParam])
+	role, err := roleOptionsFromEnv(e)
+	r.NoError(err)
+
+	s := &s3Store{
+		dest:    "bucket/path",
+		root:    "bucket/path",
+		params:  params.Merge(Params{UsePathStyleParam: "true"}),
+		role:    role,
+		testing: true,
+	}
+	store, err := s.try(context.Background(), s.BucketName())
+	r.NoError(err)
+	r.Equal(params[AssumeRoleParam], store.Params()[AssumeRoleParam])
+
+	// Each probe assumes the chain of roles.
+	byRole := make(map[string]url.Values)
+	for _, req := range sts.requests {
+		byRole[path.Base(req.Get("RoleArn"))] = req
+	}
+	r.Len(byRole, 2)
+	first, second := byRole["jump"], byRole["backup"]
+	assert.Equal(t, "AssumeRole", first.Get("Action"))
+	assert.Equal(t, "arn:aws:iam::123456789012:role/jump", first.Get("RoleArn"))
+	assert.Empty(t, first.Get("ExternalId"))
+	assert.Equal(t, "arn:aws:iam::210987654321:role/backup", second.Get("RoleArn"))
+	assert.Equal(t, "tenant-1", second.Get("ExternalId"))
+	assert.Equal(t, "1800", second.Get("DurationSeconds"))
+	assert.Equal(t, roleSessionName, second.Get("RoleSessionName"))
+
+	e.LookupEnv = func(string) (string, bool) { return "soon", true }
+	_, err = roleOptionsFromEnv(e)
+	assert.ErrorContains(t, err, "invalid "+RoleDurationEnv)
+}
+
+func TestImplicitAuth(t *testing.T) {
+	r := require.New(t)
+	// The default credential chain reads the keys from the process
+	// environment, and must not find shared files or an instance profile.
+	t.Setenv("AWS_CA_BUNDLE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAPROFILE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", path.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", path.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	server := httptest.NewServer(&fakeS3{accessKey: "AKIAPROFILE"})
+	defer server.Close()
+
+	lookup := func(key string) (string, bool) {
+		res, ok := map[string]string{
+			AuthEnv:      AuthImplicit,
+			AccountParam: "AKIASTATIC",
+			SecretParam:  "static",
+		}[key]
+		return res, ok
+	}
+	e := &env.Env{Endpoint: server.URL, Path: "bucket/path", LookupEnv: lookup}
+	store, err := S3FromEnv(stopper.WithContext(t.Context()), e)
+	r.NoError(err)
+	params := store.Params()
+	assert.Equal(t, AuthImplicit, params[AuthParam])
+	assert.NotContains(t, params, AccountParam)
+	assert.NotContains(t, params, SecretParam)
+	assert.Contains(t, store.URL(), "AUTH=implicit")
+
+	e.Testing = true
+	_, err = S3FromEnv(stopper.WithContext(t.Context()), e)
+	assert.ErrorContains(t, err, "not supported in testing mode")
+}
@@ -15,7 +15,9 @@
 package blob
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -23,14 +25,20 @@ import (
 	"log/slog"
 	"maps"
 	"net/http"
-	"net/url"
+	"os"
 	"path"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/google/uuid"
 
 	"github.com/cockroachdb/errors"
@@ -55,20 +63,46 @@ const (
 	SkipChecksum = "AWS_SKIP_CHECKSUM"
 	// SkipTLSVerify is the AWS skip TLS verify.
 	SkipTLSVerify = "AWS_SKIP_TLS_VERIFY"
+	// RequesterPaysParam makes the requester, rather than the bucket owner,
+	// pay for the requests and the data transfer. Requester pays buckets
+	// reject the requests that do not set it.
+	RequesterPaysParam = "AWS_REQUESTER_PAYS"
+	// AuthParam selects how the cluster obtains credentials: "specified"
+	// (the default) uses the keys in the URL, "implicit" uses the
+	// credentials available on each node.
+	AuthParam = "AUTH"
+	// AssumeRoleParam is a comma separated chain of role ARNs to assume.
+	AssumeRoleParam = "ASSUME_ROLE"
+	// StorageClassParam is the S3 storage class of the objects written.
+	StorageClassParam = "S3_STORAGE_CLASS"
+	// ServerEncModeParam is the server side encryption mode, AES256 or aws:kms.
+	ServerEncModeParam = "AWS_SERVER_ENC_MODE"
+	// ServerKMSIDParam is the KMS key ID used by aws:kms encryption.
+	ServerKMSIDParam = "AWS_SERVER_KMS_ID"
 
 	// DefaultRegion is the default AWS region.
 	DefaultRegion = "aws-global"
 )
 
-// ValidParams lists the valid parameters for the S3 object storage.
+// ValidParams lists the valid parameters for the S3 object storage. They
+// match the query parameters accepted by CockroachDB for s3:// URLs, which
+// rejects any parameter it does not know.
 var ValidParams = []string{
 	AccountParam, SecretParam, TokenParam, EndPointParam,
-	RegionParam, UsePathStyleParam, SkipChecksum, SkipTLSVerify,
+	RegionParam, UsePathStyleParam, SkipChecksum, SkipTLSVerify, RequesterPaysParam,
+	AuthParam, AssumeRoleParam, StorageClassParam, ServerEncModeParam, ServerKMSIDParam,
 }
 
+var (
+	// boolParams lists the parameters that take a boolean value.
+	boolParams = []string{UsePathStyleParam, SkipChecksum, SkipTLSVerify, RequesterPaysParam}
+	// urlParams lists the parameters that take a URL value.
+	urlParams = []string{EndPointParam}
+)
+
 var (
 	// ObfuscatedParams lists the parameters that should be obfuscated.
-	ObfuscatedParams = []string{SecretParam, TokenParam}
+	ObfuscatedParams = []string{SecretParam, TokenParam, CredentialsParam}
 	// Obfuscated is the value used to obfuscate sensitive parameters.
 	Obfuscated = "******"
 )
@@ -76,63 +110,501 @@ var (
 // ErrMissingParam is returned when required parameters are missing.
 var ErrMissingParam = errors.New("AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY must be set")
 
+// AuthEnv sets the AUTH parameter of a destination provided with an
+// endpoint and a path. With "implicit", the keys are not required: the
+// probes use the default credential chain of the SDK, such as an instance
+// profile or a web identity, as the nodes do.
+const AuthEnv = "BLOBCHECK_AUTH"
+
 type s3Store struct {
-	params  Params
-	dest    string
-	testing bool
-	verbose bool
+	client       *s3.Client // set once a working configuration is found
+	params       Params
+	provided     Params // parameters provided by the user, before the defaults and the probes
+	dest         string
+	root         string           // destination provided by the user, without the unique sub-path
+	dial         env.DialFunc     // dials through the configured proxy or tunnel, if any
+	timeouts     Timeouts         // timeouts of the connections to the storage
+	retries      Retries          // retry policy of the requests of the probes
+	deleteWindow time.Duration    // time allowed for a deleted object to disappear from listings
+	partSize     int64            // size of the first part uploaded by the multipart probe, if set
+	role         roleOptions      // sessions of the roles assumed by the probes
+	session      *SessionToken    // temporary credentials minted for the run, which replace the access keys
+	objects      ProbeObject      // objects written by the probes
+	kmsKey       string           // KMS key of the SSE-KMS candidates, if any
+	caFile       string           // CA bundle provided by the user, if any
+	customCA     bool             // verify the endpoint with the CA bundle instead of the system roots
+	proxy        *proxyConfig     // HTTP proxy of the requests to the storage, if any
+	bypassProxy  bool             // connect to the storage directly instead of through the proxy
+	forbidden    []string         // parameters no candidate configuration may enable, with --strict
+	approver     *approver        // asks the operator before each candidate is probed, with --interactive
+	proxyCheck   *ProxyCheck      // whether the selected configuration goes through the proxy, if any
+	endpoints    []string         // endpoints serving the destination, if more than one
+	recorder     *Recorder        // records the storage operations, if enabled
+	rank         bool             // probe every candidate configuration and rank the working ones
+	readOnly     bool             // only list the bucket while probing, for the commands that do not validate it
+	ranked       []Candidate      // working configurations, if ranking is enabled
+	probed       []Probed         // every configuration probed, if ranking is enabled
+	latency      *Latency         // timings of the probe operations, once connected
+	requests     []RequestMetrics // timings of the attempts of the requests sent by the probe, once probed
+	testing      bool
+	verbose      bool
 }
 
+var _ s3Storage = &s3Store{}
+
 // S3FromEnv creates a new S3 store from the environment.
 // It will try to connect to the S3 service using the environment variables provided,
 // and adding any parameters that are required.
 func S3FromEnv(ctx *stopper.Context, env *env.Env) (Storage, error) {
+	return s3FromEnv(ctx, env, nil)
+}
+
+// s3FromEnv creates a new S3 store from the environment, starting from the
+// default parameters, which are overridden by the ones provided by the user.
+func s3FromEnv(ctx *stopper.Context, env *env.Env, defaults Params) (s3Storage, error) {
+	params, dest, err := s3Params(env)
+	if err != nil {
+		return nil, err
+	}
+	params = defaults.Merge(params)
+	provided, _, err := providedS3Params(env)
+	if err != nil {
+		return nil, err
+	}
+	role, err := roleOptionsFromEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	caFile, err := caBundle(env)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := failoverEndpoints(env)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := proxyFromEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	initial := &s3Store{
+		dest:         path.Join(dest, DestID(env)),
+		root:         dest,
+		params:       params,
+		provided:     provided,
+		dial:         env.Dial,
+		timeouts:     timeoutsFromEnv(env),
+		retries:      retriesFromEnv(env),
+		deleteWindow: env.DeleteVisibilityWindow,
+		partSize:     env.MultipartPartSize,
+		role:         role,
+		objects:      ProbeObjectFromEnv(env),
+		kmsKey:       kmsKeyFromEnv(env),
+		caFile:       caFile,
+		endpoints:    endpoints,
+		proxy:        proxy,
+		forbidden:    forbiddenFromEnv(env),
+		approver:     approverFromEnv(env),
+		recorder:     NewRecorder(env.Recording),
+		rank:         env.RankCandidates,
+		testing:      env.Testing,
+		verbose:      env.Verbose,
+	}
+	if env.SessionTokenDuration > 0 {
+		if err := initial.mintSession(ctx, env.SessionTokenDuration); err != nil {
+			return nil, err
+		}
+	}
+	return initial.tryEndpoints(ctx)
+}
+
+// OpenS3 connects to the S3 destination in the environment as is, without
+// adding a unique sub-path, so that existing backups can be inspected.
+func OpenS3(ctx *stopper.Context, env *env.Env) (Storage, error) {
+	params, dest, err := s3Params(env)
+	if err != nil {
+		return nil, err
+	}
+	role, err := roleOptionsFromEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	caFile, err := caBundle(env)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := proxyFromEnv(env)
+	if err != nil {
+		return nil, err
+	}
+	initial := &s3Store{
+		dest:     dest,
+		root:     dest,
+		params:   params,
+		role:     role,
+		objects:  ProbeObjectFromEnv(env),
+		kmsKey:   kmsKeyFromEnv(env),
+		caFile:   caFile,
+		proxy:    proxy,
+		dial:     env.Dial,
+		timeouts: timeoutsFromEnv(env),
+		retries:  retriesFromEnv(env),
+		recorder: NewRecorder(env.Recording),
+		rank:     env.RankCandidates,
+		readOnly: true,
+		testing:  env.Testing,
+		verbose:  env.Verbose,
+	}
+	return initial.try(ctx, initial.BucketName())
+}
+
+// s3Params extracts the parameters and the destination from either the
+// URI or the endpoint, path and environment variables.
+func s3Params(env *env.Env) (Params, string, error) {
+	provided, dest, err := providedS3Params(env)
+	if err != nil {
+		return nil, "", err
+	}
+	bucket, prefix := splitDest(dest)
+	region := DefaultRegion
+	switch {
+	case IsAccessPoint(bucket):
+		// Requests through an access point are signed for its region.
+		if r, err := accessPointRegion(bucket); err == nil && r != "" {
+			region = r
+		}
+	case IsDirectoryBucket(bucket):
+		// Directory buckets are reached through the zonal endpoint of their
+		// region.
+		if r, ok := expressRegion(bucket); ok {
+			region = r
+		}
+	}
+	// Parameters provided by the user take precedence over the defaults.
+	params := Params{RegionParam: region}.Merge(provided)
+	if err := (S3URL{Bucket: bucket, Path: prefix, Params: params}).Validate(); err != nil {
+		return nil, "", err
+	}
+	if env.Testing && params[AuthParam] == AuthImplicit {
+		// Testing mode replaces the default credential chain with the keys
+		// in the parameters.
+		return nil, "", errors.Newf("%s=%s is not supported in testing mode", AuthParam, AuthImplicit)
+	}
+	return params, dest, nil
+}
+
+// providedS3Params returns the parameters provided by the user, before
+// the defaults are added, and the destination.
+func providedS3Params(env *env.Env) (Params, string, error) {
 	var params Params
 	var dest string
 	if env.URI != "" {
 		var err error
 		params, dest, err = extractFromURI(env.URI)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		fmt.Println(params)
 	} else {
+		required, optional := []string{AccountParam, SecretParam}, []string{TokenParam, RegionParam}
+		auth, _ := env.LookupEnv(AuthEnv)
+		if auth == AuthImplicit {
+			// Keys exported for the default credential chain must not end
+			// up in the URL.
+			required, optional = nil, []string{RegionParam}
+		}
 		var ok bool
-		params, ok = lookupEnv(env, []string{AccountParam, SecretParam}, []string{TokenParam, RegionParam})
+		params, ok = lookupEnv(env, required, optional)
 		if !ok {
-			return nil, ErrMissingParam
-		}
-		if env.Endpoint != "" {
-			params[EndPointParam] = env.Endpoint
+			return nil, "", errors.WithHintf(ErrMissingParam,
+				"set %s=%s to use the default credential chain, as the nodes do", AuthEnv, AuthImplicit)
 		}
+		// With several endpoints, the first one is probed first.
+		endpoint, _, _ := strings.Cut(env.Endpoint, ",")
+		params = params.Merge(Params{EndPointParam: strings.TrimSpace(endpoint), AuthParam: auth})
 		dest = env.Path
 	}
-
-	if _, ok := params[RegionParam]; !ok {
-		params[RegionParam] = DefaultRegion
-	}
-	initial := &s3Store{
-		dest:    path.Join(dest, uuid.NewString()),
-		params:  params,
-		testing: env.Testing,
-		verbose: env.Verbose,
+	if env.EndpointPrefix != "" {
+		endpoint, err := WithPathPrefix(params[EndPointParam], env.EndpointPrefix)
+		if err != nil {
+			return nil, "", err
+		}
+		params = params.Merge(Params{EndPointParam: endpoint})
 	}
-	return initial.try(ctx, initial.BucketName())
+	// The parameters in the URL take precedence over the environment
+	// variables.
+	return roleParams(env).Merge(params), dest, nil
 }
 
 // BucketName implements BlobStorage.
 func (s *s3Store) BucketName() string {
-	cleanedPath := path.Clean(s.dest)
-	components := strings.Split(cleanedPath, "/")
-	if len(components) == 0 {
-		return ""
+	bucket, _ := splitDest(path.Clean(s.dest))
+	return bucket
+}
+
+// Clean implements BlobStorage.
+func (s *s3Store) Clean(ctx context.Context) error {
+	if s.keyPrefix() == "" {
+		// Never wipe a whole bucket.
+		return errors.New("refusing to clean a destination without a prefix")
+	}
+	objects, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := s.deleteObject(ctx, obj.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete implements BlobStorage.
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	if s.client == nil {
+		return errors.New("storage is not connected")
+	}
+	locked, err := s.locked(ctx, key)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return errors.Wrapf(ErrLocked, "cannot delete %q", key)
 	}
-	return components[0]
+	return s.deleteObject(ctx, key)
+}
+
+// deleteObject removes an object, without checking its lock status.
+func (s *s3Store) deleteObject(ctx context.Context, key string) error {
+	slog.Debug("Deleting object", slog.String("key", key))
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:       aws.String(s.BucketName()),
+		Key:          aws.String(path.Join(s.keyPrefix(), key)),
+		RequestPayer: s.requestPayer(),
+	}); err != nil {
+		return errors.Wrapf(err, "failed to delete %q", key)
+	}
+	return nil
+}
+
+// Get implements BlobStorage.
+func (s *s3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	if s.client == nil {
+		return nil, errors.New("storage is not connected")
+	}
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:       aws.String(s.BucketName()),
+		Key:          aws.String(path.Join(s.keyPrefix(), key)),
+		RequestPayer: s.requestPayer(),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %q", key)
+	}
+	defer result.Body.Close()
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", key)
+	}
+	return data, nil
+}
+
+// locked returns whether the object is protected by a retention period or a
+// legal hold.
+func (s *s3Store) locked(ctx context.Context, key string) (bool, error) {
+	return s.versionLocked(ctx, path.Join(s.keyPrefix(), key), nil)
+}
+
+// versionLocked returns whether a version of the object with the full key,
+// or its current version if versionID is nil, is protected by a retention
+// period or a legal hold. Only the errors telling that there is no object
+// lock configuration mean that the object is not locked; other errors, such
+// as a denied request, are returned.
+func (s *s3Store) versionLocked(ctx context.Context, fullKey string, versionID *string) (bool, error) {
+	retention, err := s.client.GetObjectRetention(ctx, &s3.GetObjectRetentionInput{
+		Bucket:       aws.String(s.BucketName()),
+		Key:          aws.String(fullKey),
+		VersionId:    versionID,
+		RequestPayer: s.requestPayer(),
+	})
+	switch {
+	case err == nil:
+		if retention.Retention != nil && aws.ToTime(retention.Retention.RetainUntilDate).After(time.Now()) {
+			return true, nil
+		}
+	case !noObjectLock(err):
+		return false, errors.Wrapf(err, "failed to get the retention of %q", fullKey)
+	}
+	hold, err := s.client.GetObjectLegalHold(ctx, &s3.GetObjectLegalHoldInput{
+		Bucket:       aws.String(s.BucketName()),
+		Key:          aws.String(fullKey),
+		VersionId:    versionID,
+		RequestPayer: s.requestPayer(),
+	})
+	switch {
+	case err == nil:
+		return hold.LegalHold != nil && hold.LegalHold.Status == types.ObjectLockLegalHoldStatusOn, nil
+	case !noObjectLock(err):
+		return false, errors.Wrapf(err, "failed to get the legal hold of %q", fullKey)
+	}
+	return false, nil
+}
+
+// noObjectLock returns whether the error tells that the object has no
+// retention or legal hold, or that the bucket has no object lock
+// configuration: AWS rejects the request as invalid, MinIO reports the
+// missing configuration, and providers without object lock do not implement
+// the request.
+func noObjectLock(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "NoSuchObjectLockConfiguration", "ObjectLockConfigurationNotFoundError", "NotImplemented":
+		return true
+	case "InvalidRequest":
+		return strings.Contains(apiErr.ErrorMessage(), "Object Lock")
+	}
+	return false
+}
+
+// Versioned implements VersionedStorage. Buckets where versioning was
+// suspended still keep the versions written while it was enabled.
+func (s *s3Store) Versioned(ctx context.Context) (bool, error) {
+	if s.client == nil {
+		return false, errors.New("storage is not connected")
+	}
+	status, reason := s.versioning(ctx)
+	if reason != "" {
+		return false, errors.Newf("failed to get the versioning state of the bucket: %s", reason)
+	}
+	return status != VersioningDisabled, nil
+}
+
+// DeleteVersions implements VersionedStorage. The versions protected by
+// object lock are kept, and reported with ErrLocked once the others are
+// deleted.
+func (s *s3Store) DeleteVersions(ctx context.Context, key string) (int64, error) {
+	if s.client == nil {
+		return 0, errors.New("storage is not connected")
+	}
+	fullKey := path.Join(s.keyPrefix(), key)
+	paginator := s3.NewListObjectVersionsPaginator(s.client, &s3.ListObjectVersionsInput{
+		Bucket:       aws.String(s.BucketName()),
+		Prefix:       aws.String(fullKey),
+		RequestPayer: s.requestPayer(),
+	})
+	var freed int64
+	locked := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return freed, errors.Wrapf(err, "failed to list the versions of %q", key)
+		}
+		for _, v := range page.Versions {
+			if aws.ToString(v.Key) != fullKey {
+				continue
+			}
+			isLocked, err := s.versionLocked(ctx, fullKey, v.VersionId)
+			if err != nil {
+				return freed, err
+			}
+			if isLocked {
+				locked++
+				continue
+			}
+			if err := s.deleteVersion(ctx, fullKey, v.VersionId); err != nil {
+				return freed, err
+			}
+			freed += aws.ToInt64(v.Size)
+		}
+		for _, m := range page.DeleteMarkers {
+			if aws.ToString(m.Key) != fullKey {
+				continue
+			}
+			if err := s.deleteVersion(ctx, fullKey, m.VersionId); err != nil {
+				return freed, err
+			}
+		}
+	}
+	if locked > 0 {
+		return freed, errors.Wrapf(ErrLocked, "cannot delete %d versions of %q", locked, key)
+	}
+	return freed, nil
+}
+
+// deleteVersion permanently removes a version, or a delete marker, of the
+// object with the full key.
+func (s *s3Store) deleteVersion(ctx context.Context, fullKey string, versionID *string) error {
+	slog.Debug("Deleting object version", slog.String("key", fullKey), slog.String("version", aws.ToString(versionID)))
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:       aws.String(s.BucketName()),
+		Key:          aws.String(fullKey),
+		VersionId:    versionID,
+		RequestPayer: s.requestPayer(),
+	}); err != nil {
+		return errors.Wrapf(err, "failed to delete version %s of %q", aws.ToString(versionID), fullKey)
+	}
+	return nil
+}
+
+// List implements BlobStorage.
+func (s *s3Store) List(ctx context.Context) ([]Object, error) {
+	if s.client == nil {
+		return nil, errors.New("storage is not connected")
+	}
+	prefix := s.keyPrefix()
+	if prefix != "" {
+		prefix += "/"
+	}
+	var objects []Object
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:       aws.String(s.BucketName()),
+		Prefix:       aws.String(prefix),
+		RequestPayer: s.requestPayer(),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list objects")
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, Object{
+				Key:          strings.TrimPrefix(aws.ToString(obj.Key), prefix),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// requestPayer returns the payer set on the requests, which is the
+// requester if the configuration enables requester pays.
+func (s *s3Store) requestPayer() types.RequestPayer {
+	if s.params.Bool(RequesterPaysParam) {
+		return types.RequestPayerRequester
+	}
+	return ""
+}
+
+// keyPrefix returns the destination path within the bucket.
+func (s *s3Store) keyPrefix() string {
+	prefix := strings.TrimPrefix(path.Clean(s.dest), s.BucketName())
+	return strings.TrimPrefix(prefix, "/")
 }
 
 // Params implements BlobStorage.
 func (s *s3Store) Params() Params {
-	params := maps.Clone(s.params)
+	return obfuscate(s.params)
+}
+
+// obfuscate returns a copy of the parameters with the secrets obfuscated.
+func obfuscate(params Params) Params {
+	if params == nil {
+		return nil
+	}
+	params = maps.Clone(params)
 	for param := range params {
 		if slices.Contains(ObfuscatedParams, param) {
 			params[param] = Obfuscated
@@ -141,20 +613,47 @@ func (s *s3Store) Params() Params {
 	return params
 }
 
+// ProvidedParams implements Storage.
+func (s *s3Store) ProvidedParams() Params {
+	return obfuscate(s.provided)
+}
+
 // URL implements BlobStorage.
 func (s *s3Store) URL() string {
-	res := s.escapeValues()
-	res = fmt.Sprintf("s3://%s?%s", s.dest, res)
-	return res
+	return toURL(s.dest, s.params)
 }
 
-// addParam adds a parameter to the S3 store.
-func (s *s3Store) addParam(key string, value string) error {
-	if slices.Contains(ValidParams, key) {
-		s.params[key] = value
-		return nil
+// RootURL implements BlobStorage.
+func (s *s3Store) RootURL() string {
+	return toURL(s.root, s.params)
+}
+
+// Candidates implements BlobStorage.
+func (s *s3Store) Candidates() []Candidate {
+	return s.ranked
+}
+
+// Probed implements Storage.
+func (s *s3Store) Probed() []Probed {
+	return s.probed
+}
+
+// Proxy implements ProxyReporter.
+func (s *s3Store) Proxy() *ProxyCheck {
+	return s.proxyCheck
+}
+
+// CustomCA implements BlobStorage.
+func (s *s3Store) CustomCA() string {
+	if s.customCA {
+		return s.caFile
 	}
-	return errors.Newf("invalid param %q", key)
+	return ""
+}
+
+// Latency implements BlobStorage.
+func (s *s3Store) Latency() *Latency {
+	return s.latency
 }
 
 // combinations returns all subsets (the power set) of the given slice
@@ -179,59 +678,84 @@ func combinations(items []string) [][]string {
 // TODO(silvano): consider making this public.
 func (s *s3Store) candidateConfigs() iter.Seq[Storage] {
 	return func(yield func(Storage) bool) {
-		combos := combinations([]string{
-			SkipChecksum,
-			SkipTLSVerify,
-			UsePathStyleParam,
-		})
+		toggles := []string{SkipChecksum, SkipTLSVerify, UsePathStyleParam}
+		if bucket := s.BucketName(); IsAccessPoint(bucket) || IsDirectoryBucket(bucket) {
+			// Access points and directory buckets are only reachable with
+			// virtual hosted requests.
+			toggles = toggles[:2]
+		}
+		combos := combinations(toggles)
+		modes := []Params{{}}
+		if s.params[ServerEncModeParam] == "" {
+			// The encryption headers are only tried once every combination
+			// failed without them.
+			modes = s.encryptionModes()
+		}
 
-		for _, combo := range combos {
-			alt := &s3Store{
-				dest:   s.dest,
-				params: maps.Clone(s.params),
-			}
-			for _, option := range combo {
-				if alt.params[option] == "true" {
-					alt.addParam(option, "false")
-				} else {
-					alt.addParam(option, "true")
+		// With a proxy, the configurations are tried without it once they
+		// all failed through it, to tell whether the proxy breaks them.
+		bypass := []bool{false}
+		if s.proxy != nil {
+			bypass = append(bypass, true)
+		}
+
+		for _, direct := range bypass {
+			for _, mode := range modes {
+				for _, combo := range combos {
+					toggled := make(Params, len(combo))
+					for _, option := range combo {
+						toggled[option] = strconv.FormatBool(!s.params.Bool(option))
+					}
+					alt := &s3Store{
+						dest:        s.dest,
+						root:        s.root,
+						params:      s.params.Merge(toggled, mode),
+						dial:        s.dial,
+						bypassProxy: direct,
+					}
+					if forbids(s.forbidden, alt.params) {
+						continue
+					}
+					if !yield(alt) {
+						return
+					}
+					if s.caFile == "" || alt.params.Bool(SkipTLSVerify) {
+						continue
+					}
+					// Verifying the endpoint with the CA bundle is preferred to
+					// skipping the verification.
+					if !yield(&s3Store{dest: alt.dest, root: alt.root, params: alt.params, dial: alt.dial,
+						customCA: true, bypassProxy: direct}) {
+						return
+					}
 				}
 			}
-			if !yield(alt) {
-				return
-			}
 		}
 	}
 }
 
-// escapeValues provides a URL-encoded query string representation of the S3 store parameters.
-func (s *s3Store) escapeValues() string {
-	var sb strings.Builder
-	first := true
-	for key, value := range s.params.Iter() {
-		if first {
-			first = false
-		} else {
-			sb.WriteString("&")
-		}
-		sb.WriteString(fmt.Sprintf("%s=%s", url.QueryEscape(key), url.QueryEscape(value)))
+// ParseURI returns the bucket and the parameters of an S3 URI.
+func ParseURI(uri string) (string, Params, error) {
+	params, dest, err := extractFromURI(uri)
+	if err != nil {
+		return "", nil, err
 	}
-	return sb.String()
+	bucket, _ := splitDest(dest)
+	return bucket, params, nil
 }
 
 func extractFromURI(uri string) (Params, string, error) {
-	parsed, err := url.Parse(uri)
+	u, err := ParseS3URL(uri)
 	if err != nil {
 		return nil, "", err
 	}
-	if parsed.Scheme != "s3" {
-		return nil, "", fmt.Errorf("unsupported scheme: %q", parsed.Scheme)
-	}
-	res := make(Params)
-	for k, v := range parsed.Query() {
-		res[k] = v[0]
-	}
-	return res, path.Join(parsed.Host, parsed.Path), nil
+	return u.Params, u.Dest(), nil
+}
+
+// toURL returns the canonical URL of a destination within a bucket.
+func toURL(dest string, params Params) string {
+	bucket, prefix := splitDest(dest)
+	return S3URL{Bucket: bucket, Path: prefix, Params: params}.String()
 }
 
 // lookupEnv retrieves required and optional environment variables from the provided environment.
@@ -254,116 +778,582 @@ func lookupEnv(env *env.Env, required []string, optional []string) (Params, bool
 	return res, true
 }
 
-const (
-	objectKey = "_blobcheck"
-	content   = "dummy_data"
-)
+// deletePoll is the interval between the listings checking that a deleted
+// object is no longer visible.
+const deletePoll = 100 * time.Millisecond
+
+// probeWorkers is the maximum number of candidate configurations probed
+// concurrently.
+const probeWorkers = 4
+
+// errAbort marks probe failures that stop the search for a working
+// configuration, rather than moving on to the next candidate.
+var errAbort = errors.New("aborting the search for a working configuration")
 
 // try attempts to connect to the S3 store using alternative configurations.
-func (s *s3Store) try(ctx context.Context, bucketName string) (Storage, error) {
-	var clientMode aws.ClientLogMode
-	if s.verbose {
-		clientMode |= aws.LogRetries | aws.LogRequestWithBody | aws.LogRequestEventMessage | aws.LogResponse | aws.LogResponseEventMessage | aws.LogSigning
+func (s *s3Store) try(ctx context.Context, bucketName string) (s3Storage, error) {
+	s.recorder.record(Event{Op: OpOpen, Key: s.dest, Params: s.Params(), Proxy: s.proxy != nil})
+	var mu sync.Mutex
+	// timeout is the last transport timeout that failed a probe, reported
+	// if no configuration works.
+	var timeout error
+	// signature is the last probe failure caused by the signature version,
+	// which no configuration can work around.
+	var signature error
+	// diagnoses are the classified failures of the probes, reported if no
+	// configuration works.
+	var diagnoses []Diagnosis
+	// proxied is the last failure of a configuration probed through the
+	// proxy, reported if only the configurations without it work.
+	var proxied error
+	probe := func(ctx context.Context, alt *s3Store) error {
+		err := classifyTimeout(s.probe(ctx, alt, bucketName), s.timeouts)
+		if ctx.Err() != nil {
+			// The probe was abandoned, since a preferred candidate works.
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if isTransportTimeout(err) {
+			timeout = err
+		}
+		if isUnsupportedSignature(err) {
+			signature = err
+		}
+		if err != nil && !errors.Is(err, errAbort) {
+			d := diagnose(alt.params, err)
+			if region := alt.params[RegionParam]; region != s.params[RegionParam] {
+				d.Flags[RegionParam] = region
+			}
+			if alt.bypassProxy {
+				d.Flags[BypassProxy] = "true"
+			}
+			diagnoses = append(diagnoses, d)
+		}
+		if err != nil && s.proxy != nil && !alt.bypassProxy {
+			proxied = err
+		}
+		s.recorder.record(probeEvent(alt, err))
+		return err
 	}
-	for alt := range s.candidateConfigs() {
-		params := alt.Params()
-		var loadOptions []func(options *config.LoadOptions) error
-		addLoadOption := func(option config.LoadOptionsFunc) {
-			loadOptions = append(loadOptions, option)
-		}
-		client := &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: params[SkipTLSVerify] == "true"},
-			},
-		}
-		addLoadOption(config.WithHTTPClient(client))
-		if params[SkipTLSVerify] == "true" {
-			slog.Warn("TLS verification is disabled; use only for testing")
-		}
-		retryMaxAttempts := 1
-		addLoadOption(config.WithRetryMaxAttempts(retryMaxAttempts))
-		addLoadOption(config.WithClientLogMode(clientMode))
-		// TODO (silvano) - consider removing testing guard
-		// LoadDefaultConfig will always honor env based provided credentials if present.
-		if s.testing {
-			addLoadOption(config.WithCredentialsProvider(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
-				return aws.Credentials{
-					AccessKeyID:     s.params[AccountParam],
-					SecretAccessKey: s.params[SecretParam],
-					SessionToken:    s.params[TokenParam],
-				}, nil
-			})))
-		}
-		config, err := config.LoadDefaultConfig(ctx, loadOptions...)
-		if err != nil {
-			return nil, err
+	if s.approver != nil {
+		probe = withApproval(s.approver, probe)
+	}
+	from, alt, ok, err := s.selectConfig(ctx, probe, func(ctx context.Context) string {
+		region := s.discoverRegion(ctx, bucketName)
+		s.recorder.record(Event{Op: OpRegion, Key: region})
+		return region
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		sortDiagnoses(diagnoses)
+		err := withStrictHint(s.noConfiguration(ctx, from, bucketName, signature, timeout), s.forbidden)
+		err = s.approver.withSkippedHint(err)
+		return nil, &DiagnosisError{Diagnoses: diagnoses, err: err}
+	}
+	alt = minimize(ctx, alt, probe)
+	alt.ranked, alt.probed = s.ranked, s.probed
+	alt.partSize = s.partSize
+	alt.objects = s.objects
+	alt.kmsKey = s.kmsKey
+	alt.caFile = s.caFile
+	alt.endpoints = s.endpoints
+	alt.provided = s.provided
+	// The other endpoints are probed with the same clients.
+	alt.timeouts, alt.role, alt.deleteWindow = s.timeouts, s.role, s.deleteWindow
+	alt.retries = s.retries
+	alt.testing, alt.verbose = s.testing, s.verbose
+	alt.session = s.session
+	alt.proxy = s.proxy
+	if s.proxy != nil {
+		alt.proxyCheck = &ProxyCheck{Proxy: s.proxy.url.Redacted(), NoProxy: s.proxy.noProxy, Bypassed: alt.bypassProxy}
+		if alt.bypassProxy && proxied != nil {
+			alt.proxyCheck.Err = proxied.Error()
+			slog.Warn("the storage is only reachable without the proxy", slog.Any("error", proxied),
+				slog.String("hint", "add the host of the storage to NO_PROXY on the nodes"))
 		}
+	}
+	if alt.customCA {
+		slog.Warn("the endpoint is verified with the custom CA only",
+			slog.String("hint", "set the cluster setting "+CustomCASetting+" to the content of "+s.caFile))
+	}
+	slog.Debug("Suggested params", slog.Any("env", alt.Params()))
+	return s.recorder.wrap(alt), nil
+}
 
-		usePathStyle := params[UsePathStyleParam] == "true"
-		skipChecksum := params[SkipChecksum] == "true"
-		if skipChecksum {
-			config.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenSupported
-			config.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenSupported
+// selectConfig searches the candidate configurations for one accepted by
+// the probe. If none is, the search is retried with the region returned by
+// discoverRegion, when the default region was used, and then as a requester
+// pays bucket, when a probe was denied access. It returns the store the last
+// candidates were derived from, and the selected candidate, if any.
+func (s *s3Store) selectConfig(
+	ctx context.Context,
+	probe func(context.Context, *s3Store) error,
+	discoverRegion func(context.Context) string,
+) (from *s3Store, alt *s3Store, ok bool, err error) {
+	// denied is set if the provider rejected a probe with an access denied
+	// error, which is what requester pays buckets return to requests that
+	// do not set the request payer.
+	var denied atomic.Bool
+	tracked := func(ctx context.Context, alt *s3Store) error {
+		err := probe(ctx, alt)
+		if isAccessDenied(err) {
+			denied.Store(true)
 		}
-		s3Client := s3.NewFromConfig(config, func(o *s3.Options) {
-			if ep := params[EndPointParam]; ep != "" {
-				o.BaseEndpoint = aws.String(ep)
+		return err
+	}
+	if s.rank {
+		tracked = s.recordProbed(tracked)
+	}
+	search := func(from *s3Store) (*s3Store, bool, error) {
+		var alt *s3Store
+		var ok bool
+		var err error
+		s.ranked, alt, ok, err = searchCandidates(ctx, from.candidateConfigs(), tracked, s.rank, s.approver != nil)
+		return alt, ok, err
+	}
+	from = s
+	alt, ok, err = search(from)
+	if err == nil && !ok && s.params[RegionParam] == DefaultRegion {
+		// Many providers reject requests signed for the default region.
+		if region := discoverRegion(ctx); region != "" {
+			slog.Info("retrying with the region of the bucket", slog.String("region", region))
+			from = &s3Store{
+				dest:      s.dest,
+				root:      s.root,
+				params:    s.params.Merge(Params{RegionParam: region}),
+				dial:      s.dial,
+				kmsKey:    s.kmsKey,
+				caFile:    s.caFile,
+				proxy:     s.proxy,
+				forbidden: s.forbidden,
 			}
-			o.Region = params[RegionParam]
-			o.UsePathStyle = usePathStyle
+			alt, ok, err = search(from)
+		}
+	}
+	if err == nil && !ok && denied.Load() && !s.params.Bool(RequesterPaysParam) {
+		slog.Info("access denied; retrying as a requester pays bucket")
+		alt, ok, err = search(&s3Store{
+			dest:      from.dest,
+			root:      from.root,
+			params:    from.params.Merge(Params{RequesterPaysParam: "true"}),
+			dial:      from.dial,
+			kmsKey:    from.kmsKey,
+			caFile:    from.caFile,
+			proxy:     from.proxy,
+			forbidden: from.forbidden,
 		})
+	}
+	return from, alt, ok, err
+}
+
+// recordProbed returns a probe that records the outcome of each
+// configuration in the probed list, reporting the region if it differs from
+// the one provided. probeAll runs the probes one at a time. Configurations
+// skipped by the operator are not recorded, so that the cluster does not
+// check them either.
+func (s *s3Store) recordProbed(
+	probe func(context.Context, *s3Store) error,
+) func(context.Context, *s3Store) error {
+	return func(ctx context.Context, alt *s3Store) error {
+		err := probe(ctx, alt)
+		if errors.Is(err, errAbort) || errors.Is(err, errSkipped) {
+			return err
+		}
+		p := Probed{Config: alt, Flags: alt.candidateFlags(), Err: err}
+		if region := alt.params[RegionParam]; region != s.params[RegionParam] {
+			p.Flags[RegionParam] = region
+		}
+		s.probed = append(s.probed, p)
+		return err
+	}
+}
 
-		slog.Debug("Trying params", slog.Any("env", alt.Params()))
+// noConfiguration returns the error reported when no configuration works,
+// given the last probe failures caused by the signature version and by a
+// transport timeout, if any.
+func (s *s3Store) noConfiguration(ctx context.Context, from *s3Store, bucketName string, signature, timeout error) error {
+	if signature != nil {
+		return errors.WithHint(errors.Wrapf(signature, "unable to connect to storage provider %q", s.dest),
+			"the provider does not accept Signature Version 4, the only version supported by CockroachDB: "+
+				"enable it on the provider")
+	}
+	if timeout != nil {
+		return errors.Wrapf(timeout, "unable to connect to storage provider %q", s.dest)
+	}
+	err := fmt.Errorf("unable to connect to storage provider %q", s.dest)
+	if permissions := s.diagnosePermissions(ctx, from.params, bucketName); permissions != nil {
+		return &PermissionError{Permissions: permissions, err: err}
+	}
+	return err
+}
+
+// isAccessDenied returns whether the provider rejected the request because
+// the credentials are not allowed to perform it.
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDenied"
+}
+
+// searchCandidates returns the first candidate configuration accepted by
+// the probe. If rank is set, every candidate is probed first, and the
+// working ones are returned ranked; otherwise, if sequential is set, the
+// candidates are probed one at a time, so that the operator is asked about
+// them in order.
+func searchCandidates[T candidateConfig](
+	ctx context.Context, candidates iter.Seq[Storage], probe func(context.Context, T) error, rank, sequential bool,
+) ([]Candidate, T, bool, error) {
+	var ranked []Candidate
+	if rank {
+		var err error
+		ranked, candidates, probe, err = probeAll(ctx, candidates, probe)
+		if err != nil {
+			var none T
+			return nil, none, false, err
+		}
+	}
+	var alt T
+	var ok bool
+	var err error
+	if sequential && !rank {
+		alt, ok, err = selectSequential(ctx, candidates, probe)
+	} else {
+		alt, ok, err = selectCandidate(ctx, candidates, probe)
+	}
+	return ranked, alt, ok, err
+}
+
+// selectCandidate returns the first candidate configuration accepted by the
+// probe. Probe failures marked with errAbort stop the search.
+//
+// Up to probeWorkers candidates are probed concurrently, on goroutines of
+// the stopper of the context, so that endpoints with long connection
+// timeouts do not multiply them by the number of candidates. The outcome
+// is the same as probing the candidates in order: a candidate is selected,
+// or aborts the search, only once all the preferred ones failed, and the
+// probes of the less preferred candidates still running are then canceled.
+func selectCandidate[T candidateConfig](
+	ctx context.Context, candidates iter.Seq[Storage], probe func(context.Context, T) error,
+) (T, bool, error) {
+	var none T
+	var alts []T
+	for candidate := range candidates {
+		alts = append(alts, candidate.(T))
+	}
+	type outcome struct {
+		index int
+		err   error
+	}
+	work := make(chan int, len(alts))
+	for i := range alts {
+		work <- i
+	}
+	close(work)
+	outcomes := make(chan outcome, len(alts))
+	probeCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	workers := stopper.From(ctx)
+	for range min(probeWorkers, len(alts)) {
+		wg.Add(1)
+		accepted := workers.Go(func(*stopper.Context) error {
+			defer wg.Done()
+			for i := range work {
+				if err := probeCtx.Err(); err != nil {
+					outcomes <- outcome{i, err}
+					continue
+				}
+				outcomes <- outcome{i, probe(probeCtx, alts[i])}
+			}
+			return nil
+		})
+		if !accepted {
+			wg.Done()
+			return none, false, errors.Wrap(stopper.ErrStopped, "unable to probe the candidate configurations")
+		}
+	}
+	errs := make([]error, len(alts))
+	done := make([]bool, len(alts))
+	for next := 0; next < len(alts); {
+		o := <-outcomes
+		errs[o.index], done[o.index] = o.err, true
+		for ; next < len(alts) && done[next]; next++ {
+			if errs[next] == nil {
+				return alts[next], true, nil
+			}
+			if errors.Is(errs[next], errAbort) {
+				return none, false, errs[next]
+			}
+		}
+	}
+	return none, false, nil
+}
 
-		if _, err := s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-			Bucket: aws.String(bucketName),
-		}); err != nil {
-			slog.Debug("Failed to list objects", slog.Any("error", err), slog.Any("env", alt.Params()))
+// minimize removes, one at a time, the boolean parameters of the selected
+// configuration that are not needed, so that the suggestion is the smallest
+// working configuration. Parameters set to false are dropped without
+// probing, since that is their default; parameters set to true are dropped
+// only if the configuration without them passes the probe. Flags toggled by
+// the search are never redundant, since every subset of them is probed
+// first, but flags provided by the user may be.
+func minimize(ctx context.Context, alt *s3Store, probe func(context.Context, *s3Store) error) *s3Store {
+	for _, key := range boolParams {
+		value, ok := alt.params[key]
+		if !ok {
 			continue
 		}
-		// Build a probe key that includes the dest prefix (if any)
-		prefix := strings.TrimPrefix(s.dest, s.BucketName())
-		prefix = strings.TrimPrefix(prefix, "/")
-		probeKey := objectKey
-		if prefix != "" {
-			probeKey = path.Join(prefix, objectKey)
+		candidate := &s3Store{
+			dest:        alt.dest,
+			root:        alt.root,
+			params:      alt.params.Merge(Params{key: ""}),
+			dial:        alt.dial,
+			customCA:    alt.customCA,
+			bypassProxy: alt.bypassProxy,
+		}
+		if alt.params.Bool(key) {
+			if err := probe(ctx, candidate); err != nil {
+				continue
+			}
+		} else {
+			candidate.client, candidate.latency = alt.client, alt.latency
+		}
+		slog.Debug("dropping unneeded parameter", slog.String("param", key), slog.String("value", value))
+		alt = candidate
+	}
+	return alt
+}
+
+// newClient creates an S3 client for the parameters, authenticated with the
+// credentials of the store. The options are applied to the client last.
+func (s *s3Store) newClient(
+	ctx context.Context, params Params, customCA, bypassProxy bool, optFns ...func(*s3.Options),
+) (*s3.Client, error) {
+	var clientMode aws.ClientLogMode
+	if s.verbose {
+		clientMode |= aws.LogRetries | aws.LogRequestWithBody | aws.LogRequestEventMessage | aws.LogResponse | aws.LogResponseEventMessage | aws.LogSigning
+	}
+	var loadOptions []func(options *config.LoadOptions) error
+	addLoadOption := func(option config.LoadOptionsFunc) {
+		loadOptions = append(loadOptions, option)
+	}
+	transport := newTransport(s.timeouts, s.dial, params.Bool(SkipTLSVerify))
+	if customCA {
+		transport.TLSClientConfig = &tls.Config{RootCAs: loadCA(s.caFile)}
+	}
+	if s.proxy != nil && !bypassProxy {
+		transport.Proxy = s.proxy.transportProxy
+	}
+	client := &http.Client{Transport: transport}
+	addLoadOption(config.WithHTTPClient(client))
+	if params.Bool(SkipTLSVerify) {
+		slog.Warn("TLS verification is disabled; use only for testing")
+	}
+	addLoadOption(config.WithRetryer(s.retries.retryer))
+	addLoadOption(config.WithClientLogMode(clientMode))
+	if s.verbose {
+		addLoadOption(config.WithLogger(newSDKLogger(os.Stderr)))
+	}
+	// TODO (silvano) - consider removing testing guard
+	// LoadDefaultConfig will always honor env based provided credentials if present.
+	// The temporary credentials minted for the run replace them.
+	if s.testing || s.session != nil {
+		addLoadOption(config.WithCredentialsProvider(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{
+				AccessKeyID:     s.params[AccountParam],
+				SecretAccessKey: s.params[SecretParam],
+				SessionToken:    s.params[TokenParam],
+			}, nil
+		})))
+	}
+	config, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if roles := params[AssumeRoleParam]; roles != "" {
+		config.Credentials = assumeRoles(config, roles, params[RegionParam], s.role)
+	}
+
+	usePathStyle := params.Bool(UsePathStyleParam)
+	skipChecksum := params.Bool(SkipChecksum)
+	if skipChecksum {
+		// Like CockroachDB, only send and validate the checksums required
+		// by the operations.
+		config.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		config.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+	}
+	s3Client := s3.NewFromConfig(config, append([]func(*s3.Options){func(o *s3.Options) {
+		if ep := params[EndPointParam]; ep != "" {
+			o.BaseEndpoint = aws.String(ep)
 		}
+		o.Region = params[RegionParam]
+		o.UsePathStyle = usePathStyle
+	}}, optFns...)...)
+	return s3Client, nil
+}
+
+// probe verifies that the candidate configuration can list, write, read and
+// delete objects in the bucket, or only list them if the store is read-only.
+// On success, the client is stored in the candidate. The metrics of the
+// requests sent are stored in the candidate in any case.
+func (s *s3Store) probe(ctx context.Context, alt *s3Store, bucketName string) (err error) {
+	ctx, done := withProbeTimeout(ctx, s.timeouts)
+	defer func() { err = done(err) }()
+	requests := &requestLog{}
+	defer func() {
+		alt.requests = requests.snapshot()
+		logRequests(alt.Params(), alt.requests)
+	}()
+	s3Client, err := s.newClient(ctx, alt.Params(), alt.customCA, alt.bypassProxy,
+		s3.WithAPIOptions(requests.addMiddlewares))
+	if err != nil {
+		return errors.Mark(err, errAbort)
+	}
+
+	slog.Debug("Trying params", slog.Any("env", alt.Params()))
+
+	var latency Latency
+	start := time.Now()
+	payer := alt.requestPayer()
+	if _, err := s3Client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:       aws.String(bucketName),
+		RequestPayer: payer,
+	}); err != nil {
+		slog.Debug("Failed to list objects", slog.Any("error", err), slog.Any("env", alt.Params()))
+		return errors.Wrap(err, "failed to list objects")
+	}
+	latency.List = time.Since(start)
+	if s.readOnly {
+		alt.client = s3Client
+		alt.latency = &latency
+		return nil
+	}
+	// Build probe keys that include the dest prefix (if any), unique to
+	// the candidate, since the candidates are probed concurrently.
+	keys := make([]string, s.objects.count())
+	sums := make([][sha256.Size]byte, len(keys))
+	var size int64
+	for i := range keys {
+		keys[i] = path.Join(s.keyPrefix(), s.objects.name(uuid.NewString()))
+		payload := s.objects.payload()
+		sums[i] = sha256.Sum256(payload)
+		size += int64(len(payload))
 		// Try to write the object
 		input := &s3.PutObjectInput{
-			Bucket: aws.String(bucketName),
-			Key:    aws.String(probeKey),
-			Body:   strings.NewReader(content), // Use a reader for the content
+			Bucket:               aws.String(bucketName),
+			Key:                  aws.String(keys[i]),
+			Body:                 bytes.NewReader(payload), // Use a reader for the content
+			Metadata:             s.objects.metadata(),
+			ServerSideEncryption: serverSideEncryption(alt.params),
+			SSEKMSKeyId:          kmsKeyID(alt.params),
+			RequestPayer:         payer,
 		}
+		start = time.Now()
 		if _, err := s3Client.PutObject(ctx, input); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			slog.Error("Failed to put object", slog.Any("error", err), slog.Any("env", alt.Params()))
-			continue
+			return errors.Wrap(err, "failed to put object")
 		}
-		result, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
-			Bucket: aws.String(bucketName),
-			Key:    aws.String(probeKey),
-		})
+		latency.Put += time.Since(start)
+	}
+	for i, key := range keys {
+		start = time.Now()
+		got, err := getObject(ctx, s3Client, bucketName, key, payer)
 		if err != nil {
 			// this shouldn't happen, since we just wrote the object
-			return nil, err
+			return errors.Mark(err, errAbort)
 		}
-		defer result.Body.Close()
-		got, err := io.ReadAll(result.Body)
+		latency.Get += time.Since(start)
+		slog.Debug("Successfully read object", slog.String("key", key), slog.Int("size", len(got)))
+		if s.objects.sized() {
+			if sum := sha256.Sum256(got); sum != sums[i] {
+				return errors.Mark(fmt.Errorf("unexpected content of %s: got SHA-256 %x, want %x",
+					key, sum, sums[i]), errAbort)
+			}
+		} else if want := s.objects.content(); string(got) != want {
+			return errors.Mark(fmt.Errorf("unexpected content: got %q, want %q", got, want), errAbort)
+		}
+	}
+	for _, key := range keys {
+		start = time.Now()
+		_, err = s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket:       aws.String(bucketName),
+			Key:          aws.String(key),
+			RequestPayer: payer,
+		})
 		if err != nil {
-			return nil, err
+			return errors.Mark(err, errAbort)
 		}
-		slog.Debug("Successfully read object", slog.String("content", string(got)))
-		if string(got) != content {
-			return nil, fmt.Errorf("unexpected content: got %q, want %q", got, content)
+		latency.Delete += time.Since(start)
+	}
+	if s.objects.sized() {
+		latency.Upload, latency.Download = throughput(size, latency.Put), throughput(size, latency.Get)
+	}
+	if probeKey := keys[len(keys)-1]; s.deleteWindow > 0 {
+		latency.DeleteVisible, err = waitForDeletion(ctx, s3Client, bucketName, probeKey, payer, s.deleteWindow)
+		if err != nil {
+			return errors.Mark(err, errAbort)
 		}
-		_, err = s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-			Bucket: aws.String(bucketName),
-			Key:    aws.String(probeKey),
+	}
+	alt.client = s3Client
+	alt.latency = &latency
+	return nil
+}
+
+// getObject reads the content of an object.
+func getObject(
+	ctx context.Context, client *s3.Client, bucketName, key string, payer types.RequestPayer,
+) ([]byte, error) {
+	result, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:       aws.String(bucketName),
+		Key:          aws.String(key),
+		RequestPayer: payer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer result.Body.Close()
+	return io.ReadAll(result.Body)
+}
+
+// waitForDeletion lists the bucket until the deleted object is no longer
+// visible, and returns the time it took. Providers with eventually
+// consistent listings may still list the object for a while, which
+// affects backups that overwrite an existing collection.
+func waitForDeletion(
+	ctx context.Context, client *s3.Client, bucketName, key string,
+	payer types.RequestPayer, window time.Duration,
+) (time.Duration, error) {
+	start := time.Now()
+	for {
+		listed, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:       aws.String(bucketName),
+			Prefix:       aws.String(listPrefix(bucketName, key)),
+			RequestPayer: payer,
 		})
 		if err != nil {
-			return nil, err
+			return 0, errors.Wrap(err, "failed to list objects")
+		}
+		if !slices.ContainsFunc(listed.Contents, func(o types.Object) bool {
+			return aws.ToString(o.Key) == key
+		}) {
+			lag := time.Since(start)
+			slog.Debug("deleted object no longer listed", slog.Duration("lag", lag))
+			return lag, nil
+		}
+		if time.Since(start) >= window {
+			return 0, errors.Newf("deleted object %q still listed after %s", key, window)
+		}
+		select {
+		case <-time.After(deletePoll):
+		case <-ctx.Done():
+			return 0, ctx.Err()
 		}
-		slog.Debug("Suggested params", slog.Any("env", alt.Params()))
-		return alt, nil
 	}
-	return nil, fmt.Errorf("unable to connect to storage provider %q", s.dest)
 }
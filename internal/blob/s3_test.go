@@ -15,12 +15,20 @@
 package blob
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/field-eng-powertools/stopper"
 	"github.com/cockroachlabs-field/blobcheck/internal/env"
 )
@@ -188,6 +196,11 @@ func TestCandidateConfigs(t *testing.T) {
 				dest:   tt.dest,
 			}
 			gotSeq := s.candidateConfigs()
+			// The combinations are tried again with SSE-S3 headers.
+			want := slices.Clone(tt.want)
+			for _, p := range tt.want {
+				want = append(want, p.Merge(Params{ServerEncModeParam: "AES256"}))
+			}
 			var got []Params
 			gotSeq(func(d Storage) bool {
 				if alt, ok := d.(*s3Store); ok {
@@ -195,11 +208,313 @@ func TestCandidateConfigs(t *testing.T) {
 				}
 				return true
 			})
-			assert.Equal(t, len(tt.want), len(got), "candidateConfigs() count mismatch")
-			assert.ElementsMatch(t, tt.want, got, "candidateConfigs() elements mismatch")
+			assert.Equal(t, len(want), len(got), "candidateConfigs() count mismatch")
+			assert.ElementsMatch(t, want, got, "candidateConfigs() elements mismatch")
+		})
+	}
+}
+func TestMinimize(t *testing.T) {
+	a := assert.New(t)
+	var probed []Params
+	// The provider only requires path style requests.
+	probe := func(_ context.Context, alt *s3Store) error {
+		probed = append(probed, alt.params)
+		if !alt.params.Bool(UsePathStyleParam) {
+			return errors.New("no such host")
+		}
+		return nil
+	}
+	alt := &s3Store{dest: "bucket/key", params: Params{
+		RegionParam:       "us-east-1",
+		SkipChecksum:      "true",
+		SkipTLSVerify:     "false",
+		UsePathStyleParam: "true",
+	}}
+	got := minimize(t.Context(), alt, probe)
+	a.Equal(Params{RegionParam: "us-east-1", UsePathStyleParam: "true"}, got.params)
+	a.Equal("bucket/key", got.dest)
+	// Parameters set to false are dropped without probing.
+	a.Equal([]Params{
+		{RegionParam: "us-east-1", SkipChecksum: "true", SkipTLSVerify: "false"},
+		{RegionParam: "us-east-1", UsePathStyleParam: "true", SkipTLSVerify: "false"},
+	}, probed)
+}
+
+func TestSelectCandidate(t *testing.T) {
+	initial := &s3Store{dest: "bucket/key", params: Params{RegionParam: "us-east-1"}}
+	index := make(map[string]int)
+	for alt := range initial.candidateConfigs() {
+		index[alt.Params().Encode()] = len(index)
+	}
+	failed := errors.New("no such host")
+	aborted := errors.Mark(errors.New("unexpected content"), errAbort)
+	// after returns the outcome of a probe after a delay.
+	after := func(delay time.Duration, err error) func(context.Context) error {
+		return func(context.Context) error {
+			time.Sleep(delay)
+			return err
+		}
+	}
+	// blocked is the outcome of a probe that only ends when canceled.
+	blocked := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	tests := []struct {
+		name    string
+		probes  map[int]func(context.Context) error // outcomes by preference, others fail
+		want    int                                 // preference of the selected candidate, -1 if none
+		wantErr bool
+	}{
+		{
+			name: "preferred candidate wins over faster ones",
+			probes: map[int]func(context.Context) error{
+				0: after(20*time.Millisecond, failed),
+				1: after(20*time.Millisecond, nil),
+				2: after(0, nil),
+			},
+			want: 1,
+		},
+		{
+			name: "losers are canceled",
+			probes: map[int]func(context.Context) error{
+				0: after(10*time.Millisecond, nil),
+				1: blocked,
+				2: blocked,
+				3: blocked,
+			},
+			want: 0,
+		},
+		{
+			name: "abort after preferred failures",
+			probes: map[int]func(context.Context) error{
+				0: after(20*time.Millisecond, failed),
+				1: after(0, aborted),
+				2: after(0, nil),
+			},
+			want:    -1,
+			wantErr: true,
+		},
+		{
+			name: "abort of a less preferred candidate is ignored",
+			probes: map[int]func(context.Context) error{
+				0: after(20*time.Millisecond, nil),
+				1: after(0, aborted),
+			},
+			want: 0,
+		},
+		{
+			name:   "no candidate works",
+			probes: map[int]func(context.Context) error{},
+			want:   -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			var mu sync.Mutex
+			var running, peak int
+			alt, ok, err := selectCandidate(t.Context(), initial.candidateConfigs(),
+				func(ctx context.Context, alt *s3Store) error {
+					mu.Lock()
+					running++
+					peak = max(peak, running)
+					mu.Unlock()
+					defer func() {
+						mu.Lock()
+						running--
+						mu.Unlock()
+					}()
+					if probe, ok := tt.probes[index[alt.Params().Encode()]]; ok {
+						return probe(ctx)
+					}
+					return failed
+				})
+			a.LessOrEqual(peak, probeWorkers)
+			a.Zero(running, "probes still running after the selection")
+			if tt.wantErr {
+				a.True(errors.Is(err, errAbort))
+				return
+			}
+			a.NoError(err)
+			a.Equal(tt.want >= 0, ok)
+			if ok {
+				a.Equal(tt.want, index[alt.Params().Encode()])
+			}
+		})
+	}
+}
+
+// TestProbePathPrefix verifies that the SDK sends the requests under the
+// path prefix of a gateway endpoint, and that the prefix is part of the
+// suggested URL.
+func TestProbePathPrefix(t *testing.T) {
+	r := require.New(t)
+	// The CA bundle of the environment cannot be added to the test client.
+	t.Setenv("AWS_CA_BUNDLE", "")
+	var mu sync.Mutex
+	var paths []string
+	fake := &fakeS3{multipart: true, ranges: true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		paths = append(paths, req.Method+" "+req.URL.Path)
+		mu.Unlock()
+		fake.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+
+	params, dest, err := s3Params(&env.Env{
+		URI:            "s3://bucket/path?AWS_ACCESS_KEY_ID=id&AWS_SECRET_ACCESS_KEY=secret&AWS_ENDPOINT=" + server.URL,
+		EndpointPrefix: "/s3proxy",
+	})
+	r.NoError(err)
+	r.Equal(server.URL+"/s3proxy", params[EndPointParam])
+	s := &s3Store{dest: dest, root: dest, params: params, testing: true}
+	alt := &s3Store{dest: dest, root: dest, params: params.Merge(Params{UsePathStyleParam: "true"})}
+	r.NoError(s.probe(context.Background(), alt, s.BucketName()))
+	r.NotEmpty(paths)
+	for _, p := range paths {
+		_, reqPath, _ := strings.Cut(p, " ")
+		r.True(strings.HasPrefix(reqPath, "/s3proxy/bucket"), p)
+	}
+	suggested, err := ParseS3URL(alt.URL())
+	r.NoError(err)
+	r.Equal(server.URL+"/s3proxy", suggested.Params[EndPointParam])
+}
+
+// TestKeyPrefix verifies that the keys of the probes are relative to the
+// bucket, without the bucket name or redundant separators.
+func TestKeyPrefix(t *testing.T) {
+	tests := []struct {
+		dest string
+		want string
+	}{
+		{dest: "bucket", want: ""},
+		{dest: "bucket/", want: ""},
+		{dest: "bucket/path", want: "path"},
+		{dest: "bucket/path/", want: "path"},
+		{dest: "bucket//path/./run", want: "path/run"},
+		{dest: "bucket/bucket/path", want: "bucket/path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dest, func(t *testing.T) {
+			assert.Equal(t, tt.want, (&s3Store{dest: tt.dest}).keyPrefix())
 		})
 	}
 }
+
+func TestCleanAndList(t *testing.T) {
+	r := require.New(t)
+	fake := &fakeS3{}
+	_, alt := newFakeS3Store(t, fake)
+	fake.mu.Lock()
+	for _, key := range []string{"/bucket/path/a", "/bucket/path/dir/b", "/bucket/pathology/c", "/bucket/other/d"} {
+		fake.objects[key] = "content"
+	}
+	fake.mu.Unlock()
+
+	// Only the objects under the destination are listed, relative to it.
+	objects, err := alt.List(context.Background())
+	r.NoError(err)
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	r.ElementsMatch([]string{"a", "dir/b"}, keys)
+
+	// Only the objects under the destination are deleted.
+	r.NoError(alt.Clean(context.Background()))
+	objects, err = alt.List(context.Background())
+	r.NoError(err)
+	r.Empty(objects)
+	fake.mu.Lock()
+	r.Len(fake.objects, 2)
+	r.Contains(fake.objects, "/bucket/pathology/c")
+	r.Contains(fake.objects, "/bucket/other/d")
+	fake.mu.Unlock()
+
+	// A whole bucket is never wiped.
+	bucket := &s3Store{dest: "bucket", params: alt.params, client: alt.client}
+	r.ErrorContains(bucket.Clean(context.Background()), "refusing to clean a destination without a prefix")
+
+	_, err = (&s3Store{dest: "bucket/path"}).List(context.Background())
+	r.ErrorContains(err, "not connected")
+}
+
+// TestReadOnlyProbe verifies that the stores opened by list and prune only
+// list the bucket, so that they connect to buckets that deny writes.
+func TestReadOnlyProbe(t *testing.T) {
+	r := require.New(t)
+	fake := &fakeS3{denyWrites: true}
+	s, _ := fakeS3Stores(t, fake, func(s *s3Store) { s.readOnly = true })
+	store, err := s.try(context.Background(), s.BucketName())
+	r.NoError(err)
+	objects, err := store.List(context.Background())
+	r.NoError(err)
+	r.Empty(objects)
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	r.Empty(fake.objects)
+}
+
+// TestRequesterPays verifies that a bucket that denies the requests without
+// a request payer is detected, and that the suggested URL enables it.
+func TestRequesterPays(t *testing.T) {
+	r := require.New(t)
+	t.Setenv("AWS_CA_BUNDLE", "")
+	server := httptest.NewServer(&fakeS3{requesterPays: true})
+	defer server.Close()
+
+	s := &s3Store{
+		dest: "bucket/path",
+		root: "bucket",
+		params: Params{
+			AccountParam:      "id",
+			SecretParam:       "secret",
+			RegionParam:       DefaultRegion,
+			EndPointParam:     server.URL,
+			UsePathStyleParam: "true",
+		},
+		provided: Params{AccountParam: "id", SecretParam: "secret", EndPointParam: server.URL},
+		testing:  true,
+	}
+	store, err := s.try(context.Background(), s.BucketName())
+	r.NoError(err)
+	r.True(store.Params().Bool(RequesterPaysParam))
+	// The provided parameters are kept, to show what the user must change.
+	r.Equal(Params{AccountParam: "id", SecretParam: Obfuscated, EndPointParam: server.URL}, store.ProvidedParams())
+	suggested, err := ParseS3URL(store.URL())
+	r.NoError(err)
+	r.Equal("true", suggested.Params[RequesterPaysParam])
+	objects, err := store.List(context.Background())
+	r.NoError(err)
+	r.Empty(objects)
+}
+
+// TestAccessPointParams verifies that the requests through an access point
+// are signed for its region, and that path style requests are not probed.
+func TestAccessPointParams(t *testing.T) {
+	r := require.New(t)
+	const ap = "arn:aws:s3:eu-west-1:123456789012:accesspoint/my-ap"
+	params, dest, err := s3Params(&env.Env{
+		URI: "s3://" + ap + "/backups?AWS_ACCESS_KEY_ID=id&AWS_SECRET_ACCESS_KEY=secret",
+	})
+	r.NoError(err)
+	r.Equal("eu-west-1", params[RegionParam])
+	// The region is derived from the access point, not provided.
+	provided, _, err := providedS3Params(&env.Env{
+		URI: "s3://" + ap + "/backups?AWS_ACCESS_KEY_ID=id&AWS_SECRET_ACCESS_KEY=secret",
+	})
+	r.NoError(err)
+	r.NotContains(provided, RegionParam)
+	s := &s3Store{dest: dest, params: params}
+	r.Equal(ap, s.BucketName())
+	r.Equal("backups", s.keyPrefix())
+	for candidate := range s.candidateConfigs() {
+		r.False(candidate.Params().Bool(UsePathStyleParam))
+	}
+}
+
 func TestS3ParamsObfuscation(t *testing.T) {
 	tests := []struct {
 		name   string
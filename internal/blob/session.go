@@ -0,0 +1,95 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Bounds of the lifetime of the credentials returned by GetSessionToken.
+const (
+	MinSessionDuration = 15 * time.Minute
+	MaxSessionDuration = 36 * time.Hour
+)
+
+// SessionToken describes the temporary credentials minted for the run with
+// STS GetSessionToken, which replace the long-lived access keys in the
+// probes and in the external connection.
+type SessionToken struct {
+	Duration   time.Duration // lifetime requested
+	Expiration time.Time     // when the credentials expire
+}
+
+// mintSession exchanges the access keys of the store for temporary
+// credentials valid for the duration, and uses them instead of the keys
+// from then on, including in the parameters provided, so that the keys do
+// not show up as a suggested change.
+func (s *s3Store) mintSession(ctx context.Context, duration time.Duration) error {
+	switch p := s.params; {
+	case duration < MinSessionDuration || duration > MaxSessionDuration:
+		return errors.Newf("session token duration must be between %s and %s", MinSessionDuration, MaxSessionDuration)
+	case p[AuthParam] == AuthImplicit:
+		return errors.Newf("a session token requires access keys, not %s=%s", AuthParam, AuthImplicit)
+	case p[TokenParam] != "":
+		return errors.Newf("the credentials are already temporary: %s is set", TokenParam)
+	}
+	transport := newTransport(s.timeouts, s.dial, false)
+	if s.proxy != nil {
+		transport.Proxy = s.proxy.transportProxy
+	}
+	client := sts.New(sts.Options{
+		Region:      s.params[RegionParam],
+		Credentials: credentials.NewStaticCredentialsProvider(s.params[AccountParam], s.params[SecretParam], ""),
+		HTTPClient:  &http.Client{Transport: transport},
+	}, func(o *sts.Options) {
+		if s.role.endpoint != "" {
+			o.BaseEndpoint = aws.String(s.role.endpoint)
+		}
+	})
+	out, err := client.GetSessionToken(ctx, &sts.GetSessionTokenInput{
+		DurationSeconds: aws.Int32(int32(duration / time.Second)),
+	})
+	if err != nil {
+		return errors.WithHint(errors.Wrap(err, "failed to get a session token"),
+			"GetSessionToken requires the keys of an IAM user; set "+STSEndpointEnv+" for providers with their own STS")
+	}
+	temporary := Params{
+		AccountParam: aws.ToString(out.Credentials.AccessKeyId),
+		SecretParam:  aws.ToString(out.Credentials.SecretAccessKey),
+		TokenParam:   aws.ToString(out.Credentials.SessionToken),
+	}
+	s.params = s.params.Merge(temporary)
+	if s.provided != nil {
+		s.provided = s.provided.Merge(temporary)
+	}
+	s.session = &SessionToken{Duration: duration, Expiration: aws.ToTime(out.Credentials.Expiration)}
+	slog.Info("using temporary credentials",
+		slog.String("access_key_id", temporary[AccountParam]), slog.Time("expiration", s.session.Expiration))
+	return nil
+}
+
+// Session implements SessionReporter.
+func (s *s3Store) Session() *SessionToken {
+	return s.session
+}
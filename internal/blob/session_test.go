@@ -0,0 +1,77 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMintSession(t *testing.T) {
+	r := require.New(t)
+	t.Setenv("AWS_CA_BUNDLE", "")
+	sts := &fakeSTS{}
+	stsServer := httptest.NewServer(sts)
+	defer stsServer.Close()
+	// The storage only accepts the temporary credentials.
+	s3Server := httptest.NewServer(&fakeS3{accessKey: "ASIASESSION"})
+	defer s3Server.Close()
+
+	params := Params{
+		AccountParam:      "AKIALONGLIVED",
+		SecretParam:       "secret",
+		RegionParam:       "us-east-1",
+		EndPointParam:     s3Server.URL,
+		UsePathStyleParam: "true",
+	}
+	s := &s3Store{
+		dest:     "bucket/path",
+		root:     "bucket/path",
+		params:   params,
+		provided: params,
+		role:     roleOptions{endpoint: stsServer.URL},
+	}
+	r.NoError(s.mintSession(context.Background(), time.Hour))
+	r.Len(sts.requests, 1)
+	assert.Equal(t, "GetSessionToken", sts.requests[0].Get("Action"))
+	assert.Equal(t, "3600", sts.requests[0].Get("DurationSeconds"))
+
+	store, err := s.try(context.Background(), s.BucketName())
+	r.NoError(err)
+	r.Equal("ASIASESSION", store.Params()[AccountParam])
+	r.Equal(Obfuscated, store.Params()[TokenParam])
+	r.Contains(store.URL(), "AWS_SESSION_TOKEN=token")
+	r.NotContains(store.URL(), "AKIALONGLIVED")
+	// The keys are not reported as a change of the provided parameters.
+	r.Equal("ASIASESSION", store.ProvidedParams()[AccountParam])
+	r.Equal(time.Hour, store.Session().Duration)
+	r.WithinDuration(time.Now().Add(time.Hour), store.Session().Expiration, time.Minute)
+}
+
+func TestMintSessionErrors(t *testing.T) {
+	a := assert.New(t)
+	keys := Params{AccountParam: "id", SecretParam: "secret"}
+	a.ErrorContains((&s3Store{params: keys}).mintSession(context.Background(), time.Minute),
+		"must be between 15m0s and 36h0m0s")
+	a.ErrorContains((&s3Store{params: Params{AuthParam: AuthImplicit}}).mintSession(context.Background(), time.Hour),
+		"requires access keys")
+	a.ErrorContains((&s3Store{params: keys.Merge(Params{TokenParam: "token"})}).mintSession(context.Background(), time.Hour),
+		"already temporary")
+}
@@ -16,10 +16,61 @@
 package blob
 
 import (
+	"context"
 	"iter"
+	"maps"
+	"net/url"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
 )
 
+// ErrLocked is returned when an object cannot be deleted because it is
+// protected by a retention period or a legal hold.
+var ErrLocked = errors.New("object is locked")
+
+// ErrUnsupported is returned by the probes that the storage provider does
+// not implement.
+var ErrUnsupported = errors.New("not supported by the storage provider")
+
+// Object describes an object stored in the destination.
+type Object struct {
+	Key          string    `json:"key"` // key relative to the destination
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Latency holds the time taken by each operation of the probe that
+// verified the configuration of the storage. Put, Get and Delete cover all
+// the objects written by the probe.
+type Latency struct {
+	List   time.Duration
+	Put    time.Duration
+	Get    time.Duration
+	Delete time.Duration
+	// DeleteVisible is the time taken by the deleted object to disappear
+	// from listings, if checked.
+	DeleteVisible time.Duration
+	// Upload and Download are the effective throughputs of the transfers,
+	// in bytes per second, if the probe objects are padded to a size.
+	Upload, Download float64
+}
+
+// throughput returns the rate of a transfer of size bytes, in bytes per
+// second.
+func throughput(size int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(size) / elapsed.Seconds()
+}
+
 // Params represents the parameters to be set for a destination to perform a backup/restore.
 type Params map[string]string
 
@@ -32,6 +83,88 @@ func (p Params) sortedKeys() []string {
 	return keys
 }
 
+// Bool returns whether the parameter is set to a true value, as parsed by
+// CockroachDB (e.g. "true", "1", "TRUE").
+func (p Params) Bool(key string) bool {
+	b, _ := strconv.ParseBool(p[key])
+	return b
+}
+
+// URL returns the parameter parsed as an absolute http or https URL, or nil
+// if it is not set. The URL may have a path, for gateways that serve the S3
+// API under a prefix, but no query or fragment.
+func (p Params) URL(key string) (*url.URL, error) {
+	value, ok := p[key]
+	if !ok || value == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", key)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.Newf("invalid %s %q: expected http(s)://host[:port][/prefix]", key, value)
+	}
+	return u, nil
+}
+
+// Validate checks that every parameter is known, and that boolean and URL
+// parameters are well formed.
+func (p Params) Validate() error {
+	for key, value := range p.Iter() {
+		if !slices.Contains(ValidParams, key) {
+			return errors.WithHintf(errors.Newf("unknown parameter %q", key),
+				"supported parameters: %s", strings.Join(ValidParams, ", "))
+		}
+		switch {
+		case slices.Contains(boolParams, key):
+			if _, err := strconv.ParseBool(value); err != nil {
+				return errors.Newf("invalid %s %q: expected true or false", key, value)
+			}
+		case slices.Contains(urlParams, key):
+			if _, err := p.URL(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Merge returns a copy of the parameters, overridden by the given ones in
+// order: later parameters take precedence over earlier ones, and an empty
+// value removes the parameter.
+func (p Params) Merge(overrides ...Params) Params {
+	res := maps.Clone(p)
+	if res == nil {
+		res = make(Params)
+	}
+	for _, o := range overrides {
+		for k, v := range o {
+			if v == "" {
+				delete(res, k)
+			} else {
+				res[k] = v
+			}
+		}
+	}
+	return res
+}
+
+// Encode returns the canonical URL query string of the parameters: keys are
+// sorted, and keys and values are query escaped.
+func (p Params) Encode() string {
+	var sb strings.Builder
+	for key, value := range p.Iter() {
+		if sb.Len() > 0 {
+			sb.WriteString("&")
+		}
+		sb.WriteString(url.QueryEscape(key))
+		sb.WriteString("=")
+		sb.WriteString(url.QueryEscape(value))
+	}
+	return sb.String()
+}
+
 // Iter returns an iterator over the parameters sorted by key.
 func (p Params) Iter() iter.Seq2[string, string] {
 	return func(yield func(string, string) bool) {
@@ -47,8 +180,170 @@ func (p Params) Iter() iter.Seq2[string, string] {
 type Storage interface {
 	// Params returns a copy of the params.
 	Params() Params
+	// ProvidedParams returns a copy of the params provided by the user,
+	// with the secrets obfuscated like Params, or nil if the params are
+	// used as provided.
+	ProvidedParams() Params
 	// URL returns a escaped URL.
 	URL() string
+	// RootURL returns the escaped URL of the destination provided by the
+	// user, without the unique sub-path used by the validation.
+	RootURL() string
+	// Candidates returns the working configurations found while connecting,
+	// from the most to the least secure, if ranking was enabled.
+	Candidates() []Candidate
+	// Probed returns every configuration probed while connecting, working
+	// or not, if ranking was enabled, so that the cluster can check them.
+	Probed() []Probed
+	// CustomCA returns the CA bundle provided by the user that the selected
+	// configuration verifies the storage with, instead of the system roots,
+	// or an empty string.
+	CustomCA() string
+	// Latency returns the timings of the probe operations of the selected
+	// configuration, or nil if they are not known.
+	Latency() *Latency
+	// Capabilities probes the operations used by backups and restores with
+	// the selected configuration, including multipart uploads and ranged
+	// reads.
+	Capabilities(ctx context.Context) ([]Capability, error)
 	// BucketName returns the name of the bucket.
 	BucketName() string
+	// Clean removes all the objects stored in the destination.
+	Clean(ctx context.Context) error
+	// Delete removes a single object, given its key relative to the
+	// destination. It returns ErrLocked if the object is protected by object
+	// lock.
+	Delete(ctx context.Context, key string) error
+	// Get returns the content of a single object, given its key relative to
+	// the destination.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the objects stored in the destination.
+	List(ctx context.Context) ([]Object, error)
+}
+
+// The interfaces below are implemented by the stores that support the
+// matching probe, which are S3 stores. Callers check for them with a type
+// assertion, and skip the probe on the other stores.
+
+// SessionReporter is implemented by the stores that can mint temporary
+// credentials for the run.
+type SessionReporter interface {
+	// Session returns the temporary credentials minted for the run, which
+	// the external connection uses instead of the access keys, if any.
+	Session() *SessionToken
+}
+
+// ProxyReporter is implemented by the stores that can reach the storage
+// through an HTTP proxy.
+type ProxyReporter interface {
+	// Proxy reports whether the selected configuration goes through the
+	// HTTP proxy, if one is configured.
+	Proxy() *ProxyCheck
+}
+
+// ListingProber is implemented by the stores that can time the listings of
+// a growing prefix.
+type ListingProber interface {
+	// ProbeListing creates up to count tiny objects under a prefix, timing
+	// the listing of the prefix as the number of objects grows, and deletes
+	// them.
+	ProbeListing(ctx context.Context, count int) ([]ListingSample, error)
+}
+
+// PaginationProber is implemented by the stores that can verify the list
+// pagination.
+type PaginationProber interface {
+	// ProbePagination writes more objects than a list page holds under a
+	// prefix, some of them under sub-prefixes, and verifies that the
+	// listings follow the continuation tokens and group the keys by
+	// delimiter. The objects are deleted afterwards.
+	ProbePagination(ctx context.Context) (*Pagination, error)
+}
+
+// VirtualHostProber is implemented by the stores that can be addressed
+// with virtual-hosted requests.
+type VirtualHostProber interface {
+	// ProbeVirtualHost resolves the host name of the bucket as a sub-domain
+	// of the given domain, verifies its certificate, and probes the
+	// suggested configuration with virtual-hosted requests to the domain.
+	ProbeVirtualHost(ctx context.Context, domain string) (*VirtualHost, error)
+}
+
+// KeyProber is implemented by the stores that can verify which object keys
+// are safe.
+type KeyProber interface {
+	// ProbeKeys writes, lists and reads back objects whose keys contain
+	// special characters or have the maximum length, reporting which
+	// classes of keys are safe.
+	ProbeKeys(ctx context.Context) ([]KeyCheck, error)
+}
+
+// ArchivalProber is implemented by the stores whose objects can transition
+// to an archival storage class.
+type ArchivalProber interface {
+	// ProbeArchival checks whether the objects written to the destination
+	// can transition to an archival storage class, which restores cannot
+	// read until the objects are retrieved.
+	ProbeArchival(ctx context.Context) (*Archival, error)
+}
+
+// EndpointProber is implemented by the stores that can be served by
+// several endpoints.
+type EndpointProber interface {
+	// ProbeEndpoints probes the suggested configuration against each of
+	// the endpoints provided by the user, to verify that the destination
+	// survives the outage of one of them. It returns nil if a single
+	// endpoint was provided.
+	ProbeEndpoints(ctx context.Context) (*Failover, error)
+}
+
+// ObjectLockReporter is implemented by the stores that can protect the
+// objects from deletion.
+type ObjectLockReporter interface {
+	// ObjectLock returns the object lock configuration of the bucket, which
+	// protects the objects from deletion until their retention expires.
+	ObjectLock(ctx context.Context) (*ObjectLock, error)
+}
+
+// BucketInventoryReporter is implemented by the stores that can describe
+// the configuration of the bucket.
+type BucketInventoryReporter interface {
+	// BucketInventory reads the versioning, object lock and lifecycle
+	// configuration of the bucket.
+	BucketInventory(ctx context.Context) (*BucketInventory, error)
+}
+
+// s3Storage is a Storage implementing every optional interface of the S3
+// stores, so that the stores wrapping them keep the probes.
+type s3Storage interface {
+	VersionedStorage
+	SessionReporter
+	ProxyReporter
+	ListingProber
+	PaginationProber
+	VirtualHostProber
+	KeyProber
+	ArchivalProber
+	EndpointProber
+	ObjectLockReporter
+	BucketInventoryReporter
+}
+
+// DestID returns the sub-path of the destination written by a run: the one
+// set in the environment, so that a run can be resumed or inspected, or a
+// random UUID.
+func DestID(env *env.Env) string {
+	if env.DestID != "" {
+		return env.DestID
+	}
+	return uuid.NewString()
+}
+
+// ValidateDestID checks that the sub-path of a run is a single path
+// element.
+func ValidateDestID(id string) error {
+	if strings.Contains(id, "/") || id == "." || id == ".." {
+		return errors.Newf("invalid destination ID %q: must be a single path element", id)
+	}
+	return nil
 }
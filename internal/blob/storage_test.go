@@ -65,3 +65,56 @@ func TestParamsIter(t *testing.T) {
 	a.Equal(gotKeys, wantKeys)
 	a.Equal(gotVals, wantVals)
 }
+
+func TestParamsBool(t *testing.T) {
+	a := assert.New(t)
+	p := Params{SkipChecksum: "true", SkipTLSVerify: "1", UsePathStyleParam: "false"}
+	a.True(p.Bool(SkipChecksum))
+	a.True(p.Bool(SkipTLSVerify))
+	a.False(p.Bool(UsePathStyleParam))
+	a.False(p.Bool(RegionParam))
+}
+
+func TestParamsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  Params
+		wantErr string
+	}{
+		{name: "valid", params: Params{
+			AccountParam: "id", SecretParam: "secret", EndPointParam: "https://s3.example.com:9000",
+			UsePathStyleParam: "true", RegionParam: "us-east-1",
+		}},
+		{name: "unknown", params: Params{"AWS_ENDPOIT": "x"}, wantErr: `unknown parameter "AWS_ENDPOIT"`},
+		{name: "bool", params: Params{SkipChecksum: "yes"}, wantErr: "expected true or false"},
+		{name: "url scheme", params: Params{EndPointParam: "s3.example.com"}, wantErr: "expected http(s)://host[:port]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParamsMerge(t *testing.T) {
+	a := assert.New(t)
+	defaults := Params{RegionParam: DefaultRegion, UsePathStyleParam: "true"}
+	user := Params{RegionParam: "us-east-1"}
+	got := defaults.Merge(user, Params{UsePathStyleParam: ""})
+	a.Equal(Params{RegionParam: "us-east-1"}, got)
+	// The receiver is not modified.
+	a.Equal(Params{RegionParam: DefaultRegion, UsePathStyleParam: "true"}, defaults)
+	a.Equal(Params{RegionParam: "us-east-1"}, Params(nil).Merge(user))
+}
+
+func TestParamsEncode(t *testing.T) {
+	p := Params{SecretParam: "a/b+c", AccountParam: "id", EndPointParam: "http://host:9000"}
+	assert.Equal(t,
+		"AWS_ACCESS_KEY_ID=id&AWS_ENDPOINT=http%3A%2F%2Fhost%3A9000&AWS_SECRET_ACCESS_KEY=a%2Fb%2Bc",
+		p.Encode())
+}
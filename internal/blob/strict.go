@@ -0,0 +1,55 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// forbiddenFromEnv returns the parameters that no candidate configuration
+// may enable, with --strict or --strict-checksum.
+func forbiddenFromEnv(env *env.Env) []string {
+	switch {
+	case env.StrictChecksum:
+		return []string{SkipTLSVerify, SkipChecksum}
+	case env.Strict:
+		return []string{SkipTLSVerify}
+	default:
+		return nil
+	}
+}
+
+// forbids returns whether the parameters enable one of the forbidden ones.
+func forbids(forbidden []string, params Params) bool {
+	for _, key := range forbidden {
+		if params.Bool(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// withStrictHint tells the user that the storage may only work with the
+// parameters forbidden in strict mode, which were not tried.
+func withStrictHint(err error, forbidden []string) error {
+	if len(forbidden) == 0 {
+		return err
+	}
+	return errors.WithHint(err, "strict mode does not try the configurations enabling "+
+		strings.Join(forbidden, " or ")+": the storage may only work with them, which the policy forbids")
+}
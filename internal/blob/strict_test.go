@@ -0,0 +1,84 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestStrictCandidates(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       *env.Env
+		forbidden []string
+		count     int
+	}{
+		{name: "default", env: &env.Env{}, count: 16},
+		{name: "strict", env: &env.Env{Strict: true}, forbidden: []string{SkipTLSVerify}, count: 8},
+		{
+			name:      "strict checksum",
+			env:       &env.Env{StrictChecksum: true},
+			forbidden: []string{SkipTLSVerify, SkipChecksum},
+			count:     4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			forbidden := forbiddenFromEnv(tt.env)
+			a.Equal(tt.forbidden, forbidden)
+			// The initial parameters skip the verification, which the
+			// candidates toggle.
+			initial := &s3Store{
+				dest:      "bucket/key",
+				params:    Params{RegionParam: "us-east-1", SkipTLSVerify: "true"},
+				forbidden: forbidden,
+			}
+			count := 0
+			for candidate := range initial.candidateConfigs() {
+				count++
+				for _, key := range tt.forbidden {
+					a.False(candidate.Params().Bool(key), key)
+				}
+			}
+			a.Equal(tt.count, count)
+		})
+	}
+}
+
+func TestStrictHTTPCandidates(t *testing.T) {
+	a := assert.New(t)
+	store, err := newHTTPStore(&env.Env{URI: "https://files.example.com/backups", Strict: true})
+	a.NoError(err)
+	var flags []Params
+	for candidate := range store.candidateConfigs() {
+		flags = append(flags, candidate.(*httpStore).flags)
+	}
+	a.Equal([]Params{{}}, flags)
+}
+
+func TestStrictHint(t *testing.T) {
+	a := assert.New(t)
+	err := errors.New("unable to connect to storage provider")
+	a.Same(err, withStrictHint(err, nil))
+	hints := errors.GetAllHints(withStrictHint(err, []string{SkipTLSVerify}))
+	a.Len(hints, 1)
+	a.Contains(hints[0], SkipTLSVerify)
+}
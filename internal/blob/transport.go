@@ -0,0 +1,168 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// Timeout errors of the storage HTTP client. A probe that fails because of
+// a timeout is marked with the one that fired.
+var (
+	ErrDialTimeout           = errors.New("dial timeout")
+	ErrTLSHandshakeTimeout   = errors.New("TLS handshake timeout")
+	ErrResponseHeaderTimeout = errors.New("response header timeout")
+	ErrProbeTimeout          = errors.New("probe timeout")
+)
+
+// Timeouts configures the connections of the storage HTTP client, and the
+// probes sent through it. Zero values disable the corresponding timeout; a
+// negative keepalive disables TCP keepalives.
+type Timeouts struct {
+	Dial           time.Duration // time to establish a connection
+	TLSHandshake   time.Duration // time to complete the TLS handshake
+	ResponseHeader time.Duration // time to receive the response headers once the request is sent
+	KeepAlive      time.Duration // interval between TCP keepalive probes
+	Probe          time.Duration // time to complete the probe of a configuration, retries included
+}
+
+// Retries configures how the requests of the probes are retried after a
+// transient failure, such as a throttling response or a reset connection.
+type Retries struct {
+	Max        int           // number of retries of a request (0 for a single attempt)
+	MaxBackoff time.Duration // maximum delay before a retry (0 for the default of the SDK)
+}
+
+// retriesFromEnv returns the retry policy configured in the environment.
+func retriesFromEnv(env *env.Env) Retries {
+	return Retries{Max: env.ProbeRetries, MaxBackoff: env.ProbeBackoff}
+}
+
+// retryer returns the retryer of the storage clients, with exponential
+// backoff and jitter between the attempts.
+func (r Retries) retryer() aws.Retryer {
+	return retry.NewStandard(func(o *retry.StandardOptions) {
+		o.MaxAttempts = max(r.Max, 0) + 1
+		if r.MaxBackoff > 0 {
+			o.MaxBackoff = r.MaxBackoff
+		}
+	})
+}
+
+// timeoutsFromEnv returns the timeouts configured in the environment.
+func timeoutsFromEnv(env *env.Env) Timeouts {
+	return Timeouts{
+		Dial:           env.DialTimeout,
+		TLSHandshake:   env.TLSHandshakeTimeout,
+		ResponseHeader: env.ResponseHeaderTimeout,
+		KeepAlive:      env.TCPKeepAlive,
+		Probe:          env.ProbeTimeout,
+	}
+}
+
+// newTransport returns the HTTP transport used to reach the storage. If
+// dial is not nil, it is used to establish the connections, within the
+// dial timeout; keepalives are then up to the proxy or tunnel.
+func newTransport(t Timeouts, dial env.DialFunc, skipTLSVerify bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: t.Dial, KeepAlive: t.KeepAlive}
+	dialContext := dialer.DialContext
+	if dial != nil {
+		dialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if t.Dial > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, t.Dial)
+				defer cancel()
+			}
+			return dial(ctx, network, addr)
+		}
+	}
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialContext(ctx, network, addr)
+			if err != nil && ctx.Err() == nil && isTimeout(err) {
+				return nil, errors.Mark(
+					errors.Wrapf(err, "no connection to %s within the dial timeout (%s)", addr, t.Dial),
+					ErrDialTimeout)
+			}
+			return conn, err
+		},
+		TLSClientConfig:       &tls.Config{InsecureSkipVerify: skipTLSVerify},
+		TLSHandshakeTimeout:   t.TLSHandshake,
+		ResponseHeaderTimeout: t.ResponseHeader,
+	}
+}
+
+// isTimeout returns whether the error is a network timeout.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// classifyTimeout marks the error with the timeout of the transport that
+// fired, if any, so that it is reported instead of a generic deadline error.
+// The HTTP transport does not export its timeout errors, so they are
+// recognized by their message.
+func classifyTimeout(err error, t Timeouts) error {
+	switch {
+	case err == nil || errors.Is(err, ErrDialTimeout):
+		return err
+	case strings.Contains(err.Error(), "TLS handshake timeout"):
+		return errors.Mark(errors.Wrapf(err, "TLS handshake not completed within %s", t.TLSHandshake),
+			ErrTLSHandshakeTimeout)
+	case strings.Contains(err.Error(), "timeout awaiting response headers"):
+		return errors.Mark(errors.Wrapf(err, "no response headers within %s", t.ResponseHeader),
+			ErrResponseHeaderTimeout)
+	default:
+		return err
+	}
+}
+
+// isTransportTimeout returns whether the error is marked with one of the
+// timeouts of the transport, or with the timeout of the probe.
+func isTransportTimeout(err error) bool {
+	return errors.Is(err, ErrDialTimeout) || errors.Is(err, ErrTLSHandshakeTimeout) ||
+		errors.Is(err, ErrResponseHeaderTimeout) || errors.Is(err, ErrProbeTimeout)
+}
+
+// withProbeTimeout returns a context canceled once the probe timeout
+// elapses, if any, and a function that releases it and marks the error of
+// the probe with ErrProbeTimeout if the timeout fired.
+func withProbeTimeout(ctx context.Context, t Timeouts) (context.Context, func(error) error) {
+	if t.Probe <= 0 {
+		return ctx, func(err error) error { return err }
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, t.Probe)
+	return probeCtx, func(err error) error {
+		defer cancel()
+		if err != nil && ctx.Err() == nil && errors.Is(probeCtx.Err(), context.DeadlineExceeded) {
+			return errors.Mark(errors.Wrapf(err, "probe not completed within %s", t.Probe), ErrProbeTimeout)
+		}
+		return err
+	}
+}
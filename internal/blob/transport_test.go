@@ -0,0 +1,154 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestTransportTimeouts(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	get := func(t *testing.T, transport *http.Transport, url string) error {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+		require.NoError(t, err)
+		resp, err := (&http.Client{Transport: transport}).Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	t.Run("dial", func(t *testing.T) {
+		block := func(ctx context.Context, _, _ string) (net.Conn, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		timeouts := Timeouts{Dial: timeout}
+		err := classifyTimeout(get(t, newTransport(timeouts, block, false), "http://storage:9000"), timeouts)
+		assert.True(t, errors.Is(err, ErrDialTimeout), "%v", err)
+		assert.ErrorContains(t, err, "within the dial timeout (50ms)")
+	})
+
+	t.Run("tls handshake", func(t *testing.T) {
+		// The listener accepts connections, but never completes a handshake.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}()
+		timeouts := Timeouts{TLSHandshake: timeout}
+		err = classifyTimeout(get(t, newTransport(timeouts, nil, false), "https://"+listener.Addr().String()), timeouts)
+		assert.True(t, errors.Is(err, ErrTLSHandshakeTimeout), "%v", err)
+	})
+
+	t.Run("response header", func(t *testing.T) {
+		done := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(done)
+		timeouts := Timeouts{ResponseHeader: timeout}
+		err := classifyTimeout(get(t, newTransport(timeouts, nil, false), server.URL), timeouts)
+		assert.True(t, errors.Is(err, ErrResponseHeaderTimeout), "%v", err)
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		err := errors.New("connection refused")
+		assert.Equal(t, err, classifyTimeout(err, Timeouts{}))
+		assert.False(t, isTransportTimeout(err))
+	})
+}
+
+// TestProbeRetries verifies that the requests of the probes are retried
+// after a throttling response, if enabled.
+func TestProbeRetries(t *testing.T) {
+	fake := &fakeS3{}
+	var mu sync.Mutex
+	throttled := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		throttle := throttled%2 == 0
+		throttled++
+		mu.Unlock()
+		// Every other request is throttled.
+		if throttle {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`))
+			return
+		}
+		fake.ServeHTTP(w, req)
+	})
+	for _, tt := range []struct {
+		retries Retries
+		wantErr string
+	}{
+		{retries: Retries{}, wantErr: "SlowDown"},
+		{retries: Retries{Max: 1, MaxBackoff: time.Millisecond}},
+	} {
+		s, alt := fakeS3Stores(t, handler, func(s *s3Store) { s.retries = tt.retries })
+		err := s.probe(t.Context(), alt, s.BucketName())
+		if tt.wantErr != "" {
+			assert.ErrorContains(t, err, tt.wantErr)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+// TestProbeTimeout verifies that a probe that does not complete in time is
+// reported as such, and not when the run is canceled.
+func TestProbeTimeout(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	s, alt := fakeS3Stores(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}), func(s *s3Store) { s.timeouts = Timeouts{Probe: 50 * time.Millisecond} })
+	err := s.probe(t.Context(), alt, s.BucketName())
+	assert.True(t, errors.Is(err, ErrProbeTimeout), "%v", err)
+	assert.ErrorContains(t, err, "probe not completed within 50ms")
+	assert.True(t, isTransportTimeout(err))
+	assert.Equal(t, CauseResponseTimeout, diagnose(s.params, err).Cause)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err = s.probe(ctx, alt, s.BucketName())
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrProbeTimeout), "%v", err)
+}
@@ -0,0 +1,291 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Values accepted by CockroachDB for the AUTH parameter.
+const (
+	AuthSpecified = "specified"
+	AuthImplicit  = "implicit"
+)
+
+const (
+	// accessPointResource is the resource type of S3 access point ARNs.
+	accessPointResource = "accesspoint/"
+	// accessPointHost replaces an access point ARN, which is not a valid
+	// host, while the rest of a URL is parsed or formatted.
+	accessPointHost = "access-point"
+)
+
+// S3URL is an s3:// URL in the form accepted by CockroachDB's cloud storage
+// layer: the host is the bucket, the path is the prefix of the objects, and
+// the query holds the parameters.
+type S3URL struct {
+	Bucket string
+	Path   string // prefix within the bucket, without the leading slash
+	Params Params
+}
+
+// ParseS3URL parses an s3:// URL the way CockroachDB does. It rejects URLs
+// that CockroachDB would interpret differently than written, such as
+// repeated parameters or user information, but it does not check the
+// parameters themselves; see Validate.
+func ParseS3URL(uri string) (S3URL, error) {
+	var accessPoint string
+	if rest, ok := strings.CutPrefix(uri, "s3://"); ok && arn.IsARN(rest) {
+		dest, query, hasQuery := strings.Cut(rest, "?")
+		var prefix string
+		accessPoint, prefix = splitDest(dest)
+		if _, err := accessPointRegion(accessPoint); err != nil {
+			return S3URL{}, err
+		}
+		uri = "s3://" + accessPointHost + "/" + prefix
+		if hasQuery {
+			uri += "?" + query
+		}
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return S3URL{}, errors.Wrap(err, "invalid URL")
+	}
+	if parsed.Scheme != "s3" {
+		return S3URL{}, errors.Newf("unsupported scheme: %q", parsed.Scheme)
+	}
+	if parsed.Opaque != "" || parsed.User != nil || parsed.Fragment != "" {
+		return S3URL{}, errors.Newf("invalid URL %q: expected s3://bucket/path?params", uri)
+	}
+	bucket := parsed.Host
+	if accessPoint != "" {
+		bucket = accessPoint
+	} else if !isBucketName(bucket) {
+		return S3URL{}, errors.Newf("invalid bucket name %q", bucket)
+	}
+	query, err := url.ParseQuery(parsed.RawQuery)
+	if err != nil {
+		return S3URL{}, errors.Wrap(err, "invalid URL parameters")
+	}
+	params := make(Params, len(query))
+	for k, v := range query {
+		if len(v) > 1 {
+			return S3URL{}, errors.Newf("parameter %q is repeated", k)
+		}
+		params[k] = v[0]
+	}
+	return S3URL{
+		Bucket: bucket,
+		Path:   strings.TrimPrefix(parsed.Path, "/"),
+		Params: params,
+	}, nil
+}
+
+// String returns the canonical form of the URL: the path is escaped and the
+// parameters are sorted, so that parsing the result yields the same URL.
+func (u S3URL) String() string {
+	if IsAccessPoint(u.Bucket) {
+		placeholder := S3URL{Bucket: accessPointHost, Path: u.Path, Params: u.Params}.String()
+		return "s3://" + u.Bucket + strings.TrimPrefix(placeholder, "s3://"+accessPointHost)
+	}
+	res := url.URL{
+		Scheme:   "s3",
+		Host:     u.Bucket,
+		RawQuery: u.Params.Encode(),
+	}
+	if u.Path != "" {
+		res.Path = "/" + u.Path
+	}
+	return res.String()
+}
+
+// Dest returns the bucket and the path joined, as used by the S3 store.
+func (u S3URL) Dest() string {
+	return path.Join(u.Bucket, u.Path)
+}
+
+// Validate checks the parameters against the rules CockroachDB applies when
+// opening the storage, so that a URL that passes is accepted verbatim by
+// BACKUP and CREATE EXTERNAL CONNECTION.
+func (u S3URL) Validate() error {
+	if IsAccessPoint(u.Bucket) {
+		if _, err := accessPointRegion(u.Bucket); err != nil {
+			return err
+		}
+	}
+	if IsDirectoryBucket(u.Bucket) {
+		if err := validateDirectoryBucket(u.Bucket, u.Params); err != nil {
+			return err
+		}
+	}
+	if err := u.Params.Validate(); err != nil {
+		return err
+	}
+	p := u.Params
+	switch auth := p[AuthParam]; auth {
+	case "", AuthSpecified:
+		for _, required := range []string{AccountParam, SecretParam} {
+			if p[required] == "" {
+				return errors.WithHintf(
+					errors.Newf("%s is not set", required),
+					"set %s=%s to use the credentials available on the nodes", AuthParam, AuthImplicit)
+			}
+		}
+	case AuthImplicit:
+	default:
+		return errors.Newf("unsupported value %q for %s: expected %s or %s",
+			auth, AuthParam, AuthSpecified, AuthImplicit)
+	}
+	if roles, ok := p[AssumeRoleParam]; ok {
+		for role := range strings.SplitSeq(roles, ",") {
+			if !strings.HasPrefix(role, "arn:") {
+				return errors.Newf("invalid %s %q: expected a comma separated list of role ARNs",
+					AssumeRoleParam, roles)
+			}
+		}
+	}
+	if class, ok := p[StorageClassParam]; ok {
+		if !slices.Contains(types.StorageClass("").Values(), types.StorageClass(class)) {
+			return errors.Newf("unsupported value %q for %s", class, StorageClassParam)
+		}
+	}
+	switch mode := p[ServerEncModeParam]; mode {
+	case "":
+	case string(types.ServerSideEncryptionAes256):
+	case string(types.ServerSideEncryptionAwsKms):
+		if p[ServerKMSIDParam] == "" {
+			return errors.Newf("%s is set to %q, but %s is not set",
+				ServerEncModeParam, mode, ServerKMSIDParam)
+		}
+	default:
+		return errors.Newf("unsupported value %q for %s: expected %s or %s", mode, ServerEncModeParam,
+			types.ServerSideEncryptionAes256, types.ServerSideEncryptionAwsKms)
+	}
+	return nil
+}
+
+// WithPathPrefix appends a path prefix to an endpoint, for gateways that
+// serve the S3 API under a path (e.g. https://gw.example.com/s3proxy). The
+// same endpoint is used by the SDK and by the cluster, so both send the
+// requests under the prefix.
+func WithPathPrefix(endpoint, prefix string) (string, error) {
+	u, err := (Params{EndPointParam: endpoint}).URL(EndPointParam)
+	if err != nil {
+		return "", err
+	}
+	if u == nil {
+		return "", errors.New("an endpoint path prefix requires an explicit endpoint")
+	}
+	u.Path = strings.TrimSuffix(path.Join("/", u.Path, prefix), "/")
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// IsAccessPoint reports whether the bucket is an S3 access point ARN, such
+// as arn:aws:s3:us-east-1:123456789012:accesspoint/my-ap.
+func IsAccessPoint(bucket string) bool {
+	return arn.IsARN(bucket)
+}
+
+// accessPointRegion checks that an ARN names an S3 access point and
+// returns its region.
+func accessPointRegion(bucket string) (string, error) {
+	parsed, err := arn.Parse(bucket)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid access point ARN %q", bucket)
+	}
+	name, ok := strings.CutPrefix(parsed.Resource, accessPointResource)
+	if parsed.Service != "s3" || !ok || name == "" || strings.Contains(name, "/") {
+		return "", errors.Newf(
+			"invalid access point ARN %q: expected arn:<partition>:s3:<region>:<account>:accesspoint/<name>",
+			bucket)
+	}
+	return parsed.Region, nil
+}
+
+// splitDest splits a destination into the bucket and the prefix of the
+// objects. An access point ARN contains a slash, so its prefix starts after
+// the name of the access point.
+func splitDest(dest string) (bucket, prefix string) {
+	if arn.IsARN(dest) {
+		if i := strings.Index(dest, ":"+accessPointResource); i >= 0 {
+			name := i + 1 + len(accessPointResource)
+			if j := strings.IndexByte(dest[name:], '/'); j >= 0 {
+				return dest[:name+j], dest[name+j+1:]
+			}
+			return dest, ""
+		}
+	}
+	bucket, prefix, _ = strings.Cut(dest, "/")
+	return bucket, prefix
+}
+
+// isBucketName reports whether the host of a URL is usable as a bucket
+// name. Besides the current naming rules, it allows the upper case letters
+// and underscores of legacy buckets, but never ports or IP literals.
+func isBucketName(host string) bool {
+	if host == "" {
+		return false
+	}
+	for _, r := range host {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ObfuscateURL returns a copy of a storage URL with the values of the
+// ObfuscatedParams replaced by Obfuscated, so that it can be printed.
+func ObfuscateURL(raw string) string {
+	return MapURLParams(raw, func(key, value string) string {
+		if slices.Contains(ObfuscatedParams, key) {
+			return Obfuscated
+		}
+		return value
+	})
+}
+
+// MapURLParams returns a copy of a storage URL with each parameter value
+// replaced by the result of fn. The parameters are sorted, and Obfuscated
+// is left unescaped so that the placeholders stand out. A URL that cannot
+// be parsed is dropped rather than returned with its secrets.
+func MapURLParams(raw string, fn func(key, value string) string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return ""
+	}
+	params := make(Params, len(query))
+	for k, v := range query {
+		params[k] = fn(k, v[0])
+	}
+	u.RawQuery = strings.ReplaceAll(params.Encode(), url.QueryEscape(Obfuscated), Obfuscated)
+	return u.String()
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseS3URL(t *testing.T) {
+	tests := []struct {
+		uri     string
+		want    S3URL
+		wantErr string
+	}{
+		{
+			uri:  "s3://bucket/path/to?AWS_REGION=us-east-1&AUTH=implicit",
+			want: S3URL{Bucket: "bucket", Path: "path/to", Params: Params{RegionParam: "us-east-1", AuthParam: AuthImplicit}},
+		},
+		{uri: "s3://bucket", want: S3URL{Bucket: "bucket", Params: Params{}}},
+		{uri: "gs://bucket/path", wantErr: "unsupported scheme"},
+		{uri: "s3:bucket/path", wantErr: "expected s3://bucket/path?params"},
+		{uri: "s3://key@bucket/path", wantErr: "expected s3://bucket/path?params"},
+		{uri: "s3://bucket:9000/path", wantErr: "invalid bucket name"},
+		{uri: "s3://bucket/path?AWS_REGION=a&AWS_REGION=b", wantErr: "is repeated"},
+		{
+			uri: "s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap/path/to?AWS_REGION=us-west-2",
+			want: S3URL{Bucket: "arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap", Path: "path/to",
+				Params: Params{RegionParam: "us-west-2"}},
+		},
+		{
+			uri:  "s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap",
+			want: S3URL{Bucket: "arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap", Params: Params{}},
+		},
+		{uri: "s3://arn:aws:iam::123456789012:role/admin/path", wantErr: "invalid access point ARN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, err := ParseS3URL(tt.uri)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestS3URLString(t *testing.T) {
+	u := S3URL{
+		Bucket: "bucket",
+		Path:   "a b/c",
+		Params: Params{AccountParam: "id", SecretParam: "a/b+c", AssumeRoleParam: "arn:aws:iam::123:role/x"},
+	}
+	assert.Equal(t,
+		"s3://bucket/a%20b/c?ASSUME_ROLE=arn%3Aaws%3Aiam%3A%3A123%3Arole%2Fx&"+
+			"AWS_ACCESS_KEY_ID=id&AWS_SECRET_ACCESS_KEY=a%2Fb%2Bc",
+		u.String())
+}
+
+func TestObfuscateURL(t *testing.T) {
+	assert.Equal(t,
+		"s3://bucket/a%20b?AWS_ACCESS_KEY_ID=id&AWS_SECRET_ACCESS_KEY=******&AWS_SESSION_TOKEN=******",
+		ObfuscateURL("s3://bucket/a%20b?AWS_SESSION_TOKEN=t&AWS_SECRET_ACCESS_KEY=a%2Fb&AWS_ACCESS_KEY_ID=id"))
+	assert.Equal(t, "nodelocal://1/backups", ObfuscateURL("nodelocal://1/backups"))
+	assert.Empty(t, ObfuscateURL("s3://bucket/?a=%zz"))
+}
+
+func TestAccessPointURL(t *testing.T) {
+	r := require.New(t)
+	u := S3URL{
+		Bucket: "arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap",
+		Path:   "a b/c",
+		Params: Params{RegionParam: "us-west-2"},
+	}
+	r.Equal("s3://arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap/a%20b/c?AWS_REGION=us-west-2", u.String())
+	parsed, err := ParseS3URL(u.String())
+	r.NoError(err)
+	r.Equal(u, parsed)
+	r.Equal("arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap/a b/c", parsed.Dest())
+}
+
+func TestSplitDest(t *testing.T) {
+	tests := []struct {
+		dest, bucket, prefix string
+	}{
+		{"bucket", "bucket", ""},
+		{"bucket/a/b", "bucket", "a/b"},
+		{"arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap", "arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap", ""},
+		{"arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap/a/b",
+			"arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap", "a/b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dest, func(t *testing.T) {
+			bucket, prefix := splitDest(tt.dest)
+			assert.Equal(t, tt.bucket, bucket)
+			assert.Equal(t, tt.prefix, prefix)
+		})
+	}
+}
+
+func TestS3URLValidate(t *testing.T) {
+	keys := Params{AccountParam: "id", SecretParam: "secret"}
+	tests := []struct {
+		name    string
+		params  Params
+		wantErr string
+	}{
+		{name: "keys", params: keys},
+		{name: "implicit", params: Params{AuthParam: AuthImplicit}},
+		{name: "missing keys", params: Params{}, wantErr: "AWS_ACCESS_KEY_ID is not set"},
+		{name: "specified without secret", params: Params{AuthParam: AuthSpecified, AccountParam: "id"},
+			wantErr: "AWS_SECRET_ACCESS_KEY is not set"},
+		{name: "bad auth", params: Params{AuthParam: "Implicit"}, wantErr: `unsupported value "Implicit" for AUTH`},
+		{name: "role chain", params: keys.Merge(Params{AssumeRoleParam: "arn:aws:iam::1:role/a,arn:aws:iam::2:role/b"})},
+		{name: "bad role", params: keys.Merge(Params{AssumeRoleParam: "role/a"}), wantErr: "invalid ASSUME_ROLE"},
+		{name: "storage class", params: keys.Merge(Params{StorageClassParam: "INTELLIGENT_TIERING"})},
+		{name: "bad storage class", params: keys.Merge(Params{StorageClassParam: "standard"}),
+			wantErr: `unsupported value "standard" for S3_STORAGE_CLASS`},
+		{name: "kms", params: keys.Merge(Params{ServerEncModeParam: "aws:kms", ServerKMSIDParam: "key"})},
+		{name: "kms without key", params: keys.Merge(Params{ServerEncModeParam: "aws:kms"}),
+			wantErr: "AWS_SERVER_KMS_ID is not set"},
+		{name: "bad encryption", params: keys.Merge(Params{ServerEncModeParam: "kms"}),
+			wantErr: `unsupported value "kms" for AWS_SERVER_ENC_MODE`},
+		{name: "unknown", params: keys.Merge(Params{"AWS_REGON": "x"}), wantErr: `unknown parameter "AWS_REGON"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := S3URL{Bucket: "bucket", Params: tt.params}.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWithPathPrefix(t *testing.T) {
+	tests := []struct {
+		endpoint, prefix string
+		want             string
+		wantErr          string
+	}{
+		{endpoint: "https://gw.example.com", prefix: "s3proxy", want: "https://gw.example.com/s3proxy"},
+		{endpoint: "https://gw.example.com/", prefix: "/s3proxy/", want: "https://gw.example.com/s3proxy"},
+		{endpoint: "https://gw.example.com:8443/api", prefix: "s3", want: "https://gw.example.com:8443/api/s3"},
+		{endpoint: "https://gw.example.com", prefix: "/", want: "https://gw.example.com"},
+		{endpoint: "", prefix: "s3proxy", wantErr: "requires an explicit endpoint"},
+		{endpoint: "gw.example.com", prefix: "s3proxy", wantErr: "expected http(s)://host[:port][/prefix]"},
+		{endpoint: "https://gw.example.com?x=1", prefix: "s3proxy", wantErr: "expected http(s)://host[:port][/prefix]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint+"+"+tt.prefix, func(t *testing.T) {
+			got, err := WithPathPrefix(tt.endpoint, tt.prefix)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// FuzzS3URL checks that any URL accepted by ParseS3URL is printed in a form
+// that parses back to the same URL, so the suggested URL is used verbatim.
+func FuzzS3URL(f *testing.F) {
+	for _, seed := range []string{
+		"s3://bucket/path?AWS_ACCESS_KEY_ID=id&AWS_SECRET_ACCESS_KEY=a%2Fb%2Bc",
+		"s3://bucket/a%20b/?AUTH=implicit&ASSUME_ROLE=arn:aws:iam::1:role/a,arn:aws:iam::2:role/b",
+		"s3://bucket?S3_STORAGE_CLASS=STANDARD_IA&AWS_ENDPOINT=http://minio:9000",
+		"s3://Legacy_Bucket//double//slash?AWS_REGION=&x",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, uri string) {
+		u, err := ParseS3URL(uri)
+		if err != nil {
+			return
+		}
+		printed := u.String()
+		again, err := ParseS3URL(printed)
+		require.NoError(t, err, "printed %q", printed)
+		require.Equal(t, u, again, "printed %q", printed)
+		require.Equal(t, printed, again.String())
+		require.Equal(t, u.Validate() == nil, again.Validate() == nil)
+	})
+}
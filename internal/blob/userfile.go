@@ -0,0 +1,233 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/tunnel"
+)
+
+const (
+	// defaultUserFilePrefix is the table prefix of userfile:///path URLs,
+	// followed by the name of the user.
+	defaultUserFilePrefix = "defaultdb.public.userfiles_"
+	// undefinedTable is the SQLSTATE returned when the user files tables
+	// have not been created yet.
+	undefinedTable = "42P01"
+)
+
+// The tables storing the user files, as created by CockroachDB on the first
+// upload: files are split in chunks, ordered by their offset.
+const (
+	userFilesSchema = `CREATE TABLE IF NOT EXISTS %s (
+filename STRING PRIMARY KEY,
+file_id UUID UNIQUE NOT NULL DEFAULT gen_random_uuid(),
+file_size INT NOT NULL,
+username STRING NOT NULL,
+upload_time TIMESTAMP DEFAULT now())`
+	userPayloadSchema = `CREATE TABLE IF NOT EXISTS %s (
+file_id UUID,
+byte_offset INT,
+payload BYTES,
+PRIMARY KEY (file_id, byte_offset))`
+)
+
+// tableFiles stores the objects in the tables of the cluster used by
+// userfile:// destinations. The file names are the paths of the URLs.
+type tableFiles struct {
+	pool    *pgxpool.Pool
+	user    string
+	files   string // sanitized name of the table of the files
+	payload string // sanitized name of the table of the chunks
+}
+
+var _ files = &tableFiles{}
+
+// newUserFileStore parses the userfile:// URI of the environment, and
+// connects to the cluster storing the files.
+func newUserFileStore(ctx *stopper.Context, env *env.Env) (*localStore, error) {
+	host, dest, err := parseLocalURL(env.URI, UserFileScheme)
+	if err != nil {
+		return nil, err
+	}
+	if env.DatabaseURL == "" {
+		return nil, errors.New("userfile destinations require the database URL")
+	}
+	config, err := pgxpool.ParseConfig(env.DatabaseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse database URL")
+	}
+	if env.Dial != nil {
+		config.ConnConfig.DialFunc = pgconn.DialFunc(env.Dial)
+		config.ConnConfig.LookupFunc = tunnel.LookupHost
+	}
+	if env.Tenant != "" {
+		options := config.ConnConfig.RuntimeParams["options"]
+		config.ConnConfig.RuntimeParams["options"] = strings.TrimSpace(options + " -ccluster=" + env.Tenant)
+	}
+	prefix := host
+	if prefix == "" {
+		prefix = defaultUserFilePrefix + config.ConnConfig.User
+	}
+	parts := strings.Split(prefix, ".")
+	if len(parts) != 3 || slices.Contains(parts, "") {
+		return nil, errors.Newf("invalid URL %q: expected userfile://database.schema.prefix/path", env.URI)
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create database pool")
+	}
+	return &localStore{
+		files: &tableFiles{
+			pool:    pool,
+			user:    config.ConnConfig.User,
+			files:   pgx.Identifier{parts[0], parts[1], parts[2] + "_upload_files"}.Sanitize(),
+			payload: pgx.Identifier{parts[0], parts[1], parts[2] + "_upload_payload"}.Sanitize(),
+		},
+		scheme:  UserFileScheme,
+		host:    host,
+		root:    dest,
+		objects: ProbeObjectFromEnv(env),
+	}, nil
+}
+
+// put implements files. The tables are created if needed.
+func (t *tableFiles) put(ctx context.Context, name, body string) error {
+	for _, schema := range []string{fmt.Sprintf(userFilesSchema, t.files), fmt.Sprintf(userPayloadSchema, t.payload)} {
+		if _, err := t.pool.Exec(ctx, schema); err != nil {
+			return errors.Wrap(err, "failed to create the user files tables")
+		}
+	}
+	err := pgx.BeginFunc(ctx, t.pool, func(tx pgx.Tx) error {
+		if err := t.delete(ctx, tx, name); err != nil {
+			return err
+		}
+		var id pgtype.UUID
+		if err := tx.QueryRow(ctx,
+			fmt.Sprintf(`INSERT INTO %s (filename, file_size, username) VALUES ($1, $2, $3) RETURNING file_id`, t.files),
+			"/"+name, len(body), t.user,
+		).Scan(&id); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx,
+			fmt.Sprintf(`INSERT INTO %s (file_id, byte_offset, payload) VALUES ($1, 0, $2)`, t.payload),
+			id, []byte(body))
+		return err
+	})
+	return errors.Wrapf(err, "failed to put %q", name)
+}
+
+// read implements files.
+func (t *tableFiles) read(ctx context.Context, name string) ([]byte, error) {
+	var id pgtype.UUID
+	err := t.pool.QueryRow(ctx,
+		fmt.Sprintf(`SELECT file_id FROM %s WHERE filename = $1`, t.files), "/"+name).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) || isUndefinedTable(err) {
+		return nil, errors.Newf("failed to get %q: not found", name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %q", name)
+	}
+	rows, err := t.pool.Query(ctx,
+		fmt.Sprintf(`SELECT payload FROM %s WHERE file_id = $1 ORDER BY byte_offset`, t.payload), id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %q", name)
+	}
+	var data []byte
+	for rows.Next() {
+		var chunk []byte
+		if err := rows.Scan(&chunk); err != nil {
+			rows.Close()
+			return nil, errors.Wrapf(err, "failed to read %q", name)
+		}
+		data = append(data, chunk...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", name)
+	}
+	return data, nil
+}
+
+// remove implements files.
+func (t *tableFiles) remove(ctx context.Context, name string) error {
+	err := pgx.BeginFunc(ctx, t.pool, func(tx pgx.Tx) error {
+		return t.delete(ctx, tx, name)
+	})
+	return errors.Wrapf(err, "failed to delete %q", name)
+}
+
+// delete removes a file and its chunks, if it exists. The chunks are
+// removed first, since they may reference the file.
+func (t *tableFiles) delete(ctx context.Context, tx pgx.Tx, name string) error {
+	if _, err := tx.Exec(ctx, fmt.Sprintf(
+		`DELETE FROM %s WHERE file_id IN (SELECT file_id FROM %s WHERE filename = $1)`, t.payload, t.files),
+		"/"+name); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE filename = $1`, t.files), "/"+name)
+	return err
+}
+
+// list implements files.
+func (t *tableFiles) list(ctx context.Context, prefix string) ([]Object, error) {
+	rows, err := t.pool.Query(ctx, fmt.Sprintf(
+		`SELECT filename, file_size, upload_time FROM %s WHERE filename >= $1 ORDER BY filename`, t.files),
+		"/"+prefix)
+	if isUndefinedTable(err) {
+		// Nothing was uploaded yet.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+	defer rows.Close()
+	var objects []Object
+	for rows.Next() {
+		var obj Object
+		var name string
+		if err := rows.Scan(&name, &obj.Size, &obj.LastModified); err != nil {
+			return nil, errors.Wrap(err, "failed to list objects")
+		}
+		key, ok := strings.CutPrefix(name, "/"+prefix)
+		if !ok {
+			// Past the last file with the prefix.
+			break
+		}
+		obj.Key = key
+		objects = append(objects, obj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+	return objects, nil
+}
+
+// isUndefinedTable returns whether the error reports a missing table.
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == undefinedTable
+}
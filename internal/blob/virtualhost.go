@@ -0,0 +1,187 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// VirtualHost is the outcome of probing virtual-hosted requests, which
+// address the bucket as a sub-domain of the endpoint, against a domain
+// provided by the user, such as s3.corp.example.com. Path style requests
+// are not affected by the DNS records and the certificate of the bucket
+// host name, but virtual-hosted ones are.
+type VirtualHost struct {
+	Domain    string   // domain serving the buckets as sub-domains
+	Host      string   // host name of the bucket, host[:port]
+	Addresses []string // addresses the host name resolves to, unless resolved by the proxy or tunnel
+	DNSErr    string   // why the host name did not resolve, if it did not
+	TLS       bool     // whether the endpoint is reached over TLS
+	SANs      []string // names of the certificate of the host
+	Wildcard  bool     // whether the certificate covers the host name through a wildcard name
+	CustomCA  bool     // whether the certificate is verified with the CA bundle only
+	TLSErr    string   // why the certificate was not obtained or not verified, if it was not
+	Latency   *Latency // timings of the probe, if it succeeded
+	Err       string   // why the probe failed, if it did
+}
+
+// Works reports whether the DNS records and the certificate of the domain
+// support virtual-hosted requests.
+func (v *VirtualHost) Works() bool {
+	return v.DNSErr == "" && v.TLSErr == "" && v.Err == ""
+}
+
+// ProbeVirtualHost implements VirtualHostProber. It resolves the host name
+// of the bucket under the domain, verifies its certificate, and probes the
+// suggested configuration with virtual-hosted requests to the domain. The
+// scheme and the port are those of the endpoint, unless the domain has a
+// port.
+func (s *s3Store) ProbeVirtualHost(ctx context.Context, domain string) (*VirtualHost, error) {
+	if strings.Contains(domain, "/") {
+		return nil, errors.Newf("invalid virtual-hosted domain %q: expected host[:port]", domain)
+	}
+	endpoint, err := s.params.URL(EndPointParam)
+	if err != nil {
+		return nil, err
+	}
+	scheme, port := "https", ""
+	if endpoint != nil {
+		scheme, port = endpoint.Scheme, endpoint.Port()
+	}
+	hostname := domain
+	if h, p, err := net.SplitHostPort(domain); err == nil {
+		hostname, port = h, p
+	}
+	if port == "" {
+		port = "443"
+		if scheme == "http" {
+			port = "80"
+		}
+	}
+	bucket := s.BucketName()
+	res := &VirtualHost{
+		Domain: domain,
+		Host:   net.JoinHostPort(bucket+"."+hostname, port),
+		TLS:    scheme == "https",
+	}
+	if s.dial == nil {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, bucket+"."+hostname)
+		if err != nil {
+			res.DNSErr = err.Error()
+			return res, nil
+		}
+		res.Addresses = addrs
+	}
+	if res.TLS {
+		s.verifyVirtualHost(ctx, res)
+	}
+	alt := &s3Store{
+		dest: s.dest,
+		root: s.root,
+		params: s.params.Merge(Params{
+			EndPointParam:     (&url.URL{Scheme: scheme, Host: net.JoinHostPort(hostname, port)}).String(),
+			UsePathStyleParam: "",
+		}),
+		dial:        s.dial,
+		customCA:    res.CustomCA,
+		bypassProxy: s.bypassProxy,
+	}
+	if err := s.probe(ctx, alt, bucket); err != nil {
+		slog.Warn("virtual-hosted requests fail", slog.String("host", res.Host), slog.Any("error", err))
+		res.Err = err.Error()
+	} else {
+		res.Latency = alt.latency
+	}
+	return res, nil
+}
+
+// verifyVirtualHost reads the certificate of the bucket host name, and
+// verifies it with the system roots, then with the CA bundle, if any.
+func (s *s3Store) verifyVirtualHost(ctx context.Context, res *VirtualHost) {
+	conn, err := s.dialStorage(ctx, res.Host)
+	if err != nil {
+		res.TLSErr = err.Error()
+		return
+	}
+	defer conn.Close()
+	name, _, _ := net.SplitHostPort(res.Host)
+	// The chain is verified below, once the names are recorded.
+	client := tls.Client(conn, &tls.Config{ServerName: name, InsecureSkipVerify: true})
+	if err := client.HandshakeContext(ctx); err != nil {
+		res.TLSErr = err.Error()
+		return
+	}
+	chain := client.ConnectionState().PeerCertificates
+	leaf := chain[0]
+	res.SANs = leaf.DNSNames
+	res.Wildcard = coveredByWildcard(leaf, name)
+	opts := x509.VerifyOptions{DNSName: name, Intermediates: x509.NewCertPool()}
+	for _, cert := range chain[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err = leaf.Verify(opts)
+	if err != nil && s.caFile != "" {
+		opts.Roots = loadCA(s.caFile)
+		if _, caErr := leaf.Verify(opts); caErr == nil {
+			res.CustomCA, err = true, nil
+		}
+	}
+	if err != nil {
+		res.TLSErr = err.Error()
+	}
+}
+
+// dialStorage connects to the address like the clients of the storage, with
+// the dialer of the proxy or tunnel, if any, within the dial timeout.
+func (s *s3Store) dialStorage(ctx context.Context, addr string) (net.Conn, error) {
+	if s.timeouts.Dial > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeouts.Dial)
+		defer cancel()
+	}
+	if s.dial != nil {
+		return s.dial(ctx, "tcp", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// coveredByWildcard returns whether the certificate covers the host name
+// through a wildcard name only: a certificate for *.s3.example.com covers
+// every bucket served under s3.example.com.
+func coveredByWildcard(cert *x509.Certificate, host string) bool {
+	if cert.VerifyHostname(host) != nil {
+		return false
+	}
+	_, parent, _ := strings.Cut(host, ".")
+	covered := false
+	for _, name := range cert.DNSNames {
+		switch {
+		case strings.EqualFold(name, host):
+			return false
+		case strings.EqualFold(name, "*."+parent):
+			covered = true
+		}
+	}
+	return covered
+}
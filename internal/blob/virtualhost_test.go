@@ -0,0 +1,88 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeVirtualHost(t *testing.T) {
+	fake := &fakeS3{virtualHosted: true}
+	s, _ := fakeS3TLSStores(t, fake)
+	endpoint, err := url.Parse(s.params[EndPointParam])
+	require.NoError(t, err)
+	// Every host name is served by the test server, whose certificate is
+	// valid for example.com and *.example.com.
+	redirect := func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, endpoint.Host)
+	}
+	store := func(dial func(context.Context, string, string) (net.Conn, error)) *s3Store {
+		s.dial = dial
+		return s
+	}
+
+	t.Run("wildcard", func(t *testing.T) {
+		r := require.New(t)
+		res, err := store(redirect).ProbeVirtualHost(context.Background(), "example.com")
+		r.NoError(err)
+		r.Equal("bucket.example.com:"+endpoint.Port(), res.Host)
+		r.Empty(res.Addresses, "the proxy resolves the host names")
+		r.True(res.TLS)
+		r.True(res.Wildcard)
+		r.True(res.CustomCA)
+		r.Empty(res.TLSErr)
+		r.Empty(res.Err)
+		r.True(res.Works())
+		r.NotEmpty(fake.written, "the probe object is written through the bucket host")
+	})
+	t.Run("not covered", func(t *testing.T) {
+		r := require.New(t)
+		res, err := store(redirect).ProbeVirtualHost(context.Background(), "corp.test")
+		r.NoError(err)
+		r.Contains(res.TLSErr, "bucket.corp.test")
+		r.False(res.Wildcard)
+		r.NotEmpty(res.Err)
+		r.False(res.Works())
+	})
+	t.Run("unresolved", func(t *testing.T) {
+		r := require.New(t)
+		// The .invalid top level domain never resolves.
+		res, err := store(nil).ProbeVirtualHost(context.Background(), "blobcheck.invalid")
+		r.NoError(err)
+		r.NotEmpty(res.DNSErr)
+		r.False(res.Works())
+	})
+	t.Run("invalid domain", func(t *testing.T) {
+		_, err := store(nil).ProbeVirtualHost(context.Background(), "https://example.com")
+		require.ErrorContains(t, err, "expected host[:port]")
+	})
+}
+
+func TestCoveredByWildcard(t *testing.T) {
+	r := require.New(t)
+	server := httptest.NewTLSServer(&fakeS3{})
+	defer server.Close()
+	cert := server.Certificate()
+	r.True(coveredByWildcard(cert, "bucket.example.com"))
+	r.False(coveredByWildcard(cert, "example.com"), "covered by its own name")
+	r.False(coveredByWildcard(cert, "bucket.corp.test"))
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaos provides a reverse proxy that degrades the link between the
+// cluster and the storage provider, so that backups can be validated under
+// adverse network conditions.
+package chaos
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// slowDown is the body of the error returned for injected failures. S3
+// clients treat it as a throttling error and retry the request.
+const slowDown = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`
+
+// Faults describes the degradation injected by the proxy.
+type Faults struct {
+	Latency   time.Duration // delay added to every request
+	Bandwidth float64       // transfer rate cap of each request, in bytes per second (0 for no cap)
+	ErrorRate float64       // fraction of requests failed with a SlowDown error
+}
+
+// Stats counts the requests handled by the proxy.
+type Stats struct {
+	Requests int64 // requests received
+	Injected int64 // requests failed by the proxy
+	Bytes    int64 // bytes transferred in either direction
+}
+
+// Proxy forwards requests to the storage provider, injecting faults.
+type Proxy struct {
+	faults   Faults
+	listener net.Listener
+	server   *http.Server
+	proxy    *httputil.ReverseProxy
+	random   func() float64
+	requests atomic.Int64
+	injected atomic.Int64
+	bytes    atomic.Int64
+}
+
+// Start listens on the given address and forwards requests to the target
+// endpoint. The Host header of the requests is preserved, so that the
+// signatures computed by the clients for the proxy address remain valid; the
+// storage provider must accept path style requests. The proxy stops when the
+// context is stopped. If dial is not nil, it is used to connect to the
+// target.
+func Start(
+	ctx *stopper.Context, target, listen string, faults Faults, dial env.DialFunc,
+) (*Proxy, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil || targetURL.Host == "" {
+		return nil, errors.Newf("invalid endpoint %q", target)
+	}
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start the fault injection proxy")
+	}
+	p := &Proxy{
+		faults:   faults,
+		listener: listener,
+		random:   rand.Float64,
+	}
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(targetURL)
+			r.Out.Host = r.In.Host
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			resp.Body = p.throttle(resp.Body)
+			return nil
+		},
+	}
+	if dial != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = dial
+		p.proxy.Transport = transport
+	}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: time.Minute}
+	ctx.Go(func(ctx *stopper.Context) error {
+		if err := p.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("fault injection proxy failed", slog.Any("error", err))
+		}
+		return nil
+	})
+	ctx.Go(func(ctx *stopper.Context) error {
+		<-ctx.Stopping()
+		return p.Close()
+	})
+	slog.Info("fault injection proxy started", slog.String("addr", p.Addr()), slog.String("target", target),
+		slog.Duration("latency", faults.Latency), slog.Float64("bandwidth", faults.Bandwidth),
+		slog.Float64("error_rate", faults.ErrorRate))
+	return p, nil
+}
+
+// Addr returns the address the proxy listens on.
+func (p *Proxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+// Stats returns the number of requests handled so far.
+func (p *Proxy) Stats() Stats {
+	return Stats{
+		Requests: p.requests.Load(),
+		Injected: p.injected.Load(),
+		Bytes:    p.bytes.Load(),
+	}
+}
+
+// Close stops the proxy.
+func (p *Proxy) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.server.Shutdown(ctx)
+}
+
+// ServeHTTP implements http.Handler.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.requests.Add(1)
+	if p.faults.Latency > 0 {
+		select {
+		case <-time.After(p.faults.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if p.faults.ErrorRate > 0 && p.random() < p.faults.ErrorRate {
+		p.injected.Add(1)
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, slowDown)
+		return
+	}
+	if r.Body != nil {
+		r.Body = p.throttle(r.Body)
+	}
+	p.proxy.ServeHTTP(w, r)
+}
+
+// throttle wraps a body, counting the bytes transferred and capping the
+// transfer rate.
+func (p *Proxy) throttle(body io.ReadCloser) io.ReadCloser {
+	return &throttledReader{ReadCloser: body, proxy: p, start: time.Now()}
+}
+
+// throttledReader delays reads so that the average transfer rate does not
+// exceed the bandwidth of the proxy.
+type throttledReader struct {
+	io.ReadCloser
+	proxy *Proxy
+	start time.Time
+	read  int64
+}
+
+// Read implements io.Reader.
+func (t *throttledReader) Read(b []byte) (int, error) {
+	n, err := t.ReadCloser.Read(b)
+	t.read += int64(n)
+	t.proxy.bytes.Add(int64(n))
+	if rate := t.proxy.faults.Bandwidth; rate > 0 && n > 0 {
+		time.Sleep(delay(t.read, rate, time.Since(t.start)))
+	}
+	return n, err
+}
+
+// delay returns how long to wait so that transferring the given number of
+// bytes at the given rate takes at least the expected time.
+func delay(bytes int64, rate float64, elapsed time.Duration) time.Duration {
+	expected := time.Duration(float64(bytes) / rate * float64(time.Second))
+	return max(expected-elapsed, 0)
+}
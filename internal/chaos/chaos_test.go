@@ -0,0 +1,69 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+func TestProxy(t *testing.T) {
+	var host string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		_, _ = io.WriteString(w, "payload")
+	}))
+	defer upstream.Close()
+
+	ctx := stopper.WithContext(context.Background())
+	defer ctx.Stop(time.Second)
+	p, err := Start(ctx, upstream.URL, "127.0.0.1:0", Faults{Latency: 50 * time.Millisecond}, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	resp, err := http.Get("http://" + p.Addr() + "/bucket/key")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	a := assert.New(t)
+	a.Equal("payload", string(body))
+	a.Equal(p.Addr(), host, "the Host header must be preserved")
+	a.GreaterOrEqual(time.Since(start), 50*time.Millisecond)
+
+	p.faults.ErrorRate = 1
+	resp, err = http.Get("http://" + p.Addr() + "/bucket/key")
+	require.NoError(t, err)
+	resp.Body.Close()
+	a.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	a.Equal(Stats{Requests: 2, Injected: 1, Bytes: int64(len("payload"))}, p.Stats())
+}
+
+func TestDelay(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(time.Second, delay(1000, 1000, 0))
+	a.Equal(500*time.Millisecond, delay(1000, 1000, 500*time.Millisecond))
+	a.Equal(time.Duration(0), delay(1000, 1000, 2*time.Second))
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+const defaultUser = "root"
+
+// WithCertsDir configures the connection URL to authenticate with the
+// certificates found in a CockroachDB certs directory: ca.crt,
+// client.<user>.crt and client.<user>.key.
+func WithCertsDir(dbURL, certsDir string) (string, error) {
+	u, err := url.Parse(dbURL)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse database URL")
+	}
+	user := u.User.Username()
+	if user == "" {
+		user = defaultUser
+	}
+	q := u.Query()
+	if mode := q.Get("sslmode"); mode != "verify-ca" {
+		q.Set("sslmode", "verify-full")
+	}
+	q.Set("sslrootcert", filepath.Join(certsDir, "ca.crt"))
+	q.Set("sslcert", filepath.Join(certsDir, "client."+user+".crt"))
+	q.Set("sslkey", filepath.Join(certsDir, "client."+user+".key"))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// CheckCredentials validates the credentials referenced by the connection
+// URL before connecting, so that problems are reported with a clear message
+// rather than as a TLS handshake failure: the CA certificate must be
+// readable, and the client certificate must match its key, be valid now, and
+// be issued to the connecting user. GSSAPI authentication is rejected, since
+// this build does not register a GSSAPI provider with the driver.
+func CheckCredentials(dbURL string) error {
+	u, err := url.Parse(dbURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse database URL")
+	}
+	q := u.Query()
+	if q.Has("krbsrvname") || q.Has("krbspn") {
+		return errors.WithHint(errors.New("GSSAPI authentication is not supported"),
+			"use password or certificate authentication for the validation user")
+	}
+	if ca := q.Get("sslrootcert"); ca != "" {
+		pemData, err := os.ReadFile(ca)
+		if err != nil {
+			return errors.Wrap(err, "failed to read CA certificate")
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pemData) {
+			return errors.Newf("no certificates found in %s", ca)
+		}
+	}
+	certFile, keyFile := q.Get("sslcert"), q.Get("sslkey")
+	if certFile == "" && keyFile == "" {
+		return nil
+	}
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return errors.Wrap(err, "invalid client certificate")
+	}
+	user := u.User.Username()
+	if user == "" {
+		user = defaultUser
+	}
+	return checkClientCert(pair.Certificate[0], user, time.Now())
+}
+
+// checkClientCert verifies that the DER encoded certificate is valid at the
+// given time and issued to the user.
+func checkClientCert(der []byte, user string, now time.Time) error {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return errors.Wrap(err, "invalid client certificate")
+	}
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return errors.Newf("client certificate is not valid at %s (valid from %s to %s)",
+			now.Format(time.RFC3339), cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339))
+	}
+	if cert.Subject.CommonName != user {
+		return errors.WithHint(
+			errors.Newf("client certificate is issued to %q, not to %q", cert.Subject.CommonName, user),
+			"create the certificate with: cockroach cert create-client "+user)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCerts writes a self-signed client certificate for the user, and uses
+// it as the CA certificate as well.
+func writeCerts(t *testing.T, dir, user string, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: user},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), certPEM, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "client."+user+".crt"), certPEM, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "client."+user+".key"), keyPEM, 0600))
+}
+
+func TestWithCertsDir(t *testing.T) {
+	a := require.New(t)
+	got, err := WithCertsDir("postgresql://blobcheck@localhost:26257/defaultdb", "/certs")
+	a.NoError(err)
+	u, err := url.Parse(got)
+	a.NoError(err)
+	q := u.Query()
+	a.Equal("verify-full", q.Get("sslmode"))
+	a.Equal("/certs/ca.crt", q.Get("sslrootcert"))
+	a.Equal("/certs/client.blobcheck.crt", q.Get("sslcert"))
+	a.Equal("/certs/client.blobcheck.key", q.Get("sslkey"))
+
+	// The user defaults to root.
+	got, err = WithCertsDir("postgresql://localhost:26257?sslmode=verify-ca", "/certs")
+	a.NoError(err)
+	u, err = url.Parse(got)
+	a.NoError(err)
+	a.Equal("verify-ca", u.Query().Get("sslmode"))
+	a.Equal("/certs/client.root.crt", u.Query().Get("sslcert"))
+}
+
+func TestCheckCredentials(t *testing.T) {
+	a := assert.New(t)
+	valid := t.TempDir()
+	writeCerts(t, valid, "blobcheck", time.Now().Add(time.Hour))
+	expired := t.TempDir()
+	writeCerts(t, expired, "blobcheck", time.Now().Add(-time.Minute))
+
+	dbURL, err := WithCertsDir("postgresql://blobcheck@localhost:26257", valid)
+	require.NoError(t, err)
+	a.NoError(CheckCredentials(dbURL))
+
+	// Certificate issued to a different user.
+	other, err := WithCertsDir("postgresql://blobcheck@localhost:26257", valid)
+	require.NoError(t, err)
+	u, err := url.Parse(other)
+	require.NoError(t, err)
+	u.User = url.User("admin")
+	a.ErrorContains(CheckCredentials(u.String()), `issued to "blobcheck", not to "admin"`)
+
+	dbURL, err = WithCertsDir("postgresql://blobcheck@localhost:26257", expired)
+	require.NoError(t, err)
+	a.ErrorContains(CheckCredentials(dbURL), "not valid")
+
+	dbURL, err = WithCertsDir("postgresql://blobcheck@localhost:26257", t.TempDir())
+	require.NoError(t, err)
+	a.Error(CheckCredentials(dbURL))
+
+	a.NoError(CheckCredentials("postgresql://root@localhost:26257?sslmode=disable"))
+	a.ErrorContains(CheckCredentials("postgresql://root@localhost:26257?krbsrvname=postgres"), "GSSAPI")
+	a.ErrorContains(CheckCredentials("postgresql://root@localhost:26257?krbspn=postgres/db.example.com"), "GSSAPI")
+}
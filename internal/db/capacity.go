@@ -0,0 +1,53 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+// StoreCapacity describes the disk capacity of a store in the cluster.
+type StoreCapacity struct {
+	Node      int
+	Store     int
+	Capacity  int64
+	Available int64
+}
+
+// FreeFraction returns the fraction of the store capacity that is available.
+func (s StoreCapacity) FreeFraction() float64 {
+	if s.Capacity <= 0 {
+		return 0
+	}
+	return float64(s.Available) / float64(s.Capacity)
+}
+
+const storeCapacityStmt = `
+SELECT node_id, store_id, capacity, available
+FROM crdb_internal.kv_store_status
+ORDER BY node_id, store_id`
+
+// StoresCapacity returns the capacity of every store in the cluster.
+func StoresCapacity(ctx *stopper.Context, conn *pgxpool.Conn) ([]StoreCapacity, error) {
+	rows, err := conn.Query(ctx, storeCapacityStmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[StoreCapacity])
+}
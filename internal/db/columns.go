@@ -0,0 +1,114 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/cockroachdb/errors"
+)
+
+// row is a result row keyed by column name, so that the output of SHOW and
+// CHECK statements can be read regardless of the column order, which
+// changes between CockroachDB releases.
+type row map[string]any
+
+// collectRows reads all the rows of a result keyed by column name.
+func collectRows(rows pgx.Rows) ([]row, error) {
+	maps, err := pgx.CollectRows(rows, pgx.RowToMap)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]row, len(maps))
+	for i, m := range maps {
+		res[i] = m
+	}
+	return res, nil
+}
+
+// has reports whether the row has the column.
+func (r row) has(col string) bool {
+	_, ok := r[col]
+	return ok
+}
+
+// int returns the value of an integer column, or 0 if it is missing or NULL.
+func (r row) int(col string) (int64, error) {
+	switch v := r[col].(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	default:
+		return 0, errors.Newf("column %s: expected an integer, got %T", col, v)
+	}
+}
+
+// string returns the value of a string column, or "" if it is missing or
+// NULL.
+func (r row) string(col string) (string, error) {
+	switch v := r[col].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", errors.Newf("column %s: expected a string, got %T", col, v)
+	}
+}
+
+// bool returns the value of a boolean column, or false if it is missing or
+// NULL.
+func (r row) bool(col string) (bool, error) {
+	switch v := r[col].(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	default:
+		return false, errors.Newf("column %s: expected a boolean, got %T", col, v)
+	}
+}
+
+// time returns the value of a timestamp column, or the zero time if it is
+// missing or NULL.
+func (r row) time(col string) (time.Time, error) {
+	switch v := r[col].(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	default:
+		return time.Time{}, errors.Newf("column %s: expected a timestamp, got %T", col, v)
+	}
+}
+
+// require returns an error naming the first column missing from the row.
+func (r row) require(cols ...string) error {
+	for _, col := range cols {
+		if !r.has(col) {
+			return errors.Newf("missing column %s", col)
+		}
+	}
+	return nil
+}
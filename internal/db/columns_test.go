@@ -0,0 +1,141 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture builds rows from the column names and values returned by a
+// statement, as collectRows would.
+func fixture(columns []string, values ...[]any) []row {
+	res := make([]row, len(values))
+	for i, v := range values {
+		res[i] = make(row, len(columns))
+		for j, col := range columns {
+			res[i][col] = v[j]
+		}
+	}
+	return res
+}
+
+func TestStatsFromRow(t *testing.T) {
+	want := &Stats{
+		Node: 2, Locality: "region=us-east1", Success: true, Transferred: "5.0 MiB",
+		ReadSpeed: "120 MiB/s", WriteSpeed: "40 MiB/s", CanDelete: true,
+	}
+	tests := []struct {
+		name string
+		rows []row
+		want *Stats
+	}{
+		{
+			name: "v25.1",
+			rows: fixture(
+				[]string{"node", "locality", "ok", "error", "transferred", "read_speed", "write_speed", "can_delete"},
+				[]any{int64(2), "region=us-east1", true, "", "5.0 MiB", "120 MiB/s", "40 MiB/s", true}),
+			want: want,
+		},
+		{
+			name: "reordered with extra columns",
+			rows: fixture(
+				[]string{"node", "ok", "locality", "can_delete", "error", "transferred",
+					"read_speed", "write_speed", "latency"},
+				[]any{int64(2), true, "region=us-east1", true, nil, "5.0 MiB", "120 MiB/s", "40 MiB/s", "12ms"}),
+			want: want,
+		},
+		{
+			name: "missing optional columns",
+			rows: fixture([]string{"node", "ok", "error"}, []any{int32(3), false, "access denied"}),
+			want: &Stats{Node: 3, ErrStr: "access denied"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := statsFromRow(tt.rows[0])
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := statsFromRow(fixture([]string{"node"}, []any{int64(1)})[0])
+	assert.ErrorContains(t, err, "missing column ok")
+	_, err = statsFromRow(fixture([]string{"node", "ok"}, []any{"1", true})[0])
+	assert.ErrorContains(t, err, "column node: expected an integer")
+}
+
+func TestShowBackup(t *testing.T) {
+	full := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	inc := full.Add(time.Hour)
+	table := KvTable{Schema: Public, Name: "mytable"}
+	tests := []struct {
+		name string
+		rows []row
+	}{
+		{
+			name: "default columns",
+			rows: fixture(
+				[]string{"database_name", "parent_schema_name", "object_name", "object_type", "backup_type",
+					"start_time", "end_time", "size_bytes", "rows", "is_full_cluster"},
+				[]any{nil, nil, "_blobcheck", "database", "full", nil, full, nil, nil, false},
+				[]any{"_blobcheck", "public", "mytable", "table", "full", nil, full, int64(2048), int64(20), false},
+				[]any{"_blobcheck", "public", "mytable", "table", "incremental", full, inc, int64(512), int64(5), false}),
+		},
+		{
+			name: "reordered with extra columns",
+			rows: fixture(
+				[]string{"object_name", "end_time", "backup_type", "object_type", "parent_schema_name",
+					"rows", "size_bytes", "regions", "file_bytes"},
+				[]any{"_blobcheck", full, "full", "database", nil, nil, nil, nil, nil},
+				[]any{"mytable", full, "full", "table", "public", int64(20), int64(2048), nil, int64(4096)},
+				[]any{"mytable", inc, "incremental", "table", "public", int64(5), int64(512), nil, int64(1024)}),
+		},
+		{
+			name: "without backup type",
+			rows: fixture(
+				[]string{"parent_schema_name", "object_name", "object_type", "start_time", "end_time",
+					"size_bytes", "rows"},
+				[]any{nil, "_blobcheck", "database", nil, full, nil, nil},
+				[]any{"public", "mytable", "table", nil, full, int64(2048), int64(20)},
+				[]any{"public", "mytable", "table", full, inc, int64(512), int64(5)}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			var entries []backupEntry
+			for _, r := range tt.rows {
+				e, err := backupEntryFromRow(r)
+				require.NoError(t, err)
+				entries = append(entries, e)
+			}
+			a.Equal([]TableBackup{
+				{Table: table, Full: false, EndTime: inc, Size: 512, Rows: 5},
+				{Table: table, Full: true, EndTime: full, Size: 2048, Rows: 20},
+			}, tableBackups(entries, table))
+			a.Equal([]BackupLayer{
+				{Collection: "c", Full: true, EndTime: full, Tables: 1, Size: 2048, Rows: 20},
+				{Collection: "c", Full: false, EndTime: inc, Tables: 1, Size: 512, Rows: 5},
+			}, layers("c", entries))
+		})
+	}
+
+	_, err := backupEntryFromRow(fixture([]string{"object_name"}, []any{"t"})[0])
+	assert.ErrorContains(t, err, "missing column end_time")
+}
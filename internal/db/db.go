@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/cockroachdb/field-eng-powertools/stopper"
@@ -59,6 +60,15 @@ func (d *Database) Create(ctx *stopper.Context, conn *pgxpool.Conn) error {
 	return err
 }
 
+const dbExistsStmt = `SELECT count(*) > 0 FROM [SHOW DATABASES] WHERE database_name = $1`
+
+// Exists returns whether the database exists.
+func (d *Database) Exists(ctx *stopper.Context, conn *pgxpool.Conn) (bool, error) {
+	var exists bool
+	err := conn.QueryRow(ctx, dbExistsStmt, string(d.Name)).Scan(&exists)
+	return exists, err
+}
+
 const dropDbStmt = `DROP database IF EXISTS %[1]s CASCADE;`
 
 // Drop removes the database.
@@ -68,7 +78,71 @@ func (d *Database) Drop(ctx *stopper.Context, conn *pgxpool.Conn) error {
 	return err
 }
 
+const createSchemaStmt = `CREATE SCHEMA IF NOT EXISTS %[1]s.%[2]s;`
+
+// CreateSchema creates the schema in the database.
+func (d *Database) CreateSchema(ctx *stopper.Context, conn *pgxpool.Conn, schema Schema) error {
+	_, err := conn.Exec(ctx, fmt.Sprintf(createSchemaStmt, d.Name, schema.Name))
+	return err
+}
+
+const dropSchemaStmt = `DROP SCHEMA IF EXISTS %[1]s.%[2]s RESTRICT;`
+
+// DropSchema removes the schema from the database. It fails if the schema
+// still contains objects.
+func (d *Database) DropSchema(ctx *stopper.Context, conn *pgxpool.Conn, schema Schema) error {
+	slog.Debug("Dropping schema", slog.String("database", d.Name.String()), slog.String("schema", schema.String()))
+	_, err := conn.Exec(ctx, fmt.Sprintf(dropSchemaStmt, d.Name, schema.Name))
+	return err
+}
+
+const schemaExistsStmt = `SELECT count(*) > 0 FROM %[1]s.information_schema.schemata WHERE schema_name = $1`
+
+// SchemaExists returns whether the schema exists in the database.
+func (d *Database) SchemaExists(ctx *stopper.Context, conn *pgxpool.Conn, schema Schema) (bool, error) {
+	var exists bool
+	err := conn.QueryRow(ctx, fmt.Sprintf(schemaExistsStmt, d.Name), string(schema.Name)).Scan(&exists)
+	return exists, err
+}
+
 // String returns the string representation of the database.
 func (d *Database) String() string {
 	return string(d.Name)
 }
+
+const listTablesStmt = `
+SELECT table_schema, table_name
+FROM %[1]s.information_schema.tables
+WHERE table_schema NOT IN ('crdb_internal', 'information_schema', 'pg_catalog', 'pg_extension')
+ORDER BY table_schema, table_name`
+
+// Tables returns the names, qualified by schema, of the tables, views and
+// sequences in the database.
+func (d *Database) Tables(ctx *stopper.Context, conn *pgxpool.Conn) ([]string, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf(listTablesStmt, d.Name))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []string
+	for rows.Next() {
+		var schema, name string
+		if err := rows.Scan(&schema, &name); err != nil {
+			return nil, err
+		}
+		res = append(res, schema+"."+name)
+	}
+	return res, rows.Err()
+}
+
+const showDatabasesStmt = `SELECT database_name FROM [SHOW DATABASES] WHERE database_name LIKE @pattern`
+
+// Databases returns the names of the databases matching the given LIKE pattern.
+func Databases(ctx *stopper.Context, conn *pgxpool.Conn, pattern string) ([]Ident, error) {
+	rows, err := conn.Query(ctx, showDatabasesStmt, pgx.NamedArgs{"pattern": pattern})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return pgx.CollectRows(rows, pgx.RowTo[Ident])
+}
@@ -17,6 +17,7 @@ package db
 import (
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -33,8 +34,9 @@ var MinVersionForStats = semver.MustSemver("v25.1.0")
 
 // ExternalConn represents an external connection to blob storage.
 type ExternalConn struct {
-	name Ident
-	blob blob.Storage
+	name        Ident
+	blob        blob.Storage
+	incremental *ExternalConn // stores the incremental backups, if not the collection
 }
 
 // Stats represents statistics about the external connection.
@@ -54,14 +56,45 @@ type TableBackup struct {
 	Table   KvTable
 	Full    bool
 	EndTime time.Time
+	Size    int64 // bytes of the table in the layer
+	Rows    int64 // rows of the table in the layer
 }
 
+// BackupLayer summarizes a single layer (full or incremental backup) of a
+// backup collection.
+type BackupLayer struct {
+	Collection string
+	Full       bool
+	EndTime    time.Time
+	Tables     int
+	Size       int64
+	Rows       int64
+}
+
+// ConnInfo describes an external connection defined in the cluster.
+type ConnInfo struct {
+	Name string
+	URI  string // the connection URI, with secrets redacted
+}
+
+// BackupConnName is the name of the external connection created by
+// NewExternalConn.
+const BackupConnName Ident = "_blobcheck_backup"
+
 // NewExternalConn creates a new external connection.
 func NewExternalConn(
 	ctx *stopper.Context, conn *pgxpool.Conn, blob blob.Storage,
+) (*ExternalConn, error) {
+	return NewNamedExternalConn(ctx, conn, BackupConnName, blob)
+}
+
+// NewNamedExternalConn creates a new external connection with the given
+// name, replacing any existing connection with the same name.
+func NewNamedExternalConn(
+	ctx *stopper.Context, conn *pgxpool.Conn, name Ident, blob blob.Storage,
 ) (*ExternalConn, error) {
 	extConn := &ExternalConn{
-		name: "_blobcheck_backup",
+		name: name,
 		blob: blob,
 	}
 	err := extConn.Drop(ctx, conn)
@@ -93,36 +126,158 @@ func (c *ExternalConn) ListTableBackups(
 	return res, nil
 }
 
-const tableBackupStmt = `
-	SELECT backup_type, end_time, parent_schema_name, object_name
-	FROM [SHOW BACKUP '%[1]s' IN 'external://%[2]s'] 
-	WHERE parent_schema_name='%[3]s' AND object_name='%[4]s'
-	ORDER BY end_time DESC`
+const showBackupStmt = `SHOW BACKUP '%[1]s' IN 'external://%[2]s'%[3]s`
+
+// backupEntry is a row of SHOW BACKUP: an object in a layer of a backup
+// collection.
+type backupEntry struct {
+	Schema     string
+	Object     string
+	ObjectType string
+	Full       bool
+	EndTime    time.Time
+	Size       int64
+	Rows       int64
+}
+
+// backupEntryFromRow reads a row of SHOW BACKUP by column name. The object
+// name and the end time are required. Releases that do not report the
+// backup type are handled by treating layers without a start time as full
+// backups; missing sizes and row counts are reported as 0.
+func backupEntryFromRow(r row) (backupEntry, error) {
+	var e backupEntry
+	if err := r.require("object_name", "end_time"); err != nil {
+		return e, err
+	}
+	var err error
+	for col, dest := range map[string]*string{
+		"parent_schema_name": &e.Schema,
+		"object_name":        &e.Object,
+		"object_type":        &e.ObjectType,
+	} {
+		if *dest, err = r.string(col); err != nil {
+			return e, err
+		}
+	}
+	if e.EndTime, err = r.time("end_time"); err != nil {
+		return e, err
+	}
+	if r.has("backup_type") {
+		backupType, err := r.string("backup_type")
+		if err != nil {
+			return e, err
+		}
+		e.Full = backupType == "full"
+	} else {
+		start, err := r.time("start_time")
+		if err != nil {
+			return e, err
+		}
+		e.Full = start.IsZero()
+	}
+	if e.Size, err = r.int("size_bytes"); err != nil {
+		return e, err
+	}
+	if e.Rows, err = r.int("rows"); err != nil {
+		return e, err
+	}
+	return e, nil
+}
 
-// BackupInfo retrieves backup information for a specific table.
+// showBackup returns the objects in the layers of a backup collection.
+func (c *ExternalConn) showBackup(
+	ctx *stopper.Context, conn *pgxpool.Conn, collection string,
+) ([]backupEntry, error) {
+	with := ""
+	if opt := c.incrementalLocation(); opt != "" {
+		with = " WITH " + opt
+	}
+	rows, err := conn.Query(ctx, fmt.Sprintf(showBackupStmt, collection, c.String(), with))
+	if err != nil {
+		return nil, err
+	}
+	results, err := collectRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]backupEntry, 0, len(results))
+	for _, r := range results {
+		e, err := backupEntryFromRow(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "unexpected SHOW BACKUP output")
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+// BackupInfo retrieves backup information for a specific table, from the
+// most recent layer to the oldest.
 func (c *ExternalConn) BackupInfo(
 	ctx *stopper.Context, conn *pgxpool.Conn, loc string, table KvTable,
 ) ([]TableBackup, error) {
+	entries, err := c.showBackup(ctx, conn, loc)
+	if err != nil {
+		return nil, err
+	}
+	return tableBackups(entries, table), nil
+}
+
+// tableBackups returns the layers that contain the table, from the most
+// recent to the oldest.
+func tableBackups(entries []backupEntry, table KvTable) []TableBackup {
 	res := make([]TableBackup, 0)
-	stmt := fmt.Sprintf(tableBackupStmt, loc, c.String(), table.Schema.Name, table.Name)
-	rows, err := conn.Query(ctx, stmt)
+	for _, e := range entries {
+		if e.Schema != string(table.Schema.Name) || e.Object != string(table.Name) {
+			continue
+		}
+		slog.Debug("backup info", "full", e.Full, "table", e.Object, "schema", e.Schema)
+		res = append(res, TableBackup{Table: table, Full: e.Full, EndTime: e.EndTime, Size: e.Size, Rows: e.Rows})
+	}
+	slices.SortStableFunc(res, func(a, b TableBackup) int {
+		return b.EndTime.Compare(a.EndTime)
+	})
+	return res
+}
+
+// Layers retrieves the full and incremental layers of a backup collection.
+func (c *ExternalConn) Layers(
+	ctx *stopper.Context, conn *pgxpool.Conn, collection string,
+) ([]BackupLayer, error) {
+	entries, err := c.showBackup(ctx, conn, collection)
 	if err != nil {
 		return nil, err
 	}
-	for rows.Next() {
-		t := TableBackup{
-			Table: table,
+	return layers(collection, entries), nil
+}
+
+// layers groups the objects of a backup collection by layer, from the
+// oldest to the most recent.
+func layers(collection string, entries []backupEntry) []BackupLayer {
+	type key struct {
+		full    bool
+		endTime time.Time
+	}
+	index := make(map[key]int)
+	res := make([]BackupLayer, 0)
+	for _, e := range entries {
+		k := key{full: e.Full, endTime: e.EndTime}
+		i, ok := index[k]
+		if !ok {
+			i = len(res)
+			index[k] = i
+			res = append(res, BackupLayer{Collection: collection, Full: e.Full, EndTime: e.EndTime})
 		}
-		var tableName, schemeName, backupType string
-		if err := rows.Scan(&backupType, &t.EndTime, &schemeName, &tableName); err != nil {
-			return nil, err
+		if e.ObjectType == "table" {
+			res[i].Tables++
 		}
-		t.Full = (backupType == "full")
-		slog.Debug("backup info", "type", backupType, "full",
-			t.Full, "table", tableName, "schema", schemeName)
-		res = append(res, t)
+		res[i].Size += e.Size
+		res[i].Rows += e.Rows
 	}
-	return res, nil
+	slices.SortStableFunc(res, func(a, b BackupLayer) int {
+		return a.EndTime.Compare(b.EndTime)
+	})
+	return res
 }
 
 const createExtConnStmt = `CREATE EXTERNAL CONNECTION '%[1]s' AS '%[2]s'`
@@ -144,14 +299,49 @@ func (c *ExternalConn) create(ctx *stopper.Context, conn *pgxpool.Conn) error {
 		return nil
 	}
 	slog.Error("failed", slog.Any("error", err))
-	return errors.Newf("external connection failed")
+	return errors.Wrap(err, "external connection failed")
+}
+
+const listExtConnsStmt = `SELECT connection_name, connection_uri FROM [SHOW EXTERNAL CONNECTIONS]`
+
+// ListExternalConns lists the external connections defined in the cluster.
+func ListExternalConns(ctx *stopper.Context, conn *pgxpool.Conn) ([]ConnInfo, error) {
+	rows, err := conn.Query(ctx, listExtConnsStmt)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[ConnInfo])
 }
 
 const showExtConnStmt = `SELECT connection_name FROM [SHOW EXTERNAL CONNECTIONS] WHERE connection_name = '%[1]s'`
 const dropExtConnStmt = `DROP EXTERNAL CONNECTION '%[1]s';`
 
-// Drop removes the external connection.
+// SetIncrementalLocation stores the incremental backups of the collections
+// in another external connection, rather than in the collections
+// themselves. The option is added to the backups, restores and SHOW BACKUP
+// statements that use the connection, since they fail to find the
+// incremental layers otherwise.
+func (c *ExternalConn) SetIncrementalLocation(incremental *ExternalConn) {
+	c.incremental = incremental
+}
+
+// incrementalLocation returns the incremental_location option, if the
+// incremental backups are stored in another external connection.
+func (c *ExternalConn) incrementalLocation() string {
+	if c.incremental == nil {
+		return ""
+	}
+	return fmt.Sprintf("incremental_location = 'external://%s'", c.incremental)
+}
+
+// Drop removes the external connection, and the one storing its
+// incremental backups, if any.
 func (c *ExternalConn) Drop(ctx *stopper.Context, conn *pgxpool.Conn) error {
+	if c.incremental != nil {
+		if err := c.incremental.Drop(ctx, conn); err != nil {
+			return err
+		}
+	}
 	var name string
 	err := conn.QueryRow(ctx, fmt.Sprintf(showExtConnStmt, c.name)).Scan(&name)
 	if err == pgx.ErrNoRows {
@@ -165,6 +355,55 @@ func (c *ExternalConn) Drop(ctx *stopper.Context, conn *pgxpool.Conn) error {
 	return err
 }
 
+// ReplaceExternalConn creates the named external connection pointing at
+// the given URL, replacing any existing connection with the same name. The
+// existing connection is dropped in the same transaction, so that it is
+// kept if the new one cannot be created. It returns whether a connection was
+// replaced.
+func ReplaceExternalConn(
+	ctx *stopper.Context, conn *pgxpool.Conn, name Ident, url string,
+) (bool, error) {
+	var replaced bool
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		var existing string
+		err := tx.QueryRow(ctx, lookupExtConnStmt, string(name)).Scan(&existing)
+		replaced = err == nil
+		if err != nil && err != pgx.ErrNoRows {
+			return err
+		}
+		for _, stmt := range replaceExtConnStmts(name, url, replaced) {
+			if _, err := tx.Exec(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return replaced, err
+}
+
+const lookupExtConnStmt = `SELECT connection_name FROM [SHOW EXTERNAL CONNECTIONS] WHERE connection_name = $1`
+
+// replaceExtConnStmts returns the statements that create the named external
+// connection, dropping the existing one first if needed. The name is quoted
+// as an identifier, since it is provided by the user.
+func replaceExtConnStmts(name Ident, url string, exists bool) []string {
+	var stmts []string
+	if exists {
+		stmts = append(stmts, "DROP EXTERNAL CONNECTION "+quote(name))
+	}
+	return append(stmts, fmt.Sprintf("CREATE EXTERNAL CONNECTION %s AS %s", quote(name), quoteString(url)))
+}
+
+const showCreateExtConnStmt = `SELECT create_statement FROM [SHOW CREATE EXTERNAL CONNECTION '%[1]s']`
+
+// ShowCreate returns the statement that creates the external connection,
+// as shown to the user. Older versions do not support the statement.
+func (c *ExternalConn) ShowCreate(ctx *stopper.Context, conn *pgxpool.Conn) (string, error) {
+	var stmt string
+	err := conn.QueryRow(ctx, fmt.Sprintf(showCreateExtConnStmt, c.name)).Scan(&stmt)
+	return stmt, err
+}
+
 const checkExtConnStmt = `CHECK EXTERNAL CONNECTION 'external://%[1]s';`
 
 // Stats retrieves statistics for the external connection.
@@ -178,25 +417,58 @@ func (c *ExternalConn) Stats(ctx *stopper.Context, conn *pgxpool.Conn) ([]*Stats
 		return nil, nil
 	}
 
-	res := make([]*Stats, 0)
 	rows, err := conn.Query(ctx, fmt.Sprintf(checkExtConnStmt, c.name))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var stats = &Stats{}
-		if err := rows.Scan(
-			&stats.Node, &stats.Locality, &stats.Success, &stats.ErrStr,
-			&stats.Transferred, &stats.ReadSpeed, &stats.WriteSpeed,
-			&stats.CanDelete); err != nil {
-			return nil, err
+	results, err := collectRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Stats, 0, len(results))
+	for _, r := range results {
+		stats, err := statsFromRow(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "unexpected CHECK EXTERNAL CONNECTION output")
 		}
 		res = append(res, stats)
 	}
 	return res, nil
 }
 
+// statsFromRow reads a row of CHECK EXTERNAL CONNECTION by column name.
+// Only the node and the outcome are required; columns that are missing are
+// left empty, and columns that are not known are ignored.
+func statsFromRow(r row) (*Stats, error) {
+	if err := r.require("node", "ok"); err != nil {
+		return nil, err
+	}
+	stats := &Stats{}
+	node, err := r.int("node")
+	if err != nil {
+		return nil, err
+	}
+	stats.Node = int(node)
+	for col, dest := range map[string]*string{
+		"locality":    &stats.Locality,
+		"error":       &stats.ErrStr,
+		"transferred": &stats.Transferred,
+		"read_speed":  &stats.ReadSpeed,
+		"write_speed": &stats.WriteSpeed,
+	} {
+		if *dest, err = r.string(col); err != nil {
+			return nil, err
+		}
+	}
+	if stats.Success, err = r.bool("ok"); err != nil {
+		return nil, err
+	}
+	if stats.CanDelete, err = r.bool("can_delete"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
 // String returns the string representation of the external connection.
 func (c *ExternalConn) String() string {
 	return string(c.name)
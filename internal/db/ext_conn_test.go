@@ -0,0 +1,32 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceExtConnStmts(t *testing.T) {
+	a := assert.New(t)
+	a.Equal([]string{
+		`CREATE EXTERNAL CONNECTION "backups" AS 's3://bucket/path?AWS_ACCESS_KEY_ID=id'`,
+	}, replaceExtConnStmts("backups", "s3://bucket/path?AWS_ACCESS_KEY_ID=id", false))
+	a.Equal([]string{
+		`DROP EXTERNAL CONNECTION "it's ""quoted"""`,
+		`CREATE EXTERNAL CONNECTION "it's ""quoted""" AS 's3://bucket/it''s'`,
+	}, replaceExtConnStmts(`it's "quoted"`, "s3://bucket/it's", true))
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+// requiredSystemPrivileges are the system privileges needed by the
+// validation: creating the external connection, inspecting the ranges and
+// stores of the cluster, and following the backup and restore jobs.
+var requiredSystemPrivileges = []string{"EXTERNALCONNECTION", "VIEWCLUSTERMETADATA", "VIEWJOB"}
+
+// Privileges describes the privileges held by a user that are relevant to
+// the validation.
+type Privileges struct {
+	Admin            bool     // the user is a member of the admin role
+	CreateDB         bool     // the user can create databases
+	CreateInDatabase bool     // the user can create tables in the selected database
+	System           []string // system privileges granted to the user
+}
+
+// Grants returns the statements that give the user the minimal privileges
+// needed to run the validation. If database is set, the test tables are
+// created in that database; otherwise the user must be able to create
+// databases. Tables created by the user are owned by it, so no privileges
+// on individual tables are needed.
+func Grants(user Ident, database string) []string {
+	res := []string{fmt.Sprintf("CREATE USER IF NOT EXISTS %s", quote(user))}
+	res = append(res, grantsFor(user, database, Privileges{})...)
+	return res
+}
+
+// grantsFor returns the statements that grant the privileges that are
+// required but not held.
+func grantsFor(user Ident, database string, held Privileges) []string {
+	if held.Admin {
+		return nil
+	}
+	grantee := quote(user)
+	var res []string
+	if database == "" && !held.CreateDB {
+		res = append(res, fmt.Sprintf("ALTER USER %s WITH CREATEDB", grantee))
+	}
+	if database != "" && !held.CreateInDatabase {
+		res = append(res, fmt.Sprintf("GRANT CREATE ON DATABASE %s TO %s", quote(Ident(database)), grantee))
+	}
+	for _, p := range requiredSystemPrivileges {
+		if !slices.Contains(held.System, p) && !slices.Contains(held.System, "ALL") {
+			res = append(res, fmt.Sprintf("GRANT SYSTEM %s TO %s", p, grantee))
+		}
+	}
+	return res
+}
+
+const (
+	currentUserStmt  = `SELECT current_user(), crdb_internal.is_admin()`
+	createDBStmt     = `SELECT rolcreatedb FROM pg_catalog.pg_roles WHERE rolname = current_user()`
+	createInDBStmt   = `SELECT has_database_privilege($1, 'CREATE')`
+	systemGrantsStmt = `SELECT privilege_type FROM [SHOW SYSTEM GRANTS FOR %s]`
+)
+
+// MissingGrants returns the statements an administrator needs to run so
+// that the current user can run the validation. Privileges inherited from
+// roles other than admin are not taken into account.
+func MissingGrants(ctx *stopper.Context, conn *pgxpool.Conn, database string) ([]string, error) {
+	var user string
+	var held Privileges
+	if err := conn.QueryRow(ctx, currentUserStmt).Scan(&user, &held.Admin); err != nil {
+		return nil, err
+	}
+	if held.Admin {
+		return nil, nil
+	}
+	if err := conn.QueryRow(ctx, createDBStmt).Scan(&held.CreateDB); err != nil {
+		return nil, err
+	}
+	if database != "" {
+		if err := conn.QueryRow(ctx, createInDBStmt, database).Scan(&held.CreateInDatabase); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := conn.Query(ctx, fmt.Sprintf(systemGrantsStmt, quote(Ident(user))))
+	if err != nil {
+		return nil, err
+	}
+	held.System, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	return grantsFor(Ident(user), database, held), nil
+}
+
+// quote returns the identifier quoted for use in a statement.
+func quote(i Ident) string {
+	return pgx.Identifier{string(i)}.Sanitize()
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrants(t *testing.T) {
+	a := assert.New(t)
+	a.Equal([]string{
+		`CREATE USER IF NOT EXISTS "blobcheck_svc"`,
+		`ALTER USER "blobcheck_svc" WITH CREATEDB`,
+		`GRANT SYSTEM EXTERNALCONNECTION TO "blobcheck_svc"`,
+		`GRANT SYSTEM VIEWCLUSTERMETADATA TO "blobcheck_svc"`,
+		`GRANT SYSTEM VIEWJOB TO "blobcheck_svc"`,
+	}, Grants("blobcheck_svc", ""))
+	a.Contains(Grants("blobcheck_svc", "app"), `GRANT CREATE ON DATABASE "app" TO "blobcheck_svc"`)
+	a.NotContains(Grants("blobcheck_svc", "app"), `ALTER USER "blobcheck_svc" WITH CREATEDB`)
+}
+
+func TestGrantsFor(t *testing.T) {
+	a := assert.New(t)
+	a.Empty(grantsFor("root", "", Privileges{Admin: true}))
+	a.Empty(grantsFor("svc", "", Privileges{CreateDB: true, System: []string{"ALL"}}))
+	a.Equal([]string{`GRANT SYSTEM VIEWJOB TO "svc"`}, grantsFor("svc", "app", Privileges{
+		CreateInDatabase: true,
+		System:           []string{"EXTERNALCONNECTION", "VIEWCLUSTERMETADATA"},
+	}))
+}
@@ -91,7 +91,7 @@ func TestIntegration(t *testing.T) {
 		a.Equal(len(stats), 1)
 	}
 
-	r.NoError(testEnv.KvTable.Backup(ctx, conn, extConn, false))
+	r.NoError(testEnv.KvTable.Backup(ctx, conn, extConn, BackupOptions{}))
 	targetDB := Database{
 		Name: "_test_restore",
 	}
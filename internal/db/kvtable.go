@@ -17,11 +17,14 @@ package db
 import (
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/field-eng-powertools/stopper"
 )
 
@@ -32,17 +35,93 @@ type KvTable struct {
 	Name Ident
 }
 
-const backupTableStmt = `BACKUP %[1]s INTO %[2]s 'external://%[3]s'`
+const backupTableStmt = `BACKUP %[1]s INTO %[2]s 'external://%[3]s'%[4]s`
+
+// BackupOptions controls how a backup is taken.
+type BackupOptions struct {
+	// Incremental appends an incremental backup to the latest collection.
+	Incremental bool
+	// RevisionHistory includes the MVCC history of the table in the backup.
+	RevisionHistory bool
+	// ExecutionLocality restricts the nodes running the backup to those
+	// matching the locality filter (e.g. region=us-east1).
+	ExecutionLocality string
+	// Database backs up the whole database of the table, rather than the
+	// table alone.
+	Database bool
+
+	incrementalLocation string // set from the destination of incremental backups
+}
+
+// with returns the WITH clause for the options, if any.
+func (o BackupOptions) with() string {
+	var opts []string
+	if o.RevisionHistory {
+		opts = append(opts, "revision_history")
+	}
+	if o.ExecutionLocality != "" {
+		opts = append(opts, "execution locality = "+quoteString(o.ExecutionLocality))
+	}
+	if o.incrementalLocation != "" {
+		opts = append(opts, o.incrementalLocation)
+	}
+	if len(opts) == 0 {
+		return ""
+	}
+	return " WITH " + strings.Join(opts, ", ")
+}
+
+// quoteString returns the string as a SQL string literal.
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
 
 // Backup creates a backup of the table.
 func (t *KvTable) Backup(
-	ctx *stopper.Context, conn *pgxpool.Conn, dest *ExternalConn, incremental bool,
+	ctx *stopper.Context, conn *pgxpool.Conn, dest *ExternalConn, opts BackupOptions,
 ) error {
 	mod := ""
-	if incremental {
+	if opts.Incremental {
 		mod = "LATEST IN"
+		opts.incrementalLocation = dest.incrementalLocation()
+	}
+	target := t.String()
+	if opts.Database {
+		target = "DATABASE " + t.Database.String()
 	}
-	_, err := conn.Exec(ctx, fmt.Sprintf(backupTableStmt, t.String(), mod, dest))
+	stmt := fmt.Sprintf(backupTableStmt, target, mod, dest, opts.with())
+	slog.Debug(stmt)
+	_, err := conn.Exec(ctx, stmt)
+	return err
+}
+
+const gcTTLStmt = `ALTER TABLE %[1]s CONFIGURE ZONE USING gc.ttlseconds = %[2]d`
+
+// SetGCTTL sets the garbage collection TTL of the table.
+func (t *KvTable) SetGCTTL(ctx *stopper.Context, conn *pgxpool.Conn, ttl time.Duration) error {
+	_, err := conn.Exec(ctx, fmt.Sprintf(gcTTLStmt, t.String(), int(ttl.Seconds())))
+	return err
+}
+
+const addColumnStmt = `ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS %[2]s STRING NOT NULL DEFAULT 'blobcheck'`
+
+// AddColumn adds a column with a default value to the table. The existing
+// rows are backfilled by an online schema change.
+func (t *KvTable) AddColumn(ctx *stopper.Context, conn *pgxpool.Conn, name Ident) error {
+	stmt := fmt.Sprintf(addColumnStmt, t.String(), name)
+	slog.Debug(stmt)
+	_, err := conn.Exec(ctx, stmt)
+	return err
+}
+
+const createIndexStmt = `CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (v)`
+
+// CreateIndex creates a secondary index on the value column of the table.
+// The index is backfilled by an online schema change.
+func (t *KvTable) CreateIndex(ctx *stopper.Context, conn *pgxpool.Conn, name Ident) error {
+	stmt := fmt.Sprintf(createIndexStmt, t.String(), name)
+	slog.Debug(stmt)
+	_, err := conn.Exec(ctx, stmt)
 	return err
 }
 
@@ -58,6 +137,18 @@ func (t *KvTable) Create(ctx *stopper.Context, conn *pgxpool.Conn) error {
 	return err
 }
 
+const generateRowsStmt = `
+INSERT INTO %[1]s (k, v) SELECT gen_random_uuid()::STRING, repeat('x', @size) FROM generate_series(1, @rows)`
+
+// Generate inserts rows with random keys and values of the given size.
+func (t *KvTable) Generate(ctx *stopper.Context, conn *pgxpool.Conn, rows, size int) error {
+	_, err := conn.Exec(ctx, fmt.Sprintf(generateRowsStmt, t.String()), pgx.NamedArgs{
+		"rows": rows,
+		"size": size,
+	})
+	return err
+}
+
 const dropTableStmt = `
 DROP TABLE IF EXISTS %[1]s;`
 
@@ -68,6 +159,96 @@ func (t *KvTable) Drop(ctx *stopper.Context, conn *pgxpool.Conn) error {
 	return err
 }
 
+const renameTableStmt = `ALTER TABLE %[1]s RENAME TO %[2]s`
+
+// Rename renames the table, keeping it in the same database and schema.
+func (t *KvTable) Rename(ctx *stopper.Context, conn *pgxpool.Conn, name Ident) error {
+	slog.Debug("Renaming table", slog.String("table", t.String()), slog.String("name", name.String()))
+	if _, err := conn.Exec(ctx, fmt.Sprintf(renameTableStmt, t.String(), name)); err != nil {
+		return err
+	}
+	t.Name = name
+	return nil
+}
+
+const tableExistsStmt = `
+SELECT count(*) > 0
+FROM %[1]s.information_schema.tables
+WHERE table_schema = @schema AND table_name = @name`
+
+// Exists returns true if the table exists.
+func (t *KvTable) Exists(ctx *stopper.Context, conn *pgxpool.Conn) (bool, error) {
+	var exists bool
+	err := conn.QueryRow(ctx, fmt.Sprintf(tableExistsStmt, t.Database.Name), pgx.NamedArgs{
+		"schema": t.Schema.String(),
+		"name":   t.Name.String(),
+	}).Scan(&exists)
+	return exists, err
+}
+
+const countStmt = `SELECT count(*) FROM %[1]s`
+
+// Count returns the number of rows in the table.
+func (t *KvTable) Count(ctx *stopper.Context, conn *pgxpool.Conn) (int64, error) {
+	var count int64
+	err := conn.QueryRow(ctx, fmt.Sprintf(countStmt, t.String())).Scan(&count)
+	return count, err
+}
+
+const clusterTimestampStmt = `SELECT cluster_logical_timestamp()::STRING`
+
+// ClusterTimestamp returns the current timestamp of the cluster, which can be
+// used to read the data as of now later on.
+func ClusterTimestamp(ctx *stopper.Context, conn *pgxpool.Conn) (string, error) {
+	var ts string
+	err := conn.QueryRow(ctx, clusterTimestampStmt).Scan(&ts)
+	return ts, err
+}
+
+// ErrGarbageCollected is returned when reading data older than the garbage
+// collection threshold of the table.
+var ErrGarbageCollected = errors.New("the data was garbage collected")
+
+const countAtStmt = `SELECT count(*) FROM %[1]s AS OF SYSTEM TIME %[2]s`
+
+// CountAt returns the number of rows in the table as of the given cluster
+// timestamp. It returns ErrGarbageCollected if the timestamp is below the
+// garbage collection threshold of the table.
+func (t *KvTable) CountAt(ctx *stopper.Context, conn *pgxpool.Conn, ts string) (int64, error) {
+	var count int64
+	err := conn.QueryRow(ctx, fmt.Sprintf(countAtStmt, t.String(), quoteString(ts))).Scan(&count)
+	if err != nil && strings.Contains(err.Error(), "GC threshold") {
+		return 0, errors.Wrap(ErrGarbageCollected, err.Error())
+	}
+	return count, err
+}
+
+const valuesStmt = `SELECT k, v FROM %[1]s WHERE k = ANY(@keys)`
+
+// valuesBatch is the maximum number of keys read by a query.
+const valuesBatch = 1000
+
+// Values returns the values of the keys found in the table.
+func (t *KvTable) Values(
+	ctx *stopper.Context, conn *pgxpool.Conn, keys []string,
+) (map[string]string, error) {
+	res := make(map[string]string, len(keys))
+	for batch := range slices.Chunk(keys, valuesBatch) {
+		rows, err := conn.Query(ctx, fmt.Sprintf(valuesStmt, t.String()), pgx.NamedArgs{"keys": batch})
+		if err != nil {
+			return nil, err
+		}
+		var k, v string
+		if _, err := pgx.ForEachRow(rows, []any{&k, &v}, func() error {
+			res[k] = v
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
 const insertTableStmt = `
 UPSERT INTO %[1]s (k, v) values (@key, @value);`
 
@@ -80,13 +261,17 @@ func (t *KvTable) Upsert(ctx *stopper.Context, conn *pgxpool.Conn, key, value st
 	return err
 }
 
-const restoreTableStmt = `RESTORE %[1]s  FROM '%[2]s' IN 'external://%[3]s' WITH into_db=%[4]s`
+const restoreTableStmt = `RESTORE %[1]s  FROM '%[2]s' IN 'external://%[3]s' WITH into_db=%[4]s%[5]s`
 
 // Restore restores the table from a backup.
 func (t *KvTable) Restore(
 	ctx *stopper.Context, conn *pgxpool.Conn, from *ExternalConn, original *KvTable,
 ) error {
-	stmt := fmt.Sprintf(restoreTableStmt, original.String(), "LATEST", from, t.Database.Name)
+	incremental := ""
+	if opt := from.incrementalLocation(); opt != "" {
+		incremental = ", " + opt
+	}
+	stmt := fmt.Sprintf(restoreTableStmt, original.String(), "LATEST", from, t.Database.Name, incremental)
 	slog.Debug(stmt)
 	_, err := conn.Exec(ctx, stmt)
 	return err
@@ -245,3 +430,99 @@ func (t *KvTable) PendingJobs(ctx *stopper.Context, conn *pgxpool.Conn) ([]int64
 
 	return pgx.CollectRows(rows, pgx.RowTo[int64])
 }
+
+// JobProgress describes the progress of a running job.
+type JobProgress struct {
+	ID       int64
+	Type     string
+	Status   string
+	Fraction float64
+}
+
+const jobsProgressStmt = `
+SELECT job_id, job_type, status, COALESCE(fraction_completed, 0)
+FROM [SHOW JOBS]
+WHERE
+  job_type = ANY (@types)
+  AND status = 'running'
+  AND description LIKE @desc
+`
+
+var progressJobTypes = []string{"BACKUP", "RESTORE"}
+
+// JobsProgress returns the progress of the backup and restore jobs currently
+// running against the table.
+func (t *KvTable) JobsProgress(ctx *stopper.Context, conn *pgxpool.Conn) ([]JobProgress, error) {
+	rows, err := conn.Query(ctx, jobsProgressStmt, pgx.NamedArgs{
+		"types": progressJobTypes,
+		"desc":  fmt.Sprintf("%%%s%%", t.Name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []JobProgress
+	for rows.Next() {
+		var p JobProgress
+		if err := rows.Scan(&p.ID, &p.Type, &p.Status, &p.Fraction); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+// JobDescription is the description of a job, as shown by SHOW JOBS.
+type JobDescription struct {
+	ID          int64
+	Type        string
+	Description string
+}
+
+const jobDescriptionsStmt = `
+SELECT job_id, job_type, description
+FROM [SHOW JOBS]
+WHERE description LIKE @desc
+`
+
+// JobDescriptions returns the descriptions of the jobs run against the
+// table, whatever their status.
+func (t *KvTable) JobDescriptions(ctx *stopper.Context, conn *pgxpool.Conn) ([]JobDescription, error) {
+	rows, err := conn.Query(ctx, jobDescriptionsStmt, pgx.NamedArgs{
+		"desc": fmt.Sprintf("%%%s%%", t.Name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[JobDescription])
+}
+
+const tableSizeStmt = `SELECT COALESCE(sum(range_size), 0)::INT FROM [SHOW RANGES FROM TABLE %[1]s WITH DETAILS]`
+
+// Size returns the approximate size in bytes of the table, as reported by
+// the ranges that store it.
+func (t *KvTable) Size(ctx *stopper.Context, conn *pgxpool.Conn) (int64, error) {
+	var n int64
+	if err := conn.QueryRow(ctx, fmt.Sprintf(tableSizeStmt, t.String())).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// KV is a key-value pair stored in a KvTable.
+type KV struct {
+	Key, Value string
+}
+
+// UpsertBatch adds the given rows to the table in a single round trip.
+func (t *KvTable) UpsertBatch(ctx *stopper.Context, conn *pgxpool.Conn, rows []KV) error {
+	batch := &pgx.Batch{}
+	stmt := fmt.Sprintf(insertTableStmt, t.String())
+	for _, row := range rows {
+		batch.Queue(stmt, pgx.NamedArgs{
+			"key":   row.Key,
+			"value": row.Value,
+		})
+	}
+	return conn.SendBatch(ctx, batch).Close()
+}
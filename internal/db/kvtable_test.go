@@ -72,3 +72,35 @@ func TestHexSplitPoints(t *testing.T) {
 		seen[p] = true
 	}
 }
+
+func TestBackupOptionsWith(t *testing.T) {
+	tests := []struct {
+		name string
+		opts BackupOptions
+		want string
+	}{
+		{name: "none", opts: BackupOptions{}, want: ""},
+		{name: "incremental only", opts: BackupOptions{Incremental: true, Database: true}, want: ""},
+		{name: "revision history", opts: BackupOptions{RevisionHistory: true}, want: " WITH revision_history"},
+		{
+			name: "execution locality",
+			opts: BackupOptions{ExecutionLocality: "region=us-east1,cloud='gce'"},
+			want: ` WITH execution locality = 'region=us-east1,cloud=''gce'''`,
+		},
+		{
+			name: "all",
+			opts: BackupOptions{
+				RevisionHistory:     true,
+				ExecutionLocality:   "region=us-east1",
+				incrementalLocation: "incremental_location = 'external://incrementals'",
+			},
+			want: " WITH revision_history, execution locality = 'region=us-east1', " +
+				"incremental_location = 'external://incrementals'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.opts.with())
+		})
+	}
+}
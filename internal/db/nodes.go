@@ -0,0 +1,101 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+// NodeAddress is the address advertised by a node of the cluster.
+type NodeAddress struct {
+	Node    int
+	Address string // host:port
+}
+
+const nodeAddressesStmt = `
+SELECT node_id, address
+FROM crdb_internal.gossip_nodes
+ORDER BY node_id`
+
+// NodeAddresses returns the addresses advertised by the nodes of the cluster.
+func NodeAddresses(ctx *stopper.Context, conn *pgxpool.Conn) ([]NodeAddress, error) {
+	rows, err := conn.Query(ctx, nodeAddressesStmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[NodeAddress])
+}
+
+// NodeInfo describes a live node of the cluster.
+type NodeInfo struct {
+	Node       int
+	SQLAddress string // host:port
+	Locality   string // e.g. region=us-east1,zone=us-east1-b
+}
+
+const nodesStmt = `
+SELECT node_id, sql_address, locality
+FROM crdb_internal.gossip_nodes
+WHERE is_live
+ORDER BY node_id`
+
+// Nodes returns the SQL addresses and the localities of the live nodes.
+func Nodes(ctx *stopper.Context, conn *pgxpool.Conn) ([]NodeInfo, error) {
+	rows, err := conn.Query(ctx, nodesStmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[NodeInfo])
+}
+
+// MatchesLocality reports whether a node locality matches a filter in the
+// form accepted by EXECUTION LOCALITY: every tier of the filter must be
+// present in the locality.
+func MatchesLocality(locality, filter string) bool {
+	tiers := strings.Split(locality, ",")
+	for tier := range strings.SplitSeq(filter, ",") {
+		found := false
+		for _, t := range tiers {
+			if strings.TrimSpace(t) == strings.TrimSpace(tier) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckLocalityFilter checks that a filter is a comma separated list of
+// key=value tiers.
+func CheckLocalityFilter(filter string) error {
+	for tier := range strings.SplitSeq(filter, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(tier), "=")
+		if !ok || key == "" || value == "" {
+			return errors.Newf("invalid locality filter %q: expected key=value[,key=value]", filter)
+		}
+	}
+	return nil
+}
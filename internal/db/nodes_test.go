@@ -0,0 +1,54 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesLocality(t *testing.T) {
+	a := assert.New(t)
+	locality := "region=us-east1,zone=us-east1-b"
+	a.True(MatchesLocality(locality, "region=us-east1"))
+	a.True(MatchesLocality(locality, "zone=us-east1-b, region=us-east1"))
+	a.False(MatchesLocality(locality, "region=us-west1"))
+	a.False(MatchesLocality(locality, "region=us-east1,zone=us-east1-c"))
+	a.False(MatchesLocality("", "region=us-east1"))
+}
+
+func TestCheckLocalityFilter(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(CheckLocalityFilter("region=us-east1"))
+	a.NoError(CheckLocalityFilter("region=us-east1,zone=b"))
+	a.Error(CheckLocalityFilter("us-east1"))
+	a.Error(CheckLocalityFilter("region="))
+}
+
+func TestBackupOptions(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("", BackupOptions{}.with())
+	a.Equal(" WITH revision_history", BackupOptions{RevisionHistory: true}.with())
+	a.Equal(" WITH revision_history, execution locality = 'region=us-east1'",
+		BackupOptions{RevisionHistory: true, ExecutionLocality: "region=us-east1"}.with())
+	a.Equal(" WITH execution locality = 'a=b''c'", BackupOptions{ExecutionLocality: "a=b'c"}.with())
+
+	dest := &ExternalConn{name: "_blobcheck_backup"}
+	a.Equal("", dest.incrementalLocation())
+	dest.SetIncrementalLocation(&ExternalConn{name: "_blobcheck_incremental"})
+	a.Equal(" WITH revision_history, incremental_location = 'external://_blobcheck_incremental'",
+		BackupOptions{RevisionHistory: true, incrementalLocation: dest.incrementalLocation()}.with())
+}
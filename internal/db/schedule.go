@@ -0,0 +1,52 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+// Schedule is a backup schedule defined in the cluster.
+type Schedule struct {
+	ID         int64
+	Label      string
+	Recurrence string // cron expression
+	Statement  string // backup statement run by the schedule
+}
+
+// Incremental returns whether the schedule takes incremental backups.
+func (s Schedule) Incremental() bool {
+	return strings.Contains(strings.ToUpper(s.Statement), "INTO LATEST IN")
+}
+
+const backupSchedulesStmt = `
+	SELECT id, label, COALESCE(recurrence, ''), command->>'backup_statement'
+	FROM [SHOW SCHEDULES]
+	WHERE command->>'backup_statement' IS NOT NULL
+	ORDER BY id`
+
+// BackupSchedules lists the backup schedules defined in the cluster.
+func BackupSchedules(ctx *stopper.Context, conn *pgxpool.Conn) ([]Schedule, error) {
+	rows, err := conn.Query(ctx, backupSchedulesStmt)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[Schedule])
+}
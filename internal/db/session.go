@@ -0,0 +1,34 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+const sessionVarStmt = `SHOW %s`
+
+// SessionVar returns the value of a session variable of the connection.
+func SessionVar(ctx *stopper.Context, conn *pgxpool.Conn, name string) (string, error) {
+	var value string
+	if err := conn.QueryRow(ctx, fmt.Sprintf(sessionVarStmt, name)).Scan(&value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
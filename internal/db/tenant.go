@@ -0,0 +1,37 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+// SystemVirtualCluster is the name of the virtual cluster that manages the
+// others, and of the only one of clusters running without virtualization.
+const SystemVirtualCluster = "system"
+
+const virtualClusterStmt = `SHOW virtual_cluster_name`
+
+// VirtualCluster returns the name of the virtual cluster the connection is
+// routed to. Versions that predate virtual clusters return an error.
+func VirtualCluster(ctx *stopper.Context, conn *pgxpool.Conn) (string, error) {
+	var name string
+	if err := conn.QueryRow(ctx, virtualClusterStmt).Scan(&name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
@@ -15,6 +15,7 @@
 package db
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -97,11 +98,66 @@ func (t *testBlobStorage) BucketName() string {
 	return testBucket
 }
 
+// Candidates implements blob.BlobStorage.
+func (t *testBlobStorage) Candidates() []blob.Candidate {
+	return nil
+}
+
+// Probed implements blob.BlobStorage.
+func (t *testBlobStorage) Probed() []blob.Probed {
+	return nil
+}
+
+// Capabilities implements blob.BlobStorage.
+func (t *testBlobStorage) Capabilities(_ context.Context) ([]blob.Capability, error) {
+	return nil, nil
+}
+
+// Clean implements blob.BlobStorage.
+func (t *testBlobStorage) Clean(_ context.Context) error {
+	return nil
+}
+
+// Delete implements blob.BlobStorage.
+func (t *testBlobStorage) Delete(_ context.Context, _ string) error {
+	return nil
+}
+
+// CustomCA implements blob.BlobStorage.
+func (t *testBlobStorage) CustomCA() string {
+	return ""
+}
+
+// Get implements blob.BlobStorage.
+func (t *testBlobStorage) Get(_ context.Context, key string) ([]byte, error) {
+	return nil, fmt.Errorf("object %q not found", key)
+}
+
+// Latency implements blob.BlobStorage.
+func (t *testBlobStorage) Latency() *blob.Latency {
+	return nil
+}
+
+// List implements blob.BlobStorage.
+func (t *testBlobStorage) List(_ context.Context) ([]blob.Object, error) {
+	return nil, nil
+}
+
 // Params implements blob.BlobStorage.
 func (t *testBlobStorage) Params() blob.Params {
 	return blob.Params{}
 }
 
+// ProvidedParams implements blob.BlobStorage.
+func (t *testBlobStorage) ProvidedParams() blob.Params {
+	return nil
+}
+
+// RootURL implements blob.BlobStorage.
+func (t *testBlobStorage) RootURL() string {
+	return externalURL
+}
+
 // URL implements blob.BlobStorage.
 func (t *testBlobStorage) URL() string {
 	return externalURL
@@ -14,21 +14,117 @@
 
 package env
 
-import "time"
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+)
+
+// DialFunc is a function that establishes a network connection.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
 
 // LookupEnv is a function that retrieves the value of an environment variable.
 type LookupEnv func(key string) (string, bool)
 
 // Env holds the environment configuration.
 type Env struct {
-	DatabaseURL      string        // the database connection URL
-	Endpoint         string        // the S3 endpoint
-	Guess            bool          // Guess the URL parameters, no validation.
-	LookupEnv        LookupEnv     // allows injection of environment variable lookup for testing
-	Path             string        // the S3 bucket path
-	Testing          bool          // enables testing mode
-	URI              string        // the S3 object URI (if not provided,will be constructed from Endpoint and Path)
-	Verbose          bool          // enables verbose logging
-	Workers          int           // number of concurrent workers
-	WorkloadDuration time.Duration // duration to run the workload
+	ApplyConn              string        // name of the external connection to create with the validated URL (optional)
+	AssumeYes              bool          // skip confirmation prompts
+	BackupScope            string        // objects backed up: table, or database to also restore a table out of a larger backup
+	BackupWindow           time.Duration // time available to complete a full backup (optional)
+	CACert                 string        // PEM bundle of the CA certificates of the storage endpoint, preferred to skipping the verification (optional)
+	CertsDir               string        // directory with the certificates used to authenticate with the database (optional)
+	ChaosAdvertise         string        // endpoint the cluster uses to reach the fault injection proxy (optional)
+	ChaosBandwidth         string        // transfer rate cap injected by the proxy (e.g. 10MiB/s)
+	ChaosErrorRate         float64       // fraction of storage requests failed by the proxy
+	ChaosLatency           time.Duration // latency added by the proxy to every storage request
+	ChaosListen            string        // address of the fault injection proxy; enables fault injection (optional)
+	CheckEgress            bool          // report the network path and source addresses used to reach the storage
+	Database               string        // existing database where blobcheck creates its tables (optional)
+	DatabaseURL            string        // the database connection URL
+	DRClusterURL           string        // connection URL of the cluster taking over in a disaster recovery drill (optional)
+	DataSize               string        // size of the customer's data, used to scale the estimates (e.g. 500GiB)
+	Dataset                string        // optional CSV sample used to populate the source table
+	DatasetMaxBytes        int64         // maximum amount of data loaded from the dataset
+	DeleteVisibilityWindow time.Duration // time allowed for a deleted object to disappear from listings (0 to skip the check)
+	DestID                 string        // sub-path of the destination used by the run (optional, a random UUID by default)
+	Dial                   DialFunc      // dials through the configured proxy or tunnel (nil for direct connections)
+	DialTimeout            time.Duration // time to establish a connection to the storage (0 for no timeout)
+	EgressPrice            float64       // price per GB read from the storage provider
+	Endpoint               string        // the S3 endpoint
+	EndpointPrefix         string        // path prefix of the S3 API on the endpoint, for gateways that rewrite paths (optional)
+	ExecutionLocality      string        // locality filter restricting the nodes running the backups (optional)
+	ExternalIODir          string        // local path of the external IO directory of the node of nodelocal destinations (optional)
+	Force                  bool          // proceed when the sub-path of the run already has objects, leaving them in place
+	FullBackupInterval     time.Duration // interval between full backups in the customer's schedule
+	GapAnalysis            bool          // check every probed configuration from the nodes, and compare with the local probe
+	GCS                    bool          // the destination is a Google Cloud Storage bucket, selected by the gcs command
+	GCTTL                  time.Duration // GC TTL of the source table; enables revision history backups across a GC boundary
+	Guess                  bool          // Guess the URL parameters, no validation.
+	HeartbeatInterval      time.Duration // interval between progress messages for long running steps
+	IncrementalInterval    time.Duration // interval between incremental backups in the customer's schedule
+	IncrementalLocation    string        // sub-prefix of the destination storing the incremental backups (optional)
+	Interactive            bool          // ask the operator before probing each candidate configuration
+	LookupEnv              LookupEnv     // allows injection of environment variable lookup for testing
+	MetricsURLs            []string      // base URLs of the DB Console of the nodes, scraped during the full backup (optional)
+	MinBytes               int64         // size in bytes the initial workload grows the source table to (0 for --workload-duration)
+	MinFreeSpace           float64       // minimum fraction of free space required on every store
+	MinRows                int64         // number of rows the initial workload grows the source table to (0 for --workload-duration)
+	MinIOAdmin             bool          // query the MinIO admin API, which requires admin privileges
+	MultipartPartSize      int64         // size of the first part uploaded by the multipart probe, in bytes (0 for a single part)
+	NoProxy                string        // hosts reached without the proxy (default: NO_PROXY)
+	ObjectCount            int           // number of objects created to measure the listing time as it grows (0 to disable)
+	OnBackupStartCmd       string        // shell command run once the full backup job is running, e.g. to restart a node (optional)
+	OracleSample           float64       // fraction of the rows written by the workload verified in the restored table
+	OfflineAudit           bool          // block and report connections to hosts other than the configured endpoints
+	Path                   string        // the S3 bucket path
+	PauseWorkload          bool          // keep the workload running, and pause it during the incremental backup
+	ProbeKey               string        // base name of the objects written by the storage probes (optional)
+	ProbeMarker            string        // content of the objects written by the storage probes (optional)
+	ProbeCount             int           // number of objects written by the S3 probe (0 for one)
+	ProbePagination        bool          // write more objects than a list page holds to verify the list pagination
+	ProbeSize              int64         // size in bytes of the objects written by the S3 probe (0 for the marker only)
+	ProbeBackoff           time.Duration // maximum delay before a request of the S3 probes is retried (0 for the default of the SDK)
+	ProbeRetries           int           // number of retries of a request of the S3 probes after a transient failure (0 for a single attempt)
+	ProbeTimeout           time.Duration // time to complete the probe of a candidate configuration, retries included (0 for no timeout)
+	ProbeTag               string        // purpose attached as user metadata to the objects written by the storage probes (optional)
+	Proxy                  string        // HTTP proxy of the requests to the storage (default: HTTPS_PROXY or HTTP_PROXY)
+	RankCandidates         bool          // probe every candidate configuration and rank the working ones
+	Redact                 string        // redaction policy of the report: secrets (default) or full
+	RedactArtifact         string        // local file receiving the report redacted with the default policy, under full redaction
+	ResponseHeaderTimeout  time.Duration // time to receive the response headers from the storage (0 for no timeout)
+	RestoreCheckURL        string        // connection URL of a second cluster used to validate the restore (optional)
+	ReadVolume             string        // bytes read from the storage every month by restores and reads (e.g. 2TiB, optional)
+	Recording              io.Writer     // receives the trace of the storage operations (optional)
+	Retention              time.Duration // retention of the backups in the customer's schedule
+	Retries                int           // number of times the validation is re-run after a transient failure
+	Schema                 string        // schema where blobcheck creates its tables (optional)
+	SchemaChange           string        // online schema change run on the source table during the full backup (optional)
+	SessionTokenDuration   time.Duration // lifetime of the temporary credentials exchanged for the access keys (0 to use the keys)
+	SnippetsDir            string        // directory receiving the deployment snippets of the suggested URL (optional)
+	SOCKS5Proxy            string        // address of a SOCKS5 proxy used to reach the database and the storage (optional)
+	SSHHost                string        // SSH jump host used to reach the database and the storage (optional)
+	SSHKey                 string        // private key used to authenticate with the SSH jump host (optional)
+	Stdin                  io.Reader     // answers of the operator to the prompts of --interactive
+	Stdout                 io.Writer     // receives the prompts of --interactive
+	StoragePrice           float64       // price per GB-month of storage
+	Stream                 bool          // render the sections of the report as soon as they are final
+	Strict                 bool          // never suggest configurations skipping the TLS verification
+	StrictChecksum         bool          // like Strict, and never suggest configurations skipping the checksums
+	StrictTLS              bool          // fail the run if a connection does not meet the TLS policy
+	TCPKeepAlive           time.Duration // interval between TCP keepalive probes to the storage (negative to disable)
+	Tenant                 string        // virtual cluster to connect to (optional)
+	Testing                bool          // enables testing mode
+	TLSFIPS                bool          // require FIPS approved cipher suites
+	TLSHandshakeTimeout    time.Duration // time to complete the TLS handshake with the storage (0 for no timeout)
+	TLSMinVersion          string        // minimum TLS version required by the policy (e.g. 1.2)
+	URI                    string        // the S3 object URI (if not provided,will be constructed from Endpoint and Path)
+	Variants               []string      // parameter overrides, in query string form, compared with CHECK EXTERNAL CONNECTION
+	Verbose                bool          // enables verbose logging
+	VirtualHostDomain      string        // domain serving the buckets as sub-domains, probed with virtual-hosted requests (optional)
+	Workers                int           // number of concurrent workers
+	WorkloadDuration       time.Duration // duration to run the workload
+	WorkloadLocality       string        // locality filter of the nodes running the workload, isolated from the backup (optional)
+	WorkloadMaxDuration    time.Duration // cap on the initial workload growing the source table to --min-rows or --min-bytes
 }
@@ -15,11 +15,17 @@
 package format
 
 import (
+	"fmt"
 	"io"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
 	"github.com/cockroachlabs-field/blobcheck/internal/validate"
 )
 
@@ -36,8 +42,711 @@ func Report(w io.Writer, report *validate.Report) {
 		for k, v := range report.SuggestedParams.Iter() {
 			t.AppendRow(table.Row{k, v})
 		}
+		if l := report.ProbeLatency; l != nil {
+			caption := fmt.Sprintf("probe latency: list %s, put %s, get %s, delete %s",
+				l.List.Round(time.Millisecond), l.Put.Round(time.Millisecond),
+				l.Get.Round(time.Millisecond), l.Delete.Round(time.Millisecond))
+			if l.DeleteVisible > 0 {
+				caption += fmt.Sprintf(" (unlisted after %s)", l.DeleteVisible.Round(time.Millisecond))
+			}
+			if l.Upload > 0 || l.Download > 0 {
+				caption += fmt.Sprintf("; throughput: upload %s/s, download %s/s",
+					byteSize(int64(l.Upload)), byteSize(int64(l.Download)))
+			}
+			t.SetCaption("%s", caption)
+		}
+		t.Render()
+	}
+	if changes := report.ParamChanges(); len(changes) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Parameter Changes")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Parameter", "Provided", "Suggested"})
+		var added, changed int
+		for _, c := range changes {
+			suggested := orUnset(c.Suggested)
+			switch {
+			case c.Added():
+				suggested += " (added)"
+				added++
+			case c.Changed() && c.Suggested == "":
+				suggested = "(removed)"
+				changed++
+			case c.Changed():
+				suggested += " (changed)"
+				changed++
+			}
+			t.AppendRow(table.Row{c.Param, orUnset(c.Provided), suggested})
+		}
+		if added+changed > 0 {
+			t.SetCaption("%d added, %d changed or removed: update the provided parameters accordingly", added, changed)
+		} else {
+			t.SetCaption("the provided parameters work as is")
+		}
 		t.Render()
 	}
+	if report.SuggestedURL != "" {
+		// No borders, so that the statements can be copied as is.
+		plain := style
+		plain.Options.DrawBorder = false
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Backup Statements")
+		t.SetStyle(plain)
+		for _, stmt := range backupStatements(report.SuggestedURL) {
+			t.AppendRow(table.Row{stmt})
+		}
+		if strings.Contains(report.SuggestedURL, blob.Obfuscated) {
+			t.SetCaption("replace %s with the actual values before running the statements", blob.Obfuscated)
+		}
+		t.Render()
+	}
+	if c := report.Credentials; c != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Credentials")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Property", "Value"})
+		t.AppendRow(table.Row{"kind", c.Kind})
+		if s := c.Session; s != nil {
+			t.AppendRow(table.Row{"minted", "GetSessionToken, valid for " + s.Duration.String()})
+			t.AppendRow(table.Row{"expiration", s.Expiration.UTC().Format(time.RFC3339)})
+		}
+		switch {
+		case c.Kind == validate.CredentialsLongLived:
+			t.SetCaption("the access keys are stored in the external connection and do not expire: " +
+				"exchange them for temporary credentials per run with --session-token-duration, or use " +
+				blob.AuthParam + "=" + blob.AuthImplicit + " on the nodes")
+		case c.Session != nil:
+			t.SetCaption("the external connection stops working once the credentials expire: " +
+				"mint fresh credentials and recreate it before each backup, keeping the access keys out of the cluster")
+		}
+		t.Render()
+	}
+	if len(report.Limitations) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Storage Limitations")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Feature", "Limitation"})
+		for _, l := range report.Limitations {
+			t.AppendRow(table.Row{l.Feature, l.Detail})
+		}
+		t.Render()
+	}
+	if p := report.Probe; p != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Probe Objects")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Property", "Value"})
+		t.AppendRow(table.Row{"key", p.Key + "_*"})
+		t.AppendRow(table.Row{"content", p.Marker})
+		t.AppendRow(table.Row{"metadata " + blob.ProbeTagMetadata, orNone(p.Tag)})
+		if p.Size > 0 {
+			t.AppendRow(table.Row{"size", byteSize(p.Size)})
+		}
+		if p.Count > 1 {
+			t.AppendRow(table.Row{"count", p.Count})
+		}
+		t.SetCaption("written under the destination by the probes, and deleted afterwards")
+		t.Render()
+	}
+	if report.CustomCA != "" {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Custom CA")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Property", "Value"})
+		t.AppendRow(table.Row{"bundle", report.CustomCA})
+		t.AppendRow(table.Row{"cluster setting", blob.CustomCASetting})
+		t.SetCaption("set the cluster setting to the content of the bundle: the endpoint is not verified by the system roots")
+		t.Render()
+	}
+	if p := report.Proxy; p != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Proxy")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Property", "Value"})
+		t.AppendRow(table.Row{"proxy", p.Proxy})
+		if p.NoProxy != "" {
+			t.AppendRow(table.Row{"no proxy", p.NoProxy})
+		}
+		if p.Bypassed {
+			t.AppendRow(table.Row{"through the proxy", "fails"})
+			if p.Err != "" {
+				t.AppendRow(table.Row{"error", p.Err})
+			}
+			t.SetCaption("the requests fail through the proxy: add the host of the storage to NO_PROXY on the nodes")
+		} else {
+			t.AppendRow(table.Row{"through the proxy", "works"})
+			t.SetCaption("the storage is reachable through the proxy: set HTTPS_PROXY on the nodes")
+		}
+		t.Render()
+	}
+	if len(report.Capabilities) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Capabilities")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Operation", "Supported", "Latency", "Error"})
+		for _, c := range report.Capabilities {
+			supported, latency := "no", ""
+			if c.Supported {
+				supported, latency = "yes", c.Latency.Round(time.Millisecond).String()
+			}
+			t.AppendRow(table.Row{c.Operation, supported, latency, c.Err})
+		}
+		t.Render()
+	}
+	if len(report.Permissions) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Permissions")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Action", "Result", "Code", "Error"})
+		var denied []string
+		for _, p := range report.Permissions {
+			result := "failed"
+			switch {
+			case p.Allowed:
+				result = "allowed"
+			case p.Denied:
+				result = "denied"
+				denied = append(denied, p.Action)
+			}
+			t.AppendRow(table.Row{p.Action, result, p.Code, p.Err})
+		}
+		if len(denied) > 0 {
+			t.SetCaption("grant %s to the credentials", strings.Join(denied, ", "))
+		}
+		t.Render()
+	}
+	if len(report.Diagnoses) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Troubleshooting")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Flags", "Cause", "Error"})
+		for _, d := range report.Diagnoses {
+			var flags []string
+			for k, v := range d.Flags.Iter() {
+				flags = append(flags, k+"="+v)
+			}
+			t.AppendRow(table.Row{orDefaults(strings.Join(flags, ", ")), d.Cause.String(), d.Err})
+		}
+		if likely, ok := blob.LikelyCause(report.Diagnoses); ok {
+			if hint := likely.Hint(); hint != "" {
+				t.SetCaption("likely cause: %s; %s", likely.Cause, hint)
+			} else {
+				t.SetCaption("likely cause: %s", likely.Cause)
+			}
+		}
+		t.Render()
+	}
+	if len(report.KeyChecks) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Object Keys")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Characters", "Example", "Safe", "Error"})
+		for _, k := range report.KeyChecks {
+			safe := "no"
+			if k.Safe {
+				safe = "yes"
+			}
+			t.AppendRow(table.Row{k.Class, k.Example, safe, k.Err})
+		}
+		t.Render()
+	}
+	if a := report.Archival; a != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Archival Storage")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Check", "Result"})
+		t.AppendRow(table.Row{"new objects", a.StorageClass})
+		for _, r := range a.Rules {
+			t.AppendRow(table.Row{"lifecycle rule " + r.ID, fmt.Sprintf("%s after %d days", r.Class, r.Days)})
+		}
+		if a.Err != "" {
+			t.AppendRow(table.Row{"lifecycle rules", "unknown: " + a.Err})
+		}
+		t.AppendRow(table.Row{"forced transition", a.ForcedRead})
+		if a.Archived() {
+			t.SetCaption("restores fail or are delayed until the archived objects are retrieved")
+		}
+		t.Render()
+	}
+	if f := report.Failover; f != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Endpoint Failover")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Endpoint", "Suggested Configuration", "Put Latency", "Outage Survived", "Error"})
+		for i, c := range f.Endpoints {
+			result, latency, survived := "fails", "", "no"
+			if c.Works() {
+				result = "works"
+			}
+			if f.Survives(i) {
+				survived = "yes"
+			}
+			if c.Suggested {
+				result += " (found here)"
+			}
+			if c.Latency != nil {
+				latency = c.Latency.Put.Round(time.Millisecond).String()
+			}
+			t.AppendRow(table.Row{c.Endpoint, result, latency, survived, c.Err})
+		}
+		if f.Viable() {
+			t.SetCaption("DNS-based failover is viable: every endpoint accepts the suggested configuration")
+		} else {
+			t.SetCaption("DNS-based failover is not viable: backups fail while the requests reach a failing endpoint")
+		}
+		t.Render()
+	}
+	if vh := report.VirtualHost; vh != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Virtual-Hosted Addressing")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Check", "Result"})
+		t.AppendRow(table.Row{"domain", vh.Domain})
+		t.AppendRow(table.Row{"bucket host", vh.Host})
+		switch {
+		case vh.DNSErr != "":
+			t.AppendRow(table.Row{"DNS", "fails: " + vh.DNSErr})
+		case len(vh.Addresses) == 0:
+			t.AppendRow(table.Row{"DNS", "resolved by the proxy or tunnel"})
+		default:
+			t.AppendRow(table.Row{"DNS", strings.Join(vh.Addresses, ", ")})
+		}
+		if vh.DNSErr == "" {
+			switch {
+			case !vh.TLS:
+				t.AppendRow(table.Row{"certificate", "none (plain HTTP)"})
+			case vh.TLSErr != "":
+				t.AppendRow(table.Row{"certificate", "fails: " + vh.TLSErr})
+			case vh.Wildcard && vh.CustomCA:
+				t.AppendRow(table.Row{"certificate", "verified with the CA bundle (wildcard)"})
+			case vh.Wildcard:
+				t.AppendRow(table.Row{"certificate", "verified (wildcard)"})
+			case vh.CustomCA:
+				t.AppendRow(table.Row{"certificate", "verified with the CA bundle"})
+			default:
+				t.AppendRow(table.Row{"certificate", "verified"})
+			}
+			if len(vh.SANs) > 0 {
+				t.AppendRow(table.Row{"certificate names", strings.Join(vh.SANs, ", ")})
+			}
+			if vh.Latency != nil {
+				t.AppendRow(table.Row{"requests", "work (put " + vh.Latency.Put.Round(time.Millisecond).String() + ")"})
+			} else {
+				t.AppendRow(table.Row{"requests", "fail: " + vh.Err})
+			}
+		}
+		if vh.Works() {
+			t.SetCaption("the DNS records and the certificate of the domain support virtual-hosted requests")
+		} else {
+			t.SetCaption("virtual-hosted requests to the domain fail: keep %s=true", blob.UsePathStyleParam)
+		}
+		t.Render()
+	}
+	if im := report.Immutability; im != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Object Lock")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Check", "Result"})
+		if im.Lock.Err != "" {
+			t.AppendRow(table.Row{"configuration", "unknown: " + im.Lock.Err})
+		} else {
+			t.AppendRow(table.Row{"default mode", orNone(im.Lock.Mode)})
+			t.AppendRow(table.Row{"default retention", days(im.Lock.Retention)})
+			t.AppendRow(table.Row{"backup retention", days(im.BackupRetention)})
+			var notes []string
+			if im.ShortRetention() {
+				notes = append(notes, "backups are no longer protected from deletion before they expire")
+			}
+			if im.Lock.Retention > 0 {
+				notes = append(notes, "the cleanup skips the objects under retention")
+			}
+			t.SetCaption(strings.Join(notes, "; "))
+		}
+		t.Render()
+	}
+	if b := report.Bucket; b != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Bucket Capabilities")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Feature", "Configuration"})
+		if b.VersioningErr != "" {
+			t.AppendRow(table.Row{"versioning", "unknown: " + b.VersioningErr})
+		} else {
+			t.AppendRow(table.Row{"versioning", b.Versioning})
+		}
+		t.AppendRow(table.Row{"object lock", objectLock(b.ObjectLock)})
+		switch {
+		case b.LifecycleErr != "":
+			t.AppendRow(table.Row{"lifecycle rules", "unknown: " + b.LifecycleErr})
+		case len(b.Lifecycle) == 0:
+			t.AppendRow(table.Row{"lifecycle rules", "none"})
+		}
+		for _, r := range b.Lifecycle {
+			t.AppendRow(table.Row{"lifecycle rule " + r.ID, lifecycleRule(r)})
+		}
+		t.SetCaption("%s", strings.Join(b.Warnings(), "; "))
+		t.Render()
+	}
+	if p := report.Population; p != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Initial Workload")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Measure", "Target", "Actual"})
+		if p.MinRows > 0 {
+			t.AppendRow(table.Row{"rows", p.MinRows, p.Rows})
+		}
+		if p.MinBytes > 0 {
+			t.AppendRow(table.Row{"size", byteSize(p.MinBytes), byteSize(p.Bytes)})
+		}
+		t.AppendRow(table.Row{"duration", "max " + p.MaxTime.String(), p.Elapsed.Round(time.Second)})
+		if !p.Reached {
+			t.SetCaption("the target was not reached within %s: the results are not comparable with runs that reached it",
+				p.MaxTime)
+		}
+		t.Render()
+	}
+	if p := report.Pause; p != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Workload Pause")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Check", "Result"})
+		t.AppendRow(table.Row{"rows written between backups", p.Delta})
+		if p.Layer < 0 {
+			t.AppendRow(table.Row{"rows in incremental layer", "unknown"})
+		} else {
+			t.AppendRow(table.Row{"rows in incremental layer", p.Layer})
+		}
+		t.AppendRow(table.Row{"paused for", p.Paused.Round(time.Millisecond)})
+		switch {
+		case p.Layer < 0 || p.Match():
+		case p.Exact:
+			t.SetCaption("the incremental backup does not have the rows written since the full backup")
+		default:
+			t.SetCaption("the schema change rewrote the existing rows, which are also in the incremental layer")
+		}
+		t.Render()
+	}
+	if p := report.PartialRestore; p != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Partial Restore")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Check", "Result"})
+		t.AppendRow(table.Row{"database backed up", p.Database})
+		t.AppendRow(table.Row{"tables in backup", strings.Join(p.Backup, ", ")})
+		t.AppendRow(table.Row{"tables restored", strings.Join(p.Restored, ", ")})
+		if !p.OK() {
+			t.SetCaption("the restore of a single table brought back other tables of the backup")
+		}
+		t.Render()
+	}
+	if o := report.Oracle; o != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Restored Rows")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Check", "Result"})
+		t.AppendRow(table.Row{"rows verified", o.Checked})
+		t.AppendRow(table.Row{"missing", o.Missing})
+		t.AppendRow(table.Row{"different value", o.Mismatched})
+		if !o.OK() {
+			t.SetCaption("diverging keys: " + strings.Join(o.Examples, ", "))
+		}
+		t.Render()
+	}
+	if m := report.MinIO; m != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("MinIO Deployment")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Check", "Result"})
+		t.AppendRow(table.Row{"cluster health", orUnknown(m.Health)})
+		switch {
+		case m.AdminErr != "" && m.Servers == 0:
+			t.AppendRow(table.Row{"admin api", "unknown: " + m.AdminErr})
+		case m.Servers > 0:
+			t.AppendRow(table.Row{"version", strings.Join(m.Versions, ", ")})
+			t.AppendRow(table.Row{"mode", orUnknown(m.Mode)})
+			t.AppendRow(table.Row{"backend", orUnknown(m.Backend)})
+			t.AppendRow(table.Row{"servers online", fmt.Sprintf("%d/%d", m.Servers-m.Offline, m.Servers)})
+			t.AppendRow(table.Row{"drives online", fmt.Sprintf("%d/%d", m.Drives-m.Faulty, m.Drives)})
+			t.AppendRow(table.Row{"erasure sets", fmt.Sprintf("%d (parity EC:%d)", m.Sets, m.Parity)})
+			if m.AdminErr != "" {
+				t.AppendRow(table.Row{"bucket quota", "unknown: " + m.AdminErr})
+			} else if m.Quota > 0 {
+				t.AppendRow(table.Row{"bucket quota", fmt.Sprintf("%s (%s used)", byteSize(m.Quota), byteSize(m.Usage))})
+			} else {
+				t.AppendRow(table.Row{"bucket quota", "none"})
+			}
+		default:
+			t.AppendRow(table.Row{"admin api", "not queried (use --minio-admin)"})
+		}
+		if m.Degraded() {
+			t.SetCaption("the deployment is degraded: backups may fail or slow down while drives heal")
+		}
+		t.Render()
+	}
+	if len(report.Listing) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Listing Scalability")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Objects", "List Requests", "Latency"})
+		for _, l := range report.Listing {
+			t.AppendRow(table.Row{l.Objects, l.Pages, l.Latency.Round(time.Millisecond)})
+		}
+		if growth := blob.ListingGrowth(report.Listing); growth > 0 {
+			t.SetCaption("listing time grows by %s per 1000 objects", growth.Round(time.Millisecond))
+		}
+		t.Render()
+	}
+	if p := report.Pagination; p != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("List Pagination")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Check", "Result"})
+		t.AppendRow(table.Row{"objects written", p.Written})
+		t.AppendRow(table.Row{"listed", fmt.Sprintf("%d keys in %d pages", p.Listed, p.Pages)})
+		t.AppendRow(table.Row{"listed with delimiter",
+			fmt.Sprintf("%d keys, %d common prefixes", p.DelimitedKeys, p.Prefixes)})
+		if problems := p.Problems(); len(problems) > 0 {
+			t.SetCaption("%s: backups and restores may miss files", strings.Join(problems, "; "))
+		} else {
+			t.SetCaption("listings follow the continuation tokens and group the keys by delimiter")
+		}
+		t.Render()
+	}
+	if len(report.Candidates) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Candidate Configurations")
+		t.SetStyle(style)
+		// The throughput is measured only if the probe objects are sized.
+		sized := slices.ContainsFunc(report.Candidates, func(c blob.Candidate) bool { return c.Upload > 0 })
+		header := table.Row{"Rank", "Flags", "TLS Verify", "Checksums", "Security", "Latency"}
+		if sized {
+			header = append(header, "Upload", "Download")
+		}
+		t.AppendHeader(header)
+		for i, c := range report.Candidates {
+			var flags []string
+			for k, v := range c.Flags.Iter() {
+				flags = append(flags, k+"="+v)
+			}
+			row := table.Row{i + 1, orDefaults(strings.Join(flags, ", ")),
+				onOff(c.TLSVerify()), onOff(!c.Flags.Bool(blob.SkipChecksum)),
+				fmt.Sprintf("%d/%d", c.Security, blob.MaxSecurity), c.Latency.Round(time.Millisecond)}
+			if sized {
+				row = append(row, byteSize(int64(c.Upload))+"/s", byteSize(int64(c.Download))+"/s")
+			}
+			t.AppendRow(row)
+		}
+		t.Render()
+	}
+	if report.VirtualCluster != "" {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Cluster")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Virtual Cluster"})
+		t.AppendRow(table.Row{report.VirtualCluster})
+		t.SetCaption("external connections, jobs and results are scoped to the virtual cluster")
+		t.Render()
+	}
+	if len(report.ConnDiffs) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("External Connection Differences")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Connection", "Parameter", "Current", "Suggested"})
+		for _, d := range report.ConnDiffs {
+			t.AppendRow(table.Row{d.Connection, d.Param, orUnset(d.Current), orUnset(d.Suggested)})
+		}
+		t.Render()
+	}
+	if e := report.Exposure; e != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Credential Exposure")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Source", "Records", "Secrets In Clear", "Error"})
+		for _, c := range e.Checks {
+			exposed := "none"
+			if len(c.Exposed) > 0 {
+				exposed = strings.Join(c.Exposed, ", ")
+			} else if c.Err != "" {
+				exposed = "unknown"
+			}
+			t.AppendRow(table.Row{c.Source, c.Checked, exposed, c.Err})
+		}
+		if e.Exposed() {
+			t.SetCaption("SECURITY FINDING: the cluster shows the secrets of the external connection in clear; " +
+				"restrict the users allowed to run these statements, or upgrade to a version that redacts them")
+		} else {
+			t.SetCaption("the secrets are redacted in every record inspected")
+		}
+		t.Render()
+	}
+	if f := report.Failure; f != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Failure")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Step", "Class", "Error"})
+		t.AppendRow(table.Row{f.Step, f.Class, f.Err})
+		t.Render()
+	}
+	if c := report.Cleanup; c != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Cleanup")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Status", "Retained Objects", "Error"})
+		status := "verified"
+		if !c.Verified {
+			status = "NOT VERIFIED"
+		}
+		t.AppendRow(table.Row{status, c.Retained, c.Err})
+		if c.Retained > 0 {
+			t.SetCaption("locked objects, and objects found before the run, are left in the destination")
+		}
+		t.Render()
+	}
+	if a := report.Audit; a != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Offline Audit")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Network", "Address", "Connections", "Status"})
+		for _, c := range a.Connections {
+			status := "allowed"
+			if !c.Allowed {
+				status = "BLOCKED"
+			}
+			t.AppendRow(table.Row{c.Network, c.Address, c.Count, status})
+		}
+		verdict := "PASSED: no connections outside of the configured endpoints"
+		if !a.Passed() {
+			verdict = "FAILED: connections outside of the configured endpoints were attempted"
+		}
+		t.SetCaption("allowed hosts: %s\n%s", strings.Join(a.Allowed, ", "), verdict)
+		t.Render()
+	}
+	if e := report.Egress; e != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Network Path")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Endpoint", "Addresses", "Route", "VPC Endpoint", "Host VPC", "Host Public IP"})
+		t.AppendRow(table.Row{e.Endpoint, strings.Join(e.Addresses, ", "), e.Route, e.VPCEndpoint,
+			orUnknown(e.HostVPC), orUnknown(e.HostPublicIP)})
+		t.SetCaption("the host VPC and public IP are those of the blobcheck host, not of the nodes")
+		t.Render()
+		if len(e.Nodes) > 0 {
+			t := table.NewWriter()
+			t.SetOutputMirror(w)
+			t.SetTitle("Node Source Addresses")
+			t.SetStyle(style)
+			t.AppendHeader(table.Row{"Node", "Address", "Source"})
+			for _, n := range e.Nodes {
+				t.AppendRow(table.Row{n.Node, n.Address, n.Source})
+			}
+			t.Render()
+		}
+	}
+	if sc := report.SchemaChange; sc != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Schema Change")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Change", "Duration", "During Backup", "Result"})
+		during := "yes"
+		if !sc.Concurrent {
+			during = "no"
+		}
+		result := "OK"
+		if sc.Err != "" {
+			result = sc.Err
+		}
+		t.AppendRow(table.Row{sc.Kind, sc.Duration.Round(time.Millisecond), during, result})
+		t.Render()
+	}
+	if gc := report.GC; gc != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Garbage Collection")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"GC TTL", "Waited", "Status"})
+		status := "GC observed"
+		if !gc.Observed {
+			status = "GC TTL elapsed, GC not observed"
+		}
+		t.AppendRow(table.Row{gc.TTL, gc.Waited.Round(time.Second), status})
+		if !gc.Observed {
+			t.SetCaption("the incremental backup may not have run across a garbage collection boundary")
+		}
+		t.Render()
+	}
+	if h := report.Hook; h != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Backup Hook")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Check", "Result"})
+		during := "yes"
+		if !h.Concurrent {
+			during = "no, the backup completed before the job was observed"
+		}
+		completed := "yes"
+		if !h.BackupCompleted {
+			completed = "no"
+		}
+		t.AppendRow(table.Row{"command", h.Command})
+		t.AppendRow(table.Row{"during backup", during})
+		if h.Concurrent {
+			t.AppendRow(table.Row{"duration", h.Duration.Round(time.Millisecond)})
+			t.AppendRow(table.Row{"exit code", h.ExitCode})
+			t.AppendRow(table.Row{"output", orNone(h.Output)})
+		}
+		t.AppendRow(table.Row{"backup completed", completed})
+		t.Render()
+	}
+	if c := report.Chaos; c != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Fault Injection")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Latency", "Bandwidth", "Error Rate", "Requests", "Injected Errors", "Transferred"})
+		bandwidth := "unlimited"
+		if c.Faults.Bandwidth > 0 {
+			bandwidth = byteSize(int64(c.Faults.Bandwidth)) + "/s"
+		}
+		t.AppendRow(table.Row{c.Faults.Latency, bandwidth, fmt.Sprintf("%.1f%%", c.Faults.ErrorRate*100),
+			c.Stats.Requests, c.Stats.Injected, byteSize(c.Stats.Bytes)})
+		t.Render()
+	}
+	if len(report.TLS) > 0 {
+		TLS(w, report.TLS)
+	}
 	if report.Stats != nil {
 		t := table.NewWriter()
 		t.SetOutputMirror(w)
@@ -53,4 +762,473 @@ func Report(w io.Writer, report *validate.Report) {
 		}
 		t.Render()
 	}
+	if r := report.Reachability; r != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Node Reachability")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Node", "Locality", "Reachable", "Transferred", "Read Speed", "Write Speed", "Error"})
+		for _, s := range r.Stats {
+			reachable := "yes"
+			switch {
+			case !s.Success && r.Required(s):
+				reachable = "no"
+			case !s.Success:
+				reachable = "no (outside execution locality)"
+			}
+			t.AppendRow(table.Row{s.Node, orUnknown(s.Locality), reachable,
+				s.Transferred, s.ReadSpeed, s.WriteSpeed, s.ErrStr})
+		}
+		if nodes := r.Unreachable(); len(nodes) > 0 {
+			t.SetCaption("nodes %s cannot reach the storage", nodeList(nodes))
+		} else {
+			t.SetCaption("every node required to run the backups reaches the storage")
+		}
+		t.Render()
+	}
+	if len(report.Variants) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Parameter Variants")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Variant", "Nodes OK", "Read Speed", "Write Speed", "Status"})
+		for _, v := range report.Variants {
+			status := "OK"
+			if v.Err != "" {
+				status = v.Err
+			} else if len(v.Stats) == 0 {
+				status = "statistics not available"
+			}
+			ok := 0
+			for _, stat := range v.Stats {
+				if stat.Success {
+					ok++
+				}
+			}
+			read, write := v.Throughput()
+			t.AppendRow(table.Row{v.Name, fmt.Sprintf("%d/%d", ok, len(v.Stats)),
+				byteSize(int64(read)) + "/s", byteSize(int64(write)) + "/s", status})
+		}
+		t.Render()
+	}
+	if len(report.Gaps) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Configuration Gaps")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Flags", "Local", "Cluster", "Gap"})
+		var localOnly, clusterOnly int
+		for _, g := range report.Gaps {
+			var flags []string
+			for k, v := range g.Flags.Iter() {
+				flags = append(flags, k+"="+v)
+			}
+			local := "OK"
+			if !g.LocalOK() {
+				local = g.LocalErr
+			}
+			cluster := "OK"
+			if g.Err != "" {
+				cluster = g.Err
+			} else if failed := g.FailedNodes(); len(failed) > 0 {
+				cluster = "failed on nodes " + nodeList(failed)
+				for _, s := range g.Stats {
+					if !s.Success && s.ErrStr != "" {
+						cluster += ": " + s.ErrStr
+						break
+					}
+				}
+			}
+			switch g.Gap() {
+			case validate.GapLocalOnly:
+				localOnly++
+			case validate.GapClusterOnly:
+				clusterOnly++
+			}
+			t.AppendRow(table.Row{orDefaults(strings.Join(flags, ", ")), local, cluster, g.Gap()})
+		}
+		if localOnly+clusterOnly > 0 {
+			t.SetCaption("%d configurations work only locally, %d only from the nodes: "+
+				"the nodes reach the storage through another network path", localOnly, clusterOnly)
+		} else {
+			t.SetCaption("the local probe and the nodes agree on every configuration")
+		}
+		t.Render()
+	}
+	if iso := report.Isolation; iso != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Workload Isolation")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Role", "Locality", "Nodes"})
+		t.AppendRow(table.Row{"workload", iso.WorkloadLocality, nodeList(iso.WorkloadNodes)})
+		t.AppendRow(table.Row{"backup", iso.ExecutionLocality, nodeList(iso.BackupNodes)})
+		if iso.BackupTime > 0 {
+			t.SetCaption("full backup time: %s", iso.BackupTime.Round(time.Millisecond))
+		}
+		t.Render()
+	}
+	if l := report.Locality; l != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Execution Locality")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Node", "Locality", "Matches", "Check", "Data Files"})
+		for _, n := range l.Nodes {
+			matches := "no"
+			if n.Matches {
+				matches = "yes"
+			}
+			t.AppendRow(table.Row{n.Node, orUnknown(n.Locality), matches, orUnknown(n.Check), n.Files})
+		}
+		t.SetCaption("filter: %s", l.Filter)
+		t.Render()
+	}
+	if m := report.Manifests; m != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Backup Manifests")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Layer", "Type", "Spans", "Data Files", "Missing Files"})
+		for _, l := range m.Layers {
+			kind := "incremental"
+			if l.Full {
+				kind = "full"
+			}
+			files := fmt.Sprint(l.Files)
+			if l.External {
+				files += " (external)"
+			}
+			t.AppendRow(table.Row{l.Dir, kind, l.Spans, files, len(l.Unresolved)})
+		}
+		if len(m.Problems) > 0 {
+			t.SetCaption("%s", strings.Join(m.Problems, "\n"))
+		}
+		t.Render()
+	}
+	if m := report.Metrics; m != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Backup Metrics")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Node", "Bytes Written", "Bytes Read", "Retries", "Failures", "Status"})
+		for _, n := range m.Nodes {
+			if n.Err != "" {
+				t.AppendRow(table.Row{n.URL, "", "", "", "", n.Err})
+				continue
+			}
+			t.AppendRow(table.Row{n.URL, byteSize(n.BytesWritten), byteSize(n.BytesRead),
+				n.Retries, n.Failures, "OK"})
+		}
+		t.SetCaption("storage throughput: %s/s, sql throughput: %s/s",
+			byteSize(int64(m.StorageThroughput())), byteSize(int64(m.SQLThroughput())))
+		t.Render()
+	}
+	if report.CrossCluster != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Cross-Cluster Restore")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Version", "Restore Time", "Integrity"})
+		integrity := "OK"
+		if !report.CrossCluster.Matched {
+			integrity = "MISMATCH"
+		}
+		t.AppendRow(table.Row{report.CrossCluster.Version,
+			report.CrossCluster.RestoreTime.Round(time.Millisecond), integrity})
+		t.Render()
+		if drill := report.CrossCluster.Drill; drill != nil {
+			t := table.NewWriter()
+			t.SetOutputMirror(w)
+			t.SetTitle("Disaster Recovery Drill")
+			t.SetStyle(style)
+			t.AppendHeader(table.Row{"Backup Time", "RPO", "RTO", "Integrity"})
+			t.AppendRow(table.Row{drill.BackupTime.Round(time.Millisecond),
+				drill.RPO.Round(time.Millisecond), drill.RTO.Round(time.Millisecond), integrity})
+			t.Render()
+		}
+	}
+	if window := report.Window; window != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Backup Window")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Data Size", "Write Speed", "Full Backup", "Window", "Verdict"})
+		verdict := "fits window"
+		if !window.Fits() {
+			verdict = "does not fit window"
+		}
+		t.AppendRow(table.Row{byteSize(window.DataSize), byteSize(int64(window.Throughput)) + "/s",
+			window.Duration.Round(time.Second), window.Window, verdict})
+		t.Render()
+	}
+	if len(report.Schedules) > 0 {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Backup Schedules")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"ID", "Label", "Type", "Cadence", "Backup Duration", "Recommendation"})
+		for _, s := range report.Schedules {
+			kind := "full"
+			if s.Incremental {
+				kind = "incremental"
+			}
+			recommendation := "OK"
+			if s.Recommendation != "" {
+				recommendation = s.Recommendation
+			}
+			t.AppendRow(table.Row{s.ID, s.Label, kind, s.Cadence, s.Duration.Round(time.Second), recommendation})
+		}
+		t.Render()
+	}
+	if cost := report.Cost; cost != nil {
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("Monthly Cost Estimate")
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Full Backup", "Incremental Backup", "Stored", "Uploaded", "Read",
+			"Storage Cost", "Egress Cost", "Total"})
+		t.AppendRow(table.Row{byteSize(cost.FullSize), byteSize(cost.IncrementalSize), byteSize(cost.StoredBytes),
+			byteSize(cost.WrittenBytes), byteSize(cost.ReadBytes), price(cost.StorageCost), price(cost.EgressCost),
+			price(cost.Total())})
+		t.Render()
+	}
+}
+
+// TLS renders the TLS parameters negotiated with the endpoints, and the
+// certificates they presented.
+func TLS(w io.Writer, results []*validate.TLSResult) {
+	style := table.StyleLight
+	style.Format.Header = text.FormatLower
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.SetTitle("TLS")
+	t.SetStyle(style)
+	t.AppendHeader(table.Row{"Target", "Endpoint", "Version", "Cipher Suite", "Policy"})
+	for _, r := range results {
+		policy := "OK"
+		if !r.Compliant() {
+			policy = strings.Join(r.Violations, ", ")
+		}
+		version := r.Version
+		if version == "" {
+			version = "plaintext"
+		}
+		t.AppendRow(table.Row{r.Target, r.Endpoint, version, r.CipherSuite, policy})
+	}
+	t.Render()
+	for _, r := range results {
+		if len(r.Chain) == 0 {
+			continue
+		}
+		t := table.NewWriter()
+		t.SetOutputMirror(w)
+		t.SetTitle("TLS Certificates: " + r.Endpoint)
+		t.SetStyle(style)
+		t.AppendHeader(table.Row{"Certificate", "Subject", "Issuer", "Expires"})
+		for i, c := range r.Chain {
+			kind := "intermediate"
+			if i == 0 {
+				kind = "leaf"
+			}
+			t.AppendRow(table.Row{kind, c.Subject, c.Issuer, c.NotAfter.Format(time.DateOnly)})
+		}
+		roots := "no"
+		if r.SystemRoots {
+			roots = "yes"
+		}
+		t.AppendSeparator()
+		t.AppendRow(table.Row{"names", strings.Join(r.SANs, ", ")})
+		t.AppendRow(table.Row{"system roots", roots})
+		if len(r.Findings) > 0 {
+			t.SetCaption("%s", strings.Join(r.Findings, "\n"))
+		}
+		t.Render()
+	}
+}
+
+// Backups renders the layers of the backup collections found in a destination.
+func Backups(w io.Writer, layers []db.BackupLayer) {
+	style := table.StyleLight
+	style.Format.Header = text.FormatLower
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.SetTitle("Backups")
+	t.SetStyle(style)
+	t.AppendHeader(table.Row{"Collection", "Type", "End Time", "Tables", "Size", "Rows"})
+	for _, l := range layers {
+		kind := "incremental"
+		if l.Full {
+			kind = "full"
+		}
+		t.AppendRow(table.Row{l.Collection, kind, l.EndTime.UTC().Format(time.RFC3339),
+			l.Tables, byteSize(l.Size), l.Rows})
+	}
+	t.Render()
+}
+
+// Runs renders the blobcheck runs found in a destination, marking the ones
+// past the first keep as candidates for deletion.
+func Runs(w io.Writer, runs []blob.Run, keep int) {
+	style := table.StyleLight
+	style.Format.Header = text.FormatLower
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.SetTitle("Blobcheck Runs")
+	t.SetStyle(style)
+	t.AppendHeader(table.Row{"Run", "Last Modified", "Objects", "Size", "Action"})
+	for i, run := range runs {
+		action := "keep"
+		if i >= keep {
+			action = "delete"
+		}
+		t.AppendRow(table.Row{run.Prefix, run.LastModified.UTC().Format(time.RFC3339),
+			len(run.Objects), byteSize(run.Size), action})
+	}
+	t.Render()
+}
+
+// Pruned renders the outcome of pruning blobcheck runs.
+func Pruned(w io.Writer, res *blob.PruneResult) {
+	style := table.StyleLight
+	style.Format.Header = text.FormatLower
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.SetTitle("Pruned")
+	t.SetStyle(style)
+	t.AppendHeader(table.Row{"Runs", "Partially Pruned Runs", "Objects Deleted", "Objects Locked", "Reclaimed"})
+	t.AppendRow(table.Row{res.Runs, res.Partial, res.Deleted, res.Locked, byteSize(res.Reclaimed)})
+	if res.Partial > 0 {
+		t.SetCaption("the locked objects of the partially pruned runs are left in place until their retention expires")
+	}
+	t.Render()
+}
+
+// orUnset returns the value, or a placeholder if it is empty.
+// suggestedConnection is the name of the external connection in the
+// suggested statements.
+const suggestedConnection = "backup_storage"
+
+// backupStatements returns the statements that create an external
+// connection to the destination and take a full cluster backup into it.
+func backupStatements(dest string) []string {
+	return []string{
+		fmt.Sprintf("CREATE EXTERNAL CONNECTION %s AS '%s';",
+			suggestedConnection, strings.ReplaceAll(dest, "'", "''")),
+		fmt.Sprintf("BACKUP INTO 'external://%s' AS OF SYSTEM TIME '-10s';", suggestedConnection),
+	}
+}
+
+func orUnset(v string) string {
+	if v == "" {
+		return "(unset)"
+	}
+	return v
+}
+
+// orDefaults returns the value, or "(defaults)" if it is empty.
+func orDefaults(v string) string {
+	if v == "" {
+		return "(defaults)"
+	}
+	return v
+}
+
+// onOff returns "on" or "off".
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// orUnknown returns the value, or a placeholder if it is empty.
+func orNone(v string) string {
+	if v == "" {
+		return "none"
+	}
+	return v
+}
+
+// objectLock formats the object lock configuration of a bucket.
+func objectLock(l *blob.ObjectLock) string {
+	switch {
+	case l == nil:
+		return "unknown"
+	case l.Err != "":
+		return "unknown: " + l.Err
+	case !l.Enabled:
+		return "disabled"
+	case l.Retention > 0:
+		return fmt.Sprintf("enabled, %s for %s", l.Mode, days(l.Retention))
+	default:
+		return "enabled, no default retention"
+	}
+}
+
+// lifecycleRule formats the actions of a lifecycle rule.
+func lifecycleRule(r blob.LifecycleRule) string {
+	var actions []string
+	if r.Prefix != "" {
+		actions = append(actions, "prefix "+r.Prefix)
+	}
+	if r.ExpirationDays > 0 {
+		actions = append(actions, fmt.Sprintf("expire after %d days", r.ExpirationDays))
+	}
+	if !r.ExpirationDate.IsZero() {
+		actions = append(actions, "expire on "+r.ExpirationDate.Format(time.DateOnly))
+	}
+	for _, tr := range r.Transitions {
+		actions = append(actions, fmt.Sprintf("%s after %d days", tr.Class, tr.Days))
+	}
+	if r.NoncurrentDays > 0 {
+		actions = append(actions, fmt.Sprintf("expire noncurrent versions after %d days", r.NoncurrentDays))
+	}
+	if r.AbortDays > 0 {
+		actions = append(actions, fmt.Sprintf("abort incomplete uploads after %d days", r.AbortDays))
+	}
+	return strings.Join(actions, ", ")
+}
+
+// days formats a retention period as a number of days.
+func days(d time.Duration) string {
+	if d <= 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+}
+
+func orUnknown(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+	return v
+}
+
+// nodeList formats a list of node IDs.
+func nodeList(nodes []int) string {
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = fmt.Sprint(n)
+	}
+	return strings.Join(ids, ",")
+}
+
+// price formats a cost.
+func price(p float64) string {
+	return fmt.Sprintf("%.2f", p)
+}
+
+// byteSize formats a size in bytes using binary units.
+func byteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
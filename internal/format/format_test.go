@@ -16,40 +16,17 @@ package format
 
 import (
 	"bytes"
-	"fmt"
-	"os"
-	"path/filepath"
 	"testing"
+	"time"
 
-	"github.com/stretchr/testify/require"
-
+	"github.com/cockroachlabs-field/blobcheck/internal/audit"
 	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/chaos"
 	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/golden"
 	"github.com/cockroachlabs-field/blobcheck/internal/validate"
 )
 
-// Set this to true to rewrite the golden-output files.
-const rewriteFiles = false
-
-// Ensure that we can't merge this test if rewrite is true.
-func TestRewriteShouldBeFalse(t *testing.T) {
-	require.False(t, rewriteFiles)
-}
-
-func compareAgainstGoldenFile(filename string, got string, rewriteFiles bool) (bool, error) {
-	filePath := filepath.Join(".", "testdata", fmt.Sprintf("%s.txt", filename))
-	if rewriteFiles {
-		err := os.WriteFile(filePath, []byte(got), 0644)
-		if err != nil {
-			return false, err
-		}
-	}
-	expected, err := os.ReadFile(filePath)
-	if err != nil {
-		return false, err
-	}
-	return got == string(expected), nil
-}
 func TestReport(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -69,6 +46,33 @@ func TestReport(t *testing.T) {
 			},
 			goldenOutput: "no_stats",
 		},
+		{
+			name: "backup statements",
+			report: &validate.Report{
+				SuggestedURL: "s3://bucket/it's?AWS_ACCESS_KEY_ID=AKIA...&AWS_SECRET_ACCESS_KEY=******",
+			},
+			goldenOutput: "backup_statements",
+		},
+		{
+			name: "long-lived credentials",
+			report: &validate.Report{
+				Credentials: &validate.CredentialsResult{Kind: validate.CredentialsLongLived},
+			},
+			goldenOutput: "credentials_long_lived",
+		},
+		{
+			name: "session credentials",
+			report: &validate.Report{
+				Credentials: &validate.CredentialsResult{
+					Kind: validate.CredentialsTemporary,
+					Session: &blob.SessionToken{
+						Duration:   2 * time.Hour,
+						Expiration: time.Date(2025, 10, 16, 14, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+			goldenOutput: "credentials_session",
+		},
 		{
 			name: "one node",
 			report: &validate.Report{
@@ -112,15 +116,706 @@ func TestReport(t *testing.T) {
 				}},
 			goldenOutput: "two_nodes",
 		},
+		{
+			name: "cross cluster",
+			report: &validate.Report{
+				SuggestedParams: blob.Params{
+					blob.AccountParam:  "AKIA...",
+					blob.SecretParam:   blob.Obfuscated,
+					blob.RegionParam:   "us-west-2",
+					blob.EndPointParam: "https://s3.example.com",
+				},
+				CrossCluster: &validate.CrossClusterResult{
+					Version:     "v25.2.0",
+					RestoreTime: 1500 * time.Millisecond,
+					Matched:     true,
+				},
+			},
+			goldenOutput: "cross_cluster",
+		},
+		{
+			name: "dr drill",
+			report: &validate.Report{
+				CrossCluster: &validate.CrossClusterResult{
+					Version:     "v25.4.1",
+					RestoreTime: 2 * time.Second,
+					Matched:     true,
+					Drill: &validate.DrillResult{
+						BackupTime: 4 * time.Second,
+						RPO:        3 * time.Second,
+						RTO:        2500 * time.Millisecond,
+					},
+				},
+			},
+			goldenOutput: "dr_drill",
+		},
+		{
+			name: "cost",
+			report: &validate.Report{
+				Cost: &validate.CostEstimate{
+					FullSize:        500 << 30,
+					IncrementalSize: 2 << 30,
+					StoredBytes:     15500 << 30,
+					WrittenBytes:    16000 << 30,
+					ReadBytes:       546 << 30,
+					StorageCost:     383.68,
+					EgressCost:      52.76,
+				},
+			},
+			goldenOutput: "cost",
+		},
+		{
+			name: "backup window",
+			report: &validate.Report{
+				Window: &validate.WindowResult{
+					DataSize:   2 << 40,
+					Throughput: 200 << 20,
+					Duration:   10486 * time.Second,
+					Window:     2 * time.Hour,
+				},
+			},
+			goldenOutput: "backup_window",
+		},
+		{
+			name: "connection diffs",
+			report: &validate.Report{
+				SuggestedParams: blob.Params{
+					blob.AccountParam:  "AKIA...",
+					blob.SecretParam:   blob.Obfuscated,
+					blob.RegionParam:   "us-west-2",
+					blob.SkipChecksum:  "true",
+					blob.EndPointParam: "https://s3.example.com",
+				},
+				ConnDiffs: []validate.ParamDiff{
+					{Connection: "backups", Param: blob.RegionParam, Current: "us-east-1", Suggested: "us-west-2"},
+					{Connection: "backups", Param: blob.SkipChecksum, Suggested: "true"},
+				},
+			},
+			goldenOutput: "conn_diffs",
+		},
+		{
+			name: "schedules",
+			report: &validate.Report{
+				Schedules: []*validate.ScheduleLint{
+					{ID: 1, Label: "daily", Cadence: 24 * time.Hour, Duration: 2 * time.Hour},
+					{ID: 2, Label: "hourly", Incremental: true, Cadence: time.Hour, Duration: 90 * time.Minute,
+						Recommendation: "runs overlap: increase the interval to at least 1h31m0s, or reduce the amount of data per backup"},
+				},
+			},
+			goldenOutput: "schedules",
+		},
+		{
+			name: "failure",
+			report: &validate.Report{
+				SuggestedParams: blob.Params{
+					blob.AccountParam: "AKIA...",
+					blob.SecretParam:  blob.Obfuscated,
+					blob.RegionParam:  "us-west-2",
+				},
+				Failure: &validate.Failure{
+					Step:  "incremental backup",
+					Class: validate.Transient,
+					Err:   "failed to create incremental backup: SlowDown: please reduce your request rate",
+				},
+			},
+			goldenOutput: "failure",
+		},
+		{
+			name: "gc",
+			report: &validate.Report{
+				GC: &validate.GCResult{TTL: 30 * time.Second, Waited: 2*time.Minute + 40*time.Second},
+			},
+			goldenOutput: "gc",
+		},
+		{
+			name: "cleanup",
+			report: &validate.Report{
+				Cleanup: &validate.CleanupResult{
+					Retained: 3,
+					Err:      "cannot verify the cleanup of the destination: listing is not supported",
+				},
+			},
+			goldenOutput: "cleanup",
+		},
+		{
+			name: "chaos",
+			report: &validate.Report{
+				Chaos: &validate.ChaosResult{
+					Faults: chaos.Faults{Latency: 200 * time.Millisecond, Bandwidth: 10 << 20, ErrorRate: 0.05},
+					Stats:  chaos.Stats{Requests: 1240, Injected: 63, Bytes: 96 << 20},
+				},
+			},
+			goldenOutput: "chaos",
+		},
+		{
+			name: "manifests",
+			report: &validate.Report{
+				Manifests: &validate.ManifestResult{
+					Layers: []*validate.ManifestLayer{
+						{Dir: "2025/10/16-120000.00", Full: true, Spans: 1, Files: 12},
+						{Dir: "incrementals/2025/10/16-120000.00/20251016/120130.00", Spans: 1, Files: 3, External: true},
+					},
+				},
+			},
+			goldenOutput: "manifests",
+		},
+		{
+			name: "schema change",
+			report: &validate.Report{
+				SchemaChange: &validate.SchemaChangeResult{
+					Kind:       validate.SchemaChangeAddIndex,
+					Duration:   4321 * time.Millisecond,
+					Concurrent: true,
+				},
+			},
+			goldenOutput: "schema_change",
+		},
+		{
+			name: "egress",
+			report: &validate.Report{
+				Egress: &validate.EgressResult{
+					Endpoint:    "vpce-0a1b2c3d-4e5f6a7b.s3.us-east-1.vpce.amazonaws.com",
+					Addresses:   []string{"10.0.12.34", "10.0.44.12"},
+					Route:       validate.RoutePrivate,
+					VPCEndpoint: true,
+					HostVPC:     "vpc-0123456789abcdef0",
+					Nodes: []validate.NodeEgress{
+						{Node: 1, Address: "10.0.1.10:26257", Source: "10.0.1.10"},
+						{Node: 2, Address: "10.0.2.10:26257", Source: "10.0.2.10"},
+					},
+				},
+			},
+			goldenOutput: "egress",
+		},
+		{
+			name: "offline audit",
+			report: &validate.Report{
+				Audit: &audit.Attestation{
+					Allowed: []string{"10.0.1.10", "minio.internal"},
+					Connections: []audit.Connection{
+						{Network: "tcp", Address: "10.0.1.10:26257", Count: 6, Allowed: true},
+						{Network: "tcp", Address: "minio.internal:9000", Count: 3, Allowed: true},
+						{Network: "tcp", Address: "169.254.169.254:80", Count: 1},
+					},
+				},
+			},
+			goldenOutput: "offline_audit",
+		},
+		{
+			name: "tls",
+			report: &validate.Report{
+				TLS: []*validate.TLSResult{
+					{Target: "database", Endpoint: "10.0.1.10:26257", Version: "TLS 1.3",
+						CipherSuite: "TLS_AES_128_GCM_SHA256"},
+					{Target: "storage", Endpoint: "minio.internal:9000",
+						Violations: []string{"connection is not encrypted"}},
+				},
+			},
+			goldenOutput: "tls",
+		},
+		{
+			name: "tls certificates",
+			report: &validate.Report{
+				TLS: []*validate.TLSResult{
+					{Target: "storage", Endpoint: "minio.internal:9000", Version: "TLS 1.3",
+						CipherSuite: "TLS_AES_128_GCM_SHA256", Violations: []string{"certificate is not trusted"},
+						Chain: []validate.CertInfo{
+							{Subject: "CN=minio.internal", Issuer: "CN=Acme Issuing CA",
+								NotAfter: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+							{Subject: "CN=Acme Issuing CA", Issuer: "CN=Acme Root CA",
+								NotAfter: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+						},
+						SANs: []string{"minio.internal", "10.0.1.20"},
+						Findings: []string{"not verified by the system roots: x509: certificate signed by unknown authority",
+							"certificate expires in 15 days"}},
+				},
+			},
+			goldenOutput: "tls_certificates",
+		},
+		{
+			name: "candidates",
+			report: &validate.Report{
+				SuggestedParams: blob.Params{
+					blob.AccountParam:      "cockroach",
+					blob.SecretParam:       blob.Obfuscated,
+					blob.EndPointParam:     "https://minio.internal:9000",
+					blob.UsePathStyleParam: "true",
+				},
+				ProbeLatency: &blob.Latency{List: 12 * time.Millisecond, Put: 21 * time.Millisecond,
+					Get: 7 * time.Millisecond, Delete: 9 * time.Millisecond, DeleteVisible: 11 * time.Millisecond},
+				Candidates: []blob.Candidate{
+					{Flags: blob.Params{blob.UsePathStyleParam: "true"},
+						Security: 3, Latency: 42 * time.Millisecond},
+					{Flags: blob.Params{blob.UsePathStyleParam: "true", blob.SkipChecksum: "true"},
+						Security: 2, Latency: 38 * time.Millisecond},
+					{Flags: blob.Params{blob.UsePathStyleParam: "true", blob.SkipTLSVerify: "true"},
+						Security: 1, Latency: 40 * time.Millisecond},
+				},
+			},
+			goldenOutput: "candidates",
+		},
+		{
+			name: "probe throughput",
+			report: &validate.Report{
+				SuggestedParams: blob.Params{
+					blob.AccountParam:      "cockroach",
+					blob.SecretParam:       blob.Obfuscated,
+					blob.EndPointParam:     "https://minio.internal:9000",
+					blob.UsePathStyleParam: "true",
+				},
+				ProbeLatency: &blob.Latency{List: 12 * time.Millisecond, Put: 1600 * time.Millisecond,
+					Get: 800 * time.Millisecond, Delete: 9 * time.Millisecond,
+					Upload: 40 << 20, Download: 80 << 20},
+				Probe: &blob.ProbeObject{Key: "_blobcheck", Marker: "dummy_data", Size: 16 << 20, Count: 4},
+				Candidates: []blob.Candidate{
+					{Flags: blob.Params{blob.UsePathStyleParam: "true"},
+						Security: 3, Latency: 2400 * time.Millisecond, Upload: 40 << 20, Download: 80 << 20},
+					{Flags: blob.Params{blob.UsePathStyleParam: "true", blob.SkipChecksum: "true"},
+						Security: 2, Latency: 2100 * time.Millisecond, Upload: 48 << 20, Download: 80 << 20},
+				},
+			},
+			goldenOutput: "probe_throughput",
+		},
+		{
+			name: "bucket capabilities",
+			report: &validate.Report{
+				Bucket: &blob.BucketInventory{
+					Versioning: blob.VersioningEnabled,
+					ObjectLock: &blob.ObjectLock{Enabled: true, Mode: blob.LockGovernance, Retention: 30 * 24 * time.Hour},
+					Lifecycle: []blob.LifecycleRule{
+						{ID: "gc", Prefix: "backups/", ExpirationDays: 14, NoncurrentDays: 1, AbortDays: 2},
+						{ID: "cold", Transitions: []blob.Transition{{Class: "DEEP_ARCHIVE", Days: 90}}},
+					},
+				},
+			},
+			goldenOutput: "bucket_capabilities",
+		},
+		{
+			name: "param changes",
+			report: &validate.Report{
+				SuggestedParams: blob.Params{
+					blob.AccountParam:      "AKIA...",
+					blob.SecretParam:       blob.Obfuscated,
+					blob.RegionParam:       "eu-west-1",
+					blob.EndPointParam:     "https://s3.example.com",
+					blob.UsePathStyleParam: "true",
+				},
+				ProvidedParams: blob.Params{
+					blob.AccountParam:  "AKIA...",
+					blob.SecretParam:   blob.Obfuscated,
+					blob.EndPointParam: "https://s3.example.com",
+					blob.SkipChecksum:  "true",
+				},
+			},
+			goldenOutput: "param_changes",
+		},
+		{
+			name: "list pagination",
+			report: &validate.Report{
+				Pagination: &blob.Pagination{Written: 1060, Listed: 1000, Pages: 1, DelimitedKeys: 1000},
+			},
+			goldenOutput: "list_pagination",
+		},
+		{
+			name: "capabilities",
+			report: &validate.Report{
+				SuggestedParams: blob.Params{
+					blob.EndPointParam:     "https://s3.example.com",
+					blob.UsePathStyleParam: "true",
+				},
+				Capabilities: []blob.Capability{
+					{Operation: blob.CapList, Supported: true, Latency: 35 * time.Millisecond},
+					{Operation: blob.CapPut, Supported: true, Latency: 48 * time.Millisecond},
+					{Operation: blob.CapGet, Supported: true, Latency: 22 * time.Millisecond},
+					{Operation: blob.CapDelete, Supported: true, Latency: 19 * time.Millisecond},
+					{Operation: blob.CapMultipart, Err: "failed to create multipart upload: NotImplemented"},
+					{Operation: blob.CapRange, Supported: true, Latency: 41 * time.Millisecond},
+					{Operation: blob.CapOverwrite, Err: "stale content returned by 4 of 10 reads after the overwrite"},
+				},
+			},
+			goldenOutput: "capabilities",
+		},
+		{
+			name: "listing",
+			report: &validate.Report{
+				Listing: []blob.ListingSample{
+					{Objects: 100, Pages: 1, Latency: 40 * time.Millisecond},
+					{Objects: 1000, Pages: 1, Latency: 95 * time.Millisecond},
+					{Objects: 10000, Pages: 10, Latency: 1210 * time.Millisecond},
+				},
+			},
+			goldenOutput: "listing",
+		},
+		{
+			name: "archival",
+			report: &validate.Report{
+				Archival: &blob.Archival{
+					Rules:        []blob.ArchivalRule{{ID: "archive-old", Class: "GLACIER", Days: 30}},
+					StorageClass: "STANDARD",
+					ForcedRead:   "rejected until the object is restored",
+				},
+			},
+			goldenOutput: "archival",
+		},
+		{
+			name: "object lock",
+			report: &validate.Report{
+				Immutability: &validate.ImmutabilityResult{
+					Lock: &blob.ObjectLock{
+						Enabled:   true,
+						Mode:      blob.LockCompliance,
+						Retention: 7 * 24 * time.Hour,
+					},
+					BackupRetention: 30 * 24 * time.Hour,
+				},
+			},
+			goldenOutput: "object_lock",
+		},
+		{
+			name: "minio",
+			report: &validate.Report{
+				MinIO: &blob.MinIOInfo{
+					Health:   "healthy",
+					Versions: []string{"RELEASE.2025-04-22T22-12-26Z"},
+					Mode:     "online",
+					Backend:  "Erasure",
+					Servers:  4,
+					Offline:  1,
+					Drives:   16,
+					Faulty:   4,
+					Sets:     1,
+					Parity:   4,
+					Quota:    1 << 40,
+					Usage:    256 << 30,
+				},
+			},
+			goldenOutput: "minio",
+		},
+		{
+			name: "permissions",
+			report: &validate.Report{
+				Permissions: []blob.Permission{
+					{Action: blob.ActionListBucket, Allowed: true},
+					{Action: blob.ActionPutObject, Denied: true, Code: "AccessDenied", Err: "Access Denied"},
+					{Action: blob.ActionGetObject, Allowed: true, Code: "NoSuchKey"},
+					{Action: blob.ActionDeleteObject, Denied: true, Code: "AccessDenied", Err: "Access Denied"},
+					{Action: blob.ActionAbortMultipartUpload, Code: "NotImplemented",
+						Err: "A header you provided implies functionality that is not implemented"},
+				},
+			},
+			goldenOutput: "permissions",
+		},
+		{
+			name: "troubleshooting",
+			report: &validate.Report{
+				Diagnoses: []blob.Diagnosis{
+					{Flags: blob.Params{blob.SkipTLSVerify: "true"}, Cause: blob.CauseForbidden, Status: 403,
+						Err: "operation error S3: ListObjectsV2, https response error StatusCode: 403, api error AccessDenied"},
+					{Flags: blob.Params{}, Cause: blob.CauseTLS,
+						Err: "tls: failed to verify certificate: x509: certificate signed by unknown authority"},
+				},
+			},
+			goldenOutput: "troubleshooting",
+		},
+		{
+			name: "virtual-hosted addressing",
+			report: &validate.Report{
+				VirtualHost: &blob.VirtualHost{
+					Domain:    "s3.corp.example.com",
+					Host:      "backups.s3.corp.example.com:443",
+					Addresses: []string{"10.0.0.7", "10.0.0.8"},
+					TLS:       true,
+					SANs:      []string{"s3.corp.example.com", "*.s3.corp.example.com"},
+					Wildcard:  true,
+					Latency:   &blob.Latency{Put: 42 * time.Millisecond},
+				},
+			},
+			goldenOutput: "virtual_host",
+		},
+		{
+			name: "virtual-hosted addressing without certificate",
+			report: &validate.Report{
+				VirtualHost: &blob.VirtualHost{
+					Domain:    "s3.corp.example.com",
+					Host:      "backups.s3.corp.example.com:443",
+					Addresses: []string{"10.0.0.7"},
+					TLS:       true,
+					SANs:      []string{"s3.corp.example.com"},
+					TLSErr:    "x509: certificate is valid for s3.corp.example.com, not backups.s3.corp.example.com",
+					Err:       "failed to list objects: tls: failed to verify certificate",
+				},
+			},
+			goldenOutput: "virtual_host_certificate",
+		},
+		{
+			name: "initial workload",
+			report: &validate.Report{
+				Population: &validate.PopulationResult{
+					MinRows: 100000, MinBytes: 64 << 20, Rows: 104321, Bytes: 71 << 20,
+					Elapsed: 95 * time.Second, Reached: true, MaxTime: 30 * time.Minute,
+				},
+			},
+			goldenOutput: "initial_workload",
+		},
+		{
+			name: "initial workload capped",
+			report: &validate.Report{
+				Population: &validate.PopulationResult{
+					MinBytes: 1 << 30, Bytes: 310 << 20, Elapsed: 10 * time.Minute, MaxTime: 10 * time.Minute,
+				},
+			},
+			goldenOutput: "initial_workload_capped",
+		},
+		{
+			name: "workload pause",
+			report: &validate.Report{
+				Pause: &validate.PauseResult{Delta: 1200, Layer: 1150, Paused: 3456 * time.Millisecond, Exact: true},
+			},
+			goldenOutput: "workload_pause",
+		},
+		{
+			name: "partial restore",
+			report: &validate.Report{
+				PartialRestore: &validate.PartialRestoreResult{
+					Database: "_blobcheck",
+					Backup:   []string{"public.blobcheck_1a2b3c4d", "public.blobcheck_1a2b3c4d_companion"},
+					Restored: []string{"public.blobcheck_1a2b3c4d"},
+				},
+			},
+			goldenOutput: "partial_restore",
+		},
+		{
+			name: "restored rows",
+			report: &validate.Report{
+				Oracle: &validate.OracleResult{
+					Checked:    4096,
+					Missing:    2,
+					Mismatched: 1,
+					Examples: []string{"6f1c2b9e-1d4b-4c55-9a7e-0e4f3d2b1a90-17", "6f1c2b9e-1d4b-4c55-9a7e-0e4f3d2b1a90-18",
+						"d2a8e4c1-77b0-4f3e-8c2d-5b9a1e6f0c34-342"},
+				},
+			},
+			goldenOutput: "restored_rows",
+		},
+		{
+			name: "probe objects",
+			report: &validate.Report{
+				Probe: &blob.ProbeObject{Key: "_acme_dr_check", Marker: "acme_dr_check", Tag: "backup validation, see DR-1234"},
+			},
+			goldenOutput: "probe_objects",
+		},
+		{
+			name: "custom ca",
+			report: &validate.Report{
+				CustomCA: "/etc/blobcheck/minio-ca.pem",
+			},
+			goldenOutput: "custom_ca",
+		},
+		{
+			name: "proxy bypassed",
+			report: &validate.Report{
+				Proxy: &blob.ProxyCheck{
+					Proxy:    "http://proxy.corp.example.com:3128",
+					NoProxy:  "localhost,.corp.example.com",
+					Bypassed: true,
+					Err:      "failed to put object: Forbidden: TLS interception is not allowed",
+				},
+			},
+			goldenOutput: "proxy_bypassed",
+		},
+		{
+			name: "endpoint failover",
+			report: &validate.Report{
+				Failover: &blob.Failover{Endpoints: []blob.EndpointCheck{
+					{Endpoint: "https://10.0.1.10:9000", Suggested: true, Latency: &blob.Latency{Put: 12 * time.Millisecond}},
+					{Endpoint: "https://10.0.2.10:9000", Err: "failed to put object: SignatureDoesNotMatch"},
+				}},
+			},
+			goldenOutput: "endpoint_failover",
+		},
+		{
+			name: "backup hook",
+			report: &validate.Report{
+				Hook: &validate.HookResult{
+					Command:         "./drills/restart-node.sh 3",
+					Concurrent:      true,
+					Duration:        42 * time.Second,
+					Output:          "node 3 drained and restarted",
+					BackupCompleted: true,
+				},
+			},
+			goldenOutput: "backup_hook",
+		},
+		{
+			name: "limitations",
+			report: &validate.Report{
+				Limitations: blob.Limitations("backups--usw2-az1--x-s3"),
+			},
+			goldenOutput: "limitations",
+		},
+		{
+			name: "key checks",
+			report: &validate.Report{
+				KeyChecks: []blob.KeyCheck{
+					{Class: blob.KeysEncoded, Example: "encoded%2Fslash%20space", Safe: true},
+					{Class: blob.KeysPlus, Example: "2025-10-16+00:00",
+						Err: `key not listed as written: got ["_blobcheck_keys/2025-10-16 00:00"]`},
+					{Class: blob.KeysSpace, Example: "with space", Safe: true},
+					{Class: blob.KeysUnicode, Example: "clé-键-ключ", Safe: true},
+					{Class: blob.KeysSpecial, Example: "a=b,c;d@e&f$g!h'(i)*j:k", Safe: true},
+					{Class: blob.KeysLong, Example: "long-xxx… (1024 bytes)",
+						Err: "failed to put object: KeyTooLongError"},
+				},
+			},
+			goldenOutput: "key_checks",
+		},
+		{
+			name: "virtual cluster",
+			report: &validate.Report{
+				VirtualCluster: "app",
+				Stats: []*db.Stats{
+					{Node: 1, Success: true, ReadSpeed: "100MB/s", WriteSpeed: "50MB/s"},
+				},
+			},
+			goldenOutput: "virtual_cluster",
+		},
+		{
+			name: "variants",
+			report: &validate.Report{
+				Variants: []*validate.VariantResult{
+					{Name: "suggested", Stats: []*db.Stats{
+						{Node: 1, Success: true, ReadSpeed: "100MB/s", WriteSpeed: "50MB/s"},
+						{Node: 2, Success: true, ReadSpeed: "100MB/s", WriteSpeed: "50MB/s"},
+					}},
+					{Name: "AWS_USE_PATH_STYLE=false", Overrides: blob.Params{blob.UsePathStyleParam: "false"},
+						Stats: []*db.Stats{
+							{Node: 1, Success: true, ReadSpeed: "120MB/s", WriteSpeed: "60MB/s"},
+							{Node: 2, Success: false, ErrStr: "no such host"},
+						}},
+					{Name: "AWS_SKIP_CHECKSUM=true", Overrides: blob.Params{blob.SkipChecksum: "true"},
+						Err: "external connection failed"},
+				},
+			},
+			goldenOutput: "variants",
+		},
+		{
+			name: "configuration gaps",
+			report: &validate.Report{
+				Gaps: []*validate.GapResult{
+					{Flags: blob.Params{}, Stats: []*db.Stats{{Node: 1, Success: true}, {Node: 2, Success: true}}},
+					{Flags: blob.Params{blob.UsePathStyleParam: "true"}, Stats: []*db.Stats{
+						{Node: 1, Success: true},
+						{Node: 2, Success: false, ErrStr: "dial tcp: lookup s3.corp.example: no such host"},
+					}},
+					{Flags: blob.Params{blob.SkipTLSVerify: "true"},
+						LocalErr: "x509: certificate signed by unknown authority",
+						Stats:    []*db.Stats{{Node: 1, Success: true}, {Node: 2, Success: true}}},
+					{Flags: blob.Params{blob.ServerEncModeParam: "aws:kms"},
+						LocalErr: "api error NotImplemented", Err: "external connection failed: NotImplemented"},
+				},
+			},
+			goldenOutput: "configuration_gaps",
+		},
+		{
+			name: "node reachability",
+			report: &validate.Report{
+				Reachability: &validate.ReachabilityResult{
+					Filter: "region=us-east1",
+					Stats: []*db.Stats{
+						{Node: 1, Locality: "region=us-east1,zone=a", Success: true,
+							Transferred: "5.0 MiB", ReadSpeed: "100MB/s", WriteSpeed: "50MB/s"},
+						{Node: 2, Locality: "region=us-east1,zone=b",
+							ErrStr: "dial tcp 10.0.0.7:443: connect: connection refused"},
+						{Node: 3, Locality: "region=us-west1,zone=a", ErrStr: "dial tcp 10.0.0.7:443: i/o timeout"},
+					},
+				},
+			},
+			goldenOutput: "node_reachability",
+		},
+		{
+			name: "credential exposure",
+			report: &validate.Report{
+				Exposure: &validate.ExposureResult{Checks: []validate.ExposureCheck{
+					{Source: validate.ExposureConnections, Checked: 3},
+					{Source: validate.ExposureCreate, Err: `at or near "create": syntax error`},
+					{Source: validate.ExposureJobs, Checked: 4, Exposed: []string{"1101 (BACKUP)", "1102 (BACKUP)"}},
+				}},
+			},
+			goldenOutput: "credential_exposure",
+		},
+		{
+			name: "isolation",
+			report: &validate.Report{
+				Isolation: &validate.IsolationResult{
+					WorkloadLocality:  "region=us-east1",
+					WorkloadNodes:     []int{1, 2, 3},
+					ExecutionLocality: "region=us-west1",
+					BackupNodes:       []int{4, 5},
+					BackupTime:        12345 * time.Millisecond,
+				},
+			},
+			goldenOutput: "isolation",
+		},
+		{
+			name: "execution locality",
+			report: &validate.Report{
+				Locality: &validate.LocalityResult{
+					Filter: "region=us-west1",
+					Nodes: []*validate.LocalityNode{
+						{Node: 1, Locality: "region=us-east1,zone=a", Check: "dial tcp: i/o timeout"},
+						{Node: 2, Locality: "region=us-west1,zone=a", Matches: true, Check: "OK", Files: 12},
+						{Node: 3, Locality: "region=us-west1,zone=b", Matches: true, Check: "OK", Files: 9},
+					},
+				},
+			},
+			goldenOutput: "execution_locality",
+		},
+		{
+			name: "metrics",
+			report: &validate.Report{
+				Metrics: &validate.MetricsResult{
+					Nodes: []*validate.NodeMetrics{
+						{URL: "https://node1:8080", BytesWritten: 30 << 20, BytesRead: 1 << 10, Retries: 1},
+						{URL: "https://node2:8080", BytesWritten: 10 << 20},
+						{URL: "https://node3:8080", Err: "connection refused"},
+					},
+					BackupTime: 4 * time.Second,
+					BackupSize: 32 << 20,
+				},
+			},
+			goldenOutput: "metrics",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a := require.New(t)
 			w := &bytes.Buffer{}
 			Report(w, tt.report)
-			ok, err := compareAgainstGoldenFile(tt.goldenOutput, w.String(), rewriteFiles)
-			a.NoError(err)
-			a.True(ok)
+			golden.Assert(t, tt.goldenOutput+".txt", w.String())
 		})
 	}
 }
+
+func TestRuns(t *testing.T) {
+	end := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	runs := []blob.Run{
+		{Prefix: "8b1d7c2f-1e2a-4b7d-8c4f-3b7a1d2c3e4f", LastModified: end, Size: 3 << 20,
+			Objects: make([]blob.Object, 12)},
+		{Prefix: "5f0c6a9e-0d0f-4a6c-9a3e-2a6f0c1b2d3e", LastModified: end.Add(-24 * time.Hour), Size: 2048,
+			Objects: make([]blob.Object, 4)},
+	}
+	w := &bytes.Buffer{}
+	Runs(w, runs, 1)
+	Pruned(w, &blob.PruneResult{Runs: 1, Partial: 1, Deleted: 3, Locked: 1, Reclaimed: 1536})
+	golden.Assert(t, "prune.txt", w.String())
+}
+
+func TestBackups(t *testing.T) {
+	end := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	layers := []db.BackupLayer{
+		{Collection: "/2025/06/01-120000.00", Full: true, EndTime: end, Tables: 1, Size: 5 << 20, Rows: 10000},
+		{Collection: "/2025/06/01-120000.00", EndTime: end.Add(time.Minute), Tables: 1, Size: 512, Rows: 12},
+	}
+	w := &bytes.Buffer{}
+	Backups(w, layers)
+	golden.Assert(t, "backups.txt", w.String())
+}
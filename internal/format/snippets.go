@@ -0,0 +1,144 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+// Snippets render the suggested URL of the storage for deployment tooling,
+// by file name.
+var Snippets = map[string]func(io.Writer, string) error{
+	"backup_storage.tf":   Terraform,
+	"backup_storage.yaml": Kubernetes,
+}
+
+// snippetSecret is the name of the Kubernetes secret holding the URL of
+// the storage, in both snippets.
+const snippetSecret = "backup-storage"
+
+// errInvalidURL is returned for a suggested URL that cannot be parsed.
+var errInvalidURL = errors.New("invalid storage URL")
+
+// Terraform writes a Terraform configuration with the suggested URL of the
+// storage, whose secrets are sensitive variables, set in a Kubernetes
+// secret that the Kubernetes snippet reads.
+func Terraform(w io.Writer, dest string) error {
+	var secrets []string
+	dest = blob.MapURLParams(dest, func(key, value string) string {
+		if value != blob.Obfuscated {
+			return value
+		}
+		secrets = append(secrets, key)
+		return variablePlaceholder(key)
+	})
+	if dest == "" {
+		return errInvalidURL
+	}
+	slices.Sort(secrets)
+	uri := hclString.Replace(strconv.Quote(dest))
+	var b strings.Builder
+	b.WriteString("# Backup storage validated by blobcheck. Set the secrets with the TF_VAR_<name>\n")
+	b.WriteString("# environment variables, or in a tfvars file kept out of version control.\n")
+	for _, key := range secrets {
+		fmt.Fprintf(&b, "\nvariable %q {\n  type      = string\n  sensitive = true\n}\n", variableName(key))
+		uri = strings.ReplaceAll(uri, variablePlaceholder(key), "${urlencode(var."+variableName(key)+")}")
+	}
+	fmt.Fprintf(&b, "\nlocals {\n  backup_storage_uri = %s\n}\n", uri)
+	fmt.Fprintf(&b, "\nresource \"kubernetes_secret\" \"backup_storage\" {\n"+
+		"  metadata {\n    name = %q\n  }\n"+
+		"  data = {\n    uri = local.backup_storage_uri\n  }\n}\n", snippetSecret)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Kubernetes writes the manifests of a secret with the suggested URL of the
+// storage, and of a job creating the external connection of the backup
+// statements from the secret. The CrdbCluster resource of the operator has
+// no backup settings: the job runs the statements against the cluster, as
+// an operator would.
+func Kubernetes(w io.Writer, dest string) error {
+	dest = blob.ObfuscateURL(dest)
+	if dest == "" {
+		return errInvalidURL
+	}
+	var b strings.Builder
+	b.WriteString("# Backup storage validated by blobcheck. Set the connection URL of the cluster\n")
+	b.WriteString("# before applying the manifests.\n")
+	if strings.Contains(dest, blob.Obfuscated) {
+		fmt.Fprintf(&b, "# Replace %s with the actual values of the secrets.\n", blob.Obfuscated)
+	}
+	fmt.Fprintf(&b, `apiVersion: v1
+kind: Secret
+metadata:
+  name: %[1]s
+type: Opaque
+stringData:
+  uri: %[2]s
+  database-url: "postgresql://<user>@<cluster>-public:26257/defaultdb?sslmode=verify-full"
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: %[1]s-connection
+spec:
+  template:
+    spec:
+      restartPolicy: OnFailure
+      containers:
+        - name: create-external-connection
+          image: cockroachdb/cockroach
+          args:
+            - sql
+            - --url=$(DATABASE_URL)
+            - %[3]s
+          env:
+            - name: DATABASE_URL
+              valueFrom:
+                secretKeyRef:
+                  name: %[1]s
+                  key: database-url
+            - name: BACKUP_STORAGE_URI
+              valueFrom:
+                secretKeyRef:
+                  name: %[1]s
+                  key: uri
+`, snippetSecret, strconv.Quote(dest), strconv.Quote("--execute="+backupStatements("$(BACKUP_STORAGE_URI)")[0]))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// hclString escapes the template sequences of a quoted string, which is
+// otherwise quoted as in Go for the ASCII characters of a URL.
+var hclString = strings.NewReplacer("${", "$${", "%{", "%%{")
+
+// variableName returns the name of the Terraform variable of a secret
+// parameter.
+func variableName(key string) string {
+	return strings.ToLower(key)
+}
+
+// variablePlaceholder stands for the variable of a secret parameter in the
+// URL until it is quoted, and is not escaped in the URL.
+func variablePlaceholder(key string) string {
+	return "blobcheck_var_" + variableName(key)
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/golden"
+)
+
+func TestSnippets(t *testing.T) {
+	const dest = "s3://bucket/cluster1_backup?AWS_ACCESS_KEY_ID=AKIA...&AWS_ENDPOINT=https%3A%2F%2Fminio.example.com%3A9000" +
+		"&AWS_SECRET_ACCESS_KEY=******&AWS_USE_PATH_STYLE=true"
+	for name, snippet := range Snippets {
+		t.Run(name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			require.NoError(t, snippet(w, dest))
+			golden.Assert(t, name, w.String())
+		})
+	}
+}
+
+func TestTerraformEscapes(t *testing.T) {
+	assert.Equal(t, "$${a}%%{b}", hclString.Replace("${a}%{b}"))
+	w := &bytes.Buffer{}
+	require.NoError(t, Terraform(w, "s3://bucket/path?AWS_ACCESS_KEY_ID=id"))
+	assert.Contains(t, w.String(), `backup_storage_uri = "s3://bucket/path?AWS_ACCESS_KEY_ID=id"`)
+	assert.NotContains(t, w.String(), `variable "`)
+}
+
+func TestSnippetsInvalidURL(t *testing.T) {
+	for name, snippet := range Snippets {
+		assert.ErrorIs(t, snippet(&bytes.Buffer{}, "s3://bucket/?a=%zz"), errInvalidURL, name)
+	}
+}
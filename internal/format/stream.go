@@ -0,0 +1,263 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"io"
+	"sync"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/validate"
+)
+
+// streamedSection is a section of the report that is final as soon as its
+// data is set: part returns a report with only the data of the section, or
+// nil if it is not set yet, and clear removes the data from a report.
+type streamedSection struct {
+	part  func(r *validate.Report) *validate.Report
+	clear func(r *validate.Report)
+}
+
+// streamedSections are the sections rendered by a Stream as soon as they are
+// set. The others, such as the statistics of the fault injection proxy,
+// change until the end of the run.
+var streamedSections = []streamedSection{
+	{
+		part: func(r *validate.Report) *validate.Report {
+			if r.SuggestedParams == nil {
+				return nil
+			}
+			return &validate.Report{
+				SuggestedParams: r.SuggestedParams, SuggestedURL: r.SuggestedURL,
+				ProvidedParams: r.ProvidedParams, Credentials: r.Credentials, ProbeLatency: r.ProbeLatency,
+			}
+		},
+		clear: func(r *validate.Report) {
+			r.SuggestedParams, r.SuggestedURL, r.ProvidedParams, r.ProbeLatency = nil, "", nil, nil
+			r.Credentials = nil
+		},
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(len(r.Limitations) > 0, &validate.Report{Limitations: r.Limitations})
+		},
+		clear: func(r *validate.Report) { r.Limitations = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Probe != nil, &validate.Report{Probe: r.Probe})
+		},
+		clear: func(r *validate.Report) { r.Probe = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.CustomCA != "", &validate.Report{CustomCA: r.CustomCA})
+		},
+		clear: func(r *validate.Report) { r.CustomCA = "" },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Proxy != nil, &validate.Report{Proxy: r.Proxy})
+		},
+		clear: func(r *validate.Report) { r.Proxy = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(len(r.Candidates) > 0, &validate.Report{Candidates: r.Candidates})
+		},
+		clear: func(r *validate.Report) { r.Candidates = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.VirtualCluster != "", &validate.Report{VirtualCluster: r.VirtualCluster})
+		},
+		clear: func(r *validate.Report) { r.VirtualCluster = "" },
+	},
+	{
+		part:  func(r *validate.Report) *validate.Report { return partIf(len(r.TLS) > 0, &validate.Report{TLS: r.TLS}) },
+		clear: func(r *validate.Report) { r.TLS = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Stats != nil, &validate.Report{Stats: r.Stats})
+		},
+		clear: func(r *validate.Report) { r.Stats = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Reachability != nil, &validate.Report{Reachability: r.Reachability})
+		},
+		clear: func(r *validate.Report) { r.Reachability = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(len(r.Listing) > 0, &validate.Report{Listing: r.Listing})
+		},
+		clear: func(r *validate.Report) { r.Listing = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Pagination != nil, &validate.Report{Pagination: r.Pagination})
+		},
+		clear: func(r *validate.Report) { r.Pagination = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.VirtualHost != nil, &validate.Report{VirtualHost: r.VirtualHost})
+		},
+		clear: func(r *validate.Report) { r.VirtualHost = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Archival != nil, &validate.Report{Archival: r.Archival})
+		},
+		clear: func(r *validate.Report) { r.Archival = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Failover != nil, &validate.Report{Failover: r.Failover})
+		},
+		clear: func(r *validate.Report) { r.Failover = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Immutability != nil, &validate.Report{Immutability: r.Immutability})
+		},
+		clear: func(r *validate.Report) { r.Immutability = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Bucket != nil, &validate.Report{Bucket: r.Bucket})
+		},
+		clear: func(r *validate.Report) { r.Bucket = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.MinIO != nil, &validate.Report{MinIO: r.MinIO})
+		},
+		clear: func(r *validate.Report) { r.MinIO = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Population != nil, &validate.Report{Population: r.Population})
+		},
+		clear: func(r *validate.Report) { r.Population = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(len(r.Variants) > 0, &validate.Report{Variants: r.Variants})
+		},
+		clear: func(r *validate.Report) { r.Variants = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(len(r.Gaps) > 0, &validate.Report{Gaps: r.Gaps})
+		},
+		clear: func(r *validate.Report) { r.Gaps = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.SchemaChange != nil, &validate.Report{SchemaChange: r.SchemaChange})
+		},
+		clear: func(r *validate.Report) { r.SchemaChange = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Hook != nil, &validate.Report{Hook: r.Hook})
+		},
+		clear: func(r *validate.Report) { r.Hook = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.GC != nil, &validate.Report{GC: r.GC})
+		},
+		clear: func(r *validate.Report) { r.GC = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Manifests != nil, &validate.Report{Manifests: r.Manifests})
+		},
+		clear: func(r *validate.Report) { r.Manifests = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Locality != nil, &validate.Report{Locality: r.Locality})
+		},
+		clear: func(r *validate.Report) { r.Locality = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.Oracle != nil, &validate.Report{Oracle: r.Oracle})
+		},
+		clear: func(r *validate.Report) { r.Oracle = nil },
+	},
+	{
+		part: func(r *validate.Report) *validate.Report {
+			return partIf(r.PartialRestore != nil, &validate.Report{PartialRestore: r.PartialRestore})
+		},
+		clear: func(r *validate.Report) { r.PartialRestore = nil },
+	},
+}
+
+// partIf returns the part of the report if the section is set.
+func partIf(set bool, part *validate.Report) *validate.Report {
+	if !set {
+		return nil
+	}
+	return part
+}
+
+// Stream renders the sections of the report of a long run as soon as their
+// data is final, and the remaining sections once the run completes. It is
+// safe for concurrent use.
+type Stream struct {
+	mu       sync.Mutex
+	w        io.Writer
+	rendered []bool // whether each of the streamed sections was rendered
+}
+
+// NewStream returns a stream rendering the report to w.
+func NewStream(w io.Writer) *Stream {
+	return &Stream{w: w, rendered: make([]bool, len(streamedSections))}
+}
+
+// Update renders the sections of the partial report that were not rendered
+// yet and are final.
+func (s *Stream) Update(report *validate.Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, section := range streamedSections {
+		if s.rendered[i] {
+			continue
+		}
+		if part := section.part(report); part != nil {
+			Report(s.w, part)
+			s.rendered[i] = true
+		}
+	}
+}
+
+// Finish renders the sections of the complete report that were not
+// rendered yet.
+func (s *Stream) Finish(report *validate.Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rest := *report
+	for i, section := range streamedSections {
+		if s.rendered[i] {
+			section.clear(&rest)
+		}
+	}
+	Report(s.w, &rest)
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package format
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/validate"
+)
+
+func TestStream(t *testing.T) {
+	params := blob.Params{blob.RegionParam: "us-east-2"}
+	hook := &validate.HookResult{Command: "true", Concurrent: true, BackupCompleted: true}
+	failure := &validate.Failure{Step: "restore", Class: validate.Deterministic, Err: "boom"}
+
+	var got bytes.Buffer
+	stream := NewStream(&got)
+	stream.Update(&validate.Report{SuggestedParams: params})
+	stream.Update(&validate.Report{SuggestedParams: params, Hook: hook})
+	stream.Finish(&validate.Report{SuggestedParams: params, Hook: hook, Failure: failure})
+
+	// Each section is rendered once, in the order its data became final.
+	var want bytes.Buffer
+	Report(&want, &validate.Report{SuggestedParams: params})
+	Report(&want, &validate.Report{Hook: hook})
+	Report(&want, &validate.Report{Failure: failure})
+	require.Equal(t, want.String(), got.String())
+}
+
+func TestStreamConcurrent(t *testing.T) {
+	params := blob.Params{blob.RegionParam: "us-east-2"}
+	var got bytes.Buffer
+	stream := NewStream(&got)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() { stream.Update(&validate.Report{SuggestedParams: params}) })
+	}
+	wg.Wait()
+	stream.Finish(&validate.Report{SuggestedParams: params})
+
+	var want bytes.Buffer
+	Report(&want, &validate.Report{SuggestedParams: params})
+	require.Equal(t, want.String(), got.String())
+}
@@ -0,0 +1,20 @@
+# Backup storage validated by blobcheck. Set the secrets with the TF_VAR_<name>
+# environment variables, or in a tfvars file kept out of version control.
+
+variable "aws_secret_access_key" {
+  type      = string
+  sensitive = true
+}
+
+locals {
+  backup_storage_uri = "s3://bucket/cluster1_backup?AWS_ACCESS_KEY_ID=AKIA...&AWS_ENDPOINT=https%3A%2F%2Fminio.example.com%3A9000&AWS_SECRET_ACCESS_KEY=${urlencode(var.aws_secret_access_key)}&AWS_USE_PATH_STYLE=true"
+}
+
+resource "kubernetes_secret" "backup_storage" {
+  metadata {
+    name = "backup-storage"
+  }
+  data = {
+    uri = local.backup_storage_uri
+  }
+}
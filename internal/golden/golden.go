@@ -0,0 +1,58 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package golden compares the output of the report renderers with golden
+// files, so that every renderer shares the same fixture workflow.
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// RewriteEnv is the environment variable that, when set to true, rewrites
+// the golden files with the current output instead of comparing them:
+//
+//	BLOBCHECK_REWRITE_GOLDEN=true go test ./internal/format/
+const RewriteEnv = "BLOBCHECK_REWRITE_GOLDEN"
+
+// Assert compares got with the golden file of the given name, including
+// its extension, in the testdata directory of the package under test.
+func Assert(t testing.TB, name string, got string) {
+	t.Helper()
+	AssertFile(t, filepath.Join("testdata", name), got)
+}
+
+// AssertFile compares got with the golden file at the given path, or
+// rewrites the file if RewriteEnv is set.
+func AssertFile(t testing.TB, path string, got string) {
+	t.Helper()
+	if Rewrite() {
+		require.NoError(t, os.WriteFile(path, []byte(got), 0644))
+		t.Logf("rewrote %s", path)
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err, "set %s=true to create the golden file", RewriteEnv)
+	require.Equal(t, string(expected), got, "set %s=true to rewrite the golden file", RewriteEnv)
+}
+
+// Rewrite reports whether the golden files are rewritten.
+func Rewrite() bool {
+	rewrite, _ := strconv.ParseBool(os.Getenv(RewriteEnv))
+	return rewrite
+}
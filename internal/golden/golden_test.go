@@ -0,0 +1,67 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// failures records the failures of an assertion, instead of failing the
+// test.
+type failures struct {
+	testing.TB
+	failed bool
+}
+
+func (f *failures) Errorf(string, ...any) { f.failed = true }
+
+func (f *failures) FailNow() {
+	f.failed = true
+	runtime.Goexit()
+}
+
+func TestAssertFile(t *testing.T) {
+	r := require.New(t)
+	path := filepath.Join(t.TempDir(), "report.txt")
+
+	t.Setenv(RewriteEnv, "true")
+	r.True(Rewrite())
+	AssertFile(t, path, "hello\n")
+	got, err := os.ReadFile(path)
+	r.NoError(err)
+	r.Equal("hello\n", string(got))
+
+	t.Setenv(RewriteEnv, "")
+	r.False(Rewrite())
+	AssertFile(t, path, "hello\n")
+
+	// A mismatch fails the test, without rewriting the file.
+	mismatch := &failures{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		AssertFile(mismatch, path, "goodbye\n")
+	}()
+	<-done
+	r.True(mismatch.failed)
+	got, err = os.ReadFile(path)
+	r.NoError(err)
+	r.Equal("hello\n", string(got))
+}
@@ -0,0 +1,254 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package manifest parses the BACKUP_MANIFEST files written by CockroachDB
+// backups, reading only the fields needed to cross-check a backup collection
+// at the storage level, independently of SHOW BACKUP.
+package manifest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/cockroachdb/errors"
+)
+
+// FileName is the name of the manifest in the directory of each layer of a
+// backup collection.
+const FileName = "BACKUP_MANIFEST"
+
+// Field numbers of the backuppb.BackupManifest message, and of the messages
+// it references, that are read by the parser. Other fields are skipped.
+const (
+	manifestStartTime = 1
+	manifestEndTime   = 2
+	manifestSpans     = 3
+	manifestFiles     = 4
+
+	timestampWallTime = 1
+	timestampLogical  = 2
+
+	spanKey    = 3
+	spanEndKey = 4
+
+	fileSpan = 1
+	filePath = 2
+)
+
+// Protocol buffers wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Timestamp is a hybrid logical clock timestamp.
+type Timestamp struct {
+	WallTime int64
+	Logical  int32
+}
+
+// IsZero returns true if the timestamp is not set.
+func (t Timestamp) IsZero() bool {
+	return t == Timestamp{}
+}
+
+// Span is a range of keys, from Key (inclusive) to EndKey (exclusive).
+type Span struct {
+	Key, EndKey []byte
+}
+
+// Contains returns true if the span contains the other span.
+func (s Span) Contains(o Span) bool {
+	return bytes.Compare(s.Key, o.Key) <= 0 && bytes.Compare(o.EndKey, s.EndKey) <= 0
+}
+
+// File is a data file referenced by a manifest.
+type File struct {
+	Span Span
+	Path string // relative to the directory of the manifest
+}
+
+// Manifest contains the fields of a backup manifest used to check the
+// invariants of a backup collection.
+type Manifest struct {
+	StartTime Timestamp // zero for a full backup
+	EndTime   Timestamp
+	Spans     []Span
+	Files     []File
+}
+
+// Full returns true if the manifest describes a full backup.
+func (m *Manifest) Full() bool {
+	return m.StartTime.IsZero()
+}
+
+// Covers returns true if the spans of the manifest, once merged, contain
+// the given span.
+func (m *Manifest) Covers(s Span) bool {
+	for _, span := range merge(m.Spans) {
+		if span.Contains(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge returns the spans sorted by start key, with the overlapping and
+// adjacent spans merged.
+func merge(spans []Span) []Span {
+	sorted := append([]Span(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i].Key, sorted[j].Key) < 0 })
+	var res []Span
+	for _, s := range sorted {
+		if n := len(res); n > 0 && bytes.Compare(s.Key, res[n-1].EndKey) <= 0 {
+			if bytes.Compare(s.EndKey, res[n-1].EndKey) > 0 {
+				res[n-1].EndKey = s.EndKey
+			}
+			continue
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// Parse decodes a manifest. Manifests compressed with gzip, the default
+// since v21.1, are decompressed first. Encrypted manifests are not
+// supported.
+func Parse(data []byte) (*Manifest, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decompress manifest")
+		}
+		if data, err = io.ReadAll(r); err != nil {
+			return nil, errors.Wrap(err, "failed to decompress manifest")
+		}
+	}
+	m := &Manifest{}
+	err := walk(data, func(num int, value uint64, data []byte) error {
+		var err error
+		switch num {
+		case manifestStartTime:
+			m.StartTime, err = parseTimestamp(data)
+		case manifestEndTime:
+			m.EndTime, err = parseTimestamp(data)
+		case manifestSpans:
+			var s Span
+			s, err = parseSpan(data)
+			m.Spans = append(m.Spans, s)
+		case manifestFiles:
+			var f File
+			f, err = parseFile(data)
+			m.Files = append(m.Files, f)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid manifest")
+	}
+	return m, nil
+}
+
+func parseTimestamp(data []byte) (Timestamp, error) {
+	var t Timestamp
+	err := walk(data, func(num int, value uint64, _ []byte) error {
+		switch num {
+		case timestampWallTime:
+			t.WallTime = int64(value)
+		case timestampLogical:
+			t.Logical = int32(value)
+		}
+		return nil
+	})
+	return t, errors.Wrap(err, "timestamp")
+}
+
+func parseSpan(data []byte) (Span, error) {
+	var s Span
+	err := walk(data, func(num int, _ uint64, data []byte) error {
+		switch num {
+		case spanKey:
+			s.Key = data
+		case spanEndKey:
+			s.EndKey = data
+		}
+		return nil
+	})
+	return s, errors.Wrap(err, "span")
+}
+
+func parseFile(data []byte) (File, error) {
+	var f File
+	err := walk(data, func(num int, _ uint64, data []byte) error {
+		var err error
+		switch num {
+		case fileSpan:
+			f.Span, err = parseSpan(data)
+		case filePath:
+			f.Path = string(data)
+		}
+		return err
+	})
+	return f, errors.Wrap(err, "file")
+}
+
+// walk calls fn for each field of an encoded message, with the value of
+// numeric fields or the content of length-delimited fields.
+func walk(data []byte, fn func(num int, value uint64, data []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("truncated tag")
+		}
+		data = data[n:]
+		num, typ := int(tag>>3), tag&7
+		var value uint64
+		var content []byte
+		switch typ {
+		case wireVarint:
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errors.Newf("truncated field %d", num)
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errors.Newf("truncated field %d", num)
+			}
+			value, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errors.Newf("truncated field %d", num)
+			}
+			value, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errors.Newf("truncated field %d", num)
+			}
+			content, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return errors.Newf("unsupported wire type %d in field %d", typ, num)
+		}
+		if err := fn(num, value, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func varintField(num int, v uint64) []byte {
+	b := binary.AppendUvarint(nil, uint64(num)<<3|wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func bytesField(num int, content ...[]byte) []byte {
+	data := bytes.Join(content, nil)
+	b := binary.AppendUvarint(nil, uint64(num)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func encodeSpan(key, endKey string) []byte {
+	return append(bytesField(spanKey, []byte(key)), bytesField(spanEndKey, []byte(endKey))...)
+}
+
+func TestParse(t *testing.T) {
+	r := require.New(t)
+	data := bytes.Join([][]byte{
+		bytesField(manifestStartTime, varintField(timestampWallTime, 100), varintField(timestampLogical, 1)),
+		bytesField(manifestEndTime, varintField(timestampWallTime, 200)),
+		bytesField(manifestSpans, encodeSpan("a", "m")),
+		bytesField(manifestSpans, encodeSpan("m", "z")),
+		bytesField(manifestFiles, bytesField(fileSpan, encodeSpan("b", "c")), bytesField(filePath, []byte("data/1.sst"))),
+		// Unknown fields are skipped.
+		varintField(19, 1),
+		bytesField(17, []byte("ignored")),
+	}, nil)
+
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	_, err := w.Write(data)
+	r.NoError(err)
+	r.NoError(w.Close())
+
+	for name, in := range map[string][]byte{"plain": data, "gzip": compressed.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			m, err := Parse(in)
+			r.NoError(err)
+			r.Equal(Timestamp{WallTime: 100, Logical: 1}, m.StartTime)
+			r.Equal(Timestamp{WallTime: 200}, m.EndTime)
+			r.False(m.Full())
+			r.Len(m.Spans, 2)
+			r.Equal([]File{{Span: Span{Key: []byte("b"), EndKey: []byte("c")}, Path: "data/1.sst"}}, m.Files)
+			r.True(m.Covers(Span{Key: []byte("k"), EndKey: []byte("p")}))
+		})
+	}
+
+	_, err = Parse(data[:len(data)-3])
+	r.ErrorContains(err, "truncated")
+}
+
+func TestCovers(t *testing.T) {
+	a := assert.New(t)
+	m := &Manifest{Spans: []Span{
+		{Key: []byte("m"), EndKey: []byte("p")},
+		{Key: []byte("a"), EndKey: []byte("f")},
+		{Key: []byte("d"), EndKey: []byte("h")},
+	}}
+	a.True(m.Covers(Span{Key: []byte("b"), EndKey: []byte("g")}))
+	a.True(m.Covers(Span{Key: []byte("m"), EndKey: []byte("p")}))
+	a.False(m.Covers(Span{Key: []byte("g"), EndKey: []byte("n")}))
+	a.False(m.Covers(Span{Key: []byte("o"), EndKey: []byte("q")}))
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics scrapes the Prometheus endpoint exposed by the nodes of a
+// CockroachDB cluster, the same metrics charted by the DB Console.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// varsPath is the path of the Prometheus endpoint of a node.
+const varsPath = "/_status/vars"
+
+// Sample maps the name of a metric to its value, summed over all its label
+// sets.
+type Sample map[string]float64
+
+// Delta returns the change of a metric between two samples.
+func Delta(before, after Sample, name string) float64 {
+	return after[name] - before[name]
+}
+
+// Scrape retrieves the metrics of the node serving the DB Console at the
+// given base URL (e.g. https://node1:8080).
+func Scrape(ctx context.Context, client *http.Client, baseURL string) (Sample, error) {
+	u := strings.TrimSuffix(baseURL, "/") + varsPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid metrics URL %q", baseURL)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to scrape %s", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Newf("failed to scrape %s: %s", u, resp.Status)
+	}
+	return Parse(resp.Body)
+}
+
+// Parse reads metrics in the Prometheus text exposition format. Comments
+// are skipped, and the values of the label sets of a metric are added up.
+func Parse(r io.Reader) (Sample, error) {
+	res := make(Sample)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, rest, err := splitName(text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line)
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return nil, errors.Newf("line %d: missing value of %s", line, name)
+		}
+		// An optional timestamp may follow the value.
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: invalid value of %s", line, name)
+		}
+		res[name] += value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read metrics")
+	}
+	return res, nil
+}
+
+// splitName returns the name of the metric and the text following its
+// labels.
+func splitName(text string) (name, rest string, _ error) {
+	if i := strings.IndexByte(text, '{'); i >= 0 {
+		j := strings.LastIndexByte(text, '}')
+		if j < i {
+			return "", "", errors.Newf("unterminated labels in %q", text)
+		}
+		return text[:i], text[j+1:], nil
+	}
+	name, rest, _ = strings.Cut(text, " ")
+	return name, rest, nil
+}
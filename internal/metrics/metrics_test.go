@@ -0,0 +1,83 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const vars = `# HELP cloud_write_bytes Number of bytes written
+# TYPE cloud_write_bytes counter
+cloud_write_bytes{node_id="1"} 1024
+# HELP jobs_backup_resume_retry_error Number of backup jobs which failed with a retriable error
+# TYPE jobs_backup_resume_retry_error counter
+jobs_backup_resume_retry_error{node_id="1"} 2
+sql_exec_latency_bucket{node_id="1",le="1000"} 3
+sql_exec_latency_bucket{node_id="1",le="+Inf"} 4 1700000000000
+sys_uptime 12.5
+`
+
+func TestParse(t *testing.T) {
+	r := require.New(t)
+	got, err := Parse(strings.NewReader(vars))
+	r.NoError(err)
+	r.Equal(Sample{
+		"cloud_write_bytes":              1024,
+		"jobs_backup_resume_retry_error": 2,
+		"sql_exec_latency_bucket":        7,
+		"sys_uptime":                     12.5,
+	}, got)
+
+	for _, bad := range []string{"name", "name{a=\"b\" 1", "name abc"} {
+		_, err := Parse(strings.NewReader(bad))
+		r.Error(err, bad)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	a := assert.New(t)
+	before := Sample{"cloud_write_bytes": 1024}
+	after := Sample{"cloud_write_bytes": 4096, "cloud_read_bytes": 10}
+	a.Equal(3072.0, Delta(before, after, "cloud_write_bytes"))
+	a.Equal(10.0, Delta(before, after, "cloud_read_bytes"))
+	a.Equal(0.0, Delta(before, after, "missing"))
+}
+
+func TestScrape(t *testing.T) {
+	r := require.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != varsPath {
+			http.NotFound(w, req)
+			return
+		}
+		fmt.Fprint(w, vars)
+	}))
+	defer server.Close()
+
+	got, err := Scrape(context.Background(), server.Client(), server.URL+"/")
+	r.NoError(err)
+	r.Equal(1024.0, got["cloud_write_bytes"])
+
+	_, err = Scrape(context.Background(), server.Client(), server.URL+"/missing")
+	r.ErrorContains(err, "404")
+}
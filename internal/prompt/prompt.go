@@ -0,0 +1,60 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prompt asks the operator to confirm destructive or outward-facing
+// actions.
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Confirm writes the question to out and reads the answer from in. Only "y"
+// and "yes" (case insensitive) are accepted as confirmation.
+func Confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// Choose writes the question and the choices to out, and reads answers from
+// in until one of them is a choice, or its first letter. The first choice is
+// the default, returned for an empty answer or once the input ends, so it
+// should be the safe one.
+func Choose(in *bufio.Reader, out io.Writer, question string, choices ...string) string {
+	for {
+		fmt.Fprintf(out, "%s [%s] ", question, strings.Join(choices, "/"))
+		answer, err := in.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer == "" {
+			if err != nil {
+				fmt.Fprintln(out)
+			}
+			return choices[0]
+		}
+		for _, choice := range choices {
+			if answer == choice || answer == choice[:1] {
+				return choice
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(out)
+			return choices[0]
+		}
+	}
+}
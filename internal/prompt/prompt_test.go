@@ -0,0 +1,64 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{" yes ", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		out := &bytes.Buffer{}
+		assert.Equal(t, tt.want, Confirm(strings.NewReader(tt.answer), out, "proceed?"), tt.answer)
+		assert.Equal(t, "proceed? [y/N] ", out.String())
+	}
+}
+
+func TestChoose(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+		out    string
+	}{
+		{"probe\n", "probe", "next? [skip/probe/quit] "},
+		{"Q\n", "quit", "next? [skip/probe/quit] "},
+		{"\n", "skip", "next? [skip/probe/quit] "},
+		{"maybe\np\n", "probe", "next? [skip/probe/quit] next? [skip/probe/quit] "},
+		{"", "skip", "next? [skip/probe/quit] \n"},
+		{"maybe", "skip", "next? [skip/probe/quit] \n"},
+	}
+	for _, tt := range tests {
+		out := &bytes.Buffer{}
+		in := bufio.NewReader(strings.NewReader(tt.answer))
+		assert.Equal(t, tt.want, Choose(in, out, "next?", "skip", "probe", "quit"), tt.answer)
+		assert.Equal(t, tt.out, out.String(), tt.answer)
+	}
+}
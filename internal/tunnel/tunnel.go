@@ -0,0 +1,172 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tunnel routes the connections to the database and to the storage
+// provider through a SOCKS5 proxy or an SSH jump host.
+package tunnel
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/net/proxy"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+const defaultSSHPort = "22"
+
+// Open sets up the SOCKS5 proxy or the SSH tunnel configured in the
+// environment, and sets env.Dial so that the database and storage
+// connections go through it. The SSH tunnel, and the connection to the SSH
+// agent, if any, are closed when ctx is stopped.
+func Open(ctx *stopper.Context, env *env.Env) error {
+	switch {
+	case env.SOCKS5Proxy != "" && env.SSHHost != "":
+		return errors.New("a SOCKS5 proxy and an SSH tunnel cannot be used together")
+	case env.SOCKS5Proxy != "":
+		dialer, err := proxy.SOCKS5("tcp", env.SOCKS5Proxy, nil, proxy.Direct)
+		if err != nil {
+			return errors.Wrap(err, "failed to configure SOCKS5 proxy")
+		}
+		slog.Info("connecting through SOCKS5 proxy", slog.String("proxy", env.SOCKS5Proxy))
+		env.Dial = dialer.(proxy.ContextDialer).DialContext
+	case env.SSHHost != "":
+		client, err := dialSSH(env)
+		if err != nil {
+			return err
+		}
+		slog.Info("connecting through SSH tunnel", slog.String("host", env.SSHHost))
+		ctx.Go(func(ctx *stopper.Context) error {
+			<-ctx.Stopping()
+			return client.Close()
+		})
+		env.Dial = client.DialContext
+	}
+	return nil
+}
+
+// sshTunnel is the connection to the jump host, along with the connection
+// to the SSH agent that authenticated it, if any.
+type sshTunnel struct {
+	*ssh.Client
+	agent net.Conn
+}
+
+// Close closes the connection to the jump host and to the SSH agent.
+func (t *sshTunnel) Close() error {
+	err := t.Client.Close()
+	if t.agent != nil {
+		err = errors.CombineErrors(err, t.agent.Close())
+	}
+	return err
+}
+
+// splitHost parses a jump host in the form [user@]host[:port].
+func splitHost(spec string) (username, addr string, err error) {
+	username, host, ok := strings.Cut(spec, "@")
+	if !ok {
+		host = username
+		current, err := user.Current()
+		if err != nil {
+			return "", "", errors.Wrap(err, "failed to determine SSH user")
+		}
+		username = current.Username
+	}
+	if host == "" {
+		return "", "", errors.Newf("invalid SSH host %q", spec)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, defaultSSHPort)
+	}
+	return username, host, nil
+}
+
+// dialSSH connects to the jump host, authenticating with the configured
+// private key or, if none, with the keys of the running SSH agent. The host
+// key is verified against the user's known_hosts file.
+func dialSSH(env *env.Env) (_ *sshTunnel, err error) {
+	username, addr, err := splitHost(env.SSHHost)
+	if err != nil {
+		return nil, err
+	}
+	auth, agentConn, err := sshAuth(env)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil && agentConn != nil {
+			_ = agentConn.Close()
+		}
+	}()
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to locate known_hosts")
+	}
+	hostKeys, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, errors.WithHint(errors.Wrap(err, "failed to load known_hosts"),
+			"connect to the jump host with ssh once to record its host key")
+	}
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            username,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: hostKeys,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to SSH host %s", addr)
+	}
+	return &sshTunnel{Client: client, agent: agentConn}, nil
+}
+
+// sshAuth returns the authentication method for the jump host, and the
+// connection to the SSH agent it uses, if any, which the caller closes.
+func sshAuth(env *env.Env) (ssh.AuthMethod, net.Conn, error) {
+	if env.SSHKey != "" {
+		pem, err := os.ReadFile(env.SSHKey)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to read SSH key")
+		}
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to parse SSH key")
+		}
+		return ssh.PublicKeys(signer), nil, nil
+	}
+	sock, ok := env.LookupEnv("SSH_AUTH_SOCK")
+	if !ok {
+		return nil, nil, errors.New("set an SSH key or start an SSH agent to connect to the SSH host")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to connect to SSH agent")
+	}
+	return ssh.PublicKeysCallback(agent.NewClient(conn).Signers), conn, nil
+}
+
+// LookupHost returns the host unresolved, so that names are resolved at the
+// other end of the tunnel.
+func LookupHost(_ context.Context, host string) ([]string, error) {
+	return []string{host}, nil
+}
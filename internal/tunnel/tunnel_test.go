@@ -0,0 +1,93 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tunnel
+
+import (
+	"io"
+	"net"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestSplitHost(t *testing.T) {
+	a := assert.New(t)
+	username, addr, err := splitHost("ubuntu@jump.example.com")
+	a.NoError(err)
+	a.Equal("ubuntu", username)
+	a.Equal("jump.example.com:22", addr)
+
+	username, addr, err = splitHost("ubuntu@10.0.0.1:2222")
+	a.NoError(err)
+	a.Equal("ubuntu", username)
+	a.Equal("10.0.0.1:2222", addr)
+
+	current, err := user.Current()
+	require.NoError(t, err)
+	username, _, err = splitHost("jump.example.com")
+	a.NoError(err)
+	a.Equal(current.Username, username)
+
+	_, _, err = splitHost("ubuntu@")
+	a.Error(err)
+}
+
+func TestOpen(t *testing.T) {
+	a := assert.New(t)
+	ctx := stopper.WithContext(t.Context())
+	e := &env.Env{}
+	a.NoError(Open(ctx, e))
+	a.Nil(e.Dial)
+
+	e = &env.Env{SOCKS5Proxy: "localhost:1080"}
+	a.NoError(Open(ctx, e))
+	a.NotNil(e.Dial)
+
+	e = &env.Env{SOCKS5Proxy: "localhost:1080", SSHHost: "jump.example.com"}
+	a.Error(Open(ctx, e))
+}
+
+// TestSSHAgentClosed verifies that the connection to the SSH agent is
+// closed when the jump host cannot be reached.
+func TestSSHAgentClosed(t *testing.T) {
+	r := require.New(t)
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	r.NoError(err)
+	defer listener.Close()
+	// Without known_hosts, the connection fails before the handshake.
+	t.Setenv("HOME", t.TempDir())
+	_, err = dialSSH(&env.Env{
+		SSHHost: "user@127.0.0.1:1",
+		LookupEnv: func(key string) (string, bool) {
+			if key == "SSH_AUTH_SOCK" {
+				return sock, true
+			}
+			return "", false
+		},
+	})
+	r.ErrorContains(err, "known_hosts")
+	agent, err := listener.Accept()
+	r.NoError(err)
+	defer agent.Close()
+	_, err = agent.Read(make([]byte, 1))
+	r.ErrorIs(err, io.EOF)
+}
@@ -16,10 +16,15 @@ package validate
 
 import (
 	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/field-eng-powertools/stopper"
 	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/metrics"
 )
 
 // checkBackups verifies that there is exactly one full and one incremental backup.
@@ -43,6 +48,10 @@ func (v *Validator) checkBackups(ctx *stopper.Context, extConn *db.ExternalConn)
 	if err != nil {
 		return errors.Wrap(err, "failed to get backup info")
 	}
+	if len(info) > 0 {
+		// Backups are sorted by end time, most recent first.
+		v.latestEndTime = info[0].EndTime
+	}
 	if len(info) != expectedBackupCount {
 		return errors.Newf("expected exactly %d backups (1 full, 1 incremental), got %d backups", expectedBackupCount, len(info))
 	}
@@ -51,11 +60,15 @@ func (v *Validator) checkBackups(ctx *stopper.Context, extConn *db.ExternalConn)
 	for _, i := range info {
 		if i.Full {
 			fullCount++
+			v.fullBackupSize = i.Size
 		}
 	}
 	if fullCount != expectedFullBackupCount {
 		return errors.Newf("expected exactly %d full backup, got %d", expectedFullBackupCount, fullCount)
 	}
+	if v.paused != nil {
+		return v.checkIncrementalRows(info)
+	}
 	return nil
 }
 
@@ -68,7 +81,15 @@ func (v *Validator) performRestore(ctx *stopper.Context, extConn *db.ExternalCon
 	defer conn.Release()
 
 	slog.Info("restoring backup")
-	if err := v.restoredTable.Restore(ctx, conn, extConn, &v.sourceTable); err != nil {
+	eta := estimate("restore", v.tableSize(ctx, conn), v.readRate)
+	defer v.heartbeat(ctx, "restore", eta)()
+	if v.restoredTable.String() == v.sourceTable.String() {
+		// The table is restored alongside the source table: make room for it.
+		if err := v.sourceTable.Rename(ctx, conn, v.sourceTable.Name+"_source"); err != nil {
+			return errors.Wrap(err, "failed to rename source table")
+		}
+	}
+	if err := v.restoredTable.Restore(ctx, conn, extConn, &v.backedUp); err != nil {
 		return errors.Wrap(err, "failed to restore backup")
 	}
 	return nil
@@ -83,7 +104,22 @@ func (v *Validator) runFullBackup(ctx *stopper.Context, extConn *db.ExternalConn
 	defer conn.Release()
 
 	slog.Info("starting full backup")
-	if err := v.sourceTable.Backup(ctx, conn, extConn, false); err != nil {
+	eta := estimate("full backup", v.tableSize(ctx, conn)+v.expectedGrowth(), v.writeRate)
+	defer v.heartbeat(ctx, "full backup", eta)()
+	var before []metrics.Sample
+	if v.scraper != nil {
+		before, _ = v.scraper.scrape(ctx)
+	}
+	start := time.Now()
+	err = v.sourceTable.Backup(ctx, conn, extConn, v.backupOptions(false))
+	v.fullBackupTime = time.Since(start)
+	// The metrics are also reported if the backup fails, since retries and
+	// failures help diagnose the problem.
+	if v.scraper != nil {
+		after, errs := v.scraper.scrape(ctx)
+		v.metrics = v.scraper.compare(before, after, errs, v.fullBackupTime)
+	}
+	if err != nil {
 		return errors.Wrap(err, "failed to create full backup")
 	}
 	return nil
@@ -96,13 +132,112 @@ func (v *Validator) runIncrementalBackup(ctx *stopper.Context, extConn *db.Exter
 		return err
 	}
 	defer conn.Release()
+	if v.env.GCTTL > 0 {
+		if err := v.waitForGC(ctx, conn); err != nil {
+			return err
+		}
+	}
+	if v.paused != nil {
+		if err := v.pauseWorkload(ctx, conn, extConn); err != nil {
+			return err
+		}
+		defer v.resumeWorkload()
+	}
 	slog.Info("starting incremental backup")
-	if err := v.sourceTable.Backup(ctx, conn, extConn, true); err != nil {
+	eta := estimate("incremental backup", v.expectedGrowth(), v.writeRate)
+	defer v.heartbeat(ctx, "incremental backup", eta)()
+	start := time.Now()
+	defer func() { v.incrementalBackupTime = time.Since(start) }()
+	if err := v.sourceTable.Backup(ctx, conn, extConn, v.backupOptions(true)); err != nil {
+		if v.env.GCTTL > 0 && strings.Contains(err.Error(), "GC threshold") {
+			return errors.WithHint(errors.Wrap(err, "failed to create incremental backup across the GC boundary"),
+				"incremental backups with revision history must run more frequently than gc.ttlseconds, "+
+					"or use scheduled backups, which protect the data from garbage collection")
+		}
 		return errors.Wrap(err, "failed to create incremental backup")
 	}
 	return nil
 }
 
+// backupOptions returns the options for the full or incremental backup.
+func (v *Validator) backupOptions(incremental bool) db.BackupOptions {
+	return db.BackupOptions{
+		Incremental:       incremental,
+		RevisionHistory:   v.env.GCTTL > 0,
+		ExecutionLocality: v.env.ExecutionLocality,
+		Database:          v.env.BackupScope == ScopeDatabase,
+	}
+}
+
+// configureGC sets a short GC TTL on the source table, so that the
+// incremental backup runs across a garbage collection boundary.
+func (v *Validator) configureGC(ctx *stopper.Context) error {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	slog.Info("setting GC TTL on source table", slog.Duration("ttl", v.env.GCTTL))
+	if err := v.sourceTable.SetGCTTL(ctx, conn, v.env.GCTTL); err != nil {
+		return errors.Wrap(err, "failed to set GC TTL")
+	}
+	return nil
+}
+
+// GCResult tells whether the garbage collection of the source table ran
+// between the full and the incremental backup.
+type GCResult struct {
+	TTL      time.Duration
+	Waited   time.Duration // time between the full backup and the incremental backup
+	Observed bool          // whether reads from before the wait were rejected by the GC threshold
+}
+
+// waitForGC waits for the GC TTL of the source table to expire, so that the
+// data written before the full backup becomes eligible for garbage
+// collection before the incremental backup starts. It then polls reads from
+// before the wait, until they fail because the garbage collection ran. The
+// garbage collection of a range may be delayed well past the TTL, so the
+// polling gives up after gcPollTimeout, and the result only tells that the
+// TTL elapsed.
+func (v *Validator) waitForGC(ctx *stopper.Context, conn *pgxpool.Conn) error {
+	ts, err := db.ClusterTimestamp(ctx, conn)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the cluster timestamp")
+	}
+	start := time.Now()
+	v.gc = &GCResult{TTL: v.env.GCTTL}
+	defer func() { v.gc.Waited = time.Since(start) }()
+	wait := v.env.GCTTL + gcMargin
+	slog.Info("waiting for the GC TTL to expire before the incremental backup", slog.Duration("wait", wait))
+	select {
+	case <-time.After(wait):
+	case <-ctx.Stopping():
+		return ctx.Err()
+	}
+	deadline := time.Now().Add(gcPollTimeout)
+	for {
+		_, err := v.sourceTable.CountAt(ctx, conn, ts)
+		if errors.Is(err, db.ErrGarbageCollected) {
+			slog.Info("garbage collection observed on the source table")
+			v.gc.Observed = true
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to read the source table before the GC TTL")
+		}
+		if time.Now().After(deadline) {
+			slog.Warn("GC TTL elapsed, but the garbage collection was not observed on the source table",
+				slog.Duration("timeout", gcPollTimeout))
+			return nil
+		}
+		select {
+		case <-time.After(gcPollInterval):
+		case <-ctx.Stopping():
+			return ctx.Err()
+		}
+	}
+}
+
 // verifyIntegrity checks that the restored data matches the original.
 func (v *Validator) verifyIntegrity(ctx *stopper.Context) error {
 	conn, err := v.acquireConn(ctx)
@@ -112,7 +247,7 @@ func (v *Validator) verifyIntegrity(ctx *stopper.Context) error {
 	defer conn.Release()
 
 	slog.Info("checking integrity")
-	original, err := v.sourceTable.Fingerprint(ctx, conn)
+	original, err := v.sourceFingerprint(ctx, conn)
 	if err != nil {
 		return errors.Wrap(err, "failed to get original table fingerprint")
 	}
@@ -122,9 +257,53 @@ func (v *Validator) verifyIntegrity(ctx *stopper.Context) error {
 		return errors.Wrap(err, "failed to get restored table fingerprint")
 	}
 
+	if v.oracle != nil {
+		if v.oracleResult, err = v.checkOracle(ctx, conn); err != nil {
+			return errors.Wrap(err, "failed to read the restored rows")
+		}
+		if !v.oracleResult.OK() {
+			return errors.Newf("integrity check failed: %s", v.oracleResult)
+		}
+	}
+
 	if original != restore {
 		return errors.Errorf("integrity check failed: got %s, expected %s while comparing restored data with original",
 			restore, original)
 	}
 	return nil
 }
+
+// restoreIntoRemote restores the backup into the second cluster and compares
+// the restored data with the original.
+func (v *Validator) restoreIntoRemote(ctx *stopper.Context) (*CrossClusterResult, error) {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	original, err := v.sourceFingerprint(ctx, conn)
+	conn.Release()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get original table fingerprint")
+	}
+	if v.env.DRClusterURL != "" {
+		slog.Info("starting disaster recovery drill: restoring into the second cluster")
+	}
+	restoreStart := time.Now()
+	res, err := v.remote.restore(ctx, v.blobStorage, v.env.IncrementalLocation, &v.backedUp, original)
+	if err != nil {
+		return nil, err
+	}
+	if v.env.DRClusterURL == "" {
+		return res, nil
+	}
+	res.Drill = &DrillResult{
+		BackupTime: v.fullBackupTime + v.incrementalBackupTime,
+		RTO:        time.Since(restoreStart),
+	}
+	if !v.latestEndTime.IsZero() {
+		res.Drill.RPO = restoreStart.Sub(v.latestEndTime)
+	}
+	slog.Info("disaster recovery drill completed",
+		slog.Duration("rpo", res.Drill.RPO), slog.Duration("rto", res.Drill.RTO))
+	return res, nil
+}
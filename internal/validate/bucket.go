@@ -0,0 +1,42 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+// InventoryBucket reads the versioning, object lock and lifecycle
+// configuration of the bucket, and logs the features that can break
+// backups. It returns nil if the storage provider does not report them.
+func InventoryBucket(ctx context.Context, store blob.Storage) (*blob.BucketInventory, error) {
+	reporter, ok := store.(blob.BucketInventoryReporter)
+	if !ok {
+		return nil, nil
+	}
+	res, err := reporter.BucketInventory(ctx)
+	if err != nil || res == nil {
+		return nil, err
+	}
+	slog.Info("bucket capabilities", slog.String("versioning", res.Versioning),
+		slog.Int("lifecycle_rules", len(res.Lifecycle)))
+	for _, warning := range res.Warnings() {
+		slog.Warn(warning)
+	}
+	return res, nil
+}
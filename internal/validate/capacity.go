@@ -0,0 +1,124 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// defaultReplicas is the number of replicas of the tables created by the
+// validation, assuming the default replication factor of CockroachDB.
+const defaultReplicas = 3
+
+// expectedSize returns the size of the source table the validation
+// generates: the target of the initial workload, or the data loaded from the
+// dataset. It returns 0 if the size only depends on the throughput of the
+// workload, which is not known before the run.
+func expectedSize(env *env.Env) int64 {
+	size := env.MinBytes
+	if env.Dataset != "" {
+		size = max(size, env.DatasetMaxBytes)
+	}
+	return size
+}
+
+// storeNeed returns the free space each store needs: minFree of its
+// capacity, left after storing its share of the replicas of the given
+// bytes, spread evenly across the stores.
+func storeNeed(s db.StoreCapacity, stores int, minFree float64, bytes int64) int64 {
+	share := bytes * int64(min(defaultReplicas, stores)) / int64(stores)
+	return int64(minFree*float64(s.Capacity)) + share
+}
+
+// lowCapacity returns the stores whose free space is below storeNeed, when
+// the cluster stores the given bytes.
+func lowCapacity(stores []db.StoreCapacity, minFree float64, bytes int64) []db.StoreCapacity {
+	var res []db.StoreCapacity
+	for _, s := range stores {
+		if s.Capacity <= 0 || s.Available < storeNeed(s, len(stores), minFree, bytes) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// checkCapacity verifies that every store in the cluster can hold its share
+// of the data written by the validation, the source table of the given size
+// and its restored copy, and still have at least minFree of its capacity
+// available, so that the validation does not fill up a nearly full cluster.
+// The check is best effort: if the capacity cannot be read (e.g. missing
+// privileges), a warning is logged and the run proceeds.
+func checkCapacity(ctx *stopper.Context, conn *pgxpool.Conn, minFree float64, size int64) error {
+	if minFree <= 0 {
+		return nil
+	}
+	stores, err := db.StoresCapacity(ctx, conn)
+	if err != nil {
+		slog.Warn("unable to check cluster capacity", slog.Any("error", err))
+		return nil
+	}
+	// The source table is restored into the same cluster.
+	written := 2 * size
+	low := lowCapacity(stores, minFree, written)
+	if len(low) == 0 {
+		return nil
+	}
+	details := make([]string, 0, len(low))
+	for _, s := range low {
+		details = append(details, fmt.Sprintf("node %d store %d: %d bytes available, %d needed",
+			s.Node, s.Store, s.Available, storeNeed(s, len(stores), minFree, written)))
+	}
+	return errors.Newf("insufficient free space in the cluster to write %d bytes (minimum %.1f%% left free): %s",
+		written, minFree*100, strings.Join(details, ", "))
+}
+
+// checkBucketQuota verifies that the quota of the bucket, if any, can hold
+// the backups of the source table of the given size, and still have at
+// least minFree of its capacity available. Only MinIO exposes the quota of
+// a bucket, through its admin API, so the check is skipped for the other
+// providers, and when the admin API is not enabled or cannot be queried.
+func checkBucketQuota(ctx context.Context, store blob.Storage, minFree float64, size int64) error {
+	if minFree <= 0 {
+		return nil
+	}
+	info, err := CheckMinIO(ctx, store)
+	if err != nil {
+		slog.Warn("unable to check the bucket quota", slog.Any("error", err))
+		return nil
+	}
+	if info == nil || info.Quota == 0 {
+		if info != nil && info.AdminErr != "" {
+			slog.Warn("unable to check the bucket quota", slog.String("error", info.AdminErr))
+		}
+		return nil
+	}
+	if info.Quota-info.Usage >= int64(minFree*float64(info.Quota))+size {
+		return nil
+	}
+	return errors.Newf("insufficient free space in the quota of bucket %s to write %d bytes (minimum %.1f%% left free): "+
+		"%.1f%% available, %d of %d bytes used",
+		store.BucketName(), size, minFree*100, info.FreeFraction()*100, info.Usage, info.Quota)
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestLowCapacity(t *testing.T) {
+	stores := []db.StoreCapacity{
+		{Node: 1, Store: 1, Capacity: 100, Available: 50},
+		{Node: 2, Store: 2, Capacity: 100, Available: 5},
+		{Node: 3, Store: 3, Capacity: 0, Available: 0},
+	}
+	a := assert.New(t)
+	a.Equal([]db.StoreCapacity{stores[1], stores[2]}, lowCapacity(stores, 0.1, 0))
+	a.Equal([]db.StoreCapacity{stores[1], stores[2]}, lowCapacity(stores, 0.5, 0))
+	a.Equal(stores, lowCapacity(stores, 0.6, 0))
+	a.Empty(lowCapacity(stores[:1], 0.1, 0))
+	// Each of the three stores holds a replica of the data written.
+	a.Equal([]db.StoreCapacity{stores[1], stores[2]}, lowCapacity(stores, 0.1, 40))
+	a.Equal(stores, lowCapacity(stores, 0.1, 41))
+	// A single store holds the only replica.
+	a.Empty(lowCapacity(stores[:1], 0.1, 40))
+	a.Equal(stores[:1], lowCapacity(stores[:1], 0.1, 41))
+}
+
+func TestExpectedSize(t *testing.T) {
+	a := assert.New(t)
+	a.Zero(expectedSize(&env.Env{DatasetMaxBytes: 1 << 30}))
+	a.Equal(int64(100), expectedSize(&env.Env{MinBytes: 100, DatasetMaxBytes: 1 << 30}))
+	a.Equal(int64(1<<30), expectedSize(&env.Env{MinBytes: 100, Dataset: "sample.csv", DatasetMaxBytes: 1 << 30}))
+}
+
+// quotaStorage is a MinIO store reporting a fixed deployment.
+type quotaStorage struct {
+	blob.Storage
+	info *blob.MinIOInfo
+}
+
+func (s *quotaStorage) BucketName() string { return "bucket" }
+
+func (s *quotaStorage) MinIO(context.Context) (*blob.MinIOInfo, error) { return s.info, nil }
+
+func TestCheckBucketQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   blob.Storage
+		size    int64
+		wantErr string
+	}{
+		{name: "not minio", store: struct{ blob.Storage }{}},
+		{name: "no admin api", store: &quotaStorage{info: &blob.MinIOInfo{Health: "healthy"}}},
+		{name: "admin error", store: &quotaStorage{info: &blob.MinIOInfo{AdminErr: "403 Forbidden"}}},
+		{name: "no quota", store: &quotaStorage{info: &blob.MinIOInfo{}}},
+		{name: "enough space", store: &quotaStorage{info: &blob.MinIOInfo{Quota: 100, Usage: 50}}},
+		{
+			name:    "nearly full",
+			store:   &quotaStorage{info: &blob.MinIOInfo{Quota: 100, Usage: 95}},
+			wantErr: "insufficient free space in the quota of bucket bucket to write 0 bytes (minimum 10.0% left free): 5.0% available, 95 of 100 bytes used",
+		},
+		{name: "room for the backups", store: &quotaStorage{info: &blob.MinIOInfo{Quota: 100, Usage: 50}}, size: 40},
+		{
+			name:    "no room for the backups",
+			store:   &quotaStorage{info: &blob.MinIOInfo{Quota: 100, Usage: 50}},
+			size:    41,
+			wantErr: "to write 41 bytes",
+		},
+		{
+			name:    "over quota",
+			store:   &quotaStorage{info: &blob.MinIOInfo{Quota: 100, Usage: 120}},
+			wantErr: "0.0% available",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBucketQuota(context.Background(), tt.store, 0.1, tt.size)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+	assert.NoError(t, checkBucketQuota(context.Background(), &quotaStorage{
+		info: &blob.MinIOInfo{Quota: 100, Usage: 100},
+	}, 0, 100))
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"net/url"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/chaos"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// ChaosResult summarizes the faults injected between the cluster and the
+// storage provider.
+type ChaosResult struct {
+	Faults chaos.Faults
+	Stats  chaos.Stats
+}
+
+// chaosEnabled returns whether the external connection is routed through
+// the fault injection proxy.
+func chaosEnabled(env *env.Env) bool {
+	return env.ChaosListen != ""
+}
+
+// chaosFaults returns the faults to inject, as configured in the environment.
+func chaosFaults(env *env.Env) (chaos.Faults, error) {
+	faults := chaos.Faults{Latency: env.ChaosLatency, ErrorRate: env.ChaosErrorRate}
+	if env.ChaosBandwidth != "" {
+		var err error
+		if faults.Bandwidth, err = parseRate(env.ChaosBandwidth); err != nil {
+			return chaos.Faults{}, err
+		}
+	}
+	return faults, nil
+}
+
+// startChaos starts the fault injection proxy in front of the storage
+// endpoint. The returned storage has the same parameters as the original
+// one, but its URL points the external connection at the proxy, so that
+// only the traffic between the cluster and the storage provider is
+// degraded.
+func startChaos(
+	ctx *stopper.Context, env *env.Env, store blob.Storage,
+) (*chaos.Proxy, blob.Storage, error) {
+	endpoint := store.Params()[blob.EndPointParam]
+	if endpoint == "" {
+		return nil, nil, errors.New("fault injection requires an explicit endpoint")
+	}
+	faults, err := chaosFaults(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The path prefix of a gateway endpoint stays on the advertised endpoint,
+	// so that the requests signed by the cluster are forwarded unchanged.
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid endpoint")
+	}
+	prefix := target.Path
+	target.Path, target.RawPath = "", ""
+	proxy, err := chaos.Start(ctx, target.String(), env.ChaosListen, faults, env.Dial)
+	if err != nil {
+		return nil, nil, err
+	}
+	advertise := env.ChaosAdvertise
+	if advertise == "" {
+		advertise = "http://" + proxy.Addr()
+	}
+	if prefix != "" {
+		advertise, err = blob.WithPathPrefix(advertise, prefix)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return proxy, &proxiedStorage{Storage: store, endpoint: advertise}, nil
+}
+
+// chaosResult returns the faults injected so far, or nil if fault
+// injection is disabled.
+func (v *Validator) chaosResult() *ChaosResult {
+	if v.chaos == nil {
+		return nil
+	}
+	faults, _ := chaosFaults(v.env)
+	return &ChaosResult{Faults: faults, Stats: v.chaos.Stats()}
+}
+
+// proxiedStorage replaces the endpoint in the URLs of a storage.
+type proxiedStorage struct {
+	blob.Storage
+	endpoint string
+}
+
+// URL implements blob.Storage.
+func (s *proxiedStorage) URL() string {
+	return withEndpoint(s.Storage.URL(), s.endpoint)
+}
+
+// RootURL implements blob.Storage.
+func (s *proxiedStorage) RootURL() string {
+	return withEndpoint(s.Storage.RootURL(), s.endpoint)
+}
+
+// withEndpoint returns the URL with the endpoint parameter replaced.
+func withEndpoint(rawURL, endpoint string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	query.Set(blob.EndPointParam, endpoint)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,40 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/chaos"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestWithEndpoint(t *testing.T) {
+	got := withEndpoint("s3://bucket/path?AWS_ENDPOINT=http%3A%2F%2Fminio%3A9000&AWS_REGION=aws-global",
+		"http://blobcheck:9100")
+	assert.Equal(t, "s3://bucket/path?AWS_ENDPOINT=http%3A%2F%2Fblobcheck%3A9100&AWS_REGION=aws-global", got)
+}
+
+func TestChaosFaults(t *testing.T) {
+	a := assert.New(t)
+	faults, err := chaosFaults(&env.Env{ChaosLatency: time.Second, ChaosBandwidth: "1MiB/s", ChaosErrorRate: 0.1})
+	a.NoError(err)
+	a.Equal(chaos.Faults{Latency: time.Second, Bandwidth: 1 << 20, ErrorRate: 0.1}, faults)
+	_, err = chaosFaults(&env.Env{ChaosBandwidth: "fast"})
+	a.Error(err)
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+const (
+	bytesPerGB = 1e9
+	month      = 30 * 24 * time.Hour
+)
+
+// CostEstimate is the projected monthly cost of the customer's backup
+// schedule, based on the backups taken during the validation. Transferring
+// the backups to the storage is ingress, which providers do not charge for;
+// egress is charged for the bytes read back by restores and reads.
+type CostEstimate struct {
+	FullSize        int64   // projected size of a full backup
+	IncrementalSize int64   // projected size of an incremental backup
+	StoredBytes     int64   // bytes retained in the storage
+	WrittenBytes    int64   // bytes uploaded to the storage every month
+	ReadBytes       int64   // bytes read from the storage every month
+	StorageCost     float64 // monthly storage cost
+	EgressCost      float64 // monthly cost of reading the backups from the storage
+}
+
+// Total returns the total monthly cost.
+func (c *CostEstimate) Total() float64 {
+	return c.StorageCost + c.EgressCost
+}
+
+// costEnabled returns whether a cost estimate was requested.
+func costEnabled(env *env.Env) bool {
+	return env.StoragePrice > 0 || env.EgressPrice > 0
+}
+
+// estimateCost projects the measured full and incremental backups onto the
+// customer's backup schedule. The full backup is scaled to dataSize, if
+// known; the incremental backup is scaled by the same factor and by the ratio
+// between the schedule's incremental interval and the interval measured
+// during the validation. The growth rate reflects the validation workload,
+// so the incremental estimate is only as representative as the workload.
+// The egress is charged for readVolume bytes, or, if unknown, for a single
+// restore of the longest backup chain: a full backup and the incremental
+// backups taken until the next one.
+func estimateCost(full, incremental db.BackupLayer, dataSize, readVolume int64, env *env.Env) *CostEstimate {
+	scale := 1.0
+	if dataSize > 0 && full.Size > 0 {
+		scale = float64(dataSize) / float64(full.Size)
+	}
+	res := &CostEstimate{
+		FullSize: int64(float64(full.Size) * scale),
+	}
+	if measured := incremental.EndTime.Sub(full.EndTime); measured > 0 {
+		ratio := env.IncrementalInterval.Seconds() / measured.Seconds()
+		res.IncrementalSize = int64(float64(incremental.Size) * scale * ratio)
+	}
+
+	// Backups retained at any point in time.
+	fulls := math.Ceil(env.Retention.Seconds() / env.FullBackupInterval.Seconds())
+	incrementals := max(math.Ceil(env.Retention.Seconds()/env.IncrementalInterval.Seconds())-fulls, 0)
+	res.StoredBytes = int64(fulls*float64(res.FullSize) + incrementals*float64(res.IncrementalSize))
+
+	// Backups taken every month.
+	fulls = month.Seconds() / env.FullBackupInterval.Seconds()
+	incrementals = max(month.Seconds()/env.IncrementalInterval.Seconds()-fulls, 0)
+	res.WrittenBytes = int64(fulls*float64(res.FullSize) + incrementals*float64(res.IncrementalSize))
+
+	res.ReadBytes = readVolume
+	if res.ReadBytes <= 0 {
+		chain := max(math.Ceil(env.FullBackupInterval.Seconds()/env.IncrementalInterval.Seconds())-1, 0)
+		res.ReadBytes = int64(float64(res.FullSize) + chain*float64(res.IncrementalSize))
+	}
+
+	res.StorageCost = float64(res.StoredBytes) / bytesPerGB * env.StoragePrice
+	res.EgressCost = float64(res.ReadBytes) / bytesPerGB * env.EgressPrice
+	return res
+}
+
+// projectCost retrieves the sizes of the backups taken during the
+// validation and projects them onto the customer's backup schedule.
+func (v *Validator) projectCost(ctx *stopper.Context, extConn *db.ExternalConn) (*CostEstimate, error) {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	layers, err := extConn.Layers(ctx, conn, v.latest)
+	if err != nil {
+		return nil, err
+	}
+	var full, incremental db.BackupLayer
+	for _, l := range layers {
+		if l.Full {
+			full = l
+		} else {
+			incremental = l
+		}
+	}
+	var dataSize, readVolume int64
+	if v.env.DataSize != "" {
+		size, err := parseSize(v.env.DataSize)
+		if err != nil {
+			return nil, err
+		}
+		dataSize = int64(size)
+	}
+	if v.env.ReadVolume != "" {
+		size, err := parseSize(v.env.ReadVolume)
+		if err != nil {
+			return nil, err
+		}
+		readVolume = int64(size)
+	}
+	res := estimateCost(full, incremental, dataSize, readVolume, v.env)
+	slog.Info("estimated monthly cost",
+		slog.Int64("stored_bytes", res.StoredBytes), slog.Int64("read_bytes", res.ReadBytes),
+		slog.Float64("total", res.Total()))
+	return res, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestParseSize(t *testing.T) {
+	a := assert.New(t)
+	size, err := parseSize("500GiB")
+	a.NoError(err)
+	a.Equal(float64(500<<30), size)
+	size, err = parseSize("1.5 TB")
+	a.NoError(err)
+	a.Equal(1.5e12, size)
+	_, err = parseSize("large")
+	a.Error(err)
+}
+
+func TestEstimateCost(t *testing.T) {
+	a := assert.New(t)
+	now := time.Now()
+	full := db.BackupLayer{Full: true, EndTime: now, Size: 1e9}
+	// 10MB written in 1 minute: 600MB per hour.
+	incremental := db.BackupLayer{EndTime: now.Add(time.Minute), Size: 1e7}
+	e := &env.Env{
+		StoragePrice:        0.02,
+		EgressPrice:         0.09,
+		FullBackupInterval:  24 * time.Hour,
+		IncrementalInterval: time.Hour,
+		Retention:           48 * time.Hour,
+	}
+
+	res := estimateCost(full, incremental, 0, 0, e)
+	a.Equal(int64(1e9), res.FullSize)
+	a.Equal(int64(6e8), res.IncrementalSize)
+	// 2 full and 46 incremental backups retained.
+	a.Equal(int64(2*1e9+46*6e8), res.StoredBytes)
+	// 30 full and 690 incremental backups every month.
+	a.Equal(int64(30*1e9+690*6e8), res.WrittenBytes)
+	a.InDelta(float64(res.StoredBytes)/1e9*0.02, res.StorageCost, 1e-9)
+	// By default, a restore of a full backup and its 23 incremental backups.
+	a.Equal(int64(1e9+23*6e8), res.ReadBytes)
+	a.InDelta(float64(res.ReadBytes)/1e9*0.09, res.EgressCost, 1e-9)
+	a.InDelta(res.StorageCost+res.EgressCost, res.Total(), 1e-9)
+
+	// Scaled to the customer's data size.
+	res = estimateCost(full, incremental, 1e12, 0, e)
+	a.Equal(int64(1e12), res.FullSize)
+	a.Equal(int64(6e11), res.IncrementalSize)
+
+	// The read volume given by the customer.
+	res = estimateCost(full, incremental, 0, 5e12, e)
+	a.Equal(int64(5e12), res.ReadBytes)
+	a.InDelta(450, res.EgressCost, 1e-9)
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import "github.com/cockroachlabs-field/blobcheck/internal/blob"
+
+// Kinds of credentials stored in the external connection.
+const (
+	// CredentialsImplicit means that the nodes use their own credentials,
+	// such as an instance profile, and the URL holds none.
+	CredentialsImplicit = "implicit"
+	// CredentialsTemporary means that the URL holds a session token, which
+	// expires.
+	CredentialsTemporary = "temporary"
+	// CredentialsLongLived means that the URL holds access keys that do not
+	// expire.
+	CredentialsLongLived = "long-lived"
+)
+
+// CredentialsResult describes the credentials of the suggested parameters.
+type CredentialsResult struct {
+	Kind    string             // one of the Credentials constants
+	Session *blob.SessionToken // the credentials minted for the run, with --session-token-duration
+}
+
+// CheckCredentials classifies the credentials of the suggested parameters.
+// It returns nil if they are neither access keys nor implicit, such as the
+// service account key of Google Cloud Storage.
+func CheckCredentials(store blob.Storage) *CredentialsResult {
+	params := store.Params()
+	switch {
+	case params[blob.AuthParam] == blob.AuthImplicit:
+		return &CredentialsResult{Kind: CredentialsImplicit}
+	case params[blob.TokenParam] != "":
+		res := &CredentialsResult{Kind: CredentialsTemporary}
+		if reporter, ok := store.(blob.SessionReporter); ok {
+			res.Session = reporter.Session()
+		}
+		return res
+	case params[blob.AccountParam] != "":
+		return &CredentialsResult{Kind: CredentialsLongLived}
+	default:
+		return nil
+	}
+}
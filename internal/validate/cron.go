@@ -0,0 +1,135 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// cronDescriptors maps the predefined schedules to their cron expression.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField is the set of values matched by a field of a cron expression.
+type cronField struct {
+	values map[int]bool
+	any    bool // the field is "*"
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps
+// (e.g. "*/15", "1-5", "0,30") within [lo, hi].
+func parseCronField(field string, lo, hi int) (cronField, error) {
+	res := cronField{values: make(map[int]bool), any: field == "*"}
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return res, errors.Newf("invalid step in %q", field)
+			}
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return res, errors.Newf("invalid value in %q", field)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return res, errors.Newf("invalid range in %q", field)
+				}
+			} else if hasStep {
+				to = hi
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return res, errors.Newf("value out of range in %q", field)
+		}
+		for i := from; i <= to; i += step {
+			res.values[i] = true
+		}
+	}
+	return res, nil
+}
+
+// cadence returns the shortest interval between two consecutive runs of a
+// cron expression, as used by the recurrence of backup schedules.
+func cadence(recurrence string) (time.Duration, error) {
+	expr := strings.TrimSpace(recurrence)
+	if d, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return 0, errors.Newf("unsupported recurrence %q", recurrence)
+	}
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	parsed := make([]cronField, len(fields))
+	for i, f := range fields {
+		var err error
+		if parsed[i], err = parseCronField(f, bounds[i][0], bounds[i][1]); err != nil {
+			return 0, errors.Wrapf(err, "unsupported recurrence %q", recurrence)
+		}
+	}
+	minute, hour, dom, month, dow := parsed[0], parsed[1], parsed[2], parsed[3], parsed[4]
+	if dow.values[7] {
+		dow.values[0] = true
+	}
+	matches := func(t time.Time) bool {
+		if !minute.values[t.Minute()] || !hour.values[t.Hour()] || !month.values[int(t.Month())] {
+			return false
+		}
+		domMatch, dowMatch := dom.values[t.Day()], dow.values[int(t.Weekday())]
+		// When both day fields are restricted, either one may match.
+		if !dom.any && !dow.any {
+			return domMatch || dowMatch
+		}
+		return domMatch && dowMatch
+	}
+	// Walk a (non leap) year minute by minute, which covers every month and
+	// weekday, and record the shortest gap between runs.
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	var prev time.Time
+	var res time.Duration
+	for t := start; t.Before(end); t = t.Add(time.Minute) {
+		if !matches(t) {
+			continue
+		}
+		if !prev.IsZero() && (res == 0 || t.Sub(prev) < res) {
+			res = t.Sub(prev)
+		}
+		prev = t
+	}
+	if res == 0 {
+		// At most one run per year.
+		return 365 * 24 * time.Hour, nil
+	}
+	return res, nil
+}
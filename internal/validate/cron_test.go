@@ -0,0 +1,53 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCadence(t *testing.T) {
+	tests := []struct {
+		recurrence string
+		want       time.Duration
+		wantErr    bool
+	}{
+		{recurrence: "@hourly", want: time.Hour},
+		{recurrence: "@daily", want: 24 * time.Hour},
+		{recurrence: "@weekly", want: 7 * 24 * time.Hour},
+		{recurrence: "*/15 * * * *", want: 15 * time.Minute},
+		{recurrence: "0 */4 * * *", want: 4 * time.Hour},
+		{recurrence: "0 0,12 * * *", want: 12 * time.Hour},
+		// Weekdays only: the shortest gap is one day.
+		{recurrence: "30 2 * * 1-5", want: 24 * time.Hour},
+		{recurrence: "0 0 1 * *", want: 28 * 24 * time.Hour},
+		{recurrence: "every hour", wantErr: true},
+		{recurrence: "61 * * * *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.recurrence, func(t *testing.T) {
+			got, err := cadence(tt.recurrence)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
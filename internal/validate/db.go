@@ -16,12 +16,16 @@ package validate
 
 import (
 	"log/slog"
+	"regexp"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/field-eng-powertools/stopper"
 	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
 )
 
 // acquireConn acquires a database connection from the pool.
@@ -51,35 +55,170 @@ func (v *Validator) captureInitialStats(
 	return stats, nil
 }
 
-// createSourceTable creates the source database and table.
-func createSourceTable(ctx *stopper.Context, conn *pgxpool.Conn) (db.KvTable, error) {
-	source := db.Database{Name: "_blobcheck"}
-	if err := source.Create(ctx, conn); err != nil {
-		return db.KvTable{}, errors.Wrap(err, "failed to create source database")
+// sourceDatabase is the database created by blobcheck when the user does not
+// provide one.
+const sourceDatabase = "_blobcheck"
+
+// restoredDatabase is the database where blobcheck restores the backup
+// when the user does not provide one.
+const restoredDatabase = "_blobcheck_restored"
+
+// tablePrefix is the prefix of the run-scoped name of the source table, and
+// of the restored table.
+const tablePrefix = "blobcheck_"
+
+// blobcheckTable matches the schema qualified names of the tables created by
+// blobcheck, including the source table renamed before the restore and the
+// fixed name used by earlier versions.
+var blobcheckTable = regexp.MustCompile(`^[^.]+\.(?:blobcheck_[0-9a-f]{8}|mytable)(?:_source|_companion)?$`)
+
+// newTableName returns a random name for the tables of the run, so that they
+// do not collide with the customer's objects.
+func newTableName() db.Ident {
+	return db.Ident(tablePrefix + uuid.NewString()[:8])
+}
+
+// userTables returns the tables that were not created by blobcheck.
+func userTables(tables []string) []string {
+	var res []string
+	for _, t := range tables {
+		if !blobcheckTable.MatchString(t) {
+			res = append(res, t)
+		}
 	}
+	return res
+}
 
-	sourceTable := db.KvTable{
-		Database: source,
-		Schema:   db.Public,
-		Name:     "mytable",
+// checkNameCollisions verifies that the tables of the run do not collide
+// with the customer's objects. The databases created by blobcheck are
+// dropped at the end of the run, so they must not contain tables created by
+// the user; in a database selected by the user, the tables must not exist.
+func checkNameCollisions(
+	ctx *stopper.Context, conn *pgxpool.Conn, env *env.Env, name db.Ident,
+) error {
+	if env.Database != "" {
+		for _, n := range []db.Ident{name, name + "_source"} {
+			table := db.KvTable{
+				Database: db.Database{Name: db.Ident(env.Database)},
+				Schema:   schemaFor(env),
+				Name:     n,
+			}
+			exists, err := table.Exists(ctx, conn)
+			if err != nil {
+				return errors.Wrap(err, "failed to check for name collisions")
+			}
+			if exists {
+				return errors.Newf("table %s already exists in database %s", table.String(), env.Database)
+			}
+		}
+		return nil
 	}
+	for _, dbName := range []db.Ident{sourceDatabase, restoredDatabase} {
+		// Escape the underscores, which are wildcards in LIKE patterns.
+		dbs, err := db.Databases(ctx, conn, strings.ReplaceAll(dbName.String(), "_", `\_`))
+		if err != nil {
+			return errors.Wrap(err, "failed to check for name collisions")
+		}
+		if len(dbs) == 0 {
+			continue
+		}
+		database := db.Database{Name: dbName}
+		tables, err := database.Tables(ctx, conn)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the tables in database %s", dbName)
+		}
+		if user := userTables(tables); len(user) > 0 {
+			return errors.WithHint(
+				errors.Newf("database %s contains tables not created by blobcheck: %s", dbName, strings.Join(user, ", ")),
+				"blobcheck drops the database at the end of the run: move the tables to another database, "+
+					"or select an existing database for the test tables with --database")
+		}
+	}
+	return nil
+}
+
+// schemaFor returns the schema selected by the user, or the public schema.
+func schemaFor(env *env.Env) db.Schema {
+	if env.Schema == "" {
+		return db.Public
+	}
+	return db.Schema{Name: db.Ident(env.Schema)}
+}
+
+// sourceTableFor returns the source table: in the database selected by the
+// user, if any, or in the database created by blobcheck.
+func sourceTableFor(env *env.Env, name db.Ident) db.KvTable {
+	source := db.Database{Name: sourceDatabase}
+	if env.Database != "" {
+		source.Name = db.Ident(env.Database)
+	}
+	return db.KvTable{Database: source, Schema: schemaFor(env), Name: name}
+}
+
+// restoredTableFor returns the table the backup is restored into: in place of
+// the source table, if the user selected a database, or in the restored
+// database created by blobcheck.
+func restoredTableFor(env *env.Env, name db.Ident) db.KvTable {
+	if env.Database != "" {
+		return db.KvTable{Database: db.Database{Name: db.Ident(env.Database)}, Schema: schemaFor(env), Name: name}
+	}
+	return db.KvTable{Database: db.Database{Name: restoredDatabase}, Schema: schemaFor(env), Name: name}
+}
+
+// createSourceTable creates the source database and table. If the user
+// selected a database, it is expected to exist and it is not created. It
+// returns whether it created the schema in the database selected by the
+// user, which must be dropped during the cleanup.
+func createSourceTable(
+	ctx *stopper.Context, conn *pgxpool.Conn, env *env.Env, name db.Ident,
+) (db.KvTable, bool, error) {
+	sourceTable := sourceTableFor(env, name)
+	source, schema := sourceTable.Database, sourceTable.Schema
+	if env.Database == "" {
+		if err := source.Create(ctx, conn); err != nil {
+			return db.KvTable{}, false, errors.Wrap(err, "failed to create source database")
+		}
+	}
+
+	var createdSchema bool
+	if schema != db.Public {
+		if env.Database != "" {
+			exists, err := source.SchemaExists(ctx, conn, schema)
+			if err != nil {
+				return db.KvTable{}, false, errors.Wrap(err, "failed to check source schema")
+			}
+			createdSchema = !exists
+		}
+		if err := source.CreateSchema(ctx, conn, schema); err != nil {
+			return db.KvTable{}, false, errors.Wrap(err, "failed to create source schema")
+		}
+	}
+
+	slog.Info("creating source table", slog.String("table", sourceTable.String()))
 	if err := sourceTable.Create(ctx, conn); err != nil {
-		return db.KvTable{}, errors.Wrap(err, "failed to create source table")
+		return db.KvTable{}, createdSchema, errors.Wrap(err, "failed to create source table")
 	}
-	return sourceTable, nil
+	return sourceTable, createdSchema, nil
 }
 
-// createRestoredTable creates the restored database and table.
-func createRestoredTable(ctx *stopper.Context, conn *pgxpool.Conn) (db.KvTable, error) {
-	dest := db.Database{Name: "_blobcheck_restored"}
+// createRestoredTable creates the restored database and table. If the user
+// selected a database, the table is restored alongside the source table,
+// which is renamed before the restore.
+func createRestoredTable(
+	ctx *stopper.Context, conn *pgxpool.Conn, env *env.Env, name db.Ident,
+) (db.KvTable, error) {
+	restoredTable := restoredTableFor(env, name)
+	if env.Database != "" {
+		return restoredTable, nil
+	}
+	dest := restoredTable.Database
 	if err := dest.Create(ctx, conn); err != nil {
 		return db.KvTable{}, errors.Wrap(err, "failed to create restored database")
 	}
-
-	restoredTable := db.KvTable{
-		Database: dest,
-		Schema:   db.Public,
-		Name:     "mytable",
+	if schema := restoredTable.Schema; schema != db.Public {
+		if err := dest.CreateSchema(ctx, conn, schema); err != nil {
+			return db.KvTable{}, errors.Wrap(err, "failed to create restored schema")
+		}
 	}
 	return restoredTable, nil
 }
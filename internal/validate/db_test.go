@@ -0,0 +1,103 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestNewTableName(t *testing.T) {
+	a := assert.New(t)
+	name := newTableName()
+	a.Regexp(`^blobcheck_[0-9a-f]{8}$`, name.String())
+	a.NotEqual(name, newTableName())
+	a.True(blobcheckTable.MatchString("public." + name.String()))
+	a.True(blobcheckTable.MatchString("public." + name.String() + "_source"))
+	a.True(blobcheckTable.MatchString("public." + name.String() + "_companion"))
+}
+
+func TestUserTables(t *testing.T) {
+	tables := []string{
+		"public.blobcheck_0a1b2c3d",
+		"public.blobcheck_0a1b2c3d_source",
+		"public.blobcheck_0a1b2c3d_companion",
+		"myschema.mytable",
+		"public.orders",
+		"public.blobcheck_orders",
+	}
+	assert.Equal(t, []string{"public.orders", "public.blobcheck_orders"}, userTables(tables))
+}
+
+func TestSchemaFor(t *testing.T) {
+	assert.Equal(t, db.Public, schemaFor(&env.Env{}))
+	assert.Equal(t, db.Schema{Name: "backups"}, schemaFor(&env.Env{Schema: "backups"}))
+}
+
+func TestTableNames(t *testing.T) {
+	const name = db.Ident("blobcheck_0a1b2c3d")
+	tests := []struct {
+		name         string
+		env          *env.Env
+		wantSource   string
+		wantRestored string
+	}{
+		{
+			name:         "default",
+			env:          &env.Env{},
+			wantSource:   "_blobcheck.public.blobcheck_0a1b2c3d",
+			wantRestored: "_blobcheck_restored.public.blobcheck_0a1b2c3d",
+		},
+		{
+			name:         "schema",
+			env:          &env.Env{Schema: "backups"},
+			wantSource:   "_blobcheck.backups.blobcheck_0a1b2c3d",
+			wantRestored: "_blobcheck_restored.backups.blobcheck_0a1b2c3d",
+		},
+		{
+			name:         "user database",
+			env:          &env.Env{Database: "app"},
+			wantSource:   "app.public.blobcheck_0a1b2c3d",
+			wantRestored: "app.public.blobcheck_0a1b2c3d",
+		},
+		{
+			name:         "user database and schema",
+			env:          &env.Env{Database: "app", Schema: "backups"},
+			wantSource:   "app.backups.blobcheck_0a1b2c3d",
+			wantRestored: "app.backups.blobcheck_0a1b2c3d",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := sourceTableFor(tt.env, name)
+			assert.Equal(t, tt.wantSource, source.String())
+			restored := restoredTableFor(tt.env, name)
+			assert.Equal(t, tt.wantRestored, restored.String())
+		})
+	}
+}
+
+func TestRemoteNames(t *testing.T) {
+	const name = db.Ident("blobcheck_0a1b2c3d")
+	assert.Equal(t, "_0a1b2c3d", runSuffix(name))
+	table := remoteTableFor(db.Public, name)
+	assert.Equal(t, "_blobcheck_restored_0a1b2c3d.public.blobcheck_0a1b2c3d", table.String())
+	table = remoteTableFor(db.Schema{Name: "backups"}, name)
+	assert.Equal(t, "_blobcheck_restored_0a1b2c3d.backups.blobcheck_0a1b2c3d", table.String())
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+// checkDestination verifies that the sub-path of the run has no objects
+// before the first backup, other than the ones of the probes. Objects left
+// by another run, such as one with the same --dest-id, would be listed by
+// SHOW BACKUPS along with the backups of this run. With --force, they are
+// left in place, and skipped by the cleanup.
+func (v *Validator) checkDestination(ctx *stopper.Context, probe blob.ProbeObject) error {
+	objects, err := v.blobStorage.List(ctx)
+	if errors.Is(err, blob.ErrUnsupported) {
+		slog.Warn("cannot verify that the destination is empty", slog.Any("error", err))
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to list the destination")
+	}
+	found := foreignObjects(objects, probe)
+	if len(found) == 0 {
+		return nil
+	}
+	if !v.env.Force {
+		return errors.WithHintf(
+			errors.Newf("destination is not empty: %d objects found (e.g. %q)", len(found), found[0]),
+			"use a different --dest-id, or --force to leave the objects in place and proceed")
+	}
+	slog.Warn("destination is not empty; the objects found are left in place",
+		slog.Int("objects", len(found)), slog.String("example", found[0]))
+	v.preexisting = found
+	return nil
+}
+
+// foreignObjects returns the keys of the objects that were not written by
+// the probes.
+func foreignObjects(objects []blob.Object, probe blob.ProbeObject) []string {
+	var res []string
+	for _, obj := range objects {
+		if !strings.HasPrefix(obj.Key, probe.Key+"_") {
+			res = append(res, obj.Key)
+		}
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// listedStorage is a storage whose destination holds a fixed set of
+// objects.
+type listedStorage struct {
+	blob.Storage
+	objects []blob.Object
+	err     error
+}
+
+// List implements blob.Storage.
+func (s *listedStorage) List(context.Context) ([]blob.Object, error) {
+	return s.objects, s.err
+}
+
+func TestCheckDestination(t *testing.T) {
+	probe := blob.ProbeObjectFromEnv(&env.Env{})
+	leftover := []blob.Object{{Key: "_blobcheck_0f3c"}, {Key: "2025/10/16-120000.00/BACKUP_MANIFEST"}}
+	tests := []struct {
+		name    string
+		store   *listedStorage
+		force   bool
+		wantErr string
+		want    []string
+	}{
+		{name: "empty", store: &listedStorage{}},
+		{name: "probe objects only", store: &listedStorage{objects: leftover[:1]}},
+		{name: "not empty", store: &listedStorage{objects: leftover},
+			wantErr: `1 objects found (e.g. "2025/10/16-120000.00/BACKUP_MANIFEST")`},
+		{name: "forced", store: &listedStorage{objects: leftover}, force: true,
+			want: []string{"2025/10/16-120000.00/BACKUP_MANIFEST"}},
+		{name: "unsupported", store: &listedStorage{err: blob.ErrUnsupported}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Validator{env: &env.Env{Force: tt.force}, blobStorage: tt.store}
+			err := v.checkDestination(stopper.WithContext(t.Context()), probe)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, v.preexisting)
+		})
+	}
+}
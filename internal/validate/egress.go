@@ -0,0 +1,220 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// Routes from the cluster to the storage endpoint.
+const (
+	RoutePrivate = "private network" // the endpoint resolves to private addresses
+	RoutePublic  = "public internet" // the endpoint resolves to public addresses
+	// RouteUnknown is reported for the public addresses of AWS S3, which
+	// are also reached through gateway VPC endpoints: only the route tables
+	// of the nodes tell whether the traffic leaves the VPC.
+	RouteUnknown = "unknown (public internet or gateway VPC endpoint)"
+)
+
+// metadataTimeout bounds the time spent querying the instance metadata
+// service, which is not reachable outside of EC2.
+const metadataTimeout = 2 * time.Second
+
+// EgressResult describes the network path from the cluster to the storage
+// endpoint, as needed by security teams approving the backup traffic.
+type EgressResult struct {
+	Endpoint     string       // host name of the storage endpoint
+	Addresses    []string     // addresses the endpoint resolves to
+	Route        string       // RoutePrivate, RoutePublic or RouteUnknown
+	VPCEndpoint  bool         // the endpoint is an AWS interface VPC endpoint (PrivateLink)
+	HostVPC      string       // VPC of the blobcheck host, not of the nodes, from the instance metadata, if available
+	HostPublicIP string       // public address of the blobcheck host, not of the nodes, from the instance metadata, if available
+	Nodes        []NodeEgress // source addresses of the nodes
+}
+
+// NodeEgress is the source address a node uses to reach the storage.
+type NodeEgress struct {
+	Node    int
+	Address string // address advertised by the node
+	Source  string // effective source address of the traffic to the storage
+}
+
+// endpointHost returns the host name of the storage endpoint, defaulting to
+// the AWS S3 endpoint of the region.
+func endpointHost(params blob.Params) string {
+	if u, err := params.URL(blob.EndPointParam); err == nil && u != nil {
+		return u.Hostname()
+	}
+	region := params[blob.RegionParam]
+	if region == "" || region == blob.DefaultRegion {
+		return "s3.amazonaws.com"
+	}
+	return "s3." + region + ".amazonaws.com"
+}
+
+// isVPCEndpoint returns whether the host is an AWS interface VPC endpoint.
+func isVPCEndpoint(host string) bool {
+	return strings.HasPrefix(host, "vpce-") || strings.Contains(host, ".vpce.amazonaws.com")
+}
+
+// isAWSS3 returns whether the host is a public AWS S3 endpoint, which
+// gateway VPC endpoints route privately although it resolves to public
+// addresses.
+func isAWSS3(host string) bool {
+	return strings.HasSuffix(host, ".amazonaws.com") && strings.Contains(host, "s3") && !isVPCEndpoint(host)
+}
+
+// classifyRoute returns whether the addresses of the host are reached over
+// a private network or over the public internet. A single public address is
+// enough for the traffic to possibly leave the private network. The route to
+// the public addresses of AWS S3 is unknown, since gateway VPC endpoints
+// keep it private.
+func classifyRoute(host string, ips []net.IP) string {
+	for _, ip := range ips {
+		if isPrivate(ip) {
+			continue
+		}
+		if isAWSS3(host) {
+			return RouteUnknown
+		}
+		return RoutePublic
+	}
+	return RoutePrivate
+}
+
+// isPrivate returns whether the address is not routable on the internet.
+func isPrivate(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()
+}
+
+// sourceAddress returns the effective source address of the traffic from a
+// node to the storage. Nodes with a public address use it; nodes with a
+// private address keep it on a private route, and are translated by a NAT
+// gateway, invisible to the cluster, on a public route. On an unknown route,
+// either may apply.
+func sourceAddress(nodeAddr string, route string) string {
+	host, _, err := net.SplitHostPort(nodeAddr)
+	if err != nil {
+		host = nodeAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		if addrs, err := net.LookupIP(host); err == nil && len(addrs) > 0 {
+			ip = addrs[0]
+		}
+	}
+	switch {
+	case ip == nil:
+		return "unknown"
+	case !isPrivate(ip) || route == RoutePrivate:
+		return ip.String()
+	case route == RouteUnknown:
+		return ip.String() + " through a gateway VPC endpoint, or a NAT gateway"
+	default:
+		return "NAT gateway (not visible to the cluster)"
+	}
+}
+
+// checkEgress resolves the storage endpoint and reports the route and the
+// source addresses that the nodes use to reach it. Host names are resolved
+// from the blobcheck host, which is expected to share the DNS configuration
+// of the cluster.
+func (v *Validator) checkEgress(ctx *stopper.Context) (*EgressResult, error) {
+	host := endpointHost(v.blobStorage.Params())
+	if v.env.Dial != nil {
+		slog.Warn("the endpoint is resolved locally, not through the tunnel", slog.String("endpoint", host))
+	}
+	res := &EgressResult{Endpoint: host, VPCEndpoint: isVPCEndpoint(host)}
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %s", host)
+	}
+	for _, ip := range ips {
+		res.Addresses = append(res.Addresses, ip.String())
+	}
+	res.Route = classifyRoute(host, ips)
+	if res.Route == RouteUnknown {
+		slog.Warn("the storage resolves to public AWS addresses: check the route tables of the nodes "+
+			"for a gateway VPC endpoint to tell whether the traffic leaves the VPC", slog.String("endpoint", host))
+	}
+	if !v.env.OfflineAudit {
+		// The metadata service is not a configured endpoint.
+		res.HostVPC, res.HostPublicIP = instanceMetadata(ctx, v.env.Dial)
+	}
+
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	nodes, err := db.NodeAddresses(ctx, conn)
+	if err != nil {
+		slog.Warn("unable to retrieve the node addresses", slog.Any("error", err))
+		return res, nil
+	}
+	for _, n := range nodes {
+		res.Nodes = append(res.Nodes, NodeEgress{
+			Node:    n.Node,
+			Address: n.Address,
+			Source:  sourceAddress(n.Address, res.Route),
+		})
+	}
+	return res, nil
+}
+
+// instanceMetadata returns the VPC and the public address of the blobcheck
+// host, if it runs on EC2. Both are empty if the metadata is not available.
+// If dial is not nil, it is used to reach the metadata service.
+func instanceMetadata(ctx context.Context, dial env.DialFunc) (vpc, publicIP string) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+	var options imds.Options
+	if dial != nil {
+		options.HTTPClient = &http.Client{Transport: &http.Transport{DialContext: dial}}
+	}
+	client := imds.New(options)
+	get := func(path string) string {
+		out, err := client.GetMetadata(ctx, &imds.GetMetadataInput{Path: path})
+		if err != nil {
+			slog.Debug("instance metadata not available", slog.String("path", path), slog.Any("error", err))
+			return ""
+		}
+		defer out.Content.Close()
+		b, err := io.ReadAll(out.Content)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	}
+	mac := get("mac")
+	if mac == "" {
+		return "", ""
+	}
+	return get("network/interfaces/macs/" + mac + "/vpc-id"), get("public-ipv4")
+}
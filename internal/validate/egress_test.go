@@ -0,0 +1,53 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+func TestEndpointHost(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("minio.internal", endpointHost(blob.Params{blob.EndPointParam: "https://minio.internal:9000"}))
+	a.Equal("s3.us-east-2.amazonaws.com", endpointHost(blob.Params{blob.RegionParam: "us-east-2"}))
+	a.Equal("s3.amazonaws.com", endpointHost(blob.Params{blob.RegionParam: blob.DefaultRegion}))
+}
+
+func TestClassifyRoute(t *testing.T) {
+	a := assert.New(t)
+	private := []net.IP{net.ParseIP("10.0.1.2"), net.ParseIP("172.16.0.9")}
+	public := []net.IP{net.ParseIP("10.0.1.2"), net.ParseIP("52.216.8.1")}
+	a.Equal(RoutePrivate, classifyRoute("minio.internal", private))
+	a.Equal(RoutePublic, classifyRoute("storage.example.com", public))
+	a.Equal(RoutePrivate, classifyRoute("vpce-0a1b2c3d.s3.us-east-1.vpce.amazonaws.com", private))
+	// Gateway VPC endpoints route the public addresses of S3 privately.
+	a.Equal(RouteUnknown, classifyRoute("s3.us-east-1.amazonaws.com", public))
+	a.Equal(RouteUnknown, classifyRoute("mybucket.s3.amazonaws.com", public))
+	a.True(isVPCEndpoint("vpce-0a1b2c3d.s3.us-east-1.vpce.amazonaws.com"))
+	a.False(isVPCEndpoint("s3.us-east-1.amazonaws.com"))
+}
+
+func TestSourceAddress(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("10.0.1.10", sourceAddress("10.0.1.10:26257", RoutePrivate))
+	a.Equal("NAT gateway (not visible to the cluster)", sourceAddress("10.0.1.10:26257", RoutePublic))
+	a.Equal("34.201.5.6", sourceAddress("34.201.5.6:26257", RoutePublic))
+	a.Equal("10.0.1.10 through a gateway VPC endpoint, or a NAT gateway", sourceAddress("10.0.1.10:26257", RouteUnknown))
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// rateUnits maps the unit suffixes used by CHECK EXTERNAL CONNECTION to
+// their size in bytes. Longer suffixes come first so that "MiB" is not
+// matched as "B".
+var rateUnits = []struct {
+	suffix string
+	size   float64
+}{
+	{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"kB", 1e3},
+	{"B", 1},
+}
+
+// parseRate parses a transfer rate such as "50MB/s" or "1.2 GiB/s" into
+// bytes per second.
+func parseRate(s string) (float64, error) {
+	rate, err := parseSize(strings.TrimSuffix(strings.TrimSpace(s), "/s"))
+	if err != nil {
+		return 0, errors.Newf("invalid rate %q", s)
+	}
+	return rate, nil
+}
+
+// parseSize parses a size such as "500GiB" or "1.5 TB" into bytes.
+func parseSize(s string) (float64, error) {
+	value := strings.TrimSpace(s)
+	for _, unit := range rateUnits {
+		if num, ok := strings.CutSuffix(value, unit.suffix); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+			if err != nil {
+				return 0, errors.Wrapf(err, "invalid size %q", s)
+			}
+			return f * unit.size, nil
+		}
+	}
+	return 0, errors.Newf("invalid size %q", s)
+}
+
+// throughput returns the aggregate read and write speed, in bytes per second,
+// of the nodes that successfully reached the storage provider.
+func throughput(stats []*db.Stats) (read, write float64) {
+	for _, s := range stats {
+		if !s.Success {
+			continue
+		}
+		if r, err := parseRate(s.ReadSpeed); err == nil {
+			read += r
+		}
+		if w, err := parseRate(s.WriteSpeed); err == nil {
+			write += w
+		}
+	}
+	return read, write
+}
+
+// transferTime returns the time needed to move the given number of bytes at
+// the given rate. It returns 0 if the rate is unknown.
+func transferTime(bytes int64, rate float64) time.Duration {
+	if rate <= 0 || bytes <= 0 {
+		return 0
+	}
+	return time.Duration(float64(bytes) / rate * float64(time.Second))
+}
+
+// remaining returns the estimated time left for a step, given the initial
+// estimate, the time elapsed, and the fraction completed reported by the job.
+// Once the job reports progress, the estimate is refined by extrapolating the
+// observed rate.
+func remaining(estimate, elapsed time.Duration, fraction float64) time.Duration {
+	if fraction > 0 && fraction < 1 {
+		return time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+	}
+	if fraction >= 1 || estimate <= elapsed {
+		return 0
+	}
+	return estimate - elapsed
+}
+
+// expectedGrowth returns the number of bytes the concurrent workload is
+// expected to add to the source table during a single workload run.
+func (v *Validator) expectedGrowth() int64 {
+	workers := max(v.env.Workers, 1)
+	return int64(v.ingestRate * float64(workers) * v.env.WorkloadDuration.Seconds())
+}
+
+// estimate returns the estimated duration for a step that transfers the
+// given number of bytes at the given rate, logging it for the operator. It
+// returns 0 when the estimate cannot be computed.
+func estimate(step string, bytes int64, rate float64) time.Duration {
+	eta := transferTime(bytes, rate)
+	if eta > 0 {
+		slog.Info("estimated time", slog.String("step", step),
+			slog.Int64("bytes", bytes), slog.Duration("eta", eta.Round(time.Second)))
+	}
+	return eta
+}
+
+// tableSize returns the size of the source table, or 0 if it cannot be
+// determined.
+func (v *Validator) tableSize(ctx *stopper.Context, conn *pgxpool.Conn) int64 {
+	size, err := v.sourceTable.Size(ctx, conn)
+	if err != nil {
+		slog.Debug("failed to retrieve table size", slog.Any("error", err))
+		return 0
+	}
+	return size
+}
+
+// currentSize acquires a connection and returns the size of the source
+// table, or 0 if it cannot be determined.
+func (v *Validator) currentSize(ctx *stopper.Context) int64 {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return 0
+	}
+	defer conn.Release()
+	return v.tableSize(ctx, conn)
+}
+
+// measureIngest records the rate at which a single workload adds data to the
+// source table, given the table size before the workload started and the
+// time it ran.
+func (v *Validator) measureIngest(ctx *stopper.Context, before int64, elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+	v.ingestRate = float64(v.currentSize(ctx)-before) / elapsed.Seconds()
+	slog.Debug("measured ingest rate", slog.Float64("bytes_per_second", v.ingestRate))
+}
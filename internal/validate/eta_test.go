@@ -0,0 +1,73 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "50MB/s", want: 50e6},
+		{in: "1.5 GiB/s", want: 1.5 * (1 << 30)},
+		{in: "512 KiB/s", want: 512 * (1 << 10)},
+		{in: "10 B/s", want: 10},
+		{in: "", wantErr: true},
+		{in: "fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseRate(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 0.001)
+		})
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	stats := []*db.Stats{
+		{Node: 1, Success: true, ReadSpeed: "100MB/s", WriteSpeed: "50MB/s"},
+		{Node: 2, Success: true, ReadSpeed: "100MB/s", WriteSpeed: "50MB/s"},
+		{Node: 3, Success: false, ReadSpeed: "100MB/s", WriteSpeed: "50MB/s"},
+	}
+	read, write := throughput(stats)
+	assert.InDelta(t, 200e6, read, 0.001)
+	assert.InDelta(t, 100e6, write, 0.001)
+}
+
+func TestRemaining(t *testing.T) {
+	a := assert.New(t)
+	// No progress reported: count down from the estimate.
+	a.Equal(8*time.Second, remaining(10*time.Second, 2*time.Second, 0))
+	// Estimate exceeded without progress.
+	a.Equal(time.Duration(0), remaining(10*time.Second, 12*time.Second, 0))
+	// Progress reported: extrapolate from the observed rate.
+	a.Equal(30*time.Second, remaining(10*time.Second, 10*time.Second, 0.25))
+	// Completed.
+	a.Equal(time.Duration(0), remaining(10*time.Second, 10*time.Second, 1))
+}
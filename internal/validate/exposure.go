@@ -0,0 +1,147 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// Sources inspected by the credential exposure check.
+const (
+	ExposureConnections = "SHOW EXTERNAL CONNECTIONS"
+	ExposureCreate      = "SHOW CREATE EXTERNAL CONNECTION"
+	ExposureJobs        = "SHOW JOBS"
+)
+
+// ExposureCheck is the outcome of inspecting the records of a source for
+// the secrets of the external connection.
+type ExposureCheck struct {
+	Source  string   // one of the Exposure constants
+	Checked int      // number of records inspected
+	Exposed []string // records showing a secret in clear: connection names or job IDs
+	Err     string   // why the source could not be inspected, such as an older version
+}
+
+// ExposureResult reports whether the cluster shows the secrets of the
+// external connection in clear to the users that can run SHOW statements.
+type ExposureResult struct {
+	Checks []ExposureCheck
+}
+
+// Exposed reports whether a source shows a secret in clear.
+func (r *ExposureResult) Exposed() bool {
+	return slices.ContainsFunc(r.Checks, func(c ExposureCheck) bool { return len(c.Exposed) > 0 })
+}
+
+// secretValues returns the values of the secret parameters of a storage
+// URL, as they are and query escaped, since the cluster may show either.
+func secretValues(rawURL string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	var res []string
+	for key, values := range u.Query() {
+		if !slices.Contains(blob.ObfuscatedParams, key) {
+			continue
+		}
+		for _, v := range values {
+			if v == "" || v == blob.Obfuscated {
+				continue
+			}
+			res = append(res, v)
+			if escaped := url.QueryEscape(v); escaped != v {
+				res = append(res, escaped)
+			}
+		}
+	}
+	return res
+}
+
+// containsSecret reports whether a record shows one of the secrets.
+func containsSecret(record string, secrets []string) bool {
+	return slices.ContainsFunc(secrets, func(s string) bool { return strings.Contains(record, s) })
+}
+
+// checkExposure inspects the external connections, the statement that
+// created the connection of the run and the jobs of the run for the
+// secrets of the destination. A secret shown in clear is a security
+// finding, reported without failing the validation. The check is skipped
+// if the URL holds no secret, such as with implicit authentication.
+func (v *Validator) checkExposure(
+	ctx *stopper.Context, extConn *db.ExternalConn,
+) (*ExposureResult, error) {
+	secrets := secretValues(v.blobStorage.URL())
+	if len(secrets) == 0 {
+		slog.Info("the URL holds no secret; skipping the credential exposure check")
+		return nil, nil
+	}
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	res := &ExposureResult{}
+	connections := ExposureCheck{Source: ExposureConnections}
+	if conns, err := db.ListExternalConns(ctx, conn); err != nil {
+		connections.Err = err.Error()
+	} else {
+		connections.Checked = len(conns)
+		for _, c := range conns {
+			if containsSecret(c.URI, secrets) {
+				connections.Exposed = append(connections.Exposed, c.Name)
+			}
+		}
+	}
+	res.Checks = append(res.Checks, connections)
+
+	create := ExposureCheck{Source: ExposureCreate}
+	if stmt, err := extConn.ShowCreate(ctx, conn); err != nil {
+		create.Err = err.Error()
+	} else {
+		create.Checked = 1
+		if containsSecret(stmt, secrets) {
+			create.Exposed = append(create.Exposed, extConn.String())
+		}
+	}
+	res.Checks = append(res.Checks, create)
+
+	jobs := ExposureCheck{Source: ExposureJobs}
+	if descriptions, err := v.sourceTable.JobDescriptions(ctx, conn); err != nil {
+		jobs.Err = err.Error()
+	} else {
+		jobs.Checked = len(descriptions)
+		for _, j := range descriptions {
+			if containsSecret(j.Description, secrets) {
+				jobs.Exposed = append(jobs.Exposed, fmt.Sprintf("%d (%s)", j.ID, j.Type))
+			}
+		}
+	}
+	res.Checks = append(res.Checks, jobs)
+
+	if res.Exposed() {
+		slog.Warn("security finding: the cluster shows the secrets of the external connection in clear")
+	}
+	return res, nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretValues(t *testing.T) {
+	a := assert.New(t)
+	secrets := secretValues("s3://bucket/path?AWS_ACCESS_KEY_ID=AKIAEXAMPLE" +
+		"&AWS_SECRET_ACCESS_KEY=wJalr%2FK7MDENG%2BbPxRfiCY&AWS_SESSION_TOKEN=******")
+	a.ElementsMatch([]string{"wJalr/K7MDENG+bPxRfiCY", "wJalr%2FK7MDENG%2BbPxRfiCY"}, secrets)
+	a.Empty(secretValues("s3://bucket/path?AUTH=implicit"))
+
+	a.True(containsSecret("s3://bucket/path?AWS_SECRET_ACCESS_KEY=wJalr%2FK7MDENG%2BbPxRfiCY", secrets))
+	a.True(containsSecret("BACKUP INTO 's3://bucket?AWS_SECRET_ACCESS_KEY=wJalr/K7MDENG+bPxRfiCY'", secrets))
+	a.False(containsSecret("s3://bucket/path?AWS_ACCESS_KEY_ID=AKIAEXAMPLE&AWS_SECRET_ACCESS_KEY=redacted", secrets))
+}
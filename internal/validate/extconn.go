@@ -0,0 +1,98 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// ParamDiff is a parameter of an existing external connection that differs
+// from the suggested value.
+type ParamDiff struct {
+	Connection string // name of the external connection
+	Param      string
+	Current    string // value currently configured; empty if not set
+	Suggested  string // suggested value; empty if the parameter should be removed
+}
+
+// diffParams compares the parameters of an existing external connection with
+// the suggested ones. Secrets are redacted by the cluster, so they are not
+// compared.
+func diffParams(name string, current, suggested blob.Params) []ParamDiff {
+	var res []ParamDiff
+	keys := make([]string, 0, len(current)+len(suggested))
+	for k := range current {
+		keys = append(keys, k)
+	}
+	for k := range suggested {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range slices.Compact(keys) {
+		if slices.Contains(blob.ObfuscatedParams, k) {
+			continue
+		}
+		if current[k] != suggested[k] {
+			res = append(res, ParamDiff{
+				Connection: name,
+				Param:      k,
+				Current:    current[k],
+				Suggested:  suggested[k],
+			})
+		}
+	}
+	return res
+}
+
+// compareExternalConns finds the external connections already defined in
+// the cluster that point at the same bucket, and returns how their
+// parameters differ from the suggested ones. Failures are logged, since the
+// comparison is informational only.
+func (v *Validator) compareExternalConns(
+	ctx *stopper.Context, extConn *db.ExternalConn,
+) []ParamDiff {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil
+	}
+	defer conn.Release()
+	conns, err := db.ListExternalConns(ctx, conn)
+	if err != nil {
+		slog.Warn("failed to list external connections", slog.Any("error", err))
+		return nil
+	}
+	var res []ParamDiff
+	for _, c := range conns {
+		if c.Name == extConn.String() {
+			continue
+		}
+		bucket, params, err := blob.ParseURI(c.URI)
+		if err != nil || bucket != v.blobStorage.BucketName() {
+			continue
+		}
+		diffs := diffParams(c.Name, params, extConn.SuggestedParams())
+		if len(diffs) > 0 {
+			slog.Warn("existing external connection differs from the suggested parameters",
+				slog.String("connection", c.Name), slog.Int("differences", len(diffs)))
+		}
+		res = append(res, diffs...)
+	}
+	return res
+}
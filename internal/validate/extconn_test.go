@@ -0,0 +1,45 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+func TestDiffParams(t *testing.T) {
+	current := blob.Params{
+		blob.AccountParam:      "AKIA...",
+		blob.SecretParam:       "redacted",
+		blob.RegionParam:       "us-east-1",
+		blob.UsePathStyleParam: "true",
+	}
+	suggested := blob.Params{
+		blob.AccountParam:  "AKIA...",
+		blob.SecretParam:   blob.Obfuscated,
+		blob.RegionParam:   "us-west-2",
+		blob.SkipChecksum:  "true",
+		blob.EndPointParam: "https://s3.example.com",
+	}
+	assert.Equal(t, []ParamDiff{
+		{Connection: "backups", Param: blob.EndPointParam, Suggested: "https://s3.example.com"},
+		{Connection: "backups", Param: blob.RegionParam, Current: "us-east-1", Suggested: "us-west-2"},
+		{Connection: "backups", Param: blob.SkipChecksum, Suggested: "true"},
+		{Connection: "backups", Param: blob.UsePathStyleParam, Current: "true"},
+	}, diffParams("backups", current, suggested))
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// Gap kinds, for configurations whose outcome differs between the local
+// probe and the cluster.
+const (
+	// GapLocalOnly marks a configuration that works locally, but not from
+	// the nodes, which usually reach the storage through another network
+	// path.
+	GapLocalOnly = "local only"
+	// GapClusterOnly marks a configuration that works from the nodes, but
+	// not locally.
+	GapClusterOnly = "cluster only"
+)
+
+// GapResult compares the outcome of the local probe of a candidate
+// configuration with the outcome of CHECK EXTERNAL CONNECTION from the
+// nodes.
+type GapResult struct {
+	Flags    blob.Params // flags of the configuration, as in the candidates
+	LocalErr string      // why the local probe failed, empty if it works
+	Stats    []*db.Stats // outcome of CHECK EXTERNAL CONNECTION on each node
+	Err      string      // why the cluster could not create or check the external connection
+}
+
+// LocalOK reports whether the configuration works locally.
+func (r *GapResult) LocalOK() bool {
+	return r.LocalErr == ""
+}
+
+// ClusterOK reports whether the configuration works from every node. On
+// versions without statistics, the external connection is only verified
+// when it is created.
+func (r *GapResult) ClusterOK() bool {
+	return r.Err == "" && len(r.FailedNodes()) == 0
+}
+
+// FailedNodes returns the nodes that could not use the configuration.
+func (r *GapResult) FailedNodes() []int {
+	var res []int
+	for _, s := range r.Stats {
+		if !s.Success {
+			res = append(res, s.Node)
+		}
+	}
+	return res
+}
+
+// Gap returns GapLocalOnly or GapClusterOnly if the outcomes differ, or an
+// empty string.
+func (r *GapResult) Gap() string {
+	switch local, cluster := r.LocalOK(), r.ClusterOK(); {
+	case local && !cluster:
+		return GapLocalOnly
+	case cluster && !local:
+		return GapClusterOnly
+	default:
+		return ""
+	}
+}
+
+// analyzeGaps checks every candidate configuration probed locally with
+// CHECK EXTERNAL CONNECTION, one at a time, and reports the outcome of
+// both. A configuration that the cluster cannot use is reported, but it
+// does not fail the validation.
+func (v *Validator) analyzeGaps(ctx *stopper.Context) ([]*GapResult, error) {
+	probed := v.blobStorage.Probed()
+	if len(probed) == 0 {
+		return nil, nil
+	}
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	res := make([]*GapResult, 0, len(probed))
+	for i, p := range probed {
+		result := &GapResult{Flags: p.Flags}
+		if p.Err != nil {
+			result.LocalErr = p.Err.Error()
+		}
+		res = append(res, result)
+		name := db.Ident(fmt.Sprintf("_blobcheck_gap_%d", i+1))
+		slog.Info("checking candidate configuration from the cluster", slog.Any("flags", p.Flags))
+		extConn, err := db.NewNamedExternalConn(ctx, conn, name, p.Config)
+		if err == nil {
+			result.Stats, err = extConn.Stats(ctx, conn)
+		}
+		if err != nil {
+			result.Err = err.Error()
+		}
+		// The connection exists even if the listing of the backups failed.
+		if extConn != nil {
+			if err := extConn.Drop(ctx, conn); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
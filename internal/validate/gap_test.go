@@ -0,0 +1,42 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+func TestGapResult(t *testing.T) {
+	a := assert.New(t)
+	both := []*db.Stats{{Node: 1, Success: true}, {Node: 2, Success: true}}
+	one := []*db.Stats{{Node: 1, Success: true}, {Node: 2, ErrStr: "no such host"}}
+
+	a.Empty((&GapResult{Stats: both}).Gap())
+	a.Empty((&GapResult{LocalErr: "x509", Err: "external connection failed"}).Gap())
+
+	r := &GapResult{Stats: one}
+	a.Equal(GapLocalOnly, r.Gap())
+	a.Equal([]int{2}, r.FailedNodes())
+	a.Equal(GapLocalOnly, (&GapResult{Err: "external connection failed"}).Gap())
+
+	a.Equal(GapClusterOnly, (&GapResult{LocalErr: "x509", Stats: both}).Gap())
+	// Without statistics, the cluster outcome is the creation of the
+	// external connection.
+	a.Equal(GapClusterOnly, (&GapResult{LocalErr: "x509"}).Gap())
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// Grant executes the statements that give a dedicated user the privileges
+// needed to run the validation. It must be run by an administrator.
+func Grant(ctx *stopper.Context, env *env.Env, stmts []string) error {
+	pool, err := connect(ctx, env)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire database connection")
+	}
+	defer conn.Release()
+	for _, stmt := range stmts {
+		slog.Debug("granting privileges", slog.String("stmt", stmt))
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			return errors.Wrapf(err, "failed to execute %q", stmt)
+		}
+	}
+	return nil
+}
+
+// checkPrivileges verifies that the user running the validation holds the
+// privileges it needs, so that a dedicated user with missing grants is
+// reported before any data is generated. Privileges inherited from other
+// roles are not detected, so missing grants are only logged as a warning,
+// together with the statements that an administrator can run to fix them.
+func checkPrivileges(ctx *stopper.Context, conn *pgxpool.Conn, env *env.Env) {
+	missing, err := db.MissingGrants(ctx, conn, env.Database)
+	if err != nil {
+		slog.Warn("unable to check the privileges of the current user", slog.Any("error", err))
+		return
+	}
+	if len(missing) == 0 {
+		return
+	}
+	slog.Warn("the current user may lack privileges needed by the validation; "+
+		"ask an administrator to run the statements below (see blobcheck grant)",
+		slog.String("statements", strings.Join(missing, "; ")))
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+// hookOutputLimit is the number of trailing bytes of the output of the hook
+// kept in the report.
+const hookOutputLimit = 1024
+
+// HookResult contains the outcome of the command run while the full backup
+// was in progress, such as a script that restarts a node.
+type HookResult struct {
+	Command         string
+	Concurrent      bool          // whether the command started while the backup job was running
+	Duration        time.Duration // time taken by the command
+	ExitCode        int           // exit code of the command, -1 if it did not run to completion
+	Output          string        // trailing output of the command
+	BackupCompleted bool          // whether the full backup completed despite the command
+}
+
+// runBackupHook waits for the full backup job to start, and then runs the
+// command provided with --on-backup-start-cmd. The backup must complete
+// regardless of what the command does to the cluster.
+func (v *Validator) runBackupHook(ctx *stopper.Context, backupDone <-chan struct{}) error {
+	concurrent := v.waitForBackupJob(ctx, backupDone)
+	if ctx.IsStopping() {
+		return nil
+	}
+	if !concurrent {
+		slog.Warn("the full backup completed before the hook started; the hook is not run: "+
+			"increase --workload-duration or the size of the source table",
+			slog.String("command", v.env.OnBackupStartCmd))
+		v.hook = &HookResult{Command: v.env.OnBackupStartCmd, ExitCode: -1}
+		return nil
+	}
+	v.hook = runHook(ctx, v.env.OnBackupStartCmd, []string{
+		"BLOBCHECK_SOURCE_TABLE=" + v.sourceTable.String(),
+	})
+	v.hook.Concurrent = true
+	return nil
+}
+
+// runHook runs a command with the shell, adding the variables to the
+// environment of the process. A command that fails is reported, rather than
+// failing the validation: the backup is expected to complete regardless.
+func runHook(ctx context.Context, command string, vars []string) *HookResult {
+	slog.Info("running the backup start hook", slog.String("command", command))
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), vars...)
+	cmd.Stdout, cmd.Stderr = &output, &output
+	start := time.Now()
+	err := cmd.Run()
+	res := &HookResult{
+		Command:  command,
+		Duration: time.Since(start),
+		Output:   tail(strings.TrimSpace(output.String()), hookOutputLimit),
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	default:
+		res.ExitCode = -1
+		res.Output = err.Error()
+	}
+	if res.ExitCode != 0 {
+		slog.Warn("the backup start hook failed", slog.Int("exit", res.ExitCode), slog.String("output", res.Output))
+	}
+	return res
+}
+
+// tail returns the last n bytes of s.
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
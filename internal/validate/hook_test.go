@@ -0,0 +1,42 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHook(t *testing.T) {
+	a := assert.New(t)
+	res := runHook(context.Background(), `echo "restarting node in $BLOBCHECK_SOURCE_TABLE"`,
+		[]string{"BLOBCHECK_SOURCE_TABLE=db.kv"})
+	a.Equal(0, res.ExitCode)
+	a.Equal("restarting node in db.kv", res.Output)
+
+	res = runHook(context.Background(), "echo failed >&2; exit 3", nil)
+	a.Equal(3, res.ExitCode)
+	a.Equal("failed", res.Output)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res = runHook(ctx, "true", nil)
+	a.Equal(-1, res.ExitCode)
+
+	a.Equal("..."+strings.Repeat("b", 4), tail(strings.Repeat("a", 10)+strings.Repeat("b", 4), 4))
+}
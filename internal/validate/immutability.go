@@ -0,0 +1,114 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+// ImmutabilityResult compares the object lock (WORM) configuration of the
+// bucket with the retention of the customer's backups.
+type ImmutabilityResult struct {
+	Lock            *blob.ObjectLock
+	BackupRetention time.Duration // retention of the backups in the customer's schedule
+}
+
+// ShortRetention reports whether the objects are no longer protected by
+// the default retention before the backups expire.
+func (r *ImmutabilityResult) ShortRetention() bool {
+	return r.Lock.Enabled && r.Lock.Retention < r.BackupRetention
+}
+
+// checkImmutability reads the object lock configuration of the bucket, so
+// that the cleanup skips the objects under retention.
+func (v *Validator) checkImmutability(ctx *stopper.Context) (*ImmutabilityResult, error) {
+	res, err := CheckImmutability(ctx, v.unwrapped, v.env.Retention)
+	if res != nil {
+		v.objectLock = res.Lock
+	}
+	return res, err
+}
+
+// CheckImmutability reads the object lock configuration of the bucket and
+// compares its default retention with the retention of the backups. It
+// returns nil if object lock is not enabled on the bucket, or if the
+// storage provider does not report it.
+func CheckImmutability(
+	ctx context.Context, store blob.Storage, retention time.Duration,
+) (*ImmutabilityResult, error) {
+	reporter, ok := store.(blob.ObjectLockReporter)
+	if !ok {
+		return nil, nil
+	}
+	lock, err := reporter.ObjectLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil || (!lock.Enabled && lock.Err == "") {
+		return nil, nil
+	}
+	res := &ImmutabilityResult{Lock: lock, BackupRetention: retention}
+	if lock.Err != "" {
+		slog.Warn("failed to read the object lock configuration", slog.String("error", lock.Err))
+		return res, nil
+	}
+	slog.Info("object lock is enabled", slog.String("mode", lock.Mode),
+		slog.Duration("retention", lock.Retention), slog.Bool("compliance", lock.Compliance()))
+	if res.ShortRetention() {
+		slog.Warn("the default retention of the bucket is shorter than the retention of the backups",
+			slog.Duration("retention", lock.Retention), slog.Duration("backup_retention", res.BackupRetention))
+	}
+	return res, nil
+}
+
+// cleanLocked removes the objects of the destination that are not under
+// retention or legal hold, and returns the keys of the locked objects,
+// which are left in place, like the objects found before the run.
+func (v *Validator) cleanLocked(ctx *stopper.Context) ([]string, error) {
+	objects, err := v.blobStorage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var locked []string
+	for _, obj := range unlocked(objects, v.preexisting) {
+		err := v.blobStorage.Delete(ctx, obj.Key)
+		if errors.Is(err, blob.ErrLocked) {
+			locked = append(locked, obj.Key)
+			continue
+		}
+		if err != nil {
+			return locked, err
+		}
+	}
+	if len(locked) > 0 {
+		slog.Warn("objects under retention were left in the destination",
+			slog.Int("objects", len(locked)), slog.String("destination", v.blobStorage.URL()))
+	}
+	return locked, nil
+}
+
+// unlocked returns the objects whose keys are not in locked.
+func unlocked(objects []blob.Object, locked []string) []blob.Object {
+	return slices.DeleteFunc(objects, func(obj blob.Object) bool {
+		return slices.Contains(locked, obj.Key)
+	})
+}
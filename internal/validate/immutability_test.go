@@ -0,0 +1,51 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+func TestShortRetention(t *testing.T) {
+	const day = 24 * time.Hour
+	tests := []struct {
+		name string
+		lock blob.ObjectLock
+		want bool
+	}{
+		{"longer", blob.ObjectLock{Enabled: true, Mode: blob.LockCompliance, Retention: 90 * day}, false},
+		{"equal", blob.ObjectLock{Enabled: true, Mode: blob.LockCompliance, Retention: 30 * day}, false},
+		{"shorter", blob.ObjectLock{Enabled: true, Mode: blob.LockCompliance, Retention: 7 * day}, true},
+		{"no default retention", blob.ObjectLock{Enabled: true}, true},
+		{"not enabled", blob.ObjectLock{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &ImmutabilityResult{Lock: &tt.lock, BackupRetention: 30 * day}
+			assert.Equal(t, tt.want, res.ShortRetention())
+		})
+	}
+}
+
+func TestUnlocked(t *testing.T) {
+	objects := []blob.Object{{Key: "BACKUP_MANIFEST"}, {Key: "data/1.sst"}, {Key: "data/2.sst"}}
+	got := unlocked(objects, []string{"data/1.sst", "data/2.sst"})
+	assert.Equal(t, []blob.Object{{Key: "BACKUP_MANIFEST"}}, got)
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// incrementalConnName is the external connection storing the incremental
+// backups, with --incremental-location.
+const incrementalConnName = "_blobcheck_incremental"
+
+// checkIncrementalLocation verifies that the incremental location is a
+// sub-prefix of the destination, so that the cleanup removes it.
+func checkIncrementalLocation(location string) error {
+	if location == "" {
+		return nil
+	}
+	if path.IsAbs(location) || strings.Contains(location, "://") {
+		return errors.Newf("invalid incremental location %q: must be a path relative to the destination", location)
+	}
+	if clean := path.Clean(location); clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return errors.Newf("invalid incremental location %q: must be a sub-prefix of the destination", location)
+	}
+	return nil
+}
+
+// newExternalConn creates the external connection to the destination. If
+// the incremental backups are stored in another location, a second
+// connection to it is created and used as the incremental_location of the
+// first.
+func newExternalConn(
+	ctx *stopper.Context, conn *pgxpool.Conn, store blob.Storage, incremental string, suffix string,
+) (*db.ExternalConn, error) {
+	extConn, err := db.NewNamedExternalConn(ctx, conn, db.BackupConnName+db.Ident(suffix), store)
+	if err != nil || incremental == "" {
+		return extConn, err
+	}
+	incConn, err := db.NewNamedExternalConn(ctx, conn, incrementalConnName+db.Ident(suffix),
+		&incrementalStorage{Storage: store, prefix: incremental})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the external connection to the incremental location")
+	}
+	extConn.SetIncrementalLocation(incConn)
+	return extConn, nil
+}
+
+// incrementalStorage stores the incremental backups under a sub-prefix of
+// the destination.
+type incrementalStorage struct {
+	blob.Storage
+	prefix string
+}
+
+// URL implements blob.Storage.
+func (s *incrementalStorage) URL() string {
+	return withSubPath(s.Storage.URL(), s.prefix)
+}
+
+// RootURL implements blob.Storage.
+func (s *incrementalStorage) RootURL() string {
+	return withSubPath(s.Storage.RootURL(), s.prefix)
+}
+
+// withSubPath returns the URL with the prefix appended to its path.
+func withSubPath(rawURL, prefix string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Path, u.RawPath = path.Join("/", u.Path, prefix), ""
+	return u.String()
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIncrementalLocation(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(checkIncrementalLocation(""))
+	a.NoError(checkIncrementalLocation("incrementals"))
+	a.NoError(checkIncrementalLocation("layers/incrementals/"))
+	a.Error(checkIncrementalLocation("/incrementals"))
+	a.Error(checkIncrementalLocation("s3://other/incrementals"))
+	a.Error(checkIncrementalLocation("."))
+	a.Error(checkIncrementalLocation("../incrementals"))
+	a.Error(checkIncrementalLocation("a/../.."))
+}
+
+func TestWithSubPath(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{
+			url:  "s3://bucket/backup?AWS_REGION=us-east-1&AWS_SECRET_ACCESS_KEY=a%2Bb",
+			want: "s3://bucket/backup/incrementals?AWS_REGION=us-east-1&AWS_SECRET_ACCESS_KEY=a%2Bb",
+		},
+		{url: "s3://bucket?AWS_REGION=us-east-1", want: "s3://bucket/incrementals?AWS_REGION=us-east-1"},
+		{url: "nodelocal://1/backup", want: "nodelocal://1/backup/incrementals"},
+		{url: "userfile:///backup", want: "userfile:///backup/incrementals"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, withSubPath(tt.url, "incrementals"))
+		})
+	}
+}
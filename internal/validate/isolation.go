@@ -0,0 +1,177 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// IsolationResult describes how the workload and the backup were kept on
+// separate nodes, so that the storage performance is measured without
+// contention from the workload on the nodes running the backup.
+type IsolationResult struct {
+	WorkloadLocality  string
+	WorkloadNodes     []int
+	ExecutionLocality string
+	BackupNodes       []int
+	BackupTime        time.Duration // time taken by the full backup
+}
+
+// isolationEnabled returns whether the workload is pinned to a subset of
+// the nodes.
+func isolationEnabled(env *env.Env) bool {
+	return env.WorkloadLocality != ""
+}
+
+// checkIsolation validates the locality filters.
+func checkIsolation(env *env.Env) error {
+	for _, filter := range []string{env.ExecutionLocality, env.WorkloadLocality} {
+		if filter == "" {
+			continue
+		}
+		if err := db.CheckLocalityFilter(filter); err != nil {
+			return err
+		}
+	}
+	if isolationEnabled(env) && env.ExecutionLocality == "" {
+		return errors.New("isolating the workload requires an execution locality for the backup")
+	}
+	return nil
+}
+
+// splitNodes returns the nodes matching the workload locality and the nodes
+// matching the execution locality. The two sets must be disjoint and not
+// empty.
+func splitNodes(nodes []db.NodeInfo, workload, execution string) (w, b []db.NodeInfo, _ error) {
+	for _, n := range nodes {
+		inWorkload := db.MatchesLocality(n.Locality, workload)
+		inBackup := db.MatchesLocality(n.Locality, execution)
+		if inWorkload && inBackup {
+			return nil, nil, errors.Newf(
+				"node %d (%s) matches both the workload and the execution locality", n.Node, n.Locality)
+		}
+		if inWorkload {
+			w = append(w, n)
+		}
+		if inBackup {
+			b = append(b, n)
+		}
+	}
+	if len(w) == 0 {
+		return nil, nil, errors.Newf("no live node matches the workload locality %q", workload)
+	}
+	if len(b) == 0 {
+		return nil, nil, errors.Newf("no live node matches the execution locality %q", execution)
+	}
+	return w, b, nil
+}
+
+// isolate pins the workload to the nodes matching the workload locality,
+// returning a connection pool that only reaches those nodes.
+func isolate(
+	ctx *stopper.Context, conn *pgxpool.Conn, env *env.Env, virtualCluster string,
+) (*IsolationResult, *pgxpool.Pool, error) {
+	nodes, err := db.Nodes(ctx, conn)
+	if err != nil {
+		return nil, nil, withCapabilityHint(errors.Wrap(err, "failed to retrieve the nodes of the cluster"),
+			virtualCluster, "can_view_node_info")
+	}
+	workload, backup, err := splitNodes(nodes, env.WorkloadLocality, env.ExecutionLocality)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := &IsolationResult{
+		WorkloadLocality:  env.WorkloadLocality,
+		ExecutionLocality: env.ExecutionLocality,
+	}
+	for _, n := range workload {
+		res.WorkloadNodes = append(res.WorkloadNodes, n.Node)
+	}
+	for _, n := range backup {
+		res.BackupNodes = append(res.BackupNodes, n.Node)
+	}
+	config, err := poolConfig(env)
+	if err != nil {
+		return nil, nil, err
+	}
+	routeTo(config, workload)
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create workload database pool")
+	}
+	slog.Info("workload isolated", slog.Any("workload_nodes", res.WorkloadNodes),
+		slog.Any("backup_nodes", res.BackupNodes))
+	return res, pool, nil
+}
+
+// routeTo makes the pool connect directly to the SQL addresses of the
+// nodes, in turn, instead of the host in the connection URL. The node
+// certificates must be valid for their SQL addresses when the server is
+// verified.
+func routeTo(config *pgxpool.Config, nodes []db.NodeInfo) {
+	var next atomic.Uint64
+	config.BeforeConnect = func(_ context.Context, cc *pgx.ConnConfig) error {
+		n := nodes[(next.Add(1)-1)%uint64(len(nodes))]
+		host, portStr, err := net.SplitHostPort(n.SQLAddress)
+		if err != nil {
+			return errors.Wrapf(err, "invalid SQL address of node %d", n.Node)
+		}
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			return errors.Wrapf(err, "invalid SQL address of node %d", n.Node)
+		}
+		cc.Host, cc.Port, cc.Fallbacks = host, uint16(port), nil
+		if cc.TLSConfig != nil {
+			cc.TLSConfig = cc.TLSConfig.Clone()
+			if cc.TLSConfig.ServerName != "" {
+				cc.TLSConfig.ServerName = host
+			}
+		}
+		return nil
+	}
+}
+
+// workloadPool returns the pool used by the workload: the pool pinned to
+// the workload nodes when the workload is isolated, or the main pool.
+func (v *Validator) workloadPool() *pgxpool.Pool {
+	if v.isolatedPool != nil {
+		return v.isolatedPool
+	}
+	return v.pool
+}
+
+// isolationResult returns the isolation of the run, or nil if the workload
+// is not isolated.
+func (v *Validator) isolationResult() *IsolationResult {
+	if v.isolation == nil {
+		return nil
+	}
+	res := *v.isolation
+	res.BackupTime = v.fullBackupTime
+	return &res
+}
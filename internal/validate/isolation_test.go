@@ -0,0 +1,83 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestCheckIsolation(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(checkIsolation(&env.Env{}))
+	a.NoError(checkIsolation(&env.Env{ExecutionLocality: "region=us-west1"}))
+	a.NoError(checkIsolation(&env.Env{
+		WorkloadLocality: "region=us-east1", ExecutionLocality: "region=us-west1"}))
+	a.Error(checkIsolation(&env.Env{WorkloadLocality: "region=us-east1"}))
+	a.Error(checkIsolation(&env.Env{
+		WorkloadLocality: "us-east1", ExecutionLocality: "region=us-west1"}))
+}
+
+func TestSplitNodes(t *testing.T) {
+	nodes := []db.NodeInfo{
+		{Node: 1, SQLAddress: "n1:26257", Locality: "region=us-east1,zone=a"},
+		{Node: 2, SQLAddress: "n2:26257", Locality: "region=us-east1,zone=b"},
+		{Node: 3, SQLAddress: "n3:26257", Locality: "region=us-west1,zone=a"},
+	}
+	tests := []struct {
+		name      string
+		workload  string
+		execution string
+		want      []int
+		wantB     []int
+		wantErr   string
+	}{
+		{name: "regions", workload: "region=us-east1", execution: "region=us-west1",
+			want: []int{1, 2}, wantB: []int{3}},
+		{name: "zones", workload: "region=us-east1,zone=a", execution: "region=us-east1,zone=b",
+			want: []int{1}, wantB: []int{2}},
+		{name: "overlap", workload: "region=us-east1", execution: "zone=a",
+			wantErr: "matches both"},
+		{name: "no workload nodes", workload: "region=eu-west1", execution: "region=us-west1",
+			wantErr: "workload locality"},
+		{name: "no backup nodes", workload: "region=us-east1", execution: "region=eu-west1",
+			wantErr: "execution locality"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			w, b, err := splitNodes(nodes, tt.workload, tt.execution)
+			if tt.wantErr != "" {
+				a.ErrorContains(err, tt.wantErr)
+				return
+			}
+			a.NoError(err)
+			a.Equal(tt.want, ids(w))
+			a.Equal(tt.wantB, ids(b))
+		})
+	}
+}
+
+func ids(nodes []db.NodeInfo) []int {
+	var res []int
+	for _, n := range nodes {
+		res = append(res, n.Node)
+	}
+	return res
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// ListBackups returns the layers of all the backup collections found in the
+// storage, as seen by the cluster.
+func ListBackups(
+	ctx *stopper.Context, env *env.Env, blobStorage blob.Storage,
+) ([]db.BackupLayer, error) {
+	pool, err := connect(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to acquire database connection")
+	}
+	defer conn.Release()
+
+	extConn, err := db.NewExternalConn(ctx, conn, blobStorage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create external connection")
+	}
+	defer extConn.Drop(ctx, conn)
+
+	collections, err := extConn.ListTableBackups(ctx, conn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list backup collections")
+	}
+	res := make([]db.BackupLayer, 0)
+	for _, collection := range collections {
+		layers, err := extConn.Layers(ctx, conn, collection)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to inspect backup collection %s", collection)
+		}
+		res = append(res, layers...)
+	}
+	return res, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// sstName matches the data files written by backups. Their name is a
+// unique ID whose low bits are the ID of the node that wrote the file.
+var sstName = regexp.MustCompile(`(?:^|/)data/(\d+)\.sst$`)
+
+// nodeIDBits is the number of low bits of a unique ID that hold the node ID.
+const nodeIDBits = 15
+
+// LocalityNode describes a node of the cluster in the execution locality
+// check.
+type LocalityNode struct {
+	Node     int
+	Locality string
+	Matches  bool   // the locality matches the filter
+	Check    string // outcome of CHECK EXTERNAL CONNECTION on the node: OK, the error, or empty if unknown
+	Files    int    // backup data files written by the node
+}
+
+// LocalityResult reports which nodes wrote the backup data, to verify that
+// the backup honored its execution locality.
+type LocalityResult struct {
+	Filter string
+	Nodes  []*LocalityNode
+}
+
+// Unexpected returns the nodes that wrote backup data but do not match the
+// filter.
+func (r *LocalityResult) Unexpected() []int {
+	var res []int
+	for _, n := range r.Nodes {
+		if !n.Matches && n.Files > 0 {
+			res = append(res, n.Node)
+		}
+	}
+	return res
+}
+
+// writerNodes counts the backup data files written by each node.
+func writerNodes(objects []blob.Object) map[int]int {
+	res := make(map[int]int)
+	for _, o := range objects {
+		m := sstName.FindStringSubmatch(o.Key)
+		if m == nil {
+			continue
+		}
+		id, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[int(id&(1<<nodeIDBits-1))]++
+	}
+	return res
+}
+
+// checkLocality matches the nodes against the filter and the writers of
+// the backup data. It fails if a node outside of the filter wrote data, or
+// if no data file was found. Nodes outside of the filter that cannot reach
+// the storage are expected with locality-restricted egress.
+func checkLocality(filter string, stats []*db.Stats, writers map[int]int) (*LocalityResult, error) {
+	res := &LocalityResult{Filter: filter}
+	seen := make(map[int]bool)
+	for _, s := range stats {
+		seen[s.Node] = true
+		res.Nodes = append(res.Nodes, &LocalityNode{
+			Node:     s.Node,
+			Locality: s.Locality,
+			Matches:  db.MatchesLocality(s.Locality, filter),
+			Check:    checkOutcome(s),
+			Files:    writers[s.Node],
+		})
+	}
+	// Writers missing from the statistics are reported, with an unknown
+	// locality.
+	for node, files := range writers {
+		if !seen[node] {
+			res.Nodes = append(res.Nodes, &LocalityNode{Node: node, Files: files})
+		}
+	}
+	slices.SortFunc(res.Nodes, func(a, b *LocalityNode) int { return a.Node - b.Node })
+	if len(writers) == 0 {
+		return res, errors.New("no backup data file found in the destination")
+	}
+	if unexpected := res.Unexpected(); len(unexpected) > 0 {
+		return res, errors.Newf("nodes %v wrote backup data, but do not match the execution locality %q",
+			unexpected, filter)
+	}
+	return res, nil
+}
+
+// checkOutcome summarizes the outcome of CHECK EXTERNAL CONNECTION on a
+// node. It is unknown for nodes that were not checked.
+func checkOutcome(s *db.Stats) string {
+	switch {
+	case s.Success:
+		return "OK"
+	case s.ErrStr != "":
+		return s.ErrStr
+	default:
+		return ""
+	}
+}
+
+// verifyExecutionLocality lists the backup data files and checks that only
+// the nodes matching the execution locality wrote them. The localities
+// come from the statistics of CHECK EXTERNAL CONNECTION, or from the node
+// descriptors on versions that do not report them.
+func (v *Validator) verifyExecutionLocality(
+	ctx *stopper.Context, stats []*db.Stats,
+) (*LocalityResult, error) {
+	objects, err := v.blobStorage.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the backup files")
+	}
+	if len(stats) == 0 {
+		conn, err := v.acquireConn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Release()
+		nodes, err := db.Nodes(ctx, conn)
+		if err != nil {
+			return nil, withCapabilityHint(errors.Wrap(err, "failed to retrieve the nodes of the cluster"),
+				v.virtualCluster, "can_view_node_info")
+		}
+		for _, n := range nodes {
+			stats = append(stats, &db.Stats{Node: n.Node, Locality: n.Locality})
+		}
+	}
+	res, err := checkLocality(v.env.ExecutionLocality, stats, writerNodes(objects))
+	if err == nil {
+		slog.Info("backup data written only by nodes matching the execution locality",
+			slog.String("filter", v.env.ExecutionLocality))
+	}
+	return res, err
+}
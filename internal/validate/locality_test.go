@@ -0,0 +1,72 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// sst returns the key of a backup data file written by a node.
+func sst(node int, ts uint64) blob.Object {
+	return blob.Object{Key: fmt.Sprintf("2025/10/16-120000.00/data/%d.sst", ts<<nodeIDBits|uint64(node))}
+}
+
+func TestWriterNodes(t *testing.T) {
+	objects := []blob.Object{
+		sst(2, 1000), sst(2, 1001), sst(3, 1000),
+		{Key: "2025/10/16-120000.00/BACKUP_MANIFEST"},
+		{Key: "2025/10/16-120000.00/data/not-a-number.sst"},
+	}
+	assert.Equal(t, map[int]int{2: 2, 3: 1}, writerNodes(objects))
+}
+
+func TestCheckLocality(t *testing.T) {
+	stats := []*db.Stats{
+		{Node: 1, Locality: "region=us-east1", ErrStr: "i/o timeout"},
+		{Node: 2, Locality: "region=us-west1", Success: true},
+		{Node: 3, Locality: "region=us-west1", Success: true},
+	}
+	const filter = "region=us-west1"
+
+	t.Run("honored", func(t *testing.T) {
+		r := require.New(t)
+		res, err := checkLocality(filter, stats, map[int]int{2: 2, 3: 1})
+		r.NoError(err)
+		r.Equal([]*LocalityNode{
+			{Node: 1, Locality: "region=us-east1", Check: "i/o timeout"},
+			{Node: 2, Locality: "region=us-west1", Matches: true, Check: "OK", Files: 2},
+			{Node: 3, Locality: "region=us-west1", Matches: true, Check: "OK", Files: 1},
+		}, res.Nodes)
+	})
+
+	t.Run("violated", func(t *testing.T) {
+		r := require.New(t)
+		res, err := checkLocality(filter, stats, map[int]int{1: 1, 2: 2, 4: 1})
+		r.ErrorContains(err, "nodes [1 4] wrote backup data")
+		r.Equal([]int{1, 4}, res.Unexpected())
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		_, err := checkLocality(filter, stats, map[int]int{})
+		assert.ErrorContains(t, err, "no backup data file")
+	})
+}
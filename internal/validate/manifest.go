@@ -0,0 +1,157 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/manifest"
+)
+
+// ManifestLayer summarizes the manifest of a layer of the backup collection.
+type ManifestLayer struct {
+	Dir        string   // directory of the layer, relative to the destination
+	Full       bool     // whether the layer is a full backup
+	Spans      int      // number of spans backed up by the layer
+	Files      int      // number of data files in the layer
+	External   bool     // the files are listed in separate SSTs, which are not parsed
+	Unresolved []string // data files referenced by the manifest, but missing from the destination
+}
+
+// ManifestResult contains the outcome of the checks of the backup manifests,
+// read directly from the storage provider.
+type ManifestResult struct {
+	Layers   []*ManifestLayer
+	Problems []string // invariants violated by the backup collection
+}
+
+// manifestLayer is a parsed manifest, with the directory it was read from.
+type manifestLayer struct {
+	dir string
+	m   *manifest.Manifest
+}
+
+// checkManifests reads the manifests of the backup collection through the
+// blob layer and checks their invariants, cross-checking SHOW BACKUP.
+func (v *Validator) checkManifests(ctx *stopper.Context) (*ManifestResult, error) {
+	objects, err := v.blobStorage.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the backup files")
+	}
+	var layers []manifestLayer
+	for _, o := range objects {
+		if path.Base(o.Key) != manifest.FileName {
+			continue
+		}
+		data, err := v.blobStorage.Get(ctx, o.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read backup manifest")
+		}
+		m, err := manifest.Parse(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", o.Key)
+		}
+		layers = append(layers, manifestLayer{dir: path.Dir(o.Key), m: m})
+	}
+	res := checkCollection(layers, objects)
+	if len(res.Problems) > 0 {
+		return res, errors.Newf("backup manifests are inconsistent: %s", strings.Join(res.Problems, "; "))
+	}
+	slog.Info("backup manifests verified", slog.Int("layers", len(res.Layers)))
+	return res, nil
+}
+
+// checkCollection checks the invariants of the layers of a backup
+// collection: one full backup followed by incremental backups, each
+// starting where the previous one ended and covering the same spans, with
+// data files inside the spans and present in the destination.
+func checkCollection(layers []manifestLayer, objects []blob.Object) *ManifestResult {
+	keys := make(map[string]bool, len(objects))
+	for _, o := range objects {
+		keys[o.Key] = true
+	}
+	sort.Slice(layers, func(i, j int) bool {
+		a, b := layers[i].m.EndTime, layers[j].m.EndTime
+		return a.WallTime < b.WallTime || (a.WallTime == b.WallTime && a.Logical < b.Logical)
+	})
+	res := &ManifestResult{}
+	problem := func(format string, args ...any) {
+		res.Problems = append(res.Problems, fmt.Sprintf(format, args...))
+	}
+	if len(layers) != expectedBackupCount {
+		problem("expected %d layers, got %d", expectedBackupCount, len(layers))
+	}
+	for i, l := range layers {
+		layer := &ManifestLayer{
+			Dir:   l.dir,
+			Full:  l.m.Full(),
+			Spans: len(l.m.Spans),
+			Files: len(l.m.Files),
+		}
+		res.Layers = append(res.Layers, layer)
+		if i == 0 && !layer.Full {
+			problem("the first layer %s is not a full backup", l.dir)
+		}
+		if i > 0 {
+			prev := layers[i-1].m
+			if layer.Full {
+				problem("layer %s is a full backup, expected an incremental backup", l.dir)
+			} else if l.m.StartTime != prev.EndTime {
+				problem("layer %s starts at %s, but the previous layer ends at %s",
+					l.dir, formatTimestamp(l.m.StartTime), formatTimestamp(prev.EndTime))
+			}
+			for _, s := range layers[0].m.Spans {
+				if !l.m.Covers(s) {
+					problem("layer %s does not cover the span %q-%q of the full backup", l.dir, s.Key, s.EndKey)
+					break
+				}
+			}
+		}
+		if len(l.m.Files) == 0 {
+			// Recent versions list the files in separate SSTs: count the data
+			// files in the directory of the layer instead.
+			for _, o := range objects {
+				if strings.HasPrefix(o.Key, l.dir+"/data/") {
+					layer.Files++
+				}
+			}
+			layer.External = layer.Files > 0
+		}
+		for _, f := range l.m.Files {
+			if !l.m.Covers(f.Span) {
+				problem("file %s in layer %s is outside of the backed up spans", f.Path, l.dir)
+			}
+			if !keys[path.Join(l.dir, f.Path)] {
+				layer.Unresolved = append(layer.Unresolved, f.Path)
+			}
+		}
+		if len(layer.Unresolved) > 0 {
+			problem("%d files referenced by layer %s are missing", len(layer.Unresolved), l.dir)
+		}
+	}
+	return res
+}
+
+// formatTimestamp returns the timestamp in the format used by CockroachDB.
+func formatTimestamp(t manifest.Timestamp) string {
+	return fmt.Sprintf("%d.%010d", t.WallTime, t.Logical)
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/manifest"
+)
+
+func TestCheckCollection(t *testing.T) {
+	table := manifest.Span{Key: []byte("/Table/104"), EndKey: []byte("/Table/105")}
+	file := func(path string) manifest.File {
+		return manifest.File{Span: table, Path: path}
+	}
+	full := manifestLayer{dir: "2025/10/16-120000.00", m: &manifest.Manifest{
+		EndTime: manifest.Timestamp{WallTime: 100},
+		Spans:   []manifest.Span{table},
+		Files:   []manifest.File{file("data/1.sst"), file("data/2.sst")},
+	}}
+	inc := manifestLayer{dir: "incrementals/2025/10/16-120000.00/20251016/120130.00", m: &manifest.Manifest{
+		StartTime: manifest.Timestamp{WallTime: 100},
+		EndTime:   manifest.Timestamp{WallTime: 200},
+		Spans:     []manifest.Span{table},
+	}}
+	objects := []blob.Object{
+		{Key: full.dir + "/BACKUP_MANIFEST"},
+		{Key: full.dir + "/data/1.sst"},
+		{Key: full.dir + "/data/2.sst"},
+		{Key: inc.dir + "/BACKUP_MANIFEST"},
+		{Key: inc.dir + "/data/3.sst"},
+	}
+
+	t.Run("consistent", func(t *testing.T) {
+		r := require.New(t)
+		res := checkCollection([]manifestLayer{inc, full}, objects)
+		r.Empty(res.Problems)
+		r.Equal([]*ManifestLayer{
+			{Dir: full.dir, Full: true, Spans: 1, Files: 2},
+			{Dir: inc.dir, Spans: 1, Files: 1, External: true},
+		}, res.Layers)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		res := checkCollection([]manifestLayer{full, inc}, objects[1:2])
+		assert.Equal(t, []string{"1 files referenced by layer 2025/10/16-120000.00 are missing"}, res.Problems)
+		assert.Equal(t, []string{"data/2.sst"}, res.Layers[0].Unresolved)
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		gap := manifestLayer{dir: inc.dir, m: &manifest.Manifest{
+			StartTime: manifest.Timestamp{WallTime: 150},
+			EndTime:   manifest.Timestamp{WallTime: 200},
+		}}
+		res := checkCollection([]manifestLayer{full, gap}, objects)
+		assert.Len(t, res.Problems, 2)
+		assert.Contains(t, res.Problems[0], "but the previous layer ends at 100.0000000000")
+		assert.Contains(t, res.Problems[1], "does not cover the span")
+	})
+
+	t.Run("no incremental", func(t *testing.T) {
+		res := checkCollection([]manifestLayer{full}, objects)
+		assert.Equal(t, []string{"expected 2 layers, got 1"}, res.Problems)
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/metrics"
+)
+
+// Metrics exported by the nodes that track the interaction of the backup
+// jobs with the external storage.
+const (
+	cloudWriteBytes = "cloud_write_bytes"
+	cloudReadBytes  = "cloud_read_bytes"
+	backupRetries   = "jobs_backup_resume_retry_error"
+	backupFailures  = "jobs_backup_resume_failed"
+)
+
+// scrapeTimeout bounds the time spent scraping the metrics of a node.
+const scrapeTimeout = 10 * time.Second
+
+// NodeMetrics contains the change of the backup metrics of a node during
+// the full backup.
+type NodeMetrics struct {
+	URL          string // base URL of the DB Console of the node
+	BytesWritten int64  // bytes sent to external storage
+	BytesRead    int64  // bytes read from external storage
+	Retries      int64  // backup jobs retried after a transient error
+	Failures     int64  // backup jobs failed
+	Err          string // error scraping the node, if any
+}
+
+// MetricsResult correlates the storage-level view of the full backup,
+// scraped from the DB Console metrics, with the SQL-level view, based on
+// the size of the backup.
+type MetricsResult struct {
+	Nodes      []*NodeMetrics
+	BackupTime time.Duration // time taken by the full backup
+	BackupSize int64         // size of the table in the full backup, as reported by SHOW BACKUP
+}
+
+// BytesWritten returns the bytes sent to external storage by all the nodes.
+func (m *MetricsResult) BytesWritten() int64 {
+	var res int64
+	for _, n := range m.Nodes {
+		res += n.BytesWritten
+	}
+	return res
+}
+
+// StorageThroughput returns the rate, in bytes per second, at which the
+// nodes sent data to external storage during the full backup.
+func (m *MetricsResult) StorageThroughput() float64 {
+	if m.BackupTime <= 0 {
+		return 0
+	}
+	return float64(m.BytesWritten()) / m.BackupTime.Seconds()
+}
+
+// SQLThroughput returns the rate, in bytes per second, of the full backup
+// as seen from SQL.
+func (m *MetricsResult) SQLThroughput() float64 {
+	if m.BackupTime <= 0 {
+		return 0
+	}
+	return float64(m.BackupSize) / m.BackupTime.Seconds()
+}
+
+// checkMetricsURLs validates the base URLs of the DB Console of the nodes.
+func checkMetricsURLs(urls []string) error {
+	for _, s := range urls {
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Newf("invalid metrics URL %q: expected http(s)://host:port", s)
+		}
+	}
+	return nil
+}
+
+// metricsScraper scrapes the metrics of the nodes before and after the full
+// backup.
+type metricsScraper struct {
+	client *http.Client
+	urls   []string
+}
+
+// newMetricsScraper returns a scraper for the DB Console URLs of the
+// environment. The servers are verified with the CA certificate of the
+// certs directory, if provided.
+func newMetricsScraper(env *env.Env) (*metricsScraper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if env.Dial != nil {
+		transport.DialContext = env.Dial
+	}
+	if env.CertsDir != "" {
+		pemData, err := os.ReadFile(filepath.Join(env.CertsDir, "ca.crt"))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read CA certificate")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, errors.New("invalid CA certificate")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &metricsScraper{
+		client: &http.Client{Transport: transport, Timeout: scrapeTimeout},
+		urls:   env.MetricsURLs,
+	}, nil
+}
+
+// scrape retrieves the metrics of every node. Failures are returned in
+// place of the sample, since the metrics are informational only.
+func (s *metricsScraper) scrape(ctx *stopper.Context) ([]metrics.Sample, []error) {
+	samples := make([]metrics.Sample, len(s.urls))
+	errs := make([]error, len(s.urls))
+	for i, u := range s.urls {
+		samples[i], errs[i] = metrics.Scrape(ctx, s.client, u)
+		if errs[i] != nil {
+			slog.Warn("failed to scrape metrics", slog.String("url", u), slog.Any("error", errs[i]))
+		}
+	}
+	return samples, errs
+}
+
+// compare returns the change of the backup metrics of every node between
+// two scrapes.
+func (s *metricsScraper) compare(
+	before, after []metrics.Sample, errs []error, elapsed time.Duration,
+) *MetricsResult {
+	res := &MetricsResult{BackupTime: elapsed}
+	for i, u := range s.urls {
+		n := &NodeMetrics{URL: u}
+		if errs[i] != nil {
+			n.Err = errs[i].Error()
+		} else if before[i] == nil {
+			n.Err = "metrics not available before the backup"
+		} else {
+			delta := func(name string) int64 { return int64(metrics.Delta(before[i], after[i], name)) }
+			n.BytesWritten = delta(cloudWriteBytes)
+			n.BytesRead = delta(cloudReadBytes)
+			n.Retries = delta(backupRetries)
+			n.Failures = delta(backupFailures)
+		}
+		res.Nodes = append(res.Nodes, n)
+	}
+	return res
+}
+
+// metricsResult returns the backup metrics of the run, or nil if they were
+// not scraped.
+func (v *Validator) metricsResult() *MetricsResult {
+	if v.metrics == nil {
+		return nil
+	}
+	res := *v.metrics
+	res.BackupSize = v.fullBackupSize
+	return &res
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/metrics"
+)
+
+func TestCheckMetricsURLs(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(checkMetricsURLs(nil))
+	a.NoError(checkMetricsURLs([]string{"https://node1:8080", "http://localhost:8080/"}))
+	a.Error(checkMetricsURLs([]string{"node1:8080"}))
+	a.Error(checkMetricsURLs([]string{"ftp://node1"}))
+}
+
+func TestCompareMetrics(t *testing.T) {
+	a := assert.New(t)
+	s := &metricsScraper{urls: []string{"http://n1:8080", "http://n2:8080", "http://n3:8080"}}
+	before := []metrics.Sample{
+		{cloudWriteBytes: 100, backupRetries: 1},
+		nil,
+		{cloudWriteBytes: 100},
+	}
+	after := []metrics.Sample{
+		{cloudWriteBytes: 1100, cloudReadBytes: 10, backupRetries: 2},
+		{cloudWriteBytes: 500},
+		nil,
+	}
+	errs := []error{nil, nil, errors.New("connection refused")}
+	res := s.compare(before, after, errs, 2*time.Second)
+	a.Equal([]*NodeMetrics{
+		{URL: "http://n1:8080", BytesWritten: 1000, BytesRead: 10, Retries: 1},
+		{URL: "http://n2:8080", Err: "metrics not available before the backup"},
+		{URL: "http://n3:8080", Err: "connection refused"},
+	}, res.Nodes)
+	a.Equal(int64(1000), res.BytesWritten())
+	a.InDelta(500, res.StorageThroughput(), 0.001)
+	res.BackupSize = 800
+	a.InDelta(400, res.SQLThroughput(), 0.001)
+}
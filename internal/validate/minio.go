@@ -0,0 +1,31 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+// CheckMinIO describes the MinIO deployment serving the destination, or
+// returns nil if the store does not report it.
+func CheckMinIO(ctx context.Context, store blob.Storage) (*blob.MinIOInfo, error) {
+	reporter, ok := store.(blob.MinIOReporter)
+	if !ok {
+		return nil, nil
+	}
+	return reporter.MinIO(ctx)
+}
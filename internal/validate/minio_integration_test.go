@@ -95,6 +95,7 @@ func TestMinio(t *testing.T) {
 	r.NoError(err)
 	validator, err := New(ctx, env, blobStorage)
 	r.NoError(err)
+	defer validator.Close()
 	defer validator.Clean(ctx)
 	report, err := validator.Validate(ctx)
 	r.NoError(err)
@@ -147,6 +148,7 @@ func TestBackupErrorIsReported(t *testing.T) {
 	// The backup failure below stops the stopper context, so clean up with a
 	// separate context that is still usable for database access.
 	cleanupCtx := stopper.WithContext(t.Context())
+	defer validator.Close()
 	defer validator.Clean(cleanupCtx)
 
 	conn, err := validator.pool.Acquire(ctx)
@@ -0,0 +1,107 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/workload"
+)
+
+// maxDivergences is the number of diverging keys reported as examples.
+const maxDivergences = 5
+
+// OracleResult compares the restored table with the rows recorded by the
+// workload, row by row.
+type OracleResult struct {
+	Checked    int      // rows recorded by the oracle
+	Missing    int      // recorded rows missing from the restored table
+	Mismatched int      // recorded rows restored with another value
+	Examples   []string // some of the diverging keys, sorted
+}
+
+// OK reports whether every recorded row was restored.
+func (r *OracleResult) OK() bool {
+	return r.Missing == 0 && r.Mismatched == 0
+}
+
+// String describes the divergences.
+func (r *OracleResult) String() string {
+	return fmt.Sprintf("%d of %d rows written by the workload are missing and %d have another value (e.g. %s)",
+		r.Missing, r.Checked, r.Mismatched, strings.Join(r.Examples, ", "))
+}
+
+// newOracle returns the oracle recording the rows written by the workload,
+// or nil if disabled.
+func newOracle(env *env.Env) *workload.Oracle {
+	if env.OracleSample <= 0 {
+		return nil
+	}
+	return workload.NewOracle(env.OracleSample)
+}
+
+// checkOracleSample verifies that the fraction of rows recorded by the
+// oracle is valid.
+func checkOracleSample(sample float64) error {
+	if sample < 0 || sample > 1 {
+		return errors.New("oracle sample must be a fraction between 0 and 1")
+	}
+	return nil
+}
+
+// checkOracle reads the rows recorded by the oracle from the restored
+// table, and compares their values.
+func (v *Validator) checkOracle(ctx *stopper.Context, conn *pgxpool.Conn) (*OracleResult, error) {
+	expected := v.oracle.Rows()
+	keys := slices.Sorted(maps.Keys(expected))
+	got, err := v.restoredTable.Values(ctx, conn, keys)
+	if err != nil {
+		return nil, err
+	}
+	res := compareRows(keys, expected, got)
+	slog.Info("restored rows verified", slog.Int("checked", res.Checked),
+		slog.Int("missing", res.Missing), slog.Int("mismatched", res.Mismatched))
+	return res, nil
+}
+
+// compareRows compares the restored values of the sorted keys with the
+// expected ones.
+func compareRows(keys []string, expected, got map[string]string) *OracleResult {
+	res := &OracleResult{Checked: len(keys)}
+	for _, k := range keys {
+		value, ok := got[k]
+		switch {
+		case !ok:
+			res.Missing++
+		case value != expected[k]:
+			res.Mismatched++
+		default:
+			continue
+		}
+		if len(res.Examples) < maxDivergences {
+			res.Examples = append(res.Examples, k)
+		}
+	}
+	return res
+}
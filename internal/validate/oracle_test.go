@@ -0,0 +1,35 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareRows(t *testing.T) {
+	a := assert.New(t)
+	expected := map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"}
+	res := compareRows([]string{"a", "b", "c", "d"}, expected, map[string]string{"a": "1", "c": "x", "d": "4"})
+	a.Equal(&OracleResult{Checked: 4, Missing: 1, Mismatched: 1, Examples: []string{"b", "c"}}, res)
+	a.False(res.OK())
+	a.Equal("1 of 4 rows written by the workload are missing and 1 have another value (e.g. b, c)", res.String())
+
+	a.True(compareRows([]string{"a"}, expected, map[string]string{"a": "1"}).OK())
+
+	a.NoError(checkOracleSample(0.5))
+	a.Error(checkOracleSample(1.5))
+}
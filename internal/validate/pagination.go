@@ -0,0 +1,36 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// ProbePagination verifies the list pagination and delimiters of the
+// storage, if enabled in the environment. It returns nil if the probe is
+// disabled, or if the storage provider does not support it.
+func ProbePagination(ctx context.Context, env *env.Env, store blob.Storage) (*blob.Pagination, error) {
+	if !env.ProbePagination {
+		return nil, nil
+	}
+	prober, ok := store.(blob.PaginationProber)
+	if !ok {
+		return nil, nil
+	}
+	return prober.ProbePagination(ctx)
+}
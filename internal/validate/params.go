@@ -0,0 +1,54 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"maps"
+	"slices"
+)
+
+// ParamChange compares a parameter provided by the user with the value
+// suggested by blobcheck.
+type ParamChange struct {
+	Param     string
+	Provided  string // value provided by the user; empty if not set
+	Suggested string // suggested value; empty if the parameter should be removed
+}
+
+// Added reports whether the parameter must be added to the provided ones.
+func (c ParamChange) Added() bool {
+	return c.Provided == "" && c.Suggested != ""
+}
+
+// Changed reports whether the provided value must be changed or removed.
+func (c ParamChange) Changed() bool {
+	return c.Provided != "" && c.Provided != c.Suggested
+}
+
+// ParamChanges compares the parameters provided by the user with the
+// suggested ones, sorted by name. It returns nil if the storage uses the
+// parameters as provided.
+func (r *Report) ParamChanges() []ParamChange {
+	if r.ProvidedParams == nil || r.SuggestedParams == nil {
+		return nil
+	}
+	keys := slices.AppendSeq(slices.Collect(maps.Keys(r.ProvidedParams)), maps.Keys(r.SuggestedParams))
+	slices.Sort(keys)
+	var res []ParamChange
+	for _, k := range slices.Compact(keys) {
+		res = append(res, ParamChange{Param: k, Provided: r.ProvidedParams[k], Suggested: r.SuggestedParams[k]})
+	}
+	return res
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+func TestParamChanges(t *testing.T) {
+	a := assert.New(t)
+	report := &Report{
+		SuggestedParams: blob.Params{
+			blob.AccountParam:      "AKIAEXAMPLE",
+			blob.RegionParam:       "eu-west-1",
+			blob.UsePathStyleParam: "true",
+		},
+	}
+	a.Nil(report.ParamChanges(), "the storage uses the parameters as provided")
+
+	report.ProvidedParams = blob.Params{
+		blob.AccountParam: "AKIAEXAMPLE",
+		blob.RegionParam:  "us-east-1",
+		blob.AuthParam:    "specified",
+	}
+	changes := report.ParamChanges()
+	a.Equal([]ParamChange{
+		{Param: blob.AuthParam, Provided: "specified"},
+		{Param: blob.AccountParam, Provided: "AKIAEXAMPLE", Suggested: "AKIAEXAMPLE"},
+		{Param: blob.RegionParam, Provided: "us-east-1", Suggested: "eu-west-1"},
+		{Param: blob.UsePathStyleParam, Suggested: "true"},
+	}, changes)
+	var added, changed []string
+	for _, c := range changes {
+		if c.Added() {
+			added = append(added, c.Param)
+		}
+		if c.Changed() {
+			changed = append(changed, c.Param)
+		}
+	}
+	a.Equal([]string{blob.UsePathStyleParam}, added)
+	a.Equal([]string{blob.AuthParam, blob.RegionParam}, changed)
+}
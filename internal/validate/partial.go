@@ -0,0 +1,119 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// Scopes of the backups.
+const (
+	ScopeTable    = "table"
+	ScopeDatabase = "database"
+)
+
+const (
+	companionRows      = 10000 // rows of the companion table
+	companionValueSize = 1024  // size of the values of the companion table
+)
+
+// PartialRestoreResult reports the restore of the source table out of a
+// backup of its whole database.
+type PartialRestoreResult struct {
+	Database string   // database backed up
+	Backup   []string // tables in the backup
+	Restored []string // tables in the restored database
+}
+
+// OK reports whether only the source table was restored.
+func (r *PartialRestoreResult) OK() bool {
+	return len(r.Restored) == 1
+}
+
+// checkBackupScope verifies the scope of the backups. Backing up a
+// database is only allowed for the database created by blobcheck, which
+// does not contain the customer's tables.
+func checkBackupScope(env *env.Env) error {
+	switch env.BackupScope {
+	case "", ScopeTable:
+		return nil
+	case ScopeDatabase:
+		if env.Database != "" {
+			return errors.Newf("backup scope %s cannot be used with --database, which holds the customer's tables",
+				ScopeDatabase)
+		}
+		return nil
+	default:
+		return errors.Newf("invalid backup scope %q: must be %s or %s", env.BackupScope, ScopeTable, ScopeDatabase)
+	}
+}
+
+// companionTable returns the table backed up along with the source table,
+// when backing up the whole database.
+func (v *Validator) companionTable() db.KvTable {
+	table := v.sourceTable
+	table.Name += "_companion"
+	return table
+}
+
+// createCompanionTable creates and fills a second table in the source
+// database, so that restoring the source table out of the database backup
+// reads only part of the backup files.
+func (v *Validator) createCompanionTable(ctx *stopper.Context) error {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	table := v.companionTable()
+	slog.Info("creating companion table", slog.String("table", table.String()), slog.Int("rows", companionRows))
+	if err := table.Create(ctx, conn); err != nil {
+		return errors.Wrap(err, "failed to create companion table")
+	}
+	if err := table.Generate(ctx, conn, companionRows, companionValueSize); err != nil {
+		return errors.Wrap(err, "failed to fill companion table")
+	}
+	return nil
+}
+
+// checkPartialRestore verifies that restoring the source table out of the
+// database backup restored that table only.
+func (v *Validator) checkPartialRestore(ctx *stopper.Context) (*PartialRestoreResult, error) {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	res := &PartialRestoreResult{Database: v.sourceTable.Database.String()}
+	if res.Backup, err = v.sourceTable.Database.Tables(ctx, conn); err != nil {
+		return nil, errors.Wrap(err, "failed to list the tables of the source database")
+	}
+	if res.Restored, err = v.restoredTable.Database.Tables(ctx, conn); err != nil {
+		return nil, errors.Wrap(err, "failed to list the tables of the restored database")
+	}
+	slog.Info("partial restore checked", slog.Any("backup", res.Backup), slog.Any("restored", res.Restored))
+	if !res.OK() {
+		return res, errors.Newf("restoring table %s out of the database backup restored %v",
+			v.restoredTable.LocalName(), res.Restored)
+	}
+	return res, nil
+}
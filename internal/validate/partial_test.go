@@ -0,0 +1,36 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestCheckBackupScope(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(checkBackupScope(&env.Env{}))
+	a.NoError(checkBackupScope(&env.Env{BackupScope: ScopeTable, Database: "defaultdb"}))
+	a.NoError(checkBackupScope(&env.Env{BackupScope: ScopeDatabase}))
+	a.ErrorContains(checkBackupScope(&env.Env{BackupScope: ScopeDatabase, Database: "defaultdb"}),
+		"cannot be used with --database")
+	a.ErrorContains(checkBackupScope(&env.Env{BackupScope: "cluster"}), "invalid backup scope")
+
+	a.True((&PartialRestoreResult{Restored: []string{"public.t"}}).OK())
+	a.False((&PartialRestoreResult{Restored: []string{"public.t", "public.t_companion"}}).OK())
+}
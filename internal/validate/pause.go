@@ -0,0 +1,209 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/workload"
+)
+
+// PauseResult compares the rows written by the workload between the full
+// and the incremental backups, counted while the workload was paused, with
+// the rows in the incremental layer.
+type PauseResult struct {
+	Delta  int64         // rows written after the full backup
+	Layer  int64         // rows of the table in the incremental layer, -1 if unknown
+	Paused time.Duration // time the workload was paused for the incremental backup
+	Exact  bool          // false if a schema change rewrote the existing rows
+}
+
+// Match reports whether the incremental layer holds exactly the rows
+// written between the backups.
+func (r *PauseResult) Match() bool {
+	return r.Delta == r.Layer
+}
+
+// pausedWorkload runs the workload in the background from the start of the
+// full backup until the end of the run, and pauses it during the
+// incremental backup, so that the data in the incremental layer is known
+// exactly.
+type pausedWorkload struct {
+	control     workload.Control
+	done        chan bool
+	stopOnce    sync.Once
+	wg          sync.WaitGroup
+	mu          sync.Mutex
+	errs        []error
+	pausedAt    time.Time
+	fingerprint string // fingerprint of the source table while paused
+	result      *PauseResult
+}
+
+// startPausedWorkers starts the workers, which run until stopWorkload.
+func (v *Validator) startPausedWorkers(ctx *stopper.Context) {
+	v.paused = &pausedWorkload{done: make(chan bool)}
+	p := v.paused
+	for w := range v.env.Workers {
+		p.wg.Add(1)
+		accepted := ctx.Go(func(ctx *stopper.Context) error {
+			defer p.wg.Done()
+			slog.Info("starting", "worker", w)
+			err := v.runControlledWorkload(ctx, &p.control, p.done)
+			if err != nil {
+				p.mu.Lock()
+				p.errs = append(p.errs, err)
+				p.mu.Unlock()
+			}
+			return err
+		})
+		if !accepted {
+			p.wg.Done()
+		}
+	}
+}
+
+// runControlledWorkload runs the workload until done is closed, waiting
+// while the control is paused.
+func (v *Validator) runControlledWorkload(
+	ctx *stopper.Context, control *workload.Control, done <-chan bool,
+) error {
+	conn, err := v.workloadPool().Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	w := workload.Workload{
+		Prefix:  uuid.New().String(),
+		Table:   v.sourceTable,
+		Control: control,
+		Oracle:  v.oracle,
+	}
+	return w.Run(ctx, conn, done)
+}
+
+// pauseWorkload pauses the workers before the incremental backup, and
+// counts the rows written since the full backup. The fingerprint of the
+// source table is taken while paused, since it is the data captured by
+// the incremental backup.
+func (v *Validator) pauseWorkload(
+	ctx *stopper.Context, conn *pgxpool.Conn, extConn *db.ExternalConn,
+) error {
+	p := v.paused
+	slog.Info("pausing the workload for the incremental backup")
+	p.control.Pause()
+	p.pausedAt = time.Now()
+	// The writes that follow are not in the backups.
+	v.oracle.Freeze()
+	backups, err := extConn.ListTableBackups(ctx, conn)
+	if err != nil {
+		return errors.Wrap(err, "failed to list table backups")
+	}
+	if len(backups) == 0 {
+		return errors.New("the full backup is not in the destination")
+	}
+	info, err := extConn.BackupInfo(ctx, conn, backups[0], v.sourceTable)
+	if err != nil {
+		return errors.Wrap(err, "failed to get backup info")
+	}
+	var full int64
+	for _, i := range info {
+		if i.Full {
+			full = i.Rows
+		}
+	}
+	rows, err := v.sourceTable.Count(ctx, conn)
+	if err != nil {
+		return errors.Wrap(err, "failed to count the rows of the source table")
+	}
+	if p.fingerprint, err = v.sourceTable.Fingerprint(ctx, conn); err != nil {
+		return errors.Wrap(err, "failed to get original table fingerprint")
+	}
+	p.result = &PauseResult{Delta: rows - full, Layer: -1, Exact: v.env.SchemaChange == ""}
+	slog.Info("workload paused", slog.Int64("rows_since_full_backup", p.result.Delta))
+	return nil
+}
+
+// resumeWorkload resumes the workers after the incremental backup.
+func (v *Validator) resumeWorkload() {
+	p := v.paused
+	if p.result != nil {
+		p.result.Paused = time.Since(p.pausedAt)
+	}
+	p.control.Resume()
+	slog.Info("workload resumed")
+}
+
+// checkIncrementalRows compares the rows of the incremental layer with the
+// rows written between the backups.
+func (v *Validator) checkIncrementalRows(info []db.TableBackup) error {
+	res := v.paused.result
+	if res == nil {
+		return nil
+	}
+	for _, i := range info {
+		if !i.Full {
+			res.Layer = i.Rows
+		}
+	}
+	if res.Exact && !res.Match() {
+		return errors.Newf("the incremental backup has %d rows of the source table, but %d were written since the full backup",
+			res.Layer, res.Delta)
+	}
+	return nil
+}
+
+// stopWorkload stops the workers, and returns the errors they returned.
+func (v *Validator) stopWorkload() error {
+	p := v.paused
+	if p == nil {
+		return nil
+	}
+	p.stopOnce.Do(func() {
+		p.control.Resume()
+		close(p.done)
+	})
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return errors.Join(p.errs...)
+}
+
+// sourceFingerprint returns the fingerprint of the data expected in the
+// restored table: the one taken while the workload was paused, if the
+// workload kept running after the incremental backup.
+func (v *Validator) sourceFingerprint(ctx *stopper.Context, conn *pgxpool.Conn) (string, error) {
+	if v.paused != nil && v.paused.fingerprint != "" {
+		return v.paused.fingerprint, nil
+	}
+	return v.sourceTable.Fingerprint(ctx, conn)
+}
+
+// pauseResult returns the outcome of pausing the workload, or nil if the
+// workload was not paused.
+func (v *Validator) pauseResult() *PauseResult {
+	if v.paused == nil {
+		return nil
+	}
+	return v.paused.result
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+func TestCheckIncrementalRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		exact   bool
+		layer   int64
+		wantErr bool
+	}{
+		{name: "match", exact: true, layer: 100},
+		{name: "missing rows", exact: true, layer: 90, wantErr: true},
+		{name: "rewritten by a schema change", exact: false, layer: 250},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			v := &Validator{paused: &pausedWorkload{result: &PauseResult{Delta: 100, Layer: -1, Exact: tt.exact}}}
+			err := v.checkIncrementalRows([]db.TableBackup{
+				{Full: false, Rows: tt.layer},
+				{Full: true, Rows: 1000},
+			})
+			if tt.wantErr {
+				a.ErrorContains(err, "has 90 rows of the source table, but 100 were written")
+			} else {
+				a.NoError(err)
+			}
+			a.Equal(tt.layer, v.pauseResult().Layer)
+			a.Equal(tt.layer == 100, v.pauseResult().Match())
+		})
+	}
+	a := assert.New(t)
+	a.Nil((&Validator{}).pauseResult())
+	a.NoError((&Validator{}).stopWorkload())
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// populationPollInterval is the interval between the measurements of the
+// source table while the initial workload runs towards its target size.
+const populationPollInterval = 2 * time.Second
+
+// populationTarget is the size the initial workload grows the source table
+// to, so that the backups of clusters with different write throughput
+// transfer comparable amounts of data.
+type populationTarget struct {
+	rows    int64         // minimum number of rows (0 for no minimum)
+	bytes   int64         // minimum size in bytes (0 for no minimum)
+	maxTime time.Duration // time after which the workload stops, even if the target is not reached
+}
+
+// populationTargetFromEnv returns the target size configured in the
+// environment.
+func populationTargetFromEnv(env *env.Env) populationTarget {
+	return populationTarget{rows: env.MinRows, bytes: env.MinBytes, maxTime: env.WorkloadMaxDuration}
+}
+
+// set reports whether a minimum size is configured.
+func (t populationTarget) set() bool {
+	return t.rows > 0 || t.bytes > 0
+}
+
+// reached reports whether the table meets every minimum of the target.
+func (t populationTarget) reached(rows, bytes int64) bool {
+	return rows >= t.rows && bytes >= t.bytes
+}
+
+// PopulationResult describes the source table populated by the initial
+// workload, when it runs until a target size is reached.
+type PopulationResult struct {
+	MinRows  int64         // minimum number of rows requested
+	MinBytes int64         // minimum size in bytes requested
+	Rows     int64         // rows in the table when the workload stopped, if measured
+	Bytes    int64         // size of the table when the workload stopped, if measured
+	Elapsed  time.Duration // time the workload ran
+	Reached  bool          // whether the target was reached before the cap
+	MaxTime  time.Duration // cap on the time the workload runs
+}
+
+// populate runs the initial workload until the source table reaches the
+// target size, or the cap of the target elapses. Only the minimums of the
+// target are measured, since counting the rows scans the table.
+func (v *Validator) populate(ctx *stopper.Context, target populationTarget) (*PopulationResult, error) {
+	res := &PopulationResult{MinRows: target.rows, MinBytes: target.bytes, MaxTime: target.maxTime}
+	measure := func() {
+		conn, err := v.acquireConn(ctx)
+		if err != nil {
+			slog.Debug("failed to measure the source table", slog.Any("error", err))
+			return
+		}
+		defer conn.Release()
+		if target.rows > 0 {
+			if rows, err := v.sourceTable.Count(ctx, conn); err == nil {
+				res.Rows = rows
+			} else {
+				slog.Debug("failed to count the rows of the source table", slog.Any("error", err))
+			}
+		}
+		if target.bytes > 0 {
+			res.Bytes = v.tableSize(ctx, conn)
+		}
+		res.Reached = target.reached(res.Rows, res.Bytes)
+	}
+	start := time.Now()
+	stop, exited := v.startWorkload(ctx)
+	deadline := time.After(target.maxTime)
+	poll := time.NewTicker(populationPollInterval)
+	defer poll.Stop()
+wait:
+	for !res.Reached {
+		select {
+		case <-poll.C:
+			measure()
+			slog.Debug("populating the source table", slog.Int64("rows", res.Rows), slog.Int64("bytes", res.Bytes))
+		case <-deadline:
+			slog.Warn("the source table did not reach the target size within the maximum workload duration",
+				slog.Int64("rows", res.Rows), slog.Int64("bytes", res.Bytes), slog.Duration("max", target.maxTime))
+			break wait
+		case <-exited:
+			break wait
+		case <-ctx.Stopping():
+			break wait
+		}
+	}
+	err := stop()
+	res.Elapsed = time.Since(start)
+	if err != nil || ctx.IsStopping() {
+		return nil, err
+	}
+	if !res.Reached {
+		// The last measurement may predate the final writes.
+		measure()
+	}
+	slog.Info("source table populated", slog.Int64("rows", res.Rows), slog.Int64("bytes", res.Bytes),
+		slog.Duration("elapsed", res.Elapsed.Round(time.Second)))
+	return res, nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestPopulationTarget(t *testing.T) {
+	a := assert.New(t)
+	a.False(populationTargetFromEnv(&env.Env{WorkloadMaxDuration: time.Minute}).set())
+
+	rows := populationTargetFromEnv(&env.Env{MinRows: 1000, WorkloadMaxDuration: time.Minute})
+	a.True(rows.set())
+	a.Equal(time.Minute, rows.maxTime)
+	a.False(rows.reached(999, 0))
+	a.True(rows.reached(1000, 0), "the size is not measured without --min-bytes")
+
+	both := populationTarget{rows: 1000, bytes: 1 << 20}
+	a.False(both.reached(5000, 1<<19), "every minimum must be reached")
+	a.False(both.reached(500, 1<<21))
+	a.True(both.reached(1000, 1<<20))
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// ProbeListing times the listings of a growing prefix, with the number of
+// objects set in the environment. It returns nil if the probe is disabled,
+// and an error if the storage provider does not support it.
+func ProbeListing(ctx context.Context, env *env.Env, store blob.Storage) ([]blob.ListingSample, error) {
+	if env.ObjectCount <= 0 {
+		return nil, nil
+	}
+	prober, ok := store.(blob.ListingProber)
+	if !ok {
+		return nil, errors.Wrap(blob.ErrUnsupported, "the listing probe requires an S3 destination")
+	}
+	return prober.ProbeListing(ctx, env.ObjectCount)
+}
+
+// ProbeKeys reports which classes of object keys are safe. It returns nil
+// if the storage provider does not support the probe.
+func ProbeKeys(ctx context.Context, store blob.Storage) ([]blob.KeyCheck, error) {
+	prober, ok := store.(blob.KeyProber)
+	if !ok {
+		return nil, nil
+	}
+	return prober.ProbeKeys(ctx)
+}
+
+// ProbeArchival checks whether the objects can transition to an archival
+// storage class. It returns nil if the storage provider does not support
+// the probe.
+func ProbeArchival(ctx context.Context, store blob.Storage) (*blob.Archival, error) {
+	prober, ok := store.(blob.ArchivalProber)
+	if !ok {
+		return nil, nil
+	}
+	return prober.ProbeArchival(ctx)
+}
+
+// ProbeEndpoints probes the suggested configuration against each endpoint
+// provided by the user. It returns nil if a single endpoint was provided,
+// or if the storage provider does not support the probe.
+func ProbeEndpoints(ctx context.Context, store blob.Storage) (*blob.Failover, error) {
+	prober, ok := store.(blob.EndpointProber)
+	if !ok {
+		return nil, nil
+	}
+	return prober.ProbeEndpoints(ctx)
+}
+
+// CheckProxy reports whether the selected configuration goes through the
+// HTTP proxy. It returns nil if no proxy is configured, or if the storage
+// provider does not report it.
+func CheckProxy(store blob.Storage) *blob.ProxyCheck {
+	reporter, ok := store.(blob.ProxyReporter)
+	if !ok {
+		return nil
+	}
+	return reporter.Proxy()
+}
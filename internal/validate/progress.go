@@ -0,0 +1,112 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// jobsSource returns the progress of the jobs reported by the heartbeat.
+type jobsSource func(ctx *stopper.Context) ([]db.JobProgress, error)
+
+// heartbeat periodically logs the progress of the backup and restore jobs
+// running against the source table, so that operators know that a long
+// running statement is not hung. The returned function stops the heartbeat
+// and must be called once the step is complete. The estimate, if known, is
+// used to report the time remaining until the job reports its own progress.
+func (v *Validator) heartbeat(ctx *stopper.Context, step string, estimate time.Duration) func() {
+	return heartbeat(ctx, v.env.HeartbeatInterval, step, estimate, v.sourceJobs)
+}
+
+// sourceJobs returns the progress of the jobs running against the source
+// table.
+func (v *Validator) sourceJobs(ctx *stopper.Context) ([]db.JobProgress, error) {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return v.sourceTable.JobsProgress(ctx, conn)
+}
+
+// heartbeat logs the progress of the jobs every interval, until the
+// returned function is called. A non-positive interval disables it.
+func heartbeat(
+	ctx *stopper.Context, interval time.Duration, step string, estimate time.Duration, jobs jobsSource,
+) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	var g sync.WaitGroup
+	g.Add(1)
+	accepted := ctx.Go(func(ctx *stopper.Context) error {
+		defer g.Done()
+		start := time.Now()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Stopping():
+				return nil
+			case <-ticker.C:
+				logProgress(ctx, step, estimate, time.Since(start), jobs)
+			}
+		}
+	})
+	if !accepted {
+		g.Done()
+	}
+	return func() {
+		close(done)
+		g.Wait()
+	}
+}
+
+// logProgress logs the fraction completed of the jobs. Failures are logged
+// at debug level, since the heartbeat is informational only.
+func logProgress(
+	ctx *stopper.Context, step string, estimate, elapsed time.Duration, source jobsSource,
+) {
+	jobs, err := source(ctx)
+	if err != nil {
+		slog.Debug("failed to check job progress", slog.Any("error", err))
+		return
+	}
+	if len(jobs) == 0 {
+		attrs := []any{slog.String("step", step), slog.Duration("elapsed", elapsed.Round(time.Second))}
+		if estimate > 0 {
+			attrs = append(attrs, slog.Duration("eta", remaining(estimate, elapsed, 0).Round(time.Second)))
+		}
+		slog.Info("still running", attrs...)
+		return
+	}
+	for _, job := range jobs {
+		slog.Info("still running", slog.String("step", step),
+			slog.Duration("elapsed", elapsed.Round(time.Second)),
+			slog.Int64("job_id", job.ID),
+			slog.String("job_type", job.Type),
+			slog.String("fraction_completed", fmt.Sprintf("%.0f%%", job.Fraction*100)),
+			slog.Duration("eta", remaining(estimate, elapsed, job.Fraction).Round(time.Second)))
+	}
+}
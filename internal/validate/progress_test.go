@@ -0,0 +1,150 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// captureLogs redirects the default logger to the returned buffer for the
+// duration of the test.
+func captureLogs(t *testing.T) *syncBuffer {
+	buf := &syncBuffer{}
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return buf
+}
+
+// syncBuffer is a buffer safe for concurrent use by the heartbeat and the
+// test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestHeartbeat(t *testing.T) {
+	logs := captureLogs(t)
+	ctx := stopper.WithContext(t.Context())
+	polled := make(chan struct{}, 1)
+	source := func(*stopper.Context) ([]db.JobProgress, error) {
+		select {
+		case polled <- struct{}{}:
+		default:
+		}
+		return []db.JobProgress{{ID: 42, Type: "BACKUP", Fraction: 0.25}}, nil
+	}
+
+	stop := heartbeat(ctx, 5*time.Millisecond, "full backup", time.Hour, source)
+	select {
+	case <-polled:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the heartbeat did not poll the jobs")
+	}
+	stop()
+	out := logs.String()
+	assert.Contains(t, out, `msg="still running" step="full backup"`)
+	assert.Contains(t, out, "job_id=42 job_type=BACKUP fraction_completed=25%")
+	// The job reports its progress: the estimate is ignored.
+	assert.Contains(t, out, "eta=0s")
+	assert.NotContains(t, out, "eta=1h")
+
+	// A disabled heartbeat never polls the jobs.
+	calls := 0
+	counting := func(*stopper.Context) ([]db.JobProgress, error) {
+		calls++
+		return nil, nil
+	}
+	heartbeat(ctx, 0, "restore", 0, counting)()
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, calls)
+}
+
+func TestLogProgress(t *testing.T) {
+	ctx := stopper.WithContext(t.Context())
+	tests := []struct {
+		name     string
+		estimate time.Duration
+		jobs     []db.JobProgress
+		err      error
+		want     []string
+	}{
+		{
+			name:     "job progress",
+			estimate: time.Hour,
+			jobs:     []db.JobProgress{{ID: 1, Type: "RESTORE", Fraction: 0.25}},
+			// 1m elapsed for a quarter of the job leaves 3m.
+			want: []string{"elapsed=1m0s job_id=1 job_type=RESTORE fraction_completed=25% eta=3m0s"},
+		},
+		{
+			name: "several jobs",
+			jobs: []db.JobProgress{{ID: 1, Type: "BACKUP", Fraction: 0.5}, {ID: 2, Type: "BACKUP", Fraction: 0.75}},
+			want: []string{
+				"job_id=1 job_type=BACKUP fraction_completed=50% eta=1m0s",
+				"job_id=2 job_type=BACKUP fraction_completed=75% eta=20s",
+			},
+		},
+		{
+			name:     "no job yet",
+			estimate: 5 * time.Minute,
+			want:     []string{`msg="still running" step=restore elapsed=1m0s eta=4m0s`},
+		},
+		{
+			name: "no estimate",
+			want: []string{`msg="still running" step=restore elapsed=1m0s` + "\n"},
+		},
+		{
+			name: "source failure",
+			err:  errors.New("connection refused"),
+			want: []string{`level=DEBUG msg="failed to check job progress" error="connection refused`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			logProgress(ctx, "restore", tt.estimate, time.Minute, func(*stopper.Context) ([]db.JobProgress, error) {
+				return tt.jobs, tt.err
+			})
+			out := logs.String()
+			for _, want := range tt.want {
+				assert.Contains(t, out, want)
+			}
+			require.Len(t, tt.want, bytes.Count([]byte(out), []byte("\n")))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// ReachabilityResult is the outcome of CHECK EXTERNAL CONNECTION on each
+// node, for clusters whose nodes reach the storage through different
+// network paths, such as several NAT gateways or proxies.
+type ReachabilityResult struct {
+	Filter string      // execution locality of the backups; nodes outside of it are not required to reach the storage
+	Stats  []*db.Stats // outcome of CHECK EXTERNAL CONNECTION on each node
+}
+
+// Required reports whether a node must reach the storage: every node does,
+// unless the backups are restricted to an execution locality.
+func (r *ReachabilityResult) Required(s *db.Stats) bool {
+	return r.Filter == "" || db.MatchesLocality(s.Locality, r.Filter)
+}
+
+// Unreachable returns the required nodes that cannot reach the storage.
+func (r *ReachabilityResult) Unreachable() []int {
+	var res []int
+	for _, s := range r.Stats {
+		if !s.Success && r.Required(s) {
+			res = append(res, s.Node)
+		}
+	}
+	return res
+}
+
+// checkReachability fails if a node required to reach the storage could not
+// use the external connection. It returns nil if the version does not
+// report the statistics of the nodes.
+func checkReachability(filter string, stats []*db.Stats) (*ReachabilityResult, error) {
+	if len(stats) == 0 {
+		return nil, nil
+	}
+	res := &ReachabilityResult{Filter: filter, Stats: stats}
+	if nodes := res.Unreachable(); len(nodes) > 0 {
+		return res, errors.WithHint(errors.Newf("nodes %v cannot reach the storage", nodes),
+			"the nodes may reach the storage through another network path: "+
+				"check the NAT gateway, proxy and firewall rules applied to these nodes")
+	}
+	slog.Info("every node reaches the storage", slog.Int("nodes", len(stats)))
+	return res, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+func TestCheckReachability(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+	stats := []*db.Stats{
+		{Node: 1, Locality: "region=us-east1", Success: true},
+		{Node: 2, Locality: "region=us-east1", ErrStr: "connection refused"},
+		{Node: 3, Locality: "region=us-west1", ErrStr: "i/o timeout"},
+	}
+	res, err := checkReachability("", stats)
+	r.ErrorContains(err, "nodes [2 3] cannot reach the storage")
+	r.NotNil(res)
+	a.Equal([]int{2, 3}, res.Unreachable())
+
+	// Nodes outside of the execution locality do not run the backups.
+	res, err = checkReachability("region=us-east1", stats)
+	r.ErrorContains(err, "nodes [2] cannot reach the storage")
+	a.False(res.Required(stats[2]))
+
+	res, err = checkReachability("region=us-west1", stats[:1])
+	a.NoError(err)
+	a.Empty(res.Unreachable())
+
+	// Versions without statistics are not checked.
+	res, err = checkReachability("", nil)
+	a.NoError(err)
+	a.Nil(res)
+}
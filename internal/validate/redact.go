@@ -0,0 +1,343 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"cmp"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachlabs-field/blobcheck/internal/audit"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+// Redaction policies for the report.
+const (
+	// RedactSecrets masks the secret access key and the session token.
+	RedactSecrets = "secrets"
+	// RedactFull also masks the access key ID and the endpoint host names,
+	// for reports shared outside of the organization.
+	RedactFull = "full"
+)
+
+// CheckRedactPolicy verifies that the policy is supported, in every mode, so
+// that a mistyped policy never renders an unredacted report.
+func CheckRedactPolicy(policy string) error {
+	switch policy {
+	case "", RedactSecrets, RedactFull:
+		return nil
+	default:
+		return errors.Newf("invalid redaction policy %q: must be %s or %s", policy, RedactSecrets, RedactFull)
+	}
+}
+
+// Redact returns a copy of the report, with the access key ID and the host
+// names and addresses of the storage endpoint masked in every field if the
+// policy is RedactFull. Secrets are always masked by the storage.
+func (r *Report) Redact(policy string) *Report {
+	if policy != RedactFull {
+		return r
+	}
+	replacer := strings.NewReplacer(sensitiveValues(r)...)
+	redact := replacer.Replace
+	res := *r
+	if r.SuggestedParams != nil {
+		res.SuggestedParams = make(blob.Params, len(r.SuggestedParams))
+		for k, v := range r.SuggestedParams {
+			res.SuggestedParams[k] = redactParam(redact, k, v)
+		}
+	}
+	if r.SuggestedURL != "" {
+		res.SuggestedURL = blob.MapURLParams(r.SuggestedURL, func(k, v string) string {
+			return redactParam(redact, k, v)
+		})
+	}
+	if r.ProvidedParams != nil {
+		res.ProvidedParams = make(blob.Params, len(r.ProvidedParams))
+		for k, v := range r.ProvidedParams {
+			res.ProvidedParams[k] = redactParam(redact, k, v)
+		}
+	}
+	res.Capabilities = nil
+	for _, c := range r.Capabilities {
+		c.Err = redact(c.Err)
+		res.Capabilities = append(res.Capabilities, c)
+	}
+	res.Permissions = nil
+	for _, p := range r.Permissions {
+		p.Err = redact(p.Err)
+		res.Permissions = append(res.Permissions, p)
+	}
+	res.Diagnoses = nil
+	for _, d := range r.Diagnoses {
+		d.Err = redact(d.Err)
+		res.Diagnoses = append(res.Diagnoses, d)
+	}
+	res.KeyChecks = nil
+	for _, k := range r.KeyChecks {
+		k.Err = redact(k.Err)
+		res.KeyChecks = append(res.KeyChecks, k)
+	}
+	if r.Archival != nil {
+		archival := *r.Archival
+		archival.Err, archival.ForcedRead = redact(archival.Err), redact(archival.ForcedRead)
+		res.Archival = &archival
+	}
+	if r.Failover != nil {
+		failover := blob.Failover{}
+		for _, c := range r.Failover.Endpoints {
+			c.Endpoint, c.Err = redact(c.Endpoint), redact(c.Err)
+			failover.Endpoints = append(failover.Endpoints, c)
+		}
+		res.Failover = &failover
+	}
+	if r.Immutability != nil {
+		lock := *r.Immutability.Lock
+		lock.Err = redact(lock.Err)
+		res.Immutability = &ImmutabilityResult{Lock: &lock, BackupRetention: r.Immutability.BackupRetention}
+	}
+	if r.MinIO != nil {
+		minio := *r.MinIO
+		minio.Health, minio.AdminErr = redact(minio.Health), redact(minio.AdminErr)
+		res.MinIO = &minio
+	}
+	res.Stats = nil
+	for _, s := range r.Stats {
+		stat := *s
+		stat.ErrStr = redact(stat.ErrStr)
+		res.Stats = append(res.Stats, &stat)
+	}
+	if r.Reachability != nil {
+		reachability := ReachabilityResult{Filter: r.Reachability.Filter}
+		for _, s := range r.Reachability.Stats {
+			stat := *s
+			stat.ErrStr = redact(stat.ErrStr)
+			reachability.Stats = append(reachability.Stats, &stat)
+		}
+		res.Reachability = &reachability
+	}
+	res.ConnDiffs = nil
+	for _, d := range r.ConnDiffs {
+		d.Current, d.Suggested = redactParam(redact, d.Param, d.Current), redactParam(redact, d.Param, d.Suggested)
+		res.ConnDiffs = append(res.ConnDiffs, d)
+	}
+	if r.VirtualHost != nil {
+		vh := *r.VirtualHost
+		vh.Domain, vh.Host = redact(vh.Domain), redact(vh.Host)
+		vh.Addresses = slices.Repeat([]string{blob.Obfuscated}, len(vh.Addresses))
+		vh.SANs = nil
+		for _, name := range r.VirtualHost.SANs {
+			vh.SANs = append(vh.SANs, redact(name))
+		}
+		vh.DNSErr, vh.TLSErr, vh.Err = redact(vh.DNSErr), redact(vh.TLSErr), redact(vh.Err)
+		res.VirtualHost = &vh
+	}
+	if r.Proxy != nil {
+		proxy := *r.Proxy
+		proxy.Proxy, proxy.NoProxy, proxy.Err = redact(proxy.Proxy), redact(proxy.NoProxy), redact(proxy.Err)
+		res.Proxy = &proxy
+	}
+	if r.Egress != nil {
+		egress := *r.Egress
+		egress.Endpoint = redact(egress.Endpoint)
+		egress.Addresses = slices.Repeat([]string{blob.Obfuscated}, len(egress.Addresses))
+		res.Egress = &egress
+	}
+	res.Variants = nil
+	for _, v := range r.Variants {
+		variant := *v
+		variant.Overrides = make(blob.Params, len(v.Overrides))
+		for k, value := range v.Overrides {
+			variant.Overrides[k] = redactParam(redact, k, value)
+		}
+		if v.Overrides != nil {
+			variant.Name = variantName(variant.Overrides)
+		}
+		variant.Err = redact(variant.Err)
+		variant.Stats = nil
+		for _, s := range v.Stats {
+			stat := *s
+			stat.ErrStr = redact(stat.ErrStr)
+			variant.Stats = append(variant.Stats, &stat)
+		}
+		res.Variants = append(res.Variants, &variant)
+	}
+	res.Gaps = nil
+	for _, g := range r.Gaps {
+		gap := *g
+		gap.LocalErr, gap.Err = redact(gap.LocalErr), redact(gap.Err)
+		gap.Stats = nil
+		for _, s := range g.Stats {
+			stat := *s
+			stat.ErrStr = redact(stat.ErrStr)
+			gap.Stats = append(gap.Stats, &stat)
+		}
+		res.Gaps = append(res.Gaps, &gap)
+	}
+	if r.Locality != nil {
+		l := *r.Locality
+		l.Nodes = nil
+		for _, n := range r.Locality.Nodes {
+			node := *n
+			node.Check = redact(node.Check)
+			l.Nodes = append(l.Nodes, &node)
+		}
+		res.Locality = &l
+	}
+	if r.Metrics != nil {
+		m := *r.Metrics
+		m.Nodes = nil
+		for _, n := range r.Metrics.Nodes {
+			node := *n
+			node.Err = redact(node.Err)
+			m.Nodes = append(m.Nodes, &node)
+		}
+		res.Metrics = &m
+	}
+	if r.Hook != nil {
+		hook := *r.Hook
+		hook.Output = redact(hook.Output)
+		res.Hook = &hook
+	}
+	res.TLS = nil
+	for _, t := range r.TLS {
+		result := *t
+		result.Endpoint = redact(result.Endpoint)
+		result.Violations = redactAll(redact, result.Violations)
+		result.SANs = redactAll(redact, result.SANs)
+		result.Findings = redactAll(redact, result.Findings)
+		result.Chain = nil
+		for _, c := range t.Chain {
+			c.Subject, c.Issuer = redact(c.Subject), redact(c.Issuer)
+			result.Chain = append(result.Chain, c)
+		}
+		res.TLS = append(res.TLS, &result)
+	}
+	if r.Audit != nil {
+		attestation := audit.Attestation{Allowed: redactAll(redact, r.Audit.Allowed)}
+		for _, c := range r.Audit.Connections {
+			c.Address = redact(c.Address)
+			attestation.Connections = append(attestation.Connections, c)
+		}
+		res.Audit = &attestation
+	}
+	if r.Exposure != nil {
+		exposure := ExposureResult{}
+		for _, c := range r.Exposure.Checks {
+			c.Err = redact(c.Err)
+			exposure.Checks = append(exposure.Checks, c)
+		}
+		res.Exposure = &exposure
+	}
+	if r.Failure != nil {
+		failure := *r.Failure
+		failure.Err = redact(failure.Err)
+		res.Failure = &failure
+	}
+	if r.Cleanup != nil {
+		cleanup := *r.Cleanup
+		cleanup.Err = redact(cleanup.Err)
+		res.Cleanup = &cleanup
+	}
+	return &res
+}
+
+// sensitiveValues returns the pairs of values to mask and their
+// replacement: the access key ID, the host name of the endpoint, and the
+// addresses it resolves to. Longer values come first, so that they are
+// replaced before their substrings.
+func sensitiveValues(r *Report) []string {
+	values := make(map[string]bool)
+	for _, params := range []blob.Params{r.SuggestedParams, r.ProvidedParams} {
+		if id := params[blob.AccountParam]; id != "" {
+			values[id] = true
+		}
+		if ep := params[blob.EndPointParam]; ep != "" {
+			if u, err := url.Parse(ep); err == nil && u.Hostname() != "" {
+				values[u.Hostname()] = true
+			}
+		}
+	}
+	if r.Failover != nil {
+		for _, c := range r.Failover.Endpoints {
+			if u, err := url.Parse(c.Endpoint); err == nil && u.Hostname() != "" {
+				values[u.Hostname()] = true
+			}
+		}
+	}
+	if r.VirtualHost != nil {
+		for _, addr := range []string{r.VirtualHost.Domain, r.VirtualHost.Host} {
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				addr = host
+			}
+			values[addr] = true
+		}
+		for _, addr := range r.VirtualHost.Addresses {
+			values[addr] = true
+		}
+	}
+	if r.Proxy != nil {
+		if u, err := url.Parse(r.Proxy.Proxy); err == nil && u.Hostname() != "" {
+			values[u.Hostname()] = true
+		}
+	}
+	if r.Egress != nil {
+		values[r.Egress.Endpoint] = true
+		for _, addr := range r.Egress.Addresses {
+			values[addr] = true
+		}
+	}
+	delete(values, "")
+	delete(values, blob.Obfuscated)
+	keys := slices.SortedFunc(maps.Keys(values), func(a, b string) int {
+		return cmp.Or(len(b)-len(a), strings.Compare(a, b))
+	})
+	var res []string
+	for _, k := range keys {
+		res = append(res, k, blob.Obfuscated)
+	}
+	return res
+}
+
+// redactParam masks the access key ID and the host of the endpoint, which
+// may belong to connections other than the validated one, besides the
+// sensitive values of the report.
+func redactParam(redact func(string) string, key, value string) string {
+	switch {
+	case value == "":
+		return value
+	case key == blob.AccountParam:
+		return blob.Obfuscated
+	case key == blob.EndPointParam:
+		if u, err := url.Parse(value); err == nil && u.Host != "" {
+			u.Host = strings.Replace(u.Host, u.Hostname(), blob.Obfuscated, 1)
+			return u.String()
+		}
+	}
+	return redact(value)
+}
+
+// redactAll applies the redaction to every value.
+func redactAll(redact func(string) string, values []string) []string {
+	var res []string
+	for _, v := range values {
+		res = append(res, redact(v))
+	}
+	return res
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+func TestRedact(t *testing.T) {
+	a := assert.New(t)
+	report := &Report{
+		SuggestedParams: blob.Params{
+			blob.AccountParam:  "AKIAEXAMPLE",
+			blob.SecretParam:   blob.Obfuscated,
+			blob.EndPointParam: "https://minio.corp.example:9000",
+			blob.RegionParam:   "us-east-1",
+		},
+		SuggestedURL: "s3://bucket/backups?AWS_ACCESS_KEY_ID=AKIAEXAMPLE&" +
+			"AWS_ENDPOINT=https%3A%2F%2Fminio.corp.example%3A9000&AWS_SECRET_ACCESS_KEY=******",
+		ProvidedParams: blob.Params{
+			blob.AccountParam:  "AKIAEXAMPLE",
+			blob.SecretParam:   blob.Obfuscated,
+			blob.EndPointParam: "https://minio-lb.corp.example:9000",
+		},
+		Stats: []*db.Stats{{Node: 1, ErrStr: "dial tcp minio.corp.example:9000: i/o timeout"}},
+		ConnDiffs: []ParamDiff{
+			{Connection: "backups", Param: blob.AccountParam, Current: "AKIAOTHER", Suggested: "AKIAEXAMPLE"},
+		},
+		Failover: &blob.Failover{Endpoints: []blob.EndpointCheck{
+			{Endpoint: "https://minio.corp.example:9000", Suggested: true},
+			{Endpoint: "https://minio2.corp.example:9000", Err: "dial tcp minio2.corp.example:9000: i/o timeout"},
+		}},
+		VirtualHost: &blob.VirtualHost{
+			Domain:    "s3.corp.example",
+			Host:      "bucket.s3.corp.example:443",
+			Addresses: []string{"10.0.0.7"},
+			SANs:      []string{"*.s3.corp.example"},
+			TLSErr:    "x509: certificate is valid for s3.corp.example, not bucket.s3.corp.example",
+		},
+		Proxy: &blob.ProxyCheck{
+			Proxy:    "http://proxy.corp.example:3128",
+			NoProxy:  "localhost",
+			Bypassed: true,
+			Err:      "proxyconnect tcp: dial tcp proxy.corp.example:3128: connection refused",
+		},
+		Failure: &Failure{Step: "restore", Err: "access denied for AKIAEXAMPLE"},
+	}
+	a.Same(report, report.Redact(RedactSecrets))
+
+	redacted := report.Redact(RedactFull)
+	a.Equal(blob.Params{
+		blob.AccountParam:  blob.Obfuscated,
+		blob.SecretParam:   blob.Obfuscated,
+		blob.EndPointParam: "https://******:9000",
+		blob.RegionParam:   "us-east-1",
+	}, redacted.SuggestedParams)
+	a.Equal("s3://bucket/backups?AWS_ACCESS_KEY_ID=******&"+
+		"AWS_ENDPOINT=https%3A%2F%2F******%3A9000&AWS_SECRET_ACCESS_KEY=******", redacted.SuggestedURL)
+	a.Equal(blob.Params{
+		blob.AccountParam:  blob.Obfuscated,
+		blob.SecretParam:   blob.Obfuscated,
+		blob.EndPointParam: "https://******:9000",
+	}, redacted.ProvidedParams)
+	a.Equal("dial tcp ******:9000: i/o timeout", redacted.Stats[0].ErrStr)
+	a.Equal(blob.Obfuscated, redacted.ConnDiffs[0].Current)
+	a.Equal("https://******:9000", redacted.Failover.Endpoints[1].Endpoint)
+	a.Equal("dial tcp ******:9000: i/o timeout", redacted.Failover.Endpoints[1].Err)
+	a.Equal(&blob.VirtualHost{
+		Domain:    blob.Obfuscated,
+		Host:      "******:443",
+		Addresses: []string{blob.Obfuscated},
+		SANs:      []string{"*.******"},
+		TLSErr:    "x509: certificate is valid for ******, not ******",
+	}, redacted.VirtualHost)
+	a.Equal(&blob.ProxyCheck{
+		Proxy:    "http://******:3128",
+		NoProxy:  "localhost",
+		Bypassed: true,
+		Err:      "proxyconnect tcp: dial tcp ******:3128: connection refused",
+	}, redacted.Proxy)
+	a.Equal("access denied for ******", redacted.Failure.Err)
+
+	// The original report is preserved for the local artifact.
+	a.Equal("AKIAEXAMPLE", report.SuggestedParams[blob.AccountParam])
+	a.Equal("access denied for AKIAEXAMPLE", report.Failure.Err)
+
+	a.NoError(CheckRedactPolicy(RedactFull))
+	a.Error(CheckRedactPolicy("everything"))
+}
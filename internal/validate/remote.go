@@ -0,0 +1,178 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/tunnel"
+)
+
+// CrossClusterResult contains the outcome of restoring the backup into a
+// second cluster.
+type CrossClusterResult struct {
+	Version     string        // version of the second cluster
+	RestoreTime time.Duration // time taken by the restore
+	Matched     bool          // whether the restored data matches the original
+	Drill       *DrillResult  // timings of the disaster recovery drill, if enabled
+}
+
+// DrillResult contains the timings of a disaster recovery drill, where the
+// second cluster takes over from the source cluster.
+type DrillResult struct {
+	BackupTime time.Duration // total time spent taking the full and incremental backups
+	RPO        time.Duration // age of the latest backup when the restore started
+	RTO        time.Duration // time to restore and verify the data on the second cluster
+}
+
+// remoteCluster is a second CockroachDB cluster used to restore the backup
+// taken from the source cluster, validating that the storage is readable
+// from a different cluster and version. The objects created on the second
+// cluster are named after the run, so that the objects of other runs, or of
+// the operator, are never replaced or dropped.
+type remoteCluster struct {
+	pool          *pgxpool.Pool
+	restoredTable db.KvTable
+	suffix        string // suffix of the names of the external connections
+}
+
+// runSuffix returns the suffix identifying the run in the names of the
+// objects created on the second cluster, e.g. _0a1b2c3d.
+func runSuffix(name db.Ident) string {
+	return "_" + strings.TrimPrefix(string(name), tablePrefix)
+}
+
+// remoteTableFor returns the table restored on the second cluster, in a
+// database of its own named after the run.
+func remoteTableFor(schema db.Schema, name db.Ident) db.KvTable {
+	return db.KvTable{
+		Database: db.Database{Name: db.Ident(restoredDatabase + runSuffix(name))},
+		Schema:   schema,
+		Name:     name,
+	}
+}
+
+// newRemoteCluster connects to the second cluster.
+func newRemoteCluster(
+	ctx *stopper.Context, url string, schema db.Schema, name db.Ident, dial env.DialFunc,
+) (*remoteCluster, error) {
+	if err := db.CheckCredentials(url); err != nil {
+		return nil, errors.Wrap(err, "invalid second cluster credentials")
+	}
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse second cluster URL")
+	}
+	config.MaxConns = maxConns
+	overrideSessionDefaults(config.ConnConfig)
+	if dial != nil {
+		config.ConnConfig.DialFunc = pgconn.DialFunc(dial)
+		config.ConnConfig.LookupFunc = tunnel.LookupHost
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to second cluster")
+	}
+	return &remoteCluster{
+		pool:          pool,
+		restoredTable: remoteTableFor(schema, name),
+		suffix:        runSuffix(name),
+	}, nil
+}
+
+// restore restores the backup of the source table into the second cluster
+// and compares its fingerprint with the expected one. The incremental
+// backups are read from the incremental location, if set.
+func (r *remoteCluster) restore(
+	ctx *stopper.Context, store blob.Storage, incremental string, source *db.KvTable, expected string,
+) (*CrossClusterResult, error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to acquire second cluster connection")
+	}
+	defer conn.Release()
+
+	version, err := db.Version(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	extConn, err := newExternalConn(ctx, conn, store, incremental, r.suffix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create external connection on second cluster")
+	}
+	defer extConn.Drop(ctx, conn)
+
+	dest := r.restoredTable.Database
+	exists, err := dest.Exists(ctx, conn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to look up restored database on second cluster")
+	}
+	if exists {
+		return nil, errors.Newf("database %s already exists on second cluster", dest.Name)
+	}
+	if err := dest.Create(ctx, conn); err != nil {
+		return nil, errors.Wrap(err, "failed to create restored database on second cluster")
+	}
+	if r.restoredTable.Schema != db.Public {
+		if err := dest.CreateSchema(ctx, conn, r.restoredTable.Schema); err != nil {
+			return nil, errors.Wrap(err, "failed to create restored schema on second cluster")
+		}
+	}
+
+	slog.Info("restoring backup into second cluster", slog.String("version", version.String()))
+	start := time.Now()
+	if err := r.restoredTable.Restore(ctx, conn, extConn, source); err != nil {
+		return nil, errors.Wrap(err, "failed to restore backup into second cluster")
+	}
+	res := &CrossClusterResult{
+		Version:     version.String(),
+		RestoreTime: time.Since(start),
+	}
+	got, err := r.restoredTable.Fingerprint(ctx, conn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get second cluster table fingerprint")
+	}
+	res.Matched = got == expected
+	if !res.Matched {
+		slog.Error("integrity check failed on second cluster",
+			slog.String("got", got), slog.String("expected", expected))
+	}
+	return res, nil
+}
+
+// close drops the restored database and closes the connections to the
+// second cluster.
+func (r *remoteCluster) close(ctx *stopper.Context) error {
+	defer r.pool.Close()
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire second cluster connection")
+	}
+	defer conn.Release()
+	if err := r.restoredTable.Database.Drop(ctx, conn); err != nil {
+		return errors.Wrap(err, "failed to drop restored database on second cluster")
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// ScheduleLint is the outcome of comparing a backup schedule targeting the
+// destination with the backup performance measured by the validation.
+type ScheduleLint struct {
+	ID             int64
+	Label          string
+	Incremental    bool
+	Cadence        time.Duration // shortest interval between two runs of the schedule
+	Duration       time.Duration // expected duration of a single run
+	Recommendation string        // empty if the schedule looks fine
+}
+
+// Overlaps returns whether a run is expected to last longer than the
+// interval between runs.
+func (l *ScheduleLint) Overlaps() bool {
+	return l.Duration > l.Cadence
+}
+
+// lintSchedule compares the cadence of the schedule with the expected
+// duration of a single run.
+func lintSchedule(s db.Schedule, duration time.Duration) (*ScheduleLint, error) {
+	interval, err := cadence(s.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	res := &ScheduleLint{
+		ID:          s.ID,
+		Label:       s.Label,
+		Incremental: s.Incremental(),
+		Cadence:     interval,
+		Duration:    duration,
+	}
+	if res.Overlaps() {
+		res.Recommendation = fmt.Sprintf(
+			"runs overlap: increase the interval to at least %s, or reduce the amount of data per backup",
+			duration.Round(time.Minute)+time.Minute)
+	}
+	return res, nil
+}
+
+// stringLiteral matches the SQL string literals in a statement.
+var stringLiteral = regexp.MustCompile(`'((?:[^']|'')*)'`)
+
+// targets returns whether the schedule writes to the bucket, either directly
+// or through one of the given external connections. The URIs are extracted
+// from the string literals in the schedule statement, and their bucket or
+// connection name must match exactly.
+func targets(s db.Schedule, bucket string, conns []string) bool {
+	for _, m := range stringLiteral.FindAllStringSubmatch(s.Statement, -1) {
+		uri := strings.ReplaceAll(m[1], "''", "'")
+		u, err := url.Parse(uri)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		switch u.Scheme {
+		case "external":
+			if slices.Contains(conns, u.Host) {
+				return true
+			}
+		case "s3":
+			if b, _, err := blob.ParseURI(uri); err == nil && b == bucket {
+				return true
+			}
+		default:
+			if u.Host == bucket {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lintSchedules finds the backup schedules targeting the destination bucket
+// and compares their cadence with the backup durations: the projected full
+// backup duration, if known, or the durations measured by the validation.
+// Failures are logged, since the lint is informational only.
+func (v *Validator) lintSchedules(ctx *stopper.Context, window *WindowResult) []*ScheduleLint {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil
+	}
+	defer conn.Release()
+	schedules, err := db.BackupSchedules(ctx, conn)
+	if err != nil {
+		slog.Warn("failed to list backup schedules", slog.Any("error", err))
+		return nil
+	}
+	extConns, err := db.ListExternalConns(ctx, conn)
+	if err != nil {
+		slog.Warn("failed to list external connections", slog.Any("error", err))
+	}
+	bucket := v.blobStorage.BucketName()
+	var conns []string
+	for _, c := range extConns {
+		if b, _, err := blob.ParseURI(c.URI); err == nil && b == bucket {
+			conns = append(conns, c.Name)
+		}
+	}
+
+	full := v.fullBackupTime
+	if window != nil {
+		full = window.Duration
+	}
+	var res []*ScheduleLint
+	for _, s := range schedules {
+		if !targets(s, bucket, conns) {
+			continue
+		}
+		duration := full
+		if s.Incremental() {
+			duration = v.incrementalBackupTime
+		}
+		lint, err := lintSchedule(s, duration)
+		if err != nil {
+			slog.Warn("failed to lint backup schedule", slog.Int64("id", s.ID), slog.Any("error", err))
+			continue
+		}
+		if lint.Overlaps() {
+			slog.Warn("backup schedule runs would overlap", slog.Int64("id", s.ID),
+				slog.Duration("cadence", lint.Cadence), slog.Duration("duration", lint.Duration))
+		}
+		res = append(res, lint)
+	}
+	return res
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+func TestLintSchedule(t *testing.T) {
+	a := assert.New(t)
+	full := db.Schedule{ID: 1, Label: "daily", Recurrence: "@daily",
+		Statement: "BACKUP INTO 'external://backups' WITH revision_history"}
+	incremental := db.Schedule{ID: 2, Label: "hourly", Recurrence: "@hourly",
+		Statement: "BACKUP INTO LATEST IN 'external://backups' WITH revision_history"}
+
+	lint, err := lintSchedule(full, 2*time.Hour)
+	a.NoError(err)
+	a.False(lint.Incremental)
+	a.False(lint.Overlaps())
+	a.Empty(lint.Recommendation)
+
+	lint, err = lintSchedule(incremental, 90*time.Minute)
+	a.NoError(err)
+	a.True(lint.Incremental)
+	a.True(lint.Overlaps())
+	a.Contains(lint.Recommendation, "at least 1h31m0s")
+
+	a.True(targets(full, "mybucket", []string{"backups"}))
+	a.True(targets(db.Schedule{Statement: "BACKUP INTO 's3://mybucket/path?AUTH=implicit'"}, "mybucket", nil))
+	a.False(targets(full, "mybucket", []string{"other"}))
+
+	for _, tt := range []struct {
+		statement string
+		want      bool
+	}{
+		{"BACKUP INTO 's3://mybucket-archive/path'", false},
+		{"BACKUP INTO 's3://mybucket/path' WITH kms = 's3://other'", true},
+		{"BACKUP INTO 'gs://mybucket/path?AUTH=implicit'", true},
+		{"BACKUP INTO 'gs://mybucket2/path'", false},
+		{"BACKUP INTO 'external://backups-old'", false},
+		{"BACKUP INTO LATEST IN 'external://backups/subdir'", true},
+		{"BACKUP INTO 'nodelocal://1/mybucket'", false},
+		{"BACKUP INTO 's3://other/it''s' WITH detached", false},
+	} {
+		a.Equal(tt.want, targets(db.Schedule{Statement: tt.statement}, "mybucket", []string{"backups"}), tt.statement)
+	}
+}
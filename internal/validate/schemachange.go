@@ -0,0 +1,140 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+const (
+	// SchemaChangeAddColumn adds a column with a default value to the
+	// source table.
+	SchemaChangeAddColumn = "add-column"
+	// SchemaChangeAddIndex creates a secondary index on the source table.
+	SchemaChangeAddIndex = "add-index"
+)
+
+const (
+	schemaChangeColumn = db.Ident("blobcheck_added")
+	schemaChangeIndex  = db.Ident("blobcheck_v_idx")
+	// backupJobPoll is the interval between checks for the full backup job.
+	backupJobPoll = 100 * time.Millisecond
+)
+
+// SchemaChangeResult contains the outcome of the online schema change run
+// on the source table while the full backup was in progress.
+type SchemaChangeResult struct {
+	Kind       string        // add-column or add-index
+	Duration   time.Duration // time taken by the schema change, including the backfill
+	Concurrent bool          // whether the schema change started while the backup job was running
+	Err        string        // error returned by the schema change, if any
+}
+
+// checkSchemaChange verifies that the schema change is supported.
+func checkSchemaChange(kind string) error {
+	switch kind {
+	case "", SchemaChangeAddColumn, SchemaChangeAddIndex:
+		return nil
+	default:
+		return errors.Newf("invalid schema change %q: must be %s or %s",
+			kind, SchemaChangeAddColumn, SchemaChangeAddIndex)
+	}
+}
+
+// runSchemaChange waits for the full backup job to start, and then changes
+// the schema of the source table. The backup and the restore that follow
+// must succeed regardless of the schema change.
+func (v *Validator) runSchemaChange(ctx *stopper.Context, backupDone <-chan struct{}) error {
+	concurrent := v.waitForBackupJob(ctx, backupDone)
+	if ctx.IsStopping() {
+		return nil
+	}
+	if !concurrent {
+		slog.Warn("the full backup completed before the schema change started; " +
+			"increase --workload-duration or the size of the source table")
+	}
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	slog.Info("changing the schema of the source table", slog.String("change", v.env.SchemaChange))
+	start := time.Now()
+	switch v.env.SchemaChange {
+	case SchemaChangeAddColumn:
+		err = v.sourceTable.AddColumn(ctx, conn, schemaChangeColumn)
+	case SchemaChangeAddIndex:
+		err = v.sourceTable.CreateIndex(ctx, conn, schemaChangeIndex)
+	}
+	v.schemaChange = &SchemaChangeResult{
+		Kind:       v.env.SchemaChange,
+		Duration:   time.Since(start),
+		Concurrent: concurrent,
+	}
+	if err != nil {
+		v.schemaChange.Err = err.Error()
+		return errors.Wrapf(err, "failed to run schema change %s during the full backup", v.env.SchemaChange)
+	}
+	return nil
+}
+
+// waitForBackupJob waits until the full backup job is running against the
+// source table. It returns false if the backup completes, or the context
+// stops, before the job is observed.
+func (v *Validator) waitForBackupJob(ctx *stopper.Context, backupDone <-chan struct{}) bool {
+	ticker := time.NewTicker(backupJobPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-backupDone:
+			return false
+		case <-ctx.Stopping():
+			return false
+		case <-ticker.C:
+			if v.backupJobRunning(ctx) {
+				return true
+			}
+		}
+	}
+}
+
+// backupJobRunning returns true if a backup job is running against the
+// source table. Failures are logged at debug level and reported as false,
+// so that the caller keeps polling.
+func (v *Validator) backupJobRunning(ctx *stopper.Context) bool {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		slog.Debug("failed to check the backup job", slog.Any("error", err))
+		return false
+	}
+	defer conn.Release()
+	jobs, err := v.sourceTable.JobsProgress(ctx, conn)
+	if err != nil {
+		slog.Debug("failed to check the backup job", slog.Any("error", err))
+		return false
+	}
+	for _, job := range jobs {
+		if job.Type == "BACKUP" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+func TestCheckSchemaChange(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(checkSchemaChange(""))
+	a.NoError(checkSchemaChange(SchemaChangeAddColumn))
+	a.NoError(checkSchemaChange(SchemaChangeAddIndex))
+	a.ErrorContains(checkSchemaChange("drop-table"), "invalid schema change")
+}
+
+func TestWaitForBackupJobCompleted(t *testing.T) {
+	ctx := stopper.WithContext(t.Context())
+	done := make(chan struct{})
+	close(done)
+	v := &Validator{}
+	assert.False(t, v.waitForBackupJob(ctx, done))
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// sessionDefault is a session variable whose default, set on the cluster,
+// database or role, breaks the validation.
+type sessionDefault struct {
+	name   string
+	value  string // value required by blobcheck
+	reason string
+}
+
+// sessionDefaults are overridden in the connection parameters of the
+// sessions of blobcheck.
+var sessionDefaults = []sessionDefault{
+	{"default_transaction_read_only", "off", "the workload writes to the source table"},
+	{"default_transaction_use_follower_reads", "off", "fingerprints must read the latest rows"},
+	{"disallow_full_table_scans", "off", "row counts and fingerprints scan the tables"},
+	{"sql_safe_updates", "off", "the cleanup drops the databases created by blobcheck"},
+}
+
+// overrideSessionDefaults sets the session variables required by blobcheck
+// in the connection parameters, unless the database URL sets them.
+func overrideSessionDefaults(config *pgx.ConnConfig) {
+	for _, d := range sessionDefaults {
+		if _, ok := config.RuntimeParams[d.name]; !ok {
+			config.RuntimeParams[d.name] = d.value
+		}
+	}
+}
+
+// checkSessionDefaults verifies that the session variables required by
+// blobcheck are in effect, reporting the ones that must change otherwise.
+// Variables unknown to the cluster are skipped.
+func checkSessionDefaults(ctx *stopper.Context, conn *pgxpool.Conn) error {
+	var wrong []string
+	for _, d := range sessionDefaults {
+		value, err := db.SessionVar(ctx, conn, d.name)
+		if err != nil {
+			slog.Debug("unable to read session variable", slog.String("name", d.name), slog.Any("error", err))
+			continue
+		}
+		if !strings.EqualFold(value, d.value) {
+			wrong = append(wrong, d.name+" = "+value+" ("+d.reason+")")
+		}
+	}
+	if len(wrong) > 0 {
+		return errors.WithHint(
+			errors.Newf("the sessions of blobcheck have restrictive defaults: %s", strings.Join(wrong, "; ")),
+			"the connection parameters override the defaults of the cluster and of the roles: "+
+				"remove the variables from the database URL")
+	}
+	readOnly, err := db.SessionVar(ctx, conn, "transaction_read_only")
+	if err != nil {
+		return errors.Wrap(err, "failed to check whether the cluster accepts writes")
+	}
+	if strings.EqualFold(readOnly, "on") {
+		return errors.New("the cluster is read-only, such as the standby of a physical cluster replication stream: " +
+			"run blobcheck against a cluster that accepts writes")
+	}
+	return nil
+}
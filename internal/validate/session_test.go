@@ -0,0 +1,38 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestOverrideSessionDefaults(t *testing.T) {
+	r := require.New(t)
+	config, err := poolConfig(&env.Env{
+		DatabaseURL: "postgresql://root@localhost:26257/defaultdb?sslmode=disable&sql_safe_updates=on",
+	})
+	r.NoError(err)
+	params := config.ConnConfig.RuntimeParams
+	assert.Equal(t, "off", params["default_transaction_read_only"])
+	assert.Equal(t, "off", params["default_transaction_use_follower_reads"])
+	assert.Equal(t, "off", params["disallow_full_table_scans"])
+	// Variables set in the URL are kept, and reported by the preflight.
+	assert.Equal(t, "on", params["sql_safe_updates"])
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// detectVirtualCluster returns the name of the virtual cluster the
+// validation runs in, or an empty string if it cannot be determined.
+func detectVirtualCluster(ctx *stopper.Context, conn *pgxpool.Conn) string {
+	name, err := db.VirtualCluster(ctx, conn)
+	if err != nil {
+		slog.Debug("unable to detect the virtual cluster", slog.Any("error", err))
+		return ""
+	}
+	slog.Info("connected to virtual cluster", slog.String("name", name))
+	return name
+}
+
+// isSecondary returns whether the virtual cluster is not the system one.
+// Secondary virtual clusters do not see the stores and the nodes of the
+// cluster, and need capabilities to split and scatter ranges.
+func isSecondary(virtualCluster string) bool {
+	return virtualCluster != "" && virtualCluster != db.SystemVirtualCluster
+}
+
+// withCapabilityHint adds a hint to an error returned by a statement that
+// requires capabilities a secondary virtual cluster may not have.
+func withCapabilityHint(err error, virtualCluster string, capabilities ...string) error {
+	if err == nil || !isSecondary(virtualCluster) {
+		return err
+	}
+	hint := "from the system virtual cluster, run:"
+	for _, c := range capabilities {
+		hint += "\nALTER VIRTUAL CLUSTER " + virtualCluster + " GRANT CAPABILITY " + c
+	}
+	return errors.WithHint(err, hint)
+}
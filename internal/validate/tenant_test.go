@@ -0,0 +1,34 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCapabilityHint(t *testing.T) {
+	a := assert.New(t)
+	err := errors.New("operation is unsupported within a virtual cluster")
+	a.Nil(withCapabilityHint(nil, "app", "can_admin_split"))
+	a.Empty(errors.GetAllHints(withCapabilityHint(err, "", "can_admin_split")))
+	a.Empty(errors.GetAllHints(withCapabilityHint(err, "system", "can_admin_split")))
+	a.Equal([]string{"from the system virtual cluster, run:\n" +
+		"ALTER VIRTUAL CLUSTER app GRANT CAPABILITY can_admin_split\n" +
+		"ALTER VIRTUAL CLUSTER app GRANT CAPABILITY can_admin_scatter"},
+		errors.GetAllHints(withCapabilityHint(err, "app", "can_admin_split", "can_admin_scatter")))
+}
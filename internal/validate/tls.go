@@ -0,0 +1,304 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"cmp"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// tlsVersions maps the versions accepted by --tls-min-version to their
+// identifiers.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// fipsCipherSuites are the cipher suites approved by FIPS 140-3.
+var fipsCipherSuites = []uint16{
+	tls.TLS_AES_128_GCM_SHA256,
+	tls.TLS_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// TLSPolicy is the minimum security required of the connections to the
+// database and to the storage provider.
+type TLSPolicy struct {
+	MinVersion uint16 // minimum TLS version
+	FIPS       bool   // require FIPS approved cipher suites
+}
+
+// expiryWarning is how long before the expiry of the certificate of the
+// storage endpoint a finding is reported: backups scheduled past the expiry
+// fail until the certificate is renewed.
+const expiryWarning = 30 * 24 * time.Hour
+
+// TLSResult describes the TLS parameters negotiated with an endpoint.
+type TLSResult struct {
+	Target      string   // "database" or "storage"
+	Endpoint    string   // host:port
+	Version     string   // negotiated version, empty for plaintext connections
+	CipherSuite string   // negotiated cipher suite
+	Violations  []string // requirements of the policy that are not met
+	Chain       []CertInfo
+	SANs        []string // names of the leaf certificate
+	SystemRoots bool     // whether the chain validates against the system roots
+	Findings    []string // issues of the certificates, such as an upcoming expiry
+}
+
+// CertInfo describes a certificate presented by an endpoint.
+type CertInfo struct {
+	Subject  string
+	Issuer   string
+	NotAfter time.Time
+}
+
+// Compliant returns whether the connection meets the policy.
+func (r *TLSResult) Compliant() bool {
+	return len(r.Violations) == 0
+}
+
+// tlsPolicy returns the policy configured in the environment.
+func tlsPolicy(env *env.Env) (TLSPolicy, error) {
+	version, ok := tlsVersions[cmp.Or(env.TLSMinVersion, "1.2")]
+	if !ok {
+		return TLSPolicy{}, errors.Newf("invalid TLS version %q: must be one of 1.0, 1.1, 1.2, 1.3", env.TLSMinVersion)
+	}
+	return TLSPolicy{MinVersion: version, FIPS: env.TLSFIPS}, nil
+}
+
+// evaluate returns the result for a connection, given its state, or nil
+// for a plaintext connection.
+func (p TLSPolicy) evaluate(target, endpoint string, state *tls.ConnectionState) *TLSResult {
+	res := &TLSResult{Target: target, Endpoint: endpoint}
+	if state == nil {
+		res.Violations = append(res.Violations, "connection is not encrypted")
+		return res
+	}
+	res.Version = tls.VersionName(state.Version)
+	res.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	if state.Version < p.MinVersion {
+		res.Violations = append(res.Violations,
+			fmt.Sprintf("version below %s", tls.VersionName(p.MinVersion)))
+	}
+	if p.FIPS && !slices.Contains(fipsCipherSuites, state.CipherSuite) {
+		res.Violations = append(res.Violations, "cipher suite is not FIPS approved")
+	}
+	return res
+}
+
+// checkTLS reports the TLS parameters negotiated with the database and with
+// the storage provider. Connections that do not meet the policy are logged,
+// and fail the run in strict mode.
+func (v *Validator) checkTLS(ctx *stopper.Context) ([]*TLSResult, error) {
+	policy, err := tlsPolicy(v.env)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	results := []*TLSResult{databaseTLS(conn, policy)}
+	// Destinations stored by the cluster itself have no storage endpoint.
+	if !blob.IsClusterLocal(v.blobStorage.URL()) {
+		params := v.blobStorage.Params()
+		if endpoint := blob.HTTPEndpoint(v.blobStorage.URL()); endpoint != "" {
+			// File servers are reached at the host of the URL.
+			params = blob.Params{blob.EndPointParam: endpoint}
+		}
+		storage, err := storageTLS(ctx, params, policy, v.env.Dial, v.blobStorage.CustomCA())
+		if err != nil {
+			// The storage was reachable by the SDK: report the failure
+			// against the policy rather than failing the run.
+			storage = &TLSResult{Target: "storage", Violations: []string{err.Error()}}
+		}
+		results = append(results, storage)
+	}
+
+	var violations []string
+	for _, r := range results {
+		if r.Compliant() {
+			continue
+		}
+		slog.Warn("connection does not meet the TLS policy", slog.String("target", r.Target),
+			slog.String("endpoint", r.Endpoint), slog.Any("violations", r.Violations))
+		violations = append(violations, fmt.Sprintf("%s: %s", r.Target, strings.Join(r.Violations, ", ")))
+	}
+	if len(violations) > 0 && v.env.StrictTLS {
+		return results, errors.Newf("TLS policy not met: %s", strings.Join(violations, "; "))
+	}
+	return results, nil
+}
+
+// databaseTLS returns the TLS parameters of a database connection.
+func databaseTLS(conn *pgxpool.Conn, policy TLSPolicy) *TLSResult {
+	netConn := conn.Conn().PgConn().Conn()
+	addr := netConn.RemoteAddr().String()
+	if tlsConn, ok := netConn.(*tls.Conn); ok {
+		state := tlsConn.ConnectionState()
+		return policy.evaluate("database", addr, &state)
+	}
+	return policy.evaluate("database", addr, nil)
+}
+
+// InspectTLS connects to an endpoint, such as https://minio.internal:9000,
+// and returns the negotiated parameters and the certificates it presents.
+func InspectTLS(ctx *stopper.Context, env *env.Env, endpoint string) (*TLSResult, error) {
+	policy, err := tlsPolicy(env)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return storageTLS(ctx, blob.Params{blob.EndPointParam: endpoint}, policy, env.Dial,
+		env.CACert)
+}
+
+// storageTLS performs a TLS handshake with the storage endpoint and returns
+// the negotiated parameters and the certificates of the server. Unless the
+// storage parameters disable the verification, a chain that validates
+// neither against the system roots nor against the CA bundle, if any, does
+// not meet the policy.
+func storageTLS(
+	ctx *stopper.Context, params blob.Params, policy TLSPolicy, dial env.DialFunc, caFile string,
+) (*TLSResult, error) {
+	host, port := endpointHost(params), "443"
+	u, err := params.URL(blob.EndPointParam)
+	if err != nil {
+		return nil, err
+	}
+	if u != nil {
+		if u.Scheme == "http" {
+			return policy.evaluate("storage", u.Host, nil), nil
+		}
+		if u.Port() != "" {
+			port = u.Port()
+		}
+	}
+	addr := net.JoinHostPort(host, port)
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+	raw, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s", addr)
+	}
+	// The chain is verified once the handshake completes, so that the
+	// certificates of an untrusted server are reported too.
+	conn := tls.Client(raw, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	defer conn.Close()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return nil, errors.Wrapf(err, "TLS handshake with %s failed", addr)
+	}
+	state := conn.ConnectionState()
+	res := policy.evaluate("storage", addr, &state)
+	if len(state.PeerCertificates) == 0 {
+		return res, nil
+	}
+	leaf := state.PeerCertificates[0]
+	for _, cert := range state.PeerCertificates {
+		res.Chain = append(res.Chain, CertInfo{
+			Subject:  cert.Subject.String(),
+			Issuer:   cert.Issuer.String(),
+			NotAfter: cert.NotAfter.UTC(),
+		})
+	}
+	res.SANs = append(res.SANs, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		res.SANs = append(res.SANs, ip.String())
+	}
+	verifyErr := verifyChain(state.PeerCertificates, host, nil)
+	res.SystemRoots = verifyErr == nil
+	if verifyErr != nil {
+		res.Findings = append(res.Findings, "not verified by the system roots: "+verifyErr.Error())
+		if caFile != "" {
+			roots, err := loadRoots(caFile)
+			if err != nil {
+				return nil, err
+			}
+			verifyErr = verifyChain(state.PeerCertificates, host, roots)
+			if verifyErr != nil {
+				res.Findings = append(res.Findings, "not verified by "+caFile+": "+verifyErr.Error())
+			}
+		}
+	}
+	if verifyErr != nil && !params.Bool(blob.SkipTLSVerify) {
+		res.Violations = append(res.Violations, "certificate is not trusted")
+	}
+	switch left := time.Until(leaf.NotAfter); {
+	case left <= 0:
+		res.Findings = append(res.Findings, "certificate expired on "+leaf.NotAfter.UTC().Format(time.DateOnly))
+	case left < expiryWarning:
+		res.Findings = append(res.Findings, fmt.Sprintf("certificate expires in %d days", int(left/(24*time.Hour))))
+	}
+	return res, nil
+}
+
+// verifyChain verifies the certificates presented by a server against the
+// roots, or against the system roots if nil.
+func verifyChain(certs []*x509.Certificate, host string, roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// loadRoots returns the certificates of a PEM bundle.
+func loadRoots(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the CA bundle")
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, errors.Newf("no PEM encoded certificate found in %s", file)
+	}
+	return roots, nil
+}
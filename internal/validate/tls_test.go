@@ -0,0 +1,92 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+func TestTLSPolicy(t *testing.T) {
+	a := assert.New(t)
+	policy, err := tlsPolicy(&env.Env{})
+	a.NoError(err)
+	a.Equal(TLSPolicy{MinVersion: tls.VersionTLS12}, policy)
+	_, err = tlsPolicy(&env.Env{TLSMinVersion: "1.4"})
+	a.Error(err)
+
+	policy = TLSPolicy{MinVersion: tls.VersionTLS13, FIPS: true}
+	res := policy.evaluate("storage", "s3:443", &tls.ConnectionState{
+		Version:     tls.VersionTLS12,
+		CipherSuite: tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	})
+	a.False(res.Compliant())
+	a.Equal([]string{"version below TLS 1.3", "cipher suite is not FIPS approved"}, res.Violations)
+	res = policy.evaluate("storage", "s3:443", &tls.ConnectionState{
+		Version:     tls.VersionTLS13,
+		CipherSuite: tls.TLS_AES_256_GCM_SHA384,
+	})
+	a.True(res.Compliant())
+	a.Equal("TLS 1.3", res.Version)
+	a.False(policy.evaluate("database", "db:26257", nil).Compliant())
+}
+
+func TestStorageTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	ctx := stopper.WithContext(context.Background())
+	policy := TLSPolicy{MinVersion: tls.VersionTLS12}
+
+	res, err := storageTLS(ctx, blob.Params{
+		blob.EndPointParam: server.URL,
+		blob.SkipTLSVerify: "true",
+	}, policy, nil, "")
+	require.NoError(t, err)
+	assert.True(t, res.Compliant())
+	assert.Equal(t, "TLS 1.3", res.Version)
+	assert.Len(t, res.Chain, 1)
+	assert.Contains(t, res.SANs, "example.com")
+
+	// The certificate of the test server is not trusted by the system
+	// roots, but it is by its own CA.
+	res, err = storageTLS(ctx, blob.Params{blob.EndPointParam: server.URL}, policy, nil, "")
+	require.NoError(t, err)
+	assert.False(t, res.SystemRoots)
+	assert.Equal(t, []string{"certificate is not trusted"}, res.Violations)
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	res, err = storageTLS(ctx, blob.Params{blob.EndPointParam: server.URL}, policy, nil, ca)
+	require.NoError(t, err)
+	assert.True(t, res.Compliant())
+	assert.Len(t, res.Findings, 1)
+
+	res, err = storageTLS(ctx, blob.Params{blob.EndPointParam: "http://minio:9000"}, policy, nil, "")
+	require.NoError(t, err)
+	assert.False(t, res.Compliant())
+}
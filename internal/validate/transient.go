@@ -0,0 +1,139 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cockroachdb/errors"
+)
+
+// transientMessages are fragments of error messages reported by the cluster
+// when a job or a request to the storage provider failed for a reason that
+// is likely to go away on its own.
+var transientMessages = []string{
+	"restart transaction",
+	"result is ambiguous",
+	"retryable",
+	"connection reset",
+	"broken pipe",
+	"i/o timeout",
+	"slowdown",
+	"serviceunavailable",
+	"internalerror",
+	"requesttimeout",
+	"status code: 500",
+	"status code: 502",
+	"status code: 503",
+	"status code: 504",
+	"statuscode: 500",
+	"statuscode: 502",
+	"statuscode: 503",
+	"statuscode: 504",
+}
+
+// permanentMessages are fragments of error messages that explicitly say the
+// failure will not go away, and which would otherwise match one of the
+// transientMessages.
+var permanentMessages = []string{
+	"non-retryable",
+	"nonretryable",
+	"not retryable",
+}
+
+// FailureClass tells whether re-running a failed validation is worthwhile.
+type FailureClass string
+
+const (
+	// Transient failures may go away on their own: re-running may succeed.
+	Transient FailureClass = "transient"
+	// Deterministic failures (e.g. permissions, unsupported features) will
+	// happen again until the configuration is fixed.
+	Deterministic FailureClass = "deterministic"
+)
+
+// Failure describes the step that caused the validation to fail.
+type Failure struct {
+	Step  string
+	Class FailureClass
+	Err   string
+}
+
+// Classify returns whether the error is transient or deterministic.
+func Classify(err error) FailureClass {
+	if IsTransient(err) {
+		return Transient
+	}
+	return Deterministic
+}
+
+// IsTransient returns whether the error is likely to be transient, so that
+// re-running the validation may succeed: server errors from the storage
+// provider, retryable transaction and job errors, and connection resets.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	// The validation was stopped or ran out of time: do not retry.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Transaction rollback (e.g. retry errors) and connection exceptions.
+		if strings.HasPrefix(pgErr.Code, "40") || strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" {
+			return true
+		}
+	}
+	// Errors the AWS SDK itself would retry: throttling, 5xx responses, and
+	// connection errors.
+	if retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary {
+		return true
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorFault() == smithy.FaultServer {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range permanentMessages {
+		if strings.Contains(msg, m) {
+			return false
+		}
+	}
+	for _, m := range transientMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
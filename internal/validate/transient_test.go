@@ -0,0 +1,72 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachdb/errors"
+)
+
+func TestIsTransient(t *testing.T) {
+	serverErr := &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+		Err:      errors.New("service unavailable"),
+	}
+	forbidden := &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusForbidden}},
+		Err:      errors.New("access denied"),
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"retry error", &pgconn.PgError{Code: "40001"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"storage 5xx", errors.Wrap(serverErr, "failed to list objects"), true},
+		{"storage 403", errors.Wrap(forbidden, "failed to list objects"), false},
+		{"connection reset", errors.Wrap(syscall.ECONNRESET, "read"), true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"deadline", context.DeadlineExceeded, false},
+		{"throttling", errors.Wrap(&smithy.GenericAPIError{Code: "SlowDown"}, "put object"), true},
+		{"job error", errors.New("job failed: s3 put: SlowDown: please reduce your request rate"), true},
+		{"access denied", errors.New("job failed: AccessDenied: access denied"), false},
+		{"retryable job error", errors.New("job failed: retryable error: node unavailable"), true},
+		{"non-retryable job error", errors.New("job failed: non-retryable error: invalid backup"), false},
+		{"not retryable job error", errors.New("job failed: error is not retryable"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, Transient, Classify(&pgconn.PgError{Code: "40001"}))
+	assert.Equal(t, Deterministic, Classify(&pgconn.PgError{Code: "42501"}))
+}
@@ -16,16 +16,23 @@
 package validate
 
 import (
+	"cmp"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/audit"
 	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/chaos"
 	"github.com/cockroachlabs-field/blobcheck/internal/db"
 	"github.com/cockroachlabs-field/blobcheck/internal/env"
+	"github.com/cockroachlabs-field/blobcheck/internal/tunnel"
+	"github.com/cockroachlabs-field/blobcheck/internal/workload"
 )
 
 const (
@@ -36,12 +43,72 @@ const (
 
 	rangesPerNode = 3  // over-split so SCATTER lands a leaseholder on every node
 	defaultRanges = 16 // fallback when node count is unknown (CRDB < v25.1)
+
+	gcMargin       = 10 * time.Second // extra wait after the GC TTL expires
+	gcPollInterval = 5 * time.Second  // interval between the reads checking whether the GC ran
+	gcPollTimeout  = 2 * time.Minute  // time allowed for the GC to run once the GC TTL expired
 )
 
 // Report contains the results of a validation run.
 type Report struct {
 	SuggestedParams blob.Params
+	SuggestedURL    string                // destination URL with the suggested parameters and the secrets obfuscated
+	ProvidedParams  blob.Params           // parameters provided by the user, if the storage changes them
+	Credentials     *CredentialsResult    // kind of the credentials in the suggested parameters
+	ProbeLatency    *blob.Latency         // timings of the probe of the suggested configuration
+	Capabilities    []blob.Capability     // outcome of probing each storage operation, in guess mode
+	Permissions     []blob.Permission     // outcome of probing each action, if no configuration works
+	Diagnoses       []blob.Diagnosis      // classified failure of each candidate configuration, if no configuration works
+	KeyChecks       []blob.KeyCheck       // outcome of probing keys with special characters, in guess mode
+	Listing         []blob.ListingSample  // listing time as the number of objects grows, with --object-count
+	Pagination      *blob.Pagination      // listings with continuation tokens and delimiters, with --probe-pagination
+	VirtualHost     *blob.VirtualHost     // virtual-hosted requests to a custom domain, with --virtual-host-domain
+	Archival        *blob.Archival        // whether the objects can transition to archival storage classes
+	Failover        *blob.Failover        // suggested configuration probed on each endpoint, with several endpoints
+	Immutability    *ImmutabilityResult   // object lock configuration of the bucket, if enabled
+	Bucket          *blob.BucketInventory // versioning, object lock and lifecycle configuration of the bucket, on S3
+	MinIO           *blob.MinIOInfo       // deployment serving the destination, with the minio command
+	Population      *PopulationResult     // source table populated by the initial workload, with --min-rows or --min-bytes
+	Pause           *PauseResult          // rows written between the backups, with --pause-workload
+	Oracle          *OracleResult         // restored rows compared with the rows written by the workload
+	PartialRestore  *PartialRestoreResult // table restored out of a database backup, with --backup-scope
+	Candidates      []blob.Candidate      // working configurations, ranked, with --rank-candidates
+	Limitations     []blob.Limitation     // known limitations of the storage, such as directory buckets
+	Probe           *blob.ProbeObject     // objects written by the probes, for the security scanners
+	CustomCA        string                // CA bundle the suggested configuration relies on, with --ca-cert
+	Proxy           *blob.ProxyCheck      // whether the suggested configuration goes through the HTTP proxy, if any
+	VirtualCluster  string                // virtual cluster the validation ran in, if known
 	Stats           []*db.Stats
+	Variants        []*VariantResult    // statistics of the parameter variants, with --variant
+	Gaps            []*GapResult        // local and cluster outcomes of each candidate configuration, with --gap-analysis
+	Reachability    *ReachabilityResult // outcome of CHECK EXTERNAL CONNECTION on each node
+	Isolation       *IsolationResult    // nodes running the workload and the backup, with --workload-locality
+	Locality        *LocalityResult     // nodes that wrote the backup data, with --execution-locality
+	Manifests       *ManifestResult     // manifests of the backup collection, read through the blob layer
+	Metrics         *MetricsResult      // node metrics during the full backup, with --metrics-url
+	CrossCluster    *CrossClusterResult // backup restored into a second cluster, with --dr-cluster or --restore-check-version
+	Cost            *CostEstimate       // projected monthly cost of the backups, with --storage-price or --egress-price
+	Window          *WindowResult       // projected full backup duration of the data, with --backup-window
+	ConnDiffs       []ParamDiff         // parameters of the existing external connections that differ from the suggested ones
+	Schedules       []*ScheduleLint     // backup schedules targeting the bucket, compared with the measured durations
+	Chaos           *ChaosResult
+	SchemaChange    *SchemaChangeResult // online schema change run during the full backup, with --schema-change
+	Hook            *HookResult         // command run during the full backup, with --on-backup-start-cmd
+	GC              *GCResult           // garbage collection between the backups, with --gc-ttl
+	Egress          *EgressResult
+	TLS             []*TLSResult
+	Audit           *audit.Attestation // connections attempted during the run, with --offline-audit
+	Exposure        *ExposureResult    // secrets of the external connection shown in clear by the cluster, if any
+	Failure         *Failure           // the step that failed, if any
+	Cleanup         *CleanupResult     // removal of the resources created by the run
+}
+
+// CleanupResult is the outcome of removing the resources created by the
+// validation.
+type CleanupResult struct {
+	Verified bool   // no blobcheck objects remain in the destination or in the cluster
+	Retained int    // objects left in the destination: locked, or there before the run
+	Err      string // why the cleanup failed or could not be verified, if it did
 }
 
 // Validator verifies backup/restore functionality
@@ -50,7 +117,33 @@ type Validator struct {
 	pool                       *pgxpool.Pool
 	blobStorage                blob.Storage
 	sourceTable, restoredTable db.KvTable
+	createdSchema              bool       // the schema of the tables was created in the database selected by the user
+	backedUp                   db.KvTable // the source table, as named in the backup
+	remote                     *remoteCluster
+	virtualCluster             string              // virtual cluster of the connections, if known
+	isolation                  *IsolationResult    // nodes running the workload and the backup, if isolated
+	isolatedPool               *pgxpool.Pool       // connections to the workload nodes, if isolated
+	scraper                    *metricsScraper     // scrapes the node metrics during the full backup, if enabled
+	metrics                    *MetricsResult      // change of the node metrics during the full backup
+	chaos                      *chaos.Proxy        // routes the external connection through injected faults, if enabled
+	schemaChange               *SchemaChangeResult // online schema change run during the full backup, if enabled
+	hook                       *HookResult         // command run during the full backup, if enabled
+	gc                         *GCResult           // garbage collection between the backups, if enabled
+	progress                   func(*Report)       // called after each step with the partial report, if set
+	objectLock                 *blob.ObjectLock    // object lock configuration of the bucket, once checked
+	paused                     *pausedWorkload     // workload paused during the incremental backup, if enabled
+	population                 *PopulationResult   // source table populated by the initial workload, if targeted
+	oracle                     *workload.Oracle    // rows written by the workload, if recorded
+	oracleResult               *OracleResult       // restored rows compared with the oracle, once checked
+	preexisting                []string            // objects found in the destination before the backups, left in place with --force
+	unwrapped                  blob.Storage        // the store before fault injection, which implements the optional probes of its backend
 	latest                     string
+	latestEndTime              time.Time     // end time of the most recent backup
+	fullBackupTime             time.Duration // time spent taking the full backup
+	fullBackupSize             int64         // size of the table in the full backup
+	incrementalBackupTime      time.Duration // time spent taking the incremental backup
+	readRate, writeRate        float64       // aggregate storage throughput, in bytes per second
+	ingestRate                 float64       // workload ingest rate, in bytes per second
 }
 
 // New creates a new Validator.
@@ -59,15 +152,28 @@ func New(ctx *stopper.Context, env *env.Env, blobStorage blob.Storage) (*Validat
 		return nil, err
 	}
 
-	config, err := pgxpool.ParseConfig(env.DatabaseURL)
+	pool, err := connect(ctx, env)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse database URL")
+		return nil, err
 	}
-	config.MaxConns = maxConns
+	// The pools opened so far are closed if the validator is not created.
+	pools := []*pgxpool.Pool{pool}
+	created := false
+	defer func() {
+		if !created {
+			for _, p := range pools {
+				p.Close()
+			}
+		}
+	}()
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create database pool")
+	unwrapped := blobStorage
+	var proxy *chaos.Proxy
+	if chaosEnabled(env) {
+		proxy, blobStorage, err = startChaos(ctx, env, blobStorage)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	conn, err := pool.Acquire(ctx)
@@ -76,7 +182,46 @@ func New(ctx *stopper.Context, env *env.Env, blobStorage blob.Storage) (*Validat
 	}
 	defer conn.Release()
 
-	sourceTable, err := createSourceTable(ctx, conn)
+	if err := checkSessionDefaults(ctx, conn); err != nil {
+		return nil, err
+	}
+	checkPrivileges(ctx, conn, env)
+
+	virtualCluster := detectVirtualCluster(ctx, conn)
+	if isSecondary(virtualCluster) {
+		slog.Info("the stores are not visible from a secondary virtual cluster; skipping the capacity check")
+	} else if err := checkCapacity(ctx, conn, env.MinFreeSpace, expectedSize(env)); err != nil {
+		return nil, err
+	}
+	if err := checkBucketQuota(ctx, unwrapped, env.MinFreeSpace, expectedSize(env)); err != nil {
+		return nil, err
+	}
+
+	var scraper *metricsScraper
+	if len(env.MetricsURLs) > 0 {
+		scraper, err = newMetricsScraper(env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var isolation *IsolationResult
+	var isolatedPool *pgxpool.Pool
+	if isolationEnabled(env) {
+		isolation, isolatedPool, err = isolate(ctx, conn, env, virtualCluster)
+		if err != nil {
+			return nil, err
+		}
+		if isolatedPool != nil {
+			pools = append(pools, isolatedPool)
+		}
+	}
+
+	name := newTableName()
+	if err := checkNameCollisions(ctx, conn, env, name); err != nil {
+		return nil, err
+	}
+	sourceTable, createdSchema, err := createSourceTable(ctx, conn, env, name)
 	if err != nil {
 		return nil, err
 	}
@@ -91,20 +236,78 @@ func New(ctx *stopper.Context, env *env.Env, blobStorage blob.Storage) (*Validat
 		return nil, errors.New("pending jobs found on source table")
 	}
 
-	restoredTable, err := createRestoredTable(ctx, conn)
+	restoredTable, err := createRestoredTable(ctx, conn, env, name)
 	if err != nil {
 		return nil, err
 	}
 
+	var remote *remoteCluster
+	if url := cmp.Or(env.DRClusterURL, env.RestoreCheckURL); url != "" {
+		remote, err = newRemoteCluster(ctx, url, schemaFor(env), name, env.Dial)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, remote.pool)
+	}
+
+	created = true
 	return &Validator{
-		env:           env,
-		pool:          pool,
-		restoredTable: restoredTable,
-		sourceTable:   sourceTable,
-		blobStorage:   blobStorage,
+		env:            env,
+		pool:           pool,
+		restoredTable:  restoredTable,
+		sourceTable:    sourceTable,
+		backedUp:       sourceTable,
+		createdSchema:  createdSchema,
+		remote:         remote,
+		virtualCluster: virtualCluster,
+		isolation:      isolation,
+		isolatedPool:   isolatedPool,
+		scraper:        scraper,
+		chaos:          proxy,
+		unwrapped:      unwrapped,
+		oracle:         newOracle(env),
+		blobStorage:    blobStorage,
 	}, nil
 }
 
+// connect creates a connection pool to the cluster, routing the
+// connections to the selected virtual cluster, if any.
+func connect(ctx *stopper.Context, env *env.Env) (*pgxpool.Pool, error) {
+	config, err := poolConfig(env)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create database pool")
+	}
+	return pool, nil
+}
+
+// poolConfig returns the configuration of the connection pool to the
+// cluster.
+func poolConfig(env *env.Env) (*pgxpool.Config, error) {
+	if err := db.CheckCredentials(env.DatabaseURL); err != nil {
+		return nil, err
+	}
+	config, err := pgxpool.ParseConfig(env.DatabaseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse database URL")
+	}
+	config.MaxConns = maxConns
+	overrideSessionDefaults(config.ConnConfig)
+	if env.Dial != nil {
+		config.ConnConfig.DialFunc = pgconn.DialFunc(env.Dial)
+		config.ConnConfig.LookupFunc = tunnel.LookupHost
+	}
+	if env.Tenant != "" {
+		// Route the connections to the selected virtual cluster.
+		options := config.ConnConfig.RuntimeParams["options"]
+		config.ConnConfig.RuntimeParams["options"] = strings.TrimSpace(options + " -ccluster=" + env.Tenant)
+	}
+	return config, nil
+}
+
 // preflight validates the input parameters for New.
 func preflight(ctx *stopper.Context, env *env.Env, blobStorage blob.Storage) error {
 	if env == nil {
@@ -113,37 +316,256 @@ func preflight(ctx *stopper.Context, env *env.Env, blobStorage blob.Storage) err
 	if blobStorage == nil {
 		return errors.New("blob storage cannot be nil")
 	}
+	if bucket := blobStorage.BucketName(); blob.IsAccessPoint(bucket) {
+		return errors.WithHint(
+			errors.Newf("CockroachDB cannot address the access point %q by its ARN", bucket),
+			"use the alias of the access point as the bucket, or run with --guess to probe the ARN only")
+	}
 	if env.DatabaseURL == "" {
 		return errors.New("database URL cannot be empty")
 	}
+	if env.ObjectCount < 0 {
+		return errors.New("object count cannot be negative")
+	}
 	if env.Workers < 0 {
 		return errors.New("workers count cannot be negative")
 	}
+	if env.ProbeRetries < 0 || env.ProbeTimeout < 0 || env.ProbeBackoff < 0 {
+		return errors.New("probe retries, timeout and backoff cannot be negative")
+	}
 	if env.WorkloadDuration <= 0 {
 		return errors.New("workload duration must be positive")
 	}
+	if env.MinRows < 0 || env.MinBytes < 0 {
+		return errors.New("minimum rows and bytes cannot be negative")
+	}
+	if target := populationTargetFromEnv(env); target.set() {
+		if env.Dataset != "" {
+			return errors.New("--min-rows and --min-bytes apply to the workload, and cannot be combined with --dataset")
+		}
+		if target.maxTime <= 0 {
+			return errors.New("maximum workload duration must be positive")
+		}
+	}
+	if env.GapAnalysis && !env.RankCandidates {
+		return errors.New("--gap-analysis requires --rank-candidates, so that every candidate configuration is probed")
+	}
+	if env.DRClusterURL != "" && env.RestoreCheckURL != "" {
+		return errors.New("a second cluster can be provided either for a restore check or for a DR drill, not both")
+	}
+	if env.GCTTL < 0 || (env.GCTTL > 0 && env.GCTTL < time.Second) {
+		return errors.New("GC TTL must be at least one second")
+	}
+	if env.MinFreeSpace < 0 || env.MinFreeSpace >= 1 {
+		return errors.New("minimum free space must be a fraction between 0 and 1")
+	}
+	if env.StoragePrice < 0 || env.EgressPrice < 0 {
+		return errors.New("prices cannot be negative")
+	}
+	if env.ReadVolume != "" {
+		if _, err := parseSize(env.ReadVolume); err != nil {
+			return errors.Wrap(err, "invalid read volume")
+		}
+	}
+	if costEnabled(env) &&
+		(env.FullBackupInterval <= 0 || env.IncrementalInterval <= 0 || env.Retention <= 0) {
+		return errors.New("backup intervals and retention must be positive to estimate the cost")
+	}
+	if env.BackupWindow < 0 {
+		return errors.New("backup window cannot be negative")
+	}
+	if env.BackupWindow > 0 && env.DataSize == "" {
+		return errors.New("data size is required to project the backup window")
+	}
+	if env.DataSize != "" {
+		if _, err := parseSize(env.DataSize); err != nil {
+			return errors.Wrap(err, "invalid data size")
+		}
+	}
+	if !chaosEnabled(env) && (env.ChaosAdvertise != "" || env.ChaosBandwidth != "" ||
+		env.ChaosErrorRate != 0 || env.ChaosLatency != 0) {
+		return errors.New("fault injection requires the address of the proxy")
+	}
+	if env.ChaosErrorRate < 0 || env.ChaosErrorRate >= 1 {
+		return errors.New("error rate must be a fraction between 0 and 1")
+	}
+	if env.ChaosLatency < 0 {
+		return errors.New("latency cannot be negative")
+	}
+	if _, err := chaosFaults(env); err != nil {
+		return errors.Wrap(err, "invalid bandwidth")
+	}
+	if _, err := tlsPolicy(env); err != nil {
+		return err
+	}
+	if err := CheckRedactPolicy(env.Redact); err != nil {
+		return err
+	}
+	if err := checkIsolation(env); err != nil {
+		return err
+	}
+	if err := checkSchemaChange(env.SchemaChange); err != nil {
+		return err
+	}
+	if err := checkIncrementalLocation(env.IncrementalLocation); err != nil {
+		return err
+	}
+	if err := checkOracleSample(env.OracleSample); err != nil {
+		return err
+	}
+	if err := checkBackupScope(env); err != nil {
+		return err
+	}
+	if err := checkMetricsURLs(env.MetricsURLs); err != nil {
+		return err
+	}
+	if err := checkVariants(env.Variants, blobStorage); err != nil {
+		return err
+	}
+	if chaosEnabled(env) && env.ApplyConn != "" {
+		return errors.New("the validated URL cannot be applied when fault injection is enabled")
+	}
 	return nil
 }
 
-// Clean removes all resources created by the validator.
-func (v *Validator) Clean(ctx *stopper.Context) error {
+// Close releases the connections of the validator, which is not usable
+// afterwards.
+func (v *Validator) Close() {
+	v.pool.Close()
+	if v.isolatedPool != nil {
+		v.isolatedPool.Close()
+	}
+	if v.chaos != nil {
+		v.chaos.Close()
+	}
+}
+
+// Clean removes all resources created by the validator, and verifies that
+// none remain. Databases selected by the user are preserved; only the tables
+// created by blobcheck are dropped.
+func (v *Validator) Clean(ctx *stopper.Context) (*CleanupResult, error) {
 	slog.Debug("Starting cleanup of validator resources")
+	res := &CleanupResult{}
+	if err := v.stopWorkload(); err != nil {
+		slog.Warn("the workload failed", slog.Any("error", err))
+	}
 	conn, err := v.acquireConn(ctx)
 	if err != nil {
-		return err
+		res.Err = err.Error()
+		return res, err
 	}
 	defer conn.Release()
 
 	var e1, e2 error
-	slog.Debug("Dropping source database", slog.String("database", v.sourceTable.Database.String()))
-	if err := v.sourceTable.Database.Drop(ctx, conn); err != nil {
-		e1 = errors.Wrap(err, "failed to drop source database")
+	if v.env.Database != "" {
+		if err := v.sourceTable.Drop(ctx, conn); err != nil {
+			e1 = errors.Wrap(err, "failed to drop source table")
+		}
+		if err := v.restoredTable.Drop(ctx, conn); err != nil {
+			e2 = errors.Wrap(err, "failed to drop restored table")
+		}
+		if v.createdSchema && e1 == nil && e2 == nil {
+			if err := v.sourceTable.Database.DropSchema(ctx, conn, v.sourceTable.Schema); err != nil {
+				e2 = errors.Wrap(err, "failed to drop schema")
+			}
+		}
+	} else {
+		slog.Debug("Dropping source database", slog.String("database", v.sourceTable.Database.String()))
+		if err := v.sourceTable.Database.Drop(ctx, conn); err != nil {
+			e1 = errors.Wrap(err, "failed to drop source database")
+		}
+		slog.Debug("Dropping restored database", slog.String("database", v.restoredTable.Database.String()))
+		if err := v.restoredTable.Database.Drop(ctx, conn); err != nil {
+			e2 = errors.Wrap(err, "failed to drop restored database")
+		}
+	}
+	var e3, e4 error
+	if v.remote != nil {
+		if err := v.remote.close(ctx); err != nil {
+			e4 = err
+		}
 	}
-	slog.Debug("Dropping restored database", slog.String("database", v.restoredTable.Database.String()))
-	if err := v.restoredTable.Database.Drop(ctx, conn); err != nil {
-		e2 = errors.Wrap(err, "failed to drop restored database")
+	slog.Debug("Removing objects from the storage provider")
+	var locked []string
+	if (v.objectLock != nil && v.objectLock.Enabled) || len(v.preexisting) > 0 {
+		// Respect the retention of the objects, rather than failing the
+		// cleanup or leaving delete markers over them, and leave the objects
+		// that were in the destination before the run.
+		var err error
+		if locked, err = v.cleanLocked(ctx); err != nil {
+			e3 = errors.Wrap(err, "failed to remove objects from the storage provider")
+		}
+	} else if err := v.blobStorage.Clean(ctx); err != nil {
+		e3 = errors.Wrap(err, "failed to remove objects from the storage provider")
 	}
-	return errors.Join(e1, e2)
+	retained := append(locked, v.preexisting...)
+	res.Retained = len(retained)
+	err = errors.Join(e1, e2, e3, e4)
+	if err == nil {
+		err = v.verifyCleanup(ctx, conn, retained)
+	}
+	switch {
+	case errors.Is(err, blob.ErrUnsupported):
+		// The cluster was verified, but the destination cannot be listed.
+		slog.Warn("cannot verify the cleanup of the destination", slog.Any("error", err))
+		res.Err = err.Error()
+		return res, nil
+	case err != nil:
+		res.Err = err.Error()
+		return res, err
+	}
+	res.Verified = true
+	return res, nil
+}
+
+// verifyCleanup checks that no objects created by blobcheck remain in the
+// destination, other than the locked ones, and that no blobcheck databases
+// remain in the cluster. If the destination cannot be listed, the cluster
+// is still checked, and an error wrapping blob.ErrUnsupported is returned.
+func (v *Validator) verifyCleanup(ctx *stopper.Context, conn *pgxpool.Conn, locked []string) error {
+	objects, listErr := v.blobStorage.List(ctx)
+	if errors.Is(listErr, blob.ErrUnsupported) {
+		listErr = errors.Wrap(listErr, "cannot verify the cleanup of the destination")
+	} else if listErr != nil {
+		return errors.Wrap(listErr, "failed to verify cleanup of the storage provider")
+	}
+	objects = unlocked(objects, locked)
+	if len(objects) > 0 {
+		return errors.Newf("cleanup failed: %d objects remain in the destination (e.g. %q)", len(objects), objects[0].Key)
+	}
+	if v.env.Database != "" {
+		for _, table := range []db.KvTable{v.sourceTable, v.restoredTable} {
+			exists, err := table.Exists(ctx, conn)
+			if err != nil {
+				return errors.Wrap(err, "failed to verify cleanup of the cluster")
+			}
+			if exists {
+				return errors.Newf("cleanup failed: table %s remains in the cluster", table.String())
+			}
+		}
+		if v.createdSchema {
+			exists, err := v.sourceTable.Database.SchemaExists(ctx, conn, v.sourceTable.Schema)
+			if err != nil {
+				return errors.Wrap(err, "failed to verify cleanup of the cluster")
+			}
+			if exists {
+				return errors.Newf("cleanup failed: schema %s remains in the cluster", v.sourceTable.Schema.String())
+			}
+		}
+	} else {
+		dbs, err := db.Databases(ctx, conn, `\_blobcheck%`)
+		if err != nil {
+			return errors.Wrap(err, "failed to verify cleanup of the cluster")
+		}
+		if len(dbs) > 0 {
+			return errors.Newf("cleanup failed: databases %v remain in the cluster", dbs)
+		}
+	}
+	if listErr != nil {
+		return listErr
+	}
+	slog.Info("cleanup verified: no blobcheck objects remain in the destination or in the cluster")
+	return nil
 }
 
 // validationStepFn is a function that performs a validation step.
@@ -166,30 +588,203 @@ func (v *Validator) Validate(ctx *stopper.Context) (*Report, error) {
 	}
 	defer conn.Release()
 
-	extConn, err := db.NewExternalConn(ctx, conn, v.blobStorage)
+	extConn, err := newExternalConn(ctx, conn, v.blobStorage, v.env.IncrementalLocation, "")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create external connection")
 	}
 	defer extConn.Drop(ctx, conn)
 
 	var stats []*db.Stats
+	var crossCluster *CrossClusterResult
+	var cost *CostEstimate
+	var window *WindowResult
+	var egress *EgressResult
+	var tlsResults []*TLSResult
+	var variants []*VariantResult
+	var gaps []*GapResult
+	var reachability *ReachabilityResult
+	var exposure *ExposureResult
+	var locality *LocalityResult
+	probe := blob.ProbeObjectFromEnv(v.env)
+	var manifests *ManifestResult
+	var listing []blob.ListingSample
+	var archival *blob.Archival
+	var pagination *blob.Pagination
+	var virtualHost *blob.VirtualHost
+	var failover *blob.Failover
+	var immutability *ImmutabilityResult
+	var bucket *blob.BucketInventory
+	var minio *blob.MinIOInfo
+	var partial *PartialRestoreResult
 
 	// Define validation steps
 	steps := []validationStep{
+		{
+			name: "check destination",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				return v.checkDestination(ctx, probe)
+			},
+		},
+		{
+			name: "check tls policy",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				tlsResults, err = v.checkTLS(ctx)
+				return err
+			},
+		},
 		{
 			name: "capture initial stats",
 			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
 				var err error
 				stats, err = v.captureInitialStats(ctx, extConn)
+				v.readRate, v.writeRate = throughput(stats)
 				return err
 			},
 		},
+		{
+			name: "check node reachability",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				reachability, err = checkReachability(v.env.ExecutionLocality, stats)
+				return err
+			},
+		},
+		{
+			name: "probe listing scalability",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				listing, err = ProbeListing(ctx, v.env, v.unwrapped)
+				return err
+			},
+		},
+		{
+			name: "probe list pagination",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				pagination, err = ProbePagination(ctx, v.env, v.unwrapped)
+				return err
+			},
+		},
+		{
+			name: "probe virtual-hosted addressing",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				virtualHost, err = ProbeVirtualHost(ctx, v.env, v.unwrapped)
+				return err
+			},
+		},
+		{
+			name: "check archival storage",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				archival, err = ProbeArchival(ctx, v.unwrapped)
+				return err
+			},
+		},
+		{
+			name: "check endpoint failover",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				failover, err = ProbeEndpoints(ctx, v.unwrapped)
+				return err
+			},
+		},
+		{
+			name: "check object lock",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				immutability, err = v.checkImmutability(ctx)
+				return err
+			},
+		},
+		{
+			name: "inventory bucket capabilities",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				bucket, err = InventoryBucket(ctx, v.unwrapped)
+				return err
+			},
+		},
+		{
+			name: "check minio deployment",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				minio, err = CheckMinIO(ctx, v.unwrapped)
+				return err
+			},
+		},
+		{
+			name: "compare parameter variants",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if len(v.env.Variants) == 0 {
+					return nil
+				}
+				var err error
+				variants, err = v.compareVariants(ctx, stats)
+				return err
+			},
+		},
+		{
+			name: "analyze configuration gaps",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if !v.env.GapAnalysis {
+					return nil
+				}
+				var err error
+				gaps, err = v.analyzeGaps(ctx)
+				return err
+			},
+		},
+		{
+			name: "check network path",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if !v.env.CheckEgress {
+					return nil
+				}
+				var err error
+				egress, err = v.checkEgress(ctx)
+				return err
+			},
+		},
+		{
+			name: "project backup window",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if v.env.BackupWindow <= 0 {
+					return nil
+				}
+				size, err := parseSize(v.env.DataSize)
+				if err != nil {
+					return err
+				}
+				window = projectWindow(int64(size), v.writeRate, v.env.BackupWindow)
+				return nil
+			},
+		},
 		{
 			name: "presplit source table",
 			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
 				return v.presplitSourceTable(ctx, len(stats))
 			},
 		},
+		{
+			name: "configure gc ttl",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if v.env.GCTTL <= 0 {
+					return nil
+				}
+				return v.configureGC(ctx)
+			},
+		},
+		{
+			name: "create companion table",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if v.env.BackupScope != ScopeDatabase {
+					return nil
+				}
+				return v.createCompanionTable(ctx)
+			},
+		},
 		{
 			name: "workload with backup",
 			fn:   v.runWorkloadWithBackup,
@@ -202,10 +797,57 @@ func (v *Validator) Validate(ctx *stopper.Context) (*Report, error) {
 			name: "check backups",
 			fn:   v.checkBackups,
 		},
+		{
+			name: "check backup manifests",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				manifests, err = v.checkManifests(ctx)
+				return err
+			},
+		},
+		{
+			name: "verify execution locality",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if v.env.ExecutionLocality == "" {
+					return nil
+				}
+				var err error
+				locality, err = v.verifyExecutionLocality(ctx, stats)
+				return err
+			},
+		},
+		{
+			name: "estimate cost",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if !costEnabled(v.env) {
+					return nil
+				}
+				var err error
+				cost, err = v.projectCost(ctx, extConn)
+				return err
+			},
+		},
+		{
+			name: "stop workload",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				return v.stopWorkload()
+			},
+		},
 		{
 			name: "restore",
 			fn:   v.performRestore,
 		},
+		{
+			name: "check partial restore",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if v.env.BackupScope != ScopeDatabase {
+					return nil
+				}
+				var err error
+				partial, err = v.checkPartialRestore(ctx)
+				return err
+			},
+		},
 		{
 			name: "verify integrity",
 			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
@@ -217,6 +859,68 @@ func (v *Validator) Validate(ctx *stopper.Context) (*Report, error) {
 				return nil
 			},
 		},
+		{
+			name: "cross-cluster restore",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				if v.remote == nil {
+					return nil
+				}
+				var err error
+				crossCluster, err = v.restoreIntoRemote(ctx)
+				return err
+			},
+		},
+		{
+			name: "check credential exposure",
+			fn: func(ctx *stopper.Context, extConn *db.ExternalConn) error {
+				var err error
+				exposure, err = v.checkExposure(ctx, extConn)
+				return err
+			},
+		},
+	}
+
+	// completed returns the report of the steps completed so far.
+	completed := func() *Report {
+		return &Report{
+			SuggestedParams: extConn.SuggestedParams(),
+			SuggestedURL:    blob.ObfuscateURL(v.blobStorage.RootURL()),
+			ProvidedParams:  v.blobStorage.ProvidedParams(),
+			Credentials:     CheckCredentials(v.unwrapped),
+			ProbeLatency:    v.blobStorage.Latency(),
+			Candidates:      v.blobStorage.Candidates(),
+			Limitations:     blob.Limitations(v.blobStorage.BucketName()),
+			Probe:           &probe,
+			CustomCA:        v.blobStorage.CustomCA(),
+			Proxy:           CheckProxy(v.unwrapped),
+			Listing:         listing,
+			Pagination:      pagination,
+			VirtualHost:     virtualHost,
+			Archival:        archival,
+			Failover:        failover,
+			Immutability:    immutability,
+			Bucket:          bucket,
+			MinIO:           minio,
+			Population:      v.population,
+			Pause:           v.pauseResult(),
+			Oracle:          v.oracleResult,
+			PartialRestore:  partial,
+			VirtualCluster:  v.virtualCluster,
+			Stats:           stats,
+			Variants:        variants,
+			Gaps:            gaps,
+			Reachability:    reachability,
+			Exposure:        exposure,
+			Isolation:       v.isolationResult(),
+			Locality:        locality,
+			Manifests:       manifests,
+			Metrics:         v.metricsResult(),
+			Chaos:           v.chaosResult(),
+			SchemaChange:    v.schemaChange,
+			Hook:            v.hook,
+			GC:              v.gc,
+			TLS:             tlsResults,
+		}
 	}
 
 	// Execute steps
@@ -225,14 +929,49 @@ func (v *Validator) Validate(ctx *stopper.Context) (*Report, error) {
 			return nil, ctx.Err()
 		}
 		if err := step.fn(ctx, extConn); err != nil {
-			return nil, errors.Wrapf(err, "failed during step: %s", step.name)
+			failure := &Failure{Step: step.name, Class: Classify(err), Err: err.Error()}
+			slog.Error("validation failed", slog.String("step", step.name),
+				slog.String("class", string(failure.Class)))
+			report := completed()
+			report.Failure = failure
+			return report, errors.Wrapf(err, "failed during step: %s", step.name)
+		}
+		if v.progress != nil {
+			v.progress(completed())
 		}
 	}
 
-	return &Report{
-		SuggestedParams: extConn.SuggestedParams(),
-		Stats:           stats,
-	}, nil
+	report := completed()
+	report.ConnDiffs = v.compareExternalConns(ctx, extConn)
+	report.Schedules = v.lintSchedules(ctx, window)
+	report.CrossCluster = crossCluster
+	report.Cost = cost
+	report.Window = window
+	report.Egress = egress
+	return report, nil
+}
+
+// OnProgress registers a function called with the report of the steps
+// completed so far, after each step of the validation.
+func (v *Validator) OnProgress(fn func(*Report)) {
+	v.progress = fn
+}
+
+// Apply creates the named external connection with the URL validated by
+// the run, replacing an existing connection with the same name.
+func (v *Validator) Apply(ctx *stopper.Context, name string) error {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	replaced, err := db.ReplaceExternalConn(ctx, conn, db.Ident(name), v.blobStorage.RootURL())
+	if err != nil {
+		return errors.Wrapf(err, "failed to create external connection %s", name)
+	}
+	slog.Info("external connection applied", slog.String("name", name), slog.Bool("replaced", replaced),
+		slog.String("virtual_cluster", v.virtualCluster))
+	return nil
 }
 
 // presplitSourceTable splits the source table into ranges and scatters them so
@@ -255,7 +994,7 @@ func (v *Validator) presplitSourceTable(ctx *stopper.Context, nodes int) error {
 	slog.Info("presplitting and scattering source table",
 		slog.Int("nodes", nodes), slog.Int("ranges", ranges))
 	if err := v.sourceTable.PresplitAndScatter(ctx, conn, ranges); err != nil {
-		return err
+		return withCapabilityHint(err, v.virtualCluster, "can_admin_split", "can_admin_scatter")
 	}
 	// Wait up to one minute for leases to spread across nodes after SCATTER.
 	// Lease settling is best-effort: we log the result at Info and continue
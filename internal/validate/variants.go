@@ -0,0 +1,188 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+// baselineVariant names the suggested parameters among the variants.
+const baselineVariant = "suggested"
+
+// VariantResult contains the outcome of CHECK EXTERNAL CONNECTION for an
+// external connection using a variant of the suggested parameters, so that
+// the performance of the variants can be compared.
+type VariantResult struct {
+	Name      string      // the overrides, or "suggested"
+	Overrides blob.Params // parameters changed from the suggested ones
+	Stats     []*db.Stats // statistics of each node
+	Err       string      // why the external connection could not be created, if it failed
+}
+
+// Throughput returns the aggregate read and write speed of the nodes, in
+// bytes per second.
+func (r *VariantResult) Throughput() (read, write float64) {
+	return throughput(r.Stats)
+}
+
+// newVariantResult returns the result of a variant, with the secrets it
+// overrides obfuscated.
+func newVariantResult(overrides blob.Params) *VariantResult {
+	shown := maps.Clone(overrides)
+	for k := range shown {
+		if slices.Contains(blob.ObfuscatedParams, k) {
+			shown[k] = blob.Obfuscated
+		}
+	}
+	return &VariantResult{Name: variantName(shown), Overrides: shown}
+}
+
+// variantName returns the overrides of a variant, for display.
+func variantName(overrides blob.Params) string {
+	var pairs []string
+	for k, v := range overrides.Iter() {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// parseVariant parses the parameters overridden by a variant, in query
+// string form (e.g. AWS_USE_PATH_STYLE=false&AWS_SKIP_CHECKSUM=true). An
+// empty value removes the parameter.
+func parseVariant(s string) (blob.Params, error) {
+	query, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid variant %q", s)
+	}
+	if len(query) == 0 {
+		return nil, errors.Newf("invalid variant %q: no parameters", s)
+	}
+	res := make(blob.Params, len(query))
+	for k, v := range query {
+		if len(v) > 1 {
+			return nil, errors.Newf("invalid variant %q: parameter %q is repeated", s, k)
+		}
+		res[k] = v[0]
+	}
+	return res, nil
+}
+
+// checkVariants checks that the variants can be parsed and that they yield
+// URLs accepted by CockroachDB.
+func checkVariants(variants []string, store blob.Storage) error {
+	for _, s := range variants {
+		overrides, err := parseVariant(s)
+		if err != nil {
+			return err
+		}
+		if _, err := withOverrides(store.URL(), overrides); err != nil {
+			return errors.Wrapf(err, "invalid variant %q", s)
+		}
+	}
+	return nil
+}
+
+// compareVariants creates an external connection for each variant of the
+// suggested parameters, all of them coexisting in the cluster, and runs
+// CHECK EXTERNAL CONNECTION against each one in turn, so that the checks
+// do not compete for bandwidth. The statistics of the suggested parameters
+// are reported first, for reference. A variant that cannot be created is
+// reported, but it does not fail the validation.
+func (v *Validator) compareVariants(
+	ctx *stopper.Context, baseline []*db.Stats,
+) ([]*VariantResult, error) {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	res := []*VariantResult{{Name: baselineVariant, Stats: baseline}}
+	conns := make([]*db.ExternalConn, len(v.env.Variants))
+	for i, s := range v.env.Variants {
+		overrides, err := parseVariant(s)
+		if err != nil {
+			return nil, err
+		}
+		result := newVariantResult(overrides)
+		res = append(res, result)
+		store := &variantStorage{Storage: v.blobStorage, overrides: overrides}
+		name := db.Ident(fmt.Sprintf("_blobcheck_variant_%d", i+1))
+		extConn, err := db.NewNamedExternalConn(ctx, conn, name, store)
+		if err != nil {
+			slog.Warn("failed to create external connection for variant",
+				slog.String("variant", result.Name), slog.Any("error", err))
+			result.Err = err.Error()
+			continue
+		}
+		defer extConn.Drop(ctx, conn)
+		conns[i] = extConn
+	}
+	for i, extConn := range conns {
+		if extConn == nil {
+			continue
+		}
+		result := res[i+1]
+		slog.Info("checking external connection variant", slog.String("variant", result.Name))
+		if result.Stats, err = extConn.Stats(ctx, conn); err != nil {
+			return nil, errors.Wrapf(err, "failed to check variant %q", result.Name)
+		}
+	}
+	return res, nil
+}
+
+// variantStorage overrides some of the parameters in the URLs of a storage.
+type variantStorage struct {
+	blob.Storage
+	overrides blob.Params
+}
+
+// Params implements blob.Storage.
+func (s *variantStorage) Params() blob.Params {
+	return s.Storage.Params().Merge(s.overrides)
+}
+
+// URL implements blob.Storage.
+func (s *variantStorage) URL() string {
+	res, _ := withOverrides(s.Storage.URL(), s.overrides)
+	return res
+}
+
+// RootURL implements blob.Storage.
+func (s *variantStorage) RootURL() string {
+	res, _ := withOverrides(s.Storage.RootURL(), s.overrides)
+	return res
+}
+
+// withOverrides returns the URL with the parameters overridden, validated
+// against the rules CockroachDB applies.
+func withOverrides(rawURL string, overrides blob.Params) (string, error) {
+	u, err := blob.ParseS3URL(rawURL)
+	if err != nil {
+		return rawURL, err
+	}
+	u.Params = u.Params.Merge(overrides)
+	return u.String(), u.Validate()
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+)
+
+func TestParseVariant(t *testing.T) {
+	a := assert.New(t)
+	got, err := parseVariant("AWS_USE_PATH_STYLE=false&AWS_SKIP_CHECKSUM=true")
+	a.NoError(err)
+	a.Equal(blob.Params{blob.UsePathStyleParam: "false", blob.SkipChecksum: "true"}, got)
+
+	_, err = parseVariant("")
+	a.ErrorContains(err, "no parameters")
+	_, err = parseVariant("AWS_REGION=a&AWS_REGION=b")
+	a.ErrorContains(err, "is repeated")
+}
+
+func TestWithOverrides(t *testing.T) {
+	a := assert.New(t)
+	base := "s3://bucket/path?AWS_ACCESS_KEY_ID=id&AWS_SECRET_ACCESS_KEY=secret&AWS_USE_PATH_STYLE=true"
+	got, err := withOverrides(base, blob.Params{blob.UsePathStyleParam: "", blob.SkipChecksum: "true"})
+	a.NoError(err)
+	a.Equal("s3://bucket/path?AWS_ACCESS_KEY_ID=id&AWS_SECRET_ACCESS_KEY=secret&AWS_SKIP_CHECKSUM=true", got)
+
+	_, err = withOverrides(base, blob.Params{blob.SkipChecksum: "maybe"})
+	a.ErrorContains(err, "expected true or false")
+}
+
+func TestNewVariantResult(t *testing.T) {
+	r := require.New(t)
+	got := newVariantResult(blob.Params{blob.SecretParam: "other", blob.UsePathStyleParam: "false"})
+	r.Equal("AWS_SECRET_ACCESS_KEY=******&AWS_USE_PATH_STYLE=false", got.Name)
+	r.Equal(blob.Obfuscated, got.Overrides[blob.SecretParam])
+}
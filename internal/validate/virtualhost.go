@@ -0,0 +1,37 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"context"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/blob"
+	"github.com/cockroachlabs-field/blobcheck/internal/env"
+)
+
+// ProbeVirtualHost verifies that the domain provided in the environment
+// supports virtual-hosted requests to the bucket. It returns nil if no
+// domain is provided, or if the storage provider does not support the
+// probe.
+func ProbeVirtualHost(ctx context.Context, env *env.Env, store blob.Storage) (*blob.VirtualHost, error) {
+	if env.VirtualHostDomain == "" {
+		return nil, nil
+	}
+	prober, ok := store.(blob.VirtualHostProber)
+	if !ok {
+		return nil, nil
+	}
+	return prober.ProbeVirtualHost(ctx, env.VirtualHostDomain)
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"log/slog"
+	"time"
+)
+
+// WindowResult compares the projected duration of a full backup of the
+// customer's data with the backup window they need to meet.
+type WindowResult struct {
+	DataSize   int64         // size of the customer's data
+	Throughput float64       // aggregate write speed of the nodes, in bytes per second
+	Duration   time.Duration // projected duration of a full backup
+	Window     time.Duration // backup window
+}
+
+// Fits returns whether the projected full backup completes within the window.
+func (w *WindowResult) Fits() bool {
+	return w.Duration <= w.Window
+}
+
+// projectWindow projects the duration of a full backup of dataSize bytes at
+// the aggregate write speed measured on the nodes. It returns nil if the
+// write speed is unknown.
+func projectWindow(dataSize int64, writeRate float64, window time.Duration) *WindowResult {
+	if writeRate <= 0 {
+		slog.Warn("write speed is not available: cannot project the full backup duration")
+		return nil
+	}
+	res := &WindowResult{
+		DataSize:   dataSize,
+		Throughput: writeRate,
+		Duration:   transferTime(dataSize, writeRate),
+		Window:     window,
+	}
+	slog.Info("projected full backup duration",
+		slog.Duration("duration", res.Duration.Round(time.Second)),
+		slog.Duration("window", window), slog.Bool("fits", res.Fits()))
+	return res
+}
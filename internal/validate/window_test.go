@@ -0,0 +1,34 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectWindow(t *testing.T) {
+	a := assert.New(t)
+	// 1TB at 100MB/s takes 10000 seconds.
+	res := projectWindow(1e12, 1e8, 3*time.Hour)
+	a.Equal(10000*time.Second, res.Duration)
+	a.True(res.Fits())
+	res = projectWindow(1e12, 1e8, 2*time.Hour)
+	a.False(res.Fits())
+	// Unknown write speed.
+	a.Nil(projectWindow(1e12, 0, time.Hour))
+}
@@ -27,11 +27,28 @@ import (
 	"github.com/cockroachlabs-field/blobcheck/internal/workload"
 )
 
-// runWorkloadWithBackup runs the workload concurrently with a full backup.
+// runWorkloadWithBackup populates the source table, either from the user
+// provided dataset or by running the workload, and then runs the workload
+// concurrently with a full backup.
 func (v *Validator) runWorkloadWithBackup(ctx *stopper.Context, extConn *db.ExternalConn) error {
-	slog.Info("running workload to populate some data")
-	if err := v.runWorkload(ctx, v.env.WorkloadDuration); err != nil {
-		return errors.Wrap(err, "failed to run initial workload")
+	if v.env.Dataset != "" {
+		if err := v.loadDataset(ctx); err != nil {
+			return err
+		}
+	} else {
+		slog.Info("running workload to populate some data")
+		before := v.currentSize(ctx)
+		start := time.Now()
+		var err error
+		if target := populationTargetFromEnv(v.env); target.set() {
+			v.population, err = v.populate(ctx, target)
+		} else {
+			err = v.runWorkload(ctx, v.env.WorkloadDuration)
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to run initial workload")
+		}
+		v.measureIngest(ctx, before, time.Since(start))
 	}
 	if ctx.IsStopping() {
 		return nil
@@ -67,38 +84,98 @@ func (v *Validator) runConcurrentWorkloadAndBackup(
 		}
 	}
 
-	// Start worker goroutines.
-	for w := range v.env.Workers {
-		run(func(ctx *stopper.Context) error {
-			slog.Info("starting", "worker", w)
-			return v.runWorkload(ctx, v.env.WorkloadDuration)
-		})
+	// Start worker goroutines. Workers that are paused during the
+	// incremental backup keep running after this step.
+	if v.env.PauseWorkload {
+		v.startPausedWorkers(ctx)
+	} else {
+		for w := range v.env.Workers {
+			run(func(ctx *stopper.Context) error {
+				slog.Info("starting", "worker", w)
+				return v.runWorkload(ctx, v.env.WorkloadDuration)
+			})
+		}
 	}
 
 	// Start the full backup.
+	backupDone := make(chan struct{})
+	var backupErr error
 	run(func(ctx *stopper.Context) error {
-		return v.runFullBackup(ctx, extConn)
+		defer close(backupDone)
+		backupErr = v.runFullBackup(ctx, extConn)
+		return backupErr
 	})
 
+	// Change the schema of the source table while the backup is running.
+	if v.env.SchemaChange != "" {
+		run(func(ctx *stopper.Context) error {
+			return v.runSchemaChange(ctx, backupDone)
+		})
+	}
+
+	// Run the user provided command while the backup is running.
+	if v.env.OnBackupStartCmd != "" {
+		run(func(ctx *stopper.Context) error {
+			return v.runBackupHook(ctx, backupDone)
+		})
+	}
+
 	g.Wait()
+	if v.hook != nil {
+		v.hook.BackupCompleted = backupErr == nil
+	}
 	slog.Info("workers done")
 	return errors.Join(errs...)
 }
 
+// loadDataset populates the source table from the user provided dataset.
+func (v *Validator) loadDataset(ctx *stopper.Context) error {
+	conn, err := v.acquireConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	slog.Info("loading dataset", slog.String("path", v.env.Dataset))
+	d := workload.Dataset{
+		Path:     v.env.Dataset,
+		MaxBytes: v.env.DatasetMaxBytes,
+		Table:    v.sourceTable,
+	}
+	stats, err := d.Load(ctx, conn)
+	if err != nil {
+		return err
+	}
+	slog.Info("dataset loaded", slog.Int("rows", stats.Rows), slog.Int64("bytes", stats.Bytes))
+	return nil
+}
+
 // runWorkload runs a simple kv-style workload for the specified duration.
 func (v *Validator) runWorkload(ctx *stopper.Context, duration time.Duration) error {
+	stop, exited := v.startWorkload(ctx)
+	select {
+	case <-time.Tick(duration):
+	case <-exited:
+	case <-ctx.Stopping():
+	}
+	return stop()
+}
+
+// startWorkload starts a simple kv-style workload. It returns a function
+// that stops the workload and returns its error, and a channel closed once
+// the workload exits on its own, such as after a failed write.
+func (v *Validator) startWorkload(ctx *stopper.Context) (stop func() error, exited <-chan struct{}) {
 	w := workload.Workload{
 		Prefix: uuid.New().String(),
 		Table:  v.sourceTable,
+		Oracle: v.oracle,
 	}
 	done := make(chan bool)
+	exit := make(chan struct{})
 
-	var g sync.WaitGroup
 	var runErr error
-	g.Add(1)
 	accepted := ctx.Go(func(ctx *stopper.Context) error {
-		defer g.Done()
-		conn, err := v.pool.Acquire(ctx)
+		defer close(exit)
+		conn, err := v.workloadPool().Acquire(ctx)
 		if err != nil {
 			runErr = err
 			return err
@@ -108,15 +185,12 @@ func (v *Validator) runWorkload(ctx *stopper.Context, duration time.Duration) er
 		return runErr
 	})
 	if !accepted {
-		g.Done()
+		close(exit)
 	}
-
-	select {
-	case <-time.Tick(duration):
+	return func() error {
 		// signal workload to stop
 		close(done)
-	case <-ctx.Stopping():
-	}
-	g.Wait()
-	return runErr
+		<-exit
+		return runErr
+	}, exit
 }
@@ -0,0 +1,84 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"sync"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+// Control pauses and resumes the workloads that share it. Once Pause
+// returns, the table is not modified until Resume is called, so that the
+// data captured by a backup is known exactly.
+type Control struct {
+	mu     sync.Mutex
+	paused chan struct{} // closed when resumed; nil while running
+	writes sync.WaitGroup
+}
+
+// Pause stops the workloads from starting new writes, and waits for the
+// writes in progress to complete.
+func (c *Control) Pause() {
+	c.mu.Lock()
+	if c.paused == nil {
+		c.paused = make(chan struct{})
+	}
+	c.mu.Unlock()
+	c.writes.Wait()
+}
+
+// Resume lets the paused workloads write again.
+func (c *Control) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused != nil {
+		close(c.paused)
+		c.paused = nil
+	}
+}
+
+// enter waits until the workloads are not paused, and registers a write. It
+// returns false if the workload is done or stopping while paused. A nil
+// Control never pauses.
+func (c *Control) enter(ctx *stopper.Context, done <-chan bool) bool {
+	if c == nil {
+		return true
+	}
+	for {
+		c.mu.Lock()
+		paused := c.paused
+		if paused == nil {
+			c.writes.Add(1)
+			c.mu.Unlock()
+			return true
+		}
+		c.mu.Unlock()
+		select {
+		case <-paused:
+		case <-done:
+			return false
+		case <-ctx.Stopping():
+			return false
+		}
+	}
+}
+
+// exit marks the end of a write registered by enter.
+func (c *Control) exit() {
+	if c != nil {
+		c.writes.Done()
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+)
+
+func TestControl(t *testing.T) {
+	a := assert.New(t)
+	ctx := stopper.WithContext(t.Context())
+	defer ctx.Stop(0)
+	var control Control
+	var writes atomic.Int64
+	done := make(chan bool)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for control.enter(ctx, done) {
+			writes.Add(1)
+			time.Sleep(time.Millisecond)
+			control.exit()
+		}
+	}()
+	a.Eventually(func() bool { return writes.Load() > 0 }, time.Second, time.Millisecond)
+
+	// No writes happen while paused.
+	control.Pause()
+	paused := writes.Load()
+	time.Sleep(20 * time.Millisecond)
+	a.Equal(paused, writes.Load())
+
+	control.Resume()
+	a.Eventually(func() bool { return writes.Load() > paused }, time.Second, time.Millisecond)
+
+	// A paused workload stops when done.
+	control.Pause()
+	close(done)
+	a.Eventually(func() bool {
+		select {
+		case <-stopped:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+
+	// A nil control never pauses.
+	var none *Control
+	a.True(none.enter(ctx, done))
+	none.exit()
+}
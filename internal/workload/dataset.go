@@ -0,0 +1,158 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"encoding/csv"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/field-eng-powertools/stopper"
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+const batchSize = 100
+
+// piiPatterns are simple heuristics used to warn the user that the sample
+// dataset may contain personally identifiable information.
+var piiPatterns = map[string]*regexp.Regexp{
+	"email":       regexp.MustCompile(`[[:alnum:]._%+-]+@[[:alnum:].-]+\.[[:alpha:]]{2,}`),
+	"card number": regexp.MustCompile(`\b(?:\d[ -]?){13,16}\b`),
+	"SSN":         regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+}
+
+// Dataset loads a user provided sample into a table, so that the
+// validation data is shaped like the customer's own data.
+//
+// The sample is read by blobcheck and upserted in batches, rather than
+// loaded with IMPORT INTO: the file lives on the blobcheck host, which the
+// cluster nodes cannot read from, and reading it locally lets blobcheck cap
+// its size and scan it for PII before any data leaves the host. Parquet
+// samples are not supported; they must be converted to CSV first.
+type Dataset struct {
+	// Path is the location of the sample file. Only CSV files are supported.
+	Path string
+	// MaxBytes caps the amount of data loaded from the sample.
+	MaxBytes int64
+	// Table is the database table to load the sample into.
+	Table db.KvTable
+}
+
+// DatasetStats summarizes the data loaded from a sample.
+type DatasetStats struct {
+	Rows  int
+	Bytes int64
+	PII   map[string]int // number of values matching each PII heuristic
+}
+
+// Load reads the sample and upserts its records into the table. Each record
+// must have either one field (the value, with a generated key) or two
+// fields (key and value). Loading stops once MaxBytes have been read.
+func (d *Dataset) Load(ctx *stopper.Context, conn *pgxpool.Conn) (*DatasetStats, error) {
+	switch ext := strings.ToLower(filepath.Ext(d.Path)); ext {
+	case ".csv":
+	case ".parquet":
+		return nil, errors.WithHint(
+			errors.New("parquet datasets are not supported"),
+			"convert the sample to CSV, with one or two fields: [key,]value")
+	default:
+		return nil, errors.Newf("unsupported dataset format %q: only CSV files are supported", ext)
+	}
+	f, err := os.Open(d.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open dataset")
+	}
+	defer f.Close()
+	return d.load(f, func(batch []db.KV) error {
+		return d.Table.UpsertBatch(ctx, conn, batch)
+	})
+}
+
+// load reads the records from r, passing them to upsert in batches.
+func (d *Dataset) load(r io.Reader, upsert func([]db.KV) error) (*DatasetStats, error) {
+	stats := &DatasetStats{PII: make(map[string]int)}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	batch := make([]db.KV, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := upsert(batch); err != nil {
+			return errors.Wrap(err, "failed to load dataset")
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		if d.MaxBytes > 0 && stats.Bytes >= d.MaxBytes {
+			slog.Warn("dataset truncated", slog.Int64("max_bytes", d.MaxBytes), slog.Int("rows", stats.Rows))
+			break
+		}
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read dataset")
+		}
+		kv, err := toKV(record)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid record at row %d", stats.Rows+1)
+		}
+		for name, re := range piiPatterns {
+			if re.MatchString(kv.Value) {
+				stats.PII[name]++
+			}
+		}
+		stats.Rows++
+		stats.Bytes += int64(len(kv.Key) + len(kv.Value))
+		batch = append(batch, kv)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	for name, count := range stats.PII {
+		slog.Warn("dataset may contain personally identifiable information; "+
+			"the data will be copied to the cluster and to the storage provider",
+			slog.String("kind", name), slog.Int("values", count))
+	}
+	return stats, nil
+}
+
+// toKV converts a CSV record into a key-value pair.
+func toKV(record []string) (db.KV, error) {
+	switch len(record) {
+	case 1:
+		return db.KV{Key: uuid.NewString(), Value: record[0]}, nil
+	case 2:
+		return db.KV{Key: record[0], Value: record[1]}, nil
+	default:
+		return db.KV{}, errors.Newf("expected 1 or 2 fields, got %d", len(record))
+	}
+}
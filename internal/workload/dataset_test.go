@@ -0,0 +1,146 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cockroachlabs-field/blobcheck/internal/db"
+)
+
+func TestToKV(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  []string
+		want    db.KV
+		wantErr string
+	}{
+		{name: "key and value", record: []string{"k", "v"}, want: db.KV{Key: "k", Value: "v"}},
+		{name: "value only", record: []string{"v"}, want: db.KV{Value: "v"}},
+		{name: "too many fields", record: []string{"a", "b", "c"}, wantErr: "expected 1 or 2 fields, got 3"},
+		{name: "no fields", record: []string{}, wantErr: "expected 1 or 2 fields, got 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			kv, err := toKV(tt.record)
+			if tt.wantErr != "" {
+				a.ErrorContains(err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want.Key == "" {
+				// A key is generated for single field records.
+				a.NotEmpty(kv.Key)
+				tt.want.Key = kv.Key
+			}
+			a.Equal(tt.want, kv)
+		})
+	}
+}
+
+func TestDatasetLoad(t *testing.T) {
+	var large strings.Builder
+	for i := range 250 {
+		fmt.Fprintf(&large, "key%03d,value\n", i)
+	}
+	tests := []struct {
+		name     string
+		input    string
+		maxBytes int64
+		wantRows int
+		wantPII  map[string]int
+		wantErr  string
+	}{
+		{
+			name:     "plain values",
+			input:    "a,1\nb,2\nc\n",
+			wantRows: 3,
+			wantPII:  map[string]int{},
+		},
+		{
+			name: "pii",
+			input: "a,jane.doe@example.com\n" +
+				"b,4111 1111 1111 1111\n" +
+				"c,4111-1111-1111-1111\n" +
+				"d,123-45-6789\n" +
+				"e,not@an-email\n" +
+				"f,12345\n",
+			wantRows: 6,
+			wantPII:  map[string]int{"email": 1, "card number": 2, "SSN": 1},
+		},
+		{
+			name:     "batches",
+			input:    large.String(),
+			wantRows: 250,
+			wantPII:  map[string]int{},
+		},
+		{
+			name:     "truncated",
+			input:    large.String(),
+			maxBytes: 100,
+			wantRows: 10, // 11 bytes per row, the last one crosses the cap
+			wantPII:  map[string]int{},
+		},
+		{
+			name:    "malformed row",
+			input:   "a,1\nb,2,3\n",
+			wantErr: "invalid record at row 2: expected 1 or 2 fields, got 3",
+		},
+		{
+			name:    "malformed csv",
+			input:   "a,\"unterminated\n",
+			wantErr: "failed to read dataset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			d := &Dataset{MaxBytes: tt.maxBytes}
+			var loaded []db.KV
+			stats, err := d.load(strings.NewReader(tt.input), func(batch []db.KV) error {
+				a.LessOrEqual(len(batch), batchSize)
+				loaded = append(loaded, batch...)
+				return nil
+			})
+			if tt.wantErr != "" {
+				a.ErrorContains(err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			a.Equal(tt.wantRows, stats.Rows)
+			a.Len(loaded, tt.wantRows)
+			a.Equal(tt.wantPII, stats.PII)
+			var bytes int64
+			for _, kv := range loaded {
+				bytes += int64(len(kv.Key) + len(kv.Value))
+			}
+			a.Equal(bytes, stats.Bytes)
+		})
+	}
+}
+
+func TestDatasetFormat(t *testing.T) {
+	a := assert.New(t)
+	_, err := (&Dataset{Path: "sample.parquet"}).Load(nil, nil)
+	a.ErrorContains(err, "parquet datasets are not supported")
+	_, err = (&Dataset{Path: "sample.json"}).Load(nil, nil)
+	a.ErrorContains(err, `unsupported dataset format ".json"`)
+}
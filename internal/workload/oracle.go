@@ -0,0 +1,79 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"hash/fnv"
+	"maps"
+	"math"
+	"sync"
+)
+
+// Oracle records the rows written by the workloads, or a sample of them,
+// so that a restored table can be verified row by row.
+type Oracle struct {
+	sample float64 // fraction of the keys recorded
+
+	mu     sync.Mutex
+	rows   map[string]string
+	frozen bool
+}
+
+// NewOracle creates an oracle recording the given fraction of the keys.
+// The sample is deterministic: a key is either always or never recorded.
+func NewOracle(sample float64) *Oracle {
+	return &Oracle{sample: sample, rows: make(map[string]string)}
+}
+
+// Freeze stops recording the writes, so that the oracle describes the
+// table at this point.
+func (o *Oracle) Freeze() {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.frozen = true
+}
+
+// Rows returns a copy of the recorded rows.
+func (o *Oracle) Rows() map[string]string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return maps.Clone(o.rows)
+}
+
+// record records a write, if the key is sampled. A nil Oracle records
+// nothing.
+func (o *Oracle) record(key, value string) {
+	if o == nil || !o.sampled(key) {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.frozen {
+		o.rows[key] = value
+	}
+}
+
+// sampled reports whether the key belongs to the sample.
+func (o *Oracle) sampled(key string) bool {
+	if o.sample >= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return float64(h.Sum32()) < o.sample*math.MaxUint32
+}
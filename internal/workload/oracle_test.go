@@ -0,0 +1,49 @@
+// Copyright 2025 Cockroach Labs, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workload
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOracle(t *testing.T) {
+	a := assert.New(t)
+	o := NewOracle(1)
+	o.record("a", "1")
+	o.record("b", "2")
+	o.record("a", "3")
+	o.Freeze()
+	o.record("c", "4")
+	a.Equal(map[string]string{"a": "3", "b": "2"}, o.Rows())
+
+	// The sample is deterministic.
+	sampled := NewOracle(0.25)
+	again := NewOracle(0.25)
+	for i := range 1000 {
+		key := fmt.Sprintf("prefix-%d", i)
+		sampled.record(key, "v")
+		again.record(key, "v")
+	}
+	a.Equal(sampled.Rows(), again.Rows())
+	a.InDelta(250, len(sampled.Rows()), 75)
+
+	// A nil oracle records nothing.
+	var none *Oracle
+	none.record("a", "1")
+	none.Freeze()
+}
@@ -35,13 +35,25 @@ type Workload struct {
 	// Table is the database table to operate on.
 	Table  db.KvTable
 	Prefix string
+	// Control pauses the workload, if set.
+	Control *Control
+	// Oracle records the rows written by the workload, if set.
+	Oracle *Oracle
 }
 
 // Run executes a simple workload that inserts rows into the database.
 func (w *Workload) Run(ctx *stopper.Context, conn *pgxpool.Conn, done <-chan bool) error {
 	var idx int
 	for {
-		err := w.Table.Upsert(ctx, conn, fmt.Sprintf("%s-%d", w.Prefix, idx), uuid.NewString())
+		if !w.Control.enter(ctx, done) {
+			return nil
+		}
+		key, value := fmt.Sprintf("%s-%d", w.Prefix, idx), uuid.NewString()
+		err := w.Table.Upsert(ctx, conn, key, value)
+		if err == nil {
+			w.Oracle.record(key, value)
+		}
+		w.Control.exit()
 		if err != nil {
 			slog.Error("failed to upsert row", "idx", idx, "err", err)
 			return err